@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
@@ -21,11 +22,17 @@ import (
 )
 
 func main() {
-	// Handle health check flag for Docker containers
+	// Handle health check flag for Docker containers and Kubernetes probes
 	if len(os.Args) > 1 && os.Args[1] == "--health-check" {
-		// Simple health check - just exit successfully
-		// In a real implementation, this would check service health
-		os.Exit(0)
+		os.Exit(runHealthCheck())
+	}
+
+	storageType := flag.String("storage", "", "storage backend: mysql, sqlite or memory (overrides DATABASE_TYPE)")
+	flag.Parse()
+
+	// Handle schema migration subcommand before any bot startup
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(*storageType, flag.Args()[1:], os.Stdout, os.Stderr))
 	}
 
 	// Initialize structured logging
@@ -34,6 +41,16 @@ func main() {
 
 	slog.Info("BMAD Knowledge Bot starting up...")
 
+	// Start the health server first so liveness probes succeed while the bot starts up;
+	// /readyz fails until startup completes and every dependency check passes
+	healthServer := monitor.NewHealthServer(os.Getenv("HEALTH_SERVER_ADDR"), logger)
+	metrics := monitor.NewMetrics()
+	healthServer.AddMetricsCollector(metrics.WritePrometheus)
+	if err := healthServer.Start(); err != nil {
+		slog.Error("Failed to start health server", "error", err)
+		os.Exit(1)
+	}
+
 	// Read and validate bot token from environment variable
 	token := os.Getenv("BOT_TOKEN")
 	if err := validateToken(token); err != nil {
@@ -47,16 +64,19 @@ func main() {
 		aiProvider = "ollama" // Default to Ollama
 	}
 
+	// Primary provider first, followed by optional failover providers in priority order
+	aiProviders := service.ParseProviderList(aiProvider + "," + os.Getenv("AI_FAILOVER_PROVIDERS"))
+
 	// Validate AI provider selection
-	if aiProvider != "ollama" {
-		slog.Error("Invalid AI provider", "provider", aiProvider, "supported", []string{"ollama"})
-		os.Exit(1)
+	for _, provider := range aiProviders {
+		if !isSupportedAIProvider(provider) {
+			slog.Error("Invalid AI provider", "provider", provider, "supported", supportedAIProviders)
+			os.Exit(1)
+		}
 	}
 
-	// No provider-specific requirements for Ollama
-
 	// Read and validate rate limiting configuration
-	rateLimitConfig, err := loadRateLimitConfig(aiProvider)
+	rateLimitConfigs, err := loadRateLimitConfigs(aiProviders, loadRateLimitConfig)
 	if err != nil {
 		slog.Error("Failed to load rate limit configuration", "error", err)
 		os.Exit(1)
@@ -76,41 +96,22 @@ func main() {
 	// Placeholder for configuration service - will be initialized after storage service
 	var kbConfig *service.Config
 
-	// Read and validate reply mention configuration
-	replyMentionConfig, err := loadReplyMentionConfig()
-	if err != nil {
-		slog.Error("Failed to load reply mention configuration", "error", err)
-		os.Exit(1)
-	}
-
-	// Read and validate reaction trigger configuration
-	reactionTriggerConfig, err := loadReactionTriggerConfig()
-	if err != nil {
-		slog.Error("Failed to load reaction trigger configuration", "error", err)
-		os.Exit(1)
-	}
-
-	// Read and validate Forum monitoring configuration
-	forumConfig, err := loadForumConfig()
+	// Initialize the storage service selected by --storage or DATABASE_TYPE
+	databaseType, err := loadDatabaseType(*storageType)
 	if err != nil {
-		slog.Error("Failed to load Forum monitoring configuration", "error", err)
+		slog.Error("Failed to load database configuration", "error", err)
 		os.Exit(1)
 	}
-
-	// Initialize MySQL storage service (only supported database type)
-	mysqlConfig, err := loadMySQLConfig()
+	backend, err := newStorageService(databaseType)
 	if err != nil {
-		slog.Error("Failed to load MySQL configuration", "error", err)
+		slog.Error("Failed to load storage configuration", "error", err, "type", databaseType)
 		os.Exit(1)
 	}
-	storageService := storage.NewMySQLStorageService(mysqlConfig)
-	slog.Info("Using MySQL storage service",
-		"host", mysqlConfig.Host,
-		"port", mysqlConfig.Port,
-		"database", mysqlConfig.Database)
+	// Count failed storage operations for /metrics
+	storageService := storage.NewInstrumentedStorageService(backend, metrics.ObserveStorageOperation)
 
 	if err := storageService.Initialize(context.Background()); err != nil {
-		slog.Error("Failed to initialize storage service", "error", err, "type", "mysql")
+		slog.Error("Failed to initialize storage service", "error", err, "type", databaseType)
 		os.Exit(1)
 	}
 	defer func() {
@@ -119,7 +120,8 @@ func main() {
 		}
 	}()
 
-	slog.Info("Storage service initialized successfully", "type", "mysql")
+	slog.Info("Storage service initialized successfully", "type", databaseType)
+	healthServer.AddReadinessCheck("storage", storageService.HealthCheck)
 
 	// Run data migration from file-based storage to database
 	migrationService := storage.NewMigrationService(storageService, logger)
@@ -140,6 +142,7 @@ func main() {
 			slog.Error("Error closing configuration service", "error", err)
 		}
 	}()
+	healthServer.AddReadinessCheck("config", configService.HealthCheck)
 
 	// Initialize configuration loader and migrator
 	configLoader := config.NewConfigurationLoader(configService)
@@ -171,7 +174,9 @@ func main() {
 	}
 
 	// Update rate limiting configuration to use ConfigService
-	rateLimitConfig, err = loadRateLimitConfigFromService(aiProvider, configService)
+	rateLimitConfigs, err = loadRateLimitConfigs(aiProviders, func(provider string) (monitor.ProviderConfig, error) {
+		return loadRateLimitConfigFromService(provider, configService)
+	})
 	if err != nil {
 		slog.Error("Failed to load rate limit configuration from service", "error", err)
 		os.Exit(1)
@@ -197,21 +202,53 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Read reply mention, reaction trigger and Forum settings through ConfigService so stored changes survive a restart
+	lookup := configLookup(configService)
+	replyMentionConfig, err := parseReplyMentionConfig(lookup)
+	if err != nil {
+		slog.Error("Failed to load reply mention configuration", "error", err)
+		os.Exit(1)
+	}
+	reactionTriggerConfig, err := parseReactionTriggerConfig(lookup)
+	if err != nil {
+		slog.Error("Failed to load reaction trigger configuration", "error", err)
+		os.Exit(1)
+	}
+	forumConfig, err := parseForumConfig(lookup)
+	if err != nil {
+		slog.Error("Failed to load Forum monitoring configuration", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize rate limit manager with provider configurations
-	rateLimitManager := monitor.NewRateLimitManager(logger, []monitor.ProviderConfig{rateLimitConfig})
-	slog.Info("Rate limit manager initialized",
-		"provider", rateLimitConfig.ProviderID,
-		"limits", rateLimitConfig.Limits)
+	rateLimitManager := monitor.NewRateLimitManager(logger, rateLimitConfigs)
+	for _, rateLimitConfig := range rateLimitConfigs {
+		slog.Info("Rate limit manager initialized",
+			"provider", rateLimitConfig.ProviderID,
+			"limits", rateLimitConfig.Limits)
+	}
 
-	// Initialize AI service (Ollama only)
-	aiService, err := service.NewOllamaAIService(logger)
+	// Initialize AI service for the selected providers
+	aiService, err := newAIServiceChain(aiProviders, logger)
 	if err != nil {
-		slog.Error("Failed to initialize Ollama AI service", "error", err)
+		slog.Error("Failed to initialize AI service", "providers", aiProviders, "error", err)
 		os.Exit(1)
 	}
 	aiService.SetRateLimiter(rateLimitManager)
-	slog.Info("Ollama AI service initialized successfully",
+	aiService.SetMetrics(metrics)
+	metrics.RegisterProviderStatus(rateLimitManager)
+	service.RegisterQualityMetrics(metrics, aiService)
+
+	// The failover chain tracks per-provider rate limit status to compute its aggregate status
+	failoverService, isFailover := aiService.(*service.FailoverAIService)
+	if isFailover {
+		rateLimitManager.RegisterStatusCallback(failoverService.HandleProviderStatusChange)
+	}
+	slog.Info("AI service initialized successfully",
 		"provider", aiService.GetProviderID())
+	if checker, ok := aiService.(service.HealthChecker); ok {
+		healthServer.AddReadinessCheck("ai_provider", checker.HealthCheck)
+	}
 
 	slog.Info("Rate limiter configured for AI service", "provider", aiService.GetProviderID())
 
@@ -219,10 +256,23 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Cache answers to repeated standalone questions; cached answers are dropped when the knowledge base changes
+	responseCache := service.NewResponseCache(logger)
+	responseCache.SetMetrics(metrics)
+	applyResponseCacheConfig(responseCache, configService)
+
 	// Initialize knowledge base updater if enabled
 	var knowledgeUpdater service.KnowledgeUpdater
 	if kbConfig.Enabled {
-		knowledgeUpdater = service.NewHTTPKnowledgeUpdater(*kbConfig, logger)
+		httpUpdater := service.NewHTTPKnowledgeUpdater(*kbConfig, logger)
+		// Reload and re-index the AI service's knowledge base whenever the remote content changes
+		httpUpdater.SetOnContentChanged(func(content string) {
+			if err := aiService.RefreshKnowledgeBase(); err != nil {
+				slog.Warn("Failed to reload knowledge base after update", "error", err)
+			}
+			responseCache.SetKnowledgeBaseHash(service.KnowledgeBaseHash(content))
+		})
+		knowledgeUpdater = httpUpdater
 		if err := knowledgeUpdater.Start(ctx); err != nil {
 			slog.Error("Failed to start knowledge base updater", "error", err)
 			os.Exit(1)
@@ -239,14 +289,71 @@ func main() {
 		bot.ReplyMentionConfig{
 			DeleteReplyMessage: replyMentionConfig.DeleteReplyMessage,
 		},
-		bot.ReactionTriggerConfig{
-			Enabled:               reactionTriggerConfig.Enabled,
-			TriggerEmoji:          reactionTriggerConfig.TriggerEmoji,
-			ApprovedUserIDs:       reactionTriggerConfig.ApprovedUserIDs,
-			ApprovedRoleNames:     reactionTriggerConfig.ApprovedRoleNames,
-			RequireReaction:       reactionTriggerConfig.RequireReaction,
-			RemoveTriggerReaction: reactionTriggerConfig.RemoveTriggerReaction,
-		})
+		botReactionTriggerConfig(reactionTriggerConfig))
+
+	// Resolve per-guild overrides through the config service's cache rather than storage on every message
+	handler.SetConfigService(configService)
+
+	// Initialize per-user rate limiting shared by all AI entry points
+	userRateLimiter := monitor.NewUserRateLimiter(storageService, logger)
+	userRateLimiter.SetConfigService(configService)
+	if allConfigs, err := configService.GetAllConfigs(context.Background()); err != nil {
+		slog.Warn("Failed to load user rate limit configuration, using defaults", "error", err)
+	} else if err := userRateLimiter.ApplyConfiguration(allConfigs); err != nil {
+		slog.Warn("Invalid user rate limit configuration, using defaults", "error", err)
+	}
+	handler.SetUserRateLimiter(userRateLimiter)
+	handler.SetMetrics(metrics)
+
+	// Pick up USER_RATE_LIMIT_* and RATE_LIMITING_ENABLED changes without a restart
+	configLoader.RegisterServiceListener(config.ServiceConfigListener{
+		Name:     "user_rate_limiter",
+		OnReload: userRateLimiter.ApplyConfiguration,
+	})
+	slog.Info("User rate limiting configured", "enabled", userRateLimiter.IsEnabled())
+
+	// Route `!` and slash admin commands through the handler
+	adminCommands := bot.NewAdminCommands(storageService, userRateLimiter, handler.GetChannelRestrictor(), logger)
+	adminCommands.SetConfigService(configService)
+	adminCommands.SetResponseCache(responseCache)
+	handler.SetAdminCommands(adminCommands)
+	handler.SetResponseCache(responseCache)
+
+	// Bound concurrent AI generations, answering admins, DMs and thread follow-ups first when busy
+	aiQueue := service.NewAIQueue(logger)
+	applyAIQueueConfig(aiQueue, configService)
+	service.RegisterAIQueueMetrics(metrics, aiQueue)
+	handler.SetAIQueue(aiQueue)
+
+	// In-flight AI generations are cancelled on shutdown
+	handler.SetBaseContext(ctx)
+
+	// Stream contextual AI responses with progressive message edits
+	handler.SetStreamingEnabled(configService.GetConfigBoolWithDefault(context.Background(), "AI_STREAMING_ENABLED", true))
+
+	// Remember DM conversations per user, summarizing older turns once they grow long
+	dmMemory := bot.NewDMMemory(storageService, aiService, logger)
+	dmMemory.SetSummarizeAfter(configService.GetConfigIntWithDefault(context.Background(), "DM_MEMORY_SUMMARIZE_AFTER", bot.DefaultDMMemorySummarizeAfter))
+	handler.SetDMMemory(dmMemory)
+
+	// Fit thread history to the model context, summarizing older messages per thread once it overflows
+	threadHistory := bot.NewThreadHistory(storageService, aiService, logger)
+	threadHistory.SetContextTokens(configService.GetConfigIntWithDefault(context.Background(), "THREAD_HISTORY_CONTEXT_TOKENS", bot.DefaultThreadContextTokens))
+	handler.SetThreadHistory(threadHistory)
+
+	// Link answers to the knowledge base sections they are based on, on the rendered upstream document
+	handler.SetKnowledgeSourcesEnabled(configService.GetConfigBoolWithDefault(context.Background(), "KB_SOURCES_ENABLED", true))
+	handler.SetKnowledgeDocumentURL(service.KnowledgeDocumentURL(configService.GetConfigWithDefault(context.Background(), "BMAD_KB_REMOTE_URL",
+		"https://github.com/bmadcode/BMAD-METHOD/raw/refs/heads/main/bmad-core/data/bmad-kb.md")))
+
+	// Log each Q&A exchange and purge records older than the configured retention period
+	handler.SetInteractionLogEnabled(configService.GetConfigBoolWithDefault(context.Background(), "INTERACTION_LOG_ENABLED", true))
+	handler.SetFeedbackReactionsEnabled(configService.GetConfigBoolWithDefault(context.Background(), "FEEDBACK_REACTIONS_ENABLED", true))
+	interactionPurger := storage.NewInteractionPurger(storageService, func() time.Duration {
+		days := configService.GetConfigIntWithDefault(context.Background(), "INTERACTION_RETENTION_DAYS", 90)
+		return time.Duration(days) * 24 * time.Hour
+	}, logger)
+	interactionPurger.Start(ctx)
 
 	// Configure Forum channel monitoring
 	if len(forumConfig.MonitoredChannels) > 0 {
@@ -256,6 +363,72 @@ func main() {
 		slog.Info("No Forum channels configured for monitoring")
 	}
 
+	// Apply provider rate limits, reaction triggers, reply mentions, Forum channels, DM memory, response cache,
+	// AI queue and Ollama settings without a restart; only keys whose values changed are re-applied
+	initialConfigs, err := configService.GetAllConfigs(context.Background())
+	if err != nil {
+		slog.Warn("Failed to snapshot configuration for live updates", "error", err)
+	}
+	// Providers read their settings from the environment when created; apply stored overrides once up front
+	if err := applyProviderConfiguration(aiService, initialConfigs); err != nil {
+		slog.Warn("Failed to apply stored AI provider configuration", "error", err)
+	}
+	configLoader.RegisterServiceListener(config.WatchKeys("provider_rate_limits", initialConfigs, providerRateLimitKeys(aiProviders),
+		func(map[string]string) error {
+			return applyRateLimitConfigs(rateLimitManager, aiProviders, configService)
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("reaction_trigger", initialConfigs, reactionTriggerConfigKeys,
+		func(map[string]string) error {
+			reactionConfig, err := parseReactionTriggerConfig(lookup)
+			if err != nil {
+				return err
+			}
+			handler.SetReactionTriggerConfig(botReactionTriggerConfig(reactionConfig))
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("reply_mention", initialConfigs, []string{"REPLY_MENTION_DELETE_MESSAGE"},
+		func(map[string]string) error {
+			replyConfig, err := parseReplyMentionConfig(lookup)
+			if err != nil {
+				return err
+			}
+			handler.SetReplyMentionConfig(bot.ReplyMentionConfig{DeleteReplyMessage: replyConfig.DeleteReplyMessage})
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("forum_monitoring", initialConfigs, []string{"MONITORED_FORUM_CHANNELS"},
+		func(map[string]string) error {
+			forumConfig, err := parseForumConfig(lookup)
+			if err != nil {
+				return err
+			}
+			handler.SetMonitoredForumChannels(forumConfig.MonitoredChannels)
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("dm_memory", initialConfigs, []string{"DM_MEMORY_SUMMARIZE_AFTER"},
+		func(map[string]string) error {
+			dmMemory.SetSummarizeAfter(configService.GetConfigIntWithDefault(context.Background(), "DM_MEMORY_SUMMARIZE_AFTER", bot.DefaultDMMemorySummarizeAfter))
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("thread_history", initialConfigs, []string{"THREAD_HISTORY_CONTEXT_TOKENS"},
+		func(map[string]string) error {
+			threadHistory.SetContextTokens(configService.GetConfigIntWithDefault(context.Background(), "THREAD_HISTORY_CONTEXT_TOKENS", bot.DefaultThreadContextTokens))
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("response_cache", initialConfigs, responseCacheConfigKeys,
+		func(map[string]string) error {
+			applyResponseCacheConfig(responseCache, configService)
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("ai_queue", initialConfigs, []string{"AI_QUEUE_CONCURRENCY", "AI_QUEUE_MAX_DEPTH", "AI_QUEUE_JOB_TIMEOUT"},
+		func(map[string]string) error {
+			applyAIQueueConfig(aiQueue, configService)
+			return nil
+		}))
+	configLoader.RegisterServiceListener(config.WatchKeys("ai_providers", initialConfigs, []string{"OLLAMA_MODEL", "OLLAMA_TIMEOUT"},
+		func(changed map[string]string) error {
+			return applyProviderConfiguration(aiService, changed)
+		}))
+
 	// Create Discord session
 	dg, err := discordgo.New("Bot " + token)
 	if err != nil {
@@ -267,9 +440,12 @@ func main() {
 	dg.AddHandler(ready)
 	dg.AddHandler(handler.HandleMessageCreate)
 	dg.AddHandler(handler.HandleMessageReactionAdd)
+	dg.AddHandler(handler.HandleMessageReactionRemove)
+	dg.AddHandler(handler.HandleMessageDelete)
+	dg.AddHandler(handler.HandleInteractionCreate)
 
 	// Set bot intents to include message content, mention parsing, thread access, and reactions
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsDirectMessages | discordgo.IntentsGuildMessageReactions
+	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsDirectMessages | discordgo.IntentsGuildMessageReactions | discordgo.IntentsDirectMessageReactions
 
 	// Open connection to Discord
 	err = dg.Open()
@@ -278,6 +454,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	healthServer.AddReadinessCheck("discord_gateway", func(ctx context.Context) error {
+		return bot.CheckGatewayConnection(dg, bot.DefaultGatewayHeartbeatTimeout)
+	})
+
+	// Register admin slash commands (DISCORD_COMMAND_GUILD_ID scopes them to one guild for faster propagation)
+	commandGuildID := configService.GetConfigWithDefault(context.Background(), "DISCORD_COMMAND_GUILD_ID", "")
+	if err := handler.RegisterSlashCommands(dg, commandGuildID); err != nil {
+		slog.Warn("Failed to register slash commands", "error", err)
+	}
+
 	// Read BMAD status rotation configuration using ConfigService first
 	bmadStatusEnabled := configService.GetConfigBoolWithDefault(context.Background(), "BMAD_STATUS_ROTATION_ENABLED", true)
 	bmadStatusIntervalStr := configService.GetConfigWithDefault(context.Background(), "BMAD_STATUS_ROTATION_INTERVAL", "5m")
@@ -306,6 +492,15 @@ func main() {
 		statusRotator = bot.NewStatusRotator(dg, logger)
 		statusRotator.SetInterval(bmadStatusInterval)
 		statusRotator.Start(ctx)
+		configLoader.RegisterServiceListener(config.WatchKeys("status_rotator", initialConfigs, []string{"BMAD_STATUS_ROTATION_INTERVAL"},
+			func(changed map[string]string) error {
+				interval, err := parseStatusInterval(changed["BMAD_STATUS_ROTATION_INTERVAL"], "5m", 30*time.Second)
+				if err != nil {
+					return err
+				}
+				statusRotator.SetInterval(interval)
+				return nil
+			}))
 		slog.Info("BMAD status rotation started",
 			"enabled", bmadStatusEnabled,
 			"interval", bmadStatusInterval,
@@ -323,6 +518,15 @@ func main() {
 		// Create status manager
 		statusManager := bot.NewDiscordStatusManager(botSession, logger)
 		statusManager.SetDebounceInterval(statusInterval)
+		configLoader.RegisterServiceListener(config.WatchKeys("status_debounce", initialConfigs, []string{"BOT_STATUS_UPDATE_INTERVAL"},
+			func(changed map[string]string) error {
+				interval, err := parseStatusInterval(changed["BOT_STATUS_UPDATE_INTERVAL"], "30s", time.Second)
+				if err != nil {
+					return err
+				}
+				statusManager.SetDebounceInterval(interval)
+				return nil
+			}))
 
 		// Register status callback with rate limiter
 		statusCallback := func(providerID, status string) {
@@ -334,7 +538,12 @@ func main() {
 					"error", err)
 			}
 		}
-		rateLimitManager.RegisterStatusCallback(statusCallback)
+		if isFailover {
+			// Presence reflects the availability of the whole chain rather than a single provider
+			failoverService.RegisterStatusCallback(statusCallback)
+		} else {
+			rateLimitManager.RegisterStatusCallback(statusCallback)
+		}
 
 		// Set initial status only if BMAD rotation is disabled
 		if !bmadStatusEnabled {
@@ -367,6 +576,9 @@ func main() {
 		slog.Info("Thread ownership recovery completed successfully")
 	}
 
+	// Startup is complete; readiness now depends only on the registered dependency checks
+	healthServer.SetReady(true)
+
 	// Log thread-related capabilities
 	slog.Info("Bot is now running with thread creation capabilities. Press CTRL+C to exit.")
 	slog.Info("Thread permissions note: Ensure bot has 'Create Public Threads' permission in target channels")
@@ -382,6 +594,9 @@ func main() {
 		slog.Info("Context cancelled, shutting down...")
 	}
 
+	// Stop in-flight AI generations before the Discord session they would reply through closes
+	cancel()
+
 	// Graceful shutdown with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
@@ -410,6 +625,10 @@ func main() {
 		} else {
 			slog.Info("Discord session closed successfully")
 		}
+
+		if err := healthServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Error stopping health server", "error", err)
+		}
 	}()
 
 	select {
@@ -420,6 +639,20 @@ func main() {
 	}
 }
 
+// runHealthCheck queries the running instance's /readyz endpoint and returns the process exit code
+func runHealthCheck() int {
+	addr := os.Getenv("HEALTH_SERVER_ADDR")
+	if addr == "" {
+		addr = monitor.DefaultHealthServerAddr
+	}
+
+	if err := monitor.ProbeReadiness(addr, 5*time.Second); err != nil {
+		fmt.Fprintf(os.Stderr, "health check failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
 // validateToken validates the Discord bot token format and content
 func validateToken(token string) error {
 	if token == "" {
@@ -440,6 +673,164 @@ func validateToken(token string) error {
 	return nil
 }
 
+// supportedAIProviders lists the values accepted by AI_PROVIDER
+var supportedAIProviders = []string{"ollama", "openai"}
+
+// isSupportedAIProvider reports whether the provider can be selected with AI_PROVIDER
+func isSupportedAIProvider(aiProvider string) bool {
+	for _, supported := range supportedAIProviders {
+		if aiProvider == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// newAIService creates the AI service for the selected provider
+func newAIService(aiProvider string, logger *slog.Logger) (service.ProviderAIService, error) {
+	switch aiProvider {
+	case "ollama":
+		return service.NewOllamaAIService(logger)
+	case "openai":
+		return service.NewOpenAIAIService(logger)
+	default:
+		return nil, fmt.Errorf("unsupported AI provider: %s", aiProvider)
+	}
+}
+
+// newAIServiceChain creates the AI service for the ordered provider list, wrapping multiple providers
+// in a failover chain
+func newAIServiceChain(aiProviders []string, logger *slog.Logger) (service.ProviderAIService, error) {
+	providers := make([]service.ProviderAIService, 0, len(aiProviders))
+	for _, aiProvider := range aiProviders {
+		provider, err := newAIService(aiProvider, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s provider: %w", aiProvider, err)
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return service.NewFailoverAIService(providers, logger)
+}
+
+// loadRateLimitConfigs loads rate limiting configuration for each provider using the given loader
+func loadRateLimitConfigs(aiProviders []string, load func(aiProvider string) (monitor.ProviderConfig, error)) ([]monitor.ProviderConfig, error) {
+	configs := make([]monitor.ProviderConfig, 0, len(aiProviders))
+	for _, aiProvider := range aiProviders {
+		providerConfig, err := load(aiProvider)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, providerConfig)
+	}
+	return configs, nil
+}
+
+// providerConfigKey returns the provider-specific configuration key, e.g. AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE
+func providerConfigKey(aiProvider, setting string) string {
+	return "AI_PROVIDER_" + strings.ToUpper(aiProvider) + "_" + setting
+}
+
+// providerRateLimitKeys returns the rate limit configuration keys for each provider
+func providerRateLimitKeys(aiProviders []string) []string {
+	keys := make([]string, 0, len(aiProviders)*4)
+	for _, aiProvider := range aiProviders {
+		for _, setting := range []string{"RATE_LIMIT_PER_MINUTE", "RATE_LIMIT_PER_DAY", "WARNING_THRESHOLD", "THROTTLED_THRESHOLD"} {
+			keys = append(keys, providerConfigKey(aiProvider, setting))
+		}
+	}
+	return keys
+}
+
+// applyRateLimitConfigs reloads provider rate limits from ConfigService into a running manager
+func applyRateLimitConfigs(manager *monitor.RateLimitManager, aiProviders []string, configService config.ConfigService) error {
+	configs, err := loadRateLimitConfigs(aiProviders, func(provider string) (monitor.ProviderConfig, error) {
+		return loadRateLimitConfigFromService(provider, configService)
+	})
+	if err != nil {
+		return err
+	}
+	for _, providerConfig := range configs {
+		if err := manager.UpdateProviderConfig(providerConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configurableProvider is implemented by AI providers that can apply configuration changes while running
+type configurableProvider interface {
+	ApplyConfiguration(configs map[string]string) error
+}
+
+// applyProviderConfiguration passes changed configuration to every provider in the AI service chain
+func applyProviderConfiguration(aiService service.ProviderAIService, configs map[string]string) error {
+	providers := []service.ProviderAIService{aiService}
+	if failoverService, ok := aiService.(*service.FailoverAIService); ok {
+		providers = failoverService.Providers()
+	}
+	for _, provider := range providers {
+		configurable, ok := provider.(configurableProvider)
+		if !ok {
+			continue
+		}
+		if err := configurable.ApplyConfiguration(configs); err != nil {
+			return fmt.Errorf("failed to apply configuration to %s provider: %w", provider.GetProviderID(), err)
+		}
+	}
+	return nil
+}
+
+// responseCacheConfigKeys lists the keys read by applyResponseCacheConfig
+var responseCacheConfigKeys = []string{
+	"RESPONSE_CACHE_ENABLED",
+	"RESPONSE_CACHE_TTL",
+	"RESPONSE_CACHE_MAX_ENTRIES",
+	"RESPONSE_CACHE_SIMILARITY_PERCENT",
+}
+
+// applyResponseCacheConfig applies the RESPONSE_CACHE_* settings to the response cache
+func applyResponseCacheConfig(cache *service.ResponseCache, configService config.ConfigService) {
+	ctx := context.Background()
+	cache.SetTTL(configService.GetConfigDurationWithDefault(ctx, "RESPONSE_CACHE_TTL", service.DefaultResponseCacheTTL))
+	cache.SetMaxEntries(configService.GetConfigIntWithDefault(ctx, "RESPONSE_CACHE_MAX_ENTRIES", service.DefaultResponseCacheMaxEntries))
+	cache.SetSimilarityThreshold(configService.GetConfigIntWithDefault(ctx, "RESPONSE_CACHE_SIMILARITY_PERCENT", 0))
+	cache.SetEnabled(configService.GetConfigBoolWithDefault(ctx, "RESPONSE_CACHE_ENABLED", true))
+}
+
+// applyAIQueueConfig applies the AI_QUEUE_* settings to the AI work queue
+func applyAIQueueConfig(queue *service.AIQueue, configService config.ConfigService) {
+	ctx := context.Background()
+	queue.SetConcurrency(configService.GetConfigIntWithDefault(ctx, "AI_QUEUE_CONCURRENCY", service.DefaultAIQueueConcurrency))
+	queue.SetMaxDepth(configService.GetConfigIntWithDefault(ctx, "AI_QUEUE_MAX_DEPTH", service.DefaultAIQueueMaxDepth))
+	queue.SetJobTimeout(configService.GetConfigDurationWithDefault(ctx, "AI_QUEUE_JOB_TIMEOUT", service.DefaultAIQueueJobTimeout))
+}
+
+// parseStatusInterval parses a status interval, using fallback when value is empty
+func parseStatusInterval(value, fallback string, minimum time.Duration) (time.Duration, error) {
+	if value == "" {
+		value = fallback
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid status interval %q: %w", value, err)
+	}
+	if interval < minimum {
+		return 0, fmt.Errorf("status interval %s is shorter than the %s minimum", interval, minimum)
+	}
+	return interval, nil
+}
+
+// configLookup returns a lookup that reads keys through ConfigService, for reuse by the parse* helpers
+func configLookup(configService config.ConfigService) func(key string) string {
+	return func(key string) string {
+		return configService.GetConfigWithDefault(context.Background(), key, "")
+	}
+}
+
 // loadRateLimitConfig loads rate limiting configuration from environment variables
 func loadRateLimitConfig(aiProvider string) (monitor.ProviderConfig, error) {
 	config := monitor.ProviderConfig{
@@ -448,8 +839,8 @@ func loadRateLimitConfig(aiProvider string) (monitor.ProviderConfig, error) {
 		Thresholds: make(map[string]float64),
 	}
 
-	// Load rate limit per minute for Ollama
-	perMinuteStr := os.Getenv("AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE")
+	// Load rate limit per minute for the provider
+	perMinuteStr := os.Getenv(providerConfigKey(aiProvider, "RATE_LIMIT_PER_MINUTE"))
 	// Fallback to generic rate limit setting
 	if perMinuteStr == "" {
 		perMinuteStr = os.Getenv("AI_PROVIDER_RATE_LIMIT_PER_MINUTE")
@@ -466,8 +857,8 @@ func loadRateLimitConfig(aiProvider string) (monitor.ProviderConfig, error) {
 	}
 	config.Limits["minute"] = perMinute
 
-	// Load rate limit per day for Ollama
-	perDayStr := os.Getenv("AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY")
+	// Load rate limit per day for the provider
+	perDayStr := os.Getenv(providerConfigKey(aiProvider, "RATE_LIMIT_PER_DAY"))
 	// Fallback to generic rate limit setting
 	if perDayStr == "" {
 		perDayStr = os.Getenv("AI_PROVIDER_RATE_LIMIT_PER_DAY")
@@ -484,8 +875,8 @@ func loadRateLimitConfig(aiProvider string) (monitor.ProviderConfig, error) {
 	}
 	config.Limits["day"] = perDay
 
-	// Load warning threshold for Ollama
-	warningThresholdStr := os.Getenv("AI_PROVIDER_OLLAMA_WARNING_THRESHOLD")
+	// Load warning threshold for the provider
+	warningThresholdStr := os.Getenv(providerConfigKey(aiProvider, "WARNING_THRESHOLD"))
 	// Fallback to generic threshold setting
 	if warningThresholdStr == "" {
 		warningThresholdStr = os.Getenv("AI_PROVIDER_WARNING_THRESHOLD")
@@ -502,8 +893,8 @@ func loadRateLimitConfig(aiProvider string) (monitor.ProviderConfig, error) {
 	}
 	config.Thresholds["warning"] = warningThreshold
 
-	// Load throttled threshold for Ollama
-	throttledThresholdStr := os.Getenv("AI_PROVIDER_OLLAMA_THROTTLED_THRESHOLD")
+	// Load throttled threshold for the provider
+	throttledThresholdStr := os.Getenv(providerConfigKey(aiProvider, "THROTTLED_THRESHOLD"))
 	// Fallback to generic threshold setting
 	if throttledThresholdStr == "" {
 		throttledThresholdStr = os.Getenv("AI_PROVIDER_THROTTLED_THRESHOLD")
@@ -553,7 +944,7 @@ func ready(s *discordgo.Session, event *discordgo.Ready) {
 
 // loadDatabaseConfig loads database configuration from environment variables
 func loadDatabaseConfig() (int, error) {
-	// MySQL is now the only supported database type - no DATABASE_TYPE or DATABASE_PATH needed
+	// The storage backend itself is selected by loadDatabaseType
 
 	// Load message recovery window in minutes (default: 5)
 	recoveryWindowStr := os.Getenv("MESSAGE_RECOVERY_WINDOW_MINUTES")
@@ -571,12 +962,68 @@ func loadDatabaseConfig() (int, error) {
 	}
 
 	slog.Info("Database configuration loaded",
-		"database_type", "mysql",
 		"recovery_window_minutes", recoveryWindowMinutes)
 
 	return recoveryWindowMinutes, nil
 }
 
+const (
+	// databaseTypeMySQL, databaseTypeSQLite and databaseTypeMemory are the supported DATABASE_TYPE values
+	databaseTypeMySQL  = "mysql"
+	databaseTypeSQLite = "sqlite"
+	databaseTypeMemory = "memory"
+
+	// defaultSQLitePath is where the SQLite database is stored when SQLITE_DATABASE_PATH is unset
+	defaultSQLitePath = "./data/bot_state.db"
+)
+
+// loadDatabaseType loads and validates the storage backend selected by the --storage flag value,
+// falling back to DATABASE_TYPE (default: "mysql")
+func loadDatabaseType(override string) (string, error) {
+	databaseType := strings.ToLower(strings.TrimSpace(override))
+	if databaseType == "" {
+		databaseType = strings.ToLower(strings.TrimSpace(os.Getenv("DATABASE_TYPE")))
+	}
+	if databaseType == "" {
+		return databaseTypeMySQL, nil
+	}
+
+	switch databaseType {
+	case databaseTypeMySQL, databaseTypeSQLite, databaseTypeMemory:
+		return databaseType, nil
+	default:
+		return "", fmt.Errorf("invalid storage type '%s': must be '%s', '%s' or '%s'",
+			databaseType, databaseTypeMySQL, databaseTypeSQLite, databaseTypeMemory)
+	}
+}
+
+// newStorageService creates the storage backend for databaseType from its environment configuration
+func newStorageService(databaseType string) (storage.StorageService, error) {
+	if databaseType == databaseTypeMemory {
+		slog.Warn("Using in-memory storage service; all state is lost when the bot stops")
+		return storage.NewMemoryStorageService(), nil
+	}
+
+	if databaseType == databaseTypeSQLite {
+		path := os.Getenv("SQLITE_DATABASE_PATH")
+		if path == "" {
+			path = defaultSQLitePath
+		}
+		slog.Info("Using SQLite storage service", "path", path)
+		return storage.NewSQLiteStorageService(path), nil
+	}
+
+	mysqlConfig, err := loadMySQLConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MySQL configuration: %w", err)
+	}
+	slog.Info("Using MySQL storage service",
+		"host", mysqlConfig.Host,
+		"port", mysqlConfig.Port,
+		"database", mysqlConfig.Database)
+	return storage.NewMySQLStorageService(mysqlConfig), nil
+}
+
 // loadMySQLConfig loads MySQL-specific configuration from environment variables
 func loadMySQLConfig() (storage.MySQLConfig, error) {
 	config := storage.MySQLConfig{}
@@ -649,103 +1096,138 @@ type ReactionTriggerConfig struct {
 
 // loadReplyMentionConfig loads reply mention configuration from environment variables
 func loadReplyMentionConfig() (ReplyMentionConfig, error) {
-	config := ReplyMentionConfig{}
+	return parseReplyMentionConfig(os.Getenv)
+}
+
+// parseReplyMentionConfig reads reply mention configuration through lookup
+func parseReplyMentionConfig(lookup func(key string) string) (ReplyMentionConfig, error) {
+	replyConfig := ReplyMentionConfig{}
 
 	// Load delete reply message flag (default: false for safer behavior)
-	deleteReplyStr := os.Getenv("REPLY_MENTION_DELETE_MESSAGE")
+	deleteReplyStr := lookup("REPLY_MENTION_DELETE_MESSAGE")
 	if deleteReplyStr == "" {
 		deleteReplyStr = "false" // Default value - safer to not delete by default
 	}
 
-	deleteReply, err := strconv.ParseBool(deleteReplyStr)
+	deleteReply, err := config.ParseBool(deleteReplyStr)
 	if err != nil {
-		return config, fmt.Errorf("invalid REPLY_MENTION_DELETE_MESSAGE: %s", deleteReplyStr)
+		return replyConfig, fmt.Errorf("invalid REPLY_MENTION_DELETE_MESSAGE: %s", deleteReplyStr)
 	}
 
-	config.DeleteReplyMessage = deleteReply
+	replyConfig.DeleteReplyMessage = deleteReply
 
 	slog.Info("Reply mention configuration loaded",
 		"delete_reply_message", deleteReply)
 
-	return config, nil
+	return replyConfig, nil
 }
 
 // loadReactionTriggerConfig loads reaction trigger configuration from environment variables
 func loadReactionTriggerConfig() (ReactionTriggerConfig, error) {
-	config := ReactionTriggerConfig{}
+	return parseReactionTriggerConfig(os.Getenv)
+}
+
+// reactionTriggerConfigKeys lists the keys read by parseReactionTriggerConfig
+var reactionTriggerConfigKeys = []string{
+	"REACTION_TRIGGER_ENABLED",
+	"REACTION_TRIGGER_EMOJI",
+	"REACTION_TRIGGER_APPROVED_USER_IDS",
+	"REACTION_TRIGGER_APPROVED_ROLE_NAMES",
+	"REACTION_TRIGGER_REQUIRE_REACTION",
+	"REACTION_TRIGGER_REMOVE_REACTION",
+}
+
+// botReactionTriggerConfig converts the loaded reaction trigger configuration to the handler's form
+func botReactionTriggerConfig(c ReactionTriggerConfig) bot.ReactionTriggerConfig {
+	return bot.ReactionTriggerConfig{
+		Enabled:               c.Enabled,
+		TriggerEmoji:          c.TriggerEmoji,
+		ApprovedUserIDs:       c.ApprovedUserIDs,
+		ApprovedRoleNames:     c.ApprovedRoleNames,
+		RequireReaction:       c.RequireReaction,
+		RemoveTriggerReaction: c.RemoveTriggerReaction,
+	}
+}
+
+// parseReactionTriggerConfig reads reaction trigger configuration through lookup
+func parseReactionTriggerConfig(lookup func(key string) string) (ReactionTriggerConfig, error) {
+	triggerConfig := ReactionTriggerConfig{}
 
 	// Load enabled flag (default: false for safer behavior)
-	enabledStr := os.Getenv("REACTION_TRIGGER_ENABLED")
+	enabledStr := lookup("REACTION_TRIGGER_ENABLED")
 	if enabledStr == "" {
 		enabledStr = "false" // Default value - safer to be disabled by default
 	}
 
-	enabled, err := strconv.ParseBool(enabledStr)
+	enabled, err := config.ParseBool(enabledStr)
 	if err != nil {
-		return config, fmt.Errorf("invalid REACTION_TRIGGER_ENABLED: %s", enabledStr)
+		return triggerConfig, fmt.Errorf("invalid REACTION_TRIGGER_ENABLED: %s", enabledStr)
 	}
-	config.Enabled = enabled
+	triggerConfig.Enabled = enabled
 
 	// Load trigger emoji (default: "❓")
-	triggerEmoji := os.Getenv("REACTION_TRIGGER_EMOJI")
+	triggerEmoji := lookup("REACTION_TRIGGER_EMOJI")
 	if triggerEmoji == "" {
 		triggerEmoji = "❓" // Default value
 	}
-	config.TriggerEmoji = triggerEmoji
+	if err := config.ValidateStringFormat(config.FormatTriggerEmoji, triggerEmoji); err != nil {
+		return triggerConfig, fmt.Errorf("invalid REACTION_TRIGGER_EMOJI: %w", err)
+	}
+	triggerConfig.TriggerEmoji = triggerEmoji
 
 	// Load approved user IDs (comma-separated list)
-	approvedUserIDsStr := os.Getenv("REACTION_TRIGGER_APPROVED_USER_IDS")
+	approvedUserIDsStr := lookup("REACTION_TRIGGER_APPROVED_USER_IDS")
 	if approvedUserIDsStr != "" {
-		config.ApprovedUserIDs = strings.Split(approvedUserIDsStr, ",")
+		triggerConfig.ApprovedUserIDs = strings.Split(approvedUserIDsStr, ",")
 		// Trim whitespace from each ID
-		for i, id := range config.ApprovedUserIDs {
-			config.ApprovedUserIDs[i] = strings.TrimSpace(id)
+		for i, id := range triggerConfig.ApprovedUserIDs {
+			triggerConfig.ApprovedUserIDs[i] = strings.TrimSpace(id)
 		}
 	}
 
 	// Load approved role names (comma-separated list)
-	approvedRoleNamesStr := os.Getenv("REACTION_TRIGGER_APPROVED_ROLE_NAMES")
+	approvedRoleNamesStr := lookup("REACTION_TRIGGER_APPROVED_ROLE_NAMES")
 	if approvedRoleNamesStr != "" {
-		config.ApprovedRoleNames = strings.Split(approvedRoleNamesStr, ",")
+		triggerConfig.ApprovedRoleNames = strings.Split(approvedRoleNamesStr, ",")
 		// Trim whitespace from each role name
-		for i, roleName := range config.ApprovedRoleNames {
-			config.ApprovedRoleNames[i] = strings.TrimSpace(roleName)
+		for i, roleName := range triggerConfig.ApprovedRoleNames {
+			triggerConfig.ApprovedRoleNames[i] = strings.TrimSpace(roleName)
 		}
 	}
 
 	// Load require reaction flag (default: true)
-	requireReactionStr := os.Getenv("REACTION_TRIGGER_REQUIRE_REACTION")
+	requireReactionStr := lookup("REACTION_TRIGGER_REQUIRE_REACTION")
 	if requireReactionStr == "" {
 		requireReactionStr = "true" // Default value
 	}
 
-	requireReaction, err := strconv.ParseBool(requireReactionStr)
+	requireReaction, err := config.ParseBool(requireReactionStr)
 	if err != nil {
-		return config, fmt.Errorf("invalid REACTION_TRIGGER_REQUIRE_REACTION: %s", requireReactionStr)
+		return triggerConfig, fmt.Errorf("invalid REACTION_TRIGGER_REQUIRE_REACTION: %s", requireReactionStr)
 	}
-	config.RequireReaction = requireReaction
+	triggerConfig.RequireReaction = requireReaction
 
 	// Load remove trigger reaction flag (default: false)
-	removeTriggerReactionStr := os.Getenv("REACTION_TRIGGER_REMOVE_REACTION")
+	removeTriggerReactionStr := lookup("REACTION_TRIGGER_REMOVE_REACTION")
 	if removeTriggerReactionStr == "" {
 		removeTriggerReactionStr = "false" // Default value - safer to leave reactions
 	}
 
-	removeTriggerReaction, err := strconv.ParseBool(removeTriggerReactionStr)
+	removeTriggerReaction, err := config.ParseBool(removeTriggerReactionStr)
 	if err != nil {
-		return config, fmt.Errorf("invalid REACTION_TRIGGER_REMOVE_REACTION: %s", removeTriggerReactionStr)
+		return triggerConfig, fmt.Errorf("invalid REACTION_TRIGGER_REMOVE_REACTION: %s", removeTriggerReactionStr)
 	}
-	config.RemoveTriggerReaction = removeTriggerReaction
+	triggerConfig.RemoveTriggerReaction = removeTriggerReaction
 
 	slog.Info("Reaction trigger configuration loaded",
 		"enabled", enabled,
 		"trigger_emoji", triggerEmoji,
-		"approved_user_count", len(config.ApprovedUserIDs),
-		"approved_role_count", len(config.ApprovedRoleNames),
+		"approved_user_count", len(triggerConfig.ApprovedUserIDs),
+		"approved_role_count", len(triggerConfig.ApprovedRoleNames),
 		"require_reaction", requireReaction,
 		"remove_trigger_reaction", removeTriggerReaction)
 
-	return config, nil
+	return triggerConfig, nil
 }
 
 // loadRateLimitConfigFromService loads rate limiting configuration using ConfigService
@@ -757,8 +1239,8 @@ func loadRateLimitConfigFromService(aiProvider string, configService config.Conf
 		Thresholds: make(map[string]float64),
 	}
 
-	// Load rate limit per minute for Ollama
-	perMinuteKey := "AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE"
+	// Load rate limit per minute for the provider
+	perMinuteKey := providerConfigKey(aiProvider, "RATE_LIMIT_PER_MINUTE")
 
 	perMinute := configService.GetConfigIntWithDefault(ctx, perMinuteKey, 60)
 	if perMinute <= 0 {
@@ -766,8 +1248,8 @@ func loadRateLimitConfigFromService(aiProvider string, configService config.Conf
 	}
 	config.Limits["minute"] = perMinute
 
-	// Load rate limit per day for Ollama
-	perDayKey := "AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY"
+	// Load rate limit per day for the provider
+	perDayKey := providerConfigKey(aiProvider, "RATE_LIMIT_PER_DAY")
 
 	perDay := configService.GetConfigIntWithDefault(ctx, perDayKey, 1000)
 	if perDay <= 0 {
@@ -775,8 +1257,8 @@ func loadRateLimitConfigFromService(aiProvider string, configService config.Conf
 	}
 	config.Limits["day"] = perDay
 
-	// Load warning threshold for Ollama
-	warningThresholdKey := "AI_PROVIDER_OLLAMA_WARNING_THRESHOLD"
+	// Load warning threshold for the provider
+	warningThresholdKey := providerConfigKey(aiProvider, "WARNING_THRESHOLD")
 
 	warningThresholdStr := configService.GetConfigWithDefault(ctx, warningThresholdKey, "0.75")
 	warningThreshold, err := strconv.ParseFloat(warningThresholdStr, 64)
@@ -788,8 +1270,8 @@ func loadRateLimitConfigFromService(aiProvider string, configService config.Conf
 	}
 	config.Thresholds["warning"] = warningThreshold
 
-	// Load throttled threshold for Ollama
-	throttledThresholdKey := "AI_PROVIDER_OLLAMA_THROTTLED_THRESHOLD"
+	// Load throttled threshold for the provider
+	throttledThresholdKey := providerConfigKey(aiProvider, "THROTTLED_THRESHOLD")
 
 	throttledThresholdStr := configService.GetConfigWithDefault(ctx, throttledThresholdKey, "1.0")
 	throttledThreshold, err := strconv.ParseFloat(throttledThresholdStr, 64)
@@ -861,10 +1343,15 @@ type ForumConfig struct {
 
 // loadForumConfig loads Forum monitoring configuration from environment variables
 func loadForumConfig() (ForumConfig, error) {
+	return parseForumConfig(os.Getenv)
+}
+
+// parseForumConfig reads Forum monitoring configuration through lookup
+func parseForumConfig(lookup func(key string) string) (ForumConfig, error) {
 	config := ForumConfig{}
 
 	// Load monitored Forum channels (comma-separated list)
-	monitoredChannelsStr := os.Getenv("MONITORED_FORUM_CHANNELS")
+	monitoredChannelsStr := lookup("MONITORED_FORUM_CHANNELS")
 	if monitoredChannelsStr != "" {
 		channels := strings.Split(monitoredChannelsStr, ",")
 		var validChannels []string
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"bmad-knowledge-bot/internal/config"
+	"bmad-knowledge-bot/internal/monitor"
+	"bmad-knowledge-bot/internal/storage"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -131,6 +136,30 @@ func TestHealthCheckFlag(t *testing.T) {
 	}
 }
 
+func TestRunHealthCheck(t *testing.T) {
+	healthServer := monitor.NewHealthServer("127.0.0.1:0", slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	if err := healthServer.Start(); err != nil {
+		t.Fatalf("Failed to start health server: %v", err)
+	}
+	defer healthServer.Shutdown(context.Background())
+	t.Setenv("HEALTH_SERVER_ADDR", healthServer.Addr())
+
+	// Not ready until startup completes
+	if code := runHealthCheck(); code != 1 {
+		t.Errorf("Expected exit code 1 before startup completes, got %d", code)
+	}
+
+	healthServer.SetReady(true)
+	if code := runHealthCheck(); code != 0 {
+		t.Errorf("Expected exit code 0 when ready, got %d", code)
+	}
+
+	healthServer.AddReadinessCheck("storage", func(ctx context.Context) error { return fmt.Errorf("database unreachable") })
+	if code := runHealthCheck(); code != 1 {
+		t.Errorf("Expected exit code 1 when a dependency is unhealthy, got %d", code)
+	}
+}
+
 func TestLoadRateLimitConfig(t *testing.T) {
 	// Save original environment
 	originalEnv := make(map[string]string)
@@ -143,6 +172,7 @@ func TestLoadRateLimitConfig(t *testing.T) {
 		"AI_PROVIDER_WARNING_THRESHOLD",
 		"AI_PROVIDER_OLLAMA_THROTTLED_THRESHOLD",
 		"AI_PROVIDER_THROTTLED_THRESHOLD",
+		"AI_PROVIDER_OPENAI_RATE_LIMIT_PER_MINUTE",
 	}
 
 	for _, env := range envVars {
@@ -221,6 +251,15 @@ func TestLoadRateLimitConfig(t *testing.T) {
 			expectError: true,
 			errorMsg:    "warning threshold",
 		},
+		{
+			name:     "openai provider uses its own keys",
+			provider: "openai",
+			envVars: map[string]string{
+				"AI_PROVIDER_OPENAI_RATE_LIMIT_PER_MINUTE": "invalid",
+			},
+			expectError: true,
+			errorMsg:    "invalid rate limit per minute for provider openai",
+		},
 	}
 
 	for _, tt := range tests {
@@ -447,6 +486,98 @@ func TestLoadMySQLConfig(t *testing.T) {
 	}
 }
 
+func TestLoadDatabaseType(t *testing.T) {
+	originalEnv := os.Getenv("DATABASE_TYPE")
+	defer func() {
+		if originalEnv != "" {
+			os.Setenv("DATABASE_TYPE", originalEnv)
+		} else {
+			os.Unsetenv("DATABASE_TYPE")
+		}
+	}()
+
+	tests := []struct {
+		name        string
+		envValue    string
+		flagValue   string
+		expected    string
+		expectError bool
+	}{
+		{name: "default to mysql", envValue: "", expected: "mysql"},
+		{name: "mysql", envValue: "mysql", expected: "mysql"},
+		{name: "sqlite is case insensitive", envValue: " SQLite ", expected: "sqlite"},
+		{name: "memory", envValue: "memory", expected: "memory"},
+		{name: "flag overrides environment", envValue: "mysql", flagValue: "memory", expected: "memory"},
+		{name: "unsupported type", envValue: "postgres", expectError: true},
+		{name: "unsupported flag value", envValue: "mysql", flagValue: "postgres", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				os.Setenv("DATABASE_TYPE", tt.envValue)
+			} else {
+				os.Unsetenv("DATABASE_TYPE")
+			}
+
+			databaseType, err := loadDatabaseType(tt.flagValue)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for storage type '%s'/'%s', but got none", tt.flagValue, tt.envValue)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+			if databaseType != tt.expected {
+				t.Errorf("Expected database type '%s', got '%s'", tt.expected, databaseType)
+			}
+		})
+	}
+}
+
+func TestNewStorageService_Memory(t *testing.T) {
+	storageService, err := newStorageService("memory")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, ok := storageService.(*storage.MemoryStorageService); !ok {
+		t.Errorf("Expected *storage.MemoryStorageService, got %T", storageService)
+	}
+}
+
+func TestNewStorageService_SQLite(t *testing.T) {
+	originalEnv := os.Getenv("SQLITE_DATABASE_PATH")
+	defer func() {
+		if originalEnv != "" {
+			os.Setenv("SQLITE_DATABASE_PATH", originalEnv)
+		} else {
+			os.Unsetenv("SQLITE_DATABASE_PATH")
+		}
+	}()
+
+	os.Setenv("SQLITE_DATABASE_PATH", filepath.Join(t.TempDir(), "bot.db"))
+
+	// SQLite needs no MySQL credentials
+	storageService, err := newStorageService("sqlite")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, ok := storageService.(*storage.SQLiteStorageService); !ok {
+		t.Errorf("Expected *storage.SQLiteStorageService, got %T", storageService)
+	}
+
+	if err := storageService.Initialize(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize SQLite storage: %v", err)
+	}
+	defer storageService.Close()
+
+	if err := storageService.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected healthy SQLite storage, got: %v", err)
+	}
+}
+
 func TestLoadReplyMentionConfig(t *testing.T) {
 	// Save original environment
 	originalEnv := os.Getenv("REPLY_MENTION_DELETE_MESSAGE")
@@ -578,6 +709,14 @@ func TestLoadReactionTriggerConfig(t *testing.T) {
 			expectError: true,
 			errorMsg:    "invalid REACTION_TRIGGER_REQUIRE_REACTION",
 		},
+		{
+			name: "feedback emoji as trigger",
+			envVars: map[string]string{
+				"REACTION_TRIGGER_EMOJI": "👎",
+			},
+			expectError: true,
+			errorMsg:    "invalid REACTION_TRIGGER_EMOJI",
+		},
 	}
 
 	for _, tt := range tests {
@@ -742,9 +881,30 @@ func (m *mockConfigService) GetAllConfigs(ctx context.Context) (map[string]strin
 func (m *mockConfigService) ReloadConfigs(ctx context.Context) error            { return nil }
 func (m *mockConfigService) ValidateConfig(key, value string) error             { return nil }
 func (m *mockConfigService) DeleteConfig(ctx context.Context, key string) error { return nil }
-func (m *mockConfigService) HealthCheck(ctx context.Context) error              { return nil }
-func (m *mockConfigService) StartAutoReload(interval time.Duration) error       { return nil }
-func (m *mockConfigService) StopAutoReload()                                    {}
+func (m *mockConfigService) GetGuildConfig(ctx context.Context, guildID, key string) (string, error) {
+	return m.GetConfig(ctx, key)
+}
+func (m *mockConfigService) GetGuildConfigWithDefault(ctx context.Context, guildID, key, defaultValue string) string {
+	return m.GetConfigWithDefault(ctx, key, defaultValue)
+}
+func (m *mockConfigService) GetGuildConfigs(ctx context.Context, guildID string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (m *mockConfigService) SetGuildConfig(ctx context.Context, guildID, key, value string) error {
+	return nil
+}
+func (m *mockConfigService) DeleteGuildConfig(ctx context.Context, guildID, key string) error {
+	return nil
+}
+func (m *mockConfigService) GetConfigHistory(ctx context.Context, guildID, key string, limit int) ([]*storage.ConfigurationHistory, error) {
+	return nil, nil
+}
+func (m *mockConfigService) RollbackConfig(ctx context.Context, guildID, key string, historyID int64) error {
+	return nil
+}
+func (m *mockConfigService) HealthCheck(ctx context.Context) error        { return nil }
+func (m *mockConfigService) StartAutoReload(interval time.Duration) error { return nil }
+func (m *mockConfigService) StopAutoReload()                              {}
 
 func parseToInt(s string) (int, error) {
 	if s == "" {
@@ -880,6 +1040,112 @@ func TestLoadRateLimitConfigFromService(t *testing.T) {
 	}
 }
 
+func TestLoadRateLimitConfigs(t *testing.T) {
+	mockService := &mockConfigService{configs: map[string]string{
+		"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE": "120",
+		"AI_PROVIDER_OPENAI_RATE_LIMIT_PER_MINUTE": "30",
+	}}
+
+	configs, err := loadRateLimitConfigs([]string{"ollama", "openai"}, func(provider string) (monitor.ProviderConfig, error) {
+		return loadRateLimitConfigFromService(provider, mockService)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 provider configs, got %d", len(configs))
+	}
+	if configs[0].ProviderID != "ollama" || configs[0].Limits["minute"] != 120 {
+		t.Errorf("Unexpected ollama config: %+v", configs[0])
+	}
+	if configs[1].ProviderID != "openai" || configs[1].Limits["minute"] != 30 {
+		t.Errorf("Unexpected openai config: %+v", configs[1])
+	}
+
+	mockService.configs["AI_PROVIDER_OPENAI_RATE_LIMIT_PER_DAY"] = "-1"
+	_, err = loadRateLimitConfigs([]string{"ollama", "openai"}, func(provider string) (monitor.ProviderConfig, error) {
+		return loadRateLimitConfigFromService(provider, mockService)
+	})
+	if err == nil || !contains(err.Error(), "provider openai") {
+		t.Errorf("Expected error for openai provider, got: %v", err)
+	}
+}
+
+func TestApplyRateLimitConfigs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockService := &mockConfigService{configs: map[string]string{}}
+	aiProviders := []string{"ollama"}
+
+	manager := monitor.NewRateLimitManager(logger, []monitor.ProviderConfig{{
+		ProviderID: "ollama",
+		Limits:     map[string]int{"minute": 60, "day": 1000},
+		Thresholds: map[string]float64{"warning": 0.75, "throttled": 1.0},
+	}})
+
+	mockService.configs["AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE"] = "5"
+	if err := applyRateLimitConfigs(manager, aiProviders, mockService); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, limit := manager.GetProviderUsage("ollama"); limit != 5 {
+		t.Errorf("Expected updated minute limit 5, got %d", limit)
+	}
+
+	// Invalid values leave the running limits untouched
+	mockService.configs["AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE"] = "-1"
+	if err := applyRateLimitConfigs(manager, aiProviders, mockService); err == nil {
+		t.Error("Expected error for negative limit")
+	}
+	if _, limit := manager.GetProviderUsage("ollama"); limit != 5 {
+		t.Errorf("Expected minute limit to stay 5, got %d", limit)
+	}
+
+	keys := providerRateLimitKeys([]string{"ollama", "openai"})
+	if len(keys) != 8 || keys[0] != "AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE" || keys[7] != "AI_PROVIDER_OPENAI_THROTTLED_THRESHOLD" {
+		t.Errorf("Unexpected rate limit keys: %v", keys)
+	}
+}
+
+func TestParseReactionTriggerConfigFromService(t *testing.T) {
+	mockService := &mockConfigService{configs: map[string]string{
+		"REACTION_TRIGGER_ENABLED":           "true",
+		"REACTION_TRIGGER_EMOJI":             "🤖",
+		"REACTION_TRIGGER_APPROVED_USER_IDS": "123456789012345678, 987654321098765432",
+	}}
+
+	reactionConfig, err := parseReactionTriggerConfig(configLookup(mockService))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	converted := botReactionTriggerConfig(reactionConfig)
+	if !converted.Enabled || converted.TriggerEmoji != "🤖" || len(converted.ApprovedUserIDs) != 2 || !converted.RequireReaction {
+		t.Errorf("Unexpected reaction trigger config: %+v", converted)
+	}
+}
+
+func TestParseStatusInterval(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"", 5 * time.Minute, true},
+		{"45s", 45 * time.Second, true},
+		{"10s", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		interval, err := parseStatusInterval(tt.value, "5m", 30*time.Second)
+		if tt.valid && (err != nil || interval != tt.expected) {
+			t.Errorf("parseStatusInterval(%q) = %v, %v; want %v", tt.value, interval, err, tt.expected)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("parseStatusInterval(%q) should have failed", tt.value)
+		}
+	}
+}
+
 func TestLoadKnowledgeBaseConfigFromService(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"bmad-knowledge-bot/internal/storage"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = "usage: bot [--storage=mysql|sqlite] migrate status|up|down [steps]"
+
+// runMigrate handles the migrate subcommand and returns the process exit code
+func runMigrate(storageOverride string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+
+	databaseType, err := loadDatabaseType(storageOverride)
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate failed: %v\n", err)
+		return 1
+	}
+
+	backend, err := newStorageService(databaseType)
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate failed: %v\n", err)
+		return 1
+	}
+	defer backend.Close()
+
+	schemaMigrator, ok := backend.(storage.SchemaMigrator)
+	if !ok {
+		fmt.Fprintf(stderr, "migrate failed: %s storage has no schema to migrate\n", databaseType)
+		return 1
+	}
+
+	ctx := context.Background()
+	migrator, err := schemaMigrator.Migrator(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate failed: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "status":
+		err = printMigrationStatus(ctx, migrator, stdout)
+	case "up":
+		var applied []int
+		applied, err = migrator.Up(ctx)
+		if err == nil {
+			if len(applied) == 0 {
+				fmt.Fprintln(stdout, "Schema is up to date")
+			}
+			for _, version := range applied {
+				fmt.Fprintf(stdout, "Applied migration %d\n", version)
+			}
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				fmt.Fprintf(stderr, "invalid steps %q: must be a positive integer\n", args[1])
+				return 2
+			}
+		}
+
+		var reverted []int
+		reverted, err = migrator.Down(ctx, steps)
+		if err == nil {
+			if len(reverted) == 0 {
+				fmt.Fprintln(stdout, "No migrations to roll back")
+			}
+			for _, version := range reverted {
+				fmt.Fprintf(stdout, "Rolled back migration %d\n", version)
+			}
+		}
+	default:
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate %s failed: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// printMigrationStatus writes a table of every known migration and when it was applied
+func printMigrationStatus(ctx context.Context, migrator *storage.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.Applied {
+			state = "applied"
+			appliedAt = time.Unix(status.AppliedAt, 0).UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, state, appliedAt, status.Description)
+	}
+	return writer.Flush()
+}
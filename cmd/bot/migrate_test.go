@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMigrate_SQLite(t *testing.T) {
+	t.Setenv("SQLITE_DATABASE_PATH", filepath.Join(t.TempDir(), "migrate.db"))
+
+	steps := []struct {
+		args       []string
+		wantOutput string
+	}{
+		{[]string{"status"}, "pending"},
+		{[]string{"up"}, "Applied migration 1"},
+		{[]string{"up"}, "Schema is up to date"},
+		{[]string{"status"}, "applied"},
+		{[]string{"down", "100"}, "Rolled back migration 1"},
+		{[]string{"down"}, "No migrations to roll back"},
+	}
+
+	for _, step := range steps {
+		var stdout, stderr bytes.Buffer
+		if code := runMigrate("sqlite", step.args, &stdout, &stderr); code != 0 {
+			t.Fatalf("migrate %v exited with %d: %s", step.args, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), step.wantOutput) {
+			t.Errorf("migrate %v output = %q, want it to contain %q", step.args, stdout.String(), step.wantOutput)
+		}
+	}
+}
+
+func TestRunMigrate_InvalidUsage(t *testing.T) {
+	t.Setenv("SQLITE_DATABASE_PATH", filepath.Join(t.TempDir(), "migrate.db"))
+
+	tests := []struct {
+		name     string
+		storage  string
+		args     []string
+		wantCode int
+	}{
+		{"missing action", "sqlite", nil, 2},
+		{"unknown action", "sqlite", []string{"sideways"}, 2},
+		{"invalid steps", "sqlite", []string{"down", "zero"}, 2},
+		{"negative steps", "sqlite", []string{"down", "-1"}, 2},
+		{"memory storage", "memory", []string{"status"}, 1},
+		{"unknown storage", "postgres", []string{"status"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runMigrate(tt.storage, tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("runMigrate() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+		})
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
+	"bmad-knowledge-bot/internal/config"
 	"bmad-knowledge-bot/internal/monitor"
+	"bmad-knowledge-bot/internal/service"
 	"bmad-knowledge-bot/internal/storage"
 )
 
@@ -19,6 +22,8 @@ type AdminCommands struct {
 	storage           storage.StorageService
 	userRateLimiter   *monitor.UserRateLimiter
 	channelRestrictor *ChannelRestrictor
+	configService     config.ConfigService
+	responseCache     *service.ResponseCache
 	logger            *slog.Logger
 }
 
@@ -37,18 +42,59 @@ func NewAdminCommands(
 	}
 }
 
-// HandleAdminCommand processes admin commands for rate limiting and channel management
+// SetConfigService routes configuration changes through the config service so they are audited and
+// notify listeners, and enables the configuration history commands
+func (ac *AdminCommands) SetConfigService(configService config.ConfigService) {
+	ac.configService = configService
+}
+
+// SetResponseCache enables the response cache statistics and purge command
+func (ac *AdminCommands) SetResponseCache(cache *service.ResponseCache) {
+	ac.responseCache = cache
+}
+
+// HandleAdminCommand processes `!` admin commands for rate limiting and channel management.
+// Commands from non-admins return an empty response so they are ignored without a reply; text
+// commands are handled before channel restrictions and rate limits, so replying would let anyone
+// make the bot post in any channel.
 func (ac *AdminCommands) HandleAdminCommand(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, command string, args []string) (string, error) {
-	// Verify user is admin
 	isAdmin, err := ac.isUserAdmin(ctx, s, m.Author.ID, m.GuildID)
 	if err != nil {
 		ac.logger.Error("Failed to check admin status", "error", err, "user_id", m.Author.ID)
+		return "", nil
+	}
+	if !isAdmin {
+		ac.logger.Info("Ignoring admin command from non-admin user", "user_id", m.Author.ID, "command", command)
+		return "", nil
+	}
+
+	return ac.runAdminCommand(ctx, m.Author.ID, m.GuildID, command, args)
+}
+
+// ExecuteAdminCommand runs an admin command on behalf of a user, independent of how it was invoked
+// (text message or slash command interaction)
+func (ac *AdminCommands) ExecuteAdminCommand(ctx context.Context, s *discordgo.Session, userID string, guildID string, command string, args []string) (string, error) {
+	// Verify user is admin
+	isAdmin, err := ac.isUserAdmin(ctx, s, userID, guildID)
+	if err != nil {
+		ac.logger.Error("Failed to check admin status", "error", err, "user_id", userID)
 		return "❌ Failed to verify admin permissions.", nil
 	}
 	if !isAdmin {
+		ac.logger.Info("Non-admin user attempted admin command", "user_id", userID, "command", command)
 		return "🔒 This command requires admin permissions.", nil
 	}
 
+	return ac.runAdminCommand(ctx, userID, guildID, command, args)
+}
+
+// runAdminCommand dispatches an admin command for a user whose admin permissions have been verified
+func (ac *AdminCommands) runAdminCommand(ctx context.Context, userID string, guildID string, command string, args []string) (string, error) {
+	ac.logger.Info("Executing admin command", "user_id", userID, "guild_id", guildID, "command", command, "args", args)
+
+	// Attribute any configuration change made by the command to the admin
+	ctx = config.WithActor(ctx, userID)
+
 	switch command {
 	case "ratelimit-status":
 		return ac.handleRateLimitStatus(ctx, args)
@@ -58,6 +104,18 @@ func (ac *AdminCommands) HandleAdminCommand(ctx context.Context, s *discordgo.Se
 		return ac.handleRateLimitConfig(ctx, args)
 	case "channel-restrictions":
 		return ac.handleChannelRestrictions(ctx, args)
+	case "feedback-worst":
+		return ac.handleFeedbackWorst(ctx, args)
+	case "config-history":
+		return ac.handleConfigHistory(ctx, guildID, args)
+	case "config-rollback":
+		return ac.handleConfigRollback(ctx, guildID, args)
+	case "config-keys":
+		return ac.handleConfigKeys(args), nil
+	case "guild-config":
+		return ac.handleGuildConfig(ctx, guildID, args)
+	case "response-cache":
+		return ac.handleResponseCache(args), nil
 	case "admin-help":
 		return ac.handleAdminHelp(), nil
 	default:
@@ -142,7 +200,7 @@ func (ac *AdminCommands) handleRateLimitConfig(ctx context.Context, args []strin
 // handleChannelRestrictions shows or updates channel restrictions
 func (ac *AdminCommands) handleChannelRestrictions(ctx context.Context, args []string) (string, error) {
 	if len(args) == 0 {
-		restrictions, err := ac.channelRestrictor.GetChannelRestrictions(ctx)
+		restrictions, err := ac.channelRestrictor.GetChannelRestrictions(ctx, "")
 		if err != nil {
 			ac.logger.Error("Failed to get channel restrictions", "error", err)
 			return "❌ Failed to retrieve channel restrictions.", nil
@@ -151,15 +209,167 @@ func (ac *AdminCommands) handleChannelRestrictions(ctx context.Context, args []s
 	}
 
 	if len(args) < 2 {
-		return "❓ Usage: `!channel-restrictions` (show) or `!channel-restrictions <setting> <value>`\nSettings: enabled, add_channel, remove_channel, restrict_dms, admin_bypass", nil
+		return "❓ Usage: `!channel-restrictions` (show) or `!channel-restrictions <setting> <value>`\nSettings: " + channelRestrictionSettings, nil
 	}
 
 	setting := args[0]
-	value := args[1]
+	value := strings.Join(args[1:], " ")
 
 	return ac.updateChannelRestrictions(ctx, setting, value)
 }
 
+// handleFeedbackWorst lists the answers with the most 👎 votes
+func (ac *AdminCommands) handleFeedbackWorst(ctx context.Context, args []string) (string, error) {
+	limit := defaultFeedbackWorstLimit
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 || parsed > maxFeedbackWorstLimit {
+			return fmt.Sprintf("❓ Usage: `!feedback-worst [count]` (count between 1 and %d)", maxFeedbackWorstLimit), nil
+		}
+		limit = parsed
+	}
+
+	summaries, err := ac.storage.GetMostDownvotedInteractions(ctx, limit)
+	if err != nil {
+		ac.logger.Error("Failed to get downvoted interactions", "error", err)
+		return "❌ Failed to retrieve answer feedback.", nil
+	}
+
+	return formatDownvotedInteractions(summaries), nil
+}
+
+// handleConfigHistory lists the most recent changes to a configuration key's global value and guildID's override
+func (ac *AdminCommands) handleConfigHistory(ctx context.Context, guildID string, args []string) (string, error) {
+	if len(args) == 0 {
+		return fmt.Sprintf("❓ Usage: `!config-history <key> [count]` (count between 1 and %d)", maxConfigHistoryLimit), nil
+	}
+	if ac.configService == nil {
+		return "❌ Configuration history is not available.", nil
+	}
+
+	key := args[0]
+	limit := defaultConfigHistoryLimit
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed < 1 || parsed > maxConfigHistoryLimit {
+			return fmt.Sprintf("❓ Usage: `!config-history <key> [count]` (count between 1 and %d)", maxConfigHistoryLimit), nil
+		}
+		limit = parsed
+	}
+
+	history, err := ac.configService.GetConfigHistory(ctx, guildID, key, limit)
+	if err != nil {
+		ac.logger.Error("Failed to get configuration history", "error", err, "key", key)
+		return "❌ Failed to retrieve configuration history.", nil
+	}
+
+	return formatConfigHistory(key, history), nil
+}
+
+// handleConfigRollback restores a configuration key to the value set by one of its global or guildID's history entries
+func (ac *AdminCommands) handleConfigRollback(ctx context.Context, guildID string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "❓ Usage: `!config-rollback <key> <version>` (version numbers are listed by `!config-history <key>`)", nil
+	}
+	if ac.configService == nil {
+		return "❌ Configuration rollback is not available.", nil
+	}
+
+	key := args[0]
+	historyID, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+	if err != nil || historyID < 1 {
+		return "❌ Invalid version. Use a number listed by `!config-history " + key + "`.", nil
+	}
+
+	if err := ac.configService.RollbackConfig(ctx, guildID, key, historyID); err != nil {
+		ac.logger.Error("Failed to roll back configuration", "error", err, "guild_id", guildID, "key", key, "version", historyID)
+		return fmt.Sprintf("❌ Failed to roll back %s: %v", key, err), nil
+	}
+
+	ac.logger.Info("Configuration rolled back", "key", key, "version", historyID, "actor", config.ActorFromContext(ctx))
+	return fmt.Sprintf("✅ Rolled back %s to version #%d.", key, historyID), nil
+}
+
+// handleConfigKeys summarizes the registered configuration categories, or lists the keys in one category
+func (ac *AdminCommands) handleConfigKeys(args []string) string {
+	if len(args) == 0 {
+		return formatConfigCategories()
+	}
+
+	category := strings.ToLower(args[0])
+	var schemas []config.ConfigSchema
+	for _, schema := range config.ConfigSchemas() {
+		if schema.Category == category {
+			schemas = append(schemas, schema)
+		}
+	}
+	if len(schemas) == 0 {
+		return fmt.Sprintf("❓ Unknown category. Valid options: %s", strings.Join(config.ConfigCategories(), ", "))
+	}
+
+	return formatConfigSchemas(category, schemas)
+}
+
+// handleGuildConfig lists, sets or removes this guild's overrides of guild-scoped configuration keys
+func (ac *AdminCommands) handleGuildConfig(ctx context.Context, guildID string, args []string) (string, error) {
+	const usage = "❓ Usage: `!guild-config`, `!guild-config set <key> <value>` or `!guild-config unset <key>`"
+
+	if guildID == "" {
+		return "❌ Guild configuration can only be managed from a server channel.", nil
+	}
+	if ac.configService == nil {
+		return "❌ Guild configuration is not available.", nil
+	}
+
+	if len(args) == 0 {
+		overrides, err := ac.configService.GetGuildConfigs(ctx, guildID)
+		if err != nil {
+			ac.logger.Error("Failed to get guild configuration", "error", err, "guild_id", guildID)
+			return "❌ Failed to retrieve guild configuration.", nil
+		}
+		return formatGuildConfig(overrides), nil
+	}
+
+	action := strings.ToLower(args[0])
+	switch {
+	case action == "set" && len(args) >= 3:
+		key := strings.ToUpper(args[1])
+		value := strings.Join(args[2:], " ")
+		if err := ac.configService.SetGuildConfig(ctx, guildID, key, value); err != nil {
+			return fmt.Sprintf("❌ Failed to set %s for this server: %v", key, err), nil
+		}
+		ac.logger.Info("Guild configuration override set", "guild_id", guildID, "key", key, "actor", config.ActorFromContext(ctx))
+		return fmt.Sprintf("✅ %s set to %s for this server.", key, formatConfigValue(value)), nil
+	case action == "unset" && len(args) == 2 && args[1] != "":
+		key := strings.ToUpper(args[1])
+		if err := ac.configService.DeleteGuildConfig(ctx, guildID, key); err != nil {
+			return fmt.Sprintf("❌ Failed to remove %s override: %v", key, err), nil
+		}
+		ac.logger.Info("Guild configuration override removed", "guild_id", guildID, "key", key, "actor", config.ActorFromContext(ctx))
+		return fmt.Sprintf("✅ %s now uses the global value for this server.", key), nil
+	default:
+		return usage, nil
+	}
+}
+
+// handleResponseCache shows response cache statistics or purges every cached answer
+func (ac *AdminCommands) handleResponseCache(args []string) string {
+	if ac.responseCache == nil {
+		return "❌ The response cache is not available."
+	}
+
+	if len(args) == 0 {
+		return formatResponseCacheStats(ac.responseCache.Stats())
+	}
+	if len(args) != 1 || strings.ToLower(args[0]) != "purge" {
+		return "❓ Usage: `!response-cache` (show statistics) or `!response-cache purge`"
+	}
+
+	purged := ac.responseCache.Purge()
+	ac.logger.Info("Response cache purged", "purged_entries", purged)
+	return fmt.Sprintf("✅ Purged %d cached answers.", purged)
+}
+
 // handleAdminHelp shows available admin commands
 func (ac *AdminCommands) handleAdminHelp() string {
 	return `🛡️ **Admin Commands Help:**
@@ -174,15 +384,36 @@ func (ac *AdminCommands) handleAdminHelp() string {
 **Channel Restrictions:**
 • ` + "`!channel-restrictions`" + ` - Show current channel restrictions
 • ` + "`!channel-restrictions enabled true/false`" + ` - Enable/disable restrictions
-• ` + "`!channel-restrictions add_channel <channel_id>`" + ` - Add allowed channel
-• ` + "`!channel-restrictions remove_channel <channel_id>`" + ` - Remove allowed channel
+• ` + "`!channel-restrictions mode allowlist/denylist`" + ` - Choose which list applies
+• ` + "`!channel-restrictions add_channel <channel_id>`" + ` - Add allowed channel or category
+• ` + "`!channel-restrictions remove_channel <channel_id>`" + ` - Remove allowed channel or category
+• ` + "`!channel-restrictions deny_channel <channel_id>`" + ` - Add denied channel or category
+• ` + "`!channel-restrictions undeny_channel <channel_id>`" + ` - Remove denied channel or category
+• ` + "`!channel-restrictions trigger_mode <channel_id> <mode>`" + ` - Set mention-only, auto-respond, disabled or inherit
 • ` + "`!channel-restrictions restrict_dms true/false`" + ` - Restrict DM messages
 • ` + "`!channel-restrictions admin_bypass true/false`" + ` - Enable admin bypass
 
+**Answer Feedback:**
+• ` + "`!feedback-worst [count]`" + ` - List the answers with the most 👎 votes
+
+**Configuration:**
+• ` + "`!config-history <key> [count]`" + ` - Show who changed a configuration key and when
+• ` + "`!config-rollback <key> <version>`" + ` - Restore the value set by a history version
+• ` + "`!config-keys [category]`" + ` - List configurable keys, their types, ranges and defaults
+  Categories: ` + strings.Join(config.ConfigCategories(), ", ") + `
+• ` + "`!guild-config`" + ` - Show this server's configuration overrides
+• ` + "`!guild-config set <key> <value>`" + ` - Override a per-server key (marked "per server" in ` + "`!config-keys`" + `)
+• ` + "`!guild-config unset <key>`" + ` - Return a key to the global value
+
+**Response Cache:**
+• ` + "`!response-cache`" + ` - Show cached answer count, hit rate and settings
+• ` + "`!response-cache purge`" + ` - Drop every cached answer
+
 **General:**
 • ` + "`!admin-help`" + ` - Show this help message
 
-**Note:** All commands require admin permissions configured in ` + "`ADMIN_ROLE_NAMES`" + `.`
+**Note:** All commands require admin permissions configured in ` + "`ADMIN_ROLE_NAMES`" + `.
+Every command is also available as a slash command (e.g. ` + "`/ratelimit-status`" + `) with private replies.`
 }
 
 // Helper functions
@@ -194,6 +425,10 @@ func (ac *AdminCommands) isUserAdmin(ctx context.Context, s *discordgo.Session,
 		return false, nil
 	}
 
+	if ac.userRateLimiter == nil || s == nil || s.Ratelimiter == nil {
+		return false, fmt.Errorf("admin role check unavailable")
+	}
+
 	// Get user roles
 	member, err := s.GuildMember(guildID, userID)
 	if err != nil {
@@ -280,20 +515,19 @@ func (ac *AdminCommands) updateRateLimitConfig(ctx context.Context, setting stri
 		return "❌ Unknown setting. Valid options: minute_limit, hour_limit, day_limit, enabled", nil
 	}
 
-	// Update configuration
-	now := time.Now().Unix()
-	config := &storage.Configuration{
-		Key:         key,
-		Value:       value,
-		Type:        valueType,
-		Category:    "rate_limiting",
-		Description: fmt.Sprintf("Updated by admin command at %d", now),
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	if schema, ok := config.LookupConfigSchema(key); ok {
+		if err := schema.Validate(value); err != nil {
+			return fmt.Sprintf("❌ Invalid value for %s: %v.", setting, err), nil
+		}
+	}
+
+	// Update configuration through the config service so the change is audited and notifies listeners
+	if ac.configService == nil {
+		return "❌ Configuration changes are not available.", nil
 	}
 
-	err := ac.storage.UpsertConfiguration(ctx, config)
-	if err != nil {
+	description := fmt.Sprintf("Updated by admin command at %d", time.Now().Unix())
+	if err := ac.configService.SetConfigTyped(ctx, key, value, valueType, "rate_limiting", description); err != nil {
 		ac.logger.Error("Failed to update configuration", "error", err, "key", key)
 		return "❌ Failed to update configuration.", nil
 	}
@@ -303,39 +537,57 @@ func (ac *AdminCommands) updateRateLimitConfig(ctx context.Context, setting stri
 
 // updateChannelRestrictions updates channel restriction settings
 func (ac *AdminCommands) updateChannelRestrictions(ctx context.Context, setting string, value string) (string, error) {
-	restrictions, err := ac.channelRestrictor.GetChannelRestrictions(ctx)
+	restrictions, err := ac.channelRestrictor.GetChannelRestrictions(ctx, "")
 	if err != nil {
 		return "❌ Failed to get current channel restrictions.", nil
 	}
 
 	switch setting {
 	case "enabled":
-		if value == "true" {
-			restrictions.Enabled = true
-		} else if value == "false" {
-			restrictions.Enabled = false
-		} else {
+		enabled, err := config.ParseBool(value)
+		if err != nil {
 			return "❌ Invalid value for enabled. Must be true or false.", nil
 		}
+		restrictions.Enabled = enabled
+
+	case "mode":
+		mode := strings.ToLower(strings.TrimSpace(value))
+		if mode != RestrictionModeAllowlist && mode != RestrictionModeDenylist {
+			return "❌ Invalid value for mode. Must be allowlist or denylist.", nil
+		}
+		restrictions.Mode = mode
+
+	case "add_channel", "deny_channel":
+		// Add a channel or category to the allowed or denied list
+		list, listName := &restrictions.AllowedChannelIDs, "allowed"
+		if setting == "deny_channel" {
+			list, listName = &restrictions.DeniedChannelIDs, "denied"
+		}
 
-	case "add_channel":
-		// Add channel to allowed list
 		channelID := strings.TrimSpace(value)
 		if channelID == "" {
 			return "❌ Channel ID cannot be empty.", nil
 		}
+		if err := config.ValidateStringFormat(config.FormatSnowflake, channelID); err != nil {
+			return fmt.Sprintf("❌ Invalid channel ID: %v.", err), nil
+		}
 
 		// Check if already exists
-		for _, existing := range restrictions.AllowedChannelIDs {
+		for _, existing := range *list {
 			if existing == channelID {
-				return fmt.Sprintf("ℹ️ Channel <#%s> is already in the allowed list.", channelID), nil
+				return fmt.Sprintf("ℹ️ Channel <#%s> is already in the %s list.", channelID, listName), nil
 			}
 		}
 
-		restrictions.AllowedChannelIDs = append(restrictions.AllowedChannelIDs, channelID)
+		*list = append(*list, channelID)
+
+	case "remove_channel", "undeny_channel":
+		// Remove a channel or category from the allowed or denied list
+		list, listName := &restrictions.AllowedChannelIDs, "allowed"
+		if setting == "undeny_channel" {
+			list, listName = &restrictions.DeniedChannelIDs, "denied"
+		}
 
-	case "remove_channel":
-		// Remove channel from allowed list
 		channelID := strings.TrimSpace(value)
 		if channelID == "" {
 			return "❌ Channel ID cannot be empty.", nil
@@ -343,7 +595,7 @@ func (ac *AdminCommands) updateChannelRestrictions(ctx context.Context, setting
 
 		newList := []string{}
 		found := false
-		for _, existing := range restrictions.AllowedChannelIDs {
+		for _, existing := range *list {
 			if existing != channelID {
 				newList = append(newList, existing)
 			} else {
@@ -352,31 +604,50 @@ func (ac *AdminCommands) updateChannelRestrictions(ctx context.Context, setting
 		}
 
 		if !found {
-			return fmt.Sprintf("ℹ️ Channel <#%s> was not in the allowed list.", channelID), nil
+			return fmt.Sprintf("ℹ️ Channel <#%s> was not in the %s list.", channelID, listName), nil
 		}
 
-		restrictions.AllowedChannelIDs = newList
+		*list = newList
+
+	case "trigger_mode":
+		// Set or clear the trigger mode of a channel or category
+		fields := strings.Fields(value)
+		if len(fields) != 2 {
+			return "❓ Usage: `!channel-restrictions trigger_mode <channel_id> <mention-only|auto-respond|disabled|inherit>`", nil
+		}
+		channelID, mode := fields[0], ChannelTriggerMode(strings.ToLower(fields[1]))
+		if err := config.ValidateStringFormat(config.FormatSnowflake, channelID); err != nil {
+			return fmt.Sprintf("❌ Invalid channel ID: %v.", err), nil
+		}
+
+		switch mode {
+		case TriggerModeMentionOnly, TriggerModeAutoRespond, TriggerModeDisabled:
+			restrictions.TriggerModes[channelID] = mode
+		case "inherit":
+			if _, ok := restrictions.TriggerModes[channelID]; !ok {
+				return fmt.Sprintf("ℹ️ Channel <#%s> has no trigger mode set.", channelID), nil
+			}
+			delete(restrictions.TriggerModes, channelID)
+		default:
+			return "❌ Invalid trigger mode. Must be mention-only, auto-respond, disabled or inherit.", nil
+		}
 
 	case "restrict_dms":
-		if value == "true" {
-			restrictions.RestrictDMs = true
-		} else if value == "false" {
-			restrictions.RestrictDMs = false
-		} else {
+		enabled, err := config.ParseBool(value)
+		if err != nil {
 			return "❌ Invalid value for restrict_dms. Must be true or false.", nil
 		}
+		restrictions.RestrictDMs = enabled
 
 	case "admin_bypass":
-		if value == "true" {
-			restrictions.AdminBypassEnabled = true
-		} else if value == "false" {
-			restrictions.AdminBypassEnabled = false
-		} else {
+		enabled, err := config.ParseBool(value)
+		if err != nil {
 			return "❌ Invalid value for admin_bypass. Must be true or false.", nil
 		}
+		restrictions.AdminBypassEnabled = enabled
 
 	default:
-		return "❌ Unknown setting. Valid options: enabled, add_channel, remove_channel, restrict_dms, admin_bypass", nil
+		return "❌ Unknown setting. Valid options: " + channelRestrictionSettings, nil
 	}
 
 	// Update restrictions
@@ -389,6 +660,196 @@ func (ac *AdminCommands) updateChannelRestrictions(ctx context.Context, setting
 	return fmt.Sprintf("✅ Updated channel restrictions: %s", setting), nil
 }
 
+// channelRestrictionSettings lists the settings accepted by !channel-restrictions
+const channelRestrictionSettings = "enabled, mode, add_channel, remove_channel, deny_channel, undeny_channel, trigger_mode, restrict_dms, admin_bypass"
+
+const (
+	// defaultFeedbackWorstLimit and maxFeedbackWorstLimit bound how many answers !feedback-worst lists
+	defaultFeedbackWorstLimit = 10
+	maxFeedbackWorstLimit     = 25
+)
+
+// formatDownvotedInteractions formats the most downvoted answers with their votes, question and a link to the answer
+func formatDownvotedInteractions(summaries []*storage.InteractionFeedbackSummary) string {
+	if len(summaries) == 0 {
+		return "👍 No answers have been downvoted."
+	}
+
+	var b strings.Builder
+	b.WriteString("👎 **Most Downvoted Answers:**\n")
+	for i, summary := range summaries {
+		interaction := summary.Interaction
+		fmt.Fprintf(&b, "\n**%d.** 👎 %d · 👍 %d — <@%s> via %s (%s",
+			i+1, summary.Downvotes, summary.Upvotes, interaction.UserID, interaction.TriggerType, interaction.Provider)
+		if interaction.Model != "" {
+			fmt.Fprintf(&b, "/%s", interaction.Model)
+		}
+		fmt.Fprintf(&b, ", %s)\n", time.Unix(interaction.CreatedAt, 0).UTC().Format("2006-01-02"))
+		fmt.Fprintf(&b, "> **Q:** %s\n", truncateString(strings.Join(strings.Fields(interaction.Query), " "), 150))
+		if link := interactionMessageLink(interaction); link != "" {
+			fmt.Fprintf(&b, "> %s\n", link)
+		}
+	}
+	return b.String()
+}
+
+// interactionMessageLink returns a Discord link to the answer of an interaction, or "" if the answer message is unknown
+func interactionMessageLink(interaction *storage.Interaction) string {
+	if interaction.ResponseMessageID == nil {
+		return ""
+	}
+
+	guildID := interaction.GuildID
+	if guildID == "" {
+		guildID = "@me" // Direct messages
+	}
+	channelID := interaction.ChannelID
+	if interaction.ThreadID != nil {
+		channelID = *interaction.ThreadID
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, *interaction.ResponseMessageID)
+}
+
+const (
+	// defaultConfigHistoryLimit and maxConfigHistoryLimit bound how many changes !config-history lists
+	defaultConfigHistoryLimit = 10
+	maxConfigHistoryLimit     = 25
+)
+
+// formatConfigHistory formats configuration changes, newest first, with the version numbers accepted by !config-rollback
+func formatConfigHistory(key string, history []*storage.ConfigurationHistory) string {
+	if len(history) == 0 {
+		return fmt.Sprintf("📜 No recorded changes for `%s`.", key)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📜 **History for `%s`** (newest first):\n", key)
+	for _, change := range history {
+		fmt.Fprintf(&b, "• `#%d` %s — %s ", change.ID,
+			time.Unix(change.ChangedAt, 0).UTC().Format("2006-01-02 15:04 UTC"), formatConfigActor(change.Actor))
+		if change.GuildID != "" {
+			b.WriteString("[this server] ")
+		}
+		switch {
+		case change.OldValue == nil && change.NewValue != nil:
+			fmt.Fprintf(&b, "set %s\n", formatConfigValue(*change.NewValue))
+		case change.NewValue == nil:
+			if change.OldValue != nil {
+				fmt.Fprintf(&b, "deleted (was %s)\n", formatConfigValue(*change.OldValue))
+			} else {
+				b.WriteString("deleted\n")
+			}
+		default:
+			fmt.Fprintf(&b, "changed %s → %s\n", formatConfigValue(*change.OldValue), formatConfigValue(*change.NewValue))
+		}
+	}
+	fmt.Fprintf(&b, "\nUse `!config-rollback %s <version>` to restore the value a change set.", key)
+	return b.String()
+}
+
+// formatGuildConfig lists a guild's configuration overrides in key order
+func formatGuildConfig(overrides map[string]string) string {
+	if len(overrides) == 0 {
+		return "⚙️ This server uses the global configuration. Use `!guild-config set <key> <value>` to override a key."
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString("⚙️ **Configuration overrides for this server:**\n")
+	for _, key := range keys {
+		builder.WriteString(fmt.Sprintf("• `%s` = %s\n", key, formatConfigValue(overrides[key])))
+	}
+	builder.WriteString("\nUse `!guild-config unset <key>` to return a key to the global value.")
+	return builder.String()
+}
+
+// formatConfigCategories lists each configuration category with its number of keys
+func formatConfigCategories() string {
+	counts := make(map[string]int)
+	for _, schema := range config.ConfigSchemas() {
+		counts[schema.Category]++
+	}
+
+	var builder strings.Builder
+	builder.WriteString("⚙️ **Configuration Categories:**\n")
+	for _, category := range config.ConfigCategories() {
+		builder.WriteString(fmt.Sprintf("• `%s` (%d keys)\n", category, counts[category]))
+	}
+	builder.WriteString("\nUse `!config-keys <category>` to list its keys.")
+	return builder.String()
+}
+
+// formatConfigSchemas lists the keys in a configuration category with their constraints and defaults
+func formatConfigSchemas(category string, schemas []config.ConfigSchema) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("⚙️ **Configuration keys in `%s`:**\n", category))
+	for _, schema := range schemas {
+		line := fmt.Sprintf("• `%s` — %s, default %s", schema.Key, schema.Constraint(), formatConfigValue(schema.Default))
+		if schema.RestartRequired {
+			line += " (restart required)"
+		}
+		if schema.GuildScoped {
+			line += " (per server)"
+		}
+		builder.WriteString(line + "\n  " + schema.Description + "\n")
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// formatResponseCacheStats summarizes the response cache's size, hit rate and settings
+func formatResponseCacheStats(stats service.ResponseCacheStats) string {
+	if !stats.Enabled {
+		return "🗃️ The response cache is disabled. Set `RESPONSE_CACHE_ENABLED` to true to enable it."
+	}
+
+	hitRate := 0.0
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		hitRate = float64(stats.Hits) * 100 / float64(lookups)
+	}
+	matching := "exact matches only"
+	if stats.SimilarityThreshold > 0 {
+		matching = fmt.Sprintf("%d%% word overlap", stats.SimilarityThreshold)
+	}
+	kbVersion := "unknown"
+	if hash := stats.KnowledgeBaseHash; hash != "" {
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		kbVersion = "`" + hash + "`"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🗃️ **Response Cache:**\n")
+	builder.WriteString(fmt.Sprintf("• Cached answers: %d\n", stats.Entries))
+	builder.WriteString(fmt.Sprintf("• Hits: %d, misses: %d (%.1f%% hit rate)\n", stats.Hits, stats.Misses, hitRate))
+	builder.WriteString(fmt.Sprintf("• TTL: %s\n", stats.TTL))
+	builder.WriteString(fmt.Sprintf("• Matching: %s\n", matching))
+	builder.WriteString(fmt.Sprintf("• Knowledge base version: %s\n", kbVersion))
+	builder.WriteString("\nUse `!response-cache purge` to drop every cached answer.")
+	return builder.String()
+}
+
+// formatConfigActor mentions Discord users and shows system actors such as "seed" as-is
+func formatConfigActor(actor string) string {
+	if _, err := strconv.ParseUint(actor, 10, 64); err == nil {
+		return fmt.Sprintf("<@%s>", actor)
+	}
+	return fmt.Sprintf("*%s*", actor)
+}
+
+// formatConfigValue renders a configuration value as inline code, shortening long values
+func formatConfigValue(value string) string {
+	if value == "" {
+		return "*(empty)*"
+	}
+	return "`" + truncateString(strings.ReplaceAll(value, "`", "'"), 60) + "`"
+}
+
 // isValidTimeWindow checks if a time window is valid
 func isValidTimeWindow(window string) bool {
 	validWindows := []string{"minute", "hour", "day"}
@@ -1,11 +1,18 @@
 package bot
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"strconv"
 	"testing"
 
+	"bmad-knowledge-bot/internal/config"
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Simple test implementation to improve coverage
@@ -52,5 +59,445 @@ func TestHandleAdminHelp(t *testing.T) {
 	assert.Contains(t, response, "ratelimit-status")
 	assert.Contains(t, response, "ratelimit-reset")
 	assert.Contains(t, response, "channel-restrictions")
+	assert.Contains(t, response, "config-history")
+	assert.Contains(t, response, "config-rollback")
+	assert.Contains(t, response, "config-keys")
+	assert.Contains(t, response, "response-cache")
+	assert.Contains(t, response, "channel_restrictions")
 	assert.Contains(t, response, "ADMIN_ROLE_NAMES")
 }
+
+func TestFormatDownvotedInteractions(t *testing.T) {
+	assert.Equal(t, "👍 No answers have been downvoted.", formatDownvotedInteractions(nil))
+
+	threadID := "thread-1"
+	responseID := "answer-1"
+	response := formatDownvotedInteractions([]*storage.InteractionFeedbackSummary{
+		{
+			Interaction: &storage.Interaction{
+				GuildID:           "guild-1",
+				ChannelID:         "channel-1",
+				ThreadID:          &threadID,
+				UserID:            "user-1",
+				TriggerType:       "mention",
+				Query:             "What is\nthe PM agent?",
+				Provider:          "ollama",
+				Model:             "devstral",
+				ResponseMessageID: &responseID,
+			},
+			Upvotes:   1,
+			Downvotes: 3,
+		},
+		{
+			Interaction: &storage.Interaction{ChannelID: "dm-1", UserID: "user-2", TriggerType: "dm", Query: "Hi", Provider: "openai"},
+			Downvotes:   1,
+		},
+	})
+
+	assert.Contains(t, response, "**1.** 👎 3 · 👍 1 — <@user-1> via mention (ollama/devstral")
+	assert.Contains(t, response, "> **Q:** What is the PM agent?")
+	assert.Contains(t, response, "https://discord.com/channels/guild-1/thread-1/answer-1")
+	assert.Contains(t, response, "**2.** 👎 1 · 👍 0 — <@user-2> via dm (openai,")
+}
+
+func TestHandleFeedbackWorst_InvalidCount(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+
+	for _, arg := range []string{"zero", "0", "26"} {
+		response, err := adminCommands.handleFeedbackWorst(context.Background(), []string{arg})
+		assert.NoError(t, err)
+		assert.Contains(t, response, "Usage: `!feedback-worst [count]`")
+	}
+}
+
+func TestHandleResponseCache(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+	assert.Equal(t, "❌ The response cache is not available.", adminCommands.handleResponseCache(nil))
+
+	cache := service.NewResponseCache(logger)
+	adminCommands.SetResponseCache(cache)
+	mockAI := NewMockAIService()
+	_, _, _, err := cache.QueryAIWithSummary(context.Background(), mockAI, "What is BMAD?")
+	require.NoError(t, err)
+	_, _, _, err = cache.QueryAIWithSummary(context.Background(), mockAI, "what is bmad")
+	require.NoError(t, err)
+
+	response := adminCommands.handleResponseCache(nil)
+	assert.Contains(t, response, "Cached answers: 1")
+	assert.Contains(t, response, "Hits: 1, misses: 1 (50.0% hit rate)")
+	assert.Contains(t, response, "exact matches only")
+
+	assert.Contains(t, adminCommands.handleResponseCache([]string{"flush"}), "Usage: `!response-cache`")
+	assert.Equal(t, "✅ Purged 1 cached answers.", adminCommands.handleResponseCache([]string{"purge"}))
+	assert.Equal(t, 0, cache.Stats().Entries)
+
+	cache.SetEnabled(false)
+	assert.Contains(t, adminCommands.handleResponseCache(nil), "disabled")
+}
+
+func TestFormatConfigHistory(t *testing.T) {
+	assert.Equal(t, "📜 No recorded changes for `KEY`.", formatConfigHistory("KEY", nil))
+
+	oldValue, newValue := "5", "10"
+	response := formatConfigHistory("USER_RATE_LIMIT_PER_MINUTE", []*storage.ConfigurationHistory{
+		{ID: 3, Key: "USER_RATE_LIMIT_PER_MINUTE", OldValue: &newValue, Actor: "123456789", ChangedAt: 1700000200},
+		{ID: 2, Key: "USER_RATE_LIMIT_PER_MINUTE", OldValue: &oldValue, NewValue: &newValue, Actor: "123456789", ChangedAt: 1700000100},
+		{ID: 1, Key: "USER_RATE_LIMIT_PER_MINUTE", NewValue: &oldValue, Actor: config.ActorSeed, ChangedAt: 1700000000},
+	})
+
+	assert.Contains(t, response, "**History for `USER_RATE_LIMIT_PER_MINUTE`**")
+	assert.Contains(t, response, "`#3` 2023-11-14 22:16 UTC — <@123456789> deleted (was `10`)")
+	assert.Contains(t, response, "`#2` 2023-11-14 22:15 UTC — <@123456789> changed `5` → `10`")
+	assert.Contains(t, response, "`#1` 2023-11-14 22:13 UTC — *seed* set `5`")
+	assert.Contains(t, response, "!config-rollback USER_RATE_LIMIT_PER_MINUTE <version>")
+}
+
+func TestHandleConfigHistoryAndRollback(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+
+	configService := config.NewDatabaseConfigService(storage.NewMemoryStorageService())
+	require.NoError(t, configService.Initialize(ctx))
+	require.NoError(t, configService.SetConfigTyped(config.WithActor(ctx, config.ActorSeed), "USER_RATE_LIMIT_PER_MINUTE", "5", "int", "rate_limiting", ""))
+
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+
+	// Without a config service the commands report they are unavailable
+	response, err := adminCommands.handleConfigHistory(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "not available")
+
+	adminCommands.SetConfigService(configService)
+
+	// Admin changes made through the config service are attributed to the admin
+	response, err = adminCommands.updateRateLimitConfig(config.WithActor(ctx, "123456789"), "minute_limit", "10")
+	require.NoError(t, err)
+	assert.Contains(t, response, "✅")
+
+	history, err := configService.GetConfigHistory(ctx, "", "USER_RATE_LIMIT_PER_MINUTE", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "123456789", history[0].Actor)
+	seeded := history[1]
+
+	response, err = adminCommands.handleConfigHistory(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "<@123456789> changed `5` → `10`")
+
+	response, err = adminCommands.handleConfigRollback(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE", "#" + strconv.FormatInt(seeded.ID, 10)})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "✅ Rolled back USER_RATE_LIMIT_PER_MINUTE")
+
+	value, err := configService.GetConfig(ctx, "USER_RATE_LIMIT_PER_MINUTE")
+	require.NoError(t, err)
+	assert.Equal(t, "5", value)
+
+	for _, args := range [][]string{{}, {"USER_RATE_LIMIT_PER_MINUTE"}} {
+		response, err = adminCommands.handleConfigRollback(ctx, "guild-1", args)
+		assert.NoError(t, err)
+		assert.Contains(t, response, "Usage: `!config-rollback")
+	}
+
+	response, err = adminCommands.handleConfigRollback(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE", "latest"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "Invalid version")
+
+	response, err = adminCommands.handleConfigRollback(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE", "999999"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "❌ Failed to roll back")
+
+	response, err = adminCommands.handleConfigHistory(ctx, "guild-1", []string{"USER_RATE_LIMIT_PER_MINUTE", "0"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "Usage: `!config-history")
+}
+
+func TestHandleGuildConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := config.WithActor(context.Background(), "123456789")
+
+	configService := config.NewDatabaseConfigService(storage.NewMemoryStorageService())
+	require.NoError(t, configService.Initialize(ctx))
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+	adminCommands.SetConfigService(configService)
+
+	response, err := adminCommands.handleGuildConfig(ctx, "", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, response, "server channel")
+
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, response, "uses the global configuration")
+
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", []string{"set", "user_rate_limit_per_minute", "20"})
+	require.NoError(t, err)
+	assert.Contains(t, response, "✅ USER_RATE_LIMIT_PER_MINUTE set to `20` for this server.")
+
+	value, err := configService.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE")
+	require.NoError(t, err)
+	assert.Equal(t, "20", value)
+
+	history, err := configService.GetConfigHistory(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "guild-1", history[0].GuildID)
+	assert.Equal(t, "123456789", history[0].Actor)
+
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, response, "• `USER_RATE_LIMIT_PER_MINUTE` = `20`")
+
+	// Keys that are not guild-scoped and invalid values are rejected
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", []string{"set", "OLLAMA_HOST", "http://example.com"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "❌ Failed to set OLLAMA_HOST")
+
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", []string{"set", "USER_RATE_LIMIT_PER_MINUTE", "-1"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "❌ Failed to set USER_RATE_LIMIT_PER_MINUTE")
+
+	response, err = adminCommands.handleGuildConfig(ctx, "guild-1", []string{"unset", "USER_RATE_LIMIT_PER_MINUTE"})
+	assert.NoError(t, err)
+	assert.Contains(t, response, "now uses the global value")
+
+	overrides, err := configService.GetGuildConfigs(ctx, "guild-1")
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+
+	for _, args := range [][]string{{"set", "USER_RATE_LIMIT_PER_MINUTE"}, {"unset"}, {"drop", "KEY"}} {
+		response, err = adminCommands.handleGuildConfig(ctx, "guild-1", args)
+		assert.NoError(t, err)
+		assert.Contains(t, response, "Usage: `!guild-config`")
+	}
+}
+
+func TestHandleConfigHistoryAndRollback_OtherGuilds(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := config.WithActor(context.Background(), "123456789")
+
+	configService := config.NewDatabaseConfigService(storage.NewMemoryStorageService())
+	require.NoError(t, configService.Initialize(ctx))
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+	adminCommands.SetConfigService(configService)
+
+	require.NoError(t, configService.SetGuildConfig(ctx, "guild-a", "USER_RATE_LIMIT_PER_MINUTE", "20"))
+	require.NoError(t, configService.SetGuildConfig(ctx, "guild-a", "USER_RATE_LIMIT_PER_MINUTE", "30"))
+	history, err := configService.GetConfigHistory(ctx, "guild-a", "USER_RATE_LIMIT_PER_MINUTE", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	first := strconv.FormatInt(history[1].ID, 10)
+
+	// Another server's admin sees none of guild-a's values and cannot roll them back
+	response, err := adminCommands.handleConfigHistory(ctx, "guild-b", []string{"USER_RATE_LIMIT_PER_MINUTE"})
+	require.NoError(t, err)
+	assert.Contains(t, response, "No recorded changes")
+	assert.NotContains(t, response, "`20`")
+
+	response, err = adminCommands.handleConfigRollback(ctx, "guild-b", []string{"USER_RATE_LIMIT_PER_MINUTE", first})
+	require.NoError(t, err)
+	assert.Contains(t, response, "❌")
+	value, err := configService.GetGuildConfig(ctx, "guild-a", "USER_RATE_LIMIT_PER_MINUTE")
+	require.NoError(t, err)
+	assert.Equal(t, "30", value)
+
+	// guild-a's admin can
+	response, err = adminCommands.handleConfigHistory(ctx, "guild-a", []string{"USER_RATE_LIMIT_PER_MINUTE"})
+	require.NoError(t, err)
+	assert.Contains(t, response, "[this server] changed `20` → `30`")
+
+	response, err = adminCommands.handleConfigRollback(ctx, "guild-a", []string{"USER_RATE_LIMIT_PER_MINUTE", first})
+	require.NoError(t, err)
+	assert.Contains(t, response, "✅")
+	value, err = configService.GetGuildConfig(ctx, "guild-a", "USER_RATE_LIMIT_PER_MINUTE")
+	require.NoError(t, err)
+	assert.Equal(t, "20", value)
+}
+
+func TestHandleChannelRestrictions_ModesAndTriggers(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+	memoryStorage := storage.NewMemoryStorageService()
+	configService := config.NewDatabaseConfigService(memoryStorage)
+	require.NoError(t, configService.Initialize(ctx))
+	restrictor := NewChannelRestrictor(memoryStorage, logger)
+	restrictor.SetConfigService(configService)
+	adminCommands := NewAdminCommands(memoryStorage, nil, restrictor, logger)
+	adminCommands.SetConfigService(configService)
+
+	const channelID = "123456789012345678"
+	const categoryID = "987654321098765432"
+
+	steps := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"enabled", "true"}, "✅"},
+		{[]string{"mode", "denylist"}, "✅"},
+		{[]string{"mode", "blocklist"}, "Invalid value for mode"},
+		{[]string{"deny_channel", categoryID}, "✅"},
+		{[]string{"deny_channel", categoryID}, "already in the denied list"},
+		{[]string{"trigger_mode", channelID, "auto-respond"}, "✅"},
+		{[]string{"trigger_mode", channelID, "always"}, "Invalid trigger mode"},
+		{[]string{"trigger_mode", channelID}, "Usage: `!channel-restrictions trigger_mode"},
+		{[]string{"bogus", "value"}, "Unknown setting"},
+	}
+	for _, step := range steps {
+		response, err := adminCommands.handleChannelRestrictions(ctx, step.args)
+		require.NoError(t, err)
+		assert.Contains(t, response, step.expected, "args %v", step.args)
+	}
+
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "thread", ParentID: channelID, CategoryID: categoryID})
+	require.NoError(t, err)
+	assert.False(t, allowed, "Threads in a denied category should be blocked")
+	assert.Equal(t, TriggerModeAutoRespond, restrictor.TriggerMode(ctx, ChannelLocation{ChannelID: channelID}))
+
+	response, err := adminCommands.handleChannelRestrictions(ctx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "Denylist")
+	assert.Contains(t, response, "<#"+channelID+">: auto-respond")
+
+	for _, args := range [][]string{{"trigger_mode", channelID, "inherit"}, {"undeny_channel", categoryID}} {
+		response, err = adminCommands.handleChannelRestrictions(ctx, args)
+		require.NoError(t, err)
+		assert.Contains(t, response, "✅", "args %v", args)
+	}
+	assert.Equal(t, TriggerModeMentionOnly, restrictor.TriggerMode(ctx, ChannelLocation{ChannelID: channelID}))
+
+	response, err = adminCommands.handleChannelRestrictions(ctx, []string{"undeny_channel", categoryID})
+	require.NoError(t, err)
+	assert.Contains(t, response, "was not in the denied list")
+}
+
+func TestHandleChannelRestrictions_ReenableKeepsLists(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+	memoryStorage := storage.NewMemoryStorageService()
+	configService := config.NewDatabaseConfigService(memoryStorage)
+	require.NoError(t, configService.Initialize(ctx))
+	restrictor := NewChannelRestrictor(memoryStorage, logger)
+	restrictor.SetConfigService(configService)
+	adminCommands := NewAdminCommands(memoryStorage, nil, restrictor, logger)
+	adminCommands.SetConfigService(configService)
+
+	const allowedID = "123456789012345678"
+	const deniedID = "987654321098765432"
+
+	steps := [][]string{
+		{"enabled", "true"},
+		{"add_channel", allowedID},
+		{"deny_channel", deniedID},
+		{"admin_bypass", "true"},
+		{"mode", "denylist"},
+		{"enabled", "false"},
+		// Changes made while disabled must not reset the other settings
+		{"mode", "allowlist"},
+		{"enabled", "true"},
+	}
+	for _, args := range steps {
+		response, err := adminCommands.handleChannelRestrictions(ctx, args)
+		require.NoError(t, err)
+		assert.Contains(t, response, "✅", "args %v", args)
+	}
+
+	restrictions, err := restrictor.GetChannelRestrictions(ctx, "")
+	require.NoError(t, err)
+	assert.True(t, restrictions.Enabled)
+	assert.Equal(t, RestrictionModeAllowlist, restrictions.Mode)
+	assert.Equal(t, []string{allowedID}, restrictions.AllowedChannelIDs)
+	assert.Equal(t, []string{deniedID}, restrictions.DeniedChannelIDs)
+	assert.True(t, restrictions.AdminBypassEnabled)
+
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "111111111111111111"})
+	require.NoError(t, err)
+	assert.False(t, allowed, "The allowlist should apply again once re-enabled")
+}
+
+func TestHandleChannelRestrictions_RecordsHistory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+	memoryStorage := storage.NewMemoryStorageService()
+	configService := config.NewDatabaseConfigService(memoryStorage)
+	require.NoError(t, configService.Initialize(ctx))
+	restrictor := NewChannelRestrictor(memoryStorage, logger)
+	restrictor.SetConfigService(configService)
+	adminCommands := NewAdminCommands(memoryStorage, nil, restrictor, logger)
+	adminCommands.SetConfigService(configService)
+
+	const adminID = "111111111111111111"
+	const channelID = "123456789012345678"
+
+	response, err := adminCommands.runAdminCommand(ctx, adminID, "", "channel-restrictions", []string{"add_channel", channelID})
+	require.NoError(t, err)
+	assert.Contains(t, response, "✅")
+
+	history, err := configService.GetConfigHistory(ctx, "", "ALLOWED_CHANNEL_IDS", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+	assert.Equal(t, adminID, history[0].Actor)
+	require.NotNil(t, history[0].NewValue)
+	assert.Equal(t, channelID, *history[0].NewValue)
+
+	// Only the changed key is written
+	history, err = configService.GetConfigHistory(ctx, "", "CHANNEL_RESTRICTION_MODE", 10)
+	require.NoError(t, err)
+	for _, change := range history {
+		assert.NotEqual(t, adminID, change.Actor)
+	}
+}
+
+func TestHandleConfigKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	adminCommands := NewAdminCommands(nil, nil, nil, logger)
+
+	response := adminCommands.handleConfigKeys(nil)
+	assert.Contains(t, response, "**Configuration Categories:**")
+	for _, category := range config.ConfigCategories() {
+		assert.Contains(t, response, "`"+category+"`")
+	}
+
+	response = adminCommands.handleConfigKeys([]string{"RATE_LIMITING"})
+	assert.Contains(t, response, "**Configuration keys in `rate_limiting`:**")
+	assert.Contains(t, response, "• `USER_RATE_LIMIT_PER_MINUTE` — int ≥ 1, default `5` (per server)\n  User rate limit per minute")
+	assert.Contains(t, response, "`AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE` — int ≥ 1, default `60`")
+
+	response = adminCommands.handleConfigKeys([]string{"ai_services"})
+	assert.Contains(t, response, "`OLLAMA_HOST` — URL, default `http://localhost:11434` (restart required)")
+	assert.Contains(t, response, "`OLLAMA_TIMEOUT` — int ≥ 1, default `30`\n")
+
+	for _, category := range config.ConfigCategories() {
+		response = adminCommands.handleConfigKeys([]string{category})
+		assert.LessOrEqual(t, len(response), 2000, "%s keys must fit in a Discord message", category)
+	}
+
+	response = adminCommands.handleConfigKeys([]string{"nonsense"})
+	assert.Contains(t, response, "Unknown category")
+}
+
+func TestAdminCommands_RejectsValuesOutsideSchema(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+	mockStorage := storage.NewMemoryStorageService()
+	adminCommands := NewAdminCommands(mockStorage, nil, nil, logger)
+
+	response, err := adminCommands.updateRateLimitConfig(ctx, "minute_limit", "-5")
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Invalid value for minute_limit: must be at least 1.", response)
+
+	stored, err := mockStorage.GetConfiguration(ctx, "USER_RATE_LIMIT_PER_MINUTE")
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Changes are never written to storage directly
+	response, err = adminCommands.updateRateLimitConfig(ctx, "minute_limit", "7")
+	assert.NoError(t, err)
+	assert.Equal(t, "❌ Configuration changes are not available.", response)
+
+	configService := config.NewDatabaseConfigService(mockStorage)
+	require.NoError(t, configService.Initialize(ctx))
+	adminCommands.SetConfigService(configService)
+	response, err = adminCommands.updateRateLimitConfig(ctx, "minute_limit", "7")
+	assert.NoError(t, err)
+	assert.Contains(t, response, "✅")
+}
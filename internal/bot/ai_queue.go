@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"bmad-knowledge-bot/internal/service"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// aiQueueFullMessage is sent when too many questions are already waiting for an answer
+	aiQueueFullMessage = "🚦 I'm handling a lot of questions right now. Please try again in a few minutes."
+
+	// aiQueueTimeoutMessage is sent when a question waited in the queue past its deadline
+	aiQueueTimeoutMessage = "⌛ I couldn't get to your question in time. Please try asking again."
+)
+
+// aiJob is AI work in progress for a Discord message
+type aiJob struct {
+	messageID string
+	cancel    context.CancelFunc
+}
+
+// SetAIQueue limits concurrent AI work to the queue's workers, telling users their position while they wait
+func (h *Handler) SetAIQueue(queue *service.AIQueue) {
+	h.aiQueue = queue
+}
+
+// SetBaseContext sets the context all AI work derives from; cancelling it stops in-flight generations on shutdown
+func (h *Handler) SetBaseContext(ctx context.Context) {
+	h.baseCtx = ctx
+}
+
+// HandleMessageDelete cancels AI work for a message that was deleted before it was answered
+func (h *Handler) HandleMessageDelete(s *discordgo.Session, d *discordgo.MessageDelete) {
+	if d.Message == nil {
+		return
+	}
+
+	h.aiJobsMu.Lock()
+	defer h.aiJobsMu.Unlock()
+
+	for job := range h.aiJobs {
+		if job.messageID == d.ID {
+			h.logger.Info("Triggering message deleted, cancelling AI work", "message_id", d.ID, "channel_id", d.ChannelID)
+			job.cancel()
+		}
+	}
+}
+
+// startAIJob blocks until the AI work userID requested for message m may start. It returns the context the
+// work must use, which ends on shutdown, when m is deleted or at the queue deadline, and the function that
+// frees the slot. It returns false, after telling the user why if they are still waiting, when the work
+// cannot start.
+func (h *Handler) startAIJob(s *discordgo.Session, m *discordgo.MessageCreate, userID string, priority service.AIJobPriority) (context.Context, func(), bool) {
+	parent := h.baseCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	job := &aiJob{messageID: m.ID, cancel: cancel}
+	h.aiJobsMu.Lock()
+	if h.aiJobs == nil {
+		h.aiJobs = make(map[*aiJob]struct{})
+	}
+	h.aiJobs[job] = struct{}{}
+	h.aiJobsMu.Unlock()
+
+	finish := func() {
+		h.aiJobsMu.Lock()
+		delete(h.aiJobs, job)
+		h.aiJobsMu.Unlock()
+		cancel()
+	}
+
+	if h.aiQueue == nil {
+		return ctx, finish, true
+	}
+
+	// Admin roles are only looked up when there is a queue to skip
+	if h.userRateLimiter != nil && h.aiQueue.Saturated() && h.isUserRateLimitExempt(ctx, s, userID, m.GuildID) {
+		priority = service.AIJobPriorityAdmin
+	}
+
+	var notice *discordgo.Message
+	jobCtx, release, err := h.aiQueue.Acquire(ctx, service.AIJob{
+		UserID:   userID,
+		Priority: priority,
+		OnQueued: func(position int) {
+			notice = h.sendQueueReply(s, m, fmt.Sprintf("⏳ I'm answering other questions right now. You're **#%d** in the queue, and I'll reply here when it's your turn.", position))
+		},
+	})
+
+	// The position notice is only useful while waiting
+	if notice != nil {
+		if err := s.ChannelMessageDelete(notice.ChannelID, notice.ID); err != nil {
+			h.logger.Warn("Failed to delete queue position notice", "error", err, "message_id", notice.ID)
+		}
+	}
+
+	if err != nil {
+		finish()
+		h.logger.Warn("AI job not started", "error", err, "user_id", userID, "priority", priority.String())
+		switch {
+		case errors.Is(err, service.ErrAIQueueFull):
+			h.sendQueueReply(s, m, aiQueueFullMessage)
+		case errors.Is(err, context.DeadlineExceeded):
+			h.sendQueueReply(s, m, aiQueueTimeoutMessage)
+		}
+		return nil, nil, false
+	}
+	return jobCtx, func() {
+		release()
+		finish()
+	}, true
+}
+
+// aiWorkCancelled reports whether AI work stopped because ctx was cancelled by shutdown or a deleted message,
+// in which case there is nobody left to reply to
+func aiWorkCancelled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// sendQueueReply replies to the queued message, returning nil without a live session or on failure
+func (h *Handler) sendQueueReply(s *discordgo.Session, m *discordgo.MessageCreate, content string) *discordgo.Message {
+	if s == nil || s.Ratelimiter == nil {
+		return nil
+	}
+
+	message, err := s.ChannelMessageSendReply(m.ChannelID, content, m.Reference())
+	if err != nil {
+		h.logger.Error("Failed to send queue reply", "error", err, "channel_id", m.ChannelID)
+		return nil
+	}
+	return message
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_StartAIJob(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), NewMockStorageService())
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "msg-1", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-1"}}}
+
+	// Without a queue AI work starts immediately
+	_, release, ok := handler.startAIJob(nil, m, "user-1", service.AIJobPriorityNewQuestion)
+	require.True(t, ok)
+	release()
+
+	queue := service.NewAIQueue(logger)
+	queue.SetConcurrency(1)
+	queue.SetMaxDepth(1)
+	handler.SetAIQueue(queue)
+
+	_, release, ok = handler.startAIJob(nil, m, "user-1", service.AIJobPriorityNewQuestion)
+	require.True(t, ok)
+
+	// A second question waits for the first to finish
+	started := make(chan func())
+	go func() {
+		_, next, ok := handler.startAIJob(nil, m, "user-2", service.AIJobPriorityDM)
+		if ok {
+			started <- next
+		}
+	}()
+	require.Eventually(t, func() bool { return queue.Stats().Waiting == 1 }, time.Second, 5*time.Millisecond)
+
+	// A third is turned away while the queue is at its maximum depth
+	_, _, ok = handler.startAIJob(nil, m, "user-3", service.AIJobPriorityNewQuestion)
+	assert.False(t, ok)
+
+	release()
+	select {
+	case next := <-started:
+		next()
+	case <-time.After(time.Second):
+		t.Fatal("queued question did not start")
+	}
+
+	_, _, err := queue.Acquire(context.Background(), service.AIJob{UserID: "user-4"})
+	assert.NoError(t, err, "all workers should be free again")
+}
+
+func TestHandler_AIJobCancellation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), NewMockStorageService())
+	baseCtx, shutdown := context.WithCancel(context.Background())
+	handler.SetBaseContext(baseCtx)
+
+	question := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "question", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-1"}}}
+	other := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "other", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-2"}}}
+
+	ctx, release, ok := handler.startAIJob(nil, question, "user-1", service.AIJobPriorityNewQuestion)
+	require.True(t, ok)
+	defer release()
+	otherCtx, releaseOther, ok := handler.startAIJob(nil, other, "user-2", service.AIJobPriorityNewQuestion)
+	require.True(t, ok)
+	defer releaseOther()
+
+	// Deleting the triggering message cancels only its work
+	handler.HandleMessageDelete(nil, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "question", ChannelID: "channel-1"}})
+	assert.True(t, aiWorkCancelled(ctx))
+	assert.NoError(t, otherCtx.Err())
+
+	// Shutdown cancels everything still running
+	shutdown()
+	assert.True(t, aiWorkCancelled(otherCtx))
+
+	// Finished work is forgotten
+	release()
+	releaseOther()
+	assert.Empty(t, handler.aiJobs)
+}
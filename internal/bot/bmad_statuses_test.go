@@ -11,104 +11,26 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-// MockStorageForStatusTest provides a simple mock for testing status manager
-type MockStorageForStatusTest struct {
-	statusMessages []*storage.StatusMessage
-}
-
-func (m *MockStorageForStatusTest) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*storage.StatusMessage, error) {
-	if len(m.statusMessages) == 0 {
-		return nil, nil
-	}
-	if limit > len(m.statusMessages) {
-		limit = len(m.statusMessages)
+// newStatusTestStorage returns in-memory storage holding the given status messages
+func newStatusTestStorage(t *testing.T, messages []*storage.StatusMessage) *storage.MemoryStorageService {
+	t.Helper()
+	statusStorage := storage.NewMemoryStorageService()
+	for _, msg := range messages {
+		if err := statusStorage.AddStatusMessage(context.Background(), msg.ActivityType, msg.StatusText, msg.Enabled); err != nil {
+			t.Fatalf("Failed to add status message: %v", err)
+		}
 	}
-	return m.statusMessages[:limit], nil
-}
-
-// Implement required interface methods (not used in these tests)
-func (m *MockStorageForStatusTest) Initialize(ctx context.Context) error  { return nil }
-func (m *MockStorageForStatusTest) Close() error                          { return nil }
-func (m *MockStorageForStatusTest) HealthCheck(ctx context.Context) error { return nil }
-func (m *MockStorageForStatusTest) GetMessageState(ctx context.Context, channelID string, threadID *string) (*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) UpsertMessageState(ctx context.Context, state *storage.MessageState) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetAllMessageStates(ctx context.Context) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) GetThreadOwnership(ctx context.Context, threadID string) (*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) UpsertThreadOwnership(ctx context.Context, ownership *storage.ThreadOwnership) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetAllThreadOwnerships(ctx context.Context) ([]*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetConfiguration(ctx context.Context, key string) (*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) UpsertConfiguration(ctx context.Context, config *storage.Configuration) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetConfigurationsByCategory(ctx context.Context, category string) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) GetAllConfigurations(ctx context.Context) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) DeleteConfiguration(ctx context.Context, key string) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetAllStatusMessages(ctx context.Context) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
-	return len(m.statusMessages), nil
-}
-
-// New rate limiting methods required by interface
-func (m *MockStorageForStatusTest) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) UpsertUserRateLimit(ctx context.Context, rateLimit *storage.UserRateLimit) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
-	return nil
-}
-func (m *MockStorageForStatusTest) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *MockStorageForStatusTest) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
-	return nil
+	return statusStorage
 }
 
 func TestStatusManager_LoadNextBatch(t *testing.T) {
 	// Create mock storage with test data
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Test status one", Enabled: true},
-			{ID: 2, ActivityType: "Playing", StatusText: "Test status two", Enabled: true},
-			{ID: 3, ActivityType: "Listening", StatusText: "Test status three", Enabled: true},
-			{ID: 4, ActivityType: "Watching", StatusText: "Test status four", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Test status one", Enabled: true},
+		{ID: 2, ActivityType: "Playing", StatusText: "Test status two", Enabled: true},
+		{ID: 3, ActivityType: "Listening", StatusText: "Test status three", Enabled: true},
+		{ID: 4, ActivityType: "Watching", StatusText: "Test status four", Enabled: true},
+	})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 4)
@@ -131,12 +53,10 @@ func TestStatusManager_LoadNextBatch(t *testing.T) {
 
 func TestStatusManager_GetRandomStatus(t *testing.T) {
 	// Create mock storage with test data
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Test status one", Enabled: true},
-			{ID: 2, ActivityType: "Listening", StatusText: "Test status two", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Test status one", Enabled: true},
+		{ID: 2, ActivityType: "Listening", StatusText: "Test status two", Enabled: true},
+	})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 2)
@@ -160,9 +80,7 @@ func TestStatusManager_GetRandomStatus(t *testing.T) {
 
 func TestStatusManager_GetRandomStatusFallback(t *testing.T) {
 	// Create mock storage with no data to test fallback
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 5)
@@ -180,12 +98,10 @@ func TestStatusManager_GetRandomStatusFallback(t *testing.T) {
 }
 
 func TestStatusManager_GetStatusCount(t *testing.T) {
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Test status", Enabled: true},
-			{ID: 2, ActivityType: "Listening", StatusText: "Test status two", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Test status", Enabled: true},
+		{ID: 2, ActivityType: "Listening", StatusText: "Test status two", Enabled: true},
+	})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 2)
@@ -210,11 +126,9 @@ func TestStatusManager_GetStatusCount(t *testing.T) {
 }
 
 func TestStatusManager_RefreshBatch(t *testing.T) {
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Test status", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Test status", Enabled: true},
+	})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 1)
@@ -232,9 +146,7 @@ func TestStatusManager_RefreshBatch(t *testing.T) {
 }
 
 func TestInitializeStatusManager(t *testing.T) {
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{})
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
 	// Test initialization
@@ -265,11 +177,9 @@ func TestGetRandomBMADStatus(t *testing.T) {
 	}
 
 	// Test with initialized manager
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Database status", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Database status", Enabled: true},
+	})
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	InitializeStatusManager(mockStorage, logger, 1)
 
@@ -291,12 +201,10 @@ func TestGetStatusCount_Global(t *testing.T) {
 	}
 
 	// Test with initialized manager
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "Playing", StatusText: "Test", Enabled: true},
-			{ID: 2, ActivityType: "Listening", StatusText: "Test 2", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "Playing", StatusText: "Test", Enabled: true},
+		{ID: 2, ActivityType: "Listening", StatusText: "Test 2", Enabled: true},
+	})
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	InitializeStatusManager(mockStorage, logger, 2)
 
@@ -355,12 +263,10 @@ func TestInitRandomSeed(t *testing.T) {
 
 func TestStatusManager_LoadNextBatchWithInvalidActivityType(t *testing.T) {
 	// Test with invalid activity type to improve coverage
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{
-			{ID: 1, ActivityType: "invalid_type", StatusText: "Test status", Enabled: true},
-			{ID: 2, ActivityType: "Playing", StatusText: "Valid status", Enabled: true},
-		},
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{
+		{ID: 1, ActivityType: "invalid_type", StatusText: "Test status", Enabled: true},
+		{ID: 2, ActivityType: "Playing", StatusText: "Valid status", Enabled: true},
+	})
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	statusManager := NewStatusManager(mockStorage, logger, 2)
@@ -381,9 +287,7 @@ func TestStatusManager_LoadNextBatchWithInvalidActivityType(t *testing.T) {
 func TestGetRandomBMADStatus_ErrorCase(t *testing.T) {
 	// Test the error handling path in GetRandomBMADStatus
 	// Initialize with a mock that returns no status messages (causes error)
-	mockStorage := &MockStorageForStatusTest{
-		statusMessages: []*storage.StatusMessage{}, // Empty - will cause error
-	}
+	mockStorage := newStatusTestStorage(t, []*storage.StatusMessage{}) // Empty - will cause error
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	InitializeStatusManager(mockStorage, logger, 1)
 
@@ -398,3 +302,29 @@ func TestGetRandomBMADStatus_ErrorCase(t *testing.T) {
 	// Reset for other tests
 	globalStatusManager = nil
 }
+
+func TestStatusRotator_SetInterval(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rotator := NewStatusRotator(nil, logger)
+
+	rotator.SetInterval(time.Minute)
+	rotator.SetInterval(2 * time.Minute)
+	if rotator.GetInterval() != 2*time.Minute {
+		t.Errorf("Expected interval 2m, got %v", rotator.GetInterval())
+	}
+
+	// Successive changes coalesce into a single wake-up of the rotation loop
+	select {
+	case <-rotator.intervalChanged:
+	default:
+		t.Fatal("Expected the rotation loop to be notified of the new interval")
+	}
+
+	// Setting the same interval again does not wake the loop
+	rotator.SetInterval(2 * time.Minute)
+	select {
+	case <-rotator.intervalChanged:
+		t.Error("Did not expect a notification for an unchanged interval")
+	default:
+	}
+}
@@ -4,26 +4,69 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
 
+	"bmad-knowledge-bot/internal/config"
 	"bmad-knowledge-bot/internal/storage"
 )
 
 // ChannelRestrictor handles channel-based restrictions for bot operations
 type ChannelRestrictor struct {
-	storage storage.StorageService
-	logger  *slog.Logger
+	storage       storage.StorageService
+	configService config.ConfigService // Resolves settings through its cache when set
+	logger        *slog.Logger
 }
 
+// Channel restriction modes for CHANNEL_RESTRICTION_MODE
+const (
+	RestrictionModeAllowlist = "allowlist"
+	RestrictionModeDenylist  = "denylist"
+)
+
+// ChannelTriggerMode controls which messages the bot answers in a channel
+type ChannelTriggerMode string
+
+// Channel trigger modes for CHANNEL_TRIGGER_MODES
+const (
+	TriggerModeMentionOnly ChannelTriggerMode = "mention-only" // Answer mentions, replies and bot threads (default)
+	TriggerModeAutoRespond ChannelTriggerMode = "auto-respond" // Answer every message
+	TriggerModeDisabled    ChannelTriggerMode = "disabled"     // Never answer
+)
+
 // ChannelRestrictions represents the channel restriction configuration
 type ChannelRestrictions struct {
 	AllowedChannelIDs  []string
+	DeniedChannelIDs   []string
+	Mode               string                        // RestrictionModeAllowlist or RestrictionModeDenylist
+	TriggerModes       map[string]ChannelTriggerMode // Channel or category ID to trigger mode
 	RestrictDMs        bool
 	AdminBypassEnabled bool
 	Enabled            bool
 }
 
+// ChannelLocation identifies where a message was posted. Threads inherit rules from their parent channel,
+// and channels from their category.
+type ChannelLocation struct {
+	GuildID    string
+	ChannelID  string
+	ParentID   string // Parent channel when ChannelID is a thread
+	CategoryID string // Category containing the channel (or the thread's parent)
+	IsDM       bool
+}
+
+// lineage returns the IDs rules are matched against, most specific first
+func (l ChannelLocation) lineage() []string {
+	var ids []string
+	for _, id := range []string{l.ChannelID, l.ParentID, l.CategoryID} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // NewChannelRestrictor creates a new channel restrictor
 func NewChannelRestrictor(storage storage.StorageService, logger *slog.Logger) *ChannelRestrictor {
 	return &ChannelRestrictor{
@@ -32,10 +75,10 @@ func NewChannelRestrictor(storage storage.StorageService, logger *slog.Logger) *
 	}
 }
 
-// IsChannelAllowed checks if a channel is allowed for bot operations
-func (cr *ChannelRestrictor) IsChannelAllowed(ctx context.Context, channelID string, isDM bool) (bool, error) {
+// IsChannelAllowed checks if a location is allowed for bot operations, using its guild's overrides where set
+func (cr *ChannelRestrictor) IsChannelAllowed(ctx context.Context, location ChannelLocation) (bool, error) {
 	// Get channel restriction configuration
-	restrictions, err := cr.getChannelRestrictions(ctx)
+	restrictions, err := cr.getChannelRestrictions(ctx, location.GuildID)
 	if err != nil {
 		cr.logger.Error("Failed to get channel restrictions", "error", err)
 		// Default to allowing if config fails to load
@@ -48,34 +91,46 @@ func (cr *ChannelRestrictor) IsChannelAllowed(ctx context.Context, channelID str
 	}
 
 	// Handle DM channels
-	if isDM {
+	if location.IsDM {
 		// DMs are allowed by default unless explicitly restricted
 		return !restrictions.RestrictDMs, nil
 	}
 
+	if restrictions.Mode == RestrictionModeDenylist {
+		if matched := matchLineage(location, restrictions.DeniedChannelIDs); matched != "" {
+			cr.logger.Debug("Channel is in denied list",
+				"guild_id", location.GuildID,
+				"channel_id", location.ChannelID,
+				"matched_id", matched)
+			return false, nil
+		}
+		return true, nil
+	}
+
 	// If no allowed channels configured, allow all (empty list means no restrictions)
 	if len(restrictions.AllowedChannelIDs) == 0 {
 		return true, nil
 	}
 
-	// Check if channel is in allowed list
-	for _, allowedID := range restrictions.AllowedChannelIDs {
-		if channelID == allowedID {
-			return true, nil
-		}
+	// Check if the channel, its parent or its category is in the allowed list
+	if matchLineage(location, restrictions.AllowedChannelIDs) != "" {
+		return true, nil
 	}
 
 	// Channel not in allowed list
 	cr.logger.Debug("Channel not in allowed list",
-		"channel_id", channelID,
+		"guild_id", location.GuildID,
+		"channel_id", location.ChannelID,
+		"parent_id", location.ParentID,
+		"category_id", location.CategoryID,
 		"allowed_channels", len(restrictions.AllowedChannelIDs))
 	return false, nil
 }
 
-// IsChannelAllowedForAdmin checks if a channel is allowed for admin users (with bypass)
-func (cr *ChannelRestrictor) IsChannelAllowedForAdmin(ctx context.Context, channelID string, isDM bool, isAdmin bool) (bool, error) {
+// IsChannelAllowedForAdmin checks if a location is allowed for admin users (with bypass)
+func (cr *ChannelRestrictor) IsChannelAllowedForAdmin(ctx context.Context, location ChannelLocation, isAdmin bool) (bool, error) {
 	// Get channel restriction configuration
-	restrictions, err := cr.getChannelRestrictions(ctx)
+	restrictions, err := cr.getChannelRestrictions(ctx, location.GuildID)
 	if err != nil {
 		cr.logger.Error("Failed to get channel restrictions", "error", err)
 		// Default to allowing if config fails to load
@@ -85,130 +140,205 @@ func (cr *ChannelRestrictor) IsChannelAllowedForAdmin(ctx context.Context, chann
 	// If admin bypass is enabled and user is admin, allow all channels
 	if restrictions.AdminBypassEnabled && isAdmin {
 		cr.logger.Debug("Admin bypass enabled for channel restriction",
-			"channel_id", channelID,
-			"is_dm", isDM)
+			"channel_id", location.ChannelID,
+			"is_dm", location.IsDM)
 		return true, nil
 	}
 
 	// Otherwise, use normal channel restriction logic
-	return cr.IsChannelAllowed(ctx, channelID, isDM)
+	return cr.IsChannelAllowed(ctx, location)
+}
+
+// TriggerMode returns the trigger mode for a location: the mode set for the channel, else its parent, else its category.
+// Trigger modes apply whether or not channel restrictions are enabled.
+func (cr *ChannelRestrictor) TriggerMode(ctx context.Context, location ChannelLocation) ChannelTriggerMode {
+	if location.IsDM {
+		return TriggerModeMentionOnly
+	}
+
+	restrictions, err := cr.getChannelRestrictions(ctx, location.GuildID)
+	if err != nil {
+		cr.logger.Error("Failed to get channel restrictions", "error", err)
+		return TriggerModeMentionOnly
+	}
+
+	for _, id := range location.lineage() {
+		if mode, ok := restrictions.TriggerModes[id]; ok {
+			return mode
+		}
+	}
+	return TriggerModeMentionOnly
+}
+
+// matchLineage returns the first of the location's channel, parent and category IDs found in ids
+func matchLineage(location ChannelLocation, ids []string) string {
+	for _, id := range location.lineage() {
+		for _, listed := range ids {
+			if id == listed {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// SetConfigService resolves restriction settings through the config service's cache instead of reading
+// storage on every message
+func (cr *ChannelRestrictor) SetConfigService(configService config.ConfigService) {
+	cr.configService = configService
+}
+
+// resolveSetting returns guildID's value for key, falling back to the global value
+func (cr *ChannelRestrictor) resolveSetting(ctx context.Context, guildID, key string) (string, bool) {
+	if cr.configService != nil {
+		value, err := cr.configService.GetGuildConfig(ctx, guildID, key)
+		if err != nil {
+			return "", false
+		}
+		return value, true
+	}
+
+	setting, err := storage.ResolveConfiguration(ctx, cr.storage, guildID, key)
+	if err != nil || setting == nil {
+		return "", false
+	}
+	return setting.Value, true
+}
+
+// resolveBoolSetting returns guildID's boolean value for key, or false when unset or invalid
+func (cr *ChannelRestrictor) resolveBoolSetting(ctx context.Context, guildID, key string) bool {
+	value, ok := cr.resolveSetting(ctx, guildID, key)
+	if !ok {
+		return false
+	}
+	enabled, err := config.ParseBool(value)
+	if err != nil {
+		cr.logger.Warn("Ignoring invalid channel restriction setting", "guild_id", guildID, "key", key, "value", value)
+		return false
+	}
+	return enabled
 }
 
-// GetChannelRestrictions returns the current channel restriction configuration
-func (cr *ChannelRestrictor) GetChannelRestrictions(ctx context.Context) (*ChannelRestrictions, error) {
-	return cr.getChannelRestrictions(ctx)
+// GetChannelRestrictions returns the channel restriction configuration in effect for guildID (empty for the global configuration)
+func (cr *ChannelRestrictor) GetChannelRestrictions(ctx context.Context, guildID string) (*ChannelRestrictions, error) {
+	return cr.getChannelRestrictions(ctx, guildID)
 }
 
-// getChannelRestrictions retrieves channel restrictions from database configuration
-func (cr *ChannelRestrictor) getChannelRestrictions(ctx context.Context) (*ChannelRestrictions, error) {
+// getChannelRestrictions retrieves channel restrictions from database configuration, preferring guildID's overrides
+func (cr *ChannelRestrictor) getChannelRestrictions(ctx context.Context, guildID string) (*ChannelRestrictions, error) {
 	restrictions := &ChannelRestrictions{
 		AllowedChannelIDs:  []string{},
+		DeniedChannelIDs:   []string{},
+		Mode:               RestrictionModeAllowlist,
+		TriggerModes:       map[string]ChannelTriggerMode{},
 		RestrictDMs:        false,
 		AdminBypassEnabled: false,
 		Enabled:            false,
 	}
 
-	// Get enabled status
-	enabledConfig, err := cr.storage.GetConfiguration(ctx, "CHANNEL_RESTRICTIONS_ENABLED")
-	if err == nil && enabledConfig != nil && enabledConfig.Value == "true" {
-		restrictions.Enabled = true
+	// Get per-channel trigger modes, which apply even when restrictions are disabled
+	if modes, ok := cr.resolveSetting(ctx, guildID, "CHANNEL_TRIGGER_MODES"); ok {
+		restrictions.TriggerModes = parseTriggerModes(modes)
 	}
 
-	// If not enabled, return early with defaults
-	if !restrictions.Enabled {
-		return restrictions, nil
-	}
+	// Get enabled status. The remaining settings are loaded even when disabled so that writing the
+	// restrictions back, e.g. to change one setting, keeps the stored lists; callers check Enabled.
+	restrictions.Enabled = cr.resolveBoolSetting(ctx, guildID, "CHANNEL_RESTRICTIONS_ENABLED")
 
-	// Get allowed channel IDs
-	channelsConfig, err := cr.storage.GetConfiguration(ctx, "ALLOWED_CHANNEL_IDS")
-	if err == nil && channelsConfig != nil && channelsConfig.Value != "" {
-		for _, channelID := range strings.Split(channelsConfig.Value, ",") {
-			trimmed := strings.TrimSpace(channelID)
-			if trimmed != "" {
-				restrictions.AllowedChannelIDs = append(restrictions.AllowedChannelIDs, trimmed)
-			}
-		}
+	// Get restriction mode
+	if mode, ok := cr.resolveSetting(ctx, guildID, "CHANNEL_RESTRICTION_MODE"); ok && strings.EqualFold(strings.TrimSpace(mode), RestrictionModeDenylist) {
+		restrictions.Mode = RestrictionModeDenylist
 	}
 
-	// Get DM restriction setting
-	dmConfig, err := cr.storage.GetConfiguration(ctx, "RESTRICT_DMS")
-	if err == nil && dmConfig != nil && dmConfig.Value == "true" {
-		restrictions.RestrictDMs = true
+	// Get allowed and denied channel IDs
+	if channels, ok := cr.resolveSetting(ctx, guildID, "ALLOWED_CHANNEL_IDS"); ok {
+		restrictions.AllowedChannelIDs = append(restrictions.AllowedChannelIDs, splitConfigList(channels)...)
 	}
+	if denied, ok := cr.resolveSetting(ctx, guildID, "DENIED_CHANNEL_IDS"); ok {
+		restrictions.DeniedChannelIDs = append(restrictions.DeniedChannelIDs, splitConfigList(denied)...)
+	}
+
+	// Get DM restriction setting, which is global only
+	restrictions.RestrictDMs = cr.resolveBoolSetting(ctx, "", "RESTRICT_DMS")
 
 	// Get admin bypass setting
-	bypassConfig, err := cr.storage.GetConfiguration(ctx, "ADMIN_CHANNEL_BYPASS_ENABLED")
-	if err == nil && bypassConfig != nil && bypassConfig.Value == "true" {
-		restrictions.AdminBypassEnabled = true
-	}
+	restrictions.AdminBypassEnabled = cr.resolveBoolSetting(ctx, guildID, "ADMIN_CHANNEL_BYPASS_ENABLED")
 
 	return restrictions, nil
 }
 
-// UpdateChannelRestrictions updates the channel restriction configuration
+// parseTriggerModes parses CHANNEL_TRIGGER_MODES ("id:mode,id:mode"), skipping malformed pairs
+func parseTriggerModes(value string) map[string]ChannelTriggerMode {
+	modes := make(map[string]ChannelTriggerMode)
+	for _, pair := range splitConfigList(value) {
+		id, mode, found := strings.Cut(pair, ":")
+		if !found {
+			continue
+		}
+		switch triggerMode := ChannelTriggerMode(strings.ToLower(strings.TrimSpace(mode))); triggerMode {
+		case TriggerModeMentionOnly, TriggerModeAutoRespond, TriggerModeDisabled:
+			modes[strings.TrimSpace(id)] = triggerMode
+		}
+	}
+	return modes
+}
+
+// formatTriggerModes serializes trigger modes as "id:mode" pairs sorted by ID
+func formatTriggerModes(modes map[string]ChannelTriggerMode) string {
+	ids := make([]string, 0, len(modes))
+	for id := range modes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	pairs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		pairs = append(pairs, id+":"+string(modes[id]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// UpdateChannelRestrictions updates the global channel restriction configuration. Each changed setting is written
+// through the config service, so the change is validated, recorded in the configuration history with the actor
+// carried by ctx and announced to configuration listeners.
 func (cr *ChannelRestrictor) UpdateChannelRestrictions(ctx context.Context, restrictions *ChannelRestrictions) error {
-	now := time.Now().Unix()
-
-	// Update enabled status
-	enabledConfig := &storage.Configuration{
-		Key:         "CHANNEL_RESTRICTIONS_ENABLED",
-		Value:       fmt.Sprintf("%t", restrictions.Enabled),
-		Type:        "bool",
-		Category:    "channel_restrictions",
-		Description: "Enable or disable channel restrictions for bot operations",
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}
-	if err := cr.storage.UpsertConfiguration(ctx, enabledConfig); err != nil {
-		return fmt.Errorf("failed to update channel restrictions enabled: %w", err)
-	}
-
-	// Update allowed channel IDs
-	channelIDsStr := strings.Join(restrictions.AllowedChannelIDs, ",")
-	channelsConfig := &storage.Configuration{
-		Key:         "ALLOWED_CHANNEL_IDS",
-		Value:       channelIDsStr,
-		Type:        "string",
-		Category:    "channel_restrictions",
-		Description: "Comma-separated list of allowed channel IDs",
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}
-	if err := cr.storage.UpsertConfiguration(ctx, channelsConfig); err != nil {
-		return fmt.Errorf("failed to update allowed channel IDs: %w", err)
-	}
-
-	// Update DM restriction
-	dmConfig := &storage.Configuration{
-		Key:         "RESTRICT_DMS",
-		Value:       fmt.Sprintf("%t", restrictions.RestrictDMs),
-		Type:        "bool",
-		Category:    "channel_restrictions",
-		Description: "Restrict bot operations in DM channels",
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}
-	if err := cr.storage.UpsertConfiguration(ctx, dmConfig); err != nil {
-		return fmt.Errorf("failed to update DM restrictions: %w", err)
-	}
-
-	// Update admin bypass
-	bypassConfig := &storage.Configuration{
-		Key:         "ADMIN_CHANNEL_BYPASS_ENABLED",
-		Value:       fmt.Sprintf("%t", restrictions.AdminBypassEnabled),
-		Type:        "bool",
-		Category:    "channel_restrictions",
-		Description: "Allow admin users to bypass channel restrictions",
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}
-	if err := cr.storage.UpsertConfiguration(ctx, bypassConfig); err != nil {
-		return fmt.Errorf("failed to update admin bypass: %w", err)
+	if cr.configService == nil {
+		return fmt.Errorf("configuration service not available")
+	}
+
+	mode := restrictions.Mode
+	if mode == "" {
+		mode = RestrictionModeAllowlist
+	}
+
+	settings := []struct {
+		key   string
+		value string
+	}{
+		{"CHANNEL_RESTRICTIONS_ENABLED", strconv.FormatBool(restrictions.Enabled)},
+		{"ALLOWED_CHANNEL_IDS", strings.Join(restrictions.AllowedChannelIDs, ",")},
+		{"DENIED_CHANNEL_IDS", strings.Join(restrictions.DeniedChannelIDs, ",")},
+		{"CHANNEL_RESTRICTION_MODE", mode},
+		{"CHANNEL_TRIGGER_MODES", formatTriggerModes(restrictions.TriggerModes)},
+		{"RESTRICT_DMS", strconv.FormatBool(restrictions.RestrictDMs)},
+		{"ADMIN_CHANNEL_BYPASS_ENABLED", strconv.FormatBool(restrictions.AdminBypassEnabled)},
+	}
+	for _, setting := range settings {
+		if cr.currentSetting(ctx, setting.key) == setting.value {
+			continue
+		}
+		// The registry supplies each key's type, category and description
+		if err := cr.configService.SetConfigTyped(ctx, setting.key, setting.value, "", "", ""); err != nil {
+			return fmt.Errorf("failed to update %s: %w", setting.key, err)
+		}
 	}
 
 	cr.logger.Info("Updated channel restrictions",
 		"enabled", restrictions.Enabled,
+		"mode", mode,
 		"allowed_channels", len(restrictions.AllowedChannelIDs),
+		"denied_channels", len(restrictions.DeniedChannelIDs),
+		"trigger_modes", len(restrictions.TriggerModes),
 		"restrict_dms", restrictions.RestrictDMs,
 		"admin_bypass", restrictions.AdminBypassEnabled)
 
@@ -218,24 +348,26 @@ func (cr *ChannelRestrictor) UpdateChannelRestrictions(ctx context.Context, rest
 // FormatChannelRestrictionsStatus creates a user-friendly status message
 func (cr *ChannelRestrictor) FormatChannelRestrictionsStatus(restrictions *ChannelRestrictions) string {
 	if !restrictions.Enabled {
-		return "🟢 **Channel Restrictions:** Disabled - Bot responds in all channels"
+		return "🟢 **Channel Restrictions:** Disabled - Bot responds in all channels\n" + formatTriggerModesStatus(restrictions.TriggerModes)
 	}
 
 	msg := "🔒 **Channel Restrictions:** Enabled\n"
 
-	if len(restrictions.AllowedChannelIDs) == 0 {
+	if restrictions.Mode == RestrictionModeDenylist {
+		msg += "• **Mode:** Denylist - Bot responds everywhere except denied channels and categories\n"
+		if len(restrictions.DeniedChannelIDs) == 0 {
+			msg += "• **Denied Channels:** None\n"
+		} else {
+			msg += fmt.Sprintf("• **Denied Channels:** %d channel(s) or categories configured\n", len(restrictions.DeniedChannelIDs))
+			msg += formatChannelList(restrictions.DeniedChannelIDs)
+		}
+	} else if len(restrictions.AllowedChannelIDs) == 0 {
 		msg += "• **Allowed Channels:** All channels (no restrictions)\n"
 	} else {
 		msg += fmt.Sprintf("• **Allowed Channels:** %d channel(s) configured\n", len(restrictions.AllowedChannelIDs))
-		for i, channelID := range restrictions.AllowedChannelIDs {
-			if i < 5 { // Show first 5 channels
-				msg += fmt.Sprintf("  - <#%s>\n", channelID)
-			} else if i == 5 {
-				msg += fmt.Sprintf("  - ... and %d more\n", len(restrictions.AllowedChannelIDs)-5)
-				break
-			}
-		}
+		msg += formatChannelList(restrictions.AllowedChannelIDs)
 	}
+	msg += "• **Threads:** Follow their parent channel's rules\n"
 
 	if restrictions.RestrictDMs {
 		msg += "• **DM Messages:** Restricted\n"
@@ -249,5 +381,42 @@ func (cr *ChannelRestrictor) FormatChannelRestrictionsStatus(restrictions *Chann
 		msg += "• **Admin Bypass:** Disabled - Restrictions apply to all users\n"
 	}
 
+	return msg + formatTriggerModesStatus(restrictions.TriggerModes)
+}
+
+// formatChannelList lists up to the first 5 channels as mentions
+func formatChannelList(channelIDs []string) string {
+	msg := ""
+	for i, channelID := range channelIDs {
+		if i < 5 { // Show first 5 channels
+			msg += fmt.Sprintf("  - <#%s>\n", channelID)
+		} else {
+			msg += fmt.Sprintf("  - ... and %d more\n", len(channelIDs)-5)
+			break
+		}
+	}
+	return msg
+}
+
+// formatTriggerModesStatus lists the channels with a trigger mode other than the default
+func formatTriggerModesStatus(modes map[string]ChannelTriggerMode) string {
+	if len(modes) == 0 {
+		return "• **Trigger Mode:** mention-only in all channels\n"
+	}
+
+	msg := fmt.Sprintf("• **Trigger Modes:** %d channel(s) or categories configured (others are mention-only)\n", len(modes))
+	for _, pair := range strings.Split(formatTriggerModes(modes), ",") {
+		id, mode, _ := strings.Cut(pair, ":")
+		msg += fmt.Sprintf("  - <#%s>: %s\n", id, mode)
+	}
 	return msg
 }
+
+// currentSetting returns the stored global value of key, or its registry default when unset
+func (cr *ChannelRestrictor) currentSetting(ctx context.Context, key string) string {
+	if value, err := cr.configService.GetConfig(ctx, key); err == nil {
+		return value
+	}
+	schema, _ := config.LookupConfigSchema(key)
+	return schema.Default
+}
@@ -2,110 +2,17 @@ package bot
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"testing"
-	"time"
 
+	"bmad-knowledge-bot/internal/config"
 	"bmad-knowledge-bot/internal/storage"
 	"log/slog"
 	"os"
 )
 
-// mockStorageForChannelRestrictor implements minimal storage interface for testing
-type mockStorageForChannelRestrictor struct {
-	configurations map[string]*storage.Configuration
-}
-
-func newMockStorageForChannelRestrictor() *mockStorageForChannelRestrictor {
-	return &mockStorageForChannelRestrictor{
-		configurations: make(map[string]*storage.Configuration),
-	}
-}
-
-func (m *mockStorageForChannelRestrictor) GetConfiguration(ctx context.Context, key string) (*storage.Configuration, error) {
-	config, exists := m.configurations[key]
-	if !exists {
-		return nil, fmt.Errorf("configuration not found: %s", key)
-	}
-	return config, nil
-}
-
-func (m *mockStorageForChannelRestrictor) UpsertConfiguration(ctx context.Context, config *storage.Configuration) error {
-	m.configurations[config.Key] = config
-	return nil
-}
-
-// Implement required interface methods (simplified for testing)
-func (m *mockStorageForChannelRestrictor) Initialize(ctx context.Context) error { return nil }
-func (m *mockStorageForChannelRestrictor) Close() error                         { return nil }
-func (m *mockStorageForChannelRestrictor) GetMessageState(ctx context.Context, channelID string, threadID *string) (*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) UpsertMessageState(ctx context.Context, state *storage.MessageState) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetAllMessageStates(ctx context.Context) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) HealthCheck(ctx context.Context) error { return nil }
-func (m *mockStorageForChannelRestrictor) GetThreadOwnership(ctx context.Context, threadID string) (*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) UpsertThreadOwnership(ctx context.Context, ownership *storage.ThreadOwnership) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetAllThreadOwnerships(ctx context.Context) ([]*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetConfigurationsByCategory(ctx context.Context, category string) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) GetAllConfigurations(ctx context.Context) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) DeleteConfiguration(ctx context.Context, key string) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetAllStatusMessages(ctx context.Context) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
-	return 0, nil
-}
-func (m *mockStorageForChannelRestrictor) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) UpsertUserRateLimit(ctx context.Context, rateLimit *storage.UserRateLimit) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
-	return nil
-}
-func (m *mockStorageForChannelRestrictor) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *mockStorageForChannelRestrictor) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
-	return nil
-}
-
 func TestNewChannelRestrictor(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 	restrictor := NewChannelRestrictor(mockStorage, logger)
@@ -116,14 +23,14 @@ func TestNewChannelRestrictor(t *testing.T) {
 }
 
 func TestIsChannelAllowed_RestrictionsDisabled(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
 	// No configuration means restrictions are disabled
 	ctx := context.Background()
 
-	allowed, err := restrictor.IsChannelAllowed(ctx, "channel123", false)
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "channel123"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -134,7 +41,7 @@ func TestIsChannelAllowed_RestrictionsDisabled(t *testing.T) {
 }
 
 func TestIsChannelAllowed_DMChannels(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -151,7 +58,7 @@ func TestIsChannelAllowed_DMChannels(t *testing.T) {
 	ctx := context.Background()
 
 	// Test DM channel
-	allowed, err := restrictor.IsChannelAllowed(ctx, "dm_channel", true)
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "dm_channel", IsDM: true})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -162,7 +69,7 @@ func TestIsChannelAllowed_DMChannels(t *testing.T) {
 }
 
 func TestIsChannelAllowed_RestrictDMs(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -179,7 +86,7 @@ func TestIsChannelAllowed_RestrictDMs(t *testing.T) {
 	ctx := context.Background()
 
 	// Test DM channel
-	allowed, err := restrictor.IsChannelAllowed(ctx, "dm_channel", true)
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "dm_channel", IsDM: true})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -190,7 +97,7 @@ func TestIsChannelAllowed_RestrictDMs(t *testing.T) {
 }
 
 func TestIsChannelAllowed_AllowedChannelList(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -207,7 +114,7 @@ func TestIsChannelAllowed_AllowedChannelList(t *testing.T) {
 	ctx := context.Background()
 
 	// Test allowed channel
-	allowed, err := restrictor.IsChannelAllowed(ctx, "channel123", false)
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "channel123"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -216,7 +123,7 @@ func TestIsChannelAllowed_AllowedChannelList(t *testing.T) {
 	}
 
 	// Test non-allowed channel
-	allowed, err = restrictor.IsChannelAllowed(ctx, "channel789", false)
+	allowed, err = restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "channel789"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -225,8 +132,173 @@ func TestIsChannelAllowed_AllowedChannelList(t *testing.T) {
 	}
 }
 
+func TestIsChannelAllowed_GuildOverrides(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	restrictor := NewChannelRestrictor(mockStorage, logger)
+	ctx := context.Background()
+
+	// Restrictions are enabled globally; guild-a narrows the allowed channels and guild-b opts out
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "CHANNEL_RESTRICTIONS_ENABLED", Value: "true"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "ALLOWED_CHANNEL_IDS", Value: "channel123"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "guild-a", Key: "ALLOWED_CHANNEL_IDS", Value: "channel456"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "guild-b", Key: "CHANNEL_RESTRICTIONS_ENABLED", Value: "false"})
+
+	testCases := []struct {
+		guildID   string
+		channelID string
+		allowed   bool
+	}{
+		{"guild-a", "channel456", true},
+		{"guild-a", "channel123", false},
+		{"guild-b", "channel789", true},
+		{"guild-c", "channel123", true},
+		{"guild-c", "channel456", false},
+	}
+
+	for _, tc := range testCases {
+		allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{GuildID: tc.guildID, ChannelID: tc.channelID})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if allowed != tc.allowed {
+			t.Errorf("IsChannelAllowed(%s, %s) = %v, want %v", tc.guildID, tc.channelID, allowed, tc.allowed)
+		}
+	}
+
+	restrictions, err := restrictor.GetChannelRestrictions(ctx, "guild-a")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(restrictions.AllowedChannelIDs) != 1 || restrictions.AllowedChannelIDs[0] != "channel456" {
+		t.Errorf("Expected guild-a's allowed channels, got %v", restrictions.AllowedChannelIDs)
+	}
+}
+
+func TestIsChannelAllowed_ThroughConfigService(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	restrictor := NewChannelRestrictor(mockStorage, logger)
+	configService := config.NewDatabaseConfigService(mockStorage)
+	restrictor.SetConfigService(configService)
+	ctx := context.Background()
+
+	if err := configService.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	// Boolean overrides are read with the same spellings validation accepts
+	if err := configService.SetGuildConfig(ctx, "guild-a", "CHANNEL_RESTRICTIONS_ENABLED", "yes"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+	if err := configService.SetGuildConfig(ctx, "guild-a", "ALLOWED_CHANNEL_IDS", "123456789012345678"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+
+	if allowed, _ := restrictor.IsChannelAllowed(ctx, ChannelLocation{GuildID: "guild-a", ChannelID: "876543210987654321"}); allowed {
+		t.Error("Expected the 'yes' guild override to enable restrictions")
+	}
+	if allowed, _ := restrictor.IsChannelAllowed(ctx, ChannelLocation{GuildID: "guild-b", ChannelID: "876543210987654321"}); !allowed {
+		t.Error("Expected guilds without overrides to be unrestricted")
+	}
+
+	// Global changes written by UpdateChannelRestrictions are visible through the cache at once
+	err := restrictor.UpdateChannelRestrictions(ctx, &ChannelRestrictions{Enabled: true, AllowedChannelIDs: []string{"123456789012345678"}})
+	if err != nil {
+		t.Fatalf("UpdateChannelRestrictions failed: %v", err)
+	}
+	if allowed, _ := restrictor.IsChannelAllowed(ctx, ChannelLocation{GuildID: "guild-b", ChannelID: "channel456"}); allowed {
+		t.Error("Expected updated global restrictions to apply")
+	}
+}
+
+func TestIsChannelAllowed_CategoriesAndThreads(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	restrictor := NewChannelRestrictor(mockStorage, logger)
+	ctx := context.Background()
+
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "CHANNEL_RESTRICTIONS_ENABLED", Value: "true"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "ALLOWED_CHANNEL_IDS", Value: "channel123,category-help"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "DENIED_CHANNEL_IDS", Value: "channel-offtopic,category-staff"})
+
+	testCases := []struct {
+		name     string
+		location ChannelLocation
+		allowed  bool
+	}{
+		{"allowed channel", ChannelLocation{ChannelID: "channel123"}, true},
+		{"channel in allowed category", ChannelLocation{ChannelID: "channel456", CategoryID: "category-help"}, true},
+		{"thread inherits allowed parent", ChannelLocation{ChannelID: "thread1", ParentID: "channel123"}, true},
+		{"thread inherits allowed category", ChannelLocation{ChannelID: "thread2", ParentID: "channel456", CategoryID: "category-help"}, true},
+		{"thread of other channel", ChannelLocation{ChannelID: "thread3", ParentID: "channel789", CategoryID: "category-general"}, false},
+	}
+	for _, tc := range testCases {
+		allowed, err := restrictor.IsChannelAllowed(ctx, tc.location)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if allowed != tc.allowed {
+			t.Errorf("allowlist %s: got %v, want %v", tc.name, allowed, tc.allowed)
+		}
+	}
+
+	// In denylist mode the denied list applies and the allowed list is ignored
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "CHANNEL_RESTRICTION_MODE", Value: "denylist"})
+	testCases = []struct {
+		name     string
+		location ChannelLocation
+		allowed  bool
+	}{
+		{"unlisted channel", ChannelLocation{ChannelID: "channel789"}, true},
+		{"denied channel", ChannelLocation{ChannelID: "channel-offtopic"}, false},
+		{"thread inherits denied parent", ChannelLocation{ChannelID: "thread1", ParentID: "channel-offtopic"}, false},
+		{"channel in denied category", ChannelLocation{ChannelID: "channel456", CategoryID: "category-staff"}, false},
+		{"DM", ChannelLocation{ChannelID: "dm_channel", IsDM: true}, true},
+	}
+	for _, tc := range testCases {
+		allowed, err := restrictor.IsChannelAllowed(ctx, tc.location)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if allowed != tc.allowed {
+			t.Errorf("denylist %s: got %v, want %v", tc.name, allowed, tc.allowed)
+		}
+	}
+}
+
+func TestTriggerMode(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	restrictor := NewChannelRestrictor(mockStorage, logger)
+	ctx := context.Background()
+
+	// Trigger modes apply even though restrictions are disabled; malformed pairs are skipped
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{Key: "CHANNEL_TRIGGER_MODES", Value: "channel-help:auto-respond, category-archive:disabled, channel-archive-help:mention-only, bogus, channel-x:sometimes"})
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "guild-a", Key: "CHANNEL_TRIGGER_MODES", Value: "channel-help:disabled"})
+
+	testCases := []struct {
+		name     string
+		location ChannelLocation
+		mode     ChannelTriggerMode
+	}{
+		{"default", ChannelLocation{ChannelID: "channel123"}, TriggerModeMentionOnly},
+		{"channel", ChannelLocation{ChannelID: "channel-help"}, TriggerModeAutoRespond},
+		{"thread inherits parent", ChannelLocation{ChannelID: "thread1", ParentID: "channel-help"}, TriggerModeAutoRespond},
+		{"category", ChannelLocation{ChannelID: "channel456", CategoryID: "category-archive"}, TriggerModeDisabled},
+		{"channel overrides category", ChannelLocation{ChannelID: "channel-archive-help", CategoryID: "category-archive"}, TriggerModeMentionOnly},
+		{"invalid mode ignored", ChannelLocation{ChannelID: "channel-x"}, TriggerModeMentionOnly},
+		{"DM", ChannelLocation{ChannelID: "channel-help", IsDM: true}, TriggerModeMentionOnly},
+		{"guild override", ChannelLocation{GuildID: "guild-a", ChannelID: "channel-help"}, TriggerModeDisabled},
+	}
+	for _, tc := range testCases {
+		if mode := restrictor.TriggerMode(ctx, tc.location); mode != tc.mode {
+			t.Errorf("%s: got %s, want %s", tc.name, mode, tc.mode)
+		}
+	}
+}
+
 func TestIsChannelAllowedForAdmin_AdminBypass(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -247,7 +319,7 @@ func TestIsChannelAllowedForAdmin_AdminBypass(t *testing.T) {
 	ctx := context.Background()
 
 	// Test admin user in non-allowed channel
-	allowed, err := restrictor.IsChannelAllowedForAdmin(ctx, "channel789", false, true)
+	allowed, err := restrictor.IsChannelAllowedForAdmin(ctx, ChannelLocation{ChannelID: "channel789"}, true)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -256,7 +328,7 @@ func TestIsChannelAllowedForAdmin_AdminBypass(t *testing.T) {
 	}
 
 	// Test non-admin user in non-allowed channel
-	allowed, err = restrictor.IsChannelAllowedForAdmin(ctx, "channel789", false, false)
+	allowed, err = restrictor.IsChannelAllowedForAdmin(ctx, ChannelLocation{ChannelID: "channel789"}, false)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -266,19 +338,34 @@ func TestIsChannelAllowedForAdmin_AdminBypass(t *testing.T) {
 }
 
 func TestUpdateChannelRestrictions(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
 	ctx := context.Background()
 
+	const channel123, channel456, channel789 = "123000000000000000", "456000000000000000", "789000000000000000"
 	restrictions := &ChannelRestrictions{
-		AllowedChannelIDs:  []string{"channel123", "channel456"},
+		AllowedChannelIDs:  []string{channel123, channel456},
+		DeniedChannelIDs:   []string{channel789},
+		Mode:               RestrictionModeDenylist,
+		TriggerModes:       map[string]ChannelTriggerMode{channel456: TriggerModeAutoRespond, channel123: TriggerModeDisabled},
 		RestrictDMs:        true,
 		AdminBypassEnabled: true,
 		Enabled:            true,
 	}
 
+	// Changes are only written through the config service
+	if err := restrictor.UpdateChannelRestrictions(ctx, restrictions); err == nil {
+		t.Fatal("Expected an error without a config service")
+	}
+
+	configService := config.NewDatabaseConfigService(mockStorage)
+	if err := configService.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	restrictor.SetConfigService(configService)
+
 	err := restrictor.UpdateChannelRestrictions(ctx, restrictions)
 	if err != nil {
 		t.Fatalf("Unexpected error updating restrictions: %v", err)
@@ -297,13 +384,52 @@ func TestUpdateChannelRestrictions(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting channels config: %v", err)
 	}
-	if channelsConfig.Value != "channel123,channel456" {
-		t.Errorf("Expected channels to be 'channel123,channel456', got %s", channelsConfig.Value)
+	if channelsConfig.Value != channel123+","+channel456 {
+		t.Errorf("Expected channels to be '%s,%s', got %s", channel123, channel456, channelsConfig.Value)
+	}
+
+	for key, expected := range map[string]string{
+		"DENIED_CHANNEL_IDS":       channel789,
+		"CHANNEL_RESTRICTION_MODE": "denylist",
+		"CHANNEL_TRIGGER_MODES":    channel123 + ":disabled," + channel456 + ":auto-respond",
+	} {
+		stored, err := mockStorage.GetConfiguration(ctx, key)
+		if err != nil || stored == nil {
+			t.Fatalf("Error getting %s: %v", key, err)
+		}
+		if stored.Value != expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, expected, stored.Value)
+		}
+	}
+
+	// The stored configuration round-trips
+	loaded, err := restrictor.GetChannelRestrictions(ctx, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Mode != RestrictionModeDenylist || len(loaded.DeniedChannelIDs) != 1 || loaded.TriggerModes[channel456] != TriggerModeAutoRespond {
+		t.Errorf("Expected saved restrictions to load back, got %+v", loaded)
+	}
+
+	// Unchanged settings are not written again
+	history, err := configService.GetConfigHistory(ctx, "", "CHANNEL_RESTRICTION_MODE", 10)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if err := restrictor.UpdateChannelRestrictions(ctx, loaded); err != nil {
+		t.Fatalf("Unexpected error updating restrictions: %v", err)
+	}
+	again, err := configService.GetConfigHistory(ctx, "", "CHANNEL_RESTRICTION_MODE", 10)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(again) != len(history) {
+		t.Errorf("Expected no history for unchanged settings, got %d entries after %d", len(again), len(history))
 	}
 }
 
 func TestGetChannelRestrictions(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -326,7 +452,7 @@ func TestGetChannelRestrictions(t *testing.T) {
 	})
 
 	ctx := context.Background()
-	restrictions, err := restrictor.GetChannelRestrictions(ctx)
+	restrictions, err := restrictor.GetChannelRestrictions(ctx, "")
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -349,7 +475,7 @@ func TestGetChannelRestrictions(t *testing.T) {
 }
 
 func TestFormatChannelRestrictionsStatus_Disabled(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -365,7 +491,7 @@ func TestFormatChannelRestrictionsStatus_Disabled(t *testing.T) {
 }
 
 func TestFormatChannelRestrictionsStatus_Enabled(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -392,7 +518,7 @@ func TestFormatChannelRestrictionsStatus_Enabled(t *testing.T) {
 }
 
 func TestChannelRestrictions_EmptyAllowedList(t *testing.T) {
-	mockStorage := newMockStorageForChannelRestrictor()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	restrictor := NewChannelRestrictor(mockStorage, logger)
 
@@ -409,7 +535,7 @@ func TestChannelRestrictions_EmptyAllowedList(t *testing.T) {
 	ctx := context.Background()
 
 	// Any channel should be allowed when no specific channels are configured
-	allowed, err := restrictor.IsChannelAllowed(ctx, "any_channel", false)
+	allowed, err := restrictor.IsChannelAllowed(ctx, ChannelLocation{ChannelID: "any_channel"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -0,0 +1,511 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bmad-knowledge-bot/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CommandPrefix is the prefix for text-based bot commands (e.g. "!admin-help")
+const CommandPrefix = "!"
+
+// adminCommandNames lists the commands routed to AdminCommands
+var adminCommandNames = map[string]bool{
+	"ratelimit-status":     true,
+	"ratelimit-reset":      true,
+	"ratelimit-config":     true,
+	"channel-restrictions": true,
+	"feedback-worst":       true,
+	"config-history":       true,
+	"config-rollback":      true,
+	"config-keys":          true,
+	"guild-config":         true,
+	"response-cache":       true,
+	"admin-help":           true,
+}
+
+// parseCommand splits a "!command arg1 arg2" message into its command name and arguments
+func parseCommand(content string) (string, []string, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, CommandPrefix) {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(content, CommandPrefix))
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+
+	return strings.ToLower(fields[0]), fields[1:], true
+}
+
+// SetAdminCommands enables routing of `!` and slash admin commands to the given handler
+func (h *Handler) SetAdminCommands(adminCommands *AdminCommands) {
+	h.adminCommands = adminCommands
+	h.logger.Info("Admin commands configured for handler")
+}
+
+// GetChannelRestrictor returns the channel restrictor used by the handler
+func (h *Handler) GetChannelRestrictor() *ChannelRestrictor {
+	return h.channelRestrictor
+}
+
+// handleTextCommand routes `!command args` messages to AdminCommands.
+// Returns true if the message was a recognised command and has been handled.
+func (h *Handler) handleTextCommand(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if h.adminCommands == nil {
+		return false
+	}
+
+	command, args, ok := parseCommand(m.Content)
+	if !ok || !adminCommandNames[command] {
+		return false
+	}
+
+	h.logger.Info("Admin command received",
+		"command", command,
+		"args_count", len(args),
+		"user_id", m.Author.ID,
+		"guild_id", m.GuildID,
+		"channel_id", m.ChannelID)
+
+	response, err := h.adminCommands.HandleAdminCommand(context.Background(), s, m, command, args)
+	if err != nil {
+		h.logger.Error("Admin command failed", "error", err, "command", command, "user_id", m.Author.ID)
+		response = "❌ Failed to execute admin command."
+	}
+	if response == "" {
+		// Commands from non-admins are ignored without a reply
+		return true
+	}
+
+	// Reply in chunks so long status output respects Discord's 2000 character limit
+	for _, chunk := range h.splitResponseIntoChunks(response, 2000) {
+		if _, err := s.ChannelMessageSendReply(m.ChannelID, chunk, m.Reference()); err != nil {
+			h.logger.Error("Failed to send admin command response", "error", err, "command", command)
+			break
+		}
+	}
+
+	return true
+}
+
+// AdminSlashCommands returns the application (slash) command definitions mirroring the `!` admin commands
+func AdminSlashCommands() []*discordgo.ApplicationCommand {
+	dmPermission := false
+	// Only members who can manage the server see the commands by default; server admins can grant
+	// them to the configured admin roles in the integration settings
+	var adminPermissions int64 = discordgo.PermissionManageServer
+	feedbackWorstMinValue := 1.0
+	configHistoryMinValue := 1.0
+
+	windowChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "all", Value: "all"},
+		{Name: "minute", Value: "minute"},
+		{Name: "hour", Value: "hour"},
+		{Name: "day", Value: "day"},
+	}
+
+	rateLimitSettingChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "minute_limit", Value: "minute_limit"},
+		{Name: "hour_limit", Value: "hour_limit"},
+		{Name: "day_limit", Value: "day_limit"},
+		{Name: "enabled", Value: "enabled"},
+	}
+
+	channelSettingChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "enabled", Value: "enabled"},
+		{Name: "mode", Value: "mode"},
+		{Name: "add_channel", Value: "add_channel"},
+		{Name: "remove_channel", Value: "remove_channel"},
+		{Name: "deny_channel", Value: "deny_channel"},
+		{Name: "undeny_channel", Value: "undeny_channel"},
+		{Name: "trigger_mode", Value: "trigger_mode"},
+		{Name: "restrict_dms", Value: "restrict_dms"},
+		{Name: "admin_bypass", Value: "admin_bypass"},
+	}
+
+	var configCategoryChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, category := range config.ConfigCategories() {
+		configCategoryChoices = append(configCategoryChoices, &discordgo.ApplicationCommandOptionChoice{Name: category, Value: category})
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "ratelimit-status",
+			Description:              "Show rate limit status for a user (omit user for a summary)",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "User to inspect",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "ratelimit-reset",
+			Description:              "Reset rate limits for a user",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "User whose limits should be reset",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "window",
+					Description: "Time window to reset (default: all)",
+					Required:    false,
+					Choices:     windowChoices,
+				},
+			},
+		},
+		{
+			Name:                     "ratelimit-config",
+			Description:              "Show or update user rate limit configuration",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "setting",
+					Description: "Setting to update (omit to show current configuration)",
+					Required:    false,
+					Choices:     rateLimitSettingChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "value",
+					Description: "New value (number for limits, true/false for enabled)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "channel-restrictions",
+			Description:              "Show or update channel restrictions",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "setting",
+					Description: "Setting to update (omit to show current restrictions)",
+					Required:    false,
+					Choices:     channelSettingChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "channel",
+					Description: "Channel or category for list changes and trigger_mode",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "value",
+					Description: "New value (true/false, allowlist/denylist, trigger mode or channel ID)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "feedback-worst",
+			Description:              "List the answers with the most 👎 votes",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "Number of answers to list (default: 10)",
+					Required:    false,
+					MinValue:    &feedbackWorstMinValue,
+					MaxValue:    maxFeedbackWorstLimit,
+				},
+			},
+		},
+		{
+			Name:                     "config-history",
+			Description:              "Show who changed a configuration key and when",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "key",
+					Description: "Configuration key (e.g. USER_RATE_LIMIT_PER_MINUTE)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "Number of changes to list (default: 10)",
+					Required:    false,
+					MinValue:    &configHistoryMinValue,
+					MaxValue:    maxConfigHistoryLimit,
+				},
+			},
+		},
+		{
+			Name:                     "config-rollback",
+			Description:              "Restore a configuration key to the value set by a history version",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "key",
+					Description: "Configuration key to roll back",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "version",
+					Description: "Version number listed by /config-history",
+					Required:    true,
+					MinValue:    &configHistoryMinValue,
+				},
+			},
+		},
+		{
+			Name:                     "config-keys",
+			Description:              "List configurable keys, their types, ranges and defaults",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "category",
+					Description: "Category to list (omit to show all categories)",
+					Required:    false,
+					Choices:     configCategoryChoices,
+				},
+			},
+		},
+		{
+			Name:                     "guild-config",
+			Description:              "Show or change this server's configuration overrides",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Change to make (omit to show current overrides)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "set", Value: "set"},
+						{Name: "unset", Value: "unset"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "key",
+					Description: "Per-server configuration key (e.g. ALLOWED_CHANNEL_IDS)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "value",
+					Description: "New value for set",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "response-cache",
+			Description:              "Show response cache statistics or purge cached answers",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Action to take (omit to show statistics)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "purge", Value: "purge"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "admin-help",
+			Description:              "Show available admin commands",
+			DMPermission:             &dmPermission,
+			DefaultMemberPermissions: &adminPermissions,
+		},
+	}
+}
+
+// RegisterSlashCommands registers the admin slash commands with Discord.
+// An empty guildID registers them globally.
+func (h *Handler) RegisterSlashCommands(s *discordgo.Session, guildID string) error {
+	if s == nil || s.State == nil || s.State.User == nil {
+		return fmt.Errorf("discord session not ready")
+	}
+
+	commands, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, AdminSlashCommands())
+	if err != nil {
+		return fmt.Errorf("failed to register slash commands: %w", err)
+	}
+
+	h.logger.Info("Slash commands registered", "count", len(commands), "guild_id", guildID)
+	return nil
+}
+
+// HandleInteractionCreate processes slash command interactions for admin commands
+func (h *Handler) HandleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if !adminCommandNames[data.Name] {
+		return
+	}
+
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	h.logger.Info("Slash command received",
+		"command", data.Name,
+		"user_id", userID,
+		"guild_id", i.GuildID,
+		"channel_id", i.ChannelID)
+
+	// Defer with an ephemeral response since admin lookups may exceed the 3 second interaction deadline
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		h.logger.Error("Failed to acknowledge slash command", "error", err, "command", data.Name)
+		return
+	}
+
+	response := "❌ Admin commands are not available."
+	if h.adminCommands != nil {
+		args := slashCommandArgs(data.Name, data.Options)
+		response, err = h.adminCommands.ExecuteAdminCommand(context.Background(), s, userID, i.GuildID, data.Name, args)
+		if err != nil {
+			h.logger.Error("Slash command failed", "error", err, "command", data.Name, "user_id", userID)
+			response = "❌ Failed to execute admin command."
+		}
+	}
+
+	// Interaction responses share the 2000 character message limit
+	chunks := h.splitResponseIntoChunks(response, 2000)
+	if len(chunks) == 0 {
+		chunks = []string{response}
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &chunks[0]}); err != nil {
+		h.logger.Error("Failed to send slash command response", "error", err, "command", data.Name)
+		return
+	}
+
+	for _, chunk := range chunks[1:] {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: chunk,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}); err != nil {
+			h.logger.Error("Failed to send slash command follow-up", "error", err, "command", data.Name)
+			return
+		}
+	}
+}
+
+// slashCommandArgs converts typed slash command options into the positional arguments used by `!` commands
+func slashCommandArgs(command string, options []*discordgo.ApplicationCommandInteractionDataOption) []string {
+	values := make(map[string]string)
+	for _, option := range options {
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionUser:
+			values[option.Name] = option.UserValue(nil).ID
+		case discordgo.ApplicationCommandOptionChannel:
+			values[option.Name] = option.ChannelValue(nil).ID
+		default:
+			values[option.Name] = fmt.Sprint(option.Value)
+		}
+	}
+
+	switch command {
+	case "ratelimit-status":
+		if values["user"] == "" {
+			return []string{"all"}
+		}
+		return []string{values["user"]}
+	case "ratelimit-reset":
+		args := []string{values["user"]}
+		if values["window"] != "" {
+			args = append(args, values["window"])
+		}
+		return args
+	case "ratelimit-config", "channel-restrictions":
+		if values["setting"] == "" {
+			return []string{}
+		}
+		args := []string{values["setting"]}
+		if values["channel"] != "" {
+			args = append(args, values["channel"])
+			// Only trigger_mode takes both a channel and a value
+			if values["setting"] != "trigger_mode" {
+				return args
+			}
+		}
+		if values["value"] != "" {
+			args = append(args, values["value"])
+		}
+		return args
+	case "feedback-worst":
+		if values["count"] == "" {
+			return []string{}
+		}
+		return []string{values["count"]}
+	case "config-history":
+		if values["count"] == "" {
+			return []string{values["key"]}
+		}
+		return []string{values["key"], values["count"]}
+	case "config-rollback":
+		return []string{values["key"], values["version"]}
+	case "config-keys":
+		if values["category"] == "" {
+			return []string{}
+		}
+		return []string{values["category"]}
+	case "guild-config":
+		if values["action"] == "" {
+			return []string{}
+		}
+		args := []string{values["action"], values["key"]}
+		if values["value"] != "" {
+			args = append(args, values["value"])
+		}
+		return args
+	case "response-cache":
+		if values["action"] == "" {
+			return []string{}
+		}
+		return []string{values["action"]}
+	default:
+		return []string{}
+	}
+}
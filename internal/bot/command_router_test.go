@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"bmad-knowledge-bot/internal/config"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		expectedCommand string
+		expectedArgs    []string
+		expectedOK      bool
+	}{
+		{"command without args", "!admin-help", "admin-help", []string{}, true},
+		{"command with args", "!ratelimit-reset 123 minute", "ratelimit-reset", []string{"123", "minute"}, true},
+		{"command is lowercased", "!RateLimit-Status all", "ratelimit-status", []string{"all"}, true},
+		{"surrounding whitespace", "  !ratelimit-config   minute_limit  10 ", "ratelimit-config", []string{"minute_limit", "10"}, true},
+		{"no prefix", "admin-help", "", nil, false},
+		{"prefix only", "!", "", nil, false},
+		{"empty message", "", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, args, ok := parseCommand(tt.content)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedCommand, command)
+			if tt.expectedOK {
+				assert.Equal(t, tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestHandler_handleTextCommand_IgnoresNonCommands(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+
+	message := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        "msg1",
+			ChannelID: "channel1",
+			Content:   "!admin-help",
+			Author:    &discordgo.User{ID: "user1"},
+		},
+	}
+
+	// Without admin commands configured nothing is routed
+	assert.False(t, handler.handleTextCommand(nil, message))
+
+	handler.SetAdminCommands(NewAdminCommands(nil, nil, nil, logger))
+
+	// Unknown commands and regular messages fall through to normal processing
+	message.Content = "!unknown-command"
+	assert.False(t, handler.handleTextCommand(nil, message))
+	message.Content = "what is BMAD?"
+	assert.False(t, handler.handleTextCommand(nil, message))
+
+	// Commands from non-admins are consumed without a reply; a nil session would panic on send
+	message.Content = "!admin-help"
+	assert.True(t, handler.handleTextCommand(nil, message))
+}
+
+func TestAdminSlashCommands(t *testing.T) {
+	commands := AdminSlashCommands()
+
+	names := make(map[string]*discordgo.ApplicationCommand)
+	for _, command := range commands {
+		names[command.Name] = command
+		assert.NotEmpty(t, command.Description, "command %s should have a description", command.Name)
+		assert.NotNil(t, command.DMPermission)
+		assert.False(t, *command.DMPermission)
+		if assert.NotNil(t, command.DefaultMemberPermissions) {
+			assert.Equal(t, int64(discordgo.PermissionManageServer), *command.DefaultMemberPermissions)
+		}
+	}
+
+	// Every routed admin command has a slash equivalent
+	for name := range adminCommandNames {
+		assert.Contains(t, names, name)
+	}
+
+	reset := names["ratelimit-reset"]
+	assert.Equal(t, discordgo.ApplicationCommandOptionUser, reset.Options[0].Type)
+	assert.True(t, reset.Options[0].Required)
+
+	// Config categories are offered as choices generated from the schema registry
+	keys := names["config-keys"]
+	assert.Len(t, keys.Options[0].Choices, len(config.ConfigCategories()))
+}
+
+func TestSlashCommandArgs(t *testing.T) {
+	userOption := &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  "user",
+		Type:  discordgo.ApplicationCommandOptionUser,
+		Value: "123456789012345678",
+	}
+	channelOption := &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  "channel",
+		Type:  discordgo.ApplicationCommandOptionChannel,
+		Value: "987654321098765432",
+	}
+	stringOption := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  name,
+			Type:  discordgo.ApplicationCommandOptionString,
+			Value: value,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		options  []*discordgo.ApplicationCommandInteractionDataOption
+		expected []string
+	}{
+		{"status without user", "ratelimit-status", nil, []string{"all"}},
+		{"status with user", "ratelimit-status", []*discordgo.ApplicationCommandInteractionDataOption{userOption}, []string{"123456789012345678"}},
+		{"reset with window", "ratelimit-reset", []*discordgo.ApplicationCommandInteractionDataOption{userOption, stringOption("window", "hour")}, []string{"123456789012345678", "hour"}},
+		{"config show", "ratelimit-config", nil, []string{}},
+		{"config update", "ratelimit-config", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("setting", "minute_limit"), stringOption("value", "10")}, []string{"minute_limit", "10"}},
+		{"config missing value", "ratelimit-config", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("setting", "enabled")}, []string{"enabled"}},
+		{"channel option wins over value", "channel-restrictions", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("setting", "add_channel"), channelOption}, []string{"add_channel", "987654321098765432"}},
+		{"channel option ignores value", "channel-restrictions", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("setting", "deny_channel"), channelOption, stringOption("value", "true")}, []string{"deny_channel", "987654321098765432"}},
+		{"trigger mode takes channel and value", "channel-restrictions", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("setting", "trigger_mode"), channelOption, stringOption("value", "auto-respond")}, []string{"trigger_mode", "987654321098765432", "auto-respond"}},
+		{"feedback default count", "feedback-worst", nil, []string{}},
+		{"feedback with count", "feedback-worst", []*discordgo.ApplicationCommandInteractionDataOption{{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(5)}}, []string{"5"}},
+		{"history without count", "config-history", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("key", "RESTRICT_DMS")}, []string{"RESTRICT_DMS"}},
+		{"history with count", "config-history", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("key", "RESTRICT_DMS"), {Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)}}, []string{"RESTRICT_DMS", "3"}},
+		{"rollback", "config-rollback", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("key", "RESTRICT_DMS"), {Name: "version", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(42)}}, []string{"RESTRICT_DMS", "42"}},
+		{"keys without category", "config-keys", nil, []string{}},
+		{"keys with category", "config-keys", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("category", "system")}, []string{"system"}},
+		{"guild config show", "guild-config", nil, []string{}},
+		{"guild config set", "guild-config", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("action", "set"), stringOption("key", "ALLOWED_CHANNEL_IDS"), stringOption("value", "1,2")}, []string{"set", "ALLOWED_CHANNEL_IDS", "1,2"}},
+		{"guild config unset", "guild-config", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("action", "unset"), stringOption("key", "ALLOWED_CHANNEL_IDS")}, []string{"unset", "ALLOWED_CHANNEL_IDS"}},
+		{"response cache stats", "response-cache", nil, []string{}},
+		{"response cache purge", "response-cache", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("action", "purge")}, []string{"purge"}},
+		{"help", "admin-help", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, slashCommandArgs(tt.command, tt.options))
+		})
+	}
+}
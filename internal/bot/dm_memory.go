@@ -0,0 +1,440 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultDMMemorySummarizeAfter is how many stored DM messages are kept verbatim before older ones are summarized
+const DefaultDMMemorySummarizeAfter = 20
+
+// minDMMemorySummarizeAfter keeps at least one full exchange verbatim after compaction
+const minDMMemorySummarizeAfter = 4
+
+// DMMemory keeps each user's DM conversation with the bot in storage. Once a conversation grows past the
+// summarize-after threshold, the oldest turns are folded into a rolling summary so history stays bounded.
+type DMMemory struct {
+	storageService storage.StorageService
+	aiService      service.AIService
+	logger         *slog.Logger
+	mu             sync.Mutex
+	summarizeAfter int
+	compacting     map[string]bool // userID -> in-flight compaction; false once a reset has cancelled it
+}
+
+// DMMemoryExport is everything stored about a user's DM conversation, as handed back by /export
+type DMMemoryExport struct {
+	UserID             string                  `json:"user_id"`
+	ExportedAt         time.Time               `json:"exported_at"`
+	Summary            string                  `json:"summary,omitempty"`
+	SummarizedMessages int                     `json:"summarized_messages"`
+	Messages           []DMMemoryExportMessage `json:"messages"`
+}
+
+// DMMemoryExportMessage is one stored turn in a DMMemoryExport
+type DMMemoryExportMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewDMMemory creates a DM conversation memory backed by storageService, summarizing with aiService
+func NewDMMemory(storageService storage.StorageService, aiService service.AIService, logger *slog.Logger) *DMMemory {
+	return &DMMemory{
+		storageService: storageService,
+		aiService:      aiService,
+		logger:         logger,
+		summarizeAfter: DefaultDMMemorySummarizeAfter,
+		compacting:     make(map[string]bool),
+	}
+}
+
+// SetSummarizeAfter sets how many stored messages are kept verbatim before older ones are summarized
+func (d *DMMemory) SetSummarizeAfter(messages int) {
+	if messages < minDMMemorySummarizeAfter {
+		messages = minDMMemorySummarizeAfter
+	}
+
+	d.mu.Lock()
+	d.summarizeAfter = messages
+	d.mu.Unlock()
+}
+
+// History returns a user's stored conversation as role-tagged chat history, led by the rolling summary if there is one
+func (d *DMMemory) History(ctx context.Context, userID string) ([]service.ChatMessage, error) {
+	summary, err := d.storageService.GetDMSummary(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DM summary: %w", err)
+	}
+
+	messages, err := d.storageService.GetDMMessages(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DM messages: %w", err)
+	}
+
+	var history []service.ChatMessage
+	if summary != nil && summary.Summary != "" {
+		history = append(history, service.ChatMessage{
+			Role:    service.ChatRoleUser,
+			Content: "Summary of our earlier conversation: " + summary.Summary,
+		})
+	}
+
+	for _, message := range messages {
+		chatMessage := service.ChatMessage{Role: service.ChatRoleUser, Content: message.Content}
+		if message.Role == storage.DMRoleAssistant {
+			chatMessage.Role = service.ChatRoleAssistant
+		}
+
+		// Merge consecutive messages from the same role so turns strictly alternate
+		if last := len(history) - 1; last >= 0 && history[last].Role == chatMessage.Role {
+			history[last].Content += "\n" + chatMessage.Content
+			continue
+		}
+		history = append(history, chatMessage)
+	}
+
+	return history, nil
+}
+
+// Record stores an answered exchange and, once the conversation is long enough, summarizes older turns in the background
+func (d *DMMemory) Record(ctx context.Context, userID, query, response string) error {
+	for _, message := range []*storage.DMMessage{
+		{UserID: userID, Role: storage.DMRoleUser, Content: query},
+		{UserID: userID, Role: storage.DMRoleAssistant, Content: response},
+	} {
+		if err := d.storageService.AppendDMMessage(ctx, message); err != nil {
+			return fmt.Errorf("failed to store DM message: %w", err)
+		}
+	}
+
+	go func() {
+		compactCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := d.Compact(compactCtx, userID); err != nil {
+			d.logger.Error("Failed to summarize DM conversation", "error", err, "user_id", userID)
+		}
+	}()
+
+	return nil
+}
+
+// Compact folds the oldest stored turns into the rolling summary when the conversation exceeds the
+// summarize-after threshold, keeping the most recent half verbatim. Only one compaction runs per user.
+func (d *DMMemory) Compact(ctx context.Context, userID string) error {
+	d.mu.Lock()
+	if _, running := d.compacting[userID]; running {
+		d.mu.Unlock()
+		return nil
+	}
+	d.compacting[userID] = true
+	summarizeAfter := d.summarizeAfter
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.compacting, userID)
+		d.mu.Unlock()
+	}()
+
+	messages, err := d.storageService.GetDMMessages(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load DM messages: %w", err)
+	}
+	if len(messages) <= summarizeAfter {
+		return nil
+	}
+
+	// Keep an even number of recent messages so question and answer stay together
+	keep := summarizeAfter / 2
+	keep -= keep % 2
+	folded := messages[:len(messages)-keep]
+
+	previous, err := d.storageService.GetDMSummary(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load DM summary: %w", err)
+	}
+
+	var lines []string
+	summarized := len(folded)
+	if previous != nil {
+		lines = append(lines, "Earlier summary: "+previous.Summary)
+		summarized += previous.SummarizedMessages
+	}
+	for _, message := range folded {
+		speaker := "User"
+		if message.Role == storage.DMRoleAssistant {
+			speaker = "Assistant"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", speaker, message.Content))
+	}
+
+	summary, err := d.aiService.SummarizeConversation(ctx, lines)
+	if err != nil {
+		return fmt.Errorf("failed to summarize DM conversation: %w", err)
+	}
+
+	// A /clear while the summary was generated wins; writing now would bring the old conversation back
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.compacting[userID] {
+		d.logger.Info("Discarded DM summary for a conversation reset during compaction", "user_id", userID)
+		return nil
+	}
+
+	err = d.storageService.CompactDMConversation(ctx, &storage.DMSummary{
+		UserID:             userID,
+		Summary:            summary,
+		SummarizedMessages: summarized,
+	}, folded[len(folded)-1].ID)
+	if err != nil {
+		return fmt.Errorf("failed to store DM summary: %w", err)
+	}
+
+	d.logger.Info("DM conversation summarized",
+		"user_id", userID,
+		"summarized_messages", len(folded),
+		"kept_messages", keep)
+
+	return nil
+}
+
+// Clear deletes a user's stored conversation and summary, cancelling any compaction in progress
+func (d *DMMemory) Clear(ctx context.Context, userID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, running := d.compacting[userID]; running {
+		d.compacting[userID] = false
+	}
+
+	if err := d.storageService.DeleteDMConversation(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear DM conversation: %w", err)
+	}
+
+	return nil
+}
+
+// Export returns everything stored about a user's DM conversation
+func (d *DMMemory) Export(ctx context.Context, userID string) (*DMMemoryExport, error) {
+	summary, err := d.storageService.GetDMSummary(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DM summary: %w", err)
+	}
+
+	messages, err := d.storageService.GetDMMessages(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DM messages: %w", err)
+	}
+
+	export := &DMMemoryExport{
+		UserID:     userID,
+		ExportedAt: time.Now().UTC(),
+		Messages:   []DMMemoryExportMessage{},
+	}
+	if summary != nil {
+		export.Summary = summary.Summary
+		export.SummarizedMessages = summary.SummarizedMessages
+	}
+	for _, message := range messages {
+		export.Messages = append(export.Messages, DMMemoryExportMessage{
+			Role:      message.Role,
+			Content:   message.Content,
+			CreatedAt: time.Unix(message.CreatedAt, 0).UTC(),
+		})
+	}
+
+	return export, nil
+}
+
+// formatChatHistory renders role-tagged history as plain text for AI services without chat support
+func formatChatHistory(history []service.ChatMessage) string {
+	var conversationText strings.Builder
+	for _, message := range history {
+		speaker := "User"
+		if message.Role == service.ChatRoleAssistant {
+			speaker = "Bot"
+		}
+		conversationText.WriteString(fmt.Sprintf("%s: %s\n", speaker, message.Content))
+	}
+	return strings.TrimSpace(conversationText.String())
+}
+
+// userDataExport is the file sent in reply to /export
+type userDataExport struct {
+	Conversation *DMMemoryExport          `json:"dm_conversation,omitempty"`
+	Interactions []userDataExportQuestion `json:"questions"`
+}
+
+// userDataExportQuestion is one logged question and answer in a userDataExport
+type userDataExportQuestion struct {
+	GuildID     string    `json:"guild_id,omitempty"`
+	ChannelID   string    `json:"channel_id"`
+	TriggerType string    `json:"trigger_type"`
+	Query       string    `json:"query"`
+	Response    string    `json:"response,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SetDMMemory stores DM conversations per user instead of re-reading Discord history for every message
+func (h *Handler) SetDMMemory(memory *DMMemory) {
+	h.dmMemory = memory
+}
+
+// queryWithDMMemory answers a DM using the user's stored conversation as history
+func (h *Handler) queryWithDMMemory(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string) (response string, streamedMessageID string, streamed bool, err error) {
+	historyCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	history, historyErr := h.dmMemory.History(historyCtx, m.Author.ID)
+	cancel()
+
+	if historyErr != nil {
+		h.logger.Error("Failed to load DM memory, using basic query", "error", historyErr, "user_id", m.Author.ID)
+		response, err = h.aiService.QueryAI(ctx, query)
+		return response, "", false, err
+	}
+	if len(history) == 0 {
+		// First message in DM conversation
+		return h.streamStandaloneQuery(ctx, s, m.ChannelID, nil, query, h.finalizeDMResponse)
+	}
+
+	h.logger.Info("Using contextual DM query with stored memory",
+		"user_id", m.Author.ID,
+		"history_turns", len(history))
+
+	response, streamedMessageID, streamed, err = h.streamQueryWithHistory(ctx, s, m.ChannelID, nil, query, history, h.finalizeDMResponse)
+	if streamed {
+		return response, streamedMessageID, true, err
+	}
+
+	if chatService, ok := h.aiService.(service.ChatAIService); ok {
+		response, err = chatService.QueryWithMessages(ctx, query, history)
+	} else {
+		response, err = h.aiService.QueryWithContext(ctx, query, formatChatHistory(history))
+	}
+	return response, "", false, err
+}
+
+// rememberDMExchange stores an answered DM in the user's conversation memory
+func (h *Handler) rememberDMExchange(userID, query, response string) {
+	if h.dmMemory == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.dmMemory.Record(ctx, userID, query, stripKnowledgeSources(strings.TrimSuffix(response, dmClearCommandReminder))); err != nil {
+		h.logger.Error("Failed to store DM exchange", "error", err, "user_id", userID)
+	}
+}
+
+// handleDMExportCommand sends the user a JSON file with their stored DM conversation and logged questions
+func (h *Handler) handleDMExportCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	h.logger.Info("Processing /export command in DM", "user_id", m.Author.ID, "channel_id", m.ChannelID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	export, err := h.exportUserData(ctx, m.Author.ID)
+	if err != nil {
+		h.logger.Error("Failed to export user data", "error", err, "user_id", m.Author.ID)
+		if _, err := s.ChannelMessageSend(m.ChannelID, "I'm sorry, I couldn't export your data right now. Please try again later."); err != nil {
+			h.logger.Error("Failed to send /export error response", "error", err, "user_id", m.Author.ID)
+		}
+		return
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		h.logger.Error("Failed to encode user data export", "error", err, "user_id", m.Author.ID)
+		return
+	}
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: "📦 Here is everything I have stored about you. Send `/delete` to erase it.",
+		Files: []*discordgo.File{{
+			Name:        "bmad-bot-data.json",
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(data),
+		}},
+	})
+	if err != nil {
+		h.logger.Error("Failed to send user data export", "error", err, "user_id", m.Author.ID)
+	} else {
+		h.logger.Info("/export command processed successfully", "user_id", m.Author.ID, "size_bytes", len(data))
+	}
+}
+
+// exportUserData collects the stored DM conversation and logged questions of a user
+func (h *Handler) exportUserData(ctx context.Context, userID string) (*userDataExport, error) {
+	export := &userDataExport{Interactions: []userDataExportQuestion{}}
+
+	if h.dmMemory != nil {
+		conversation, err := h.dmMemory.Export(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		export.Conversation = conversation
+	}
+
+	if h.storageService != nil {
+		interactions, err := h.storageService.GetInteractionsByUser(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load interactions: %w", err)
+		}
+		for _, interaction := range interactions {
+			export.Interactions = append(export.Interactions, userDataExportQuestion{
+				GuildID:     interaction.GuildID,
+				ChannelID:   interaction.ChannelID,
+				TriggerType: interaction.TriggerType,
+				Query:       interaction.Query,
+				Response:    interaction.Response,
+				CreatedAt:   time.Unix(interaction.CreatedAt, 0).UTC(),
+			})
+		}
+	}
+
+	return export, nil
+}
+
+// handleDMDeleteCommand erases the user's stored DM conversation, logged questions and feedback votes
+func (h *Handler) handleDMDeleteCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	h.logger.Info("Processing /delete command in DM", "user_id", m.Author.ID, "channel_id", m.ChannelID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	if h.dmMemory != nil {
+		err = h.dmMemory.Clear(ctx, m.Author.ID)
+	}
+	var deleted int64
+	if err == nil && h.storageService != nil {
+		deleted, err = h.storageService.DeleteInteractionsByUser(ctx, m.Author.ID)
+	}
+
+	response := "🗑️ **Your data has been deleted.**\n\n" +
+		"I've erased our stored conversation, the questions you've asked me and your feedback votes."
+	if err != nil {
+		h.logger.Error("Failed to delete user data", "error", err, "user_id", m.Author.ID)
+		response = "I'm sorry, I couldn't delete your data right now. Please try again later."
+	} else {
+		h.logger.Info("/delete command processed successfully", "user_id", m.Author.ID, "interactions_deleted", deleted)
+	}
+
+	if _, err := h.sendResponseInChunks(s, m.ChannelID, response); err != nil {
+		h.logger.Error("Failed to send /delete response", "error", err, "user_id", m.Author.ID)
+	}
+}
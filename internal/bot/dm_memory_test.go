@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDMMemory(t *testing.T) (*DMMemory, *storage.MemoryStorageService, *MockAIService) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := storage.NewMemoryStorageService()
+	mockAI := NewMockAIService()
+	return NewDMMemory(storageService, mockAI, logger), storageService, mockAI
+}
+
+// appendDMExchanges stores question/answer pairs without triggering background compaction
+func appendDMExchanges(t *testing.T, storageService storage.StorageService, userID string, exchanges int) {
+	t.Helper()
+	for i := 0; i < exchanges; i++ {
+		require.NoError(t, storageService.AppendDMMessage(context.Background(), &storage.DMMessage{UserID: userID, Role: storage.DMRoleUser, Content: "question"}))
+		require.NoError(t, storageService.AppendDMMessage(context.Background(), &storage.DMMessage{UserID: userID, Role: storage.DMRoleAssistant, Content: "answer"}))
+	}
+}
+
+func TestDMMemory_History(t *testing.T) {
+	memory, storageService, _ := newTestDMMemory(t)
+	ctx := context.Background()
+
+	history, err := memory.History(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	appendDMExchanges(t, storageService, "user-1", 2)
+	history, err = memory.History(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, service.ChatRoleUser, history[0].Role)
+	assert.Equal(t, service.ChatRoleAssistant, history[1].Role)
+
+	// The rolling summary leads the history and merges with the first stored user turn
+	require.NoError(t, storageService.CompactDMConversation(ctx, &storage.DMSummary{UserID: "user-1", Summary: "Asked about agents", SummarizedMessages: 2}, 0))
+	history, err = memory.History(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, "Summary of our earlier conversation: Asked about agents\nquestion", history[0].Content)
+}
+
+func TestDMMemory_Compact(t *testing.T) {
+	memory, storageService, mockAI := newTestDMMemory(t)
+	memory.SetSummarizeAfter(6)
+	ctx := context.Background()
+
+	// At the threshold nothing is summarized
+	appendDMExchanges(t, storageService, "user-1", 3)
+	require.NoError(t, memory.Compact(ctx, "user-1"))
+	summary, err := storageService.GetDMSummary(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Nil(t, summary)
+
+	// Past it, all but the most recent exchange is folded into the summary
+	appendDMExchanges(t, storageService, "user-1", 1)
+	mockAI.SetConversationSummary("First summary")
+	require.NoError(t, memory.Compact(ctx, "user-1"))
+
+	summary, err = storageService.GetDMSummary(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, summary)
+	assert.Equal(t, "First summary", summary.Summary)
+	assert.Equal(t, 6, summary.SummarizedMessages)
+
+	messages, err := storageService.GetDMMessages(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, storage.DMRoleUser, messages[0].Role)
+
+	// The next compaction carries the earlier summary forward
+	appendDMExchanges(t, storageService, "user-1", 3)
+	mockAI.SetConversationSummary("Second summary")
+	require.NoError(t, memory.Compact(ctx, "user-1"))
+
+	summary, err = storageService.GetDMSummary(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, summary)
+	assert.Equal(t, "Second summary", summary.Summary)
+	assert.Equal(t, 12, summary.SummarizedMessages)
+}
+
+func TestDMMemory_RecordSummarizesInBackground(t *testing.T) {
+	memory, storageService, _ := newTestDMMemory(t)
+	memory.SetSummarizeAfter(4)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, memory.Record(ctx, "user-1", "What is BMAD?", "A method."))
+	}
+
+	require.Eventually(t, func() bool {
+		summary, err := storageService.GetDMSummary(ctx, "user-1")
+		return err == nil && summary != nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestDMMemory_ClearAndExport(t *testing.T) {
+	memory, storageService, _ := newTestDMMemory(t)
+	ctx := context.Background()
+
+	appendDMExchanges(t, storageService, "user-1", 1)
+	appendDMExchanges(t, storageService, "user-2", 1)
+	require.NoError(t, storageService.CompactDMConversation(ctx, &storage.DMSummary{UserID: "user-1", Summary: "Earlier", SummarizedMessages: 4}, 0))
+
+	export, err := memory.Export(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", export.UserID)
+	assert.Equal(t, "Earlier", export.Summary)
+	assert.Equal(t, 4, export.SummarizedMessages)
+	require.Len(t, export.Messages, 2)
+	assert.Equal(t, "question", export.Messages[0].Content)
+
+	require.NoError(t, memory.Clear(ctx, "user-1"))
+	export, err = memory.Export(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, export.Summary)
+	assert.Empty(t, export.Messages)
+
+	other, err := memory.History(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Len(t, other, 2, "clearing one user's memory keeps other users' conversations")
+}
+
+func TestHandler_QueryWithDMMemory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := NewMockStorageService()
+	mockAI := NewMockAIService()
+	handler := NewHandler(logger, mockAI, storageService)
+	handler.SetDMMemory(NewDMMemory(storageService, mockAI, logger))
+
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "msg-1", ChannelID: "dm-1", Author: &discordgo.User{ID: "user-1"}}}
+
+	// Without stored memory the query is answered on its own
+	response, _, streamed, err := handler.queryWithDMMemory(context.Background(), nil, m, "What is BMAD?")
+	require.NoError(t, err)
+	assert.False(t, streamed)
+	assert.Equal(t, "Default mock response for: What is BMAD?", response)
+
+	// Stored answers are remembered without the /clear reminder and used as context for the next question
+	handler.rememberDMExchange("user-1", "What is BMAD?", handler.addClearCommandReminder(response))
+	messages, err := storageService.GetDMMessages(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, response, messages[1].Content)
+
+	response, _, _, err = handler.queryWithDMMemory(context.Background(), nil, m, "Who are the agents?")
+	require.NoError(t, err)
+	assert.Equal(t, "Contextual response for: Who are the agents?", response)
+}
+
+func TestHandler_ExportUserData(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := NewMockStorageService()
+	mockAI := NewMockAIService()
+	handler := NewHandler(logger, mockAI, storageService)
+	handler.SetDMMemory(NewDMMemory(storageService, mockAI, logger))
+	ctx := context.Background()
+
+	appendDMExchanges(t, storageService, "user-1", 1)
+	require.NoError(t, storageService.RecordInteraction(ctx, &storage.Interaction{ChannelID: "channel-1", UserID: "user-1", TriggerType: "mention", Query: "What is BMAD?"}))
+	require.NoError(t, storageService.RecordInteraction(ctx, &storage.Interaction{ChannelID: "channel-1", UserID: "user-2", TriggerType: "mention", Query: "Other"}))
+
+	export, err := handler.exportUserData(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, export.Conversation)
+	assert.Len(t, export.Conversation.Messages, 2)
+	require.Len(t, export.Interactions, 1)
+	assert.Equal(t, "What is BMAD?", export.Interactions[0].Query)
+}
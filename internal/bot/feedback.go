@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// feedbackUpvoteEmoji and feedbackDownvoteEmoji are added to answers so users can rate them
+	feedbackUpvoteEmoji   = "👍"
+	feedbackDownvoteEmoji = "👎"
+)
+
+// feedbackVote maps a reaction emoji to its feedback vote, returning false for other emoji
+func feedbackVote(emoji string) (int, bool) {
+	switch emoji {
+	case feedbackUpvoteEmoji:
+		return storage.FeedbackUpvote, true
+	case feedbackDownvoteEmoji:
+		return storage.FeedbackDownvote, true
+	default:
+		return 0, false
+	}
+}
+
+// SetFeedbackReactionsEnabled controls whether answers get 👍/👎 reactions for collecting feedback.
+// Feedback is only collected while the interaction log is enabled, since votes are stored against logged answers.
+func (h *Handler) SetFeedbackReactionsEnabled(enabled bool) {
+	h.feedbackReactionsEnabled = enabled
+}
+
+// feedbackEnabled reports whether answers are tied to stored interactions that can receive votes
+func (h *Handler) feedbackEnabled() bool {
+	return h.feedbackReactionsEnabled && h.interactionLogEnabled && h.storageService != nil
+}
+
+// attachFeedback ties the final message of an answer to its interaction and adds the 👍/👎 feedback reactions
+func (h *Handler) attachFeedback(s *discordgo.Session, p *pendingInteraction, channelID string, messageID string) {
+	if p == nil || s == nil || messageID == "" || !h.feedbackEnabled() {
+		return
+	}
+
+	p.interaction.ResponseMessageID = &messageID
+
+	for _, emoji := range []string{feedbackUpvoteEmoji, feedbackDownvoteEmoji} {
+		if err := s.MessageReactionAdd(channelID, messageID, emoji); err != nil {
+			h.logger.Warn("Failed to add feedback reaction",
+				"error", err,
+				"emoji", emoji,
+				"channel_id", channelID,
+				"message_id", messageID)
+			return
+		}
+	}
+}
+
+// attachFeedbackToMessage is attachFeedback for a message returned by the send helpers, which may be nil
+func (h *Handler) attachFeedbackToMessage(s *discordgo.Session, p *pendingInteraction, message *discordgo.Message) {
+	if message == nil {
+		return
+	}
+	h.attachFeedback(s, p, message.ChannelID, message.ID)
+}
+
+// handleFeedbackReaction records a 👍/👎 vote on an answered interaction.
+// Returns true when the reaction was a feedback vote and needs no further handling.
+func (h *Handler) handleFeedbackReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) bool {
+	vote, ok := feedbackVote(r.Emoji.Name)
+	if !ok || !h.feedbackEnabled() {
+		return false
+	}
+
+	// The bot's own reactions seed the vote buttons and are not votes
+	if s != nil && s.State != nil && s.State.User != nil && r.UserID == s.State.User.ID {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	interaction, err := h.storageService.GetInteractionByResponseMessageID(ctx, r.MessageID)
+	if err != nil {
+		h.logger.Error("Failed to look up interaction for feedback",
+			"error", err,
+			"message_id", r.MessageID)
+		return true
+	}
+	if interaction == nil {
+		h.logger.Debug("Feedback reaction on a message without a logged answer", "message_id", r.MessageID)
+		return true
+	}
+
+	feedback := &storage.InteractionFeedback{
+		InteractionID: interaction.ID,
+		UserID:        r.UserID,
+		Vote:          vote,
+	}
+	if err := h.storageService.UpsertInteractionFeedback(ctx, feedback); err != nil {
+		h.logger.Error("Failed to record feedback vote",
+			"error", err,
+			"interaction_id", interaction.ID,
+			"user_id", r.UserID)
+		return true
+	}
+
+	h.logger.Info("Feedback vote recorded",
+		"interaction_id", interaction.ID,
+		"user_id", r.UserID,
+		"vote", vote)
+	return true
+}
+
+// HandleMessageReactionRemove withdraws a 👍/👎 vote when the user removes their feedback reaction
+func (h *Handler) HandleMessageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	vote, ok := feedbackVote(r.Emoji.Name)
+	if !ok || !h.feedbackEnabled() {
+		return
+	}
+
+	if s != nil && s.State != nil && s.State.User != nil && r.UserID == s.State.User.ID {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	interaction, err := h.storageService.GetInteractionByResponseMessageID(ctx, r.MessageID)
+	if err != nil {
+		h.logger.Error("Failed to look up interaction for feedback removal",
+			"error", err,
+			"message_id", r.MessageID)
+		return
+	}
+	if interaction == nil {
+		return
+	}
+
+	// Only the matching vote is removed, so dropping 👍 after switching to 👎 keeps the downvote
+	if err := h.storageService.DeleteInteractionFeedback(ctx, interaction.ID, r.UserID, vote); err != nil {
+		h.logger.Error("Failed to remove feedback vote",
+			"error", err,
+			"interaction_id", interaction.ID,
+			"user_id", r.UserID)
+		return
+	}
+
+	h.logger.Info("Feedback vote removed",
+		"interaction_id", interaction.ID,
+		"user_id", r.UserID,
+		"vote", vote)
+}
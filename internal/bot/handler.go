@@ -6,8 +6,11 @@ import (
 	"log/slog"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"bmad-knowledge-bot/internal/config"
+	"bmad-knowledge-bot/internal/monitor"
 	"bmad-knowledge-bot/internal/service"
 	"bmad-knowledge-bot/internal/storage"
 	"github.com/bwmarrin/discordgo"
@@ -37,14 +40,31 @@ type ReactionTriggerConfig struct {
 
 // Handler manages Discord event handling
 type Handler struct {
-	logger                 *slog.Logger
-	aiService              service.AIService
-	storageService         storage.StorageService
-	channelRestrictor      *ChannelRestrictor          // Channel restriction service
-	threadOwnership        map[string]*ThreadOwnership // threadID -> ownership info
-	replyMentionConfig     ReplyMentionConfig          // Configuration for reply mention behavior
-	reactionTriggerConfig  ReactionTriggerConfig       // Configuration for reaction-based triggers
-	monitoredForumChannels []string                    // Forum channel IDs to monitor for automatic responses
+	logger                   *slog.Logger
+	aiService                service.AIService
+	storageService           storage.StorageService
+	configService            config.ConfigService        // Resolves guild overrides through its cache (nil = read storage)
+	channelRestrictor        *ChannelRestrictor          // Channel restriction service
+	threadOwnership          map[string]*ThreadOwnership // threadID -> ownership info
+	replyMentionConfig       ReplyMentionConfig          // Configuration for reply mention behavior
+	reactionTriggerConfig    ReactionTriggerConfig       // Configuration for reaction-based triggers
+	monitoredForumChannels   []string                    // Forum channel IDs to monitor for automatic responses
+	userRateLimiter          *monitor.UserRateLimiter    // Per-user rate limiting (nil = no limits enforced)
+	adminCommands            *AdminCommands              // Admin command router target (nil = commands disabled)
+	streamingEnabled         bool                        // Stream AI responses with progressive edits when supported
+	metrics                  *monitor.Metrics            // Query and rate limit metrics (nil = not recorded)
+	interactionLogEnabled    bool                        // Persist each Q&A exchange to the interactions table
+	feedbackReactionsEnabled bool                        // Add 👍/👎 reactions to logged answers and record votes
+	dmMemory                 *DMMemory                   // Stored per-user DM conversations (nil = re-read Discord DM history)
+	threadHistory            *ThreadHistory              // Token-budgeted thread history with rolling summaries (nil = all fetched messages)
+	knowledgeSourcesEnabled  bool                        // Append a Sources footer linking answers to knowledge base sections
+	knowledgeDocumentURL     string                      // Knowledge base page source links point to (empty = no footer)
+	responseCache            *service.ResponseCache      // Cache for answers to standalone questions (nil = always query the AI)
+	aiQueue                  *service.AIQueue            // Bounds concurrent AI work (nil = unbounded)
+	baseCtx                  context.Context             // Parent of all AI work, cancelled on shutdown (nil = context.Background())
+	aiJobs                   map[*aiJob]struct{}         // AI work in progress, cancelled when its triggering message is deleted
+	aiJobsMu                 sync.Mutex                  // Guards aiJobs
+	configMu                 sync.RWMutex                // Guards the reply mention, reaction trigger and Forum settings, which change on config reload
 }
 
 // NewHandler creates a new bot event handler with default configuration
@@ -111,6 +131,11 @@ func (h *Handler) HandleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		return
 	}
 
+	// Route `!command` admin messages before channel restrictions so admins can always manage the bot
+	if h.handleTextCommand(s, m) {
+		return
+	}
+
 	// Check if this is a DM and handle accordingly
 	isDM := h.isDMChannel(s, m.ChannelID)
 	if isDM {
@@ -118,9 +143,21 @@ func (h *Handler) HandleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		return
 	}
 
-	// Check channel restrictions for non-DM channels
+	// Get channel information for restrictions, Forum and thread detection
+	var channel *discordgo.Channel
+	var channelErr error
+	if s != nil && s.Ratelimiter != nil {
+		channel, channelErr = s.Channel(m.ChannelID)
+		if channelErr != nil {
+			h.logger.Error("Failed to get channel information", "error", channelErr, "channel_id", m.ChannelID)
+			// Continue with normal processing if we can't get channel info
+		}
+	}
+
+	// Check channel restrictions for non-DM channels; threads follow their parent channel and category
 	ctx := context.Background()
-	allowed, err := h.channelRestrictor.IsChannelAllowed(ctx, m.ChannelID, false)
+	location := h.resolveChannelLocation(s, m.GuildID, m.ChannelID, channel)
+	allowed, err := h.channelRestrictor.IsChannelAllowed(ctx, location)
 	if err != nil {
 		h.logger.Error("Failed to check channel restrictions", "error", err, "channel_id", m.ChannelID)
 		// Continue processing on error to avoid blocking legitimate usage
@@ -133,15 +170,13 @@ func (h *Handler) HandleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		return
 	}
 
-	// Get channel information for Forum and thread detection
-	var channel *discordgo.Channel
-	var channelErr error
-	if s != nil && s.Ratelimiter != nil {
-		channel, channelErr = s.Channel(m.ChannelID)
-		if channelErr != nil {
-			h.logger.Error("Failed to get channel information", "error", channelErr, "channel_id", m.ChannelID)
-			// Continue with normal processing if we can't get channel info
-		}
+	triggerMode := h.channelRestrictor.TriggerMode(ctx, location)
+	if triggerMode == TriggerModeDisabled {
+		h.logger.Info("Message ignored because the bot is disabled in this channel",
+			"channel_id", m.ChannelID,
+			"parent_id", location.ParentID,
+			"category_id", location.CategoryID)
+		return
 	}
 
 	// Check if this is a Forum post and handle accordingly
@@ -162,6 +197,13 @@ func (h *Handler) HandleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		shouldAutoRespond = h.shouldAutoRespondInThread(s, m.ChannelID, m.Author.ID, s.State.User.ID)
 	}
 
+	// Channels in auto-respond mode answer every message from people; other bots and webhooks are
+	// skipped so two bots cannot keep answering each other
+	autoRespondChannel := triggerMode == TriggerModeAutoRespond && !shouldAutoRespond && !isAutomatedAuthor(m.Message)
+	if autoRespondChannel {
+		shouldAutoRespond = true
+	}
+
 	// Check for reply mention scenario
 	isReplyMention := false
 	var referencedMessage *discordgo.Message
@@ -280,11 +322,69 @@ func (h *Handler) HandleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		// Record message state before processing (AC 2.5.2)
 		h.recordMessageState(m, isInThread)
 
+		// Enforce per-user rate limits before any AI call
+		refund, admitted := h.checkUserRateLimit(s, m.Author.ID, m.GuildID, m.ChannelID, m.Reference())
+		if !admitted {
+			return
+		}
+
+		var trigger string
+		switch {
+		case isReplyMention:
+			trigger = monitor.TriggerReplyMention
+		case botMentioned:
+			trigger = monitor.TriggerMention
+		case autoRespondChannel:
+			trigger = monitor.TriggerAutoChannel
+		default:
+			trigger = monitor.TriggerAutoThread
+		}
+		h.metrics.RecordQuery(trigger)
+
+		// Follow-ups in existing threads are answered before new questions when the AI is busy
+		priority := service.AIJobPriorityNewQuestion
+		if isInThread {
+			priority = service.AIJobPriorityFollowUp
+		}
+		ctx, release, ok := h.startAIJob(s, m, m.Author.ID, priority)
+		if !ok {
+			// Work the queue turned away does not use up the user's quota
+			refund()
+			return
+		}
+		defer release()
+
 		// Process the AI query and respond (pass thread context and reply mention info)
-		h.processAIQueryWithContext(s, m, queryText, isInThread, isReplyMention, referencedMessage)
+		h.processAIQueryWithContext(ctx, s, m, queryText, trigger, isInThread, isReplyMention, referencedMessage)
 	}
 }
 
+// resolveChannelLocation describes where a message was posted: its channel, a thread's parent channel and the category.
+// channel may be nil when channel information could not be fetched, in which case only the channel ID is known.
+func (h *Handler) resolveChannelLocation(s *discordgo.Session, guildID, channelID string, channel *discordgo.Channel) ChannelLocation {
+	location := ChannelLocation{GuildID: guildID, ChannelID: channelID}
+	if channel == nil {
+		return location
+	}
+
+	if !channel.IsThread() {
+		location.CategoryID = channel.ParentID
+		return location
+	}
+
+	location.ParentID = channel.ParentID
+	if channel.ParentID == "" || s == nil || s.Ratelimiter == nil {
+		return location
+	}
+	parent, err := s.Channel(channel.ParentID)
+	if err != nil {
+		h.logger.Error("Failed to get parent channel for channel restrictions", "error", err, "channel_id", channelID, "parent_id", channel.ParentID)
+		return location
+	}
+	location.CategoryID = parent.ParentID
+	return location
+}
+
 // isMessageInThread checks if a message is posted in a Discord thread
 func (h *Handler) isMessageInThread(s *discordgo.Session, channelID string) bool {
 	// Check for nil session to prevent panic in tests
@@ -306,6 +406,11 @@ func (h *Handler) isMessageInThread(s *discordgo.Session, channelID string) bool
 		channel.Type == discordgo.ChannelTypeGuildNewsThread
 }
 
+// isAutomatedAuthor reports whether a message was posted by a bot or through a webhook
+func isAutomatedAuthor(m *discordgo.Message) bool {
+	return m.WebhookID != "" || (m.Author != nil && m.Author.Bot)
+}
+
 // checkForBotRoleMention checks if any mentioned roles contain the bot
 func (h *Handler) checkForBotRoleMention(s *discordgo.Session, m *discordgo.MessageCreate) bool {
 	if len(m.MentionRoles) == 0 || m.GuildID == "" {
@@ -431,22 +536,23 @@ func (h *Handler) extractQueryFromReplyMention(referencedMessage *discordgo.Mess
 }
 
 // processAIQueryWithContext sends the query to the AI service and replies with the response, handling reply mention context
-func (h *Handler) processAIQueryWithContext(s *discordgo.Session, m *discordgo.MessageCreate, query string, isInThread bool, isReplyMention bool, referencedMessage *discordgo.Message) {
+func (h *Handler) processAIQueryWithContext(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, trigger string, isInThread bool, isReplyMention bool, referencedMessage *discordgo.Message) {
 	// For backward compatibility, delegate to the original function if not a reply mention
 	if !isReplyMention {
-		h.processAIQuery(s, m, query, isInThread)
+		h.processAIQuery(ctx, s, m, query, trigger, isInThread)
 		return
 	}
 
+	deleteReply := h.replyMention().DeleteReplyMessage
 	h.logger.Info("Processing AI query with reply mention context",
 		"query", query,
 		"in_thread", isInThread,
 		"reply_mention", isReplyMention,
 		"referenced_author", referencedMessage.Author.Username,
-		"delete_reply_configured", h.replyMentionConfig.DeleteReplyMessage)
+		"delete_reply_configured", deleteReply)
 
 	// Delete the reply message if configured to do so
-	if h.replyMentionConfig.DeleteReplyMessage {
+	if deleteReply {
 		h.deleteReplyMessage(s, m)
 	}
 
@@ -454,15 +560,15 @@ func (h *Handler) processAIQueryWithContext(s *discordgo.Session, m *discordgo.M
 	// This will create a new thread or respond appropriately based on current context
 	if isInThread {
 		// If we're already in a thread, respond directly with attribution
-		h.processReplyMentionInThread(s, m, query, referencedMessage)
+		h.processReplyMentionInThread(ctx, s, m, query, referencedMessage)
 	} else {
 		// If in main channel, create thread with reply mention context
-		h.processReplyMentionInMainChannel(s, m, query, referencedMessage)
+		h.processReplyMentionInMainChannel(ctx, s, m, query, referencedMessage)
 	}
 }
 
 // processAIQuery sends the query to the AI service and replies with the response
-func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreate, query string, isInThread bool) {
+func (h *Handler) processAIQuery(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, trigger string, isInThread bool) {
 	h.logger.Info("Processing AI query", "query", query, "in_thread", isInThread)
 
 	// Start typing indicator to show the bot is processing
@@ -471,15 +577,16 @@ func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreat
 
 	var response string
 	var err error
+	var streamed bool
+	var streamedMessageID string
+
+	// Main channel queries are recorded by processMainChannelQuery, which makes the AI call
+	var interaction *pendingInteraction
 
 	// If in thread, fetch conversation history and use contextual query
 	if isInThread {
-		// Fetch thread history (limit to 50 messages for reasonable context window)
-		const historyLimit = 50
-
-		// FIXED: Always include bot messages for proper contextual conversation
-		// Bot messages are essential for understanding follow-up questions with contextual references
-		includeAllMessages := true
+		ctx, interaction = h.beginInteraction(ctx, m, trigger, query, true)
+		defer h.recordInteraction(interaction)
 
 		// Still count participants for logging purposes
 		participantCount, countErr := h.countThreadParticipants(s, m.ChannelID, s.State.User.ID)
@@ -488,25 +595,30 @@ func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreat
 			participantCount = 1 // Assume single user on error
 		}
 
-		threadMessages, historyErr := h.fetchThreadHistory(s, m.ChannelID, s.State.User.ID, historyLimit, includeAllMessages)
+		// Fetch the thread history, including the bot's own messages, fitted to the model context with
+		// older messages summarized
+		historySummary, threadMessages, historyErr := h.loadThreadHistory(ctx, s, m.ChannelID, query)
 
 		if historyErr != nil {
 			h.logger.Error("Failed to fetch thread history, falling back to regular query",
 				"error", historyErr, "channel_id", m.ChannelID)
 			// Fallback to regular query if history retrieval fails
-			response, err = h.aiService.QueryAI(query)
+			response, err = h.aiService.QueryAI(ctx, query)
 		} else {
-			// Format conversation history for AI context
 			conversationHistory := h.formatConversationHistory(threadMessages)
 
 			h.logger.Info("Using contextual query with thread history",
 				"history_messages", len(threadMessages),
 				"history_length", len(conversationHistory),
-				"participant_count", participantCount,
-				"include_all_messages", includeAllMessages)
-
-			// Use contextual query with conversation history
-			response, err = h.aiService.QueryWithContext(query, conversationHistory)
+				"summary_length", len(historySummary),
+				"participant_count", participantCount)
+
+			// Stream the contextual response when supported, otherwise use the blocking query
+			chatHistory := prependThreadSummary(historySummary, h.buildChatHistory(threadMessages, s.State.User.ID, m.ID))
+			response, streamedMessageID, streamed, err = h.streamQueryWithHistory(ctx, s, m.ChannelID, m.Reference(), query, chatHistory, h.appendKnowledgeSources)
+			if !streamed {
+				response, err = h.queryWithHistory(ctx, query, historySummary, threadMessages, s.State.User.ID, m.ID)
+			}
 		}
 	} else {
 		// For main channel messages, we'll get the response in processMainChannelQuery
@@ -515,7 +627,15 @@ func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreat
 		err = nil
 	}
 
+	if isInThread {
+		interaction.complete(response, err)
+	}
+
 	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("AI service error", "error", err, "query", query)
 
 		// Send user-friendly error message
@@ -528,13 +648,20 @@ func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreat
 
 	// If message is in main channel (not a thread), create a new thread for the conversation
 	if !isInThread {
-		h.processMainChannelQuery(s, m, query, response)
+		h.processMainChannelQuery(ctx, s, m, query, trigger, response)
+	} else if streamed {
+		h.attachFeedback(s, interaction, m.ChannelID, streamedMessageID)
+		h.logger.Info("AI contextual response streamed successfully in existing thread",
+			"response_length", len(response),
+			"message_id", m.ID)
 	} else {
 		// If already in a thread, reply directly with contextual response
 		// Handle Discord's 2000 character limit by chunking if necessary
-		if err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true); err != nil {
+		response = h.appendKnowledgeSources(response)
+		if message, err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true); err != nil {
 			h.logger.Error("Failed to send AI response in thread", "error", err)
 		} else {
+			h.attachFeedbackToMessage(s, interaction, message)
 			h.logger.Info("AI contextual response sent successfully in existing thread",
 				"response_length", len(response),
 				"message_id", m.ID)
@@ -543,16 +670,40 @@ func (h *Handler) processAIQuery(s *discordgo.Session, m *discordgo.MessageCreat
 }
 
 // processMainChannelQuery handles AI queries from main channels by creating threads
-func (h *Handler) processMainChannelQuery(s *discordgo.Session, m *discordgo.MessageCreate, query string, response string) {
+func (h *Handler) processMainChannelQuery(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, trigger string, response string) {
 	h.logger.Info("Processing main channel query, creating thread with integrated summarization", "query", query)
 
 	// Start typing indicator to show the bot is processing
 	stopTyping := h.triggerTypingIndicator(s, m.ChannelID)
 	defer stopTyping() // Ensure typing stops when function exits
 
+	ctx, interaction := h.beginInteraction(ctx, m, trigger, query, false)
+	defer h.recordInteraction(interaction)
+
+	// Stream answers that are not cached into the thread as they are generated
+	aiResponse, summary, hit, store := h.lookupCachedAnswer(interaction, query)
+	if !hit && h.streamingAvailable(s) {
+		h.streamAnswerInNewThread(ctx, s, m, interaction, query, store, newThreadAnswer{
+			title:        func(summary string) string { return h.threadTitle(query, summary) },
+			fallbackLead: "I encountered an issue creating a thread for our conversation. Here's my response:\n\n",
+		})
+		return
+	}
+
 	// Use integrated query with summary to get both response and thread title in one API call
-	aiResponse, summary, err := h.aiService.QueryAIWithSummary(query)
+	var err error
+	if !hit {
+		aiResponse, summary, err = h.aiService.QueryAIWithSummary(ctx, query)
+		if err == nil {
+			store(aiResponse, summary)
+		}
+	}
+	interaction.complete(aiResponse, err)
 	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("Failed to get AI response with summary", "error", err)
 		// Fallback: reply in main channel if AI query fails
 		errorMsg := "I'm sorry, I encountered an error while processing your request. Please try again later."
@@ -562,17 +713,11 @@ func (h *Handler) processMainChannelQuery(s *discordgo.Session, m *discordgo.Mes
 		return
 	}
 
-	// Determine thread title from extracted summary
-	var threadTitle string
-	if summary != "" {
-		threadTitle = summary
-		h.logger.Info("Using extracted summary as thread title", "summary", summary, "length", len(summary))
-	} else {
-		// Fallback to manual title creation if summary extraction failed
-		h.logger.Warn("No summary extracted, using fallback title generation")
-		threadTitle = h.createFallbackTitle(query)
-	}
+	// Link the knowledge base sections the answer is based on
+	aiResponse = h.appendKnowledgeSources(aiResponse)
 
+	// Determine thread title from extracted summary
+	threadTitle := h.threadTitle(query, summary)
 	h.logger.Info("Thread title determined", "title", threadTitle, "length", len(threadTitle), "api_calls_saved", 1)
 
 	// Create a public thread in the channel
@@ -583,7 +728,7 @@ func (h *Handler) processMainChannelQuery(s *discordgo.Session, m *discordgo.Mes
 		// Fallback: reply in main channel if thread creation fails
 		errorMsg := "I encountered an issue creating a thread for our conversation. Here's my response:"
 		fallbackResponse := errorMsg + "\n\n" + aiResponse
-		if err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
+		if _, err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
 			h.logger.Error("Failed to send fallback response", "error", err)
 		}
 		return
@@ -593,23 +738,25 @@ func (h *Handler) processMainChannelQuery(s *discordgo.Session, m *discordgo.Mes
 		"thread_id", thread.ID,
 		"thread_name", thread.Name,
 		"parent_channel", m.ChannelID)
+	interaction.setThread(thread.ID)
 
 	// Record thread ownership for auto-response functionality (AC 1.4.5)
 	h.recordThreadOwnership(thread.ID, m.Author.ID, s.State.User.ID)
 
 	// Post the AI response as the first message in the newly created thread
 	// Handle Discord's 2000 character limit by chunking if necessary
-	if err := h.sendResponseInChunksWithOptions(s, thread.ID, aiResponse, true); err != nil {
+	if message, err := h.sendResponseInChunksWithOptions(s, thread.ID, aiResponse, true); err != nil {
 		h.logger.Error("Failed to send AI response in new thread", "error", err, "thread_id", thread.ID)
 
 		// If we can't post in the thread, try to reply in main channel as fallback
 		errorMsg := "I created a thread but couldn't post my response there. Here's my answer:"
 		fallbackResponse := errorMsg + "\n\n" + aiResponse
 		// Also chunk the fallback response if needed
-		if err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
+		if _, err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
 			h.logger.Error("Failed to send fallback response after thread creation", "error", err)
 		}
 	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
 		h.logger.Info("AI response posted successfully in new thread with integrated summarization",
 			"response_length", len(aiResponse),
 			"thread_id", thread.ID,
@@ -620,7 +767,7 @@ func (h *Handler) processMainChannelQuery(s *discordgo.Session, m *discordgo.Mes
 }
 
 // processReplyMentionInMainChannel handles reply mentions in main channels by creating threads with attribution
-func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *discordgo.MessageCreate, query string, referencedMessage *discordgo.Message) {
+func (h *Handler) processReplyMentionInMainChannel(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, referencedMessage *discordgo.Message) {
 	h.logger.Info("Processing reply mention in main channel, creating thread with attribution",
 		"query", query,
 		"referenced_author", referencedMessage.Author.Username)
@@ -629,9 +776,41 @@ func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *disc
 	stopTyping := h.triggerTypingIndicator(s, m.ChannelID)
 	defer stopTyping() // Ensure typing stops when function exits
 
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerReplyMention, query, false)
+	defer h.recordInteraction(interaction)
+
+	// Create attribution message for the reply mention
+	attributionText := fmt.Sprintf("*Responding to %s's message: \"%s\"*\n\n",
+		referencedMessage.Author.Username,
+		h.truncateForAttribution(referencedMessage.Content))
+
+	// Stream answers that are not cached into the thread as they are generated
+	aiResponse, summary, hit, store := h.lookupCachedAnswer(interaction, query)
+	if !hit && h.streamingAvailable(s) {
+		h.streamAnswerInNewThread(ctx, s, m, interaction, query, store, newThreadAnswer{
+			title: func(summary string) string {
+				return h.replyMentionThreadTitle(query, summary, referencedMessage.Author.Username)
+			},
+			lead:         attributionText,
+			fallbackLead: fmt.Sprintf("*Responding to %s's message:*\n\n", referencedMessage.Author.Username),
+		})
+		return
+	}
+
 	// Use integrated query with summary to get both response and thread title in one API call
-	aiResponse, summary, err := h.aiService.QueryAIWithSummary(query)
+	var err error
+	if !hit {
+		aiResponse, summary, err = h.aiService.QueryAIWithSummary(ctx, query)
+		if err == nil {
+			store(aiResponse, summary)
+		}
+	}
+	interaction.complete(aiResponse, err)
 	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("Failed to get AI response with summary for reply mention", "error", err)
 		// Fallback: reply in main channel if AI query fails
 		errorMsg := "I'm sorry, I encountered an error while processing your request. Please try again later."
@@ -641,22 +820,11 @@ func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *disc
 		return
 	}
 
-	// Create thread title with reply mention context
-	var threadTitle string
-	if summary != "" {
-		threadTitle = fmt.Sprintf("Re: %s - %s", referencedMessage.Author.Username, summary)
-		h.logger.Info("Using reply mention summary as thread title", "summary", summary, "referenced_author", referencedMessage.Author.Username)
-	} else {
-		// Fallback to manual title creation if summary extraction failed
-		h.logger.Warn("No summary extracted for reply mention, using fallback title generation")
-		threadTitle = fmt.Sprintf("Re: %s - %s", referencedMessage.Author.Username, h.createFallbackTitle(query))
-	}
-
-	// Ensure thread title doesn't exceed Discord's limit (100 characters)
-	if len(threadTitle) > 100 {
-		threadTitle = threadTitle[:97] + "..."
-	}
+	// Link the knowledge base sections the answer is based on
+	aiResponse = h.appendKnowledgeSources(aiResponse)
 
+	// Create thread title with reply mention context
+	threadTitle := h.replyMentionThreadTitle(query, summary, referencedMessage.Author.Username)
 	h.logger.Info("Reply mention thread title determined", "title", threadTitle, "length", len(threadTitle))
 
 	// Create a public thread in the channel
@@ -667,7 +835,7 @@ func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *disc
 		// Fallback: reply in main channel if thread creation fails
 		attributionText := fmt.Sprintf("*Responding to %s's message:*\n\n", referencedMessage.Author.Username)
 		fallbackResponse := attributionText + aiResponse
-		if err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
+		if _, err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
 			h.logger.Error("Failed to send fallback response for reply mention", "error", err)
 		}
 		return
@@ -677,28 +845,25 @@ func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *disc
 		"thread_id", thread.ID,
 		"thread_name", thread.Name,
 		"parent_channel", m.ChannelID)
+	interaction.setThread(thread.ID)
 
 	// Record thread ownership for auto-response functionality
 	h.recordThreadOwnership(thread.ID, m.Author.ID, s.State.User.ID)
 
-	// Create attribution message for the reply mention
-	attributionText := fmt.Sprintf("*Responding to %s's message: \"%s\"*\n\n",
-		referencedMessage.Author.Username,
-		h.truncateForAttribution(referencedMessage.Content))
-
 	// Combine attribution with AI response
 	responseWithAttribution := attributionText + aiResponse
 
 	// Post the AI response with attribution as the first message in the newly created thread
-	if err := h.sendResponseInChunksWithOptions(s, thread.ID, responseWithAttribution, true); err != nil {
+	if message, err := h.sendResponseInChunksWithOptions(s, thread.ID, responseWithAttribution, true); err != nil {
 		h.logger.Error("Failed to send AI response in new reply mention thread", "error", err, "thread_id", thread.ID)
 
 		// If we can't post in the thread, try to reply in main channel as fallback
 		fallbackResponse := fmt.Sprintf("I created a thread but couldn't post my response there. Here's my answer:\n\n%s", responseWithAttribution)
-		if err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
+		if _, err := h.sendResponseInChunks(s, m.ChannelID, fallbackResponse); err != nil {
 			h.logger.Error("Failed to send fallback response after reply mention thread creation", "error", err)
 		}
 	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
 		h.logger.Info("AI response with attribution posted successfully in new reply mention thread",
 			"response_length", len(responseWithAttribution),
 			"thread_id", thread.ID,
@@ -709,7 +874,7 @@ func (h *Handler) processReplyMentionInMainChannel(s *discordgo.Session, m *disc
 }
 
 // processReplyMentionInThread handles reply mentions within existing threads
-func (h *Handler) processReplyMentionInThread(s *discordgo.Session, m *discordgo.MessageCreate, query string, referencedMessage *discordgo.Message) {
+func (h *Handler) processReplyMentionInThread(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, referencedMessage *discordgo.Message) {
 	h.logger.Info("Processing reply mention in existing thread",
 		"query", query,
 		"thread_id", m.ChannelID,
@@ -722,28 +887,34 @@ func (h *Handler) processReplyMentionInThread(s *discordgo.Session, m *discordgo
 	var response string
 	var err error
 
-	// Fetch thread history for contextual response
-	const historyLimit = 50
-	includeAllMessages := true
-	threadMessages, historyErr := h.fetchThreadHistory(s, m.ChannelID, s.State.User.ID, historyLimit, includeAllMessages)
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerReplyMention, query, true)
+	defer h.recordInteraction(interaction)
+
+	// Fetch thread history for contextual response, fitted to the model context with older messages summarized
+	historySummary, threadMessages, historyErr := h.loadThreadHistory(ctx, s, m.ChannelID, query)
 
 	if historyErr != nil {
 		h.logger.Error("Failed to fetch thread history for reply mention, falling back to regular query",
 			"error", historyErr, "channel_id", m.ChannelID)
 		// Fallback to regular query if history retrieval fails
-		response, err = h.aiService.QueryAI(query)
+		response, err = h.aiService.QueryAI(ctx, query)
 	} else {
-		// Format conversation history for AI context
 		conversationHistory := h.formatConversationHistory(threadMessages)
 		h.logger.Info("Using contextual query with thread history for reply mention",
 			"history_messages", len(threadMessages),
-			"history_length", len(conversationHistory))
+			"history_length", len(conversationHistory),
+			"summary_length", len(historySummary))
 
 		// Use contextual query with conversation history
-		response, err = h.aiService.QueryWithContext(query, conversationHistory)
+		response, err = h.queryWithHistory(ctx, query, historySummary, threadMessages, s.State.User.ID, m.ID)
 	}
 
+	interaction.complete(response, err)
 	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("AI service error for reply mention in thread", "error", err, "query", query)
 
 		// Send user-friendly error message
@@ -759,13 +930,14 @@ func (h *Handler) processReplyMentionInThread(s *discordgo.Session, m *discordgo
 		referencedMessage.Author.Username,
 		h.truncateForAttribution(referencedMessage.Content))
 
-	// Combine attribution with AI response
-	responseWithAttribution := attributionText + response
+	// Combine attribution with AI response and the knowledge base sections it is based on
+	responseWithAttribution := attributionText + h.appendKnowledgeSources(response)
 
 	// Send response with attribution in the existing thread
-	if err := h.sendResponseInChunksWithOptions(s, m.ChannelID, responseWithAttribution, true); err != nil {
+	if message, err := h.sendResponseInChunksWithOptions(s, m.ChannelID, responseWithAttribution, true); err != nil {
 		h.logger.Error("Failed to send AI response with attribution in thread", "error", err)
 	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
 		h.logger.Info("AI response with attribution sent successfully in existing thread",
 			"response_length", len(responseWithAttribution),
 			"message_id", m.ID,
@@ -828,6 +1000,29 @@ func (h *Handler) deleteReplyMessage(s *discordgo.Session, m *discordgo.MessageC
 		"author", m.Author.Username)
 }
 
+// threadTitle returns the title of the thread answering query, from the AI generated summary when there is one
+func (h *Handler) threadTitle(query string, summary string) string {
+	if summary != "" {
+		h.logger.Info("Using extracted summary as thread title", "summary", summary, "length", len(summary))
+		return summary
+	}
+
+	// Fallback to manual title creation if summary extraction failed
+	h.logger.Warn("No summary extracted, using fallback title generation")
+	return h.createFallbackTitle(query)
+}
+
+// replyMentionThreadTitle returns the title of the thread answering a reply mention, naming the referenced author
+func (h *Handler) replyMentionThreadTitle(query string, summary string, referencedAuthor string) string {
+	threadTitle := fmt.Sprintf("Re: %s - %s", referencedAuthor, h.threadTitle(query, summary))
+
+	// Ensure thread title doesn't exceed Discord's limit (100 characters)
+	if len(threadTitle) > 100 {
+		threadTitle = threadTitle[:97] + "..."
+	}
+	return threadTitle
+}
+
 // createFallbackTitle creates a simple fallback title when AI summarization fails
 func (h *Handler) createFallbackTitle(query string) string {
 	// Simple fallback: take first few words
@@ -909,7 +1104,7 @@ func (h *Handler) formatConversationHistory(messages []*discordgo.Message) strin
 		// Format: "Username: Message content" (with special handling for bot messages)
 		if msg.Author.Bot {
 			// Mark bot messages clearly in the conversation history
-			conversationText.WriteString(fmt.Sprintf("Bot (%s): %s\n", msg.Author.Username, msg.Content))
+			conversationText.WriteString(fmt.Sprintf("Bot (%s): %s\n", msg.Author.Username, stripKnowledgeSources(msg.Content)))
 		} else {
 			conversationText.WriteString(fmt.Sprintf("%s: %s\n", msg.Author.Username, msg.Content))
 		}
@@ -918,6 +1113,81 @@ func (h *Handler) formatConversationHistory(messages []*discordgo.Message) strin
 	return strings.TrimSpace(conversationText.String())
 }
 
+// buildChatHistory converts Discord messages to role-tagged history for chat-capable AI services.
+// The bot's own messages become assistant turns, everyone else's become user turns prefixed with their
+// username, and the message currently being answered is skipped since it is sent as the query
+func (h *Handler) buildChatHistory(messages []*discordgo.Message, botID string, currentMessageID string) []service.ChatMessage {
+	var history []service.ChatMessage
+	for _, msg := range messages {
+		if msg.ID == currentMessageID || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+
+		message := service.ChatMessage{Role: service.ChatRoleUser, Content: fmt.Sprintf("%s: %s", msg.Author.Username, msg.Content)}
+		if msg.Author.ID == botID {
+			message = service.ChatMessage{Role: service.ChatRoleAssistant, Content: stripKnowledgeSources(msg.Content)}
+		}
+
+		// Merge consecutive messages from the same role so turns strictly alternate
+		if last := len(history) - 1; last >= 0 && history[last].Role == message.Role {
+			history[last].Content += "\n" + message.Content
+			continue
+		}
+		history = append(history, message)
+	}
+
+	return history
+}
+
+// SetResponseCache answers repeated standalone questions from cache instead of querying the AI service
+func (h *Handler) SetResponseCache(cache *service.ResponseCache) {
+	h.responseCache = cache
+}
+
+// queryAIWithSummary answers a standalone question and generates its thread title, from the response cache
+// when one is set, marking the interaction when the answer was cached
+func (h *Handler) queryAIWithSummary(ctx context.Context, interaction *pendingInteraction, query string) (string, string, error) {
+	response, summary, hit, store := h.lookupCachedAnswer(interaction, query)
+	if hit {
+		return response, summary, nil
+	}
+
+	response, summary, err := h.aiService.QueryAIWithSummary(ctx, query)
+	if err == nil {
+		store(response, summary)
+	}
+	return response, summary, err
+}
+
+// lookupCachedAnswer returns the cached answer and thread title for a standalone question when a response cache
+// is set, marking the interaction on a hit. On a miss, store caches the answer once it has been generated.
+func (h *Handler) lookupCachedAnswer(interaction *pendingInteraction, query string) (response string, summary string, hit bool, store func(response, summary string)) {
+	if h.responseCache == nil {
+		return "", "", false, func(string, string) {}
+	}
+
+	response, summary, hit, store = h.responseCache.Lookup(query)
+	if hit {
+		interaction.cached = true
+		h.logger.Info("Answered from response cache", "query_length", len(query))
+	}
+	return response, summary, hit, store
+}
+
+// queryWithHistory sends a contextual query, passing role-tagged history to AI services with chat support
+// and the formatted conversation history to the rest. A non-empty summary of older messages leads the history.
+func (h *Handler) queryWithHistory(ctx context.Context, query string, summary string, messages []*discordgo.Message, botID string, currentMessageID string) (string, error) {
+	if chatService, ok := h.aiService.(service.ChatAIService); ok {
+		return chatService.QueryWithMessages(ctx, query, prependThreadSummary(summary, h.buildChatHistory(messages, botID, currentMessageID)))
+	}
+
+	conversationHistory := h.formatConversationHistory(messages)
+	if summary != "" {
+		conversationHistory = strings.TrimSpace(threadSummaryPrefix + summary + "\n" + conversationHistory)
+	}
+	return h.aiService.QueryWithContext(ctx, query, conversationHistory)
+}
+
 // recordThreadOwnership stores thread ownership information for auto-response functionality
 func (h *Handler) recordThreadOwnership(threadID string, originalUserID string, botID string) {
 	ownership := &storage.ThreadOwnership{
@@ -1049,11 +1319,151 @@ func (h *Handler) GetThreadOwnership(threadID string) (*ThreadOwnership, bool) {
 
 // SetMonitoredForumChannels configures which Forum channels should be monitored for automatic responses
 func (h *Handler) SetMonitoredForumChannels(channelIDs []string) {
-	h.monitoredForumChannels = make([]string, len(channelIDs))
-	copy(h.monitoredForumChannels, channelIDs)
+	channels := make([]string, len(channelIDs))
+	copy(channels, channelIDs)
+
+	h.configMu.Lock()
+	h.monitoredForumChannels = channels
+	h.configMu.Unlock()
+
 	h.logger.Info("Monitored Forum channels configured", "count", len(channelIDs), "channels", channelIDs)
 }
 
+// SetReplyMentionConfig replaces the reply mention behavior
+func (h *Handler) SetReplyMentionConfig(config ReplyMentionConfig) {
+	h.configMu.Lock()
+	h.replyMentionConfig = config
+	h.configMu.Unlock()
+
+	h.logger.Info("Reply mention configuration updated", "delete_reply_message", config.DeleteReplyMessage)
+}
+
+// SetReactionTriggerConfig replaces the reaction trigger settings
+func (h *Handler) SetReactionTriggerConfig(config ReactionTriggerConfig) {
+	h.configMu.Lock()
+	h.reactionTriggerConfig = config
+	h.configMu.Unlock()
+
+	h.logger.Info("Reaction trigger configuration updated",
+		"enabled", config.Enabled,
+		"trigger_emoji", config.TriggerEmoji,
+		"approved_user_count", len(config.ApprovedUserIDs),
+		"approved_role_count", len(config.ApprovedRoleNames))
+}
+
+// replyMention returns the current reply mention settings
+func (h *Handler) replyMention() ReplyMentionConfig {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.replyMentionConfig
+}
+
+// reactionTrigger returns the current reaction trigger settings
+func (h *Handler) reactionTrigger() ReactionTriggerConfig {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.reactionTriggerConfig
+}
+
+// reactionTriggerFor returns the reaction trigger settings for guildID, with its overrides applied over the global settings
+func (h *Handler) reactionTriggerFor(guildID string) ReactionTriggerConfig {
+	settings := h.reactionTrigger()
+	overrides := h.guildOverrides(guildID)
+	if len(overrides) == 0 {
+		return settings
+	}
+
+	parseBool := func(key string, current bool) bool {
+		value, ok := overrides[key]
+		if !ok {
+			return current
+		}
+		parsed, err := config.ParseBool(value)
+		if err != nil {
+			h.logger.Warn("Ignoring invalid guild override", "guild_id", guildID, "key", key, "value", value)
+			return current
+		}
+		return parsed
+	}
+
+	settings.Enabled = parseBool("REACTION_TRIGGER_ENABLED", settings.Enabled)
+	settings.RequireReaction = parseBool("REACTION_TRIGGER_REQUIRE_REACTION", settings.RequireReaction)
+	settings.RemoveTriggerReaction = parseBool("REACTION_TRIGGER_REMOVE_REACTION", settings.RemoveTriggerReaction)
+	if emoji, ok := overrides["REACTION_TRIGGER_EMOJI"]; ok && strings.TrimSpace(emoji) != "" {
+		settings.TriggerEmoji = strings.TrimSpace(emoji)
+	}
+	if userIDs, ok := overrides["REACTION_TRIGGER_APPROVED_USER_IDS"]; ok {
+		settings.ApprovedUserIDs = splitConfigList(userIDs)
+	}
+	if roleNames, ok := overrides["REACTION_TRIGGER_APPROVED_ROLE_NAMES"]; ok {
+		settings.ApprovedRoleNames = splitConfigList(roleNames)
+	}
+	return settings
+}
+
+// SetConfigService resolves guild overrides, including channel restrictions, through the config service's
+// cache instead of reading storage on every message
+func (h *Handler) SetConfigService(configService config.ConfigService) {
+	h.configService = configService
+	h.channelRestrictor.SetConfigService(configService)
+}
+
+// guildOverrides returns the configuration values overridden for guildID, or nil outside a guild
+func (h *Handler) guildOverrides(guildID string) map[string]string {
+	if guildID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if h.configService != nil {
+		overrides, err := h.configService.GetGuildConfigs(ctx, guildID)
+		if err != nil {
+			h.logger.Error("Failed to load guild configuration overrides", "error", err, "guild_id", guildID)
+			return nil
+		}
+		return overrides
+	}
+	if h.storageService == nil {
+		return nil
+	}
+
+	configs, err := h.storageService.GetGuildConfigurations(ctx, guildID)
+	if err != nil {
+		h.logger.Error("Failed to load guild configuration overrides", "error", err, "guild_id", guildID)
+		return nil
+	}
+
+	overrides := make(map[string]string, len(configs))
+	for _, override := range configs {
+		overrides[override.Key] = override.Value
+	}
+	return overrides
+}
+
+// splitConfigList splits a comma-separated configuration value, dropping empty entries
+func splitConfigList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SetUserRateLimiter enables per-user rate limiting on every AI entry point
+func (h *Handler) SetUserRateLimiter(userRateLimiter *monitor.UserRateLimiter) {
+	h.userRateLimiter = userRateLimiter
+	h.logger.Info("User rate limiter configured for handler")
+}
+
+// SetMetrics enables recording of query triggers and rate limit denials
+func (h *Handler) SetMetrics(metrics *monitor.Metrics) {
+	h.metrics = metrics
+}
+
 // isForumChannel checks if a channel is a Discord Forum channel
 func (h *Handler) isForumChannel(s *discordgo.Session, channelID string) bool {
 	if s == nil || s.Ratelimiter == nil {
@@ -1090,8 +1500,21 @@ func (h *Handler) isForumPost(s *discordgo.Session, channel *discordgo.Channel)
 	return parentChannel.Type == discordgo.ChannelTypeGuildForum
 }
 
-// shouldMonitorForumChannel checks if a Forum channel should be monitored for automatic responses
-func (h *Handler) shouldMonitorForumChannel(channelID string) bool {
+// shouldMonitorForumChannel checks if a Forum channel should be monitored for automatic responses.
+// A guild's MONITORED_FORUM_CHANNELS override replaces the global list.
+func (h *Handler) shouldMonitorForumChannel(guildID, channelID string) bool {
+	if channels, ok := h.guildOverrides(guildID)["MONITORED_FORUM_CHANNELS"]; ok {
+		for _, monitoredID := range splitConfigList(channels) {
+			if monitoredID == channelID {
+				return true
+			}
+		}
+		return false
+	}
+
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+
 	for _, monitoredID := range h.monitoredForumChannels {
 		if monitoredID == channelID {
 			return true
@@ -1151,13 +1574,14 @@ func (h *Handler) RecoverThreadOwnership(ctx context.Context) error {
 	return nil
 }
 
-// sendResponseInChunks sends a response message, splitting it into chunks if it exceeds Discord's 2000 character limit
-func (h *Handler) sendResponseInChunks(s *discordgo.Session, channelID string, response string) error {
+// sendResponseInChunks sends a response message, splitting it into chunks if it exceeds Discord's 2000 character limit.
+// Returns the last message sent
+func (h *Handler) sendResponseInChunks(s *discordgo.Session, channelID string, response string) (*discordgo.Message, error) {
 	return h.sendResponseInChunksWithOptions(s, channelID, response, false)
 }
 
-// sendResponseInChunksWithOptions sends a response message with chunking options
-func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelID string, response string, inThread bool) error {
+// sendResponseInChunksWithOptions sends a response message with chunking options and returns the last message sent
+func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelID string, response string, inThread bool) (*discordgo.Message, error) {
 	const maxDiscordMessageLength = 2000
 
 	// Ensure proper Discord formatting for line breaks
@@ -1165,8 +1589,7 @@ func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelI
 
 	// If response fits in one message, send it directly
 	if len(formattedResponse) <= maxDiscordMessageLength {
-		_, err := s.ChannelMessageSend(channelID, formattedResponse)
-		return err
+		return s.ChannelMessageSend(channelID, formattedResponse)
 	}
 
 	h.logger.Info("Response exceeds Discord limit, chunking message",
@@ -1192,6 +1615,7 @@ func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelI
 		"headers_needed", needsHeaders,
 		"in_thread", inThread)
 
+	var lastMessage *discordgo.Message
 	for i, chunk := range chunks {
 		// Add chunk indicator only if we determined headers are needed
 		var messageContent string
@@ -1205,14 +1629,16 @@ func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelI
 			messageContent = chunk
 		}
 
-		if _, err := s.ChannelMessageSend(channelID, messageContent); err != nil {
+		message, err := s.ChannelMessageSend(channelID, messageContent)
+		if err != nil {
 			h.logger.Error("Failed to send message chunk",
 				"error", err,
 				"chunk", i+1,
 				"total_chunks", len(chunks),
 				"channel_id", channelID)
-			return fmt.Errorf("failed to send chunk %d/%d: %w", i+1, len(chunks), err)
+			return nil, fmt.Errorf("failed to send chunk %d/%d: %w", i+1, len(chunks), err)
 		}
+		lastMessage = message
 
 		h.logger.Info("Message chunk sent successfully",
 			"chunk", i+1,
@@ -1225,7 +1651,7 @@ func (h *Handler) sendResponseInChunksWithOptions(s *discordgo.Session, channelI
 		}
 	}
 
-	return nil
+	return lastMessage, nil
 }
 
 // splitResponseIntoChunks splits a long response into chunks at word boundaries
@@ -1638,10 +2064,16 @@ func (h *Handler) recoverChannelMessages(s *discordgo.Session, state *storage.Me
 	return processedCount, nil
 }
 
-// HandleMessageReactionAdd processes Discord message reaction events for reaction-based triggers
+// HandleMessageReactionAdd processes Discord message reaction events for feedback votes and reaction-based triggers
 func (h *Handler) HandleMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	// Record 👍/👎 votes on answers independently of reaction triggers
+	if h.handleFeedbackReaction(s, r) {
+		return
+	}
+
 	// Skip if reaction triggers are disabled
-	if !h.reactionTriggerConfig.Enabled {
+	reactionConfig := h.reactionTriggerFor(r.GuildID)
+	if !reactionConfig.Enabled {
 		return
 	}
 
@@ -1651,10 +2083,10 @@ func (h *Handler) HandleMessageReactionAdd(s *discordgo.Session, r *discordgo.Me
 	}
 
 	// Check if the reaction matches the configured trigger emoji
-	if r.Emoji.Name != h.reactionTriggerConfig.TriggerEmoji {
+	if r.Emoji.Name != reactionConfig.TriggerEmoji {
 		h.logger.Debug("Reaction emoji does not match trigger",
 			"reaction_emoji", r.Emoji.Name,
-			"trigger_emoji", h.reactionTriggerConfig.TriggerEmoji,
+			"trigger_emoji", reactionConfig.TriggerEmoji,
 			"user_id", r.UserID)
 		return
 	}
@@ -1687,8 +2119,19 @@ func (h *Handler) HandleMessageReactionAdd(s *discordgo.Session, r *discordgo.Me
 		"username", user.Username,
 		"message_id", r.MessageID)
 
+	// Enforce per-user rate limits for the reacting user before any AI call
+	refund, admitted := h.checkUserRateLimit(s, r.UserID, r.GuildID, r.ChannelID, &discordgo.MessageReference{
+		MessageID: r.MessageID,
+		ChannelID: r.ChannelID,
+		GuildID:   r.GuildID,
+	})
+	if !admitted {
+		return
+	}
+	h.metrics.RecordQuery(monitor.TriggerReaction)
+
 	// Add confirmation reaction if required
-	if h.reactionTriggerConfig.RequireReaction {
+	if reactionConfig.RequireReaction {
 		err = s.MessageReactionAdd(r.ChannelID, r.MessageID, "✅")
 		if err != nil {
 			h.logger.Error("Failed to add confirmation reaction",
@@ -1702,7 +2145,7 @@ func (h *Handler) HandleMessageReactionAdd(s *discordgo.Session, r *discordgo.Me
 	}
 
 	// Remove the trigger reaction if configured to do so
-	if h.reactionTriggerConfig.RemoveTriggerReaction {
+	if reactionConfig.RemoveTriggerReaction {
 		err = s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.Name, r.UserID)
 		if err != nil {
 			h.logger.Warn("Failed to remove trigger reaction - continuing with normal processing",
@@ -1764,14 +2207,27 @@ func (h *Handler) HandleMessageReactionAdd(s *discordgo.Session, r *discordgo.Me
 	// Record message state before processing
 	h.recordMessageState(messageCreate, isInThread)
 
+	priority := service.AIJobPriorityNewQuestion
+	if isInThread {
+		priority = service.AIJobPriorityFollowUp
+	}
+	ctx, release, ok := h.startAIJob(s, messageCreate, r.UserID, priority)
+	if !ok {
+		refund()
+		return
+	}
+	defer release()
+
 	// Process the AI query with reaction trigger attribution
-	h.processReactionTriggerQuery(s, messageCreate, queryText, isInThread, user.Username)
+	h.processReactionTriggerQuery(ctx, s, messageCreate, queryText, isInThread, user.Username)
 }
 
 // isUserAuthorizedForReactionTrigger checks if a user is authorized to use reaction triggers
 func (h *Handler) isUserAuthorizedForReactionTrigger(s *discordgo.Session, user *discordgo.User, guildID string) bool {
+	reactionConfig := h.reactionTriggerFor(guildID)
+
 	// Check if user ID is in approved list
-	for _, approvedUserID := range h.reactionTriggerConfig.ApprovedUserIDs {
+	for _, approvedUserID := range reactionConfig.ApprovedUserIDs {
 		if user.ID == approvedUserID {
 			h.logger.Info("User authorized by user ID",
 				"user_id", user.ID,
@@ -1781,7 +2237,7 @@ func (h *Handler) isUserAuthorizedForReactionTrigger(s *discordgo.Session, user
 	}
 
 	// Check if user has approved roles (only for guild channels)
-	if guildID != "" && len(h.reactionTriggerConfig.ApprovedRoleNames) > 0 {
+	if guildID != "" && len(reactionConfig.ApprovedRoleNames) > 0 {
 		member, err := s.GuildMember(guildID, user.ID)
 		if err != nil {
 			h.logger.Error("Failed to fetch guild member for role check",
@@ -1813,7 +2269,7 @@ func (h *Handler) isUserAuthorizedForReactionTrigger(s *discordgo.Session, user
 				continue
 			}
 
-			for _, approvedRoleName := range h.reactionTriggerConfig.ApprovedRoleNames {
+			for _, approvedRoleName := range reactionConfig.ApprovedRoleNames {
 				if roleName == approvedRoleName {
 					h.logger.Info("User authorized by role",
 						"user_id", user.ID,
@@ -1828,24 +2284,156 @@ func (h *Handler) isUserAuthorizedForReactionTrigger(s *discordgo.Session, user
 	return false
 }
 
+// checkUserRateLimit is the shared admission check run before every AI call (mention, reply mention,
+// auto-response, DM, Forum post and reaction trigger). It returns true if the request may proceed, along with
+// a function that takes the request back off the user's quota if the AI work then cannot start.
+// Admins (ADMIN_ROLE_NAMES) bypass limits; blocked users are answered with their rate limit status.
+func (h *Handler) checkUserRateLimit(s *discordgo.Session, userID, guildID, channelID string, replyTo *discordgo.MessageReference) (func(), bool) {
+	noRefund := func() {}
+	if h.userRateLimiter == nil {
+		return noRefund, true
+	}
+
+	ctx := context.Background()
+	if !h.userRateLimiter.IsEnabledForGuild(ctx, guildID) {
+		return noRefund, true
+	}
+
+	// Admin bypass requires Discord role information, so it is resolved here rather than in the limiter
+	if h.isUserRateLimitExempt(ctx, s, userID, guildID) {
+		h.logger.Info("Admin user bypassing rate limits", "user_id", userID, "guild_id", guildID)
+		return noRefund, true
+	}
+
+	// Drop rapid-fire requests silently to avoid amplifying spam with replies
+	if lastRequest := h.userRateLimiter.GetLastRequestTime(userID); !lastRequest.IsZero() {
+		allowed, err := h.userRateLimiter.PreventRapidSuccessiveRequests(ctx, userID, lastRequest)
+		if err == nil && !allowed {
+			h.logger.Info("Request dropped due to rapid successive requests",
+				"user_id", userID,
+				"channel_id", channelID)
+			h.metrics.RecordUserRateLimitDenial("rapid_succession")
+			return noRefund, false
+		}
+	}
+
+	result, err := h.userRateLimiter.CheckUserRateLimit(ctx, userID, guildID)
+	if err != nil {
+		// Fail open on storage errors to avoid blocking legitimate usage
+		h.logger.Error("Failed to check user rate limit", "error", err, "user_id", userID)
+		return noRefund, true
+	}
+
+	if !result.Allowed {
+		h.logger.Info("User rate limit exceeded",
+			"user_id", userID,
+			"channel_id", channelID,
+			"time_window", result.TimeWindow,
+			"current_count", result.CurrentCount,
+			"limit", result.WindowLimit,
+			"next_available", result.NextAvailableTime)
+		h.metrics.RecordUserRateLimitDenial(result.TimeWindow)
+		h.sendRateLimitResponse(ctx, s, userID, guildID, channelID, replyTo, result)
+		return noRefund, false
+	}
+
+	requestTime := time.Now()
+	if err := h.userRateLimiter.RecordUserRequest(ctx, userID); err != nil {
+		h.logger.Error("Failed to record user request", "error", err, "user_id", userID)
+		return noRefund, true
+	}
+
+	return func() {
+		if err := h.userRateLimiter.ForgetUserRequest(context.Background(), userID, requestTime); err != nil {
+			h.logger.Error("Failed to refund user request", "error", err, "user_id", userID)
+		}
+	}, true
+}
+
+// isUserRateLimitExempt checks whether the user holds one of the ADMIN_ROLE_NAMES roles in the guild
+func (h *Handler) isUserRateLimitExempt(ctx context.Context, s *discordgo.Session, userID, guildID string) bool {
+	// Roles cannot be resolved for DMs or without a live session
+	if guildID == "" || s == nil || s.Ratelimiter == nil {
+		return false
+	}
+
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		h.logger.Error("Failed to fetch guild member for rate limit bypass check",
+			"error", err,
+			"user_id", userID,
+			"guild_id", guildID)
+		return false
+	}
+
+	roles, err := s.GuildRoles(guildID)
+	if err != nil {
+		h.logger.Error("Failed to fetch guild roles for rate limit bypass check",
+			"error", err,
+			"guild_id", guildID)
+		return false
+	}
+
+	roleIDToName := make(map[string]string)
+	for _, role := range roles {
+		roleIDToName[role.ID] = role.Name
+	}
+
+	isAdmin, err := h.userRateLimiter.CheckUserAdminByRoles(ctx, member.Roles, roleIDToName)
+	if err != nil {
+		h.logger.Error("Failed to check admin roles for rate limit bypass", "error", err, "user_id", userID)
+		return false
+	}
+
+	return isAdmin
+}
+
+// sendRateLimitResponse tells a blocked user when they can try again along with their current usage
+func (h *Handler) sendRateLimitResponse(ctx context.Context, s *discordgo.Session, userID, guildID, channelID string, replyTo *discordgo.MessageReference, result *monitor.RateLimitResult) {
+	if s == nil || s.Ratelimiter == nil {
+		return
+	}
+
+	message := result.UserFriendlyMsg
+	status, err := h.userRateLimiter.GetUserRateLimitStatusForGuild(ctx, userID, guildID)
+	if err != nil {
+		h.logger.Error("Failed to get user rate limit status", "error", err, "user_id", userID)
+	} else {
+		message = strings.TrimSpace(message + "\n\n" + h.userRateLimiter.FormatRateLimitStatusMessage(status))
+	}
+
+	if replyTo != nil {
+		_, err = s.ChannelMessageSendReply(channelID, message, replyTo)
+	} else {
+		_, err = s.ChannelMessageSend(channelID, message)
+	}
+	if err != nil {
+		h.logger.Error("Failed to send rate limit response", "error", err, "user_id", userID, "channel_id", channelID)
+	}
+}
+
 // processReactionTriggerQuery processes AI queries triggered by reactions (behaves like direct mention)
-func (h *Handler) processReactionTriggerQuery(s *discordgo.Session, m *discordgo.MessageCreate, queryText string, isInThread bool, triggerUser string) {
+func (h *Handler) processReactionTriggerQuery(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, queryText string, isInThread bool, triggerUser string) {
 	// Process reaction triggers exactly like direct mentions - no special attribution needed
 	// The reaction itself is the user intent signal, just like a direct mention would be
 
 	if isInThread {
 		// Process in existing thread (same as regular mention)
-		h.processReactionTriggerInThread(s, m, queryText, triggerUser)
+		h.processReactionTriggerInThread(ctx, s, m, queryText, triggerUser)
 	} else {
 		// Create new thread (same as regular mention)
-		h.processReactionTriggerInMainChannel(s, m, queryText, triggerUser)
+		h.processReactionTriggerInMainChannel(ctx, s, m, queryText, triggerUser)
 	}
 }
 
 // processReactionTriggerInMainChannel handles reaction triggers in main channels by creating a new thread
-func (h *Handler) processReactionTriggerInMainChannel(s *discordgo.Session, m *discordgo.MessageCreate, query string, triggerUser string) {
+func (h *Handler) processReactionTriggerInMainChannel(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, triggerUser string) {
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerReaction, query, false)
+	defer h.recordInteraction(interaction)
+
 	// Generate thread title using existing logic
-	response, title, err := h.aiService.QueryAIWithSummary(query)
+	response, title, err := h.queryAIWithSummary(ctx, interaction, query)
+	interaction.complete(response, err)
 	if err != nil {
 		h.logger.Error("AI service query failed for reaction trigger",
 			"error", err,
@@ -1853,6 +2441,7 @@ func (h *Handler) processReactionTriggerInMainChannel(s *discordgo.Session, m *d
 			"trigger_user", triggerUser)
 		return
 	}
+	response = h.appendKnowledgeSources(response)
 
 	// Use the same thread title as regular mentions (no special indicators needed)
 	threadTitle := title
@@ -1879,18 +2468,21 @@ func (h *Handler) processReactionTriggerInMainChannel(s *discordgo.Session, m *d
 		"thread_name", thread.Name,
 		"original_message_id", m.ID,
 		"trigger_user", triggerUser)
+	interaction.setThread(thread.ID)
 
 	// Record thread ownership for the original message author (not the trigger user)
 	h.recordThreadOwnership(thread.ID, m.Author.ID, s.State.User.ID)
 
 	// Send response in the new thread (no attribution needed - reaction is the intent signal)
-	if err := h.sendResponseInChunksWithOptions(s, thread.ID, response, true); err != nil {
+	message, err := h.sendResponseInChunksWithOptions(s, thread.ID, response, true)
+	if err != nil {
 		h.logger.Error("Failed to send reaction trigger response in thread",
 			"error", err,
 			"thread_id", thread.ID,
 			"trigger_user", triggerUser)
 		return
 	}
+	h.attachFeedbackToMessage(s, interaction, message)
 
 	h.logger.Info("Sent reaction trigger response in new thread",
 		"thread_id", thread.ID,
@@ -1899,11 +2491,12 @@ func (h *Handler) processReactionTriggerInMainChannel(s *discordgo.Session, m *d
 }
 
 // processReactionTriggerInThread handles reaction triggers in existing threads
-func (h *Handler) processReactionTriggerInThread(s *discordgo.Session, m *discordgo.MessageCreate, query string, triggerUser string) {
-	// Fetch thread history for contextual response
-	const historyLimit = 50
-	includeAllMessages := true
-	threadMessages, historyErr := h.fetchThreadHistory(s, m.ChannelID, s.State.User.ID, historyLimit, includeAllMessages)
+func (h *Handler) processReactionTriggerInThread(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string, triggerUser string) {
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerReaction, query, true)
+	defer h.recordInteraction(interaction)
+
+	// Fetch thread history for contextual response, fitted to the model context with older messages summarized
+	historySummary, threadMessages, historyErr := h.loadThreadHistory(ctx, s, m.ChannelID, query)
 
 	var response string
 	var err error
@@ -1912,18 +2505,19 @@ func (h *Handler) processReactionTriggerInThread(s *discordgo.Session, m *discor
 		h.logger.Error("Failed to fetch thread history for reaction trigger, falling back to regular query",
 			"error", historyErr, "thread_id", m.ChannelID)
 		// Fallback to regular query if history retrieval fails
-		response, err = h.aiService.QueryAI(query)
+		response, err = h.aiService.QueryAI(ctx, query)
 	} else {
-		// Format conversation history for AI context
 		conversationHistory := h.formatConversationHistory(threadMessages)
 		h.logger.Info("Using contextual query with thread history for reaction trigger",
 			"history_messages", len(threadMessages),
 			"history_length", len(conversationHistory),
+			"summary_length", len(historySummary),
 			"trigger_user", triggerUser)
 		// Use contextual query with conversation history
-		response, err = h.aiService.QueryWithContext(query, conversationHistory)
+		response, err = h.queryWithHistory(ctx, query, historySummary, threadMessages, s.State.User.ID, m.ID)
 	}
 
+	interaction.complete(response, err)
 	if err != nil {
 		h.logger.Error("AI service query failed for reaction trigger in thread",
 			"error", err,
@@ -1931,15 +2525,18 @@ func (h *Handler) processReactionTriggerInThread(s *discordgo.Session, m *discor
 			"trigger_user", triggerUser)
 		return
 	}
+	response = h.appendKnowledgeSources(response)
 
 	// Send response in the existing thread (no attribution needed - reaction is the intent signal)
-	if err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true); err != nil {
+	message, err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true)
+	if err != nil {
 		h.logger.Error("Failed to send reaction trigger response in thread",
 			"error", err,
 			"thread_id", m.ChannelID,
 			"trigger_user", triggerUser)
 		return
 	}
+	h.attachFeedbackToMessage(s, interaction, message)
 
 	h.logger.Info("Sent reaction trigger response in existing thread",
 		"thread_id", m.ChannelID,
@@ -2012,7 +2609,7 @@ func (h *Handler) processDMMessage(s *discordgo.Session, m *discordgo.MessageCre
 	if !h.verifyGuildMembership(s, m.Author.ID) {
 		// Send informative response for non-members
 		response := "Hello! I'm the BMAD Knowledge Bot. To interact with me, you need to be a member of a server where I'm active. Please ask a server administrator to invite me to your server, or join a server where I'm already present."
-		if err := h.sendResponseInChunks(s, m.ChannelID, response); err != nil {
+		if _, err := h.sendResponseInChunks(s, m.ChannelID, response); err != nil {
 			h.logger.Error("Failed to send non-member response", "error", err, "user_id", m.Author.ID)
 		} else {
 			h.logger.Info("Sent non-member response", "user_id", m.Author.ID)
@@ -2030,42 +2627,58 @@ func (h *Handler) processDMMessage(s *discordgo.Session, m *discordgo.MessageCre
 		return
 	}
 
-	// Handle /clear command to reset conversation history (AC 2.15.10)
-	if strings.ToLower(queryText) == "/clear" {
+	// Handle /clear to reset conversation history (AC 2.15.10) and /export and /delete for stored data
+	switch strings.ToLower(queryText) {
+	case "/clear":
 		h.handleDMClearCommand(s, m)
 		return
+	case "/export":
+		h.handleDMExportCommand(s, m)
+		return
+	case "/delete":
+		h.handleDMDeleteCommand(s, m)
+		return
 	}
 
 	h.logger.Info("Processing DM query", "user_id", m.Author.ID, "query_length", len(queryText))
 
+	// Enforce per-user rate limits before any AI call
+	refund, admitted := h.checkUserRateLimit(s, m.Author.ID, m.GuildID, m.ChannelID, m.Reference())
+	if !admitted {
+		return
+	}
+	h.metrics.RecordQuery(monitor.TriggerDM)
+
+	ctx, release, ok := h.startAIJob(s, m, m.Author.ID, service.AIJobPriorityDM)
+	if !ok {
+		refund()
+		return
+	}
+	defer release()
+
 	// Start typing indicator to show the bot is processing
 	stopTyping := h.triggerTypingIndicator(s, m.ChannelID)
 	defer stopTyping() // Ensure typing stops when function exits
 
-	// Check if we have conversation history for this DM channel (AC 2.13.4)
-	dmHistory, historyErr := h.fetchDMHistory(s, m.ChannelID, 50)
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerDM, queryText, false)
+	defer h.recordInteraction(interaction)
 
-	var response string
+	// Answer from the stored conversation memory, or the DM channel's history without one (AC 2.13.4)
+	var response, streamedMessageID string
+	var streamed bool
 	var err error
-
-	if historyErr != nil {
-		h.logger.Error("Failed to fetch DM history, using basic query",
-			"error", historyErr, "channel_id", m.ChannelID)
-		// Fallback to basic query if history retrieval fails
-		response, err = h.aiService.QueryAI(queryText)
-	} else if len(dmHistory) > 1 { // More than just the current message
-		// Use contextual query with DM conversation history
-		conversationHistory := h.formatConversationHistory(dmHistory)
-		h.logger.Info("Using contextual DM query with history",
-			"history_messages", len(dmHistory),
-			"history_length", len(conversationHistory))
-		response, err = h.aiService.QueryWithContext(queryText, conversationHistory)
+	if h.dmMemory != nil {
+		response, streamedMessageID, streamed, err = h.queryWithDMMemory(ctx, s, m, queryText)
 	} else {
-		// First message in DM conversation
-		response, err = h.aiService.QueryAI(queryText)
+		response, streamedMessageID, streamed, err = h.queryWithDMChannelHistory(ctx, s, m, queryText)
 	}
 
+	interaction.complete(response, err)
 	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("AI service error for DM", "error", err, "user_id", m.Author.ID)
 		errorMsg := "I'm sorry, I encountered an error while processing your request. Please try again later."
 		if _, err := s.ChannelMessageSend(m.ChannelID, errorMsg); err != nil {
@@ -2074,19 +2687,61 @@ func (h *Handler) processDMMessage(s *discordgo.Session, m *discordgo.MessageCre
 		return
 	}
 
-	// Add helpful reminder about /clear command (AC 2.15.10)
-	responseWithReminder := h.addClearCommandReminder(response)
+	h.rememberDMExchange(m.Author.ID, queryText, response)
+
+	// Streamed responses were delivered progressively with the reminder already appended
+	if streamed {
+		h.attachFeedback(s, interaction, m.ChannelID, streamedMessageID)
+		h.logger.Info("DM response streamed successfully",
+			"user_id", m.Author.ID,
+			"response_length", len(response))
+		return
+	}
+
+	// Link the knowledge base sections the answer is based on and add a reminder about /clear (AC 2.15.10)
+	responseWithReminder := h.finalizeDMResponse(response)
 
 	// Send response directly in DM channel (AC 2.13.5)
-	if err := h.sendResponseInChunks(s, m.ChannelID, responseWithReminder); err != nil {
+	if message, err := h.sendResponseInChunks(s, m.ChannelID, responseWithReminder); err != nil {
 		h.logger.Error("Failed to send DM response", "error", err, "user_id", m.Author.ID)
 	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
 		h.logger.Info("DM response sent successfully",
 			"user_id", m.Author.ID,
 			"response_length", len(responseWithReminder))
 	}
 }
 
+// queryWithDMChannelHistory answers a DM using the DM channel's Discord message history as context
+func (h *Handler) queryWithDMChannelHistory(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, query string) (response string, streamedMessageID string, streamed bool, err error) {
+	dmHistory, historyErr := h.fetchDMHistory(s, m.ChannelID, 50)
+
+	if historyErr != nil {
+		h.logger.Error("Failed to fetch DM history, using basic query",
+			"error", historyErr, "channel_id", m.ChannelID)
+		// Fallback to basic query if history retrieval fails
+		response, err = h.aiService.QueryAI(ctx, query)
+		return response, "", false, err
+	}
+
+	if len(dmHistory) <= 1 { // Nothing but the current message
+		// First message in DM conversation
+		return h.streamStandaloneQuery(ctx, s, m.ChannelID, nil, query, h.finalizeDMResponse)
+	}
+
+	// Use contextual query with DM conversation history
+	conversationHistory := h.formatConversationHistory(dmHistory)
+	h.logger.Info("Using contextual DM query with history",
+		"history_messages", len(dmHistory),
+		"history_length", len(conversationHistory))
+	chatHistory := h.buildChatHistory(dmHistory, s.State.User.ID, m.ID)
+	response, streamedMessageID, streamed, err = h.streamQueryWithHistory(ctx, s, m.ChannelID, nil, query, chatHistory, h.finalizeDMResponse)
+	if !streamed {
+		response, err = h.queryWithHistory(ctx, query, "", dmHistory, s.State.User.ID, m.ID)
+	}
+	return response, streamedMessageID, streamed, err
+}
+
 // fetchDMHistory retrieves message history from a DM channel for conversation context
 func (h *Handler) fetchDMHistory(s *discordgo.Session, channelID string, limit int) ([]*discordgo.Message, error) {
 	h.logger.Info("Fetching DM history", "channel_id", channelID, "limit", limit)
@@ -2131,54 +2786,50 @@ func (h *Handler) handleDMClearCommand(s *discordgo.Session, m *discordgo.Messag
 	stopTyping := h.triggerTypingIndicator(s, m.ChannelID)
 	defer stopTyping() // Ensure typing stops when function exits
 
-	// Clear conversation history by clearing message state for this DM channel
-	if h.storageService != nil {
-		go func() {
-			// Create independent context with timeout for database operation
-			dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-
-			// Clear the message state for this DM channel to reset conversation history
-			// We do this by setting a new message state with only the current /clear message
-			messageState := &storage.MessageState{
-				ChannelID:         m.ChannelID,
-				ThreadID:          nil, // DMs don't have threads
-				LastMessageID:     m.ID,
-				LastSeenTimestamp: time.Now().Unix(),
-			}
+	// Delete the stored conversation and its summary so the next message starts fresh
+	if h.dmMemory != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := h.dmMemory.Clear(ctx, m.Author.ID)
+		cancel()
 
-			err := h.storageService.UpsertMessageState(dbCtx, messageState)
-			if err != nil {
-				h.logger.Error("Failed to clear DM conversation state",
-					"error", err,
-					"channel_id", m.ChannelID,
-					"user_id", m.Author.ID)
-			} else {
-				h.logger.Info("DM conversation state cleared successfully",
-					"channel_id", m.ChannelID,
-					"user_id", m.Author.ID)
+		if err != nil {
+			h.logger.Error("Failed to clear DM conversation memory",
+				"error", err,
+				"channel_id", m.ChannelID,
+				"user_id", m.Author.ID)
+			errorMsg := "I'm sorry, I couldn't clear our conversation right now. Please try again later."
+			if _, err := s.ChannelMessageSend(m.ChannelID, errorMsg); err != nil {
+				h.logger.Error("Failed to send /clear error response", "error", err, "user_id", m.Author.ID)
 			}
-		}()
+			return
+		}
 	}
 
 	// Send confirmation response
 	confirmationMsg := "✅ **Conversation cleared!**\n\n" +
 		"Your conversation history has been reset. I'm ready for a fresh start! " +
 		"Feel free to ask me anything about BMAD methods, development practices, or any questions you have.\n\n" +
-		"*You can use `/clear` anytime to start a new conversation.*"
+		"*You can use `/clear` anytime to start a new conversation, `/export` to download what I've stored about you, " +
+		"or `/delete` to erase it.*"
 
-	if err := h.sendResponseInChunks(s, m.ChannelID, confirmationMsg); err != nil {
+	if _, err := h.sendResponseInChunks(s, m.ChannelID, confirmationMsg); err != nil {
 		h.logger.Error("Failed to send /clear confirmation", "error", err, "user_id", m.Author.ID)
 	} else {
 		h.logger.Info("/clear command processed successfully", "user_id", m.Author.ID)
 	}
 }
 
+// dmClearCommandReminder is appended to DM responses to point users at /clear
+const dmClearCommandReminder = "\n\n*💡 Tip: Send `/clear` to start a fresh conversation anytime.*"
+
 // addClearCommandReminder adds a helpful note about the /clear command to DM responses
 func (h *Handler) addClearCommandReminder(response string) string {
-	// Add a subtle reminder about the /clear command at the end of responses
-	reminder := "\n\n*💡 Tip: Send `/clear` to start a fresh conversation anytime.*"
-	return response + reminder
+	return response + dmClearCommandReminder
+}
+
+// finalizeDMResponse decorates a DM answer with its knowledge base sources and the /clear reminder
+func (h *Handler) finalizeDMResponse(response string) string {
+	return h.addClearCommandReminder(h.appendKnowledgeSources(response))
 }
 
 // processForumPost handles messages posted in Discord Forum post threads
@@ -2197,7 +2848,7 @@ func (h *Handler) processForumPost(s *discordgo.Session, m *discordgo.MessageCre
 		"content_length", len(m.Content))
 
 	// Check if the parent Forum channel should be monitored
-	if !h.shouldMonitorForumChannel(parentChannelID) {
+	if !h.shouldMonitorForumChannel(m.GuildID, parentChannelID) {
 		h.logger.Info("Forum channel not monitored, skipping automatic response",
 			"parent_forum_id", parentChannelID,
 			"forum_post_id", m.ChannelID)
@@ -2225,35 +2876,72 @@ func (h *Handler) processForumPost(s *discordgo.Session, m *discordgo.MessageCre
 		"parent_forum_id", parentChannelID,
 		"query_length", len(queryText))
 
+	// Enforce per-user rate limits before any AI call
+	refund, admitted := h.checkUserRateLimit(s, m.Author.ID, m.GuildID, m.ChannelID, m.Reference())
+	if !admitted {
+		return
+	}
+	h.metrics.RecordQuery(monitor.TriggerForum)
+
+	// Replies to a Forum post are follow-ups; the post's starter message shares its ID with the post
+	priority := service.AIJobPriorityNewQuestion
+	if m.ID != m.ChannelID {
+		priority = service.AIJobPriorityFollowUp
+	}
+	ctx, release, ok := h.startAIJob(s, m, m.Author.ID, priority)
+	if !ok {
+		refund()
+		return
+	}
+	defer release()
+
 	// Start typing indicator to show the bot is processing
 	stopTyping := h.triggerTypingIndicator(s, m.ChannelID)
 	defer stopTyping() // Ensure typing stops when function exits
 
-	// Check if we have conversation history for this Forum post thread (AC 2.14.6)
-	forumHistory, historyErr := h.fetchForumPostHistory(s, m.ChannelID, 50)
+	// Forum posts record the parent Forum channel and the post thread, like message state
+	ctx, interaction := h.beginInteraction(ctx, m, monitor.TriggerForum, queryText, true)
+	interaction.interaction.ChannelID = parentChannelID
+	defer h.recordInteraction(interaction)
+
+	// Check if we have conversation history for this Forum post thread (AC 2.14.6), fitted to the model
+	// context with older messages summarized
+	historySummary, forumHistory, historyErr := h.loadThreadHistory(ctx, s, m.ChannelID, queryText)
 
 	var response string
 	var aiErr error
+	var streamed bool
+	var streamedMessageID string
 
 	if historyErr != nil {
 		h.logger.Error("Failed to fetch Forum post history, using basic query",
 			"error", historyErr, "forum_post_id", m.ChannelID)
 		// Fallback to basic query if history retrieval fails
-		response, aiErr = h.aiService.QueryAI(queryText)
-	} else if len(forumHistory) > 1 { // More than just the current message
+		response, aiErr = h.aiService.QueryAI(ctx, queryText)
+	} else if len(forumHistory) > 1 || historySummary != "" { // More than just the current message
 		// Use contextual query with Forum post conversation history
 		conversationHistory := h.formatConversationHistory(forumHistory)
 		h.logger.Info("Using contextual Forum post query with history",
 			"history_messages", len(forumHistory),
 			"history_length", len(conversationHistory),
+			"summary_length", len(historySummary),
 			"forum_post_id", m.ChannelID)
-		response, aiErr = h.aiService.QueryWithContext(queryText, conversationHistory)
+		chatHistory := prependThreadSummary(historySummary, h.buildChatHistory(forumHistory, s.State.User.ID, m.ID))
+		response, streamedMessageID, streamed, aiErr = h.streamQueryWithHistory(ctx, s, m.ChannelID, nil, queryText, chatHistory, h.appendKnowledgeSources)
+		if !streamed {
+			response, aiErr = h.queryWithHistory(ctx, queryText, historySummary, forumHistory, s.State.User.ID, m.ID)
+		}
 	} else {
 		// First message in Forum post conversation
-		response, aiErr = h.aiService.QueryAI(queryText)
+		response, streamedMessageID, streamed, aiErr = h.streamStandaloneQuery(ctx, s, m.ChannelID, nil, queryText, h.appendKnowledgeSources)
 	}
 
+	interaction.complete(response, aiErr)
 	if aiErr != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", aiErr, "message_id", m.ID)
+			return
+		}
 		h.logger.Error("AI service error for Forum post", "error", aiErr, "forum_post_id", m.ChannelID)
 		errorMsg := "I'm sorry, I encountered an error while processing your request. Please try again later."
 		if _, err := s.ChannelMessageSend(m.ChannelID, errorMsg); err != nil {
@@ -2262,10 +2950,21 @@ func (h *Handler) processForumPost(s *discordgo.Session, m *discordgo.MessageCre
 		return
 	}
 
+	if streamed {
+		h.attachFeedback(s, interaction, m.ChannelID, streamedMessageID)
+		h.logger.Info("Forum post response streamed successfully",
+			"forum_post_id", m.ChannelID,
+			"parent_forum_id", parentChannelID,
+			"response_length", len(response))
+		return
+	}
+
 	// Send response directly in the Forum post thread (AC 2.14.4)
-	if err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true); err != nil {
+	response = h.appendKnowledgeSources(response)
+	if message, err := h.sendResponseInChunksWithOptions(s, m.ChannelID, response, true); err != nil {
 		h.logger.Error("Failed to send Forum post response", "error", err, "forum_post_id", m.ChannelID)
 	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
 		h.logger.Info("Forum post response sent successfully",
 			"forum_post_id", m.ChannelID,
 			"parent_forum_id", parentChannelID,
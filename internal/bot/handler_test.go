@@ -10,6 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"bmad-knowledge-bot/internal/config"
+	"bmad-knowledge-bot/internal/monitor"
+	"bmad-knowledge-bot/internal/service"
 	"bmad-knowledge-bot/internal/storage"
 	"github.com/bwmarrin/discordgo"
 	"github.com/stretchr/testify/assert"
@@ -37,7 +40,7 @@ func NewMockAIService() *MockAIService {
 	}
 }
 
-func (m *MockAIService) QueryAI(query string) (string, error) {
+func (m *MockAIService) QueryAI(ctx context.Context, query string) (string, error) {
 	if err, exists := m.errors[query]; exists {
 		return "", err
 	}
@@ -47,7 +50,7 @@ func (m *MockAIService) QueryAI(query string) (string, error) {
 	return "Default mock response for: " + query, nil
 }
 
-func (m *MockAIService) SummarizeQuery(query string) (string, error) {
+func (m *MockAIService) SummarizeQuery(ctx context.Context, query string) (string, error) {
 	if err, exists := m.errors["summary:"+query]; exists {
 		return "", err
 	}
@@ -66,7 +69,7 @@ func (m *MockAIService) SummarizeQuery(query string) (string, error) {
 }
 
 // QueryAIWithSummary sends a query and returns both response and extracted summary
-func (m *MockAIService) QueryAIWithSummary(query string) (string, string, error) {
+func (m *MockAIService) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
 	summaryKey := "integrated:" + query
 	if err, exists := m.errors[summaryKey]; exists {
 		return "", "", err
@@ -98,7 +101,7 @@ func (m *MockAIService) QueryAIWithSummary(query string) (string, string, error)
 }
 
 // QueryWithContext sends a query with conversation history context to the AI service
-func (m *MockAIService) QueryWithContext(query string, conversationHistory string) (string, error) {
+func (m *MockAIService) QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error) {
 	contextKey := "context:" + query + ":" + conversationHistory
 	if err, exists := m.errors[contextKey]; exists {
 		return "", err
@@ -114,7 +117,7 @@ func (m *MockAIService) QueryWithContext(query string, conversationHistory strin
 }
 
 // SummarizeConversation creates a summary of conversation history for context preservation
-func (m *MockAIService) SummarizeConversation(messages []string) (string, error) {
+func (m *MockAIService) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
 	if len(messages) == 0 {
 		return "", nil
 	}
@@ -326,7 +329,7 @@ func TestHandler_HandleMessageCreate_ProcessesMentions(t *testing.T) {
 	}
 
 	// Test 3: Verify AI service is called correctly
-	response, err := mockAI.QueryAI(queryText)
+	response, err := mockAI.QueryAI(context.Background(), queryText)
 	if err != nil {
 		t.Errorf("Unexpected error from AI service: %v", err)
 	}
@@ -340,7 +343,7 @@ func TestHandler_HandleMessageCreate_ProcessesMentions(t *testing.T) {
 func TestHandler_HandleMessageCreate_IgnoresNonMentions(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	mockAI := NewMockAIService()
-	mockStorage := &MockStorageService{}
+	mockStorage := NewMockStorageService()
 	handler := NewHandler(logger, mockAI, mockStorage)
 
 	// Create a mock session
@@ -448,6 +451,12 @@ func TestHandler_MessageProcessingFlow(t *testing.T) {
 }
 
 // Test thread detection functionality
+func TestIsAutomatedAuthor(t *testing.T) {
+	assert.False(t, isAutomatedAuthor(&discordgo.Message{Author: &discordgo.User{ID: "user1"}}))
+	assert.True(t, isAutomatedAuthor(&discordgo.Message{Author: &discordgo.User{ID: "bot2", Bot: true}}))
+	assert.True(t, isAutomatedAuthor(&discordgo.Message{Author: &discordgo.User{ID: "hook"}, WebhookID: "webhook1"}))
+}
+
 func TestHandler_isMessageInThread(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	mockAI := NewMockAIService()
@@ -620,7 +629,7 @@ func TestMockAIService_SummarizeQuery(t *testing.T) {
 				mockAI.SetError("summary:"+tt.query, tt.setupError)
 			}
 
-			result, err := mockAI.SummarizeQuery(tt.query)
+			result, err := mockAI.SummarizeQuery(context.Background(), tt.query)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
@@ -659,7 +668,7 @@ func TestHandler_processMainChannelQuery_ErrorHandling(t *testing.T) {
 		}()
 
 		// This will panic due to nil session, but proves the function exists
-		handler.processMainChannelQuery(nil, nil, query, response)
+		handler.processMainChannelQuery(context.Background(), nil, nil, query, monitor.TriggerMention, response)
 	})
 }
 
@@ -690,7 +699,7 @@ func TestHandler_ThreadWorkflowIntegration(t *testing.T) {
 		}
 
 		// 2. Test AI query processing
-		response, err := mockAI.QueryAI(extractedQuery)
+		response, err := mockAI.QueryAI(context.Background(), extractedQuery)
 		if err != nil {
 			t.Fatalf("unexpected AI service error: %v", err)
 		}
@@ -699,7 +708,7 @@ func TestHandler_ThreadWorkflowIntegration(t *testing.T) {
 		}
 
 		// 3. Test summarization
-		titleSummary, err := mockAI.SummarizeQuery(extractedQuery)
+		titleSummary, err := mockAI.SummarizeQuery(context.Background(), extractedQuery)
 		if err != nil {
 			t.Fatalf("unexpected summarization error: %v", err)
 		}
@@ -798,6 +807,82 @@ func TestHandler_formatConversationHistory(t *testing.T) {
 	}
 }
 
+// Test role-tagged chat history construction
+func TestHandler_buildChatHistory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	handler := newTestHandler(logger, NewMockAIService())
+
+	bot := &discordgo.User{ID: "bot123", Username: "bmadhelper", Bot: true}
+	alice := &discordgo.User{ID: "user1", Username: "alice"}
+	bob := &discordgo.User{ID: "user2", Username: "bob"}
+
+	messages := []*discordgo.Message{
+		{ID: "m1", Content: "What are BMAD agents?", Author: alice},
+		{ID: "m2", Content: "Agents are specialized roles.", Author: bot},
+		{ID: "m3", Content: "Which one writes stories?", Author: alice},
+		{ID: "m4", Content: "And who reviews them?", Author: bob},
+		{ID: "m5", Content: "", Author: bot},
+		{ID: "m6", Content: "@bmadhelper tell me more", Author: alice},
+	}
+
+	history := handler.buildChatHistory(messages, "bot123", "m6")
+
+	assert.Equal(t, []service.ChatMessage{
+		{Role: service.ChatRoleUser, Content: "alice: What are BMAD agents?"},
+		{Role: service.ChatRoleAssistant, Content: "Agents are specialized roles."},
+		{Role: service.ChatRoleUser, Content: "alice: Which one writes stories?\nbob: And who reviews them?"},
+	}, history)
+
+	assert.Empty(t, handler.buildChatHistory(nil, "bot123", "m1"))
+}
+
+// Test that contextual queries use role-tagged history when the AI service supports it
+func TestHandler_queryWithHistory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	messages := []*discordgo.Message{
+		{ID: "m1", Content: "What is BMAD?", Author: &discordgo.User{ID: "user1", Username: "alice"}},
+		{ID: "m2", Content: "A method.", Author: &discordgo.User{ID: "bot123", Username: "bmadhelper", Bot: true}},
+	}
+
+	t.Run("chat_service", func(t *testing.T) {
+		chatAI := &mockStreamingAIService{MockAIService: NewMockAIService(), final: "chat answer"}
+		handler := NewHandler(logger, chatAI, nil)
+
+		response, err := handler.queryWithHistory(context.Background(), "More?", "", messages, "bot123", "m3")
+		require.NoError(t, err)
+		assert.Equal(t, "chat answer", response)
+		assert.Len(t, chatAI.history, 2)
+	})
+
+	t.Run("text_history_fallback", func(t *testing.T) {
+		mockAI := NewMockAIService()
+		mockAI.SetContextResponse("More?", "alice: What is BMAD?\nBot (bmadhelper): A method.", "context answer")
+		handler := newTestHandler(logger, mockAI)
+
+		response, err := handler.queryWithHistory(context.Background(), "More?", "", messages, "bot123", "m3")
+		require.NoError(t, err)
+		assert.Equal(t, "context answer", response)
+	})
+
+	t.Run("summary_leads_history", func(t *testing.T) {
+		chatAI := &mockStreamingAIService{MockAIService: NewMockAIService(), final: "chat answer"}
+		handler := NewHandler(logger, chatAI, nil)
+
+		_, err := handler.queryWithHistory(context.Background(), "More?", "Alice set up BMAD.", messages, "bot123", "m3")
+		require.NoError(t, err)
+		require.Len(t, chatAI.history, 2)
+		assert.Equal(t, "Summary of the earlier conversation: Alice set up BMAD.\nalice: What is BMAD?", chatAI.history[0].Content)
+
+		mockAI := NewMockAIService()
+		mockAI.SetContextResponse("More?", "Summary of the earlier conversation: Alice set up BMAD.\nalice: What is BMAD?\nBot (bmadhelper): A method.", "context answer")
+		handler = newTestHandler(logger, mockAI)
+
+		response, err := handler.queryWithHistory(context.Background(), "More?", "Alice set up BMAD.", messages, "bot123", "m3")
+		require.NoError(t, err)
+		assert.Equal(t, "context answer", response)
+	})
+}
+
 // Test contextual AI query processing
 func TestHandler_ProcessAIQuery_WithContext(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -813,7 +898,7 @@ func TestHandler_ProcessAIQuery_WithContext(t *testing.T) {
 
 	t.Run("contextual_query_processing", func(t *testing.T) {
 		// Test the AI service contextual call directly
-		response, err := mockAI.QueryWithContext(query, history)
+		response, err := mockAI.QueryWithContext(context.Background(), query, history)
 
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -844,7 +929,7 @@ func TestHandler_ConversationSummarization(t *testing.T) {
 	mockAI.SetConversationSummary(expectedSummary)
 
 	t.Run("conversation_summarization", func(t *testing.T) {
-		summary, err := mockAI.SummarizeConversation(messages)
+		summary, err := mockAI.SummarizeConversation(context.Background(), messages)
 
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -1895,7 +1980,7 @@ func TestHandler_ReplyMentionProcessingWorkflow(t *testing.T) {
 			}
 
 			// Test AI response
-			aiResponse, err := mockAI.QueryAI(extractedQuery)
+			aiResponse, err := mockAI.QueryAI(context.Background(), extractedQuery)
 			if err != nil {
 				t.Errorf("Unexpected AI service error: %v", err)
 			}
@@ -1912,7 +1997,7 @@ func TestHandler_ReplyMentionProcessingWorkflow(t *testing.T) {
 
 			// Test integrated response (for main channel scenarios)
 			if !tt.isInThread {
-				integratedResponse, integratedSummary, err := mockAI.QueryAIWithSummary(extractedQuery)
+				integratedResponse, integratedSummary, err := mockAI.QueryAIWithSummary(context.Background(), extractedQuery)
 				if err != nil {
 					t.Errorf("Unexpected integrated AI service error: %v", err)
 				}
@@ -2554,6 +2639,57 @@ func TestHandler_ReactionTriggerConfiguration(t *testing.T) {
 		assert.Len(t, handler.reactionTriggerConfig.ApprovedUserIDs, 1, "Should have one approved user")
 		assert.Len(t, handler.reactionTriggerConfig.ApprovedRoleNames, 0, "Should have no approved roles")
 	})
+
+	t.Run("live_update", func(t *testing.T) {
+		handler := NewHandler(logger, mockAI, nil)
+
+		handler.SetReactionTriggerConfig(ReactionTriggerConfig{Enabled: true, TriggerEmoji: "🤖", RequireReaction: true})
+		handler.SetReplyMentionConfig(ReplyMentionConfig{DeleteReplyMessage: true})
+
+		assert.Equal(t, "🤖", handler.reactionTrigger().TriggerEmoji, "Updated trigger emoji should be used")
+		assert.True(t, handler.reactionTrigger().Enabled, "Reaction triggers should be enabled after update")
+		assert.True(t, handler.replyMention().DeleteReplyMessage, "Reply deletion should be enabled after update")
+	})
+
+	t.Run("guild_overrides", func(t *testing.T) {
+		memoryStorage := storage.NewMemoryStorageService()
+		handler := NewHandler(logger, mockAI, memoryStorage)
+		handler.SetReactionTriggerConfig(ReactionTriggerConfig{Enabled: false, TriggerEmoji: "❓", ApprovedUserIDs: []string{"global-user"}})
+
+		ctx := context.Background()
+		for key, value := range map[string]string{
+			"REACTION_TRIGGER_ENABLED":           "true",
+			"REACTION_TRIGGER_EMOJI":             "🤖",
+			"REACTION_TRIGGER_APPROVED_USER_IDS": "guild-user-1, guild-user-2",
+		} {
+			require.NoError(t, memoryStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "guild-1", Key: key, Value: value, Type: "string"}))
+		}
+
+		guildConfig := handler.reactionTriggerFor("guild-1")
+		assert.True(t, guildConfig.Enabled, "Guild override should enable reaction triggers")
+		assert.Equal(t, "🤖", guildConfig.TriggerEmoji, "Guild override emoji should be used")
+		assert.Equal(t, []string{"guild-user-1", "guild-user-2"}, guildConfig.ApprovedUserIDs, "Guild approved users should replace the global list")
+
+		otherConfig := handler.reactionTriggerFor("guild-2")
+		assert.False(t, otherConfig.Enabled, "Guilds without overrides should use the global settings")
+		assert.Equal(t, []string{"global-user"}, otherConfig.ApprovedUserIDs, "Guilds without overrides should use the global approved users")
+	})
+
+	t.Run("guild_overrides_through_config_service", func(t *testing.T) {
+		memoryStorage := storage.NewMemoryStorageService()
+		configService := config.NewDatabaseConfigService(memoryStorage)
+		require.NoError(t, configService.Initialize(context.Background()))
+		handler := NewHandler(logger, mockAI, memoryStorage)
+		handler.SetConfigService(configService)
+		handler.SetReactionTriggerConfig(ReactionTriggerConfig{Enabled: false, TriggerEmoji: "❓"})
+
+		// Every boolean spelling accepted when setting an override is honored
+		require.NoError(t, configService.SetGuildConfig(context.Background(), "guild-1", "REACTION_TRIGGER_ENABLED", "on"))
+		assert.True(t, handler.reactionTriggerFor("guild-1").Enabled, "Guild override 'on' should enable reaction triggers")
+
+		require.NoError(t, configService.SetGuildConfig(context.Background(), "guild-1", "REACTION_TRIGGER_ENABLED", "disabled"))
+		assert.False(t, handler.reactionTriggerFor("guild-1").Enabled, "Guild override 'disabled' should disable reaction triggers")
+	})
 }
 
 // Test reaction trigger integration with existing functionality
@@ -2591,7 +2727,7 @@ func TestHandler_ReactionTriggerIntegration(t *testing.T) {
 		assert.Equal(t, "test question", queryText, "Query text should match message content")
 
 		// Verify AI service would receive the correct query
-		response, err := mockAI.QueryAI(queryText)
+		response, err := mockAI.QueryAI(context.Background(), queryText)
 		assert.NoError(t, err, "AI service should handle the query")
 		assert.Equal(t, "Mock AI response", response, "AI response should match expected")
 	})
@@ -2650,7 +2786,7 @@ func TestHandler_ReactionTriggerErrorHandling(t *testing.T) {
 
 	t.Run("ai_service_error_handling", func(t *testing.T) {
 		// Test that AI service errors are handled gracefully
-		_, err := mockAI.QueryAI("error query")
+		_, err := mockAI.QueryAI(context.Background(), "error query")
 		assert.Error(t, err, "AI service should return error for error query")
 		assert.Contains(t, err.Error(), "AI service error", "Error message should match")
 	})
@@ -2833,7 +2969,7 @@ func TestHandler_processDMMessage(t *testing.T) {
 		assert.Equal(t, "Hello bot!", queryText, "Query text should be extracted correctly")
 
 		// 3. Test AI service integration
-		response, err := mockAI.QueryAI(queryText)
+		response, err := mockAI.QueryAI(context.Background(), queryText)
 		assert.NoError(t, err, "AI service should process query")
 		assert.Equal(t, "Hello! How can I help you with BMAD-related questions?", response, "AI response should match expected")
 
@@ -2921,7 +3057,7 @@ func TestHandler_processDMMessage(t *testing.T) {
 		assert.Equal(t, expectedHistory, conversationHistory, "Conversation history should be formatted correctly")
 
 		// Test contextual AI query
-		contextualResponse, err := mockAI.QueryWithContext("Follow up question", conversationHistory)
+		contextualResponse, err := mockAI.QueryWithContext(context.Background(), "Follow up question", conversationHistory)
 		assert.NoError(t, err, "Contextual AI query should succeed")
 		assert.Equal(t, "This is a contextual response to your follow-up question.", contextualResponse, "Contextual response should match expected")
 	})
@@ -3078,7 +3214,7 @@ func TestHandler_DMWorkflowIntegration(t *testing.T) {
 
 		// 2. Query extraction and AI processing
 		queryText := strings.TrimSpace(firstDM.Content)
-		response, err := mockAI.QueryAI(queryText)
+		response, err := mockAI.QueryAI(context.Background(), queryText)
 		assert.NoError(t, err, "AI query should succeed")
 		assert.Equal(t, "BMAD is a methodology for building better software.", response, "AI response should match expected")
 
@@ -3104,7 +3240,7 @@ func TestHandler_DMWorkflowIntegration(t *testing.T) {
 		assert.Equal(t, expectedHistory, conversationHistory, "Conversation history should be formatted correctly")
 
 		// 5. Test contextual AI query
-		contextualResponse, err := mockAI.QueryWithContext(followUpDM.Content, conversationHistory)
+		contextualResponse, err := mockAI.QueryWithContext(context.Background(), followUpDM.Content, conversationHistory)
 		assert.NoError(t, err, "Contextual query should succeed")
 		assert.Equal(t, "BMAD focuses on best practices, maintainable code, agile development, and documentation.", contextualResponse, "Contextual response should be appropriate")
 
@@ -3429,17 +3565,32 @@ func TestHandler_SetMonitoredForumChannels(t *testing.T) {
 
 			// Test shouldMonitorForumChannel for each configured channel
 			for _, channelID := range tt.channels {
-				assert.True(t, handler.shouldMonitorForumChannel(channelID),
+				assert.True(t, handler.shouldMonitorForumChannel("", channelID),
 					"Configured channel should be monitored")
 			}
 
 			// Test that unconfigured channel is not monitored
-			assert.False(t, handler.shouldMonitorForumChannel("unconfigured-channel"),
+			assert.False(t, handler.shouldMonitorForumChannel("", "unconfigured-channel"),
 				"Unconfigured channel should not be monitored")
 
 			t.Logf("%s: configured %d channels", tt.name, len(tt.channels))
 		})
 	}
+
+	// Reconfiguring replaces the previous list
+	handler.SetMonitoredForumChannels([]string{"forum-999"})
+	assert.True(t, handler.shouldMonitorForumChannel("", "forum-999"), "New channel should be monitored")
+	assert.False(t, handler.shouldMonitorForumChannel("", "forum-123"), "Removed channel should no longer be monitored")
+
+	// A guild override replaces the global list for that guild only
+	memoryStorage := storage.NewMemoryStorageService()
+	handler.storageService = memoryStorage
+	require.NoError(t, memoryStorage.UpsertConfiguration(context.Background(), &storage.Configuration{
+		GuildID: "guild-1", Key: "MONITORED_FORUM_CHANNELS", Value: "forum-guild", Type: "string",
+	}))
+	assert.True(t, handler.shouldMonitorForumChannel("guild-1", "forum-guild"), "Guild forum channel should be monitored")
+	assert.False(t, handler.shouldMonitorForumChannel("guild-1", "forum-999"), "Global forum channels should not apply to a guild with an override")
+	assert.True(t, handler.shouldMonitorForumChannel("guild-2", "forum-999"), "Other guilds should use the global list")
 }
 
 // Test Forum message state recording functionality
@@ -3618,6 +3769,24 @@ func TestHandler_HandleMessageCreate_ForumIntegration(t *testing.T) {
 	}()
 }
 
+func TestHandler_ResolveChannelLocation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	handler := newTestHandler(logger, NewMockAIService())
+
+	location := handler.resolveChannelLocation(nil, "guild-1", "channel-1", nil)
+	assert.Equal(t, ChannelLocation{GuildID: "guild-1", ChannelID: "channel-1"}, location, "Unknown channels only carry their ID")
+
+	channel := &discordgo.Channel{ID: "channel-1", Type: discordgo.ChannelTypeGuildText, ParentID: "category-1"}
+	location = handler.resolveChannelLocation(nil, "guild-1", "channel-1", channel)
+	assert.Equal(t, "category-1", location.CategoryID, "A text channel's parent is its category")
+	assert.Empty(t, location.ParentID)
+
+	thread := &discordgo.Channel{ID: "thread-1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "channel-1"}
+	location = handler.resolveChannelLocation(nil, "guild-1", "thread-1", thread)
+	assert.Equal(t, "channel-1", location.ParentID, "A thread's parent is its channel")
+	assert.Empty(t, location.CategoryID, "The category needs a session to look up the parent channel")
+}
+
 // TestFormatForDiscord tests the Discord message formatting function
 func TestFormatForDiscord(t *testing.T) {
 	// Create a mock handler for testing
@@ -3888,11 +4057,8 @@ func TestMessageStatePersistenceRobustness(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
 	// Create a mock storage service that simulates database timeout and retry scenarios
-	storageService := &MockStorageService{
-		messageStates: make(map[string]*storage.MessageState),
-		failureCount:  make(map[string]int),
-		shouldTimeout: true, // Initially simulate timeout
-	}
+	storageService := NewMockStorageService()
+	storageService.shouldTimeout = true // Initially simulate timeout
 
 	mockAI := NewMockAIService()
 	mockAI.responses["Test DM query"] = "Test response for DM"
@@ -3971,12 +4137,12 @@ func TestMessageStatePersistenceRobustness(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 
 	// Verify successful persistence
-	if storageService.messageStates["robust-dm-channel-2"] == nil {
+	persistedState, _ := storageService.GetMessageState(context.Background(), "robust-dm-channel-2", nil)
+	if persistedState == nil {
 		t.Error("Expected message state to be persisted after retry logic")
 	}
 
 	// Verify the persisted state has correct data
-	persistedState := storageService.messageStates["robust-dm-channel-2"]
 	if persistedState != nil {
 		if persistedState.ChannelID != "robust-dm-channel-2" {
 			t.Errorf("Expected channel ID 'robust-dm-channel-2', got '%s'", persistedState.ChannelID)
@@ -3987,124 +4153,307 @@ func TestMessageStatePersistenceRobustness(t *testing.T) {
 	}
 }
 
-// MockStorageService extends the existing mock with failure simulation
+// MockStorageService wraps the in-memory storage with failure simulation and a log of recorded feedback votes
 type MockStorageService struct {
-	messageStates map[string]*storage.MessageState
+	*storage.MemoryStorageService
 	failureCount  map[string]int
 	shouldTimeout bool
+	feedback      []*storage.InteractionFeedback
 	mutex         sync.RWMutex
 }
 
+// NewMockStorageService creates a mock backed by empty in-memory storage
+func NewMockStorageService() *MockStorageService {
+	return &MockStorageService{
+		MemoryStorageService: storage.NewMemoryStorageService(),
+		failureCount:         make(map[string]int),
+	}
+}
+
 func (m *MockStorageService) UpsertMessageState(ctx context.Context, state *storage.MessageState) error {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
 	key := state.ChannelID
 	if state.ThreadID != nil {
 		key = key + "_" + *state.ThreadID
 	}
 
-	// Track failure attempts
-	if m.failureCount == nil {
-		m.failureCount = make(map[string]int)
-	}
-
 	// Simulate database timeout or connection issues
 	if m.shouldTimeout && m.failureCount[key] < 2 {
 		m.failureCount[key]++
-		return fmt.Errorf("context canceled: database timeout simulation (attempt %d)", m.failureCount[key])
+		attempt := m.failureCount[key]
+		m.mutex.Unlock()
+		return fmt.Errorf("context canceled: database timeout simulation (attempt %d)", attempt)
 	}
+	m.mutex.Unlock()
 
-	// Success case
-	if m.messageStates == nil {
-		m.messageStates = make(map[string]*storage.MessageState)
-	}
-	m.messageStates[key] = state
-	return nil
+	return m.MemoryStorageService.UpsertMessageState(ctx, state)
 }
 
-func (m *MockStorageService) GetMessageState(ctx context.Context, channelID string, threadID *string) (*storage.MessageState, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
-	key := channelID
-	if threadID != nil {
-		key = key + "_" + *threadID
+func (m *MockStorageService) UpsertInteractionFeedback(ctx context.Context, feedback *storage.InteractionFeedback) error {
+	if err := m.MemoryStorageService.UpsertInteractionFeedback(ctx, feedback); err != nil {
+		return err
 	}
 
-	state, exists := m.messageStates[key]
-	if !exists {
-		return nil, fmt.Errorf("message state not found")
-	}
-	return state, nil
-}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-// Implement other required methods as no-ops for testing
-func (m *MockStorageService) Initialize(ctx context.Context) error { return nil }
-func (m *MockStorageService) Close() error                         { return nil }
-func (m *MockStorageService) GetAllMessageStates(ctx context.Context) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *MockStorageService) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *MockStorageService) HealthCheck(ctx context.Context) error { return nil }
-func (m *MockStorageService) GetThreadOwnership(ctx context.Context, threadID string) (*storage.ThreadOwnership, error) {
-	return nil, fmt.Errorf("not found")
-}
-func (m *MockStorageService) UpsertThreadOwnership(ctx context.Context, ownership *storage.ThreadOwnership) error {
-	return nil
-}
-func (m *MockStorageService) GetAllThreadOwnerships(ctx context.Context) ([]*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *MockStorageService) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
-	return nil
-}
-func (m *MockStorageService) GetConfiguration(ctx context.Context, key string) (*storage.Configuration, error) {
-	return nil, fmt.Errorf("not found")
-}
-func (m *MockStorageService) UpsertConfiguration(ctx context.Context, config *storage.Configuration) error {
+	stored := *feedback
+	for i, existing := range m.feedback {
+		if existing.InteractionID == feedback.InteractionID && existing.UserID == feedback.UserID {
+			m.feedback[i] = &stored
+			return nil
+		}
+	}
+	m.feedback = append(m.feedback, &stored)
 	return nil
 }
-func (m *MockStorageService) GetConfigurationsByCategory(ctx context.Context, category string) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *MockStorageService) GetAllConfigurations(ctx context.Context) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *MockStorageService) DeleteConfiguration(ctx context.Context, key string) error { return nil }
-func (m *MockStorageService) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *MockStorageService) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
+
+func (m *MockStorageService) DeleteInteractionFeedback(ctx context.Context, interactionID int64, userID string, vote int) error {
+	if err := m.MemoryStorageService.DeleteInteractionFeedback(ctx, interactionID, userID, vote); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, existing := range m.feedback {
+		if existing.InteractionID == interactionID && existing.UserID == userID && existing.Vote == vote {
+			m.feedback = append(m.feedback[:i], m.feedback[i+1:]...)
+			return nil
+		}
+	}
 	return nil
 }
-func (m *MockStorageService) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
-	return nil
+
+// recordedInteractions returns a snapshot of the interactions recorded so far
+func (m *MockStorageService) recordedInteractions() []*storage.Interaction {
+	interactions, _ := m.GetRecentInteractions(context.Background(), -1)
+	return interactions
 }
-func (m *MockStorageService) GetAllStatusMessages(ctx context.Context) ([]*storage.StatusMessage, error) {
-	return nil, nil
+
+// recordedFeedback returns a snapshot of the feedback votes recorded so far
+func (m *MockStorageService) recordedFeedback() []*storage.InteractionFeedback {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return append([]*storage.InteractionFeedback(nil), m.feedback...)
 }
-func (m *MockStorageService) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
-	return 0, nil
+
+// TestHandler_RecordInteraction verifies completed exchanges are persisted with their thread and outcome
+func TestHandler_RecordInteraction(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := NewMockStorageService()
+	handler := NewHandler(logger, NewMockAIService(), storageService)
+
+	message := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        "question-1",
+			ChannelID: "channel-1",
+			GuildID:   "guild-1",
+			Author:    &discordgo.User{ID: "user-1"},
+		},
+	}
+
+	// Nothing is recorded while the interaction log is disabled
+	_, interaction := handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "What is BMAD?", false)
+	interaction.complete("BMAD is a method.", nil)
+	handler.recordInteraction(interaction)
+
+	handler.SetInteractionLogEnabled(true)
+
+	// Interactions that never reached the AI provider are not recorded
+	_, ignored := handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "ignored", false)
+	handler.recordInteraction(ignored)
+
+	_, interaction = handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "What is BMAD?", false)
+	interaction.complete("BMAD is a method.", nil)
+	interaction.setThread("thread-1")
+	handler.recordInteraction(interaction)
+
+	_, failed := handler.beginInteraction(context.Background(), message, monitor.TriggerDM, "Why?", false)
+	failed.complete("partial", fmt.Errorf("provider unavailable"))
+	handler.recordInteraction(failed)
+
+	require.Eventually(t, func() bool { return len(storageService.recordedInteractions()) == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.Len(t, storageService.recordedInteractions(), 2)
+
+	recorded := map[string]*storage.Interaction{}
+	for _, stored := range storageService.recordedInteractions() {
+		recorded[stored.TriggerType] = stored
+	}
+
+	answered := recorded[monitor.TriggerMention]
+	require.NotNil(t, answered)
+	assert.Equal(t, "guild-1", answered.GuildID)
+	assert.Equal(t, "channel-1", answered.ChannelID)
+	require.NotNil(t, answered.ThreadID)
+	assert.Equal(t, "thread-1", *answered.ThreadID)
+	assert.Equal(t, "user-1", answered.UserID)
+	assert.Equal(t, "question-1", answered.MessageID)
+	assert.Equal(t, "BMAD is a method.", answered.Response)
+	assert.Equal(t, "mock", answered.Provider)
+	assert.Empty(t, answered.Error)
+
+	errored := recorded[monitor.TriggerDM]
+	require.NotNil(t, errored)
+	assert.Empty(t, errored.Response)
+	assert.Equal(t, "provider unavailable", errored.Error)
+	assert.Nil(t, errored.ThreadID)
 }
 
-// New rate limiting methods required by interface
-func (m *MockStorageService) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*storage.UserRateLimit, error) {
-	return nil, nil
+// describedMockProvider is a MockAIService usable as a provider in a failover chain
+type describedMockProvider struct {
+	*MockAIService
+	id    string
+	model string
 }
-func (m *MockStorageService) UpsertUserRateLimit(ctx context.Context, rateLimit *storage.UserRateLimit) error {
-	return nil
+
+func (p *describedMockProvider) GetProviderID() string                                    { return p.id }
+func (p *describedMockProvider) SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter) {}
+func (p *describedMockProvider) RefreshKnowledgeBase() error                              { return nil }
+func (p *describedMockProvider) SetMetrics(metrics *monitor.Metrics)                      {}
+func (p *describedMockProvider) DescribeResponse(query, response string) service.ResponseMetadata {
+	return service.ResponseMetadata{Provider: p.id, Model: p.model}
 }
-func (m *MockStorageService) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
-	return nil
+
+// TestHandler_RecordInteractionPerRequestProvider verifies each exchange is attributed to the provider that
+// answered it, even when a later exchange through the same failover chain is answered by another provider
+func TestHandler_RecordInteractionPerRequestProvider(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	primary := &describedMockProvider{MockAIService: NewMockAIService(), id: "ollama", model: "llama3.2"}
+	secondary := &describedMockProvider{MockAIService: NewMockAIService(), id: "openai", model: "gpt-4o-mini"}
+	primary.errors["Why?"] = fmt.Errorf("connection refused")
+
+	failover, err := service.NewFailoverAIService([]service.ProviderAIService{primary, secondary}, logger)
+	require.NoError(t, err)
+
+	storageService := NewMockStorageService()
+	handler := NewHandler(logger, failover, storageService)
+	handler.SetInteractionLogEnabled(true)
+
+	message := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "question-1", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-1"}}}
+
+	firstCtx, first := handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "What is BMAD?", false)
+	secondCtx, second := handler.beginInteraction(context.Background(), message, monitor.TriggerDM, "Why?", false)
+
+	response, err := failover.QueryAI(firstCtx, "What is BMAD?")
+	require.NoError(t, err)
+	first.complete(response, nil)
+
+	response, err = failover.QueryAI(secondCtx, "Why?")
+	require.NoError(t, err)
+	second.complete(response, nil)
+
+	handler.recordInteraction(first)
+	handler.recordInteraction(second)
+	require.Eventually(t, func() bool { return len(storageService.recordedInteractions()) == 2 }, time.Second, 10*time.Millisecond)
+
+	recorded := map[string]*storage.Interaction{}
+	for _, stored := range storageService.recordedInteractions() {
+		recorded[stored.TriggerType] = stored
+	}
+	assert.Equal(t, "ollama", recorded[monitor.TriggerMention].Provider)
+	assert.Equal(t, "llama3.2", recorded[monitor.TriggerMention].Model)
+	assert.Equal(t, "openai", recorded[monitor.TriggerDM].Provider)
+	assert.Equal(t, "gpt-4o-mini", recorded[monitor.TriggerDM].Model)
 }
-func (m *MockStorageService) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*storage.UserRateLimit, error) {
-	return nil, nil
+
+// TestHandler_QueryAIWithSummaryUsesResponseCache verifies cached answers are reused and logged as cache hits
+func TestHandler_QueryAIWithSummaryUsesResponseCache(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := NewMockStorageService()
+	mockAI := NewMockAIService()
+	handler := NewHandler(logger, mockAI, storageService)
+	handler.SetInteractionLogEnabled(true)
+	handler.SetResponseCache(service.NewResponseCache(logger))
+
+	message := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "question-1", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-1"}}}
+
+	ctx, first := handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "What is BMAD?", false)
+	response, _, err := handler.queryAIWithSummary(ctx, first, "What is BMAD?")
+	require.NoError(t, err)
+	assert.False(t, first.cached)
+	assert.Equal(t, "Default mock response for: What is BMAD?", response)
+
+	// A later answer change in the AI service is not seen until the cached answer expires or is purged
+	mockAI.SetIntegratedResponse("what is bmad", "Fresh answer", "Fresh")
+	ctx, second := handler.beginInteraction(context.Background(), message, monitor.TriggerMention, "what is bmad", false)
+	response, _, err = handler.queryAIWithSummary(ctx, second, "what is bmad")
+	require.NoError(t, err)
+	assert.True(t, second.cached)
+	assert.Equal(t, "Default mock response for: What is BMAD?", response)
+
+	second.complete(response, nil)
+	handler.recordInteraction(second)
+	require.Eventually(t, func() bool { return len(storageService.recordedInteractions()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, cachedResponseProvider, storageService.recordedInteractions()[0].Provider)
 }
-func (m *MockStorageService) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
-	return nil
+
+// TestHandler_FeedbackReactions verifies 👍/👎 reactions on answers are recorded as votes
+func TestHandler_FeedbackReactions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := NewMockStorageService()
+	handler := NewHandler(logger, NewMockAIService(), storageService)
+
+	responseID := "answer-1"
+	require.NoError(t, storageService.RecordInteraction(context.Background(), &storage.Interaction{
+		MessageID:         "question-1",
+		ResponseMessageID: &responseID,
+	}))
+
+	session := &discordgo.Session{State: discordgo.NewState()}
+	session.State.User = &discordgo.User{ID: "bot-1"}
+	reaction := func(userID, messageID, emoji string) *discordgo.MessageReactionAdd {
+		return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+			UserID:    userID,
+			MessageID: messageID,
+			ChannelID: "thread-1",
+			Emoji:     discordgo.Emoji{Name: emoji},
+		}}
+	}
+
+	// Votes are ignored until both the interaction log and feedback reactions are enabled
+	assert.False(t, handler.handleFeedbackReaction(session, reaction("user-1", responseID, feedbackUpvoteEmoji)))
+	handler.SetInteractionLogEnabled(true)
+	handler.SetFeedbackReactionsEnabled(true)
+
+	// Other emoji are left to the reaction trigger logic
+	assert.False(t, handler.handleFeedbackReaction(session, reaction("user-1", responseID, "❓")))
+
+	// The bot's own seed reactions are not votes
+	assert.True(t, handler.handleFeedbackReaction(session, reaction("bot-1", responseID, feedbackUpvoteEmoji)))
+	assert.Empty(t, storageService.recordedFeedback())
+
+	// Reactions on messages without a logged answer are consumed without recording
+	assert.True(t, handler.handleFeedbackReaction(session, reaction("user-1", "other-message", feedbackDownvoteEmoji)))
+	assert.Empty(t, storageService.recordedFeedback())
+
+	handler.HandleMessageReactionAdd(session, reaction("user-1", responseID, feedbackUpvoteEmoji))
+	handler.HandleMessageReactionAdd(session, reaction("user-2", responseID, feedbackDownvoteEmoji))
+
+	// A user changing their mind replaces their earlier vote
+	handler.HandleMessageReactionAdd(session, reaction("user-1", responseID, feedbackDownvoteEmoji))
+
+	feedback := storageService.recordedFeedback()
+	require.Len(t, feedback, 2)
+	for _, vote := range feedback {
+		assert.Equal(t, int64(1), vote.InteractionID)
+		assert.Equal(t, storage.FeedbackDownvote, vote.Vote)
+	}
+
+	removal := func(userID, emoji string) *discordgo.MessageReactionRemove {
+		return &discordgo.MessageReactionRemove{MessageReaction: reaction(userID, responseID, emoji).MessageReaction}
+	}
+
+	// Removing the reaction the user switched away from keeps their current vote
+	handler.HandleMessageReactionRemove(session, removal("user-1", feedbackUpvoteEmoji))
+	assert.Len(t, storageService.recordedFeedback(), 2)
+
+	// Removing the current vote's reaction withdraws it
+	handler.HandleMessageReactionRemove(session, removal("user-1", feedbackDownvoteEmoji))
+	feedback = storageService.recordedFeedback()
+	require.Len(t, feedback, 1)
+	assert.Equal(t, "user-2", feedback[0].UserID)
 }
 
 // TestDMClearCommand tests the /clear command functionality in DMs
@@ -4114,9 +4463,7 @@ func TestDMClearCommand(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
 	// Create mock storage service
-	storageService := &MockStorageService{
-		messageStates: make(map[string]*storage.MessageState),
-	}
+	storageService := NewMockStorageService()
 
 	mockAI := NewMockAIService()
 	mockAI.responses["Hello"] = "Hi there! How can I help you today?"
@@ -4158,7 +4505,7 @@ func TestDMClearCommand(t *testing.T) {
 	time.Sleep(500 * time.Millisecond)
 
 	// Verify that message state was set (clearing previous history)
-	messageState := storageService.messageStates["dm-channel-clear"]
+	messageState, _ := storageService.GetMessageState(context.Background(), "dm-channel-clear", nil)
 	if messageState == nil {
 		t.Error("Expected message state to be set after /clear command")
 	} else {
@@ -4174,7 +4521,7 @@ func TestDMClearCommand(t *testing.T) {
 // TestAddClearCommandReminder tests the reminder functionality
 func TestAddClearCommandReminder(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	storageService := &MockStorageService{}
+	storageService := NewMockStorageService()
 	mockAI := NewMockAIService()
 
 	handler := NewHandler(logger, mockAI, storageService)
@@ -4230,3 +4577,76 @@ func TestDMClearCommandDetection(t *testing.T) {
 		})
 	}
 }
+
+// admitted drops the refund function returned by checkUserRateLimit
+func admitted(_ func(), ok bool) bool {
+	return ok
+}
+
+// TestHandler_checkUserRateLimit tests the shared per-user admission check
+func TestHandler_checkUserRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockStorage := storage.NewMemoryStorageService()
+	handler := NewHandler(logger, NewMockAIService(), mockStorage)
+
+	t.Run("no limiter configured allows all requests", func(t *testing.T) {
+		assert.True(t, admitted(handler.checkUserRateLimit(nil, "user1", "guild1", "channel1", nil)))
+	})
+
+	userRateLimiter := monitor.NewUserRateLimiter(mockStorage, logger)
+	userRateLimiter.UpdateLimits(1, 10, 100)
+	handler.SetUserRateLimiter(userRateLimiter)
+	metrics := monitor.NewMetrics()
+	handler.SetMetrics(metrics)
+
+	t.Run("request within limits is admitted and recorded", func(t *testing.T) {
+		assert.True(t, admitted(handler.checkUserRateLimit(nil, "user1", "guild1", "channel1", nil)))
+		rateLimit, err := mockStorage.GetUserRateLimit(context.Background(), "user1", "minute")
+		require.NoError(t, err)
+		require.NotNil(t, rateLimit)
+		assert.Equal(t, 1, rateLimit.RequestCount)
+	})
+
+	t.Run("request over the minute limit is blocked", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, mockStorage.UpsertUserRateLimit(context.Background(), &storage.UserRateLimit{
+			UserID:          "user2",
+			TimeWindow:      "minute",
+			RequestCount:    1,
+			WindowStartTime: now.Truncate(time.Minute).Unix(),
+			LastRequestTime: now.Add(-30 * time.Second).Unix(),
+		}))
+		assert.False(t, admitted(handler.checkUserRateLimit(nil, "user2", "guild1", "channel1", nil)))
+	})
+
+	t.Run("rapid successive requests are dropped", func(t *testing.T) {
+		assert.True(t, admitted(handler.checkUserRateLimit(nil, "user3", "", "dm-channel", nil)))
+		assert.False(t, admitted(handler.checkUserRateLimit(nil, "user3", "", "dm-channel", nil)))
+	})
+
+	t.Run("refunded request no longer counts", func(t *testing.T) {
+		refund, ok := handler.checkUserRateLimit(nil, "user4", "guild1", "channel1", nil)
+		require.True(t, ok)
+		refund()
+		rateLimit, err := mockStorage.GetUserRateLimit(context.Background(), "user4", "minute")
+		require.NoError(t, err)
+		require.NotNil(t, rateLimit)
+		assert.Equal(t, 0, rateLimit.RequestCount)
+
+		// The user was asked to try again, so an immediate retry is admitted
+		assert.True(t, admitted(handler.checkUserRateLimit(nil, "user4", "guild1", "channel1", nil)))
+	})
+
+	t.Run("disabled rate limiting admits blocked users", func(t *testing.T) {
+		userRateLimiter.SetEnabled(false)
+		defer userRateLimiter.SetEnabled(true)
+		assert.True(t, admitted(handler.checkUserRateLimit(nil, "user2", "guild1", "channel1", nil)))
+	})
+
+	t.Run("denials are recorded by window", func(t *testing.T) {
+		var output strings.Builder
+		metrics.WritePrometheus(&output)
+		assert.Contains(t, output.String(), `bmad_bot_user_rate_limit_denials_total{window="minute"} 1`)
+		assert.Contains(t, output.String(), `bmad_bot_user_rate_limit_denials_total{window="rapid_succession"} 1`)
+	})
+}
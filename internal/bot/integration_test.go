@@ -445,7 +445,7 @@ func TestThreadCreationWorkflow(t *testing.T) {
 		}
 
 		// Test AI response
-		response, err := mockAI.QueryAI(query)
+		response, err := mockAI.QueryAI(context.Background(), query)
 		if err != nil {
 			t.Fatalf("Unexpected AI service error: %v", err)
 		}
@@ -455,7 +455,7 @@ func TestThreadCreationWorkflow(t *testing.T) {
 		}
 
 		// Test summarization
-		summary, err := mockAI.SummarizeQuery(query)
+		summary, err := mockAI.SummarizeQuery(context.Background(), query)
 		if err != nil {
 			t.Fatalf("Unexpected summarization error: %v", err)
 		}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// pendingInteraction collects the details of a Q&A exchange until it is persisted
+type pendingInteraction struct {
+	interaction storage.Interaction
+	started     time.Time
+	completed   bool                      // Set once the AI provider has answered or failed
+	cached      bool                      // Set when the answer was served from the response cache
+	recorder    *service.ResponseRecorder // Captures the provider that answered this exchange
+}
+
+// cachedResponseProvider is the provider recorded for answers served from the response cache
+const cachedResponseProvider = "cache"
+
+// beginInteraction starts tracking an exchange triggered by message m. For messages in a thread,
+// the thread is recorded as the thread the answer is posted in. AI calls for the exchange must use the
+// returned context so the interaction is attributed to the provider that answered it.
+func (h *Handler) beginInteraction(ctx context.Context, m *discordgo.MessageCreate, trigger string, query string, isInThread bool) (context.Context, *pendingInteraction) {
+	ctx, recorder := service.WithResponseRecorder(ctx)
+	p := &pendingInteraction{
+		interaction: storage.Interaction{
+			GuildID:     m.GuildID,
+			ChannelID:   m.ChannelID,
+			MessageID:   m.ID,
+			TriggerType: trigger,
+			Query:       query,
+		},
+		started:  time.Now(),
+		recorder: recorder,
+	}
+	if m.Author != nil {
+		p.interaction.UserID = m.Author.ID
+	}
+	if isInThread {
+		p.setThread(m.ChannelID)
+	}
+	return ctx, p
+}
+
+// setThread records the thread the answer is posted in
+func (p *pendingInteraction) setThread(threadID string) {
+	p.interaction.ThreadID = &threadID
+}
+
+// complete records the AI provider's answer or error and the time spent waiting for it
+func (p *pendingInteraction) complete(response string, err error) {
+	p.completed = true
+	p.interaction.LatencyMs = time.Since(p.started).Milliseconds()
+	p.interaction.Response = response
+	if err != nil {
+		p.interaction.Response = ""
+		p.interaction.Error = err.Error()
+	}
+}
+
+// SetInteractionLogEnabled controls whether each Q&A exchange is persisted to the interactions table
+func (h *Handler) SetInteractionLogEnabled(enabled bool) {
+	h.interactionLogEnabled = enabled
+}
+
+// describeResponse returns the provider, model and quality score of a successful answer. Services that route
+// across providers report the one that answered through the exchange's recorder; a single provider answers
+// every call itself and is asked directly.
+func (h *Handler) describeResponse(p *pendingInteraction) (service.ResponseMetadata, bool) {
+	if p.interaction.Error != "" {
+		return service.ResponseMetadata{}, false
+	}
+	if metadata, ok := p.recorder.Describe(p.interaction.Query, p.interaction.Response); ok {
+		return metadata, true
+	}
+	if describer, ok := h.aiService.(service.ResponseDescriber); ok {
+		return describer.DescribeResponse(p.interaction.Query, p.interaction.Response), true
+	}
+	return service.ResponseMetadata{}, false
+}
+
+// recordInteraction persists a completed exchange with the provider, model and quality score that produced it
+func (h *Handler) recordInteraction(p *pendingInteraction) {
+	if p == nil || !p.completed || !h.interactionLogEnabled || h.storageService == nil {
+		return
+	}
+
+	interaction := p.interaction
+	interaction.Provider = h.aiService.GetProviderID()
+	if p.cached {
+		interaction.Provider = cachedResponseProvider
+	} else if metadata, ok := h.describeResponse(p); ok {
+		if metadata.Provider != "" {
+			interaction.Provider = metadata.Provider
+		}
+		interaction.Model = metadata.Model
+		if metadata.Quality != nil {
+			interaction.QualityOverall = &metadata.Quality.OverallScore
+			interaction.QualityBMADCoverage = &metadata.Quality.BMADCoverageScore
+			interaction.QualityKnowledgeBoundary = &metadata.Quality.KnowledgeBoundaryScore
+			interaction.QualityContent = &metadata.Quality.ContentQualityScore
+		}
+	}
+
+	// Persist asynchronously to avoid blocking message processing
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := h.storageService.RecordInteraction(ctx, &interaction); err != nil {
+			h.logger.Error("Failed to record interaction",
+				"error", err,
+				"message_id", interaction.MessageID,
+				"trigger", interaction.TriggerType)
+			return
+		}
+
+		h.logger.Debug("Interaction recorded",
+			"interaction_id", interaction.ID,
+			"message_id", interaction.MessageID,
+			"trigger", interaction.TriggerType,
+			"latency_ms", interaction.LatencyMs)
+	}()
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"bmad-knowledge-bot/internal/service"
+)
+
+// knowledgeSourcesPrefix starts the footer linking an answer to the knowledge base sections behind it
+const knowledgeSourcesPrefix = "📚 **Sources:** "
+
+// knowledgeSourceTitleReplacer keeps section titles from breaking out of their markdown link text
+var knowledgeSourceTitleReplacer = strings.NewReplacer("[", "(", "]", ")")
+
+// SetKnowledgeSourcesEnabled toggles the Sources footer linking answers to the knowledge base sections behind them
+func (h *Handler) SetKnowledgeSourcesEnabled(enabled bool) {
+	h.knowledgeSourcesEnabled = enabled
+}
+
+// SetKnowledgeDocumentURL sets the knowledge base page that source links point to; section anchors are appended to it
+func (h *Handler) SetKnowledgeDocumentURL(documentURL string) {
+	h.knowledgeDocumentURL = documentURL
+}
+
+// appendKnowledgeSources adds a compact footer linking the knowledge base sections that support response,
+// leaving it unchanged when sources are disabled or none are found
+func (h *Handler) appendKnowledgeSources(response string) string {
+	if !h.knowledgeSourcesEnabled || h.knowledgeDocumentURL == "" || strings.TrimSpace(response) == "" {
+		return response
+	}
+
+	citer, ok := h.aiService.(service.SourceCiter)
+	if !ok {
+		return response
+	}
+
+	sections := citer.CiteSources(response)
+	if len(sections) == 0 {
+		return response
+	}
+
+	links := make([]string, 0, len(sections))
+	for _, section := range sections {
+		// Angle brackets stop Discord from adding a preview embed for every link
+		links = append(links, fmt.Sprintf("[%s](<%s#%s>)", knowledgeSourceTitleReplacer.Replace(section.Heading), h.knowledgeDocumentURL, section.Anchor))
+	}
+
+	return response + "\n\n" + knowledgeSourcesPrefix + strings.Join(links, " · ")
+}
+
+// stripKnowledgeSources removes the Sources footer from a sent answer so it is not fed back to the AI as history
+func stripKnowledgeSources(content string) string {
+	start := strings.LastIndex(content, "\n\n"+knowledgeSourcesPrefix)
+	if start < 0 {
+		return content
+	}
+
+	end := len(content)
+	if newline := strings.Index(content[start+2:], "\n"); newline >= 0 {
+		end = start + 2 + newline
+	}
+	return content[:start] + content[end:]
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"bmad-knowledge-bot/internal/service"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKnowledgeDocumentURL = "https://github.com/bmadcode/BMAD-METHOD/blob/main/bmad-core/data/bmad-kb.md"
+
+// sourceCitingAIService cites a fixed set of knowledge base sections
+type sourceCitingAIService struct {
+	*MockAIService
+	sections []service.KnowledgeSection
+}
+
+func (m *sourceCitingAIService) CiteSources(response string) []service.KnowledgeSection {
+	return m.sections
+}
+
+func newTestSourcesHandler(sections []service.KnowledgeSection) *Handler {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, &sourceCitingAIService{MockAIService: NewMockAIService(), sections: sections}, nil)
+	handler.SetKnowledgeSourcesEnabled(true)
+	handler.SetKnowledgeDocumentURL(testKnowledgeDocumentURL)
+	return handler
+}
+
+func TestHandler_appendKnowledgeSources(t *testing.T) {
+	handler := newTestSourcesHandler([]service.KnowledgeSection{
+		{Heading: "Scrum Master", Anchor: "scrum-master"},
+		{Heading: "Stories [SM]", Anchor: "stories-sm"},
+	})
+
+	response := handler.appendKnowledgeSources("The Scrum Master drafts stories.")
+	assert.Equal(t, "The Scrum Master drafts stories.\n\n📚 **Sources:** "+
+		"[Scrum Master](<"+testKnowledgeDocumentURL+"#scrum-master>) · "+
+		"[Stories (SM)](<"+testKnowledgeDocumentURL+"#stories-sm>)", response)
+
+	// Blank answers are left alone
+	assert.Equal(t, " ", handler.appendKnowledgeSources(" "))
+
+	// Disabled or without a document to link to, answers are unchanged
+	handler.SetKnowledgeSourcesEnabled(false)
+	assert.Equal(t, "Answer", handler.appendKnowledgeSources("Answer"))
+	handler.SetKnowledgeSourcesEnabled(true)
+	handler.SetKnowledgeDocumentURL("")
+	assert.Equal(t, "Answer", handler.appendKnowledgeSources("Answer"))
+}
+
+func TestHandler_appendKnowledgeSourcesWithoutCitations(t *testing.T) {
+	// Nothing cited
+	handler := newTestSourcesHandler(nil)
+	assert.Equal(t, "Answer", handler.appendKnowledgeSources("Answer"))
+
+	// AI services that cannot cite sources
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler = newTestHandler(logger, NewMockAIService())
+	handler.SetKnowledgeSourcesEnabled(true)
+	handler.SetKnowledgeDocumentURL(testKnowledgeDocumentURL)
+	assert.Equal(t, "Answer", handler.appendKnowledgeSources("Answer"))
+}
+
+func TestStripKnowledgeSources(t *testing.T) {
+	handler := newTestSourcesHandler([]service.KnowledgeSection{{Heading: "Scrum Master", Anchor: "scrum-master"}})
+
+	assert.Equal(t, "Answer", stripKnowledgeSources(handler.appendKnowledgeSources("Answer")))
+	assert.Equal(t, "Answer"+dmClearCommandReminder, stripKnowledgeSources(handler.finalizeDMResponse("Answer")))
+	assert.Equal(t, "No footer here", stripKnowledgeSources("No footer here"))
+}
+
+func TestHandler_buildChatHistoryStripsKnowledgeSources(t *testing.T) {
+	handler := newTestSourcesHandler([]service.KnowledgeSection{{Heading: "Scrum Master", Anchor: "scrum-master"}})
+	messages := []*discordgo.Message{
+		{ID: "m1", Content: "What does the SM do?", Author: &discordgo.User{ID: "user1", Username: "alice"}},
+		{ID: "m2", Content: handler.appendKnowledgeSources("Drafts stories."), Author: &discordgo.User{ID: "bot123", Bot: true}},
+	}
+
+	history := handler.buildChatHistory(messages, "bot123", "m3")
+	assert.Equal(t, []service.ChatMessage{
+		{Role: service.ChatRoleUser, Content: "alice: What does the SM do?"},
+		{Role: service.ChatRoleAssistant, Content: "Drafts stories."},
+	}, history)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -71,6 +72,33 @@ func (s *Session) IsTokenValid() error {
 	return nil
 }
 
+// DefaultGatewayHeartbeatTimeout is how long the gateway may go without acknowledging a heartbeat
+// before it is considered hung (Discord heartbeats roughly every 41 seconds)
+const DefaultGatewayHeartbeatTimeout = 2 * time.Minute
+
+// CheckGatewayConnection reports an error when the Discord gateway is disconnected or has stopped
+// acknowledging heartbeats for longer than heartbeatTimeout
+func CheckGatewayConnection(session *discordgo.Session, heartbeatTimeout time.Duration) error {
+	if session == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	session.RLock()
+	dataReady := session.DataReady
+	lastAck := session.LastHeartbeatAck
+	session.RUnlock()
+
+	if !dataReady {
+		return fmt.Errorf("discord gateway is not connected")
+	}
+
+	if sinceAck := time.Since(lastAck); sinceAck > heartbeatTimeout {
+		return fmt.Errorf("discord gateway has not acknowledged a heartbeat in %v", sinceAck.Round(time.Second))
+	}
+
+	return nil
+}
+
 // GetToken returns the bot token (for internal use)
 func (s *Session) GetToken() string {
 	return s.token
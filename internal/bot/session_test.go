@@ -3,7 +3,11 @@ package bot
 import (
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 func TestNewSession(t *testing.T) {
@@ -47,3 +51,24 @@ func TestSessionTokenValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckGatewayConnection(t *testing.T) {
+	if err := CheckGatewayConnection(nil, time.Minute); err == nil {
+		t.Error("Expected error for nil session")
+	}
+
+	session := &discordgo.Session{LastHeartbeatAck: time.Now()}
+	if err := CheckGatewayConnection(session, time.Minute); err == nil || !strings.Contains(err.Error(), "not connected") {
+		t.Errorf("Expected disconnected gateway error, got %v", err)
+	}
+
+	session.DataReady = true
+	if err := CheckGatewayConnection(session, time.Minute); err != nil {
+		t.Errorf("Expected healthy gateway, got %v", err)
+	}
+
+	session.LastHeartbeatAck = time.Now().Add(-5 * time.Minute)
+	if err := CheckGatewayConnection(session, time.Minute); err == nil || !strings.Contains(err.Error(), "heartbeat") {
+		t.Errorf("Expected hung gateway error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package bot
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -17,7 +18,9 @@ type StatusRotator struct {
 	batchRefreshInterval time.Duration
 	enabled              bool
 	stopChan             chan struct{}
-	rotationCount        int // Track rotations to determine when to refresh batch
+	intervalChanged      chan struct{} // Wakes the rotation loop to pick up a new interval
+	rotationCount        int           // Track rotations to determine when to refresh batch
+	mutex                sync.RWMutex  // Guards interval, which can change while rotating
 }
 
 // NewStatusRotator creates a new status rotator
@@ -30,6 +33,7 @@ func NewStatusRotator(session *discordgo.Session, logger *slog.Logger) *StatusRo
 		batchRefreshInterval: 25 * time.Minute,    // Refresh batch every 25 minutes (5 rotations)
 		enabled:              false,
 		stopChan:             make(chan struct{}),
+		intervalChanged:      make(chan struct{}, 1),
 	}
 }
 
@@ -39,9 +43,22 @@ func (sr *StatusRotator) SetStatusManager(statusManager *StatusManager) {
 	sr.logger.Info("Status manager set for rotator")
 }
 
-// SetInterval sets the rotation interval
+// SetInterval sets the rotation interval, taking effect immediately if rotation is running
 func (sr *StatusRotator) SetInterval(interval time.Duration) {
+	sr.mutex.Lock()
+	if sr.interval == interval {
+		sr.mutex.Unlock()
+		return
+	}
 	sr.interval = interval
+	sr.mutex.Unlock()
+
+	// A pending wake-up already covers this change
+	select {
+	case sr.intervalChanged <- struct{}{}:
+	default:
+	}
+
 	sr.logger.Info("Status rotation interval set", "interval", interval)
 }
 
@@ -67,7 +84,7 @@ func (sr *StatusRotator) Start(ctx context.Context) {
 	}
 
 	sr.logger.Info("Starting BMAD status rotation",
-		"interval", sr.interval,
+		"interval", sr.GetInterval(),
 		"batch_refresh_interval", sr.batchRefreshInterval,
 		"current_batch_size", sr.statusManager.GetStatusCount())
 
@@ -91,7 +108,7 @@ func (sr *StatusRotator) Stop() {
 
 // rotationLoop runs the status rotation in a goroutine
 func (sr *StatusRotator) rotationLoop(ctx context.Context) {
-	ticker := time.NewTicker(sr.interval)
+	ticker := time.NewTicker(sr.GetInterval())
 	defer ticker.Stop()
 
 	for {
@@ -102,6 +119,8 @@ func (sr *StatusRotator) rotationLoop(ctx context.Context) {
 		case <-sr.stopChan:
 			sr.logger.Info("Status rotation stopped")
 			return
+		case <-sr.intervalChanged:
+			ticker.Reset(sr.GetInterval())
 		case <-ticker.C:
 			sr.rotateStatus(ctx)
 		}
@@ -198,5 +217,7 @@ func (sr *StatusRotator) IsRunning() bool {
 
 // GetInterval returns the current rotation interval
 func (sr *StatusRotator) GetInterval() time.Duration {
+	sr.mutex.RLock()
+	defer sr.mutex.RUnlock()
 	return sr.interval
 }
@@ -0,0 +1,381 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// streamPlaceholderMessage is posted immediately while the AI response is being generated
+	streamPlaceholderMessage = "⏳ Thinking..."
+
+	// streamEditInterval throttles progressive edits to stay well within Discord's per-channel rate limits
+	streamEditInterval = 1500 * time.Millisecond
+
+	// maxStreamMessageLength is Discord's message length limit used when rolling over into new messages
+	maxStreamMessageLength = 2000
+)
+
+// streamMessenger is the subset of the Discord session used to deliver streamed responses
+type streamMessenger interface {
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+}
+
+// streamResponder posts a placeholder reply and progressively edits it as a streamed AI response arrives,
+// rolling over into additional messages when the response exceeds Discord's message length limit.
+// Partial responses are rendered by a separate goroutine that keeps only the latest one, so slow Discord
+// edits never hold up the AI stream.
+type streamResponder struct {
+	handler      *Handler
+	messenger    streamMessenger
+	channelID    string
+	replyTo      *discordgo.MessageReference
+	editInterval time.Duration
+	mu           sync.Mutex
+	messageIDs   []string // Messages posted so far, in order
+	contents     []string // Last content rendered into each message
+	lastRender   time.Time
+
+	pendingMu  sync.Mutex
+	pending    string        // Latest partial response not yet rendered
+	hasPending bool          // Set when pending holds a partial response
+	wake       chan struct{} // Signals the render loop that a partial response is pending
+	stop       chan struct{} // Closed to end the render loop
+	done       chan struct{} // Closed once the render loop has ended; nil until it is started
+	stopOnce   sync.Once
+}
+
+// newStreamResponder creates a responder for the given channel; replyTo may be nil for plain messages
+func (h *Handler) newStreamResponder(messenger streamMessenger, channelID string, replyTo *discordgo.MessageReference) *streamResponder {
+	return &streamResponder{
+		handler:      h,
+		messenger:    messenger,
+		channelID:    channelID,
+		replyTo:      replyTo,
+		editInterval: streamEditInterval,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// start posts the placeholder message and starts rendering partial responses
+func (r *streamResponder) start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, err := r.send(streamPlaceholderMessage, true)
+	if err != nil {
+		return fmt.Errorf("failed to send placeholder message: %w", err)
+	}
+
+	r.messageIDs = append(r.messageIDs, message.ID)
+	r.contents = append(r.contents, streamPlaceholderMessage)
+
+	r.done = make(chan struct{})
+	go r.renderLoop()
+	return nil
+}
+
+// update records the latest partial response for the render loop without waiting for Discord
+func (r *streamResponder) update(partial string) {
+	r.pendingMu.Lock()
+	r.pending = partial
+	r.hasPending = true
+	r.pendingMu.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default: // The render loop has already been signalled
+	}
+}
+
+// renderLoop renders the latest partial response at most once per edit interval until stopped
+func (r *streamResponder) renderLoop() {
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-r.wake:
+		}
+
+		r.mu.Lock()
+		wait := r.editInterval - time.Since(r.lastRender)
+		r.mu.Unlock()
+		if wait > 0 {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		r.pendingMu.Lock()
+		partial, ok := r.pending, r.hasPending
+		r.pending, r.hasPending = "", false
+		r.pendingMu.Unlock()
+		if !ok {
+			continue
+		}
+
+		r.mu.Lock()
+		if err := r.render(partial); err != nil {
+			r.handler.logger.Warn("Failed to update streamed response", "error", err, "channel_id", r.channelID)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// stopRendering ends the render loop and waits for an edit in progress to complete
+func (r *streamResponder) stopRendering() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	if r.done != nil {
+		<-r.done
+	}
+}
+
+// finish renders the final response, deleting any trailing messages that are no longer needed
+func (r *streamResponder) finish(response string) error {
+	r.stopRendering()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.render(r.handler.formatForDiscord(response))
+}
+
+// discard deletes every message posted by the responder, used when the AI query fails
+func (r *streamResponder) discard() {
+	r.stopRendering()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, messageID := range r.messageIDs {
+		if err := r.messenger.ChannelMessageDelete(r.channelID, messageID); err != nil {
+			r.handler.logger.Warn("Failed to delete streamed message", "error", err, "message_id", messageID)
+		}
+	}
+	r.messageIDs = nil
+	r.contents = nil
+}
+
+// lastMessageID returns the ID of the last message posted by the responder, or "" if none remain
+func (r *streamResponder) lastMessageID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.messageIDs) == 0 {
+		return ""
+	}
+	return r.messageIDs[len(r.messageIDs)-1]
+}
+
+// render distributes text across the responder's messages using splitResponseIntoChunks,
+// editing messages whose content changed and sending new ones as the text grows
+func (r *streamResponder) render(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	r.lastRender = time.Now()
+
+	chunks := r.handler.splitResponseIntoChunks(text, maxStreamMessageLength)
+	for i, chunk := range chunks {
+		if i < len(r.messageIDs) {
+			if r.contents[i] == chunk {
+				continue
+			}
+			if _, err := r.messenger.ChannelMessageEdit(r.channelID, r.messageIDs[i], chunk); err != nil {
+				return fmt.Errorf("failed to edit message %d/%d: %w", i+1, len(chunks), err)
+			}
+			r.contents[i] = chunk
+			continue
+		}
+
+		message, err := r.send(chunk, len(r.messageIDs) == 0)
+		if err != nil {
+			return fmt.Errorf("failed to send message %d/%d: %w", i+1, len(chunks), err)
+		}
+		r.messageIDs = append(r.messageIDs, message.ID)
+		r.contents = append(r.contents, chunk)
+	}
+
+	// The final cleaned response can be shorter than the streamed text (e.g. once summary markers are removed)
+	for len(r.messageIDs) > len(chunks) {
+		last := len(r.messageIDs) - 1
+		if err := r.messenger.ChannelMessageDelete(r.channelID, r.messageIDs[last]); err != nil {
+			return fmt.Errorf("failed to delete surplus message: %w", err)
+		}
+		r.messageIDs = r.messageIDs[:last]
+		r.contents = r.contents[:last]
+	}
+
+	return nil
+}
+
+// send posts a new message, replying to the triggering message for the first one when configured
+func (r *streamResponder) send(content string, first bool) (*discordgo.Message, error) {
+	if first && r.replyTo != nil {
+		return r.messenger.ChannelMessageSendReply(r.channelID, content, r.replyTo)
+	}
+	return r.messenger.ChannelMessageSend(r.channelID, content)
+}
+
+// SetStreamingEnabled toggles progressive streaming of AI responses for AI services that support it
+func (h *Handler) SetStreamingEnabled(enabled bool) {
+	h.streamingEnabled = enabled
+	h.logger.Info("AI response streaming configured", "enabled", enabled)
+}
+
+// streamQueryWithHistory streams a contextual AI response into the channel, editing a placeholder as tokens arrive.
+// finalize, if set, decorates the final response before it is rendered. finalMessageID is the last message holding
+// the response. streamed is false when streaming is disabled or unsupported, in which case nothing was sent and the
+// caller should fall back to a blocking query. When streamed is true and err is non-nil, all streamed messages have
+// been removed and the caller should report the error.
+func (h *Handler) streamQueryWithHistory(ctx context.Context, s *discordgo.Session, channelID string, replyTo *discordgo.MessageReference, query string, history []service.ChatMessage, finalize func(string) string) (response string, finalMessageID string, streamed bool, err error) {
+	if !h.streamingAvailable(s) {
+		return "", "", false, nil
+	}
+
+	return h.streamQueryTo(ctx, s, h.aiService.(service.StreamingAIService), channelID, replyTo, query, history, finalize)
+}
+
+// streamStandaloneQuery streams the answer to a question that has no conversation history, falling back to a
+// blocking query when streaming is disabled or unsupported. Results are as for streamQueryWithHistory.
+func (h *Handler) streamStandaloneQuery(ctx context.Context, s *discordgo.Session, channelID string, replyTo *discordgo.MessageReference, query string, finalize func(string) string) (response string, finalMessageID string, streamed bool, err error) {
+	response, finalMessageID, streamed, err = h.streamQueryWithHistory(ctx, s, channelID, replyTo, query, nil, finalize)
+	if streamed {
+		return response, finalMessageID, true, err
+	}
+
+	response, err = h.aiService.QueryAI(ctx, query)
+	return response, "", false, err
+}
+
+// streamingAvailable reports whether streaming is enabled and supported by the AI service
+func (h *Handler) streamingAvailable(s *discordgo.Session) bool {
+	if !h.streamingEnabled || s == nil {
+		return false
+	}
+
+	_, ok := h.aiService.(service.StreamingAIService)
+	return ok
+}
+
+// newThreadAnswer describes how the answer to a main channel message is posted in a thread of its own
+type newThreadAnswer struct {
+	title        func(summary string) string // Builds the thread title from the question summary, "" if none
+	lead         string                      // Precedes the answer in the thread
+	fallbackLead string                      // Precedes the answer when the thread cannot be created
+}
+
+// streamAnswerInNewThread creates the thread for a main channel question before streaming the answer into it,
+// taking the thread title from a separate summary call. When the thread cannot be created, the answer is
+// streamed as a reply in the channel instead. store caches the answer and its summary once generated.
+func (h *Handler) streamAnswerInNewThread(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, interaction *pendingInteraction, query string, store func(response, summary string), answer newThreadAnswer) {
+	summary, err := h.aiService.SummarizeQuery(ctx, query)
+	if err != nil {
+		h.logger.Warn("Failed to summarize query for thread title", "error", err)
+		summary = ""
+	}
+
+	threadTitle := answer.title(summary)
+	h.logger.Info("Thread title determined", "title", threadTitle, "length", len(threadTitle))
+
+	channelID, replyTo, lead := m.ChannelID, m.Reference(), answer.fallbackLead
+	thread, err := s.ThreadStart(m.ChannelID, threadTitle, discordgo.ChannelTypeGuildPublicThread, 60) // 60 minute auto-archive
+	if err != nil {
+		h.logger.Error("Failed to create thread, streaming response in channel", "error", err, "channel_id", m.ChannelID)
+	} else {
+		h.logger.Info("Thread created successfully",
+			"thread_id", thread.ID,
+			"thread_name", thread.Name,
+			"parent_channel", m.ChannelID)
+		interaction.setThread(thread.ID)
+
+		// Record thread ownership for auto-response functionality
+		h.recordThreadOwnership(thread.ID, m.Author.ID, s.State.User.ID)
+		channelID, replyTo, lead = thread.ID, nil, answer.lead
+	}
+
+	var aiResponse string
+	response, finalMessageID, streamed, err := h.streamQueryWithHistory(ctx, s, channelID, replyTo, query, nil, func(response string) string {
+		aiResponse = response
+		return lead + h.appendKnowledgeSources(response)
+	})
+	if !streamed {
+		aiResponse, err = h.aiService.QueryAI(ctx, query)
+	}
+
+	interaction.complete(aiResponse, err)
+	if err != nil {
+		if aiWorkCancelled(ctx) {
+			h.logger.Info("AI work cancelled, not replying", "error", err, "message_id", m.ID)
+			return
+		}
+		h.logger.Error("AI service error", "error", err, "query", query)
+
+		errorMsg := "I'm sorry, I encountered an error while processing your request. Please try again later."
+		if _, err := s.ChannelMessageSendReply(m.ChannelID, errorMsg, m.Reference()); err != nil {
+			h.logger.Error("Failed to send error reply", "error", err)
+		}
+		return
+	}
+	store(aiResponse, summary)
+
+	if streamed {
+		h.attachFeedback(s, interaction, channelID, finalMessageID)
+		h.logger.Info("AI response streamed successfully",
+			"response_length", len(response),
+			"channel_id", channelID,
+			"message_id", m.ID)
+		return
+	}
+
+	response = lead + h.appendKnowledgeSources(aiResponse)
+	if message, err := h.sendResponseInChunksWithOptions(s, channelID, response, channelID != m.ChannelID); err != nil {
+		h.logger.Error("Failed to send AI response", "error", err, "channel_id", channelID)
+	} else {
+		h.attachFeedbackToMessage(s, interaction, message)
+		h.logger.Info("AI response sent successfully",
+			"response_length", len(response),
+			"channel_id", channelID,
+			"message_id", m.ID)
+	}
+}
+
+// streamQueryTo performs the streamed query using the given messenger
+func (h *Handler) streamQueryTo(ctx context.Context, messenger streamMessenger, streamingService service.StreamingAIService, channelID string, replyTo *discordgo.MessageReference, query string, history []service.ChatMessage, finalize func(string) string) (string, string, bool, error) {
+	responder := h.newStreamResponder(messenger, channelID, replyTo)
+	if err := responder.start(); err != nil {
+		h.logger.Warn("Failed to start streamed response, falling back to regular query", "error", err, "channel_id", channelID)
+		return "", "", false, nil
+	}
+
+	response, err := streamingService.QueryWithMessagesStream(ctx, query, history, responder.update)
+	if err != nil {
+		responder.discard()
+		return "", "", true, err
+	}
+
+	if finalize != nil {
+		response = finalize(response)
+	}
+
+	if err := responder.finish(response); err != nil {
+		h.logger.Error("Failed to send final streamed response", "error", err, "channel_id", channelID)
+	}
+
+	return response, responder.lastMessageID(), true, nil
+}
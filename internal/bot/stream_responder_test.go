@@ -0,0 +1,302 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/service"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStreamMessenger records messages sent, edited and deleted by a streamResponder
+type fakeStreamMessenger struct {
+	mu       sync.Mutex
+	nextID   int
+	messages map[string]string
+	order    []string
+	replies  int
+	edits    int
+	deleted  []string
+	sendErr  error
+	editGate chan struct{} // When set, edits block until it is closed
+}
+
+func newFakeStreamMessenger() *fakeStreamMessenger {
+	return &fakeStreamMessenger{messages: make(map[string]string)}
+}
+
+func (f *fakeStreamMessenger) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	f.nextID++
+	id := fmt.Sprintf("msg%d", f.nextID)
+	f.messages[id] = content
+	f.order = append(f.order, id)
+	return &discordgo.Message{ID: id, ChannelID: channelID, Content: content}, nil
+}
+
+func (f *fakeStreamMessenger) ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	f.replies++
+	f.mu.Unlock()
+	return f.ChannelMessageSend(channelID, content)
+}
+
+func (f *fakeStreamMessenger) ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.editGate != nil {
+		<-f.editGate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.edits++
+	f.messages[messageID] = content
+	return &discordgo.Message{ID: messageID, ChannelID: channelID, Content: content}, nil
+}
+
+func (f *fakeStreamMessenger) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, messageID)
+	delete(f.messages, messageID)
+	return nil
+}
+
+// visible returns the content of messages that have not been deleted, in posting order
+func (f *fakeStreamMessenger) visible() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var contents []string
+	for _, id := range f.order {
+		if content, ok := f.messages[id]; ok {
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
+
+// mockStreamingAIService streams a fixed set of partial responses
+type mockStreamingAIService struct {
+	*MockAIService
+	partials []string
+	final    string
+	err      error
+	history  []service.ChatMessage
+}
+
+func (m *mockStreamingAIService) QueryWithMessages(ctx context.Context, query string, history []service.ChatMessage) (string, error) {
+	m.history = history
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.final, nil
+}
+
+func (m *mockStreamingAIService) QueryWithMessagesStream(ctx context.Context, query string, history []service.ChatMessage, onProgress func(partial string)) (string, error) {
+	m.history = history
+	for _, partial := range m.partials {
+		onProgress(partial)
+	}
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.final, nil
+}
+
+func TestStreamResponder_ThrottlesAndRollsOver(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+	messenger := newFakeStreamMessenger()
+
+	responder := handler.newStreamResponder(messenger, "channel1", &discordgo.MessageReference{MessageID: "question"})
+	responder.editInterval = 200 * time.Millisecond
+	require.NoError(t, responder.start())
+	assert.Equal(t, []string{streamPlaceholderMessage}, messenger.visible())
+	assert.Equal(t, 1, messenger.replies)
+
+	// First update renders immediately
+	responder.update("Hello")
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"Hello"}, messenger.visible())
+	}, time.Second, 5*time.Millisecond)
+
+	// Updates inside the interval are coalesced into a single render of the latest partial
+	responder.update("Hello world")
+	responder.update("Hello world again")
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"Hello world again"}, messenger.visible())
+	}, time.Second, 5*time.Millisecond)
+
+	// Final response longer than the limit rolls over into additional messages
+	words := strings.Repeat("word ", 500)
+	require.NoError(t, responder.finish(words))
+
+	visible := messenger.visible()
+	require.Len(t, visible, 2)
+	for _, content := range visible {
+		assert.LessOrEqual(t, len(content), maxStreamMessageLength)
+	}
+	assert.Equal(t, strings.TrimSpace(words), visible[0]+" "+visible[1])
+	assert.Equal(t, 1, messenger.replies, "only the first message should be a reply")
+	assert.Equal(t, 3, messenger.edits, "two coalesced partial renders and the final render")
+}
+
+func TestStreamResponder_UpdateDoesNotWaitForEdits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+	messenger := newFakeStreamMessenger()
+	messenger.editGate = make(chan struct{})
+
+	responder := handler.newStreamResponder(messenger, "channel1", nil)
+	responder.editInterval = 0
+	require.NoError(t, responder.start())
+
+	// The first edit blocks, yet further updates return immediately
+	updated := make(chan struct{})
+	go func() {
+		for i := 1; i <= 50; i++ {
+			responder.update(fmt.Sprintf("partial %d", i))
+		}
+		close(updated)
+	}()
+
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("update blocked on a slow edit")
+	}
+
+	close(messenger.editGate)
+	require.NoError(t, responder.finish("Final answer"))
+	assert.Equal(t, []string{"Final answer"}, messenger.visible())
+}
+
+func TestStreamResponder_RemovesSurplusMessages(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+	messenger := newFakeStreamMessenger()
+
+	responder := handler.newStreamResponder(messenger, "channel1", nil)
+	responder.editInterval = 0
+	require.NoError(t, responder.start())
+
+	responder.update(strings.Repeat("word ", 500))
+	require.Eventually(t, func() bool { return len(messenger.visible()) == 2 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, responder.finish("Short final answer"))
+	assert.Equal(t, []string{"Short final answer"}, messenger.visible())
+	assert.Len(t, messenger.deleted, 1)
+}
+
+// testChatHistory is a short role-tagged conversation used by streaming tests
+var testChatHistory = []service.ChatMessage{
+	{Role: service.ChatRoleUser, Content: "alice: What are BMAD agents?"},
+	{Role: service.ChatRoleAssistant, Content: "Agents are specialized roles."},
+}
+
+func TestHandler_streamQueryTo(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+
+	t.Run("streams final response with finalize", func(t *testing.T) {
+		messenger := newFakeStreamMessenger()
+		streamingService := &mockStreamingAIService{
+			MockAIService: NewMockAIService(),
+			partials:      []string{"BMAD", "BMAD agents"},
+			final:         "BMAD agents help.",
+		}
+
+		response, finalMessageID, streamed, err := handler.streamQueryTo(context.Background(), messenger, streamingService, "channel1", nil, "query", testChatHistory, func(response string) string {
+			return response + "\n\nReminder"
+		})
+		require.NoError(t, err)
+		assert.True(t, streamed)
+		assert.Equal(t, "msg1", finalMessageID)
+		assert.Equal(t, "BMAD agents help.\n\nReminder", response)
+		assert.Equal(t, []string{"BMAD agents help.\n\nReminder"}, messenger.visible())
+		assert.Equal(t, testChatHistory, streamingService.history)
+	})
+
+	t.Run("discards messages on error", func(t *testing.T) {
+		messenger := newFakeStreamMessenger()
+		streamingService := &mockStreamingAIService{
+			MockAIService: NewMockAIService(),
+			partials:      []string{"partial"},
+			err:           errors.New("ollama API error"),
+		}
+
+		_, _, streamed, err := handler.streamQueryTo(context.Background(), messenger, streamingService, "channel1", nil, "query", testChatHistory, nil)
+		assert.Error(t, err)
+		assert.True(t, streamed)
+		assert.Empty(t, messenger.visible())
+	})
+
+	t.Run("falls back when placeholder cannot be sent", func(t *testing.T) {
+		messenger := newFakeStreamMessenger()
+		messenger.sendErr = errors.New("missing permissions")
+		streamingService := &mockStreamingAIService{MockAIService: NewMockAIService()}
+
+		_, _, streamed, err := handler.streamQueryTo(context.Background(), messenger, streamingService, "channel1", nil, "query", testChatHistory, nil)
+		assert.NoError(t, err)
+		assert.False(t, streamed)
+	})
+}
+
+func TestHandler_streamQueryWithHistory_DisabledOrUnsupported(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Streaming disabled by default
+	handler := NewHandler(logger, &mockStreamingAIService{MockAIService: NewMockAIService()}, nil)
+	_, _, streamed, err := handler.streamQueryWithHistory(context.Background(), &discordgo.Session{}, "channel1", nil, "query", testChatHistory, nil)
+	assert.NoError(t, err)
+	assert.False(t, streamed)
+
+	// AI service without streaming support
+	handler = NewHandler(logger, NewMockAIService(), nil)
+	handler.SetStreamingEnabled(true)
+	_, _, streamed, err = handler.streamQueryWithHistory(context.Background(), &discordgo.Session{}, "channel1", nil, "query", testChatHistory, nil)
+	assert.NoError(t, err)
+	assert.False(t, streamed)
+}
+
+func TestHandler_streamStandaloneQuery_FallsBackToQueryAI(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, &mockStreamingAIService{MockAIService: NewMockAIService()}, nil)
+
+	// Streaming disabled by default, so the blocking query answers and nothing was sent
+	response, finalMessageID, streamed, err := handler.streamStandaloneQuery(context.Background(), &discordgo.Session{}, "channel1", nil, "What is BMAD?", handler.finalizeDMResponse)
+	require.NoError(t, err)
+	assert.False(t, streamed)
+	assert.Empty(t, finalMessageID)
+	assert.Equal(t, "Default mock response for: What is BMAD?", response)
+}
+
+func TestHandler_replyMentionThreadTitle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewHandler(logger, NewMockAIService(), nil)
+
+	assert.Equal(t, "Re: alice - BMAD agents", handler.replyMentionThreadTitle("What are BMAD agents?", "BMAD agents", "alice"))
+	assert.Equal(t, "Re: alice - "+handler.createFallbackTitle("What are BMAD agents?"),
+		handler.replyMentionThreadTitle("What are BMAD agents?", "", "alice"))
+
+	title := handler.replyMentionThreadTitle("query", strings.Repeat("long ", 30), "alice")
+	assert.Len(t, title, 100)
+	assert.True(t, strings.HasSuffix(title, "..."))
+}
@@ -0,0 +1,384 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultThreadContextTokens is the model context size thread history is budgeted against
+const DefaultThreadContextTokens = 32768
+
+const (
+	// threadResponseReserveTokens leaves room in the context for the generated answer
+	threadResponseReserveTokens = 1024
+
+	// minThreadHistoryTokens keeps some history even when the prompt alone nearly fills the context
+	minThreadHistoryTokens = 512
+
+	// threadMessageOverheadTokens approximates the role and formatting tokens added around each message
+	threadMessageOverheadTokens = 4
+
+	// threadSummaryPrefix introduces the summary of older thread messages in prompts
+	threadSummaryPrefix = "Summary of the earlier conversation: "
+
+	// threadHistoryPageSize is the most messages Discord returns for one history request
+	threadHistoryPageSize = 100
+
+	// maxThreadHistoryPages bounds how far back one question reads a thread that has not been summarized yet
+	maxThreadHistoryPages = 10
+
+	// unbudgetedThreadHistoryLimit is how many recent messages are used when history is not budgeted
+	unbudgetedThreadHistoryLimit = 50
+)
+
+// ThreadMessagePager returns up to limit messages of a thread sent before the message with ID before, or the
+// newest messages when before is empty, newest first as Discord returns them
+type ThreadMessagePager func(limit int, before string) ([]*discordgo.Message, error)
+
+// ThreadHistory fits thread conversation history into the model's context. Messages that no longer fit
+// the token budget are folded into a rolling summary stored per thread, which is extended with newly
+// overflowing messages rather than regenerated on every question.
+type ThreadHistory struct {
+	storageService storage.StorageService
+	aiService      service.AIService
+	logger         *slog.Logger
+	mu             sync.Mutex
+	contextTokens  int
+	threadLocks    map[string]*threadHistoryLock
+}
+
+// threadHistoryLock serializes summary updates for one thread
+type threadHistoryLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+// NewThreadHistory creates a thread history fitter storing summaries in storageService and summarizing with aiService
+func NewThreadHistory(storageService storage.StorageService, aiService service.AIService, logger *slog.Logger) *ThreadHistory {
+	return &ThreadHistory{
+		storageService: storageService,
+		aiService:      aiService,
+		logger:         logger,
+		contextTokens:  DefaultThreadContextTokens,
+		threadLocks:    make(map[string]*threadHistoryLock),
+	}
+}
+
+// SetContextTokens sets the model context size, in tokens, that history is budgeted against
+func (t *ThreadHistory) SetContextTokens(tokens int) {
+	t.mu.Lock()
+	t.contextTokens = tokens
+	t.mu.Unlock()
+}
+
+// Budget returns how many tokens of history fit alongside the prompt, the query and the answer
+func (t *ThreadHistory) Budget(query string) int {
+	t.mu.Lock()
+	budget := t.contextTokens
+	t.mu.Unlock()
+
+	budget -= threadResponseReserveTokens + service.EstimateTokens(query)
+	if sizer, ok := t.aiService.(service.PromptSizer); ok {
+		budget -= sizer.PromptTokens()
+	}
+	return max(budget, minThreadHistoryTokens)
+}
+
+// Fit returns the thread's stored summary and the newest messages that fit the history budget for query.
+// Messages, oldest first, already covered by the summary are dropped. When the rest overflows the budget,
+// the newest messages filling half of it are kept and the older ones are folded into the summary.
+// Only a cancelled or expired ctx is returned as an error; other failures fall back to trimming.
+func (t *ThreadHistory) Fit(ctx context.Context, threadID, query string, messages []*discordgo.Message) (string, []*discordgo.Message, error) {
+	unlock := t.lockThread(threadID)
+	defer unlock()
+
+	previous, err := t.loadSummary(ctx, threadID)
+	if err != nil {
+		return "", nil, err
+	}
+	return t.fit(ctx, threadID, query, previous, messages)
+}
+
+// FetchAndFit reads the thread through fetch back to the last message covered by its stored summary, or to
+// the start of the thread, and fits the messages like Fit. Reading back to the summary rather than a fixed
+// number of messages means messages that scroll out of the budget are always folded into the summary.
+// Only fetch failures and a cancelled or expired ctx are returned as errors.
+func (t *ThreadHistory) FetchAndFit(ctx context.Context, threadID, query string, fetch ThreadMessagePager) (string, []*discordgo.Message, error) {
+	unlock := t.lockThread(threadID)
+	defer unlock()
+
+	previous, err := t.loadSummary(ctx, threadID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var throughID string
+	if previous != nil {
+		throughID = previous.SummarizedThroughID
+	}
+	messages, err := t.fetchUnsummarized(ctx, threadID, throughID, fetch)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return t.fit(ctx, threadID, query, previous, messages)
+}
+
+// loadSummary returns the thread's stored summary, or nil when it has none or it cannot be loaded.
+// Only a cancelled or expired ctx is returned as an error.
+func (t *ThreadHistory) loadSummary(ctx context.Context, threadID string) (*storage.ThreadSummary, error) {
+	previous, err := t.storageService.GetThreadSummary(ctx, threadID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		t.logger.Warn("Failed to load thread summary, budgeting history without it", "error", err, "thread_id", threadID)
+		return nil, nil
+	}
+	return previous, nil
+}
+
+// fetchUnsummarized pages back through the thread until the message with ID throughID, the start of the
+// thread or the page limit, returning the messages after throughID oldest first
+func (t *ThreadHistory) fetchUnsummarized(ctx context.Context, threadID, throughID string, fetch ThreadMessagePager) ([]*discordgo.Message, error) {
+	var newestFirst []*discordgo.Message
+	before := ""
+	for page := 0; ; page++ {
+		if page == maxThreadHistoryPages {
+			t.logger.Warn("Thread history exceeds the page limit, older messages are left out",
+				"thread_id", threadID,
+				"fetched_messages", len(newestFirst))
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		messages, err := fetch(threadHistoryPageSize, before)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch thread messages: %w", err)
+		}
+
+		reachedSummary := false
+		for _, message := range messages {
+			if throughID != "" && !snowflakeAfter(message.ID, throughID) {
+				reachedSummary = true
+				break
+			}
+			newestFirst = append(newestFirst, message)
+		}
+		if reachedSummary || len(messages) < threadHistoryPageSize {
+			break
+		}
+		before = messages[len(messages)-1].ID
+	}
+
+	messages := make([]*discordgo.Message, len(newestFirst))
+	for i, message := range newestFirst {
+		messages[len(newestFirst)-1-i] = message
+	}
+	return messages, nil
+}
+
+// fit budgets messages, oldest first, against the previous summary, folding what overflows into it
+func (t *ThreadHistory) fit(ctx context.Context, threadID, query string, previous *storage.ThreadSummary, messages []*discordgo.Message) (string, []*discordgo.Message, error) {
+	var summary string
+	if previous != nil {
+		summary = previous.Summary
+		messages = messagesAfter(messages, previous.SummarizedThroughID)
+	}
+
+	budget := t.Budget(query)
+	used := service.EstimateTokens(summary)
+	for _, message := range messages {
+		used += threadMessageTokens(message)
+	}
+	if used <= budget {
+		return summary, messages, nil
+	}
+
+	// Keep the newest messages in half the budget, leaving the rest for the summary and newer turns
+	kept := 0
+	keptTokens := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		tokens := threadMessageTokens(messages[i])
+		if kept > 0 && keptTokens+tokens > budget/2 {
+			break
+		}
+		kept++
+		keptTokens += tokens
+	}
+	folded := messages[:len(messages)-kept]
+	messages = messages[len(messages)-kept:]
+	if len(folded) == 0 {
+		return summary, messages, nil
+	}
+
+	updated, err := t.summarize(ctx, threadID, previous, folded, budget)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", nil, ctx.Err()
+		}
+		t.logger.Warn("Failed to summarize thread history, dropping older messages", "error", err, "thread_id", threadID)
+		return summary, messages, nil
+	}
+
+	t.logger.Info("Thread history summarized",
+		"thread_id", threadID,
+		"summarized_messages", len(folded),
+		"kept_messages", kept,
+		"budget_tokens", budget)
+
+	return updated, messages, nil
+}
+
+// summarize extends the previous summary with folded messages and stores the result. Messages are summarized
+// in batches of about budget tokens, each extending the summary of the batches before it, so a long backlog
+// never overflows the summarizing call.
+func (t *ThreadHistory) summarize(ctx context.Context, threadID string, previous *storage.ThreadSummary, folded []*discordgo.Message, budget int) (string, error) {
+	var summary string
+	summarized := len(folded)
+	if previous != nil {
+		summary = previous.Summary
+		summarized += previous.SummarizedMessages
+	}
+
+	for start := 0; start < len(folded); {
+		var lines []string
+		used := 0
+		if summary != "" {
+			lines = append(lines, "Earlier summary: "+summary)
+			used = service.EstimateTokens(summary)
+		}
+
+		end := start
+		for end < len(folded) {
+			tokens := threadMessageTokens(folded[end])
+			if end > start && used+tokens > budget {
+				break
+			}
+			lines = append(lines, formatThreadMessage(folded[end]))
+			used += tokens
+			end++
+		}
+
+		var err error
+		summary, err = t.aiService.SummarizeConversation(ctx, lines)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize thread history: %w", err)
+		}
+		start = end
+	}
+
+	err := t.storageService.UpsertThreadSummary(ctx, &storage.ThreadSummary{
+		ThreadID:            threadID,
+		Summary:             summary,
+		SummarizedThroughID: folded[len(folded)-1].ID,
+		SummarizedMessages:  summarized,
+	})
+	if err != nil {
+		// The summary still serves this question; the messages are summarized again next time
+		t.logger.Warn("Failed to store thread summary", "error", err, "thread_id", threadID)
+	}
+
+	return summary, nil
+}
+
+// lockThread serializes Fit calls for a thread so concurrent questions do not summarize the same messages twice
+func (t *ThreadHistory) lockThread(threadID string) func() {
+	t.mu.Lock()
+	lock, exists := t.threadLocks[threadID]
+	if !exists {
+		lock = &threadHistoryLock{}
+		t.threadLocks[threadID] = lock
+	}
+	lock.waiters++
+	t.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		t.mu.Lock()
+		if lock.waiters--; lock.waiters == 0 {
+			delete(t.threadLocks, threadID)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// threadMessageTokens estimates the tokens a thread message takes in the prompt
+func threadMessageTokens(message *discordgo.Message) int {
+	return service.EstimateTokens(formatThreadMessage(message)) + threadMessageOverheadTokens
+}
+
+// formatThreadMessage renders a thread message as a "speaker: content" line
+func formatThreadMessage(message *discordgo.Message) string {
+	speaker := "User"
+	if message.Author != nil {
+		speaker = message.Author.Username
+		if message.Author.Bot {
+			speaker = "Assistant"
+		}
+	}
+	return fmt.Sprintf("%s: %s", speaker, stripKnowledgeSources(message.Content))
+}
+
+// messagesAfter drops the messages, oldest first, up to and including the one with ID throughID
+func messagesAfter(messages []*discordgo.Message, throughID string) []*discordgo.Message {
+	for i, message := range messages {
+		if snowflakeAfter(message.ID, throughID) {
+			return messages[i:]
+		}
+	}
+	return nil
+}
+
+// snowflakeAfter reports whether Discord ID a was created after ID b; snowflakes grow over time, so a
+// longer decimal ID is newer and IDs of equal length compare lexically
+func snowflakeAfter(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
+// prependThreadSummary leads chat history with the summary of older thread messages
+func prependThreadSummary(summary string, history []service.ChatMessage) []service.ChatMessage {
+	if summary == "" {
+		return history
+	}
+
+	content := threadSummaryPrefix + summary
+	if len(history) > 0 && history[0].Role == service.ChatRoleUser {
+		// Merge with a leading user turn so turns strictly alternate
+		return append([]service.ChatMessage{{Role: service.ChatRoleUser, Content: content + "\n" + history[0].Content}}, history[1:]...)
+	}
+	return append([]service.ChatMessage{{Role: service.ChatRoleUser, Content: content}}, history...)
+}
+
+// SetThreadHistory budgets thread history against the model context, summarizing what no longer fits
+func (h *Handler) SetThreadHistory(history *ThreadHistory) {
+	h.threadHistory = history
+}
+
+// loadThreadHistory returns the summary of older thread messages and the messages that fit the prompt for
+// query, oldest first. Budgeted history reads the thread back to where its stored summary ends; without a
+// budget the most recent messages are used as they are.
+func (h *Handler) loadThreadHistory(ctx context.Context, s *discordgo.Session, threadID, query string) (string, []*discordgo.Message, error) {
+	if h.threadHistory == nil {
+		messages, err := h.fetchThreadHistory(s, threadID, s.State.User.ID, unbudgetedThreadHistoryLimit, true)
+		return "", messages, err
+	}
+
+	return h.threadHistory.FetchAndFit(ctx, threadID, query, func(limit int, before string) ([]*discordgo.Message, error) {
+		return s.ChannelMessages(threadID, limit, before, "", "")
+	})
+}
@@ -0,0 +1,250 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"bmad-knowledge-bot/internal/service"
+	"bmad-knowledge-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThreadHistory(t *testing.T) (*ThreadHistory, *storage.MemoryStorageService, *MockAIService) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	storageService := storage.NewMemoryStorageService()
+	mockAI := NewMockAIService()
+	history := NewThreadHistory(storageService, mockAI, logger)
+	// Leaves the minimum history budget of 512 tokens
+	history.SetContextTokens(threadResponseReserveTokens)
+	return history, storageService, mockAI
+}
+
+// threadMessages creates count messages with increasing IDs from first, each taking about 56 tokens
+func threadMessages(first, count int) []*discordgo.Message {
+	messages := make([]*discordgo.Message, count)
+	for i := range messages {
+		messages[i] = &discordgo.Message{
+			ID:      fmt.Sprint(1000 + first + i),
+			Content: strings.Repeat("x", 200),
+			Author:  &discordgo.User{ID: "user-1", Username: "alice"},
+		}
+	}
+	return messages
+}
+
+// promptSizedAIService reports a fixed prompt size
+type promptSizedAIService struct {
+	*MockAIService
+	tokens int
+}
+
+func (m *promptSizedAIService) PromptTokens() int { return m.tokens }
+
+func TestThreadHistory_Budget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	history := NewThreadHistory(storage.NewMemoryStorageService(), &promptSizedAIService{MockAIService: NewMockAIService(), tokens: 20000}, logger)
+
+	// The prompt, query and answer reserve come out of the context
+	assert.Equal(t, DefaultThreadContextTokens-20000-threadResponseReserveTokens-service.EstimateTokens("What is BMAD?"), history.Budget("What is BMAD?"))
+
+	// Some history is always allowed
+	history.SetContextTokens(8192)
+	assert.Equal(t, minThreadHistoryTokens, history.Budget("What is BMAD?"))
+}
+
+func TestThreadHistory_Fit(t *testing.T) {
+	history, storageService, mockAI := newTestThreadHistory(t)
+	ctx := context.Background()
+
+	// Short threads are used as they are
+	summary, kept, err := history.Fit(ctx, "thread-1", "More?", threadMessages(0, 3))
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+	assert.Len(t, kept, 3)
+
+	// Long threads keep the newest messages in half the budget and summarize the rest
+	mockAI.SetConversationSummary("First summary")
+	messages := threadMessages(0, 20)
+	summary, kept, err = history.Fit(ctx, "thread-1", "More?", messages)
+	require.NoError(t, err)
+	assert.Equal(t, "First summary", summary)
+	require.Len(t, kept, 4)
+	assert.Equal(t, messages[16].ID, kept[0].ID)
+
+	stored, err := storageService.GetThreadSummary(ctx, "thread-1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, messages[15].ID, stored.SummarizedThroughID)
+	assert.Equal(t, 16, stored.SummarizedMessages)
+
+	// Messages covered by the summary are skipped and the summary is reused while the rest fits
+	mockAI.SetConversationSummary("Second summary")
+	messages = append(messages, threadMessages(20, 2)...)
+	summary, kept, err = history.Fit(ctx, "thread-1", "More?", messages)
+	require.NoError(t, err)
+	assert.Equal(t, "First summary", summary)
+	assert.Len(t, kept, 6)
+
+	// Once the thread overflows again the summary is extended
+	messages = append(messages, threadMessages(22, 10)...)
+	summary, kept, err = history.Fit(ctx, "thread-1", "More?", messages)
+	require.NoError(t, err)
+	assert.Equal(t, "Second summary", summary)
+	assert.Len(t, kept, 4)
+
+	stored, err = storageService.GetThreadSummary(ctx, "thread-1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 28, stored.SummarizedMessages)
+}
+
+func TestThreadHistory_FitSummarizeFailure(t *testing.T) {
+	history, storageService, mockAI := newTestThreadHistory(t)
+	mockAI.SetError("conversation_summary", errors.New("provider down"))
+
+	// Without a summary the older messages are dropped
+	summary, kept, err := history.Fit(context.Background(), "thread-1", "More?", threadMessages(0, 20))
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+	assert.Len(t, kept, 4)
+
+	stored, err := storageService.GetThreadSummary(context.Background(), "thread-1")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Cancelled work is reported rather than answered from partial history
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = history.Fit(ctx, "thread-1", "More?", threadMessages(0, 20))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// threadPager serves a thread's messages, oldest first, in pages like Discord's history endpoint
+type threadPager struct {
+	messages []*discordgo.Message
+	requests int
+	err      error
+}
+
+func (p *threadPager) fetch(limit int, before string) ([]*discordgo.Message, error) {
+	p.requests++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	end := len(p.messages)
+	if before != "" {
+		for end > 0 && !snowflakeAfter(before, p.messages[end-1].ID) {
+			end--
+		}
+	}
+
+	var page []*discordgo.Message
+	for i := end - 1; i >= 0 && len(page) < limit; i-- {
+		page = append(page, p.messages[i])
+	}
+	return page, nil
+}
+
+func TestThreadHistory_FetchAndFit(t *testing.T) {
+	history, storageService, mockAI := newTestThreadHistory(t)
+	ctx := context.Background()
+
+	// A thread longer than one page is read to its start and everything that does not fit is summarized
+	mockAI.SetConversationSummary("First summary")
+	pager := &threadPager{messages: threadMessages(0, 250)}
+	summary, kept, err := history.FetchAndFit(ctx, "thread-1", "More?", pager.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "First summary", summary)
+	assert.Equal(t, 3, pager.requests)
+	require.Len(t, kept, 4)
+	assert.Equal(t, pager.messages[246].ID, kept[0].ID)
+
+	stored, err := storageService.GetThreadSummary(ctx, "thread-1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, pager.messages[245].ID, stored.SummarizedThroughID)
+	assert.Equal(t, 246, stored.SummarizedMessages)
+
+	// Later questions read back only to where the summary ends
+	pager.messages = append(pager.messages, threadMessages(250, 2)...)
+	pager.requests = 0
+	summary, kept, err = history.FetchAndFit(ctx, "thread-1", "More?", pager.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "First summary", summary)
+	assert.Equal(t, 1, pager.requests)
+	assert.Len(t, kept, 6)
+
+	// Fetch failures are reported so the caller can answer without history
+	pager.err = errors.New("discord unavailable")
+	_, _, err = history.FetchAndFit(ctx, "thread-1", "More?", pager.fetch)
+	assert.Error(t, err)
+}
+
+func TestThreadHistory_FetchAndFitPageLimit(t *testing.T) {
+	history, storageService, _ := newTestThreadHistory(t)
+
+	pager := &threadPager{messages: threadMessages(0, (maxThreadHistoryPages+2)*threadHistoryPageSize)}
+	_, kept, err := history.FetchAndFit(context.Background(), "thread-1", "More?", pager.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, maxThreadHistoryPages, pager.requests)
+	assert.Len(t, kept, 4)
+
+	stored, err := storageService.GetThreadSummary(context.Background(), "thread-1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, maxThreadHistoryPages*threadHistoryPageSize-4, stored.SummarizedMessages)
+}
+
+func TestThreadHistory_SummarizeInBatches(t *testing.T) {
+	history, _, mockAI := newTestThreadHistory(t)
+	batches := &countingSummaryAIService{MockAIService: mockAI}
+	history.aiService = batches
+
+	// 246 messages of about 56 tokens each are folded in batches that fit the 512 token budget
+	_, _, err := history.Fit(context.Background(), "thread-1", "More?", threadMessages(0, 250))
+	require.NoError(t, err)
+	require.Greater(t, len(batches.calls), 1)
+	for i, lines := range batches.calls {
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(lines[0], "Earlier summary: "), "each batch extends the previous summary")
+		}
+		assert.LessOrEqual(t, len(lines), 10)
+	}
+}
+
+// countingSummaryAIService records the lines of every conversation summary request
+type countingSummaryAIService struct {
+	*MockAIService
+	calls [][]string
+}
+
+func (m *countingSummaryAIService) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
+	m.calls = append(m.calls, messages)
+	return fmt.Sprintf("Summary %d", len(m.calls)), nil
+}
+
+func TestSnowflakeAfter(t *testing.T) {
+	assert.True(t, snowflakeAfter("1002", "1001"))
+	assert.True(t, snowflakeAfter("10000", "9999"))
+	assert.False(t, snowflakeAfter("1001", "1001"))
+	assert.False(t, snowflakeAfter("999", "1000"))
+}
+
+func TestPrependThreadSummary(t *testing.T) {
+	history := []service.ChatMessage{{Role: service.ChatRoleAssistant, Content: "A method."}}
+	assert.Equal(t, history, prependThreadSummary("", history))
+	assert.Equal(t, []service.ChatMessage{
+		{Role: service.ChatRoleUser, Content: "Summary of the earlier conversation: Asked about BMAD"},
+		{Role: service.ChatRoleAssistant, Content: "A method."},
+	}, prependThreadSummary("Asked about BMAD", history))
+}
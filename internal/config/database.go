@@ -15,6 +15,8 @@ import (
 type DatabaseConfigService struct {
 	storageService storage.StorageService
 	cache          map[string]*storage.Configuration
+	guildCache     map[string]map[string]*storage.Configuration // guildID -> key -> override, loaded on first use
+	guildCacheGen  uint64                                       // bumped on invalidation so stale loads are not cached
 	cacheMutex     sync.RWMutex
 	listeners      []ConfigChangeListener
 	listenerMutex  sync.RWMutex
@@ -27,6 +29,7 @@ func NewDatabaseConfigService(storageService storage.StorageService) *DatabaseCo
 	return &DatabaseConfigService{
 		storageService: storageService,
 		cache:          make(map[string]*storage.Configuration),
+		guildCache:     make(map[string]map[string]*storage.Configuration),
 		listeners:      make([]ConfigChangeListener, 0),
 	}
 }
@@ -45,6 +48,8 @@ func (s *DatabaseConfigService) Close() error {
 	// Clear cache
 	s.cacheMutex.Lock()
 	s.cache = make(map[string]*storage.Configuration)
+	s.guildCache = make(map[string]map[string]*storage.Configuration)
+	s.guildCacheGen++
 	s.cacheMutex.Unlock()
 
 	return nil
@@ -60,6 +65,9 @@ func (s *DatabaseConfigService) ReloadConfigs(ctx context.Context) error {
 	s.cacheMutex.Lock()
 	oldCache := s.cache
 	s.cache = make(map[string]*storage.Configuration)
+	// Guild overrides are reloaded on next use, picking up changes made by other instances
+	s.guildCache = make(map[string]map[string]*storage.Configuration)
+	s.guildCacheGen++
 
 	// Update cache with new configurations
 	for _, config := range configs {
@@ -160,15 +168,11 @@ func (s *DatabaseConfigService) GetConfigBool(ctx context.Context, key string) (
 		return false, err
 	}
 
-	// Parse boolean values flexibly
-	switch strings.ToLower(value) {
-	case "true", "1", "yes", "on", "enabled":
-		return true, nil
-	case "false", "0", "no", "off", "disabled":
-		return false, nil
-	default:
+	boolValue, err := ParseBool(value)
+	if err != nil {
 		return false, NewConfigError(key, "invalid boolean value", nil)
 	}
+	return boolValue, nil
 }
 
 // GetConfigBoolWithDefault retrieves a configuration value as boolean with default
@@ -216,6 +220,17 @@ func (s *DatabaseConfigService) SetConfigTyped(ctx context.Context, key, value,
 		return err
 	}
 
+	// Registered keys always use the registry's type, and its category and description unless given
+	if schema, ok := LookupConfigSchema(key); ok {
+		valueType = string(schema.Type)
+		if category == "" {
+			category = schema.Category
+		}
+		if description == "" {
+			description = schema.Description
+		}
+	}
+
 	// Validate the value type
 	if err := s.validateValueType(valueType, value); err != nil {
 		return NewConfigError(key, "invalid value for type", err)
@@ -230,32 +245,53 @@ func (s *DatabaseConfigService) SetConfigTyped(ctx context.Context, key, value,
 		Description: description,
 	}
 
-	// Store in database
-	if err := s.storageService.UpsertConfiguration(ctx, config); err != nil {
+	// Store in database together with its history entry
+	change := newConfigChange(ctx, "", key, &value, valueType, category)
+	if err := s.storageService.UpsertConfigurationWithHistory(ctx, config, change); err != nil {
 		return NewConfigError(key, "failed to store configuration", err)
 	}
 
 	// Update cache
 	s.cacheMutex.Lock()
-	oldValue := ""
-	if oldConfig, exists := s.cache[key]; exists {
-		oldValue = oldConfig.Value
-	}
 	s.cache[key] = config
 	s.cacheMutex.Unlock()
 
+	oldValue := ""
+	if change.OldValue != nil {
+		oldValue = *change.OldValue
+	}
+
 	// Notify listeners if value changed
 	if oldValue != value {
-		s.listenerMutex.RLock()
-		for _, listener := range s.listeners {
-			listener.OnConfigChanged(key, oldValue, value)
-		}
-		s.listenerMutex.RUnlock()
+		s.notifyListeners(key, oldValue, value)
 	}
 
 	return nil
 }
 
+// notifyListeners tells every change listener that key changed, whether globally or in a guild's override
+func (s *DatabaseConfigService) notifyListeners(key, oldValue, newValue string) {
+	s.listenerMutex.RLock()
+	defer s.listenerMutex.RUnlock()
+
+	for _, listener := range s.listeners {
+		listener.OnConfigChanged(key, oldValue, newValue)
+	}
+}
+
+// newConfigChange builds the history entry for a set or delete, attributed to the actor carried by ctx.
+// Storage fills in the old value from the stored entry when it applies the change.
+func newConfigChange(ctx context.Context, guildID, key string, newValue *string, valueType, category string) *storage.ConfigurationHistory {
+	return &storage.ConfigurationHistory{
+		GuildID:  guildID,
+		Key:      key,
+		NewValue: newValue,
+		Type:     valueType,
+		Category: category,
+		Actor:    ActorFromContext(ctx),
+	}
+}
+
 // validateValueType validates that a value matches the specified type
 func (s *DatabaseConfigService) validateValueType(valueType, value string) error {
 	switch ValueType(valueType) {
@@ -265,12 +301,10 @@ func (s *DatabaseConfigService) validateValueType(valueType, value string) error
 		_, err := strconv.Atoi(value)
 		return err
 	case ValueTypeBool:
-		switch strings.ToLower(value) {
-		case "true", "false", "1", "0", "yes", "no", "on", "off", "enabled", "disabled":
-			return nil
-		default:
+		if !isBoolValue(value) {
 			return fmt.Errorf("invalid boolean value: %s", value)
 		}
+		return nil
 	case ValueTypeDuration:
 		_, err := time.ParseDuration(value)
 		return err
@@ -309,6 +343,11 @@ func (s *DatabaseConfigService) GetAllConfigs(ctx context.Context) (map[string]s
 
 // ValidateConfig validates a configuration key-value pair before storing
 func (s *DatabaseConfigService) ValidateConfig(key, value string) error {
+	return validateConfig(key, value)
+}
+
+// validateConfig checks a key-value pair against the schema registry, falling back to key-pattern rules for unregistered keys
+func validateConfig(key, value string) error {
 	// Basic validation rules
 	if key == "" {
 		return NewConfigError(key, "configuration key cannot be empty", nil)
@@ -322,6 +361,13 @@ func (s *DatabaseConfigService) ValidateConfig(key, value string) error {
 		return NewConfigError(key, "configuration value too long (max 65535 characters)", nil)
 	}
 
+	if schema, ok := LookupConfigSchema(key); ok {
+		if err := schema.Validate(value); err != nil {
+			return NewConfigError(key, fmt.Sprintf("invalid value %q for %s", value, key), err)
+		}
+		return nil
+	}
+
 	// Additional validation based on key patterns
 	if strings.HasSuffix(key, "_RATE_LIMIT_PER_MINUTE") || strings.HasSuffix(key, "_RATE_LIMIT_PER_DAY") {
 		if _, err := strconv.Atoi(value); err != nil {
@@ -329,13 +375,8 @@ func (s *DatabaseConfigService) ValidateConfig(key, value string) error {
 		}
 	}
 
-	if strings.HasSuffix(key, "_ENABLED") {
-		switch strings.ToLower(value) {
-		case "true", "false", "1", "0", "yes", "no", "on", "off", "enabled", "disabled":
-			// Valid boolean values
-		default:
-			return NewConfigError(key, "boolean configuration values must be true/false, 1/0, yes/no, on/off, or enabled/disabled", nil)
-		}
+	if strings.HasSuffix(key, "_ENABLED") && !isBoolValue(value) {
+		return NewConfigError(key, "boolean configuration values must be true/false, 1/0, yes/no, on/off, or enabled/disabled", nil)
 	}
 
 	return nil
@@ -343,32 +384,248 @@ func (s *DatabaseConfigService) ValidateConfig(key, value string) error {
 
 // DeleteConfig removes a configuration entry
 func (s *DatabaseConfigService) DeleteConfig(ctx context.Context, key string) error {
-	// Remove from database
-	if err := s.storageService.DeleteConfiguration(ctx, key); err != nil {
+	// Remove from database together with its history entry
+	change := newConfigChange(ctx, "", key, nil, "", "")
+	if err := s.storageService.DeleteConfigurationWithHistory(ctx, "", key, change); err != nil {
 		return NewConfigError(key, "failed to delete configuration", err)
 	}
 
 	// Remove from cache
 	s.cacheMutex.Lock()
-	oldValue := ""
-	if oldConfig, exists := s.cache[key]; exists {
-		oldValue = oldConfig.Value
-		delete(s.cache, key)
-	}
+	delete(s.cache, key)
 	s.cacheMutex.Unlock()
 
+	oldValue := *change.OldValue
+
 	// Notify listeners
 	if oldValue != "" {
-		s.listenerMutex.RLock()
-		for _, listener := range s.listeners {
-			listener.OnConfigChanged(key, oldValue, "")
-		}
-		s.listenerMutex.RUnlock()
+		s.notifyListeners(key, oldValue, "")
+	}
+
+	return nil
+}
+
+// guildOverrides returns a guild's overrides keyed by configuration key. They are loaded from storage on
+// first use and cached until the guild's overrides change or configuration is reloaded.
+func (s *DatabaseConfigService) guildOverrides(ctx context.Context, guildID string) (map[string]*storage.Configuration, error) {
+	s.cacheMutex.RLock()
+	overrides, cached := s.guildCache[guildID]
+	generation := s.guildCacheGen
+	s.cacheMutex.RUnlock()
+	if cached {
+		return overrides, nil
+	}
+
+	configs, err := s.storageService.GetGuildConfigurations(ctx, guildID)
+	if err != nil {
+		return nil, NewConfigError("", "failed to load guild configurations", err)
+	}
+	overrides = make(map[string]*storage.Configuration, len(configs))
+	for _, config := range configs {
+		overrides[config.Key] = config
+	}
+
+	s.cacheMutex.Lock()
+	// Skip caching when the overrides changed while they were being loaded
+	if s.guildCacheGen == generation {
+		s.guildCache[guildID] = overrides
+	}
+	s.cacheMutex.Unlock()
+
+	return overrides, nil
+}
+
+// invalidateGuildOverrides drops a guild's cached overrides after they change
+func (s *DatabaseConfigService) invalidateGuildOverrides(guildID string) {
+	s.cacheMutex.Lock()
+	delete(s.guildCache, guildID)
+	s.guildCacheGen++
+	s.cacheMutex.Unlock()
+}
+
+// guildOverride returns a guild's cached override for key, or nil if it has none
+func (s *DatabaseConfigService) guildOverride(ctx context.Context, guildID, key string) (*storage.Configuration, error) {
+	if guildID == "" {
+		return nil, nil
+	}
+	overrides, err := s.guildOverrides(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return overrides[key], nil
+}
+
+// storedGuildOverride reads a guild's override for key directly from storage, bypassing the cache
+func (s *DatabaseConfigService) storedGuildOverride(ctx context.Context, guildID, key string) (*storage.Configuration, error) {
+	config, err := s.storageService.GetGuildConfiguration(ctx, guildID, key)
+	if err != nil {
+		return nil, NewConfigError(key, "failed to load guild configuration", err)
+	}
+	return config, nil
+}
+
+// GetGuildConfig retrieves a guild's value for key, falling back to the global value when the guild has no override
+func (s *DatabaseConfigService) GetGuildConfig(ctx context.Context, guildID, key string) (string, error) {
+	override, err := s.guildOverride(ctx, guildID, key)
+	if err != nil {
+		return "", err
+	}
+	if override != nil {
+		return override.Value, nil
+	}
+	return s.GetConfig(ctx, key)
+}
+
+// GetGuildConfigWithDefault retrieves a guild's value for key with fallback to the global value, then to default
+func (s *DatabaseConfigService) GetGuildConfigWithDefault(ctx context.Context, guildID, key, defaultValue string) string {
+	value, err := s.GetGuildConfig(ctx, guildID, key)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetGuildConfigs retrieves a guild's configuration overrides as a key-value map
+func (s *DatabaseConfigService) GetGuildConfigs(ctx context.Context, guildID string) (map[string]string, error) {
+	overrides, err := s.guildOverrides(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(overrides))
+	for key, config := range overrides {
+		result[key] = config.Value
+	}
+	return result, nil
+}
+
+// SetGuildConfig creates or updates a guild's override for a guild-scoped key
+func (s *DatabaseConfigService) SetGuildConfig(ctx context.Context, guildID, key, value string) error {
+	schema, err := guildScopedSchema(guildID, key)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidateConfig(key, value); err != nil {
+		return err
+	}
+
+	config := &storage.Configuration{
+		GuildID:     guildID,
+		Key:         key,
+		Value:       value,
+		Type:        string(schema.Type),
+		Category:    schema.Category,
+		Description: schema.Description,
+	}
+	change := newConfigChange(ctx, guildID, key, &value, config.Type, config.Category)
+	if err := s.storageService.UpsertConfigurationWithHistory(ctx, config, change); err != nil {
+		return NewConfigError(key, "failed to store guild configuration", err)
+	}
+	s.invalidateGuildOverrides(guildID)
+
+	oldValue := ""
+	if change.OldValue != nil {
+		oldValue = *change.OldValue
+	}
+	if oldValue != value {
+		s.notifyListeners(key, oldValue, value)
 	}
 
 	return nil
 }
 
+// DeleteGuildConfig removes a guild's override so the guild uses the global value again
+func (s *DatabaseConfigService) DeleteGuildConfig(ctx context.Context, guildID, key string) error {
+	if guildID == "" {
+		return NewConfigError(key, "guild ID cannot be empty", nil)
+	}
+
+	existing, err := s.storedGuildOverride(ctx, guildID, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return NewConfigError(key, "no override set for this guild", nil)
+	}
+
+	change := newConfigChange(ctx, guildID, key, nil, "", "")
+	if err := s.storageService.DeleteConfigurationWithHistory(ctx, guildID, key, change); err != nil {
+		return NewConfigError(key, "failed to delete guild configuration", err)
+	}
+	s.invalidateGuildOverrides(guildID)
+
+	if change.OldValue != nil && *change.OldValue != "" {
+		s.notifyListeners(key, *change.OldValue, "")
+	}
+
+	return nil
+}
+
+// guildScopedSchema returns the schema for key if guilds may override it
+func guildScopedSchema(guildID, key string) (ConfigSchema, error) {
+	if guildID == "" {
+		return ConfigSchema{}, NewConfigError(key, "guild ID cannot be empty", nil)
+	}
+	schema, ok := LookupConfigSchema(key)
+	if !ok || !schema.GuildScoped {
+		return ConfigSchema{}, NewConfigError(key, "configuration key cannot be overridden per guild", nil)
+	}
+	return schema, nil
+}
+
+// GetConfigHistory retrieves the most recent changes to a configuration key's global value and guildID's override,
+// newest first; an empty guildID returns global changes only
+func (s *DatabaseConfigService) GetConfigHistory(ctx context.Context, guildID, key string, limit int) ([]*storage.ConfigurationHistory, error) {
+	history, err := s.storageService.GetConfigurationHistory(ctx, guildID, key, limit)
+	if err != nil {
+		return nil, NewConfigError(key, "failed to load configuration history", err)
+	}
+	return history, nil
+}
+
+// RollbackConfig restores a configuration key to the value set by one of its history entries. Entries for
+// another guild's override are rejected, so a guild can only roll back global values and its own overrides.
+// Rolling back to a deletion removes the key. The rollback is itself recorded and notifies listeners.
+func (s *DatabaseConfigService) RollbackConfig(ctx context.Context, guildID, key string, historyID int64) error {
+	change, err := s.storageService.GetConfigurationChange(ctx, historyID)
+	if err != nil {
+		return NewConfigError(key, "failed to load configuration history", err)
+	}
+	// Another guild's entries are reported as missing so their existence is not revealed
+	if change == nil || change.Key != key || (change.GuildID != "" && change.GuildID != guildID) {
+		return NewConfigError(key, fmt.Sprintf("history entry %d not found for this key", historyID), nil)
+	}
+
+	// Entries for a guild override restore that guild's override
+	if change.GuildID != "" {
+		if change.NewValue != nil {
+			return s.SetGuildConfig(ctx, change.GuildID, key, *change.NewValue)
+		}
+		override, err := s.storedGuildOverride(ctx, change.GuildID, key)
+		if err != nil || override == nil {
+			return err // Already deleted
+		}
+		return s.DeleteGuildConfig(ctx, change.GuildID, key)
+	}
+
+	s.cacheMutex.RLock()
+	current, exists := s.cache[key]
+	s.cacheMutex.RUnlock()
+
+	if change.NewValue == nil {
+		if !exists {
+			return nil // Already deleted
+		}
+		return s.DeleteConfig(ctx, key)
+	}
+
+	description := ""
+	if exists {
+		description = current.Description
+	}
+	return s.SetConfigTyped(ctx, key, *change.NewValue, change.Type, change.Category, description)
+}
+
 // HealthCheck verifies that the configuration service is working properly
 func (s *DatabaseConfigService) HealthCheck(ctx context.Context) error {
 	// Check storage service health
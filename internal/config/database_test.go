@@ -3,179 +3,81 @@ package config
 import (
 	"context"
 	"fmt"
-	"sync"
 	"testing"
 	"time"
 
 	"bmad-knowledge-bot/internal/storage"
 )
 
-// MockStorageService implements storage.StorageService for testing
+// MockStorageService wraps the in-memory storage with injectable errors for the configuration methods
 type MockStorageService struct {
-	mu             sync.RWMutex
-	configurations map[string]*storage.Configuration
-	healthError    error
-	getError       error
-	upsertError    error
-	deleteError    error
+	*storage.MemoryStorageService
+	healthError error
+	getError    error
+	upsertError error
+	deleteError error
 }
 
 func NewMockStorageService() *MockStorageService {
 	return &MockStorageService{
-		configurations: make(map[string]*storage.Configuration),
+		MemoryStorageService: storage.NewMemoryStorageService(),
 	}
 }
 
-// Storage service interface methods (partial implementation for testing)
-func (m *MockStorageService) Initialize(ctx context.Context) error {
-	return nil
-}
-
-func (m *MockStorageService) Close() error {
-	return nil
-}
-
-func (m *MockStorageService) GetMessageState(ctx context.Context, channelID string, threadID *string) (*storage.MessageState, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) UpsertMessageState(ctx context.Context, state *storage.MessageState) error {
-	return nil
-}
-
-func (m *MockStorageService) GetAllMessageStates(ctx context.Context) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-
 func (m *MockStorageService) HealthCheck(ctx context.Context) error {
-	return m.healthError
-}
-
-func (m *MockStorageService) GetThreadOwnership(ctx context.Context, threadID string) (*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) UpsertThreadOwnership(ctx context.Context, ownership *storage.ThreadOwnership) error {
-	return nil
-}
-
-func (m *MockStorageService) GetAllThreadOwnerships(ctx context.Context) ([]*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
-	return nil
-}
-
-// Status message methods (required by StorageService interface)
-func (m *MockStorageService) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
-	return nil
-}
-
-func (m *MockStorageService) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
-	return nil
-}
-
-func (m *MockStorageService) GetAllStatusMessages(ctx context.Context) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-
-func (m *MockStorageService) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
-	return 0, nil
-}
-
-// New rate limiting methods required by interface
-func (m *MockStorageService) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *MockStorageService) UpsertUserRateLimit(ctx context.Context, rateLimit *storage.UserRateLimit) error {
-	return nil
-}
-func (m *MockStorageService) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
-	return nil
-}
-func (m *MockStorageService) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *MockStorageService) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
-	return nil
+	if m.healthError != nil {
+		return m.healthError
+	}
+	return m.MemoryStorageService.HealthCheck(ctx)
 }
 
-// Configuration methods for testing
 func (m *MockStorageService) GetConfiguration(ctx context.Context, key string) (*storage.Configuration, error) {
 	if m.getError != nil {
 		return nil, m.getError
 	}
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	config, exists := m.configurations[key]
-	if !exists {
-		return nil, nil
-	}
-	return config, nil
+	return m.MemoryStorageService.GetConfiguration(ctx, key)
 }
 
 func (m *MockStorageService) UpsertConfiguration(ctx context.Context, config *storage.Configuration) error {
 	if m.upsertError != nil {
 		return m.upsertError
 	}
-	if config.CreatedAt == 0 {
-		config.CreatedAt = time.Now().Unix()
+	return m.MemoryStorageService.UpsertConfiguration(ctx, config)
+}
+
+func (m *MockStorageService) UpsertConfigurationWithHistory(ctx context.Context, config *storage.Configuration, change *storage.ConfigurationHistory) error {
+	if m.upsertError != nil {
+		return m.upsertError
 	}
-	config.UpdatedAt = time.Now().Unix()
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.configurations[config.Key] = config
-	return nil
+	return m.MemoryStorageService.UpsertConfigurationWithHistory(ctx, config, change)
 }
 
 func (m *MockStorageService) GetConfigurationsByCategory(ctx context.Context, category string) ([]*storage.Configuration, error) {
 	if m.getError != nil {
 		return nil, m.getError
 	}
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	var result []*storage.Configuration
-	for _, config := range m.configurations {
-		if config.Category == category {
-			result = append(result, config)
-		}
-	}
-	return result, nil
+	return m.MemoryStorageService.GetConfigurationsByCategory(ctx, category)
 }
 
 func (m *MockStorageService) GetAllConfigurations(ctx context.Context) ([]*storage.Configuration, error) {
 	if m.getError != nil {
 		return nil, m.getError
 	}
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	var result []*storage.Configuration
-	for _, config := range m.configurations {
-		result = append(result, config)
-	}
-	return result, nil
+	return m.MemoryStorageService.GetAllConfigurations(ctx)
 }
 
 func (m *MockStorageService) DeleteConfiguration(ctx context.Context, key string) error {
 	if m.deleteError != nil {
 		return m.deleteError
 	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if _, exists := m.configurations[key]; !exists {
-		return NewConfigError(key, "configuration not found", nil)
+	return m.MemoryStorageService.DeleteConfiguration(ctx, key)
+}
+
+func (m *MockStorageService) DeleteConfigurationWithHistory(ctx context.Context, guildID, key string, change *storage.ConfigurationHistory) error {
+	if m.deleteError != nil {
+		return m.deleteError
 	}
-	delete(m.configurations, key)
-	return nil
+	return m.MemoryStorageService.DeleteConfigurationWithHistory(ctx, guildID, key, change)
 }
 
 // Test helper methods
@@ -196,17 +98,13 @@ func (m *MockStorageService) SetDeleteError(err error) {
 }
 
 func (m *MockStorageService) AddTestConfiguration(key, value, valueType, category, description string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.configurations[key] = &storage.Configuration{
+	m.MemoryStorageService.UpsertConfiguration(context.Background(), &storage.Configuration{
 		Key:         key,
 		Value:       value,
 		Type:        valueType,
 		Category:    category,
 		Description: description,
-		CreatedAt:   time.Now().Unix(),
-		UpdatedAt:   time.Now().Unix(),
-	}
+	})
 }
 
 // Test DatabaseConfigService
@@ -600,6 +498,315 @@ func TestDatabaseConfigService_DeleteConfig(t *testing.T) {
 	}
 }
 
+func TestDatabaseConfigService_ConfigHistory(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	service := NewDatabaseConfigService(mockStorage)
+	ctx := context.Background()
+
+	if err := service.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if err := service.SetConfigTyped(WithActor(ctx, ActorSeed), "HISTORY_LIMIT", "5", "int", "test", "Limit"); err != nil {
+		t.Fatalf("SetConfigTyped failed: %v", err)
+	}
+	if err := service.SetConfigTyped(WithActor(ctx, "123456789"), "HISTORY_LIMIT", "10", "int", "test", "Limit"); err != nil {
+		t.Fatalf("SetConfigTyped failed: %v", err)
+	}
+	if err := service.DeleteConfig(ctx, "HISTORY_LIMIT"); err != nil {
+		t.Fatalf("DeleteConfig failed: %v", err)
+	}
+
+	history, err := service.GetConfigHistory(ctx, "", "HISTORY_LIMIT", 10)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(history))
+	}
+
+	// Newest first: delete by the default actor, update by a user, then the seeded value
+	deleted, updated, created := history[0], history[1], history[2]
+	if deleted.Actor != ActorSystem || deleted.NewValue != nil || deleted.OldValue == nil || *deleted.OldValue != "10" {
+		t.Errorf("Unexpected delete entry: %+v", deleted)
+	}
+	if deleted.Type != "int" || deleted.Category != "test" {
+		t.Errorf("Delete entry should keep the deleted value's type and category, got %s/%s", deleted.Type, deleted.Category)
+	}
+	if updated.Actor != "123456789" || updated.OldValue == nil || *updated.OldValue != "5" || updated.NewValue == nil || *updated.NewValue != "10" {
+		t.Errorf("Unexpected update entry: %+v", updated)
+	}
+	if created.Actor != ActorSeed || created.OldValue != nil || created.NewValue == nil || *created.NewValue != "5" {
+		t.Errorf("Unexpected create entry: %+v", created)
+	}
+}
+
+func TestDatabaseConfigService_ConfigHistoryUsesStoredValue(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	ctx := context.Background()
+
+	// Two instances share storage, so each one's cache can be stale
+	first := NewDatabaseConfigService(mockStorage)
+	second := NewDatabaseConfigService(mockStorage)
+	for _, service := range []*DatabaseConfigService{first, second} {
+		if err := service.Initialize(ctx); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+	}
+
+	if err := first.SetConfig(ctx, "SHARED_KEY", "one", "test", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if err := second.SetConfig(ctx, "SHARED_KEY", "two", "test", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if err := first.SetConfig(ctx, "SHARED_KEY", "three", "test", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	history, err := first.GetConfigHistory(ctx, "", "SHARED_KEY", 10)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(history))
+	}
+	if history[1].OldValue == nil || *history[1].OldValue != "one" {
+		t.Errorf("Expected second change to replace 'one', got %v", history[1].OldValue)
+	}
+	if history[0].OldValue == nil || *history[0].OldValue != "two" {
+		t.Errorf("Expected latest change to replace the stored 'two', got %v", history[0].OldValue)
+	}
+
+	// A rejected write records no history
+	mockStorage.SetUpsertError(fmt.Errorf("database unavailable"))
+	if err := first.SetConfig(ctx, "SHARED_KEY", "four", "test", ""); err == nil {
+		t.Fatal("Expected SetConfig to fail")
+	}
+	history, _ = first.GetConfigHistory(ctx, "", "SHARED_KEY", 10)
+	if len(history) != 3 {
+		t.Errorf("Expected a failed write to leave history unchanged, got %d entries", len(history))
+	}
+}
+
+func TestDatabaseConfigService_RollbackConfig(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	service := NewDatabaseConfigService(mockStorage)
+	ctx := context.Background()
+
+	if err := service.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if err := service.SetConfigTyped(ctx, "ROLLBACK_KEY", "original", "string", "test", "Rollback test"); err != nil {
+		t.Fatalf("SetConfigTyped failed: %v", err)
+	}
+	if err := service.SetConfigTyped(ctx, "ROLLBACK_KEY", "changed", "string", "test", "Rollback test"); err != nil {
+		t.Fatalf("SetConfigTyped failed: %v", err)
+	}
+
+	history, err := service.GetConfigHistory(ctx, "", "ROLLBACK_KEY", 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d (err: %v)", len(history), err)
+	}
+	original := history[1]
+
+	listener := &MockConfigChangeListener{}
+	service.AddConfigChangeListener(listener)
+
+	if err := service.RollbackConfig(WithActor(ctx, "987654321"), "", "ROLLBACK_KEY", original.ID); err != nil {
+		t.Fatalf("RollbackConfig failed: %v", err)
+	}
+
+	value, _ := service.GetConfig(ctx, "ROLLBACK_KEY")
+	if value != "original" {
+		t.Errorf("Expected rolled back value 'original', got '%s'", value)
+	}
+
+	changes := listener.GetChanges()
+	if len(changes) != 1 || changes[0].OldValue != "changed" || changes[0].NewValue != "original" {
+		t.Errorf("Expected listener to see changed -> original, got %+v", changes)
+	}
+
+	// The rollback is itself recorded and attributed
+	history, _ = service.GetConfigHistory(ctx, "", "ROLLBACK_KEY", 1)
+	if len(history) != 1 || history[0].Actor != "987654321" || *history[0].NewValue != "original" {
+		t.Errorf("Expected rollback to be recorded, got %+v", history)
+	}
+
+	// A history entry belonging to a different key is rejected
+	if err := service.SetConfig(ctx, "OTHER_KEY", "x", "test", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	other, _ := service.GetConfigHistory(ctx, "", "OTHER_KEY", 1)
+	if err := service.RollbackConfig(ctx, "", "ROLLBACK_KEY", other[0].ID); err == nil {
+		t.Error("Expected error when rolling back to another key's history entry")
+	}
+	if err := service.RollbackConfig(ctx, "", "ROLLBACK_KEY", 999999); err == nil {
+		t.Error("Expected error for unknown history entry")
+	}
+
+	// Rolling back to a deletion removes the key
+	if err := service.DeleteConfig(ctx, "ROLLBACK_KEY"); err != nil {
+		t.Fatalf("DeleteConfig failed: %v", err)
+	}
+	history, _ = service.GetConfigHistory(ctx, "", "ROLLBACK_KEY", 1)
+	deletion := history[0]
+	if err := service.SetConfig(ctx, "ROLLBACK_KEY", "recreated", "test", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if err := service.RollbackConfig(ctx, "", "ROLLBACK_KEY", deletion.ID); err != nil {
+		t.Fatalf("RollbackConfig to deletion failed: %v", err)
+	}
+	if _, err := service.GetConfig(ctx, "ROLLBACK_KEY"); err == nil {
+		t.Error("Expected key to be deleted after rolling back to a deletion")
+	}
+}
+
+func TestDatabaseConfigService_GuildConfig(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	service := NewDatabaseConfigService(mockStorage)
+	ctx := context.Background()
+
+	if err := service.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := service.SetConfig(ctx, "USER_RATE_LIMIT_PER_MINUTE", "5", "", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	// Guilds without an override see the global value
+	if value, err := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); err != nil || value != "5" {
+		t.Errorf("Expected global value '5', got '%s' (err: %v)", value, err)
+	}
+
+	if err := service.SetGuildConfig(WithActor(ctx, "123456789"), "guild-1", "USER_RATE_LIMIT_PER_MINUTE", "20"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "20" {
+		t.Errorf("Expected guild override '20', got '%s'", value)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-2", "USER_RATE_LIMIT_PER_MINUTE"); value != "5" {
+		t.Errorf("Expected other guilds to keep the global value '5', got '%s'", value)
+	}
+	if value, _ := service.GetConfig(ctx, "USER_RATE_LIMIT_PER_MINUTE"); value != "5" {
+		t.Errorf("Expected the global value to be unchanged, got '%s'", value)
+	}
+	if value := service.GetGuildConfigWithDefault(ctx, "guild-1", "MISSING_KEY", "fallback"); value != "fallback" {
+		t.Errorf("Expected default for a missing key, got '%s'", value)
+	}
+
+	overrides, err := service.GetGuildConfigs(ctx, "guild-1")
+	if err != nil || len(overrides) != 1 || overrides["USER_RATE_LIMIT_PER_MINUTE"] != "20" {
+		t.Errorf("Expected a single guild override, got %v (err: %v)", overrides, err)
+	}
+	if globals, _ := service.GetAllConfigs(ctx); globals["USER_RATE_LIMIT_PER_MINUTE"] != "5" {
+		t.Errorf("Expected guild overrides to be excluded from global configs, got '%s'", globals["USER_RATE_LIMIT_PER_MINUTE"])
+	}
+
+	// Only guild-scoped keys with valid values can be overridden
+	if err := service.SetGuildConfig(ctx, "guild-1", "OLLAMA_HOST", "http://example.com"); err == nil {
+		t.Error("Expected non guild-scoped key to be rejected")
+	}
+	if err := service.SetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", "-1"); err == nil {
+		t.Error("Expected invalid value to be rejected")
+	}
+	if err := service.SetGuildConfig(ctx, "", "USER_RATE_LIMIT_PER_MINUTE", "10"); err == nil {
+		t.Error("Expected empty guild ID to be rejected")
+	}
+
+	// Guild changes are recorded with their guild and can be rolled back
+	history, err := service.GetConfigHistory(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", 1)
+	if err != nil || len(history) != 1 || history[0].GuildID != "guild-1" || history[0].Actor != "123456789" {
+		t.Fatalf("Expected attributed guild history entry, got %+v (err: %v)", history, err)
+	}
+	guildSet := history[0]
+
+	if err := service.DeleteGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); err != nil {
+		t.Fatalf("DeleteGuildConfig failed: %v", err)
+	}
+	if err := service.DeleteGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); err == nil {
+		t.Error("Expected deleting a missing override to fail")
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "5" {
+		t.Errorf("Expected global value after removing the override, got '%s'", value)
+	}
+
+	// Other guilds can neither see nor roll back the guild's changes
+	others, err := service.GetConfigHistory(ctx, "guild-2", "USER_RATE_LIMIT_PER_MINUTE", 10)
+	if err != nil || len(others) == 0 {
+		t.Fatalf("Expected the global history entry, got %+v (err: %v)", others, err)
+	}
+	for _, change := range others {
+		if change.GuildID != "" {
+			t.Errorf("Expected other guilds not to see the guild's history, got %+v", change)
+		}
+	}
+	for _, guildID := range []string{"guild-2", ""} {
+		if err := service.RollbackConfig(ctx, guildID, "USER_RATE_LIMIT_PER_MINUTE", guildSet.ID); err == nil {
+			t.Errorf("Expected rollback of guild-1's change from %q to be rejected", guildID)
+		}
+	}
+	if overrides, _ := service.GetGuildConfigs(ctx, "guild-2"); len(overrides) != 0 {
+		t.Errorf("Expected a rejected rollback not to create overrides, got %v", overrides)
+	}
+
+	// Guild changes notify listeners, including those made by a rollback
+	listener := &MockConfigChangeListener{}
+	service.AddConfigChangeListener(listener)
+	if err := service.RollbackConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", guildSet.ID); err != nil {
+		t.Fatalf("RollbackConfig failed: %v", err)
+	}
+	if changes := listener.GetChanges(); len(changes) != 1 || changes[0] != (ConfigChange{Key: "USER_RATE_LIMIT_PER_MINUTE", NewValue: "20"}) {
+		t.Errorf("Expected the rollback to notify listeners, got %+v", changes)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "20" {
+		t.Errorf("Expected rollback to restore the guild override, got '%s'", value)
+	}
+	if value, _ := service.GetConfig(ctx, "USER_RATE_LIMIT_PER_MINUTE"); value != "5" {
+		t.Errorf("Expected rollback of a guild change to leave the global value alone, got '%s'", value)
+	}
+}
+
+func TestDatabaseConfigService_GuildConfigCache(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	service := NewDatabaseConfigService(mockStorage)
+	ctx := context.Background()
+
+	if err := service.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := service.SetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", "20"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "20" {
+		t.Fatalf("Expected guild override '20', got '%s'", value)
+	}
+
+	// Overrides written elsewhere, e.g. by another instance, are served from the cache until the next reload
+	err := mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "guild-1", Key: "USER_RATE_LIMIT_PER_MINUTE", Value: "30", Type: "int"})
+	if err != nil {
+		t.Fatalf("UpsertConfiguration failed: %v", err)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "20" {
+		t.Errorf("Expected the cached override '20', got '%s'", value)
+	}
+	if err := service.ReloadConfigs(ctx); err != nil {
+		t.Fatalf("ReloadConfigs failed: %v", err)
+	}
+	if value, _ := service.GetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE"); value != "30" {
+		t.Errorf("Expected the reloaded override '30', got '%s'", value)
+	}
+
+	// Changes made through the service take effect immediately
+	if err := service.SetGuildConfig(ctx, "guild-1", "USER_RATE_LIMIT_PER_MINUTE", "40"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+	if overrides, _ := service.GetGuildConfigs(ctx, "guild-1"); overrides["USER_RATE_LIMIT_PER_MINUTE"] != "40" {
+		t.Errorf("Expected the updated override '40', got %v", overrides)
+	}
+}
+
 func TestDatabaseConfigService_ReloadConfigs(t *testing.T) {
 	mockStorage := NewMockStorageService()
 	service := NewDatabaseConfigService(mockStorage)
@@ -19,7 +19,10 @@ var SecureConfigKeys = map[string]bool{
 	"MYSQL_PORT":     true,
 	"MYSQL_DATABASE": true,
 	"MYSQL_TIMEOUT":  true,
-	// DATABASE_TYPE removed in Story 2.12 - MySQL-only architecture
+	"OPENAI_API_KEY": true,
+	// The storage backend must be known before the database can be read
+	"DATABASE_TYPE":        true,
+	"SQLITE_DATABASE_PATH": true,
 }
 
 // HybridConfigService implements ConfigService with database-first loading and environment variable fallback
@@ -133,15 +136,11 @@ func (s *HybridConfigService) GetConfigBool(ctx context.Context, key string) (bo
 		return false, err
 	}
 
-	// Parse boolean values flexibly
-	switch strings.ToLower(value) {
-	case "true", "1", "yes", "on", "enabled":
-		return true, nil
-	case "false", "0", "no", "off", "disabled":
-		return false, nil
-	default:
+	boolValue, err := ParseBool(value)
+	if err != nil {
 		return false, NewConfigError(key, "invalid boolean value", nil)
 	}
+	return boolValue, nil
 }
 
 // GetConfigBoolWithDefault retrieves a configuration value as boolean with default
@@ -317,12 +316,8 @@ func (s *HybridConfigService) ValidateConfig(key, value string) error {
 		return s.databaseService.ValidateConfig(key, value)
 	}
 
-	// Basic validation if database service not available
-	if key == "" {
-		return NewConfigError(key, "configuration key cannot be empty", nil)
-	}
-
-	return nil
+	// The same rules apply when the database service is not available
+	return validateConfig(key, value)
 }
 
 // DeleteConfig removes a configuration entry (only for non-secure keys)
@@ -340,6 +335,79 @@ func (s *HybridConfigService) DeleteConfig(ctx context.Context, key string) erro
 	return s.databaseService.DeleteConfig(ctx, key)
 }
 
+// GetGuildConfig retrieves a guild's value for key, falling back to the global value (and then the environment)
+func (s *HybridConfigService) GetGuildConfig(ctx context.Context, guildID, key string) (string, error) {
+	if !SecureConfigKeys[key] && s.initialized && s.databaseWorking && s.databaseService != nil {
+		override, err := s.databaseService.guildOverride(ctx, guildID, key)
+		if err == nil && override != nil {
+			return override.Value, nil
+		}
+	}
+
+	return s.GetConfig(ctx, key)
+}
+
+// GetGuildConfigWithDefault retrieves a guild's value for key with fallback to the global value, then to default
+func (s *HybridConfigService) GetGuildConfigWithDefault(ctx context.Context, guildID, key, defaultValue string) string {
+	value, err := s.GetGuildConfig(ctx, guildID, key)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetGuildConfigs retrieves a guild's configuration overrides as a key-value map
+func (s *HybridConfigService) GetGuildConfigs(ctx context.Context, guildID string) (map[string]string, error) {
+	if !s.initialized || !s.databaseWorking || s.databaseService == nil {
+		return nil, NewConfigError("", "database configuration service not available", nil)
+	}
+
+	return s.databaseService.GetGuildConfigs(ctx, guildID)
+}
+
+// SetGuildConfig creates or updates a guild's override for a guild-scoped key
+func (s *HybridConfigService) SetGuildConfig(ctx context.Context, guildID, key, value string) error {
+	if !s.initialized || !s.databaseWorking || s.databaseService == nil {
+		return NewConfigError(key, "database configuration service not available", nil)
+	}
+
+	return s.databaseService.SetGuildConfig(ctx, guildID, key, value)
+}
+
+// DeleteGuildConfig removes a guild's override so the guild uses the global value again
+func (s *HybridConfigService) DeleteGuildConfig(ctx context.Context, guildID, key string) error {
+	if !s.initialized || !s.databaseWorking || s.databaseService == nil {
+		return NewConfigError(key, "database configuration service not available", nil)
+	}
+
+	return s.databaseService.DeleteGuildConfig(ctx, guildID, key)
+}
+
+// GetConfigHistory retrieves the most recent changes to a configuration key's global value and guildID's override, newest first
+func (s *HybridConfigService) GetConfigHistory(ctx context.Context, guildID, key string, limit int) ([]*storage.ConfigurationHistory, error) {
+	if !s.initialized || !s.databaseWorking || s.databaseService == nil {
+		return nil, NewConfigError(key, "database configuration service not available", nil)
+	}
+
+	return s.databaseService.GetConfigHistory(ctx, guildID, key, limit)
+}
+
+// RollbackConfig restores a configuration key to the value set by one of its global or guildID's history entries
+// (only for non-secure keys)
+func (s *HybridConfigService) RollbackConfig(ctx context.Context, guildID, key string, historyID int64) error {
+	// Prevent modifying secure configuration keys
+	if SecureConfigKeys[key] {
+		return NewConfigError(key, "secure configuration keys cannot be modified through configuration service", nil)
+	}
+
+	// Only allow rollback if database service is available
+	if !s.initialized || !s.databaseWorking || s.databaseService == nil {
+		return NewConfigError(key, "database configuration service not available", nil)
+	}
+
+	return s.databaseService.RollbackConfig(ctx, guildID, key, historyID)
+}
+
 // HealthCheck verifies that the configuration service is working properly
 func (s *HybridConfigService) HealthCheck(ctx context.Context) error {
 	// Check if we can access environment variables
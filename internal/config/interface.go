@@ -3,6 +3,8 @@ package config
 import (
 	"context"
 	"time"
+
+	"bmad-knowledge-bot/internal/storage"
 )
 
 // ConfigService defines the interface for configuration management
@@ -58,6 +60,28 @@ type ConfigService interface {
 	// DeleteConfig removes a configuration entry
 	DeleteConfig(ctx context.Context, key string) error
 
+	// GetGuildConfig retrieves a guild's value for key, falling back to the global value when the guild has no override
+	GetGuildConfig(ctx context.Context, guildID, key string) (string, error)
+
+	// GetGuildConfigWithDefault retrieves a guild's value for key with fallback to the global value, then to default
+	GetGuildConfigWithDefault(ctx context.Context, guildID, key, defaultValue string) string
+
+	// GetGuildConfigs retrieves a guild's configuration overrides as a key-value map
+	GetGuildConfigs(ctx context.Context, guildID string) (map[string]string, error)
+
+	// SetGuildConfig creates or updates a guild's override for a guild-scoped key
+	SetGuildConfig(ctx context.Context, guildID, key, value string) error
+
+	// DeleteGuildConfig removes a guild's override so the guild uses the global value again
+	DeleteGuildConfig(ctx context.Context, guildID, key string) error
+
+	// GetConfigHistory retrieves the most recent changes to a configuration key's global value and guildID's
+	// override, newest first; an empty guildID returns global changes only
+	GetConfigHistory(ctx context.Context, guildID, key string, limit int) ([]*storage.ConfigurationHistory, error)
+
+	// RollbackConfig restores a configuration key to the value set by one of its global or guildID's history entries
+	RollbackConfig(ctx context.Context, guildID, key string, historyID int64) error
+
 	// HealthCheck verifies that the configuration service is working properly
 	HealthCheck(ctx context.Context) error
 
@@ -78,6 +102,29 @@ const (
 	ValueTypeDuration ValueType = "duration"
 )
 
+// Actors recorded in configuration history for changes the bot makes on its own behalf
+const (
+	ActorSystem       = "system"
+	ActorEnvMigration = "env-migration"
+	ActorSeed         = "seed"
+)
+
+// actorContextKey is the context key holding who is making a configuration change
+type actorContextKey struct{}
+
+// WithActor returns a context that attributes configuration changes to actor, a Discord user ID or one of the Actor constants
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns who configuration changes made with ctx are attributed to, defaulting to ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
+
 // ConfigChangeListener defines the interface for configuration change notifications
 type ConfigChangeListener interface {
 	OnConfigChanged(key, oldValue, newValue string)
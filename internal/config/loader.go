@@ -2,7 +2,9 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
@@ -74,13 +76,50 @@ func (l *ConfigurationLoader) ReloadAndNotifyServices(ctx context.Context) error
 	for _, listener := range l.listeners {
 		if err := listener.OnReload(allConfigs); err != nil {
 			// Log error but continue with other services
-			_ = fmt.Sprintf("Service %s configuration reload failed: %v", listener.Name, err)
+			slog.Warn("Service configuration reload failed", "service", listener.Name, "error", err)
 		}
 	}
 
 	return nil
 }
 
+// WatchKeys returns a service listener that calls apply with just the watched keys whose values changed since the
+// previous snapshot, starting from initial; reloads that leave every watched key untouched are skipped
+func WatchKeys(name string, initial map[string]string, keys []string, apply func(changed map[string]string) error) ServiceConfigListener {
+	var mutex sync.Mutex
+	last := make(map[string]string, len(keys))
+	for _, key := range keys {
+		last[key] = initial[key]
+	}
+
+	return ServiceConfigListener{
+		Name: name,
+		OnReload: func(configs map[string]string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			changed := make(map[string]string)
+			for _, key := range keys {
+				if configs[key] != last[key] {
+					changed[key] = configs[key]
+				}
+			}
+			if len(changed) == 0 {
+				return nil
+			}
+
+			// Failed changes are retried on the next reload
+			if err := apply(changed); err != nil {
+				return err
+			}
+			for key, value := range changed {
+				last[key] = value
+			}
+			return nil
+		},
+	}
+}
+
 // GetConfigService returns the underlying configuration service
 func (l *ConfigurationLoader) GetConfigService() ConfigService {
 	return l.configService
@@ -127,7 +166,7 @@ func (s *serviceNotificationListener) OnConfigChanged(key, oldValue, newValue st
 	for _, listener := range s.loader.listeners {
 		if err := listener.OnReload(allConfigs); err != nil {
 			// Log error but continue with other services
-			_ = fmt.Sprintf("Service %s configuration change notification failed for key %s: %v", listener.Name, key, err)
+			slog.Warn("Service configuration change could not be applied", "service", listener.Name, "key", key, "error", err)
 		}
 	}
 }
@@ -146,67 +185,39 @@ func NewConfigurationMigrator(configService ConfigService) *ConfigurationMigrato
 
 // MigrateEnvironmentVariables migrates non-secure environment variables to database configuration
 func (m *ConfigurationMigrator) MigrateEnvironmentVariables(ctx context.Context) error {
-	// Define migration mappings from environment variables to database configuration
-	migrationMappings := []struct {
-		EnvKey      string
-		Category    string
-		Description string
-		ValueType   string
-	}{
-		// Rate limiting configuration
-		{"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE", "rate_limiting", "Ollama API rate limit per minute", "int"},
-		{"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY", "rate_limiting", "Ollama API rate limit per day", "int"},
-		{"USER_RATE_LIMIT_PER_MINUTE", "rate_limiting", "User rate limit per minute", "int"},
-		{"USER_RATE_LIMIT_PER_HOUR", "rate_limiting", "User rate limit per hour", "int"},
-		{"USER_RATE_LIMIT_PER_DAY", "rate_limiting", "User rate limit per day", "int"},
-		{"ADMIN_ROLE_NAMES", "rate_limiting", "Comma-separated list of admin role names that bypass rate limits", "string"},
-		{"RATE_LIMITING_ENABLED", "rate_limiting", "Enable user rate limiting", "bool"},
-
-		// Feature flags
-		{"BMAD_KB_REFRESH_ENABLED", "features", "Enable knowledge base refresh functionality", "bool"},
-		{"REACTION_TRIGGER_ENABLED", "features", "Enable reaction trigger functionality", "bool"},
-		{"BOT_STATUS_UPDATE_ENABLED", "features", "Enable bot status updates", "bool"},
-
-		// AI service configuration
-		{"OLLAMA_HOST", "ai_services", "Ollama service host address", "string"},
-		{"OLLAMA_MODEL", "ai_services", "Default Ollama model to use", "string"},
-
-		// Channel restrictions configuration
-		{"ALLOWED_CHANNEL_IDS", "channel_restrictions", "Comma-separated list of allowed channel IDs", "string"},
-		{"CHANNEL_RESTRICTIONS_ENABLED", "channel_restrictions", "Enable channel restrictions", "bool"},
-		{"RESTRICT_DMS", "channel_restrictions", "Restrict bot operations in DM channels", "bool"},
-		{"ADMIN_CHANNEL_BYPASS_ENABLED", "channel_restrictions", "Allow admin users to bypass channel restrictions", "bool"},
-
-		// System configuration
-		{"BOT_STATUS_UPDATE_INTERVAL", "system", "Interval for bot status updates", "duration"},
-		{"CONFIG_RELOAD_INTERVAL", "system", "Configuration reload interval", "duration"},
-	}
-
+	ctx = WithActor(ctx, ActorEnvMigration)
 	migratedCount := 0
-	for _, mapping := range migrationMappings {
+	var invalid []error
+	for _, schema := range ConfigSchemas() {
 		// Skip secure configuration keys
-		if SecureConfigKeys[mapping.EnvKey] {
+		if SecureConfigKeys[schema.Key] {
 			continue
 		}
 
 		// Check if configuration already exists in database
-		_, err := m.configService.GetConfig(ctx, mapping.EnvKey)
+		_, err := m.configService.GetConfig(ctx, schema.Key)
 		if err == nil {
 			// Configuration already exists, skip migration
 			continue
 		}
 
 		// Get value from environment variable
-		envValue := GetEnvWithDefault(mapping.EnvKey, "")
+		envValue := GetEnvWithDefault(schema.Key, "")
 		if envValue == "" {
 			// Environment variable not set, skip
 			continue
 		}
 
+		// Leave invalid values in the environment and keep migrating the rest
+		if err := schema.Validate(envValue); err != nil {
+			invalid = append(invalid, fmt.Errorf("skipped %s: %w", schema.Key, err))
+			continue
+		}
+
 		// Migrate to database
-		err = m.configService.SetConfigTyped(ctx, mapping.EnvKey, envValue, mapping.ValueType, mapping.Category, mapping.Description)
+		err = m.configService.SetConfigTyped(ctx, schema.Key, envValue, string(schema.Type), schema.Category, schema.Description)
 		if err != nil {
-			return fmt.Errorf("failed to migrate %s: %w", mapping.EnvKey, err)
+			return fmt.Errorf("failed to migrate %s: %w", schema.Key, err)
 		}
 
 		migratedCount++
@@ -216,60 +227,29 @@ func (m *ConfigurationMigrator) MigrateEnvironmentVariables(ctx context.Context)
 		_ = fmt.Sprintf("Migrated %d environment variables to database configuration", migratedCount)
 	}
 
-	return nil
+	return errors.Join(invalid...)
 }
 
 // SeedDefaultConfigurations creates default configuration values if they don't exist
 func (m *ConfigurationMigrator) SeedDefaultConfigurations(ctx context.Context) error {
-	defaultConfigurations := []struct {
-		Key         string
-		Value       string
-		ValueType   string
-		Category    string
-		Description string
-	}{
-		// Default rate limits
-		{"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE", "60", "int", "rate_limiting", "Default Ollama API rate limit per minute"},
-		{"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY", "2000", "int", "rate_limiting", "Default Ollama API rate limit per day"},
-		{"USER_RATE_LIMIT_PER_MINUTE", "5", "int", "rate_limiting", "Default user rate limit per minute"},
-		{"USER_RATE_LIMIT_PER_HOUR", "30", "int", "rate_limiting", "Default user rate limit per hour"},
-		{"USER_RATE_LIMIT_PER_DAY", "100", "int", "rate_limiting", "Default user rate limit per day"},
-		{"ADMIN_ROLE_NAMES", "admin", "string", "rate_limiting", "Default admin role names that bypass rate limits"},
-		{"RATE_LIMITING_ENABLED", "true", "bool", "rate_limiting", "Enable user rate limiting by default"},
-
-		// Default feature flags
-		{"BMAD_KB_REFRESH_ENABLED", "true", "bool", "features", "Enable knowledge base refresh functionality by default"},
-		{"REACTION_TRIGGER_ENABLED", "true", "bool", "features", "Enable reaction trigger functionality by default"},
-		{"BOT_STATUS_UPDATE_ENABLED", "true", "bool", "features", "Enable bot status updates by default"},
-
-		// Default AI service configuration
-		{"OLLAMA_HOST", "http://localhost:11434", "string", "ai_services", "Default Ollama service host"},
-		{"OLLAMA_MODEL", "llama2", "string", "ai_services", "Default Ollama model"},
-
-		// Default channel restrictions
-		{"ALLOWED_CHANNEL_IDS", "", "string", "channel_restrictions", "Default allowed channel IDs (empty = all channels)"},
-		{"CHANNEL_RESTRICTIONS_ENABLED", "false", "bool", "channel_restrictions", "Disable channel restrictions by default"},
-		{"RESTRICT_DMS", "false", "bool", "channel_restrictions", "Allow DMs by default"},
-		{"ADMIN_CHANNEL_BYPASS_ENABLED", "false", "bool", "channel_restrictions", "Disable admin bypass by default"},
-
-		// Default system configuration
-		{"BOT_STATUS_UPDATE_INTERVAL", "5m", "duration", "system", "Default bot status update interval"},
-		{"CONFIG_RELOAD_INTERVAL", "1m", "duration", "system", "Default configuration reload interval"},
-	}
-
+	ctx = WithActor(ctx, ActorSeed)
 	seededCount := 0
-	for _, config := range defaultConfigurations {
+	for _, schema := range ConfigSchemas() {
+		if !schema.Seeded {
+			continue
+		}
+
 		// Check if configuration already exists
-		_, err := m.configService.GetConfig(ctx, config.Key)
+		_, err := m.configService.GetConfig(ctx, schema.Key)
 		if err == nil {
 			// Configuration already exists, skip seeding
 			continue
 		}
 
 		// Create default configuration
-		err = m.configService.SetConfigTyped(ctx, config.Key, config.Value, config.ValueType, config.Category, config.Description)
+		err = m.configService.SetConfigTyped(ctx, schema.Key, schema.Default, string(schema.Type), schema.Category, schema.Description)
 		if err != nil {
-			return fmt.Errorf("failed to seed default configuration %s: %w", config.Key, err)
+			return fmt.Errorf("failed to seed default configuration %s: %w", schema.Key, err)
 		}
 
 		seededCount++
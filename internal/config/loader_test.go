@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -193,6 +194,48 @@ func TestConfigurationLoader_ReloadAndNotifyServices_WithError(t *testing.T) {
 	}
 }
 
+func TestWatchKeys(t *testing.T) {
+	var applied []map[string]string
+	fail := false
+	listener := WatchKeys("watcher", map[string]string{"A": "1", "B": "2", "C": "x"}, []string{"A", "B"},
+		func(changed map[string]string) error {
+			applied = append(applied, changed)
+			if fail {
+				return fmt.Errorf("apply failed")
+			}
+			return nil
+		})
+
+	// Unwatched and unchanged keys do not trigger apply
+	if err := listener.OnReload(map[string]string{"A": "1", "B": "2", "C": "y"}); err != nil {
+		t.Fatalf("OnReload failed: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("Expected no apply for unchanged keys, got %v", applied)
+	}
+
+	// Only changed keys are passed; a removed key is reported as empty
+	if err := listener.OnReload(map[string]string{"A": "5"}); err != nil {
+		t.Fatalf("OnReload failed: %v", err)
+	}
+	if len(applied) != 1 || len(applied[0]) != 2 || applied[0]["A"] != "5" || applied[0]["B"] != "" {
+		t.Fatalf("Expected A=5 and B removed, got %v", applied)
+	}
+
+	// Failed changes are retried on the next reload
+	fail = true
+	if err := listener.OnReload(map[string]string{"A": "6"}); err == nil {
+		t.Fatal("Expected the apply error to be returned")
+	}
+	fail = false
+	if err := listener.OnReload(map[string]string{"A": "6"}); err != nil {
+		t.Fatalf("OnReload failed: %v", err)
+	}
+	if len(applied) != 3 || applied[2]["A"] != "6" {
+		t.Errorf("Expected the failed change to be retried, got %v", applied)
+	}
+}
+
 func TestConfigurationMigrator_MigrateEnvironmentVariables(t *testing.T) {
 	mockStorage := NewMockStorageService()
 	configService := NewHybridConfigService(mockStorage)
@@ -245,6 +288,33 @@ func TestConfigurationMigrator_MigrateEnvironmentVariables(t *testing.T) {
 	}
 }
 
+func TestConfigurationMigrator_MigrateEnvironmentVariables_SkipsInvalid(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	configService := NewDatabaseConfigService(mockStorage)
+	migrator := NewConfigurationMigrator(configService)
+	ctx := context.Background()
+
+	if err := configService.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	t.Setenv("USER_RATE_LIMIT_PER_MINUTE", "-1")
+	t.Setenv("USER_RATE_LIMIT_PER_HOUR", "40")
+
+	// The invalid value is reported but does not stop the remaining keys from migrating
+	err := migrator.MigrateEnvironmentVariables(ctx)
+	if err == nil || !strings.Contains(err.Error(), "USER_RATE_LIMIT_PER_MINUTE") {
+		t.Errorf("Expected an error naming USER_RATE_LIMIT_PER_MINUTE, got %v", err)
+	}
+
+	if stored, _ := mockStorage.GetConfiguration(ctx, "USER_RATE_LIMIT_PER_MINUTE"); stored != nil {
+		t.Error("Expected invalid value to stay out of the database")
+	}
+	if value, err := configService.GetConfig(ctx, "USER_RATE_LIMIT_PER_HOUR"); err != nil || value != "40" {
+		t.Errorf("Expected USER_RATE_LIMIT_PER_HOUR to be migrated as 40, got %q (%v)", value, err)
+	}
+}
+
 func TestConfigurationMigrator_MigrateEnvironmentVariables_SkipExisting(t *testing.T) {
 	mockStorage := NewMockStorageService()
 	configService := NewHybridConfigService(mockStorage)
@@ -315,6 +385,15 @@ func TestConfigurationMigrator_SeedDefaultConfigurations(t *testing.T) {
 			t.Errorf("Expected default value '%s' for key %s, got '%s'", expectedValue, key, value)
 		}
 	}
+
+	// Seeded values are attributed to the seed actor in the history
+	history, err := configService.GetConfigHistory(ctx, "", "OLLAMA_HOST", 10)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Actor != ActorSeed {
+		t.Errorf("Expected one history entry by %q, got %+v", ActorSeed, history)
+	}
 }
 
 func TestConfigurationMigrator_SeedDefaultConfigurations_SkipExisting(t *testing.T) {
@@ -0,0 +1,344 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StringFormat constrains the shape of a string configuration value
+type StringFormat string
+
+const (
+	// FormatAny accepts any string
+	FormatAny StringFormat = ""
+	// FormatSnowflake accepts a single Discord ID, or an empty value
+	FormatSnowflake StringFormat = "snowflake"
+	// FormatSnowflakeList accepts a comma-separated list of Discord IDs, or an empty value
+	FormatSnowflakeList StringFormat = "snowflake_list"
+	// FormatURL accepts an absolute http(s) URL
+	FormatURL StringFormat = "url"
+	// FormatChannelTriggerModes accepts comma-separated <Discord ID>:<trigger mode> pairs, or an empty value
+	FormatChannelTriggerModes StringFormat = "channel_trigger_modes"
+	// FormatTriggerEmoji accepts a reaction trigger emoji other than the feedback vote emoji, or an empty value
+	FormatTriggerEmoji StringFormat = "trigger_emoji"
+)
+
+// ChannelTriggerModes lists the trigger modes accepted in CHANNEL_TRIGGER_MODES
+var ChannelTriggerModes = []string{"mention-only", "auto-respond", "disabled"}
+
+// FeedbackEmoji lists the emoji reserved for 👍/👎 answer feedback, which cannot trigger answers
+var FeedbackEmoji = []string{"👍", "👎"}
+
+// snowflakePattern matches a Discord ID
+var snowflakePattern = regexp.MustCompile(`^[0-9]{17,20}$`)
+
+// ConfigSchema declares a configuration key the bot understands and the values it accepts
+type ConfigSchema struct {
+	Key         string
+	Type        ValueType
+	Default     string
+	Category    string
+	Description string
+
+	// Min and Max bound int values; int values are never negative and a zero Max means unbounded
+	Min, Max int
+
+	// MinDuration and MaxDuration bound duration values; a zero MaxDuration means unbounded
+	MinDuration, MaxDuration time.Duration
+
+	// Enum lists the only accepted string values, compared case-insensitively
+	Enum []string
+
+	// Format constrains the shape of string values
+	Format StringFormat
+
+	// RestartRequired marks keys that are only read at startup
+	RestartRequired bool
+
+	// Seeded keys are written with their default the first time the bot starts
+	Seeded bool
+
+	// GuildScoped keys can be overridden per Discord guild with SetGuildConfig
+	GuildScoped bool
+}
+
+// configSchemas is the registry of every database-managed configuration key, grouped by category
+var configSchemas = []ConfigSchema{
+	// Rate limiting
+	{Key: "AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_MINUTE", Type: ValueTypeInt, Default: "60", Category: "rate_limiting", Description: "Ollama API rate limit per minute", Min: 1, Seeded: true},
+	{Key: "AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY", Type: ValueTypeInt, Default: "2000", Category: "rate_limiting", Description: "Ollama API rate limit per day", Min: 1, Seeded: true},
+	{Key: "AI_PROVIDER_OPENAI_RATE_LIMIT_PER_MINUTE", Type: ValueTypeInt, Default: "60", Category: "rate_limiting", Description: "OpenAI-compatible API rate limit per minute", Min: 1},
+	{Key: "AI_PROVIDER_OPENAI_RATE_LIMIT_PER_DAY", Type: ValueTypeInt, Default: "1000", Category: "rate_limiting", Description: "OpenAI-compatible API rate limit per day", Min: 1},
+	{Key: "USER_RATE_LIMIT_PER_MINUTE", Type: ValueTypeInt, Default: "5", Category: "rate_limiting", Description: "User rate limit per minute", Min: 1, Seeded: true, GuildScoped: true},
+	{Key: "USER_RATE_LIMIT_PER_HOUR", Type: ValueTypeInt, Default: "30", Category: "rate_limiting", Description: "User rate limit per hour", Min: 1, Seeded: true, GuildScoped: true},
+	{Key: "USER_RATE_LIMIT_PER_DAY", Type: ValueTypeInt, Default: "100", Category: "rate_limiting", Description: "User rate limit per day", Min: 1, Seeded: true, GuildScoped: true},
+	{Key: "ADMIN_ROLE_NAMES", Type: ValueTypeString, Default: "admin", Category: "rate_limiting", Description: "Comma-separated list of admin role names that bypass rate limits", Seeded: true},
+	{Key: "RATE_LIMITING_ENABLED", Type: ValueTypeBool, Default: "true", Category: "rate_limiting", Description: "Enable user rate limiting", Seeded: true, GuildScoped: true},
+
+	// Feature flags
+	{Key: "BMAD_KB_REFRESH_ENABLED", Type: ValueTypeBool, Default: "true", Category: "features", Description: "Enable knowledge base refresh functionality", RestartRequired: true, Seeded: true},
+	{Key: "REACTION_TRIGGER_ENABLED", Type: ValueTypeBool, Default: "false", Category: "features", Description: "Enable reaction trigger functionality", Seeded: true, GuildScoped: true},
+	{Key: "REACTION_TRIGGER_EMOJI", Type: ValueTypeString, Default: "❓", Category: "features", Description: "Emoji that triggers an answer to the reacted message", Format: FormatTriggerEmoji, GuildScoped: true},
+	{Key: "REACTION_TRIGGER_APPROVED_USER_IDS", Type: ValueTypeString, Category: "features", Description: "Comma-separated user IDs allowed to use the reaction trigger", Format: FormatSnowflakeList, GuildScoped: true},
+	{Key: "REACTION_TRIGGER_APPROVED_ROLE_NAMES", Type: ValueTypeString, Category: "features", Description: "Comma-separated role names allowed to use the reaction trigger", GuildScoped: true},
+	{Key: "REACTION_TRIGGER_REQUIRE_REACTION", Type: ValueTypeBool, Default: "true", Category: "features", Description: "Only approved users and roles may use the reaction trigger", GuildScoped: true},
+	{Key: "REACTION_TRIGGER_REMOVE_REACTION", Type: ValueTypeBool, Default: "false", Category: "features", Description: "Remove the trigger reaction after answering", GuildScoped: true},
+	{Key: "REPLY_MENTION_DELETE_MESSAGE", Type: ValueTypeBool, Default: "false", Category: "features", Description: "Delete the reply that mentioned the bot after answering"},
+	{Key: "MONITORED_FORUM_CHANNELS", Type: ValueTypeString, Category: "features", Description: "Comma-separated Forum channel IDs answered automatically", Format: FormatSnowflakeList, GuildScoped: true},
+	{Key: "BOT_STATUS_UPDATE_ENABLED", Type: ValueTypeBool, Default: "true", Category: "features", Description: "Enable bot status updates", RestartRequired: true, Seeded: true},
+	{Key: "BMAD_STATUS_ROTATION_ENABLED", Type: ValueTypeBool, Default: "true", Category: "features", Description: "Rotate BMAD-themed bot status messages", RestartRequired: true},
+	{Key: "AI_STREAMING_ENABLED", Type: ValueTypeBool, Default: "true", Category: "features", Description: "Stream AI answers into Discord as they are generated", RestartRequired: true},
+	{Key: "DM_MEMORY_SUMMARIZE_AFTER", Type: ValueTypeInt, Default: "20", Category: "features", Description: "Stored DM messages kept verbatim before older ones are summarized", Min: 4, Max: 200},
+	{Key: "THREAD_HISTORY_CONTEXT_TOKENS", Type: ValueTypeInt, Default: "32768", Category: "features", Description: "Model context size in tokens that thread history is fitted to before older messages are summarized", Min: 1024},
+
+	// Knowledge base
+	{Key: "BMAD_KB_REFRESH_INTERVAL_HOURS", Type: ValueTypeInt, Default: "6", Category: "knowledge_base", Description: "Hours between knowledge base refreshes", Min: 1, Max: 168, RestartRequired: true},
+	{Key: "BMAD_KB_REMOTE_URL", Type: ValueTypeString, Default: "https://github.com/bmadcode/BMAD-METHOD/raw/refs/heads/main/bmad-core/data/bmad-kb.md", Category: "knowledge_base", Description: "URL the knowledge base is downloaded from", Format: FormatURL, RestartRequired: true},
+	{Key: "KB_SOURCES_ENABLED", Type: ValueTypeBool, Default: "true", Category: "knowledge_base", Description: "Append a Sources footer linking answers to the knowledge base sections they are based on", RestartRequired: true},
+
+	// Response cache
+	{Key: "RESPONSE_CACHE_ENABLED", Type: ValueTypeBool, Default: "true", Category: "response_cache", Description: "Reuse answers to repeated standalone questions"},
+	{Key: "RESPONSE_CACHE_TTL", Type: ValueTypeDuration, Default: "6h", Category: "response_cache", Description: "How long a cached answer is served", MinDuration: time.Minute},
+	{Key: "RESPONSE_CACHE_MAX_ENTRIES", Type: ValueTypeInt, Default: "500", Category: "response_cache", Description: "Cached answers kept before the oldest is evicted", Min: 1, Max: 100000},
+	{Key: "RESPONSE_CACHE_SIMILARITY_PERCENT", Type: ValueTypeInt, Default: "0", Category: "response_cache", Description: "Word overlap % for near-duplicate questions to share an answer (0 = exact only)", Max: 100},
+
+	// AI work queue
+	{Key: "AI_QUEUE_CONCURRENCY", Type: ValueTypeInt, Default: "2", Category: "ai_queue", Description: "AI questions answered at the same time", Min: 1, Max: 64},
+	{Key: "AI_QUEUE_MAX_DEPTH", Type: ValueTypeInt, Default: "50", Category: "ai_queue", Description: "Questions allowed to wait for an answer before new ones are turned away", Max: 10000},
+	{Key: "AI_QUEUE_JOB_TIMEOUT", Type: ValueTypeDuration, Default: "5m", Category: "ai_queue", Description: "How long a question may wait and generate before it is cancelled", MinDuration: time.Minute},
+
+	// AI services
+	{Key: "OLLAMA_HOST", Type: ValueTypeString, Default: "http://localhost:11434", Category: "ai_services", Description: "Ollama service host address", Format: FormatURL, RestartRequired: true, Seeded: true},
+	{Key: "OLLAMA_MODEL", Type: ValueTypeString, Default: "devstral", Category: "ai_services", Description: "Ollama model to use", Seeded: true},
+	{Key: "OLLAMA_TIMEOUT", Type: ValueTypeInt, Default: "30", Category: "ai_services", Description: "Ollama request timeout in seconds", Min: 1},
+	{Key: "OPENAI_BASE_URL", Type: ValueTypeString, Category: "ai_services", Description: "OpenAI-compatible API base URL", Format: FormatURL, RestartRequired: true},
+	{Key: "OPENAI_MODEL", Type: ValueTypeString, Category: "ai_services", Description: "OpenAI-compatible model to use", RestartRequired: true},
+
+	// Channel restrictions
+	{Key: "ALLOWED_CHANNEL_IDS", Type: ValueTypeString, Default: "", Category: "channel_restrictions", Description: "Comma-separated channel or category IDs answered in allowlist mode (empty = all channels)", Format: FormatSnowflakeList, Seeded: true, GuildScoped: true},
+	{Key: "DENIED_CHANNEL_IDS", Type: ValueTypeString, Default: "", Category: "channel_restrictions", Description: "Comma-separated channel or category IDs ignored in denylist mode", Format: FormatSnowflakeList, GuildScoped: true},
+	{Key: "CHANNEL_RESTRICTION_MODE", Type: ValueTypeString, Default: "allowlist", Category: "channel_restrictions", Description: "Whether restrictions use the allowed or the denied channel list", Enum: []string{"allowlist", "denylist"}, Seeded: true, GuildScoped: true},
+	{Key: "CHANNEL_RESTRICTIONS_ENABLED", Type: ValueTypeBool, Default: "false", Category: "channel_restrictions", Description: "Enable channel restrictions", Seeded: true, GuildScoped: true},
+	{Key: "CHANNEL_TRIGGER_MODES", Type: ValueTypeString, Default: "", Category: "channel_restrictions", Description: "Comma-separated channel_or_category_id:mode pairs (threads inherit from their parent)", Format: FormatChannelTriggerModes, GuildScoped: true},
+	{Key: "RESTRICT_DMS", Type: ValueTypeBool, Default: "false", Category: "channel_restrictions", Description: "Restrict bot operations in DM channels", Seeded: true},
+	{Key: "ADMIN_CHANNEL_BYPASS_ENABLED", Type: ValueTypeBool, Default: "false", Category: "channel_restrictions", Description: "Allow admin users to bypass channel restrictions", Seeded: true, GuildScoped: true},
+
+	// System
+	{Key: "BOT_STATUS_UPDATE_INTERVAL", Type: ValueTypeDuration, Default: "5m", Category: "system", Description: "Interval for bot status updates", MinDuration: time.Second, Seeded: true},
+	{Key: "BMAD_STATUS_ROTATION_INTERVAL", Type: ValueTypeDuration, Default: "5m", Category: "system", Description: "Interval between BMAD status rotations", MinDuration: 30 * time.Second},
+	{Key: "CONFIG_RELOAD_INTERVAL", Type: ValueTypeDuration, Default: "1m", Category: "system", Description: "Configuration reload interval", MinDuration: time.Second, RestartRequired: true, Seeded: true},
+	{Key: "DISCORD_COMMAND_GUILD_ID", Type: ValueTypeString, Category: "system", Description: "Guild to register slash commands in (empty = global)", Format: FormatSnowflake, RestartRequired: true},
+
+	// Interaction log
+	{Key: "INTERACTION_LOG_ENABLED", Type: ValueTypeBool, Default: "true", Category: "interactions", Description: "Persist each Q&A exchange to the interactions table", RestartRequired: true, Seeded: true},
+	{Key: "INTERACTION_RETENTION_DAYS", Type: ValueTypeInt, Default: "90", Category: "interactions", Description: "Days to keep logged interactions (0 = keep forever)", Max: 3650, Seeded: true},
+	{Key: "FEEDBACK_REACTIONS_ENABLED", Type: ValueTypeBool, Default: "true", Category: "interactions", Description: "Add 👍/👎 feedback reactions to logged answers", RestartRequired: true, Seeded: true},
+}
+
+// configSchemaIndex maps each registered key to its position in configSchemas
+var configSchemaIndex = func() map[string]int {
+	index := make(map[string]int, len(configSchemas))
+	for i, schema := range configSchemas {
+		index[schema.Key] = i
+	}
+	return index
+}()
+
+// ConfigSchemas returns every registered configuration key in registry order
+func ConfigSchemas() []ConfigSchema {
+	schemas := make([]ConfigSchema, len(configSchemas))
+	copy(schemas, configSchemas)
+	return schemas
+}
+
+// ConfigCategories returns the categories of registered keys in registry order
+func ConfigCategories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, schema := range configSchemas {
+		if !seen[schema.Category] {
+			seen[schema.Category] = true
+			categories = append(categories, schema.Category)
+		}
+	}
+	return categories
+}
+
+// LookupConfigSchema returns the schema for key, including per-provider rate limits such as AI_PROVIDER_GEMINI_RATE_LIMIT_PER_DAY
+func LookupConfigSchema(key string) (ConfigSchema, bool) {
+	if i, ok := configSchemaIndex[key]; ok {
+		return configSchemas[i], true
+	}
+
+	if provider, ok := strings.CutPrefix(key, "AI_PROVIDER_"); ok {
+		for _, window := range []string{"MINUTE", "DAY"} {
+			if name, ok := strings.CutSuffix(provider, "_RATE_LIMIT_PER_"+window); ok && name != "" {
+				return ConfigSchema{
+					Key:         key,
+					Type:        ValueTypeInt,
+					Category:    "rate_limiting",
+					Description: fmt.Sprintf("%s API rate limit per %s", name, strings.ToLower(window)),
+					Min:         1,
+				}, true
+			}
+		}
+	}
+
+	return ConfigSchema{}, false
+}
+
+// Validate checks that value has the schema's type and satisfies its range, enum and format
+func (c ConfigSchema) Validate(value string) error {
+	switch c.Type {
+	case ValueTypeInt:
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		if parsed < c.Min {
+			return fmt.Errorf("must be at least %d", c.Min)
+		}
+		if c.Max > 0 && parsed > c.Max {
+			return fmt.Errorf("must be at most %d", c.Max)
+		}
+	case ValueTypeBool:
+		if !isBoolValue(value) {
+			return fmt.Errorf("must be true/false, 1/0, yes/no, on/off, or enabled/disabled")
+		}
+	case ValueTypeDuration:
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m")
+		}
+		if parsed < c.MinDuration {
+			return fmt.Errorf("must be at least %s", c.MinDuration)
+		}
+		if c.MaxDuration > 0 && parsed > c.MaxDuration {
+			return fmt.Errorf("must be at most %s", c.MaxDuration)
+		}
+	case ValueTypeString:
+		if len(c.Enum) > 0 && !containsFold(c.Enum, value) {
+			return fmt.Errorf("must be one of %s", strings.Join(c.Enum, ", "))
+		}
+		return ValidateStringFormat(c.Format, value)
+	default:
+		return fmt.Errorf("unsupported value type: %s", c.Type)
+	}
+
+	return nil
+}
+
+// Constraint describes the values the schema accepts, e.g. "int ≥ 1" or "duration ≥ 1s"
+func (c ConfigSchema) Constraint() string {
+	switch c.Type {
+	case ValueTypeInt:
+		if c.Max > 0 {
+			return fmt.Sprintf("int %d–%d", c.Min, c.Max)
+		}
+		return fmt.Sprintf("int ≥ %d", c.Min)
+	case ValueTypeDuration:
+		switch {
+		case c.MaxDuration > 0:
+			return fmt.Sprintf("duration %s–%s", c.MinDuration, c.MaxDuration)
+		case c.MinDuration > 0:
+			return fmt.Sprintf("duration ≥ %s", c.MinDuration)
+		}
+		return "duration"
+	case ValueTypeString:
+		if len(c.Enum) > 0 {
+			return strings.Join(c.Enum, "|")
+		}
+		switch c.Format {
+		case FormatSnowflake:
+			return "Discord ID"
+		case FormatSnowflakeList:
+			return "Discord IDs, comma-separated"
+		case FormatURL:
+			return "URL"
+		case FormatChannelTriggerModes:
+			return "ID:" + strings.Join(ChannelTriggerModes, "|") + " pairs"
+		case FormatTriggerEmoji:
+			return "emoji other than " + strings.Join(FeedbackEmoji, "/")
+		}
+	}
+	return string(c.Type)
+}
+
+// ValidateStringFormat checks value against a string format
+func ValidateStringFormat(format StringFormat, value string) error {
+	switch format {
+	case FormatAny:
+		return nil
+	case FormatSnowflake:
+		if value != "" && !snowflakePattern.MatchString(value) {
+			return fmt.Errorf("must be a Discord ID (17-20 digits)")
+		}
+	case FormatSnowflakeList:
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+		for _, id := range strings.Split(value, ",") {
+			if !snowflakePattern.MatchString(strings.TrimSpace(id)) {
+				return fmt.Errorf("must be a comma-separated list of Discord IDs, %q is not one", strings.TrimSpace(id))
+			}
+		}
+	case FormatChannelTriggerModes:
+		if strings.TrimSpace(value) == "" {
+			return nil
+		}
+		for _, pair := range strings.Split(value, ",") {
+			id, mode, found := strings.Cut(strings.TrimSpace(pair), ":")
+			if !found || !snowflakePattern.MatchString(strings.TrimSpace(id)) || !containsFold(ChannelTriggerModes, strings.TrimSpace(mode)) {
+				return fmt.Errorf("must be comma-separated <Discord ID>:<mode> pairs with mode one of %s, %q is not one", strings.Join(ChannelTriggerModes, ", "), strings.TrimSpace(pair))
+			}
+		}
+	case FormatTriggerEmoji:
+		for _, emoji := range FeedbackEmoji {
+			if strings.TrimSpace(value) == emoji {
+				return fmt.Errorf("must not be %s, which is used for answer feedback", strings.Join(FeedbackEmoji, " or "))
+			}
+		}
+	case FormatURL:
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("must be an http(s) URL")
+		}
+	default:
+		return fmt.Errorf("unsupported string format: %s", format)
+	}
+	return nil
+}
+
+// ParseBool parses a boolean configuration value, accepting true/false, 1/0, yes/no, on/off and
+// enabled/disabled in any case. Every reader of boolean settings uses it so that any value accepted
+// by validation is read back the same way.
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes", "on", "enabled":
+		return true, nil
+	case "false", "0", "no", "off", "disabled":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean value %q", value)
+	}
+}
+
+// isBoolValue reports whether value is one of the accepted boolean spellings
+func isBoolValue(value string) bool {
+	_, err := ParseBool(value)
+	return err == nil
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
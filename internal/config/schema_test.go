@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestConfigSchemas_RegistryIsConsistent(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, schema := range ConfigSchemas() {
+		if seen[schema.Key] {
+			t.Errorf("key %s is registered twice", schema.Key)
+		}
+		seen[schema.Key] = true
+
+		if SecureConfigKeys[schema.Key] {
+			t.Errorf("secure key %s must not be registered", schema.Key)
+		}
+		if schema.Category == "" || schema.Description == "" {
+			t.Errorf("key %s needs a category and description", schema.Key)
+		}
+		if schema.Seeded {
+			if err := schema.Validate(schema.Default); err != nil {
+				t.Errorf("seeded default %q for %s is invalid: %v", schema.Default, schema.Key, err)
+			}
+		}
+	}
+}
+
+func TestLookupConfigSchema(t *testing.T) {
+	schema, ok := LookupConfigSchema("USER_RATE_LIMIT_PER_MINUTE")
+	if !ok || schema.Type != ValueTypeInt {
+		t.Fatalf("expected registered int schema, got %+v (ok=%v)", schema, ok)
+	}
+
+	schema, ok = LookupConfigSchema("AI_PROVIDER_GEMINI_RATE_LIMIT_PER_DAY")
+	if !ok || schema.Type != ValueTypeInt || schema.Min != 1 || schema.Category != "rate_limiting" {
+		t.Errorf("expected per-provider rate limit schema, got %+v (ok=%v)", schema, ok)
+	}
+
+	for _, key := range []string{"AI_PROVIDER__RATE_LIMIT_PER_DAY", "AI_PROVIDER_GEMINI_WARNING_THRESHOLD", "UNKNOWN_KEY"} {
+		if _, ok := LookupConfigSchema(key); ok {
+			t.Errorf("did not expect a schema for %s", key)
+		}
+	}
+}
+
+func TestConfigSchema_Validate(t *testing.T) {
+	intSchema := ConfigSchema{Type: ValueTypeInt, Min: 1, Max: 10}
+	durationSchema := ConfigSchema{Type: ValueTypeDuration, MinDuration: time.Second, MaxDuration: time.Hour}
+	enumSchema := ConfigSchema{Type: ValueTypeString, Enum: []string{"allowlist", "denylist"}}
+
+	testCases := []struct {
+		name   string
+		schema ConfigSchema
+		value  string
+		valid  bool
+	}{
+		{"int in range", intSchema, "5", true},
+		{"int below min", intSchema, "0", false},
+		{"negative int", ConfigSchema{Type: ValueTypeInt}, "-1", false},
+		{"int above max", intSchema, "11", false},
+		{"int not a number", intSchema, "five", false},
+		{"bool", ConfigSchema{Type: ValueTypeBool}, "enabled", true},
+		{"bad bool", ConfigSchema{Type: ValueTypeBool}, "maybe", false},
+		{"duration in range", durationSchema, "30s", true},
+		{"sub-second duration", durationSchema, "500ms", false},
+		{"duration above max", durationSchema, "2h", false},
+		{"malformed duration", durationSchema, "soon", false},
+		{"enum value", enumSchema, "DenyList", true},
+		{"enum other value", enumSchema, "off", false},
+		{"snowflake", ConfigSchema{Type: ValueTypeString, Format: FormatSnowflake}, "123456789012345678", true},
+		{"empty snowflake", ConfigSchema{Type: ValueTypeString, Format: FormatSnowflake}, "", true},
+		{"malformed snowflake", ConfigSchema{Type: ValueTypeString, Format: FormatSnowflake}, "general", false},
+		{"snowflake list", ConfigSchema{Type: ValueTypeString, Format: FormatSnowflakeList}, "123456789012345678, 987654321098765432", true},
+		{"snowflake list with bad entry", ConfigSchema{Type: ValueTypeString, Format: FormatSnowflakeList}, "123456789012345678,#general", false},
+		{"url", ConfigSchema{Type: ValueTypeString, Format: FormatURL}, "https://ollama.example.com", true},
+		{"url without scheme", ConfigSchema{Type: ValueTypeString, Format: FormatURL}, "ollama:11434", false},
+		{"trigger modes", ConfigSchema{Type: ValueTypeString, Format: FormatChannelTriggerModes}, "123456789012345678:auto-respond, 987654321098765432:Disabled", true},
+		{"empty trigger modes", ConfigSchema{Type: ValueTypeString, Format: FormatChannelTriggerModes}, "", true},
+		{"trigger mode without id", ConfigSchema{Type: ValueTypeString, Format: FormatChannelTriggerModes}, "auto-respond", false},
+		{"unknown trigger mode", ConfigSchema{Type: ValueTypeString, Format: FormatChannelTriggerModes}, "123456789012345678:always", false},
+		{"trigger emoji", ConfigSchema{Type: ValueTypeString, Format: FormatTriggerEmoji}, "🤖", true},
+		{"feedback emoji as trigger", ConfigSchema{Type: ValueTypeString, Format: FormatTriggerEmoji}, "👍", false},
+	}
+
+	for _, tc := range testCases {
+		err := tc.schema.Validate(tc.value)
+		if tc.valid && err != nil {
+			t.Errorf("%s: expected %q to be valid, got %v", tc.name, tc.value, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: expected %q to be rejected", tc.name, tc.value)
+		}
+	}
+}
+
+func TestConfigSchema_Constraint(t *testing.T) {
+	testCases := []struct {
+		schema ConfigSchema
+		want   string
+	}{
+		{ConfigSchema{Type: ValueTypeInt, Min: 1}, "int ≥ 1"},
+		{ConfigSchema{Type: ValueTypeInt, Max: 3650}, "int 0–3650"},
+		{ConfigSchema{Type: ValueTypeDuration, MinDuration: time.Second}, "duration ≥ 1s"},
+		{ConfigSchema{Type: ValueTypeBool}, "bool"},
+		{ConfigSchema{Type: ValueTypeString, Enum: []string{"a", "b"}}, "a|b"},
+		{ConfigSchema{Type: ValueTypeString, Format: FormatSnowflakeList}, "Discord IDs, comma-separated"},
+		{ConfigSchema{Type: ValueTypeString, Format: FormatChannelTriggerModes}, "ID:mention-only|auto-respond|disabled pairs"},
+		{ConfigSchema{Type: ValueTypeString, Format: FormatTriggerEmoji}, "emoji other than 👍/👎"},
+		{ConfigSchema{Type: ValueTypeString}, "string"},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.schema.Constraint(); got != tc.want {
+			t.Errorf("Constraint() = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	testCases := map[string]bool{
+		"true": true, "TRUE": true, "1": true, "yes": true, " on ": true, "Enabled": true,
+		"false": false, "0": false, "no": false, "off": false, "DISABLED": false,
+	}
+	for value, want := range testCases {
+		got, err := ParseBool(value)
+		if err != nil || got != want {
+			t.Errorf("ParseBool(%q) = %v, %v; want %v", value, got, err, want)
+		}
+		// Everything validation accepts is read back
+		if !isBoolValue(value) {
+			t.Errorf("Expected %q to be a valid boolean", value)
+		}
+	}
+
+	if _, err := ParseBool("maybe"); err == nil {
+		t.Error("Expected an error for an unknown boolean spelling")
+	}
+}
+
+func TestDatabaseConfigService_SetConfigUsesSchema(t *testing.T) {
+	mockStorage := NewMockStorageService()
+	service := NewDatabaseConfigService(mockStorage)
+	ctx := context.Background()
+
+	rejected := map[string]string{
+		"USER_RATE_LIMIT_PER_MINUTE":               "-5",
+		"AI_PROVIDER_GEMINI_RATE_LIMIT_PER_DAY":    "0",
+		"ALLOWED_CHANNEL_IDS":                      "general,random",
+		"BOT_STATUS_UPDATE_INTERVAL":               "100ms",
+		"INTERACTION_RETENTION_DAYS":               "100000",
+		"DISCORD_COMMAND_GUILD_ID":                 "my-guild",
+		"AI_PROVIDER_OLLAMA_RATE_LIMIT_PER_DAY":    "lots",
+		"AI_PROVIDER_OPENAI_RATE_LIMIT_PER_MINUTE": "1.5",
+	}
+	for key, value := range rejected {
+		if err := service.SetConfig(ctx, key, value, "", ""); err == nil {
+			t.Errorf("SetConfig(%s, %q) should have been rejected", key, value)
+		}
+	}
+
+	// Registered keys take their type, category and description from the registry
+	if err := service.SetConfig(ctx, "USER_RATE_LIMIT_PER_MINUTE", "10", "", ""); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	stored, err := mockStorage.GetConfiguration(ctx, "USER_RATE_LIMIT_PER_MINUTE")
+	if err != nil || stored == nil {
+		t.Fatalf("GetConfiguration failed: %v", err)
+	}
+	if stored.Type != string(ValueTypeInt) || stored.Category != "rate_limiting" || stored.Description != "User rate limit per minute" {
+		t.Errorf("expected registry type, category and description, got %+v", stored)
+	}
+}
@@ -0,0 +1,314 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthServerAddr is the listen address used when HEALTH_SERVER_ADDR is not set
+	DefaultHealthServerAddr = ":8080"
+
+	// defaultReadinessCheckTimeout bounds each readiness check so a hung dependency fails the probe
+	defaultReadinessCheckTimeout = 5 * time.Second
+)
+
+// ReadinessCheck reports whether a dependency required to serve traffic is healthy
+type ReadinessCheck func(ctx context.Context) error
+
+// MetricsCollector writes metrics in the Prometheus text exposition format
+type MetricsCollector func(w io.Writer)
+
+// ReadinessResult is the outcome of a single readiness check
+type ReadinessResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// readinessResponse is the JSON body served by /readyz
+type readinessResponse struct {
+	Ready  bool              `json:"ready"`
+	Checks []ReadinessResult `json:"checks"`
+}
+
+// namedReadinessCheck pairs a readiness check with the name reported in results
+type namedReadinessCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// HealthServer serves /healthz (process alive), /readyz (dependencies healthy) and /metrics
+type HealthServer struct {
+	addr         string
+	logger       *slog.Logger
+	checkTimeout time.Duration
+	startTime    time.Time
+	server       *http.Server
+	listener     net.Listener
+
+	mu          sync.RWMutex
+	ready       bool // Set once startup has completed
+	checks      []namedReadinessCheck
+	lastResults []ReadinessResult
+	collectors  []MetricsCollector
+}
+
+// NewHealthServer creates a health server listening on the given address
+func NewHealthServer(addr string, logger *slog.Logger) *HealthServer {
+	if addr == "" {
+		addr = DefaultHealthServerAddr
+	}
+
+	return &HealthServer{
+		addr:         addr,
+		logger:       logger,
+		checkTimeout: defaultReadinessCheckTimeout,
+		startTime:    time.Now(),
+	}
+}
+
+// AddReadinessCheck registers a dependency check evaluated by /readyz
+func (h *HealthServer) AddReadinessCheck(name string, check ReadinessCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedReadinessCheck{name: name, check: check})
+}
+
+// AddMetricsCollector registers a collector whose output is appended to /metrics
+func (h *HealthServer) AddMetricsCollector(collector MetricsCollector) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.collectors = append(h.collectors, collector)
+}
+
+// SetReady marks whether startup has completed; /readyz fails until it has
+func (h *HealthServer) SetReady(ready bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = ready
+}
+
+// Handler returns the HTTP handler serving the health endpoints
+func (h *HealthServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.handleHealthz)
+	mux.HandleFunc("/readyz", h.handleReadyz)
+	mux.HandleFunc("/metrics", h.handleMetrics)
+	return mux
+}
+
+// Start begins serving the health endpoints in the background
+func (h *HealthServer) Start() error {
+	listener, err := net.Listen("tcp", h.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", h.addr, err)
+	}
+
+	h.listener = listener
+	h.server = &http.Server{
+		Handler:           h.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := h.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			h.logger.Error("Health server stopped unexpectedly", "error", err)
+		}
+	}()
+
+	h.logger.Info("Health server started", "addr", listener.Addr().String())
+	return nil
+}
+
+// Addr returns the address the server is listening on, or the configured address before Start
+func (h *HealthServer) Addr() string {
+	if h.listener != nil {
+		return h.listener.Addr().String()
+	}
+	return h.addr
+}
+
+// Shutdown gracefully stops the health server
+func (h *HealthServer) Shutdown(ctx context.Context) error {
+	if h.server == nil {
+		return nil
+	}
+	if err := h.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down health server: %w", err)
+	}
+	return nil
+}
+
+// CheckReadiness runs every readiness check concurrently and reports whether the bot can serve traffic
+func (h *HealthServer) CheckReadiness(ctx context.Context) (bool, []ReadinessResult) {
+	h.mu.RLock()
+	ready := h.ready
+	checks := append([]namedReadinessCheck(nil), h.checks...)
+	h.mu.RUnlock()
+
+	results := make([]ReadinessResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check namedReadinessCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.checkTimeout)
+			defer cancel()
+
+			results[i] = ReadinessResult{Name: check.name, OK: true}
+			if err := runReadinessCheck(checkCtx, check.check); err != nil {
+				results[i] = ReadinessResult{Name: check.name, OK: false, Error: err.Error()}
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	if !ready {
+		results = append([]ReadinessResult{{Name: "startup", OK: false, Error: "startup has not completed"}}, results...)
+	}
+	for _, result := range results {
+		if !result.OK {
+			ready = false
+		}
+	}
+
+	h.mu.Lock()
+	h.lastResults = results
+	h.mu.Unlock()
+
+	return ready, results
+}
+
+// runReadinessCheck runs a check, treating checks that outlive their context as failed
+func runReadinessCheck(ctx context.Context, check ReadinessCheck) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
+
+// handleHealthz reports that the process is alive
+func (h *HealthServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports whether startup completed and every dependency is healthy
+func (h *HealthServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready, results := h.CheckReadiness(r.Context())
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+		for _, result := range results {
+			if !result.OK {
+				h.logger.Warn("Readiness check failed", "check", result.Name, "error", result.Error)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(readinessResponse{Ready: ready, Checks: results})
+}
+
+// handleMetrics serves health gauges followed by the output of every registered collector
+func (h *HealthServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	results := append([]ReadinessResult(nil), h.lastResults...)
+	collectors := append([]MetricsCollector(nil), h.collectors...)
+	h.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP bmad_bot_up Whether the bot process is running.")
+	fmt.Fprintln(w, "# TYPE bmad_bot_up gauge")
+	fmt.Fprintln(w, "bmad_bot_up 1")
+
+	fmt.Fprintln(w, "# HELP bmad_bot_uptime_seconds Seconds since the bot process started.")
+	fmt.Fprintln(w, "# TYPE bmad_bot_uptime_seconds gauge")
+	fmt.Fprintf(w, "bmad_bot_uptime_seconds %.0f\n", time.Since(h.startTime).Seconds())
+
+	// Report the outcome of the most recent readiness probe without re-running checks on every scrape
+	if len(results) > 0 {
+		sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+		fmt.Fprintln(w, "# HELP bmad_bot_readiness_check Result of the most recent readiness check (1 = healthy).")
+		fmt.Fprintln(w, "# TYPE bmad_bot_readiness_check gauge")
+		for _, result := range results {
+			fmt.Fprintf(w, "bmad_bot_readiness_check{check=%q} %d\n", result.Name, boolToGauge(result.OK))
+		}
+	}
+
+	for _, collector := range collectors {
+		collector(w)
+	}
+}
+
+// boolToGauge converts a boolean to a 0/1 gauge value
+func boolToGauge(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// HealthCheckURL builds the URL of an endpoint on a health server listening on addr, using loopback for wildcard hosts
+func HealthCheckURL(addr, path string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr + path
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + path
+}
+
+// ProbeReadiness queries a running instance's /readyz endpoint, returning an error unless it reports ready
+func ProbeReadiness(addr string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(HealthCheckURL(addr, "/readyz"))
+	if err != nil {
+		return fmt.Errorf("failed to reach health server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body readinessResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil {
+			var failed []string
+			for _, result := range body.Checks {
+				if !result.OK {
+					failed = append(failed, result.Name+": "+result.Error)
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("not ready (status %d): %s", resp.StatusCode, strings.Join(failed, "; "))
+			}
+		}
+		return fmt.Errorf("not ready (status %d)", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHealthServer() *HealthServer {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewHealthServer("127.0.0.1:0", logger)
+}
+
+func TestHealthServer_Healthz(t *testing.T) {
+	server := newTestHealthServer()
+	server.AddReadinessCheck("storage", func(ctx context.Context) error { return errors.New("down") })
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	// Liveness does not depend on readiness checks
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected /healthz to return 200, got %d", recorder.Code)
+	}
+}
+
+func TestHealthServer_Readyz(t *testing.T) {
+	server := newTestHealthServer()
+
+	var storageErr error
+	server.AddReadinessCheck("discord_gateway", func(ctx context.Context) error { return nil })
+	server.AddReadinessCheck("storage", func(ctx context.Context) error { return storageErr })
+
+	readyz := func() (int, readinessResponse) {
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var body readinessResponse
+		if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode /readyz response: %v", err)
+		}
+		return recorder.Code, body
+	}
+
+	// Not ready until startup has completed
+	code, body := readyz()
+	if code != http.StatusServiceUnavailable || body.Ready || body.Checks[0].Name != "startup" {
+		t.Errorf("Expected startup to fail readiness, got %d %+v", code, body)
+	}
+
+	server.SetReady(true)
+	code, body = readyz()
+	if code != http.StatusOK || !body.Ready || len(body.Checks) != 2 {
+		t.Errorf("Expected ready response, got %d %+v", code, body)
+	}
+
+	storageErr = errors.New("connection refused")
+	code, body = readyz()
+	if code != http.StatusServiceUnavailable || body.Ready {
+		t.Fatalf("Expected failing storage check to fail readiness, got %d %+v", code, body)
+	}
+	if body.Checks[1].OK || body.Checks[1].Error != "connection refused" {
+		t.Errorf("Expected storage check failure to be reported, got %+v", body.Checks[1])
+	}
+}
+
+func TestHealthServer_ReadinessCheckTimeout(t *testing.T) {
+	server := newTestHealthServer()
+	server.checkTimeout = 20 * time.Millisecond
+	server.SetReady(true)
+
+	hung := make(chan struct{})
+	defer close(hung)
+	server.AddReadinessCheck("discord_gateway", func(ctx context.Context) error {
+		<-hung // Ignores its context, like a hung dependency
+		return nil
+	})
+
+	ready, results := server.CheckReadiness(context.Background())
+	if ready || !strings.Contains(results[0].Error, "timed out") {
+		t.Errorf("Expected hung check to time out, got ready=%v %+v", ready, results)
+	}
+}
+
+func TestHealthServer_Metrics(t *testing.T) {
+	server := newTestHealthServer()
+	server.SetReady(true)
+	server.AddReadinessCheck("storage", func(ctx context.Context) error { return nil })
+	server.AddMetricsCollector(func(w io.Writer) {
+		io.WriteString(w, "custom_metric 42\n")
+	})
+
+	server.CheckReadiness(context.Background())
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := recorder.Body.String()
+	for _, expected := range []string{"bmad_bot_up 1", `bmad_bot_readiness_check{check="storage"} 1`, "custom_metric 42"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected /metrics to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestProbeReadiness(t *testing.T) {
+	server := newTestHealthServer()
+	server.AddReadinessCheck("storage", func(ctx context.Context) error { return errors.New("database unreachable") })
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Shutdown(context.Background())
+
+	server.SetReady(true)
+	err := ProbeReadiness(server.Addr(), time.Second)
+	if err == nil || !strings.Contains(err.Error(), "storage: database unreachable") {
+		t.Errorf("Expected probe to report the failing check, got %v", err)
+	}
+
+	if err := ProbeReadiness("127.0.0.1:1", time.Second); err == nil {
+		t.Error("Expected probe to fail when no server is listening")
+	}
+}
+
+func TestHealthCheckURL(t *testing.T) {
+	tests := map[string]string{
+		":8080":          "http://127.0.0.1:8080/readyz",
+		"0.0.0.0:9090":   "http://127.0.0.1:9090/readyz",
+		"localhost:8080": "http://localhost:8080/readyz",
+	}
+	for addr, expected := range tests {
+		if url := HealthCheckURL(addr, "/readyz"); url != expected {
+			t.Errorf("HealthCheckURL(%q) = %q, expected %q", addr, url, expected)
+		}
+	}
+}
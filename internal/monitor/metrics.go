@@ -0,0 +1,417 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query trigger labels for bmad_bot_queries_total
+const (
+	TriggerMention      = "mention"
+	TriggerReplyMention = "reply_mention"
+	TriggerReaction     = "reaction"
+	TriggerDM           = "dm"
+	TriggerForum        = "forum"
+	TriggerAutoThread   = "auto_thread"
+	TriggerAutoChannel  = "auto_channel"
+)
+
+// AI request mode labels for the AI request metrics
+const (
+	AIRequestModeBlocking  = "blocking"
+	AIRequestModeStreaming = "streaming"
+)
+
+var (
+	// aiDurationBuckets covers fast local models through slow CPU-bound generations
+	aiDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+	// payloadSizeBuckets covers short replies through prompts carrying the full knowledge base
+	payloadSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+	// providerStatuses lists every status reported by RateLimitManager.GetProviderStatus
+	providerStatuses = []string{"Normal", "Warning", "Throttled", "Quota Exhausted"}
+)
+
+// GaugeSample is a single labelled value reported by a gauge function
+type GaugeSample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// GaugeFunc returns the current samples of a gauge at scrape time
+type GaugeFunc func() []GaugeSample
+
+// Metrics records bot activity and renders it in the Prometheus text exposition format.
+// A nil *Metrics is valid and discards every observation, so components can be instrumented unconditionally.
+type Metrics struct {
+	queries          *counterVec
+	aiRequests       *counterVec
+	aiDuration       *histogramVec
+	aiPromptBytes    *histogramVec
+	aiResponseBytes  *histogramVec
+	rateLimitDenials *counterVec
+	storageErrors    *counterVec
+	cacheLookups     *counterVec
+
+	mu     sync.RWMutex
+	gauges []*gaugeFunc
+}
+
+// NewMetrics creates a metrics registry with the bot's counters and histograms
+func NewMetrics() *Metrics {
+	return &Metrics{
+		queries: newCounterVec("bmad_bot_queries_total",
+			"Queries accepted for an AI response, by trigger.", "trigger"),
+		aiRequests: newCounterVec("bmad_bot_ai_requests_total",
+			"AI provider requests, by provider, mode and outcome.", "provider", "mode", "outcome"),
+		aiDuration: newHistogramVec("bmad_bot_ai_request_duration_seconds",
+			"AI provider request latency in seconds.", aiDurationBuckets, "provider", "mode"),
+		aiPromptBytes: newHistogramVec("bmad_bot_ai_prompt_bytes",
+			"Size of prompts sent to AI providers in bytes.", payloadSizeBuckets, "provider"),
+		aiResponseBytes: newHistogramVec("bmad_bot_ai_response_bytes",
+			"Size of responses received from AI providers in bytes.", payloadSizeBuckets, "provider"),
+		rateLimitDenials: newCounterVec("bmad_bot_user_rate_limit_denials_total",
+			"Requests denied by the per-user rate limiter, by time window.", "window"),
+		storageErrors: newCounterVec("bmad_bot_storage_errors_total",
+			"Failed storage operations, by operation.", "operation"),
+		cacheLookups: newCounterVec("bmad_bot_response_cache_lookups_total",
+			"Response cache lookups, by outcome (hit or miss).", "outcome"),
+	}
+}
+
+// RecordQuery counts a query accepted for an AI response
+func (m *Metrics) RecordQuery(trigger string) {
+	if m == nil {
+		return
+	}
+	m.queries.inc(trigger)
+}
+
+// ObserveAIRequest records the latency, payload sizes and outcome of a single AI provider request
+func (m *Metrics) ObserveAIRequest(provider, mode string, duration time.Duration, promptBytes, responseBytes int, err error) {
+	if m == nil {
+		return
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.aiRequests.inc(provider, mode, outcome)
+	m.aiDuration.observe(duration.Seconds(), provider, mode)
+	m.aiPromptBytes.observe(float64(promptBytes), provider)
+	if err == nil {
+		m.aiResponseBytes.observe(float64(responseBytes), provider)
+	}
+}
+
+// RecordUserRateLimitDenial counts a request rejected by the per-user rate limiter
+func (m *Metrics) RecordUserRateLimitDenial(window string) {
+	if m == nil {
+		return
+	}
+	m.rateLimitDenials.inc(window)
+}
+
+// RecordStorageError counts a failed storage operation
+func (m *Metrics) RecordStorageError(operation string) {
+	if m == nil {
+		return
+	}
+	m.storageErrors.inc(operation)
+}
+
+// RecordResponseCacheLookup counts a response cache lookup as a hit or a miss
+func (m *Metrics) RecordResponseCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+
+	outcome := "miss"
+	if hit {
+		outcome = "hit"
+	}
+	m.cacheLookups.inc(outcome)
+}
+
+// ObserveStorageOperation counts the operation as failed when err is set; it matches the storage
+// package's observer signature so it can be passed to storage.NewInstrumentedStorageService
+func (m *Metrics) ObserveStorageOperation(operation string, err error) {
+	if err != nil {
+		m.RecordStorageError(operation)
+	}
+}
+
+// RegisterGaugeFunc adds a gauge whose samples are collected from fn on every scrape
+func (m *Metrics) RegisterGaugeFunc(name, help string, labels []string, fn GaugeFunc) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges = append(m.gauges, &gaugeFunc{name: name, help: help, labels: labels, fn: fn})
+}
+
+// RegisterProviderStatus exposes each rate-limited provider's status and minute-window usage
+func (m *Metrics) RegisterProviderStatus(manager *RateLimitManager) {
+	m.RegisterGaugeFunc("bmad_bot_ai_provider_status",
+		"Current rate limit status of each AI provider (1 = active status).",
+		[]string{"provider", "status"},
+		func() []GaugeSample {
+			var samples []GaugeSample
+			for _, providerID := range manager.ProviderIDs() {
+				current := manager.GetProviderStatus(providerID)
+				for _, status := range providerStatuses {
+					samples = append(samples, GaugeSample{
+						LabelValues: []string{providerID, status},
+						Value:       float64(boolToGauge(status == current)),
+					})
+				}
+			}
+			return samples
+		})
+
+	m.RegisterGaugeFunc("bmad_bot_ai_provider_usage",
+		"AI provider calls in the current minute window.",
+		[]string{"provider"},
+		func() []GaugeSample {
+			var samples []GaugeSample
+			for _, providerID := range manager.ProviderIDs() {
+				usage, _ := manager.GetProviderUsage(providerID)
+				samples = append(samples, GaugeSample{LabelValues: []string{providerID}, Value: float64(usage)})
+			}
+			return samples
+		})
+
+	m.RegisterGaugeFunc("bmad_bot_ai_provider_limit",
+		"AI provider call limit for the minute window.",
+		[]string{"provider"},
+		func() []GaugeSample {
+			var samples []GaugeSample
+			for _, providerID := range manager.ProviderIDs() {
+				_, limit := manager.GetProviderUsage(providerID)
+				samples = append(samples, GaugeSample{LabelValues: []string{providerID}, Value: float64(limit)})
+			}
+			return samples
+		})
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	if m == nil {
+		return
+	}
+
+	m.queries.write(w)
+	m.aiRequests.write(w)
+	m.aiDuration.write(w)
+	m.aiPromptBytes.write(w)
+	m.aiResponseBytes.write(w)
+	m.rateLimitDenials.write(w)
+	m.storageErrors.write(w)
+	m.cacheLookups.write(w)
+
+	m.mu.RLock()
+	gauges := append([]*gaugeFunc(nil), m.gauges...)
+	m.mu.RUnlock()
+
+	for _, gauge := range gauges {
+		gauge.write(w)
+	}
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// inc increments the counter for the given label values
+func (c *counterVec) inc(labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[seriesKey(labelValues)]++
+}
+
+// write renders the counter; counters without observations are omitted
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.values) == 0 {
+		return
+	}
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, splitSeriesKey(key), "", ""), formatValue(c.values[key]))
+	}
+}
+
+// histogramVec is a histogram with fixed buckets partitioned by label values
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// histogramSeries holds the per-bucket counts for one set of label values
+type histogramSeries struct {
+	bucketCounts []uint64 // Non-cumulative; accumulated when written
+	count        uint64
+	sum          float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+// observe adds a value to the histogram for the given label values
+func (h *histogramVec) observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(labelValues)
+	series, exists := h.series[key]
+	if !exists {
+		series = &histogramSeries{bucketCounts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+
+	series.count++
+	series.sum += value
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		series.bucketCounts[i]++
+	}
+}
+
+// write renders the histogram with cumulative buckets; histograms without observations are omitted
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.series) == 0 {
+		return
+	}
+
+	writeHeader(w, h.name, h.help, "histogram")
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		series := h.series[key]
+		labelValues := splitSeriesKey(key)
+
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += series.bucketCounts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, labelValues, "le", formatValue(upperBound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, labelValues, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, labelValues, "", ""), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, labelValues, "", ""), series.count)
+	}
+}
+
+// gaugeFunc is a gauge whose samples are collected at scrape time
+type gaugeFunc struct {
+	name   string
+	help   string
+	labels []string
+	fn     GaugeFunc
+}
+
+// write renders the gauge; gauges reporting no samples are omitted
+func (g *gaugeFunc) write(w io.Writer) {
+	samples := g.fn()
+	if len(samples) == 0 {
+		return
+	}
+
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, sample.LabelValues, "", ""), formatValue(sample.Value))
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines for a metric family
+func writeHeader(w io.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// formatLabels renders a label set, appending the extra label (e.g. a histogram's "le") when set
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+"="+quoteLabelValue(value))
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+"="+quoteLabelValue(extraValue))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// quoteLabelValue escapes a label value as required by the exposition format
+func quoteLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// formatValue renders a sample value using the shortest exact representation
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// seriesKeySeparator joins label values into a map key; it cannot appear in Discord IDs or metric labels
+const seriesKeySeparator = "\xff"
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, seriesKeySeparator)
+}
+
+func splitSeriesKey(key string) []string {
+	return strings.Split(key, seriesKeySeparator)
+}
+
+// sortedKeys returns the map keys in a stable order so scrapes are deterministic
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
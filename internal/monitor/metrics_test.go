@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape renders the metrics registry as a /metrics response body
+func scrape(metrics *Metrics) string {
+	var output strings.Builder
+	metrics.WritePrometheus(&output)
+	return output.String()
+}
+
+func expectMetricLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetrics_Counters(t *testing.T) {
+	metrics := NewMetrics()
+
+	// Families without observations are omitted entirely
+	if body := scrape(metrics); body != "" {
+		t.Errorf("Expected empty output before any observation, got:\n%s", body)
+	}
+
+	metrics.RecordQuery(TriggerMention)
+	metrics.RecordQuery(TriggerMention)
+	metrics.RecordQuery(TriggerDM)
+	metrics.RecordUserRateLimitDenial("hour")
+	metrics.ObserveStorageOperation("get_configuration", nil)
+	metrics.ObserveStorageOperation("upsert_user_rate_limit", errors.New("connection refused"))
+	metrics.RecordResponseCacheLookup(true)
+	metrics.RecordResponseCacheLookup(false)
+	metrics.RecordResponseCacheLookup(false)
+
+	expectMetricLines(t, scrape(metrics),
+		"# TYPE bmad_bot_queries_total counter",
+		`bmad_bot_queries_total{trigger="dm"} 1`,
+		`bmad_bot_queries_total{trigger="mention"} 2`,
+		`bmad_bot_user_rate_limit_denials_total{window="hour"} 1`,
+		`bmad_bot_storage_errors_total{operation="upsert_user_rate_limit"} 1`,
+		`bmad_bot_response_cache_lookups_total{outcome="hit"} 1`,
+		`bmad_bot_response_cache_lookups_total{outcome="miss"} 2`,
+	)
+	if strings.Contains(scrape(metrics), "get_configuration") {
+		t.Error("Expected successful storage operations not to be counted as errors")
+	}
+}
+
+func TestMetrics_AIRequestHistograms(t *testing.T) {
+	metrics := NewMetrics()
+
+	metrics.ObserveAIRequest("ollama", AIRequestModeStreaming, 3*time.Second, 2000, 500, nil)
+	metrics.ObserveAIRequest("ollama", AIRequestModeStreaming, 45*time.Second, 2000, 0, errors.New("timeout"))
+
+	expectMetricLines(t, scrape(metrics),
+		"# TYPE bmad_bot_ai_request_duration_seconds histogram",
+		`bmad_bot_ai_requests_total{provider="ollama",mode="streaming",outcome="error"} 1`,
+		`bmad_bot_ai_requests_total{provider="ollama",mode="streaming",outcome="success"} 1`,
+		`bmad_bot_ai_request_duration_seconds_bucket{provider="ollama",mode="streaming",le="2.5"} 0`,
+		`bmad_bot_ai_request_duration_seconds_bucket{provider="ollama",mode="streaming",le="5"} 1`,
+		`bmad_bot_ai_request_duration_seconds_bucket{provider="ollama",mode="streaming",le="60"} 2`,
+		`bmad_bot_ai_request_duration_seconds_bucket{provider="ollama",mode="streaming",le="+Inf"} 2`,
+		`bmad_bot_ai_request_duration_seconds_sum{provider="ollama",mode="streaming"} 48`,
+		`bmad_bot_ai_request_duration_seconds_count{provider="ollama",mode="streaming"} 2`,
+		`bmad_bot_ai_prompt_bytes_count{provider="ollama"} 2`,
+		// Failed requests have no response to measure
+		`bmad_bot_ai_response_bytes_count{provider="ollama"} 1`,
+	)
+}
+
+func TestMetrics_GaugeFuncs(t *testing.T) {
+	metrics := NewMetrics()
+	manager := NewRateLimitManager(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})), []ProviderConfig{
+		{ProviderID: "openai", Limits: map[string]int{"minute": 4}, Thresholds: map[string]float64{"warning": 0.5, "throttled": 1.0}},
+		{ProviderID: "ollama", Limits: map[string]int{"minute": 10}, Thresholds: map[string]float64{"warning": 0.5, "throttled": 1.0}},
+	})
+	metrics.RegisterProviderStatus(manager)
+	metrics.RegisterGaugeFunc("test_gauge", "Label values are escaped.", []string{"name"}, func() []GaugeSample {
+		return []GaugeSample{{LabelValues: []string{`say "hi"`}, Value: 0.75}}
+	})
+
+	manager.RegisterCall("openai")
+	manager.RegisterCall("openai")
+
+	expectMetricLines(t, scrape(metrics),
+		`bmad_bot_ai_provider_status{provider="ollama",status="Normal"} 1`,
+		`bmad_bot_ai_provider_status{provider="openai",status="Normal"} 0`,
+		`bmad_bot_ai_provider_status{provider="openai",status="Warning"} 1`,
+		`bmad_bot_ai_provider_usage{provider="openai"} 2`,
+		`bmad_bot_ai_provider_limit{provider="openai"} 4`,
+		`test_gauge{name="say \"hi\""} 0.75`,
+	)
+}
+
+func TestMetrics_NilIsNoop(t *testing.T) {
+	var metrics *Metrics
+
+	// Components instrumented without a registry must not panic
+	metrics.RecordQuery(TriggerForum)
+	metrics.ObserveAIRequest("ollama", AIRequestModeBlocking, time.Second, 1, 1, nil)
+	metrics.RecordUserRateLimitDenial("minute")
+	metrics.RecordResponseCacheLookup(true)
+	metrics.ObserveStorageOperation("health_check", errors.New("down"))
+	metrics.RegisterGaugeFunc("test_gauge", "", nil, func() []GaugeSample { return nil })
+
+	if body := scrape(metrics); body != "" {
+		t.Errorf("Expected no output from a nil registry, got %q", body)
+	}
+}
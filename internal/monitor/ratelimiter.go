@@ -1,7 +1,10 @@
 package monitor
 
 import (
+	"fmt"
 	"log/slog"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -143,6 +146,42 @@ func (rm *RateLimitManager) notifyStatusChange(providerID, newStatus string) {
 		"callback_count", len(rm.statusCallbacks))
 }
 
+// UpdateProviderConfig replaces a registered provider's limits and thresholds, keeping its recorded calls
+func (rm *RateLimitManager) UpdateProviderConfig(config ProviderConfig) error {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	provider, exists := rm.providers[config.ProviderID]
+	if !exists {
+		return fmt.Errorf("provider %s is not registered for rate limiting", config.ProviderID)
+	}
+
+	provider.Mutex.Lock()
+	defer provider.Mutex.Unlock()
+
+	if reflect.DeepEqual(provider.Limits, config.Limits) && reflect.DeepEqual(provider.Thresholds, config.Thresholds) {
+		return nil
+	}
+
+	provider.Limits = config.Limits
+	provider.Thresholds = config.Thresholds
+	for window := range config.Limits {
+		if _, tracked := provider.TimeWindows[window]; !tracked {
+			provider.TimeWindows[window] = make([]time.Time, 0)
+		}
+	}
+
+	rm.logger.Info("Provider rate limits updated",
+		"provider", config.ProviderID,
+		"limits", config.Limits,
+		"thresholds", config.Thresholds)
+
+	// New limits can move the provider between Normal, Warning and Throttled
+	rm.notifyStatusChange(config.ProviderID, rm.getProviderStatusLocked(provider))
+
+	return nil
+}
+
 // RegisterCall records an API call for the specified provider
 func (rm *RateLimitManager) RegisterCall(providerID string) error {
 	rm.mutex.Lock()
@@ -317,6 +356,19 @@ func (rm *RateLimitManager) GetProviderStatus(providerID string) string {
 	return rm.getProviderStatusLocked(provider)
 }
 
+// ProviderIDs returns the IDs of every rate-limited provider in sorted order
+func (rm *RateLimitManager) ProviderIDs() []string {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	ids := make([]string, 0, len(rm.providers))
+	for id := range rm.providers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // GetProviderState returns the complete state for a provider (for testing/debugging)
 func (rm *RateLimitManager) GetProviderState(providerID string) (*ProviderRateLimitState, bool) {
 	rm.mutex.RLock()
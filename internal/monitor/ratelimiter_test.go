@@ -818,3 +818,65 @@ func TestRateLimitManager_QuotaExhaustedUnknownProvider(t *testing.T) {
 
 	// Should complete without error
 }
+
+func TestRateLimitManager_UpdateProviderConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	config := ProviderConfig{
+		ProviderID: "test-provider",
+		Limits:     map[string]int{"minute": 4, "day": 100},
+		Thresholds: map[string]float64{"warning": 0.75, "throttled": 1.0},
+	}
+	manager := NewRateLimitManager(logger, []ProviderConfig{config})
+
+	statusChanges := make(chan string, 10)
+	manager.RegisterStatusCallback(func(providerID, status string) {
+		statusChanges <- status
+	})
+
+	for i := 0; i < 3; i++ {
+		manager.RegisterCall("test-provider")
+	}
+	if status := manager.GetProviderStatus("test-provider"); status != "Warning" {
+		t.Fatalf("Expected Warning status before update, got %s", status)
+	}
+
+	// Notifications are delivered asynchronously; consume the Warning one so it cannot be mistaken for the update's
+	select {
+	case status := <-statusChanges:
+		if status != "Warning" {
+			t.Fatalf("Expected Warning status notification, got %s", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a status notification when reaching the warning threshold")
+	}
+
+	// Raising the limit takes effect immediately without losing recorded calls
+	err := manager.UpdateProviderConfig(ProviderConfig{
+		ProviderID: "test-provider",
+		Limits:     map[string]int{"minute": 10, "day": 100},
+		Thresholds: map[string]float64{"warning": 0.75, "throttled": 1.0},
+	})
+	if err != nil {
+		t.Fatalf("UpdateProviderConfig failed: %v", err)
+	}
+	usage, limit := manager.GetProviderUsage("test-provider")
+	if usage != 3 || limit != 10 {
+		t.Errorf("Expected usage 3 of 10 after update, got %d of %d", usage, limit)
+	}
+	if status := manager.GetProviderStatus("test-provider"); status != "Normal" {
+		t.Errorf("Expected Normal status after update, got %s", status)
+	}
+
+	select {
+	case status := <-statusChanges:
+		if status != "Normal" {
+			t.Errorf("Expected Normal status notification, got %s", status)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a status notification after the limit change")
+	}
+
+	if err := manager.UpdateProviderConfig(ProviderConfig{ProviderID: "unknown"}); err == nil {
+		t.Error("Expected an error for an unregistered provider")
+	}
+}
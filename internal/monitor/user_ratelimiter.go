@@ -4,17 +4,28 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"bmad-knowledge-bot/internal/config"
 	"bmad-knowledge-bot/internal/storage"
 )
 
+// minRequestInterval is the shortest gap between a user's requests before they are treated as abuse
+const minRequestInterval = time.Second
+
 // UserRateLimiter handles user-specific rate limiting
 type UserRateLimiter struct {
-	storage      storage.StorageService
-	logger       *slog.Logger
-	limitsConfig map[string]int // time window -> limit
+	storage       storage.StorageService
+	configService config.ConfigService // Resolves guild overrides through its cache when set
+	logger        *slog.Logger
+	mutex         sync.RWMutex
+	limitsConfig  map[string]int       // time window -> limit
+	enabled       bool                 // RATE_LIMITING_ENABLED
+	bypassUntil   time.Time            // Emergency bypass expiry (zero = no bypass)
+	lastRequests  map[string]time.Time // userID -> last recorded request time
 }
 
 // RateLimitResult represents the result of a rate limit check
@@ -57,33 +68,204 @@ func NewUserRateLimiter(storage storage.StorageService, logger *slog.Logger) *Us
 		storage:      storage,
 		logger:       logger,
 		limitsConfig: defaultLimits,
+		enabled:      true,
+		lastRequests: make(map[string]time.Time),
 	}
 }
 
 // UpdateLimits updates the rate limiting configuration
 func (url *UserRateLimiter) UpdateLimits(minuteLimit, hourLimit, dayLimit int) {
+	url.mutex.Lock()
 	url.limitsConfig = map[string]int{
 		"minute": minuteLimit,
 		"hour":   hourLimit,
 		"day":    dayLimit,
 	}
+	url.mutex.Unlock()
+
 	url.logger.Info("Updated user rate limits",
 		"minute_limit", minuteLimit,
 		"hour_limit", hourLimit,
 		"day_limit", dayLimit)
 }
 
+// SetEnabled toggles user rate limiting (RATE_LIMITING_ENABLED)
+func (url *UserRateLimiter) SetEnabled(enabled bool) {
+	url.mutex.Lock()
+	url.enabled = enabled
+	url.mutex.Unlock()
+
+	url.logger.Info("User rate limiting enabled state updated", "enabled", enabled)
+}
+
+// IsEnabled reports whether limits are currently enforced (disabled flag or active emergency bypass return false)
+func (url *UserRateLimiter) IsEnabled() bool {
+	url.mutex.RLock()
+	defer url.mutex.RUnlock()
+	return url.enabled && !time.Now().Before(url.bypassUntil)
+}
+
+// ApplyConfiguration applies USER_RATE_LIMIT_* and RATE_LIMITING_ENABLED values from a configuration snapshot.
+// Its signature matches config.ServiceConfigListener.OnReload so it can be registered directly.
+func (url *UserRateLimiter) ApplyConfiguration(configs map[string]string) error {
+	url.mutex.RLock()
+	minuteLimit := url.limitsConfig["minute"]
+	hourLimit := url.limitsConfig["hour"]
+	dayLimit := url.limitsConfig["day"]
+	enabled := url.enabled
+	url.mutex.RUnlock()
+
+	// Missing keys keep their current values
+	limitKeys := []struct {
+		key    string
+		target *int
+	}{
+		{"USER_RATE_LIMIT_PER_MINUTE", &minuteLimit},
+		{"USER_RATE_LIMIT_PER_HOUR", &hourLimit},
+		{"USER_RATE_LIMIT_PER_DAY", &dayLimit},
+	}
+	for _, limitKey := range limitKeys {
+		value, exists := configs[limitKey.key]
+		if !exists || strings.TrimSpace(value) == "" {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", limitKey.key, value, err)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("%s must be positive: %d", limitKey.key, limit)
+		}
+		*limitKey.target = limit
+	}
+
+	if value, exists := configs["RATE_LIMITING_ENABLED"]; exists && strings.TrimSpace(value) != "" {
+		parsed, err := config.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid RATE_LIMITING_ENABLED value %q: %w", value, err)
+		}
+		enabled = parsed
+	}
+
+	if err := url.ValidateRateLimitConfiguration(minuteLimit, hourLimit, dayLimit); err != nil {
+		return fmt.Errorf("invalid user rate limit configuration: %w", err)
+	}
+
+	url.mutex.RLock()
+	limitsChanged := minuteLimit != url.limitsConfig["minute"] ||
+		hourLimit != url.limitsConfig["hour"] ||
+		dayLimit != url.limitsConfig["day"]
+	enabledChanged := enabled != url.enabled
+	url.mutex.RUnlock()
+
+	if limitsChanged {
+		url.UpdateLimits(minuteLimit, hourLimit, dayLimit)
+	}
+	if enabledChanged {
+		url.SetEnabled(enabled)
+	}
+
+	return nil
+}
+
+// getLimit returns the configured limit for a time window
+func (url *UserRateLimiter) getLimit(timeWindow string) (int, bool) {
+	url.mutex.RLock()
+	defer url.mutex.RUnlock()
+	limit, exists := url.limitsConfig[timeWindow]
+	return limit, exists
+}
+
+// SetConfigService resolves guild overrides through the config service's cache instead of reading storage per request
+func (url *UserRateLimiter) SetConfigService(configService config.ConfigService) {
+	url.configService = configService
+}
+
+// guildOverride returns guildID's override for key, if it has one
+func (url *UserRateLimiter) guildOverride(ctx context.Context, guildID, key string) (string, bool) {
+	if url.configService != nil {
+		overrides, err := url.configService.GetGuildConfigs(ctx, guildID)
+		if err != nil {
+			return "", false
+		}
+		value, ok := overrides[key]
+		return value, ok
+	}
+
+	override, err := url.storage.GetGuildConfiguration(ctx, guildID, key)
+	if err != nil || override == nil {
+		return "", false
+	}
+	return override.Value, true
+}
+
+// getGuildLimit returns guildID's USER_RATE_LIMIT_PER_* override for a time window, falling back to the global limit
+func (url *UserRateLimiter) getGuildLimit(ctx context.Context, guildID, timeWindow string) (int, bool) {
+	limit, exists := url.getLimit(timeWindow)
+	if !exists || guildID == "" {
+		return limit, exists
+	}
+
+	key := "USER_RATE_LIMIT_PER_" + strings.ToUpper(timeWindow)
+	override, ok := url.guildOverride(ctx, guildID, key)
+	if !ok {
+		return limit, exists
+	}
+	guildLimit, err := strconv.Atoi(strings.TrimSpace(override))
+	if err != nil || guildLimit <= 0 {
+		url.logger.Warn("Ignoring invalid guild rate limit override", "guild_id", guildID, "key", key, "value", override)
+		return limit, exists
+	}
+	return guildLimit, true
+}
+
+// IsEnabledForGuild reports whether limits are enforced in guildID, honoring its RATE_LIMITING_ENABLED override.
+// An active emergency bypass disables limits everywhere.
+func (url *UserRateLimiter) IsEnabledForGuild(ctx context.Context, guildID string) bool {
+	url.mutex.RLock()
+	enabled := url.enabled
+	bypassed := time.Now().Before(url.bypassUntil)
+	url.mutex.RUnlock()
+
+	if bypassed {
+		return false
+	}
+	if guildID == "" {
+		return enabled
+	}
+
+	override, ok := url.guildOverride(ctx, guildID, "RATE_LIMITING_ENABLED")
+	if !ok {
+		return enabled
+	}
+	guildEnabled, err := config.ParseBool(override)
+	if err != nil {
+		url.logger.Warn("Ignoring invalid guild override", "guild_id", guildID, "key", "RATE_LIMITING_ENABLED", "value", override)
+		return enabled
+	}
+	return guildEnabled
+}
+
 // CheckUserRateLimit checks if a user is within rate limits for all time windows
 func (url *UserRateLimiter) CheckUserRateLimit(ctx context.Context, userID string, guildID string) (*RateLimitResult, error) {
 	// Note: Admin bypass check is deferred to the calling code (handler)
 	// since it requires Discord session access for role checking
 	// The calling code should use CheckUserAdminByRoles if admin bypass is needed
 
+	// Rate limiting disabled by configuration or emergency bypass
+	if !url.IsEnabledForGuild(ctx, guildID) {
+		return &RateLimitResult{
+			Allowed:           true,
+			Reason:            "rate_limiting_disabled",
+			NextAvailableTime: time.Now(),
+		}, nil
+	}
+
 	// Check rate limits for each time window
 	timeWindows := []string{"minute", "hour", "day"}
 
 	for _, window := range timeWindows {
-		result, err := url.checkWindowRateLimit(ctx, userID, window)
+		result, err := url.checkWindowRateLimit(ctx, userID, guildID, window)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check %s rate limit: %w", window, err)
 		}
@@ -107,9 +289,9 @@ func (url *UserRateLimiter) CheckUserRateLimit(ctx context.Context, userID strin
 	}, nil
 }
 
-// checkWindowRateLimit checks rate limit for a specific time window
-func (url *UserRateLimiter) checkWindowRateLimit(ctx context.Context, userID string, timeWindow string) (*RateLimitResult, error) {
-	limit, exists := url.limitsConfig[timeWindow]
+// checkWindowRateLimit checks rate limit for a specific time window using guildID's limits
+func (url *UserRateLimiter) checkWindowRateLimit(ctx context.Context, userID, guildID, timeWindow string) (*RateLimitResult, error) {
+	limit, exists := url.getGuildLimit(ctx, guildID, timeWindow)
 	if !exists {
 		return nil, fmt.Errorf("unknown time window: %s", timeWindow)
 	}
@@ -180,10 +362,53 @@ func (url *UserRateLimiter) RecordUserRequest(ctx context.Context, userID string
 		}
 	}
 
+	url.mutex.Lock()
+	// Only requests within the rapid request interval matter, so older entries are dropped to keep the map bounded
+	for id, last := range url.lastRequests {
+		if now.Sub(last) >= minRequestInterval {
+			delete(url.lastRequests, id)
+		}
+	}
+	url.lastRequests[userID] = now
+	url.mutex.Unlock()
+
 	url.logger.Debug("Recorded user request", "user_id", userID, "timestamp", now.Unix())
 	return nil
 }
 
+// ForgetUserRequest takes back a request recorded at requestTime, for work that was turned away before it ran
+func (url *UserRateLimiter) ForgetUserRequest(ctx context.Context, userID string, requestTime time.Time) error {
+	for _, window := range []string{"minute", "hour", "day"} {
+		rateLimit, err := url.storage.GetUserRateLimit(ctx, userID, window)
+		if err != nil {
+			return fmt.Errorf("failed to get rate limit for %s window: %w", window, err)
+		}
+		// A request from an earlier window no longer counts against the current one
+		if rateLimit == nil || rateLimit.RequestCount == 0 || rateLimit.WindowStartTime != url.getWindowStart(requestTime, window).Unix() {
+			continue
+		}
+		rateLimit.RequestCount--
+		if err := url.storage.UpsertUserRateLimit(ctx, rateLimit); err != nil {
+			return fmt.Errorf("failed to forget request for %s window: %w", window, err)
+		}
+	}
+
+	// The user was told to try again, so a prompt retry must not be dropped as rapid-fire
+	url.mutex.Lock()
+	delete(url.lastRequests, userID)
+	url.mutex.Unlock()
+
+	url.logger.Debug("Forgot user request", "user_id", userID, "timestamp", requestTime.Unix())
+	return nil
+}
+
+// GetLastRequestTime returns the time of the user's last recorded request (zero if none)
+func (url *UserRateLimiter) GetLastRequestTime(userID string) time.Time {
+	url.mutex.RLock()
+	defer url.mutex.RUnlock()
+	return url.lastRequests[userID]
+}
+
 // recordRequestForWindow records a request for a specific time window
 func (url *UserRateLimiter) recordRequestForWindow(ctx context.Context, userID string, timeWindow string, requestTime time.Time) error {
 	windowStart := url.getWindowStart(requestTime, timeWindow)
@@ -224,12 +449,17 @@ func (url *UserRateLimiter) IsUserAdmin(ctx context.Context, userID string, guil
 		}
 		return false, fmt.Errorf("failed to get admin role configuration: %w", err)
 	}
+	if adminRolesConfig == nil {
+		return false, nil
+	}
 
 	// Parse admin role names (comma-separated)
 	adminRoleNames := []string{}
 	if adminRolesConfig.Value != "" {
 		for _, roleName := range strings.Split(adminRolesConfig.Value, ",") {
-			adminRoleNames = append(adminRoleNames, strings.TrimSpace(roleName))
+			if trimmed := strings.TrimSpace(roleName); trimmed != "" {
+				adminRoleNames = append(adminRoleNames, trimmed)
+			}
 		}
 	}
 
@@ -263,12 +493,17 @@ func (url *UserRateLimiter) CheckUserAdminByRoles(ctx context.Context, userRoles
 		}
 		return false, fmt.Errorf("failed to get admin role configuration: %w", err)
 	}
+	if adminRolesConfig == nil {
+		return false, nil
+	}
 
 	// Parse admin role names (comma-separated)
 	adminRoleNames := []string{}
 	if adminRolesConfig.Value != "" {
 		for _, roleName := range strings.Split(adminRolesConfig.Value, ",") {
-			adminRoleNames = append(adminRoleNames, strings.TrimSpace(roleName))
+			if trimmed := strings.TrimSpace(roleName); trimmed != "" {
+				adminRoleNames = append(adminRoleNames, trimmed)
+			}
 		}
 	}
 
@@ -299,17 +534,20 @@ func (url *UserRateLimiter) CheckUserAdminByRoles(ctx context.Context, userRoles
 
 // GetUserRateLimitStatus retrieves comprehensive rate limit status for a user
 func (url *UserRateLimiter) GetUserRateLimitStatus(ctx context.Context, userID string) (*UserRateLimitStatus, error) {
+	return url.GetUserRateLimitStatusForGuild(ctx, userID, "")
+}
+
+// GetUserRateLimitStatusForGuild retrieves a user's rate limit status against guildID's limits
+func (url *UserRateLimiter) GetUserRateLimitStatusForGuild(ctx context.Context, userID, guildID string) (*UserRateLimitStatus, error) {
 	rateLimits, err := url.storage.GetUserRateLimitsByUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user rate limits: %w", err)
 	}
 
-	status := &UserRateLimitStatus{
-		UserID:      userID,
-		MinuteLimit: url.limitsConfig["minute"],
-		HourLimit:   url.limitsConfig["hour"],
-		DayLimit:    url.limitsConfig["day"],
-	}
+	status := &UserRateLimitStatus{UserID: userID}
+	status.MinuteLimit, _ = url.getGuildLimit(ctx, guildID, "minute")
+	status.HourLimit, _ = url.getGuildLimit(ctx, guildID, "hour")
+	status.DayLimit, _ = url.getGuildLimit(ctx, guildID, "day")
 
 	now := time.Now()
 
@@ -372,8 +610,6 @@ func (url *UserRateLimiter) PreventRapidSuccessiveRequests(ctx context.Context,
 	timeSinceLastRequest := now.Sub(lastRequestTime)
 
 	// Prevent requests faster than 1 per second as potential abuse
-	minRequestInterval := time.Second
-
 	if timeSinceLastRequest < minRequestInterval {
 		url.logger.Warn("Rapid successive requests detected",
 			"user_id", userID,
@@ -403,21 +639,23 @@ func (url *UserRateLimiter) GetRateLimitingStatistics(ctx context.Context) (*Rat
 
 // EnableEmergencyBypass temporarily disables rate limiting for maintenance
 func (url *UserRateLimiter) EnableEmergencyBypass(duration time.Duration) {
+	url.mutex.Lock()
+	url.bypassUntil = time.Now().Add(duration)
+	url.mutex.Unlock()
+
 	url.logger.Warn("Emergency rate limiting bypass enabled",
 		"duration", duration,
 		"enabled_at", time.Now())
-
-	// In a real implementation, this would set a flag that CheckUserRateLimit
-	// would check before applying limits
-	// For now, we just log the event
 }
 
 // DisableEmergencyBypass re-enables normal rate limiting
 func (url *UserRateLimiter) DisableEmergencyBypass() {
+	url.mutex.Lock()
+	url.bypassUntil = time.Time{}
+	url.mutex.Unlock()
+
 	url.logger.Info("Emergency rate limiting bypass disabled",
 		"disabled_at", time.Now())
-
-	// In a real implementation, this would clear the emergency bypass flag
 }
 
 // ValidateRateLimitConfiguration validates rate limit settings for security
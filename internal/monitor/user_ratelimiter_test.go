@@ -2,124 +2,21 @@ package monitor
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"bmad-knowledge-bot/internal/config"
 	"bmad-knowledge-bot/internal/storage"
 )
 
-// mockStorageService implements storage.StorageService for testing
-type mockStorageService struct {
-	rateLimits     map[string]*storage.UserRateLimit
-	configurations map[string]*storage.Configuration
-}
-
-func newMockStorageService() *mockStorageService {
-	return &mockStorageService{
-		rateLimits:     make(map[string]*storage.UserRateLimit),
-		configurations: make(map[string]*storage.Configuration),
-	}
-}
-
-func (m *mockStorageService) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*storage.UserRateLimit, error) {
-	key := userID + "_" + timeWindow
-	rateLimit, exists := m.rateLimits[key]
-	if !exists {
-		return nil, nil // No rate limit record found
-	}
-	return rateLimit, nil
-}
-
-func (m *mockStorageService) UpsertUserRateLimit(ctx context.Context, rateLimit *storage.UserRateLimit) error {
-	key := rateLimit.UserID + "_" + rateLimit.TimeWindow
-	m.rateLimits[key] = rateLimit
-	return nil
-}
-
-func (m *mockStorageService) GetConfiguration(ctx context.Context, key string) (*storage.Configuration, error) {
-	config, exists := m.configurations[key]
-	if !exists {
-		return nil, fmt.Errorf("configuration not found")
-	}
-	return config, nil
-}
-
-func (m *mockStorageService) UpsertConfiguration(ctx context.Context, config *storage.Configuration) error {
-	m.configurations[config.Key] = config
-	return nil
-}
-
-// Implement required interface methods (simplified for testing)
-func (m *mockStorageService) Initialize(ctx context.Context) error { return nil }
-func (m *mockStorageService) Close() error                         { return nil }
-func (m *mockStorageService) GetMessageState(ctx context.Context, channelID string, threadID *string) (*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageService) UpsertMessageState(ctx context.Context, state *storage.MessageState) error {
-	return nil
-}
-func (m *mockStorageService) GetAllMessageStates(ctx context.Context) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageService) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*storage.MessageState, error) {
-	return nil, nil
-}
-func (m *mockStorageService) HealthCheck(ctx context.Context) error { return nil }
-func (m *mockStorageService) GetThreadOwnership(ctx context.Context, threadID string) (*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *mockStorageService) UpsertThreadOwnership(ctx context.Context, ownership *storage.ThreadOwnership) error {
-	return nil
-}
-func (m *mockStorageService) GetAllThreadOwnerships(ctx context.Context) ([]*storage.ThreadOwnership, error) {
-	return nil, nil
-}
-func (m *mockStorageService) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
-	return nil
-}
-func (m *mockStorageService) GetConfigurationsByCategory(ctx context.Context, category string) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *mockStorageService) GetAllConfigurations(ctx context.Context) ([]*storage.Configuration, error) {
-	return nil, nil
-}
-func (m *mockStorageService) DeleteConfiguration(ctx context.Context, key string) error { return nil }
-func (m *mockStorageService) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *mockStorageService) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
-	return nil
-}
-func (m *mockStorageService) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
-	return nil
-}
-func (m *mockStorageService) GetAllStatusMessages(ctx context.Context) ([]*storage.StatusMessage, error) {
-	return nil, nil
-}
-func (m *mockStorageService) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
-	return 0, nil
-}
-func (m *mockStorageService) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
-	return nil
-}
-func (m *mockStorageService) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*storage.UserRateLimit, error) {
-	return nil, nil
-}
-func (m *mockStorageService) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
-	key := userID + "_" + timeWindow
-	delete(m.rateLimits, key)
-	return nil
-}
-
 func TestNewUserRateLimiter(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	if rateLimiter == nil {
 		t.Fatal("Expected UserRateLimiter to be created")
@@ -131,9 +28,9 @@ func TestNewUserRateLimiter(t *testing.T) {
 }
 
 func TestUpdateLimits(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	rateLimiter.UpdateLimits(10, 60, 200)
 
@@ -149,9 +46,9 @@ func TestUpdateLimits(t *testing.T) {
 }
 
 func TestCheckUserRateLimit_NewUser(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	ctx := context.Background()
 	result, err := rateLimiter.CheckUserRateLimit(ctx, "user123", "guild456")
@@ -170,9 +67,9 @@ func TestCheckUserRateLimit_NewUser(t *testing.T) {
 }
 
 func TestRecordUserRequest(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	ctx := context.Background()
 	userID := "user123"
@@ -183,7 +80,7 @@ func TestRecordUserRequest(t *testing.T) {
 	}
 
 	// Check that rate limit was recorded for all windows
-	minuteLimit, err := storage.GetUserRateLimit(ctx, userID, "minute")
+	minuteLimit, err := memoryStorage.GetUserRateLimit(ctx, userID, "minute")
 	if err != nil {
 		t.Fatalf("Error getting minute rate limit: %v", err)
 	}
@@ -194,10 +91,45 @@ func TestRecordUserRequest(t *testing.T) {
 	}
 }
 
+func TestForgetUserRequest(t *testing.T) {
+	memoryStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
+
+	ctx := context.Background()
+	userID := "user123"
+
+	if err := rateLimiter.RecordUserRequest(ctx, userID); err != nil {
+		t.Fatalf("Unexpected error recording request: %v", err)
+	}
+	requestTime := time.Now()
+	if err := rateLimiter.RecordUserRequest(ctx, userID); err != nil {
+		t.Fatalf("Unexpected error recording request: %v", err)
+	}
+
+	if err := rateLimiter.ForgetUserRequest(ctx, userID, requestTime); err != nil {
+		t.Fatalf("Unexpected error forgetting request: %v", err)
+	}
+
+	for _, window := range []string{"minute", "hour", "day"} {
+		rateLimit, err := memoryStorage.GetUserRateLimit(ctx, userID, window)
+		if err != nil {
+			t.Fatalf("Error getting %s rate limit: %v", window, err)
+		}
+		if rateLimit == nil || rateLimit.RequestCount != 1 {
+			t.Errorf("Expected %s request count to be 1 after forgetting a request, got %+v", window, rateLimit)
+		}
+	}
+
+	if !rateLimiter.GetLastRequestTime(userID).IsZero() {
+		t.Error("Expected a forgotten request not to block a prompt retry")
+	}
+}
+
 func TestCheckUserRateLimit_ExceedsLimit(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 	rateLimiter.UpdateLimits(2, 10, 50) // Low limits for testing
 
 	ctx := context.Background()
@@ -227,9 +159,9 @@ func TestCheckUserRateLimit_ExceedsLimit(t *testing.T) {
 }
 
 func TestCheckUserAdminByRoles_NoAdminConfig(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	ctx := context.Background()
 	userRoles := []string{"role1", "role2"}
@@ -249,7 +181,7 @@ func TestCheckUserAdminByRoles_NoAdminConfig(t *testing.T) {
 }
 
 func TestCheckUserAdminByRoles_WithAdminRole(t *testing.T) {
-	mockStorage := newMockStorageService()
+	mockStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	rateLimiter := NewUserRateLimiter(mockStorage, logger)
 
@@ -284,9 +216,9 @@ func TestCheckUserAdminByRoles_WithAdminRole(t *testing.T) {
 }
 
 func TestResetUserRateLimit(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	ctx := context.Background()
 	userID := "user123"
@@ -295,7 +227,7 @@ func TestResetUserRateLimit(t *testing.T) {
 	rateLimiter.RecordUserRequest(ctx, userID)
 
 	// Verify rate limit exists
-	minuteLimit, _ := storage.GetUserRateLimit(ctx, userID, "minute")
+	minuteLimit, _ := memoryStorage.GetUserRateLimit(ctx, userID, "minute")
 	if minuteLimit == nil {
 		t.Fatal("Expected rate limit to exist before reset")
 	}
@@ -307,16 +239,16 @@ func TestResetUserRateLimit(t *testing.T) {
 	}
 
 	// Verify rate limit was reset
-	minuteLimit, _ = storage.GetUserRateLimit(ctx, userID, "minute")
+	minuteLimit, _ = memoryStorage.GetUserRateLimit(ctx, userID, "minute")
 	if minuteLimit != nil {
 		t.Error("Expected rate limit to be reset")
 	}
 }
 
 func TestFormatRateLimitMessage(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	// Test minute window
 	msg := rateLimiter.formatRateLimitMessage("minute", 5, 5, 30*time.Second)
@@ -338,9 +270,9 @@ func TestFormatRateLimitMessage(t *testing.T) {
 }
 
 func TestGetWindowDuration(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	if rateLimiter.getWindowDuration("minute") != time.Minute {
 		t.Error("Expected minute duration to be 1 minute")
@@ -354,9 +286,9 @@ func TestGetWindowDuration(t *testing.T) {
 }
 
 func TestGetWindowStart(t *testing.T) {
-	storage := newMockStorageService()
+	memoryStorage := storage.NewMemoryStorageService()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	rateLimiter := NewUserRateLimiter(storage, logger)
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
 
 	testTime := time.Date(2023, 12, 15, 14, 35, 42, 0, time.UTC)
 
@@ -381,3 +313,213 @@ func TestGetWindowStart(t *testing.T) {
 		t.Errorf("Expected day start %v, got %v", expectedDay, dayStart)
 	}
 }
+
+func TestCheckUserAdminByRoles_MissingConfigReturnsNil(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// Storage returns (nil, nil) for missing configuration keys, as MySQLStorageService does
+	rateLimiter := NewUserRateLimiter(storage.NewMemoryStorageService(), logger)
+
+	ctx := context.Background()
+	isAdmin, err := rateLimiter.CheckUserAdminByRoles(ctx, []string{"role1"}, map[string]string{"role1": "admin"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if isAdmin {
+		t.Error("Expected user to not be admin when ADMIN_ROLE_NAMES is missing")
+	}
+
+	isAdmin, err = rateLimiter.IsUserAdmin(ctx, "user123", "guild456")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if isAdmin {
+		t.Error("Expected IsUserAdmin to return false when ADMIN_ROLE_NAMES is missing")
+	}
+}
+
+func TestCheckUserRateLimit_Disabled(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(mockStorage, logger)
+	rateLimiter.UpdateLimits(1, 10, 100)
+
+	ctx := context.Background()
+	if err := rateLimiter.RecordUserRequest(ctx, "user123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := rateLimiter.CheckUserRateLimit(ctx, "user123", "guild456")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Fatal("Expected request to be blocked once the minute limit is used")
+	}
+
+	rateLimiter.SetEnabled(false)
+	result, err = rateLimiter.CheckUserRateLimit(ctx, "user123", "guild456")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Allowed {
+		t.Error("Expected request to be allowed when rate limiting is disabled")
+	}
+
+	rateLimiter.SetEnabled(true)
+	rateLimiter.EnableEmergencyBypass(time.Minute)
+	if rateLimiter.IsEnabled() {
+		t.Error("Expected rate limiting to be suspended during emergency bypass")
+	}
+	rateLimiter.DisableEmergencyBypass()
+	if !rateLimiter.IsEnabled() {
+		t.Error("Expected rate limiting to be enforced after emergency bypass is disabled")
+	}
+}
+
+func TestUserRateLimiter_GuildOverrides(t *testing.T) {
+	mockStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(mockStorage, logger)
+	rateLimiter.UpdateLimits(1, 10, 100)
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	for key, value := range map[string]string{"USER_RATE_LIMIT_PER_MINUTE": "3", "RATE_LIMITING_ENABLED": "true"} {
+		mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "relaxed", Key: key, Value: value, Type: "string", Category: "rate_limiting", CreatedAt: now, UpdatedAt: now})
+	}
+	mockStorage.UpsertConfiguration(ctx, &storage.Configuration{GuildID: "unlimited", Key: "RATE_LIMITING_ENABLED", Value: "false", Type: "bool", Category: "rate_limiting", CreatedAt: now, UpdatedAt: now})
+
+	if err := rateLimiter.RecordUserRequest(ctx, "user123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		guildID string
+		allowed bool
+	}{
+		{"guild456", false},
+		{"relaxed", true},
+		{"unlimited", true},
+	}
+	for _, tc := range testCases {
+		result, err := rateLimiter.CheckUserRateLimit(ctx, "user123", tc.guildID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Allowed != tc.allowed {
+			t.Errorf("guild %s: expected allowed=%v, got %v (%s)", tc.guildID, tc.allowed, result.Allowed, result.Reason)
+		}
+	}
+
+	if rateLimiter.IsEnabledForGuild(ctx, "unlimited") {
+		t.Error("Expected guild override to disable rate limiting")
+	}
+	if !rateLimiter.IsEnabledForGuild(ctx, "guild456") {
+		t.Error("Expected guild without overrides to use the global setting")
+	}
+
+	status, err := rateLimiter.GetUserRateLimitStatusForGuild(ctx, "user123", "relaxed")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.MinuteLimit != 3 || status.HourLimit != 10 {
+		t.Errorf("Expected guild minute limit 3 and global hour limit 10, got %d and %d", status.MinuteLimit, status.HourLimit)
+	}
+}
+
+func TestUserRateLimiter_GuildOverridesThroughConfigService(t *testing.T) {
+	memoryStorage := storage.NewMemoryStorageService()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(memoryStorage, logger)
+	configService := config.NewDatabaseConfigService(memoryStorage)
+	rateLimiter.SetConfigService(configService)
+
+	ctx := context.Background()
+	if err := configService.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	// Any boolean spelling accepted when setting an override is honored
+	if err := configService.SetGuildConfig(ctx, "unlimited", "RATE_LIMITING_ENABLED", "off"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+	if err := configService.SetGuildConfig(ctx, "relaxed", "USER_RATE_LIMIT_PER_MINUTE", "30"); err != nil {
+		t.Fatalf("SetGuildConfig failed: %v", err)
+	}
+
+	if rateLimiter.IsEnabledForGuild(ctx, "unlimited") {
+		t.Error("Expected the 'off' guild override to disable rate limiting")
+	}
+	if limit, _ := rateLimiter.getGuildLimit(ctx, "relaxed", "minute"); limit != 30 {
+		t.Errorf("Expected guild minute limit 30, got %d", limit)
+	}
+}
+
+func TestApplyConfiguration(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(storage.NewMemoryStorageService(), logger)
+
+	err := rateLimiter.ApplyConfiguration(map[string]string{
+		"USER_RATE_LIMIT_PER_MINUTE": "10",
+		"USER_RATE_LIMIT_PER_HOUR":   "120",
+		"RATE_LIMITING_ENABLED":      "false",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if rateLimiter.limitsConfig["minute"] != 10 {
+		t.Errorf("Expected minute limit to be 10, got %d", rateLimiter.limitsConfig["minute"])
+	}
+	if rateLimiter.limitsConfig["hour"] != 120 {
+		t.Errorf("Expected hour limit to be 120, got %d", rateLimiter.limitsConfig["hour"])
+	}
+	if rateLimiter.limitsConfig["day"] != 100 {
+		t.Errorf("Expected day limit to keep its default of 100, got %d", rateLimiter.limitsConfig["day"])
+	}
+	if rateLimiter.IsEnabled() {
+		t.Error("Expected rate limiting to be disabled")
+	}
+
+	// Invalid values are rejected and leave the current limits untouched
+	if err := rateLimiter.ApplyConfiguration(map[string]string{"USER_RATE_LIMIT_PER_MINUTE": "-1"}); err == nil {
+		t.Error("Expected error for negative minute limit")
+	}
+	if err := rateLimiter.ApplyConfiguration(map[string]string{"USER_RATE_LIMIT_PER_DAY": "abc"}); err == nil {
+		t.Error("Expected error for non-numeric day limit")
+	}
+	if rateLimiter.limitsConfig["minute"] != 10 {
+		t.Errorf("Expected minute limit to remain 10, got %d", rateLimiter.limitsConfig["minute"])
+	}
+}
+
+func TestGetLastRequestTime(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(storage.NewMemoryStorageService(), logger)
+
+	if !rateLimiter.GetLastRequestTime("user123").IsZero() {
+		t.Error("Expected zero last request time for unknown user")
+	}
+
+	if err := rateLimiter.RecordUserRequest(context.Background(), "user123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if time.Since(rateLimiter.GetLastRequestTime("user123")) > time.Second {
+		t.Error("Expected last request time to be recorded")
+	}
+}
+
+func TestRecordUserRequest_PrunesLastRequests(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	rateLimiter := NewUserRateLimiter(storage.NewMemoryStorageService(), logger)
+	rateLimiter.lastRequests["idle-user"] = time.Now().Add(-time.Minute)
+
+	if err := rateLimiter.RecordUserRequest(context.Background(), "user123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !rateLimiter.GetLastRequestTime("idle-user").IsZero() {
+		t.Error("Expected requests outside the rapid request interval to be pruned")
+	}
+	if len(rateLimiter.lastRequests) != 1 {
+		t.Errorf("Expected only the recent request to be kept, got %d", len(rateLimiter.lastRequests))
+	}
+}
@@ -1,29 +1,165 @@
 package service
 
+import (
+	"context"
+	"sync"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
 // AIService defines the interface for AI interaction services
-// This interface must be used for all business logic interacting with AI models
+// This interface must be used for all business logic interacting with AI models.
+// Every call stops generating and returns the context's error once ctx is cancelled.
 type AIService interface {
 	// QueryAI sends a query to the AI service and returns the response
 	// Following coding standards: all AI business logic must use this interface
-	QueryAI(query string) (string, error)
+	QueryAI(ctx context.Context, query string) (string, error)
 
 	// QueryAIWithSummary sends a query and returns both response and extracted summary
 	// Returns (response, summary, error) with integrated summarization to reduce API calls
-	QueryAIWithSummary(query string) (string, string, error)
+	QueryAIWithSummary(ctx context.Context, query string) (string, string, error)
 
 	// SummarizeQuery creates a summarized version of a user query suitable for Discord thread titles
 	// Returns a summary limited to 100 characters for Discord thread title requirements
-	SummarizeQuery(query string) (string, error)
+	SummarizeQuery(ctx context.Context, query string) (string, error)
 
 	// QueryWithContext sends a query with conversation history context to the AI service
 	// conversationHistory should be formatted as a string containing previous messages
-	QueryWithContext(query string, conversationHistory string) (string, error)
+	QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error)
 
 	// SummarizeConversation creates a summary of conversation history for context preservation
 	// Returns a summarized version that fits within reasonable token limits
-	SummarizeConversation(messages []string) (string, error)
+	SummarizeConversation(ctx context.Context, messages []string) (string, error)
 
 	// GetProviderID returns the unique identifier for this AI provider
 	// Used for provider-specific rate limiting and monitoring
 	GetProviderID() string
 }
+
+// Chat message roles used in structured conversation history
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage is a single role-tagged message of conversation history
+type ChatMessage struct {
+	Role    string // ChatRoleUser or ChatRoleAssistant
+	Content string
+}
+
+// ChatAIService is implemented by AI services that accept conversation history as role-tagged messages
+type ChatAIService interface {
+	AIService
+
+	// QueryWithMessages sends a query following the given conversation history, oldest message first
+	QueryWithMessages(ctx context.Context, query string, history []ChatMessage) (string, error)
+}
+
+// StreamingAIService is implemented by AI services that can stream responses as they are generated
+type StreamingAIService interface {
+	ChatAIService
+
+	// QueryWithMessagesStream behaves like QueryWithMessages but calls onProgress with the cleaned response text
+	// accumulated so far while tokens arrive. Returns the complete cleaned response once generation finishes
+	QueryWithMessagesStream(ctx context.Context, query string, history []ChatMessage, onProgress func(partial string)) (string, error)
+}
+
+// ProviderAIService is implemented by concrete AI providers that build prompts from the BMAD knowledge base
+type ProviderAIService interface {
+	AIService
+
+	// SetRateLimiter sets the rate limiter consulted before each provider call
+	SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter)
+
+	// RefreshKnowledgeBase reloads the knowledge base from the ephemeral cache
+	RefreshKnowledgeBase() error
+
+	// SetMetrics sets the metrics registry that records each provider request
+	SetMetrics(metrics *monitor.Metrics)
+}
+
+// QualityReporter is implemented by AI services that score the quality of their responses
+type QualityReporter interface {
+	// GetQualityMetrics returns a snapshot of the running quality metrics
+	GetQualityMetrics() QualityMetrics
+}
+
+// HealthChecker is implemented by AI services that can verify their backend is reachable
+type HealthChecker interface {
+	// HealthCheck returns an error when the AI backend cannot be reached
+	HealthCheck(ctx context.Context) error
+}
+
+// ResponseMetadata describes which model produced a response and how it scored
+type ResponseMetadata struct {
+	Provider string
+	Model    string
+	Quality  *QualityScore // nil when the provider does not score responses
+}
+
+// PromptSizer is implemented by AI services that can estimate the fixed part of their prompts, so callers can
+// budget how much conversation history still fits the model's context
+type PromptSizer interface {
+	// PromptTokens estimates the tokens taken by the instructions and knowledge base sent with every query
+	PromptTokens() int
+}
+
+// SourceCiter is implemented by AI services that can tell which knowledge base sections support an answer
+type SourceCiter interface {
+	// CiteSources returns the knowledge base sections that best support response, most relevant first
+	CiteSources(response string) []KnowledgeSection
+}
+
+// ResponseDescriber is implemented by AI services that can describe the response they just served
+type ResponseDescriber interface {
+	// DescribeResponse returns the provider, model and quality score for a response to query
+	DescribeResponse(query, response string) ResponseMetadata
+}
+
+// responseRecorderKey is the context key holding a request's ResponseRecorder
+type responseRecorderKey struct{}
+
+// ResponseRecorder captures which provider answered the calls made with one request's context, so concurrent
+// requests through a service that routes across providers are each attributed to the provider that served them
+type ResponseRecorder struct {
+	mu     sync.Mutex
+	served ProviderAIService
+}
+
+// WithResponseRecorder returns a context whose answered queries report their serving provider to the returned recorder
+func WithResponseRecorder(ctx context.Context) (context.Context, *ResponseRecorder) {
+	recorder := &ResponseRecorder{}
+	return context.WithValue(ctx, responseRecorderKey{}, recorder), recorder
+}
+
+// recordServedBy notes the provider that answered a query made with ctx, if ctx carries a ResponseRecorder
+func recordServedBy(ctx context.Context, provider ProviderAIService) {
+	recorder, ok := ctx.Value(responseRecorderKey{}).(*ResponseRecorder)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	recorder.served = provider
+	recorder.mu.Unlock()
+}
+
+// Describe returns the provider, model and quality score for a response from the provider that answered the most
+// recent query made with the recorder's context. It returns false when no such query has been answered.
+func (r *ResponseRecorder) Describe(query, response string) (ResponseMetadata, bool) {
+	if r == nil {
+		return ResponseMetadata{}, false
+	}
+
+	r.mu.Lock()
+	served := r.served
+	r.mu.Unlock()
+
+	if served == nil {
+		return ResponseMetadata{}, false
+	}
+	if describer, ok := served.(ResponseDescriber); ok {
+		return describer.DescribeResponse(query, response), true
+	}
+	return ResponseMetadata{Provider: served.GetProviderID()}, true
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAIQueueConcurrency is how many AI jobs run at once
+	DefaultAIQueueConcurrency = 2
+
+	// DefaultAIQueueMaxDepth bounds how many AI jobs may wait for a worker
+	DefaultAIQueueMaxDepth = 50
+
+	// DefaultAIQueueJobTimeout bounds how long an AI job may wait and run before it is cancelled
+	DefaultAIQueueJobTimeout = 5 * time.Minute
+)
+
+// ErrAIQueueFull is returned when a job arrives while the maximum number of jobs are already waiting
+var ErrAIQueueFull = errors.New("AI queue is full")
+
+// AIJobPriority orders waiting AI jobs; lower values are started first
+type AIJobPriority int
+
+const (
+	// AIJobPriorityAdmin is used for questions from bot administrators
+	AIJobPriorityAdmin AIJobPriority = iota
+	// AIJobPriorityDM is used for direct message questions
+	AIJobPriorityDM
+	// AIJobPriorityFollowUp is used for follow-up questions in existing threads
+	AIJobPriorityFollowUp
+	// AIJobPriorityNewQuestion is used for questions that start a new conversation
+	AIJobPriorityNewQuestion
+)
+
+// String returns the priority class name used in logs
+func (p AIJobPriority) String() string {
+	switch p {
+	case AIJobPriorityAdmin:
+		return "admin"
+	case AIJobPriorityDM:
+		return "dm"
+	case AIJobPriorityFollowUp:
+		return "follow_up"
+	case AIJobPriorityNewQuestion:
+		return "new_question"
+	default:
+		return "unknown"
+	}
+}
+
+// AIJob describes a unit of AI work waiting for a worker
+type AIJob struct {
+	UserID   string
+	Priority AIJobPriority
+	// OnQueued is called with the job's 1-based queue position when it cannot start immediately
+	OnQueued func(position int)
+}
+
+// aiQueueTicket is a job waiting in the AIQueue
+type aiQueueTicket struct {
+	job     AIJob
+	seq     uint64
+	started bool
+	ready   chan struct{}
+}
+
+// AIQueueStats is a snapshot of the queue's load
+type AIQueueStats struct {
+	Running     int
+	Waiting     int
+	Concurrency int
+	MaxDepth    int
+	JobTimeout  time.Duration
+}
+
+// AIQueue limits how many AI jobs run concurrently. Waiting jobs start in priority order; within a
+// priority, users take turns so one user's burst of questions cannot hold up everyone else.
+// It is safe for concurrent use.
+type AIQueue struct {
+	logger *slog.Logger
+
+	mu            sync.Mutex
+	concurrency   int
+	maxDepth      int
+	jobTimeout    time.Duration
+	running       int
+	runningByUser map[string]int
+	lastStarted   map[string]uint64 // Start counter value when each user with queued or running jobs last got a worker
+	starts        uint64
+	waiting       []*aiQueueTicket
+	nextSeq       uint64
+}
+
+// NewAIQueue creates a queue with the default concurrency and depth
+func NewAIQueue(logger *slog.Logger) *AIQueue {
+	return &AIQueue{
+		logger:        logger,
+		concurrency:   DefaultAIQueueConcurrency,
+		maxDepth:      DefaultAIQueueMaxDepth,
+		jobTimeout:    DefaultAIQueueJobTimeout,
+		runningByUser: make(map[string]int),
+		lastStarted:   make(map[string]uint64),
+	}
+}
+
+// SetConcurrency sets how many jobs run at once, starting waiting jobs if it grew
+func (q *AIQueue) SetConcurrency(concurrency int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	q.concurrency = concurrency
+	q.dispatchLocked()
+}
+
+// SetMaxDepth sets how many jobs may wait; jobs already waiting are kept
+func (q *AIQueue) SetMaxDepth(maxDepth int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxDepth = maxDepth
+}
+
+// SetJobTimeout sets how long a job may take from being queued until it finishes; zero disables the deadline.
+// Jobs already queued keep their deadline.
+func (q *AIQueue) SetJobTimeout(timeout time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobTimeout = timeout
+}
+
+// Acquire waits until job may run and returns the context the AI work must use along with a release
+// function that must be called when the work is done. The job context ends at the queue's job deadline,
+// measured from when the job was queued, and on release. Acquire fails with ErrAIQueueFull when too many
+// jobs are waiting, or with the context's error if ctx or the deadline ends first.
+func (q *AIQueue) Acquire(ctx context.Context, job AIJob) (context.Context, func(), error) {
+	q.mu.Lock()
+	if q.running >= q.concurrency && len(q.waiting) >= q.maxDepth {
+		q.mu.Unlock()
+		q.logger.Warn("AI queue full, rejecting job", "user_id", job.UserID, "priority", job.Priority.String())
+		return nil, nil, ErrAIQueueFull
+	}
+
+	var cancel context.CancelFunc
+	if q.jobTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, q.jobTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	q.nextSeq++
+	ticket := &aiQueueTicket{job: job, seq: q.nextSeq, ready: make(chan struct{})}
+	q.waiting = append(q.waiting, ticket)
+	q.dispatchLocked()
+	started := ticket.started
+	position := q.positionLocked(ticket)
+	q.mu.Unlock()
+
+	release := func() {
+		q.release(ticket)
+		cancel()
+	}
+	if started {
+		return ctx, release, nil
+	}
+
+	q.logger.Info("AI job queued", "user_id", job.UserID, "priority", job.Priority.String(), "position", position)
+	if job.OnQueued != nil {
+		job.OnQueued(position)
+	}
+
+	select {
+	case <-ticket.ready:
+		return ctx, release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if ticket.started {
+			q.mu.Unlock()
+			release()
+		} else {
+			q.removeLocked(ticket)
+			q.forgetUserLocked(job.UserID)
+			q.mu.Unlock()
+			cancel()
+		}
+		return nil, nil, ctx.Err()
+	}
+}
+
+// Stats returns the number of running and waiting jobs and the queue's limits
+func (q *AIQueue) Stats() AIQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return AIQueueStats{
+		Running:     q.running,
+		Waiting:     len(q.waiting),
+		Concurrency: q.concurrency,
+		MaxDepth:    q.maxDepth,
+		JobTimeout:  q.jobTimeout,
+	}
+}
+
+// Saturated reports whether a new job would have to wait
+func (q *AIQueue) Saturated() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running >= q.concurrency || len(q.waiting) > 0
+}
+
+// release frees a worker held by ticket and starts the next waiting job
+func (q *AIQueue) release(ticket *aiQueueTicket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !ticket.started {
+		return
+	}
+	ticket.started = false
+	q.running--
+	if q.runningByUser[ticket.job.UserID]--; q.runningByUser[ticket.job.UserID] <= 0 {
+		delete(q.runningByUser, ticket.job.UserID)
+		q.forgetUserLocked(ticket.job.UserID)
+	}
+	q.dispatchLocked()
+}
+
+// dispatchLocked starts waiting jobs in order while workers are free; callers must hold the lock
+func (q *AIQueue) dispatchLocked() {
+	for q.running < q.concurrency && len(q.waiting) > 0 {
+		ticket := q.orderedLocked()[0]
+		q.removeLocked(ticket)
+
+		ticket.started = true
+		q.running++
+		q.runningByUser[ticket.job.UserID]++
+		q.starts++
+		q.lastStarted[ticket.job.UserID] = q.starts
+		close(ticket.ready)
+	}
+}
+
+// orderedLocked returns the waiting jobs in the order they will start: by priority, then by how many jobs
+// the user already has running or ahead in the queue, then giving the longest-unserved user their turn,
+// then by arrival; callers must hold the lock
+func (q *AIQueue) orderedLocked() []*aiQueueTicket {
+	turns := make(map[*aiQueueTicket]int, len(q.waiting))
+	queuedByUser := make(map[string]int)
+	for _, ticket := range q.waiting {
+		userID := ticket.job.UserID
+		turns[ticket] = q.runningByUser[userID] + queuedByUser[userID]
+		queuedByUser[userID]++
+	}
+
+	ordered := append([]*aiQueueTicket(nil), q.waiting...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.job.Priority != b.job.Priority {
+			return a.job.Priority < b.job.Priority
+		}
+		if turns[a] != turns[b] {
+			return turns[a] < turns[b]
+		}
+		if servedA, servedB := q.lastStarted[a.job.UserID], q.lastStarted[b.job.UserID]; servedA != servedB {
+			return servedA < servedB
+		}
+		return a.seq < b.seq
+	})
+	return ordered
+}
+
+// positionLocked returns the 1-based position of a waiting ticket, or 0 if it is not waiting;
+// callers must hold the lock
+func (q *AIQueue) positionLocked(ticket *aiQueueTicket) int {
+	for i, waiting := range q.orderedLocked() {
+		if waiting == ticket {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// removeLocked drops a ticket from the waiting list; callers must hold the lock
+func (q *AIQueue) removeLocked(ticket *aiQueueTicket) {
+	for i, waiting := range q.waiting {
+		if waiting == ticket {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// forgetUserLocked drops a user's turn history once they have no running or waiting jobs; callers must
+// hold the lock
+func (q *AIQueue) forgetUserLocked(userID string) {
+	if q.runningByUser[userID] > 0 {
+		return
+	}
+	for _, waiting := range q.waiting {
+		if waiting.job.UserID == userID {
+			return
+		}
+	}
+	delete(q.lastStarted, userID)
+}
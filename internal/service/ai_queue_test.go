@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+func newTestAIQueue(concurrency int) *AIQueue {
+	queue := NewAIQueue(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	queue.SetConcurrency(concurrency)
+	return queue
+}
+
+// acquireInBackground queues job, waits until it is queued and reports name on started once it runs,
+// releasing its worker straight away
+func acquireInBackground(t *testing.T, queue *AIQueue, name string, job AIJob, started chan<- string) {
+	t.Helper()
+	queued := make(chan struct{})
+	job.OnQueued = func(int) { close(queued) }
+
+	go func() {
+		_, release, err := queue.Acquire(context.Background(), job)
+		if err != nil {
+			t.Errorf("Acquire(%s) failed: %v", name, err)
+			return
+		}
+		started <- name
+		release()
+	}()
+
+	select {
+	case <-queued:
+	case <-time.After(time.Second):
+		t.Fatalf("job %s was not queued", name)
+	}
+}
+
+func TestAIQueue_RunsUpToConcurrency(t *testing.T) {
+	queue := newTestAIQueue(2)
+	ctx := context.Background()
+
+	_, releaseA, err := queue.Acquire(ctx, AIJob{UserID: "user-a"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if queue.Saturated() {
+		t.Error("expected a free worker after one job")
+	}
+	_, releaseB, err := queue.Acquire(ctx, AIJob{UserID: "user-b"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if !queue.Saturated() {
+		t.Error("expected the queue to be saturated with both workers busy")
+	}
+
+	started := make(chan string, 1)
+	acquireInBackground(t, queue, "c", AIJob{UserID: "user-c"}, started)
+	if stats := queue.Stats(); stats.Running != 2 || stats.Waiting != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	releaseA()
+	releaseA() // Releasing twice must not free a second worker
+	if name := <-started; name != "c" {
+		t.Errorf("expected job c to start, got %s", name)
+	}
+	if stats := queue.Stats(); stats.Waiting != 0 || stats.Running > 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	releaseB()
+}
+
+func TestAIQueue_StartsByPriorityAndUserTurns(t *testing.T) {
+	queue := newTestAIQueue(1)
+	_, release, err := queue.Acquire(context.Background(), AIJob{UserID: "busy", Priority: AIJobPriorityNewQuestion})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// A burst from one user does not hold up another user's question at the same priority
+	started := make(chan string, 5)
+	acquireInBackground(t, queue, "spam-1", AIJob{UserID: "spammer", Priority: AIJobPriorityNewQuestion}, started)
+	acquireInBackground(t, queue, "spam-2", AIJob{UserID: "spammer", Priority: AIJobPriorityNewQuestion}, started)
+	acquireInBackground(t, queue, "other", AIJob{UserID: "other", Priority: AIJobPriorityNewQuestion}, started)
+	acquireInBackground(t, queue, "follow-up", AIJob{UserID: "follower", Priority: AIJobPriorityFollowUp}, started)
+	acquireInBackground(t, queue, "admin", AIJob{UserID: "admin", Priority: AIJobPriorityAdmin}, started)
+
+	release()
+	for i, want := range []string{"admin", "follow-up", "spam-1", "other", "spam-2"} {
+		if got := <-started; got != want {
+			t.Errorf("start %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestAIQueue_ReportsPositionAndRejectsWhenFull(t *testing.T) {
+	queue := newTestAIQueue(1)
+	queue.SetMaxDepth(2)
+	_, release, err := queue.Acquire(context.Background(), AIJob{UserID: "user-a"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	positions := make(chan int, 1)
+	errs := make(chan error, 2)
+	for _, priority := range []AIJobPriority{AIJobPriorityNewQuestion, AIJobPriorityDM} {
+		job := AIJob{UserID: "user-" + priority.String(), Priority: priority, OnQueued: func(position int) { positions <- position }}
+		go func() {
+			_, _, err := queue.Acquire(ctx, job)
+			errs <- err
+		}()
+
+		// The DM queued second goes ahead of the earlier new question
+		if position := <-positions; position != 1 {
+			t.Errorf("expected %s job at position 1, got %d", priority, position)
+		}
+	}
+
+	if _, _, err := queue.Acquire(context.Background(), AIJob{UserID: "user-c"}); !errors.Is(err, ErrAIQueueFull) {
+		t.Errorf("expected ErrAIQueueFull, got %v", err)
+	}
+
+	// Abandoned waits leave the queue
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+	if stats := queue.Stats(); stats.Waiting != 0 || stats.Running != 1 {
+		t.Errorf("unexpected stats after cancellation %+v", stats)
+	}
+}
+
+func TestAIQueue_JobDeadline(t *testing.T) {
+	queue := newTestAIQueue(1)
+	queue.SetJobTimeout(50 * time.Millisecond)
+
+	jobCtx, release, err := queue.Acquire(context.Background(), AIJob{UserID: "user-a"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if _, ok := jobCtx.Deadline(); !ok {
+		t.Error("expected the job context to carry the queue deadline")
+	}
+
+	// A job whose deadline passes while waiting never starts
+	if _, _, err := queue.Acquire(context.Background(), AIJob{UserID: "user-b"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded for the waiting job, got %v", err)
+	}
+	select {
+	case <-jobCtx.Done():
+		if !errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+			t.Errorf("expected the running job to be past its deadline, got %v", jobCtx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the running job's context to end at its deadline")
+	}
+
+	// Releasing ends the job context and frees the worker
+	release()
+	queue.SetJobTimeout(0)
+	jobCtx, release, err = queue.Acquire(context.Background(), AIJob{UserID: "user-c"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if _, ok := jobCtx.Deadline(); ok {
+		t.Error("expected no deadline with the job timeout disabled")
+	}
+	release()
+	if !errors.Is(jobCtx.Err(), context.Canceled) {
+		t.Errorf("expected the job context to end on release, got %v", jobCtx.Err())
+	}
+}
+
+func TestRegisterAIQueueMetrics(t *testing.T) {
+	metrics := monitor.NewMetrics()
+	queue := newTestAIQueue(1)
+	RegisterAIQueueMetrics(metrics, queue)
+
+	_, release, err := queue.Acquire(context.Background(), AIJob{UserID: "user-a"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+
+	var output strings.Builder
+	metrics.WritePrometheus(&output)
+	for _, line := range []string{`bmad_bot_ai_queue_jobs{state="running"} 1`, `bmad_bot_ai_queue_jobs{state="waiting"} 0`} {
+		if !strings.Contains(output.String(), line+"\n") {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, output.String())
+		}
+	}
+}
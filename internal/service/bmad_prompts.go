@@ -0,0 +1,484 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// buildPromptForStyle creates a BMAD-constrained prompt using the given prompt style
+// ("structured" by default, "simple", "detailed" or "chain_of_thought")
+func buildPromptForStyle(promptStyle, knowledge, userQuery string) string {
+	switch promptStyle {
+	case "simple":
+		return buildSimplePrompt(knowledge, userQuery)
+	case "detailed":
+		return buildDetailedPrompt(knowledge, userQuery)
+	case "chain_of_thought":
+		return buildChainOfThoughtPrompt(knowledge, userQuery)
+	default:
+		return buildStructuredPrompt(knowledge, userQuery)
+	}
+}
+
+// buildStructuredPrompt creates a highly structured prompt for better model guidance
+func buildStructuredPrompt(knowledge, userQuery string) string {
+	return fmt.Sprintf(`# BMAD-METHOD KNOWLEDGE BASE
+%s
+
+---
+
+# YOUR IDENTITY
+You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
+
+# TASK
+Answer the user's question using ONLY the BMAD knowledge base above.
+
+# USER QUESTION
+%s
+
+# INSTRUCTIONS
+1. READ the knowledge base carefully
+2. FIND relevant information for the question
+3. PROVIDE a clear, specific answer using BMAD terminology
+4. USE proper BMAD concepts (agents, workflows, stories, epics, etc.)
+5. If information is NOT in the knowledge base, say "This information is not available in the BMAD knowledge base"
+6. If asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules."
+
+# RESPONSE FORMAT
+Write your answer with proper paragraph breaks for Discord readability. Use double line breaks (blank lines) between paragraphs. Structure your response clearly with:
+- Introduction paragraph (double line break after)
+- Main content paragraphs (double line break between each)
+- Conclusion or summary paragraph (if needed)
+
+[Your answer here using BMAD terminology - remember to use double line breaks between paragraphs]
+
+[SUMMARY]: [6-8 word summary for Discord thread title]
+
+# REMEMBER
+- Stay within BMAD knowledge base boundaries
+- Use BMAD-specific terms when possible
+- Be concise but comprehensive
+- Focus on BMAD methodology and concepts`, knowledge, userQuery)
+}
+
+// buildSimplePrompt creates a simpler, more direct prompt
+func buildSimplePrompt(knowledge, userQuery string) string {
+	return fmt.Sprintf(`You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
+
+BMAD Knowledge Base:
+%s
+
+Question: %s
+
+Answer using only BMAD knowledge base information. Use BMAD terms like agents, workflows, stories, and epics. If asked about release dates, updates, ETAs, or future features, remind the user that you only have access to current BMAD documentation. Format with proper paragraph breaks for Discord readability - use double line breaks (blank lines) between paragraphs. End with [SUMMARY]: brief title.`, knowledge, userQuery)
+}
+
+// buildDetailedPrompt creates a more detailed prompt with examples
+func buildDetailedPrompt(knowledge, userQuery string) string {
+	return fmt.Sprintf(`# BMAD-METHOD EXPERT SYSTEM
+
+## KNOWLEDGE BASE
+%s
+
+## YOUR ROLE
+You are bmadhelper, the BMAD-METHOD assistant agent on Discord. Your job is to answer questions using ONLY the knowledge base above. You are a helpful AI assistant specializing in BMAD methodology.
+
+## QUESTION
+%s
+
+## RESPONSE GUIDELINES
+✓ USE BMAD terminology: agents, workflows, stories, epics, PRD, architecture
+✓ REFERENCE specific BMAD concepts and processes
+✓ EXPLAIN how things work within the BMAD framework
+✓ BE specific about BMAD roles (PM, Dev, Architect, QA, UX, SM, PO)
+✗ DON'T make up information not in the knowledge base
+✗ DON'T use general software development advice
+✗ DON'T reference external frameworks or methods
+⚠️ IF asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules."
+
+## EXAMPLE GOOD RESPONSE
+"In BMAD-METHOD, agents work in structured workflows. The SM agent creates stories from sharded PRD documents, while the Dev agent implements approved stories following the coding standards."
+
+## YOUR RESPONSE
+Format your answer with proper paragraph breaks for Discord readability - use double line breaks (blank lines) between paragraphs.
+
+[Answer here with clear paragraph spacing - remember double line breaks between paragraphs]
+
+[SUMMARY]: [Brief BMAD-focused title]`, knowledge, userQuery)
+}
+
+// buildChainOfThoughtPrompt uses chain-of-thought reasoning for better responses
+func buildChainOfThoughtPrompt(knowledge, userQuery string) string {
+	return fmt.Sprintf(`# YOUR IDENTITY
+You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
+
+# BMAD KNOWLEDGE BASE
+%s
+
+---
+
+# QUESTION: %s
+
+# REASONING PROCESS
+Let me think step by step:
+
+1. IDENTIFY: What BMAD concepts does this question relate to?
+2. SEARCH: What information is available in the knowledge base?
+3. CONNECT: How do these concepts work together in BMAD?
+4. CHECK: Is this about future updates/releases? (If so, remind user I only have current documentation)
+5. RESPOND: Provide a clear answer using BMAD terminology
+
+# ANALYSIS
+[Think through the question step by step]
+- What BMAD concepts are relevant?
+- What specific information is in the knowledge base?
+- How should I structure my response?
+
+# ANSWER
+[Your detailed BMAD-focused response - use double line breaks (blank lines) between paragraphs for Discord readability]
+
+[SUMMARY]: [Concise BMAD topic summary]`, knowledge, userQuery)
+}
+
+// buildConversationPrompt creates a contextual prompt from BMAD knowledge, conversation history and the follow-up question
+func buildConversationPrompt(knowledge, conversationHistory, query string) string {
+	return fmt.Sprintf(`%s
+
+-----
+
+CONVERSATION HISTORY:
+%s
+
+USER QUESTION: %s
+
+IMPORTANT: You are bmadhelper, the BMAD-METHOD assistant agent, continuing a conversation on Discord. Answer ONLY based on the information provided in the BMAD knowledge base above. If the follow-up question refers to something mentioned earlier in the conversation, use the conversation history to understand the context. However, your answer must still be grounded in the BMAD knowledge base. If the question cannot be answered from the knowledge base, politely indicate that the information is not available in your BMAD knowledge base. If asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules." Maintain any citation markers (e.g., [cite: 123]) from the source text in your response.
+
+FORMAT YOUR RESPONSE: Use double line breaks (blank lines) between paragraphs for proper Discord readability. Structure your answer clearly with proper paragraph spacing.
+
+After your main answer, provide a concise, 8-word or less topic summary of this conversation for Discord thread titles, prefixed with "[SUMMARY]:". This summary should focus on the BMAD topic or concept discussed. Example: "[SUMMARY]: BMAD Roles and Responsibilities".`, knowledge, conversationHistory, query)
+}
+
+// buildChatSystemPrompt creates the system message for chat endpoints, carrying the BMAD identity,
+// the answering guidelines for the prompt style and the knowledge base
+func buildChatSystemPrompt(promptStyle, knowledge string) string {
+	var guidelines string
+	switch promptStyle {
+	case "simple":
+		guidelines = `Answer using only BMAD knowledge base information. Use BMAD terms like agents, workflows, stories, and epics.`
+	case "detailed":
+		guidelines = `✓ USE BMAD terminology: agents, workflows, stories, epics, PRD, architecture
+✓ REFERENCE specific BMAD concepts and processes
+✓ EXPLAIN how things work within the BMAD framework
+✓ BE specific about BMAD roles (PM, Dev, Architect, QA, UX, SM, PO)
+✗ DON'T make up information not in the knowledge base
+✗ DON'T use general software development advice
+✗ DON'T reference external frameworks or methods`
+	case "chain_of_thought":
+		guidelines = `Before answering, think step by step:
+1. IDENTIFY: What BMAD concepts does this question relate to?
+2. SEARCH: What information is available in the knowledge base?
+3. CONNECT: How do these concepts work together in BMAD?
+4. RESPOND: Provide a clear answer using BMAD terminology
+Reply with the final answer only.`
+	default:
+		guidelines = `1. READ the knowledge base carefully
+2. FIND relevant information for the question
+3. PROVIDE a clear, specific answer using BMAD terminology
+4. USE proper BMAD concepts (agents, workflows, stories, epics, etc.)`
+	}
+
+	return fmt.Sprintf(`# YOUR IDENTITY
+You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
+
+# TASK
+Answer the user's questions using ONLY the BMAD knowledge base below. Earlier messages in the conversation explain what follow-up questions refer to, but answers must still be grounded in the knowledge base.
+
+# INSTRUCTIONS
+%s
+
+If information is NOT in the knowledge base, say "This information is not available in the BMAD knowledge base".
+If asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules."
+
+# RESPONSE FORMAT
+Write your answer with proper paragraph breaks for Discord readability. Use double line breaks (blank lines) between paragraphs.
+
+# BMAD-METHOD KNOWLEDGE BASE
+%s`, guidelines, knowledge)
+}
+
+// chatSummaryInstructions asks the model to return the answer and thread title as a JSON object
+const chatSummaryInstructions = `
+
+# OUTPUT
+Respond with a JSON object containing "answer" (your full answer, keeping the paragraph breaks) and "summary" (a 6-8 word BMAD-focused title for a Discord thread).`
+
+// answerWithSummaryFormat is the JSON schema for responses that include a thread title
+var answerWithSummaryFormat = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"answer":  map[string]string{"type": "string"},
+		"summary": map[string]string{"type": "string"},
+	},
+	"required": []string{"answer", "summary"},
+}
+
+// threadTitleFormat is the JSON schema for thread title summaries
+var threadTitleFormat = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"title": map[string]string{"type": "string"},
+	},
+	"required": []string{"title"},
+}
+
+// parseStructuredAnswer decodes a JSON answer/summary response, reporting false if it is not valid JSON with an answer
+func parseStructuredAnswer(content string) (string, string, bool) {
+	var structured struct {
+		Answer  string `json:"answer"`
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(content), &structured); err != nil || strings.TrimSpace(structured.Answer) == "" {
+		return "", "", false
+	}
+
+	answer := cleanCitations(removeUnnecessaryHeaders(strings.TrimSpace(structured.Answer)))
+	return answer, truncateThreadTitle(cleanCitations(structured.Summary)), true
+}
+
+// parseThreadTitle decodes a JSON thread title response, falling back to the raw content
+func parseThreadTitle(content string) string {
+	var structured struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(content), &structured); err == nil {
+		return truncateThreadTitle(structured.Title)
+	}
+	return truncateThreadTitle(unescapeText(content))
+}
+
+// truncateThreadTitle trims a title to Discord's 100 character thread title limit
+func truncateThreadTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if len(title) > 100 {
+		title = title[:97] + "..."
+	}
+	return title
+}
+
+// appendChatMessage appends a message, merging it into the previous one when both have the same role
+// so that user and assistant messages strictly alternate
+func appendChatMessage(history []ChatMessage, message ChatMessage) []ChatMessage {
+	content := strings.TrimSpace(message.Content)
+	if content == "" {
+		return history
+	}
+
+	if last := len(history) - 1; last >= 0 && history[last].Role == message.Role {
+		history[last].Content += "\n\n" + content
+		return history
+	}
+	return append(history, ChatMessage{Role: message.Role, Content: content})
+}
+
+// FormatChatHistory flattens role-tagged history into text for AI services without chat support
+func FormatChatHistory(history []ChatMessage) string {
+	var builder strings.Builder
+	for _, message := range history {
+		if message.Role == ChatRoleAssistant {
+			builder.WriteString("Bot: ")
+		}
+		builder.WriteString(message.Content)
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// parseResponseWithSummary extracts the main answer and summary from an integrated response
+func parseResponseWithSummary(response string, logger *slog.Logger) (string, string, error) {
+	if response == "" {
+		return "", "", fmt.Errorf("empty response")
+	}
+
+	// Look for various summary markers the AI might use
+	summaryMarkers := []string{"[SUMMARY]:", "### Summary", "##Summary", "Summary:", "SUMMARY:"}
+	var summaryIndex int = -1
+	var foundMarker string
+
+	for _, marker := range summaryMarkers {
+		if idx := strings.LastIndex(response, marker); idx != -1 {
+			summaryIndex = idx
+			foundMarker = marker
+			break
+		}
+	}
+
+	if summaryIndex == -1 {
+		// No summary found, return the full response as main answer with empty summary
+		logger.Warn("No summary marker found in response, summary extraction failed",
+			"response_preview", response[len(response)-min(200, len(response)):]) // Show last 200 chars for debugging
+		cleanedResponse := removeUnnecessaryHeaders(strings.TrimSpace(response))
+		return cleanedResponse, "", nil
+	}
+
+	// Extract main answer (everything before the summary marker)
+	mainAnswer := strings.TrimSpace(response[:summaryIndex])
+
+	// Remove unnecessary headers from the main answer
+	mainAnswer = removeUnnecessaryHeaders(mainAnswer)
+
+	// Extract summary (everything after the found marker)
+	summaryStart := summaryIndex + len(foundMarker)
+	summary := strings.TrimSpace(response[summaryStart:])
+
+	// Validate summary length (Discord thread title limit is 100 characters)
+	if len(summary) > 100 {
+		logger.Warn("Summary too long, truncating",
+			"original_length", len(summary),
+			"summary", summary)
+		summary = summary[:97] + "..."
+	}
+
+	// Validate summary is not empty
+	if summary == "" {
+		logger.Warn("Empty summary extracted")
+		return mainAnswer, "", nil
+	}
+
+	logger.Info("Response parsed successfully",
+		"main_answer_length", len(mainAnswer),
+		"summary_length", len(summary),
+		"summary", summary)
+
+	// Clean citations from both main answer and summary
+	mainAnswer = cleanCitations(mainAnswer)
+	summary = cleanCitations(summary)
+
+	return mainAnswer, summary, nil
+}
+
+// unescapeText converts common escape sequences to their actual characters for Discord formatting
+func unescapeText(text string) string {
+	// Replace common escape sequences
+	text = strings.ReplaceAll(text, "\\n", "\n")  // Newlines
+	text = strings.ReplaceAll(text, "\\t", "\t")  // Tabs
+	text = strings.ReplaceAll(text, "\\r", "\r")  // Carriage returns
+	text = strings.ReplaceAll(text, "\\\"", "\"") // Quotes
+	text = strings.ReplaceAll(text, "\\'", "'")   // Single quotes
+	text = strings.ReplaceAll(text, "\\\\", "\\") // Backslashes (do this last)
+	return text
+}
+
+// removeSummaryMarkers removes summary markers and content from text
+func removeSummaryMarkers(text string) string {
+	summaryMarkers := []string{"[SUMMARY]:", "### Summary", "##Summary", "Summary:", "SUMMARY:"}
+
+	for _, marker := range summaryMarkers {
+		if summaryIndex := strings.LastIndex(text, marker); summaryIndex != -1 {
+			// Return everything before the summary marker, trimmed
+			return strings.TrimSpace(text[:summaryIndex])
+		}
+	}
+
+	return text // No summary marker found
+}
+
+// removeUnnecessaryHeaders removes unnecessary headers like "### Answer" from response
+func removeUnnecessaryHeaders(text string) string {
+	unnecessaryHeaders := []string{"### Answer", "## Answer", "# Answer", "**Answer**", "Answer:", "ANSWER:"}
+
+	lines := strings.Split(text, "\n")
+	var filteredLines []string
+
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		isUnnecessaryHeader := false
+
+		for _, header := range unnecessaryHeaders {
+			if trimmedLine == header {
+				isUnnecessaryHeader = true
+				break
+			}
+		}
+
+		if !isUnnecessaryHeader {
+			filteredLines = append(filteredLines, line)
+		}
+	}
+
+	return strings.Join(filteredLines, "\n")
+}
+
+// cleanCitations removes citation markers like [cite: 1, 2] from response text
+func cleanCitations(text string) string {
+	// Remove citation patterns like [cite: 1], [cite: 1, 2], [cite: 1,2,3], etc.
+	citationPattern := `\[cite:[^\]]*\]`
+	re := regexp.MustCompile(citationPattern)
+	cleaned := re.ReplaceAllString(text, "")
+
+	// Clean up any multiple consecutive spaces and tabs, but preserve newlines
+	// Only collapse horizontal whitespace (spaces and tabs), not vertical (newlines)
+	cleaned = regexp.MustCompile(`[ \t]+`).ReplaceAllString(cleaned, " ")
+
+	// Remove spaces at the end of lines (before newlines)
+	cleaned = regexp.MustCompile(` +\n`).ReplaceAllString(cleaned, "\n")
+
+	return strings.TrimSpace(cleaned)
+}
+
+// fallbackSummarize provides a simple fallback summarization when AI fails
+func fallbackSummarize(query string) string {
+	// Simple fallback: take first few words and truncate to fit Discord limit
+	words := strings.Fields(strings.TrimSpace(query))
+	if len(words) == 0 {
+		return "Question"
+	}
+
+	summary := ""
+	for _, word := range words {
+		testSummary := summary + " " + word
+		if len(strings.TrimSpace(testSummary)) > 95 { // Leave room for "..."
+			break
+		}
+		summary = testSummary
+	}
+
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "Question"
+	}
+
+	// Add ellipsis if we truncated
+	if len(words) > len(strings.Fields(summary)) {
+		summary += "..."
+	}
+
+	return summary
+}
+
+// fallbackConversationSummary provides a simple fallback when AI summarization fails
+func fallbackConversationSummary(messages []string) string {
+	if len(messages) == 0 {
+		return ""
+	}
+
+	// Simple fallback: take the last few messages and truncate if needed
+	const maxMessages = 5
+	const maxLength = 1000
+
+	startIdx := 0
+	if len(messages) > maxMessages {
+		startIdx = len(messages) - maxMessages
+	}
+
+	recentMessages := messages[startIdx:]
+	summary := strings.Join(recentMessages, "\n")
+
+	if len(summary) > maxLength {
+		summary = summary[:maxLength-3] + "..."
+	}
+
+	return summary
+}
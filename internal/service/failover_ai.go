@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+const (
+	// FailoverProviderID identifies the composite service in status notifications
+	FailoverProviderID = "failover"
+
+	// defaultErrorRateWindow is how far back provider outcomes count toward the error rate
+	defaultErrorRateWindow = 5 * time.Minute
+
+	// defaultErrorRateThreshold is the error rate at which a provider is considered unhealthy
+	defaultErrorRateThreshold = 0.5
+
+	// defaultErrorRateMinSamples is the number of recent calls required before the error rate is trusted
+	defaultErrorRateMinSamples = 3
+)
+
+// ErrQuotaExhausted is returned (wrapped) by providers whose account quota is used up
+var ErrQuotaExhausted = errors.New("provider quota exhausted")
+
+// providerOutcome records the result of a single provider call
+type providerOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// FailoverAIService routes queries across an ordered list of AI providers, skipping providers that are
+// throttled, quota exhausted or failing, and failing over to the next provider when a call errors
+type FailoverAIService struct {
+	providers           []ProviderAIService
+	logger              *slog.Logger
+	rateLimiter         monitor.AIProviderRateLimiter
+	errorRateWindow     time.Duration
+	errorRateThreshold  float64
+	errorRateMinSamples int
+
+	mu              sync.Mutex
+	outcomes        map[string][]providerOutcome
+	servedCounts    map[string]int
+	lastStatus      string
+	statusCallbacks []monitor.StatusCallback
+}
+
+// NewFailoverAIService creates a composite AI service; providers are tried in the given order
+func NewFailoverAIService(providers []ProviderAIService, logger *slog.Logger) (*FailoverAIService, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one AI provider is required")
+	}
+
+	seen := make(map[string]bool)
+	providerIDs := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		id := provider.GetProviderID()
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate AI provider: %s", id)
+		}
+		seen[id] = true
+		providerIDs = append(providerIDs, id)
+	}
+
+	logger.Info("AI provider failover chain configured", "providers", providerIDs)
+
+	return &FailoverAIService{
+		providers:           providers,
+		logger:              logger,
+		errorRateWindow:     defaultErrorRateWindow,
+		errorRateThreshold:  defaultErrorRateThreshold,
+		errorRateMinSamples: defaultErrorRateMinSamples,
+		outcomes:            make(map[string][]providerOutcome),
+		servedCounts:        make(map[string]int),
+	}, nil
+}
+
+// GetProviderID returns the unique identifier for this AI provider
+func (f *FailoverAIService) GetProviderID() string {
+	return FailoverProviderID
+}
+
+// SetRateLimiter sets the rate limiter for the composite service and every provider in the chain
+func (f *FailoverAIService) SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter) {
+	f.rateLimiter = rateLimiter
+	for _, provider := range f.providers {
+		provider.SetRateLimiter(rateLimiter)
+	}
+}
+
+// SetMetrics sets the metrics registry for every provider in the chain
+func (f *FailoverAIService) SetMetrics(metrics *monitor.Metrics) {
+	for _, provider := range f.providers {
+		provider.SetMetrics(metrics)
+	}
+}
+
+// Providers returns the providers in the chain in configured order
+func (f *FailoverAIService) Providers() []ProviderAIService {
+	return append([]ProviderAIService(nil), f.providers...)
+}
+
+// RefreshKnowledgeBase refreshes the knowledge base of every provider in the chain
+func (f *FailoverAIService) RefreshKnowledgeBase() error {
+	var errs []error
+	for _, provider := range f.providers {
+		if err := provider.RefreshKnowledgeBase(); err != nil {
+			errs = append(errs, fmt.Errorf("provider %s: %w", provider.GetProviderID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HealthCheck succeeds when at least one provider in the chain is reachable.
+// Providers that cannot check their backend are assumed reachable.
+func (f *FailoverAIService) HealthCheck(ctx context.Context) error {
+	var errs []error
+	for _, provider := range f.providers {
+		checker, ok := provider.(HealthChecker)
+		if !ok {
+			return nil
+		}
+		if err := checker.HealthCheck(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("provider %s: %w", provider.GetProviderID(), err))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("no AI provider is reachable: %w", errors.Join(errs...))
+}
+
+// PromptTokens returns the largest prompt estimate of the providers, since any of them may serve the next call
+func (f *FailoverAIService) PromptTokens() int {
+	tokens := 0
+	for _, provider := range f.providers {
+		if sizer, ok := provider.(PromptSizer); ok {
+			tokens = max(tokens, sizer.PromptTokens())
+		}
+	}
+	return tokens
+}
+
+// CiteSources returns the sources found by the first provider able to cite any; all providers index the
+// same knowledge base
+func (f *FailoverAIService) CiteSources(response string) []KnowledgeSection {
+	for _, provider := range f.providers {
+		if citer, ok := provider.(SourceCiter); ok {
+			if sections := citer.CiteSources(response); len(sections) > 0 {
+				return sections
+			}
+		}
+	}
+	return nil
+}
+
+// ServedCounts returns how many calls each provider has served
+func (f *FailoverAIService) ServedCounts() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts := make(map[string]int, len(f.servedCounts))
+	for id, count := range f.servedCounts {
+		counts[id] = count
+	}
+	return counts
+}
+
+// RegisterStatusCallback adds a callback notified with the aggregate status of the chain
+// (Normal, Warning or Throttled) under FailoverProviderID whenever it changes
+func (f *FailoverAIService) RegisterStatusCallback(callback monitor.StatusCallback) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statusCallbacks = append(f.statusCallbacks, callback)
+}
+
+// HandleProviderStatusChange is registered with RateLimitManager to re-evaluate the aggregate status
+// when an individual provider's rate limit status changes
+func (f *FailoverAIService) HandleProviderStatusChange(providerID, status string) {
+	f.logger.Debug("Provider status changed", "provider", providerID, "status", status)
+	f.evaluateStatus()
+}
+
+// QueryAI sends a query to the first available provider, failing over on error
+func (f *FailoverAIService) QueryAI(ctx context.Context, query string) (string, error) {
+	var response string
+	err := f.answer(ctx, "QueryAI", func(provider ProviderAIService) error {
+		var err error
+		response, err = provider.QueryAI(ctx, query)
+		return err
+	})
+	return response, err
+}
+
+// QueryAIWithSummary sends a query to the first available provider and returns the response and summary
+func (f *FailoverAIService) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
+	var response, summary string
+	err := f.answer(ctx, "QueryAIWithSummary", func(provider ProviderAIService) error {
+		var err error
+		response, summary, err = provider.QueryAIWithSummary(ctx, query)
+		return err
+	})
+	return response, summary, err
+}
+
+// SummarizeQuery creates a thread title summary using the first available provider
+func (f *FailoverAIService) SummarizeQuery(ctx context.Context, query string) (string, error) {
+	var summary string
+	_, err := f.execute(ctx, "SummarizeQuery", func(provider ProviderAIService) error {
+		var err error
+		summary, err = provider.SummarizeQuery(ctx, query)
+		return err
+	})
+	return summary, err
+}
+
+// QueryWithContext sends a contextual query to the first available provider, failing over on error
+func (f *FailoverAIService) QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error) {
+	var response string
+	err := f.answer(ctx, "QueryWithContext", func(provider ProviderAIService) error {
+		var err error
+		response, err = provider.QueryWithContext(ctx, query, conversationHistory)
+		return err
+	})
+	return response, err
+}
+
+// QueryWithMessages sends a query with role-tagged history to the first available provider.
+// Providers without chat support receive the history flattened through QueryWithContext.
+func (f *FailoverAIService) QueryWithMessages(ctx context.Context, query string, history []ChatMessage) (string, error) {
+	var response string
+	err := f.answer(ctx, "QueryWithMessages", func(provider ProviderAIService) error {
+		var err error
+		response, err = queryProviderWithMessages(ctx, provider, query, history)
+		return err
+	})
+	return response, err
+}
+
+// QueryWithMessagesStream streams a response from the first available provider.
+// Providers without streaming support answer with QueryWithMessages and the full response is reported once.
+func (f *FailoverAIService) QueryWithMessagesStream(ctx context.Context, query string, history []ChatMessage, onProgress func(partial string)) (string, error) {
+	var response string
+	err := f.answer(ctx, "QueryWithMessagesStream", func(provider ProviderAIService) error {
+		var err error
+		if streamingProvider, ok := provider.(StreamingAIService); ok {
+			response, err = streamingProvider.QueryWithMessagesStream(ctx, query, history, onProgress)
+			return err
+		}
+
+		response, err = queryProviderWithMessages(ctx, provider, query, history)
+		if err == nil && onProgress != nil {
+			onProgress(response)
+		}
+		return err
+	})
+	return response, err
+}
+
+// queryProviderWithMessages uses the provider's chat support when available, flattening the history otherwise
+func queryProviderWithMessages(ctx context.Context, provider ProviderAIService, query string, history []ChatMessage) (string, error) {
+	if chatProvider, ok := provider.(ChatAIService); ok {
+		return chatProvider.QueryWithMessages(ctx, query, history)
+	}
+	return provider.QueryWithContext(ctx, query, FormatChatHistory(history))
+}
+
+// SummarizeConversation summarizes conversation history using the first available provider
+func (f *FailoverAIService) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
+	var summary string
+	_, err := f.execute(ctx, "SummarizeConversation", func(provider ProviderAIService) error {
+		var err error
+		summary, err = provider.SummarizeConversation(ctx, messages)
+		return err
+	})
+	return summary, err
+}
+
+// answer runs a call that answers a query through execute and reports the serving provider to the
+// ResponseRecorder carried by ctx
+func (f *FailoverAIService) answer(ctx context.Context, operation string, call func(provider ProviderAIService) error) error {
+	provider, err := f.execute(ctx, operation, call)
+	if err != nil {
+		return err
+	}
+	recordServedBy(ctx, provider)
+	return nil
+}
+
+// execute runs the call against each candidate provider in order until one succeeds, returning the provider
+// that served it. Once ctx ends no further providers are tried, and the cancelled attempt does not count
+// against the provider's health.
+func (f *FailoverAIService) execute(ctx context.Context, operation string, call func(provider ProviderAIService) error) (ProviderAIService, error) {
+	candidates := f.candidates()
+	if len(candidates) == 0 {
+		f.logger.Error("No AI providers available", "operation", operation)
+		return nil, fmt.Errorf("all AI providers are unavailable")
+	}
+
+	var errs []error
+	for i, provider := range candidates {
+		providerID := provider.GetProviderID()
+
+		err := call(provider)
+		if err != nil && ctx.Err() != nil {
+			f.logger.Info("AI request abandoned", "operation", operation, "provider", providerID, "reason", ctx.Err())
+			return nil, fmt.Errorf("AI request abandoned: %w", ctx.Err())
+		}
+		f.recordOutcome(provider, err)
+		if err == nil {
+			f.logger.Info("AI request served",
+				"operation", operation,
+				"served_by", providerID,
+				"attempt", i+1)
+			return provider, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", providerID, err))
+		if i < len(candidates)-1 {
+			f.logger.Warn("AI provider failed, failing over to next provider",
+				"operation", operation,
+				"provider", providerID,
+				"next_provider", candidates[i+1].GetProviderID(),
+				"error", err)
+		}
+	}
+
+	f.logger.Error("All AI providers failed", "operation", operation, "attempts", len(candidates))
+	return nil, fmt.Errorf("all AI providers failed: %w", errors.Join(errs...))
+}
+
+// candidates returns the providers to try in order: healthy providers first, then providers with
+// a high recent error rate as a last resort. Throttled and quota exhausted providers are skipped.
+func (f *FailoverAIService) candidates() []ProviderAIService {
+	var healthy, degraded []ProviderAIService
+	for _, provider := range f.providers {
+		providerID := provider.GetProviderID()
+		if !f.isRateLimitAvailable(providerID) {
+			f.logger.Debug("Skipping rate limited AI provider", "provider", providerID)
+			continue
+		}
+		if f.isFailing(providerID) {
+			f.logger.Debug("Deprioritizing failing AI provider", "provider", providerID)
+			degraded = append(degraded, provider)
+			continue
+		}
+		healthy = append(healthy, provider)
+	}
+	return append(healthy, degraded...)
+}
+
+// isRateLimitAvailable reports whether the provider is neither throttled nor quota exhausted
+func (f *FailoverAIService) isRateLimitAvailable(providerID string) bool {
+	if f.rateLimiter == nil {
+		return true
+	}
+	status := f.rateLimiter.GetProviderStatus(providerID)
+	return status != "Throttled" && status != "Quota Exhausted"
+}
+
+// isFailing reports whether the provider's recent error rate is at or above the threshold
+func (f *FailoverAIService) isFailing(providerID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	failures, total := f.recentOutcomesLocked(providerID)
+	if total < f.errorRateMinSamples {
+		return false
+	}
+	return float64(failures)/float64(total) >= f.errorRateThreshold
+}
+
+// recentOutcomesLocked prunes outcomes outside the error rate window and returns failure and total counts
+func (f *FailoverAIService) recentOutcomesLocked(providerID string) (int, int) {
+	cutoff := time.Now().Add(-f.errorRateWindow)
+	outcomes := f.outcomes[providerID]
+
+	recent := outcomes[:0]
+	failures := 0
+	for _, outcome := range outcomes {
+		if outcome.at.After(cutoff) {
+			recent = append(recent, outcome)
+			if outcome.failed {
+				failures++
+			}
+		}
+	}
+	f.outcomes[providerID] = recent
+
+	return failures, len(recent)
+}
+
+// recordOutcome tracks the call result for error rate routing and flags quota exhaustion
+func (f *FailoverAIService) recordOutcome(provider ProviderAIService, err error) {
+	providerID := provider.GetProviderID()
+
+	if errors.Is(err, ErrQuotaExhausted) && f.rateLimiter != nil {
+		f.rateLimiter.SetQuotaExhausted(providerID, nextQuotaReset(time.Now()))
+	}
+
+	f.mu.Lock()
+	f.outcomes[providerID] = append(f.outcomes[providerID], providerOutcome{at: time.Now(), failed: err != nil})
+	if err == nil {
+		f.servedCounts[providerID]++
+	}
+	f.mu.Unlock()
+
+	f.evaluateStatus()
+}
+
+// aggregateStatus summarizes the chain: Normal when the primary provider is healthy, Warning when
+// serving from a fallback or the primary is near its limit, and Throttled when no provider is available
+func (f *FailoverAIService) aggregateStatus() string {
+	available := 0
+	for i, provider := range f.providers {
+		providerID := provider.GetProviderID()
+		if !f.isRateLimitAvailable(providerID) || f.isFailing(providerID) {
+			continue
+		}
+		available++
+
+		if i == 0 && (f.rateLimiter == nil || f.rateLimiter.GetProviderStatus(providerID) == "Normal") {
+			return "Normal"
+		}
+	}
+
+	if available == 0 {
+		return "Throttled"
+	}
+	return "Warning"
+}
+
+// evaluateStatus notifies status callbacks when the aggregate status changes
+func (f *FailoverAIService) evaluateStatus() {
+	status := f.aggregateStatus()
+
+	f.mu.Lock()
+	if status == f.lastStatus {
+		f.mu.Unlock()
+		return
+	}
+	previous := f.lastStatus
+	f.lastStatus = status
+	callbacks := append([]monitor.StatusCallback(nil), f.statusCallbacks...)
+	f.mu.Unlock()
+
+	f.logger.Info("AI provider chain status changed",
+		"old_status", previous,
+		"new_status", status)
+
+	for _, callback := range callbacks {
+		go func(cb monitor.StatusCallback) {
+			defer func() {
+				if r := recover(); r != nil {
+					f.logger.Error("Status callback panicked", "status", status, "panic", r)
+				}
+			}()
+			cb(FailoverProviderID, status)
+		}(callback)
+	}
+}
+
+// nextQuotaReset returns the next UTC midnight, when provider daily quotas typically reset
+func nextQuotaReset(now time.Time) time.Time {
+	utc := now.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC).Add(24 * time.Hour)
+}
+
+// ParseProviderList splits a comma-separated provider list, trimming whitespace and dropping duplicates
+func ParseProviderList(value string) []string {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		provider := strings.ToLower(strings.TrimSpace(part))
+		if provider == "" || seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		providers = append(providers, provider)
+	}
+	return providers
+}
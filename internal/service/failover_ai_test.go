@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+// fakeProvider is a scripted ProviderAIService used to exercise failover routing
+type fakeProvider struct {
+	id          string
+	err         error
+	calls       int
+	refreshErr  error
+	healthErr   error
+	rateLimiter monitor.AIProviderRateLimiter
+	metrics     *monitor.Metrics
+}
+
+func (p *fakeProvider) respond(ctx context.Context, answer string) (string, error) {
+	p.calls++
+	if p.err != nil {
+		return "", p.err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return answer + " from " + p.id, nil
+}
+
+func (p *fakeProvider) QueryAI(ctx context.Context, query string) (string, error) {
+	return p.respond(ctx, query)
+}
+func (p *fakeProvider) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
+	response, err := p.respond(ctx, query)
+	return response, "summary", err
+}
+func (p *fakeProvider) SummarizeQuery(ctx context.Context, query string) (string, error) {
+	return p.respond(ctx, "summary")
+}
+func (p *fakeProvider) QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error) {
+	return p.respond(ctx, query)
+}
+func (p *fakeProvider) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
+	return p.respond(ctx, "conversation")
+}
+func (p *fakeProvider) GetProviderID() string { return p.id }
+func (p *fakeProvider) SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter) {
+	p.rateLimiter = rateLimiter
+}
+func (p *fakeProvider) RefreshKnowledgeBase() error           { return p.refreshErr }
+func (p *fakeProvider) HealthCheck(ctx context.Context) error { return p.healthErr }
+func (p *fakeProvider) SetMetrics(metrics *monitor.Metrics)   { p.metrics = metrics }
+
+func newTestFailoverRateLimiter(providerIDs ...string) *monitor.RateLimitManager {
+	var configs []monitor.ProviderConfig
+	for _, id := range providerIDs {
+		configs = append(configs, monitor.ProviderConfig{
+			ProviderID: id,
+			Limits:     map[string]int{"minute": 2, "day": 100},
+			Thresholds: map[string]float64{"warning": 0.5, "throttled": 1.0},
+		})
+	}
+	return monitor.NewRateLimitManager(newTestRetrievalLogger(), configs)
+}
+
+func TestNewFailoverAIService_Validation(t *testing.T) {
+	if _, err := NewFailoverAIService(nil, newTestRetrievalLogger()); err == nil {
+		t.Error("Expected error for empty provider list")
+	}
+
+	_, err := NewFailoverAIService([]ProviderAIService{&fakeProvider{id: "ollama"}, &fakeProvider{id: "ollama"}}, newTestRetrievalLogger())
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("Expected duplicate provider error, got %v", err)
+	}
+}
+
+func TestFailoverAIService_FailsOverOnError(t *testing.T) {
+	primary := &fakeProvider{id: "ollama", err: errors.New("connection refused")}
+	secondary := &fakeProvider{id: "openai"}
+
+	failover, err := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+	if err != nil {
+		t.Fatalf("NewFailoverAIService failed: %v", err)
+	}
+
+	ctx, recorder := WithResponseRecorder(context.Background())
+	response, err := failover.QueryAI(ctx, "agents")
+	if err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if response != "agents from openai" {
+		t.Errorf("Unexpected response: %q", response)
+	}
+	if metadata, ok := recorder.Describe("agents", response); !ok || metadata.Provider != "openai" {
+		t.Errorf("Expected openai to be recorded as serving provider, got %+v", metadata)
+	}
+	if counts := failover.ServedCounts(); counts["openai"] != 1 || counts["ollama"] != 0 {
+		t.Errorf("Unexpected served counts: %v", counts)
+	}
+}
+
+func TestFailoverAIService_AllProvidersFail(t *testing.T) {
+	primary := &fakeProvider{id: "ollama", err: errors.New("connection refused")}
+	secondary := &fakeProvider{id: "openai", err: errors.New("bad gateway")}
+
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	_, err := failover.QueryWithContext(context.Background(), "agents", "history")
+	if err == nil {
+		t.Fatal("Expected error when all providers fail")
+	}
+	if !strings.Contains(err.Error(), "connection refused") || !strings.Contains(err.Error(), "bad gateway") {
+		t.Errorf("Expected error to include every provider failure, got %v", err)
+	}
+}
+
+func TestFailoverAIService_SkipsThrottledAndQuotaExhausted(t *testing.T) {
+	primary := &fakeProvider{id: "ollama"}
+	secondary := &fakeProvider{id: "openai"}
+
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+	rateLimiter := newTestFailoverRateLimiter("ollama", "openai")
+	failover.SetRateLimiter(rateLimiter)
+
+	// Exhaust the primary's per-minute limit
+	rateLimiter.RegisterCall("ollama")
+	rateLimiter.RegisterCall("ollama")
+
+	if _, err := failover.QueryAI(context.Background(), "agents"); err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+	if primary.calls != 0 || secondary.calls != 1 {
+		t.Errorf("Expected throttled primary to be skipped, got primary=%d secondary=%d", primary.calls, secondary.calls)
+	}
+
+	rateLimiter.SetQuotaExhausted("openai", time.Now().Add(time.Hour))
+	_, err := failover.QueryAI(context.Background(), "agents")
+	if err == nil || !strings.Contains(err.Error(), "all AI providers are unavailable") {
+		t.Errorf("Expected unavailable error, got %v", err)
+	}
+}
+
+func TestFailoverAIService_FlagsQuotaExhaustion(t *testing.T) {
+	primary := &fakeProvider{id: "openai", err: fmt.Errorf("openai API returned status 429: %w", ErrQuotaExhausted)}
+	secondary := &fakeProvider{id: "ollama"}
+
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+	rateLimiter := newTestFailoverRateLimiter("openai", "ollama")
+	failover.SetRateLimiter(rateLimiter)
+
+	if _, err := failover.QueryAI(context.Background(), "agents"); err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+	if status := rateLimiter.GetProviderStatus("openai"); status != "Quota Exhausted" {
+		t.Errorf("Expected openai to be flagged as quota exhausted, got %s", status)
+	}
+
+	// The exhausted provider is no longer attempted
+	failover.QueryAI(context.Background(), "agents")
+	if primary.calls != 1 {
+		t.Errorf("Expected quota exhausted provider to be skipped, got %d calls", primary.calls)
+	}
+}
+
+func TestFailoverAIService_DeprioritizesFailingProvider(t *testing.T) {
+	primary := &fakeProvider{id: "ollama", err: errors.New("timeout")}
+	secondary := &fakeProvider{id: "openai"}
+
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	for i := 0; i < defaultErrorRateMinSamples; i++ {
+		failover.QueryAI(context.Background(), "agents")
+	}
+	if primary.calls != defaultErrorRateMinSamples {
+		t.Fatalf("Expected primary to be attempted until the error rate is established, got %d calls", primary.calls)
+	}
+
+	// The failing primary is now tried after the healthy provider
+	failover.QueryAI(context.Background(), "agents")
+	if primary.calls != defaultErrorRateMinSamples {
+		t.Errorf("Expected failing primary to be deprioritized, got %d calls", primary.calls)
+	}
+
+	// Outcomes outside the window no longer count
+	failover.errorRateWindow = 0
+	primary.err = nil
+	if response, _ := failover.QueryAI(context.Background(), "agents"); response != "agents from ollama" {
+		t.Errorf("Expected recovered primary to serve again, got %q", response)
+	}
+}
+
+func TestFailoverAIService_StatusCallbacks(t *testing.T) {
+	primary := &fakeProvider{id: "ollama"}
+	secondary := &fakeProvider{id: "openai"}
+
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+	rateLimiter := newTestFailoverRateLimiter("ollama", "openai")
+	failover.SetRateLimiter(rateLimiter)
+	rateLimiter.RegisterStatusCallback(failover.HandleProviderStatusChange)
+
+	statuses := make(chan string, 10)
+	failover.RegisterStatusCallback(func(providerID, status string) {
+		if providerID != FailoverProviderID {
+			t.Errorf("Expected callback for %s, got %s", FailoverProviderID, providerID)
+		}
+		statuses <- status
+	})
+
+	expectStatus := func(expected string) {
+		t.Helper()
+		select {
+		case status := <-statuses:
+			if status != expected {
+				t.Errorf("Expected aggregate status %s, got %s", expected, status)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for status %s", expected)
+		}
+	}
+
+	// Serving from a healthy primary reports Normal
+	failover.QueryAI(context.Background(), "agents")
+	expectStatus("Normal")
+
+	// Throttling the primary degrades to the fallback
+	rateLimiter.RegisterCall("ollama")
+	rateLimiter.RegisterCall("ollama")
+	expectStatus("Warning")
+
+	// No provider left
+	rateLimiter.SetQuotaExhausted("openai", time.Now().Add(time.Hour))
+	expectStatus("Throttled")
+}
+
+func TestFailoverAIService_StreamsWithNonStreamingProvider(t *testing.T) {
+	provider := &fakeProvider{id: "openai"}
+	failover, _ := NewFailoverAIService([]ProviderAIService{provider}, newTestRetrievalLogger())
+
+	var partials []string
+	history := []ChatMessage{{Role: ChatRoleUser, Content: "hi"}, {Role: ChatRoleAssistant, Content: "hello"}}
+	response, err := failover.QueryWithMessagesStream(context.Background(), "agents", history, func(partial string) {
+		partials = append(partials, partial)
+	})
+	if err != nil {
+		t.Fatalf("QueryWithMessagesStream failed: %v", err)
+	}
+	if response != "agents from openai" || len(partials) != 1 || partials[0] != response {
+		t.Errorf("Expected single progress update with the full response, got %q and %v", response, partials)
+	}
+}
+
+func TestFailoverAIService_StopsWhenCancelled(t *testing.T) {
+	primary := &fakeProvider{id: "ollama"}
+	secondary := &fakeProvider{id: "openai"}
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < defaultErrorRateMinSamples; i++ {
+		if _, err := failover.QueryAI(ctx, "agents"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	}
+	if secondary.calls != 0 {
+		t.Errorf("Expected no failover after cancellation, got %d secondary calls", secondary.calls)
+	}
+
+	// Cancelled requests do not count against the provider
+	if response, _ := failover.QueryAI(context.Background(), "agents"); response != "agents from ollama" {
+		t.Errorf("Expected primary to keep serving after cancelled requests, got %q", response)
+	}
+}
+
+func TestFailoverAIService_RefreshKnowledgeBase(t *testing.T) {
+	primary := &fakeProvider{id: "ollama"}
+	secondary := &fakeProvider{id: "openai", refreshErr: errors.New("cache missing")}
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	err := failover.RefreshKnowledgeBase()
+	if err == nil || !strings.Contains(err.Error(), "provider openai: cache missing") {
+		t.Errorf("Expected refresh error naming the provider, got %v", err)
+	}
+}
+
+func TestFailoverAIService_HealthCheck(t *testing.T) {
+	primary := &fakeProvider{id: "ollama", healthErr: errors.New("connection refused")}
+	secondary := &fakeProvider{id: "openai"}
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	if err := failover.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected chain to be healthy while a fallback is reachable, got %v", err)
+	}
+
+	secondary.healthErr = errors.New("bad gateway")
+	err := failover.HealthCheck(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "provider ollama: connection refused") || !strings.Contains(err.Error(), "provider openai: bad gateway") {
+		t.Errorf("Expected error naming every unreachable provider, got %v", err)
+	}
+}
+
+func TestParseProviderList(t *testing.T) {
+	providers := ParseProviderList(" Ollama, openai,,ollama ")
+	if strings.Join(providers, ",") != "ollama,openai" {
+		t.Errorf("Unexpected providers: %v", providers)
+	}
+
+	if providers := ParseProviderList(""); len(providers) != 0 {
+		t.Errorf("Expected no providers for empty value, got %v", providers)
+	}
+}
+
+func TestNextQuotaReset(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	expected := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+	if reset := nextQuotaReset(now); !reset.Equal(expected) {
+		t.Errorf("Expected reset at %v, got %v", expected, reset)
+	}
+}
+
+// describedProvider describes its responses with a fixed model
+type describedProvider struct {
+	*fakeProvider
+	model string
+}
+
+func (p *describedProvider) DescribeResponse(query, response string) ResponseMetadata {
+	return ResponseMetadata{Provider: p.id, Model: p.model}
+}
+
+func TestFailoverAIService_ResponseRecorder(t *testing.T) {
+	primary := &describedProvider{fakeProvider: &fakeProvider{id: "ollama"}, model: "llama3.2"}
+	secondary := &describedProvider{fakeProvider: &fakeProvider{id: "openai"}, model: "gpt-4o-mini"}
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	// Each request is described by the provider that served it, even when a later request is served elsewhere
+	primaryCtx, primaryRecorder := WithResponseRecorder(context.Background())
+	if _, err := failover.QueryWithMessages(primaryCtx, "agents", nil); err != nil {
+		t.Fatalf("QueryWithMessages failed: %v", err)
+	}
+
+	primary.err = errors.New("connection refused")
+	secondaryCtx, secondaryRecorder := WithResponseRecorder(context.Background())
+	if _, err := failover.QueryAI(secondaryCtx, "agents"); err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+
+	if metadata, ok := primaryRecorder.Describe("agents", "agents from ollama"); !ok || metadata.Provider != "ollama" || metadata.Model != "llama3.2" {
+		t.Errorf("Expected first request to be attributed to ollama, got %+v", metadata)
+	}
+	if metadata, ok := secondaryRecorder.Describe("agents", "agents from openai"); !ok || metadata.Provider != "openai" || metadata.Model != "gpt-4o-mini" {
+		t.Errorf("Expected second request to be attributed to openai, got %+v", metadata)
+	}
+
+	// Summaries made with the request context do not replace the provider that answered it
+	primary.err = nil
+	if _, err := failover.SummarizeConversation(secondaryCtx, []string{"message"}); err != nil {
+		t.Fatalf("SummarizeConversation failed: %v", err)
+	}
+	if metadata, _ := secondaryRecorder.Describe("agents", "agents from openai"); metadata.Provider != "openai" {
+		t.Errorf("Expected summary not to change the serving provider, got %+v", metadata)
+	}
+
+	// Failed requests and contexts without a recorder record nothing
+	primary.err = errors.New("connection refused")
+	secondary.err = errors.New("bad gateway")
+	failedCtx, failedRecorder := WithResponseRecorder(context.Background())
+	failover.QueryAI(failedCtx, "agents")
+	if _, ok := failedRecorder.Describe("agents", ""); ok {
+		t.Error("Expected no provider to be recorded for a failed request")
+	}
+	var missing *ResponseRecorder
+	if _, ok := missing.Describe("agents", ""); ok {
+		t.Error("Expected a nil recorder to describe nothing")
+	}
+}
+
+// sizedProvider reports a fixed prompt size
+type sizedProvider struct {
+	*fakeProvider
+	tokens int
+}
+
+func (p *sizedProvider) PromptTokens() int { return p.tokens }
+
+func TestFailoverAIService_PromptTokens(t *testing.T) {
+	failover, _ := NewFailoverAIService([]ProviderAIService{
+		&sizedProvider{fakeProvider: &fakeProvider{id: "ollama"}, tokens: 1200},
+		&fakeProvider{id: "local"},
+		&sizedProvider{fakeProvider: &fakeProvider{id: "openai"}, tokens: 3400},
+	}, newTestRetrievalLogger())
+
+	if tokens := failover.PromptTokens(); tokens != 3400 {
+		t.Errorf("Expected the largest provider prompt of 3400 tokens, got %d", tokens)
+	}
+}
+
+// citingProvider cites a fixed set of knowledge base sections
+type citingProvider struct {
+	*fakeProvider
+	sections []KnowledgeSection
+}
+
+func (p *citingProvider) CiteSources(response string) []KnowledgeSection { return p.sections }
+
+func TestFailoverAIService_CiteSources(t *testing.T) {
+	failover, _ := NewFailoverAIService([]ProviderAIService{
+		&fakeProvider{id: "local"},
+		&citingProvider{fakeProvider: &fakeProvider{id: "ollama"}},
+		&citingProvider{fakeProvider: &fakeProvider{id: "openai"}, sections: []KnowledgeSection{{Heading: "Scrum Master", Anchor: "scrum-master"}}},
+	}, newTestRetrievalLogger())
+
+	sections := failover.CiteSources("The Scrum Master drafts stories.")
+	if len(sections) != 1 || sections[0].Anchor != "scrum-master" {
+		t.Errorf("Expected the first non-empty citation, got %+v", sections)
+	}
+}
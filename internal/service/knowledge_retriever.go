@@ -0,0 +1,568 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// DefaultRetrievalTopK is the number of knowledge base sections included in a prompt by default
+	DefaultRetrievalTopK = 5
+
+	// maxSectionLength caps the size of a single indexed section; longer sections are split on paragraphs
+	maxSectionLength = 4000
+
+	// historyScoreWeight scales how much conversation history contributes relative to the query itself
+	historyScoreWeight = 0.5
+
+	// maxHistoryLength limits retrieval to the most recent part of the conversation history
+	maxHistoryLength = 2000
+
+	// embeddingScoreWeight is the share of the hybrid score taken from embedding similarity
+	embeddingScoreWeight = 0.5
+
+	// BM25 tuning parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// retrievalStopWords are common words ignored when indexing and querying
+var retrievalStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "does": true, "for": true, "from": true, "how": true, "i": true, "if": true,
+	"in": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true, "or": true,
+	"should": true, "that": true, "the": true, "this": true, "to": true, "use": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true, "with": true, "you": true,
+	"your": true, "we": true, "our": true, "there": true, "their": true, "was": true, "were": true,
+}
+
+// KnowledgeSection is a heading-delimited chunk of the knowledge base
+type KnowledgeSection struct {
+	Heading string   // Heading text of the section (empty for content before the first heading)
+	Path    []string // Heading hierarchy from the top-level heading down to this section
+	Level   int      // Markdown heading level (0 for content before the first heading)
+	Content string   // Section markdown including its heading line
+	Anchor  string   // Document anchor of the heading (empty for content before the first heading)
+}
+
+// Title returns the heading hierarchy of the section joined for display
+func (s KnowledgeSection) Title() string {
+	return strings.Join(s.Path, " > ")
+}
+
+// ChunkKnowledgeBase splits markdown content into sections at each heading.
+// Headings inside fenced code blocks are ignored, sections without any body
+// text are dropped and oversized sections are split on paragraph boundaries.
+func ChunkKnowledgeBase(content string) []KnowledgeSection {
+	var sections []KnowledgeSection
+	var path []string
+	var levels []int
+	anchors := make(map[string]int)
+
+	current := KnowledgeSection{}
+	var body strings.Builder
+	inFence := false
+
+	flush := func() {
+		text := strings.TrimSpace(body.String())
+		body.Reset()
+
+		// Skip sections that only consist of their heading line
+		bodyText := text
+		if current.Level > 0 {
+			if idx := strings.Index(bodyText, "\n"); idx >= 0 {
+				bodyText = bodyText[idx+1:]
+			} else {
+				bodyText = ""
+			}
+		}
+		if strings.TrimSpace(bodyText) == "" {
+			return
+		}
+
+		for _, part := range splitSectionContent(text, maxSectionLength) {
+			section := current
+			section.Path = append([]string(nil), current.Path...)
+			section.Content = part
+			sections = append(sections, section)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		level, heading := parseMarkdownHeading(line)
+		if inFence || level == 0 {
+			body.WriteString(line)
+			body.WriteString("\n")
+			continue
+		}
+
+		flush()
+
+		// Pop headings at the same or a deeper level before descending
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+			path = path[:len(path)-1]
+		}
+		levels = append(levels, level)
+		path = append(path, heading)
+
+		// Repeated headings get numbered anchors, counted across every heading in the document
+		slug := HeadingAnchor(heading)
+		anchor := slug
+		if count := anchors[slug]; count > 0 {
+			anchor = fmt.Sprintf("%s-%d", slug, count)
+		}
+		anchors[slug]++
+
+		current = KnowledgeSection{
+			Heading: heading,
+			Path:    append([]string(nil), path...),
+			Level:   level,
+			Anchor:  anchor,
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+
+	return sections
+}
+
+// parseMarkdownHeading returns the level and text of an ATX heading line, or 0 if the line is not a heading
+func parseMarkdownHeading(line string) (int, string) {
+	if !strings.HasPrefix(line, "#") {
+		return 0, ""
+	}
+
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+
+	heading := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	if heading == "" {
+		return 0, ""
+	}
+	return level, heading
+}
+
+// splitSectionContent splits text into parts no longer than maxLength, breaking on blank lines where possible
+func splitSectionContent(text string, maxLength int) []string {
+	if len(text) <= maxLength {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(paragraph)+2 > maxLength {
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		parts = append(parts, strings.TrimSpace(current.String()))
+	}
+
+	return parts
+}
+
+// tokenizeForRetrieval lowercases text, splits it into words, drops stop words and folds simple plurals
+func tokenizeForRetrieval(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if len(word) < 2 || retrievalStopWords[word] {
+			continue
+		}
+		switch {
+		case len(word) > 4 && strings.HasSuffix(word, "ies"):
+			word = word[:len(word)-3] + "y"
+		case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+			word = word[:len(word)-1]
+		}
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
+
+// bm25Index is an Okapi BM25 index over tokenized documents
+type bm25Index struct {
+	termFreqs    []map[string]int
+	docLengths   []int
+	docFreqs     map[string]int
+	avgDocLength float64
+}
+
+// newBM25Index builds a BM25 index from tokenized documents
+func newBM25Index(docs [][]string) *bm25Index {
+	index := &bm25Index{
+		termFreqs:  make([]map[string]int, len(docs)),
+		docLengths: make([]int, len(docs)),
+		docFreqs:   make(map[string]int),
+	}
+
+	totalLength := 0
+	for i, tokens := range docs {
+		freqs := make(map[string]int)
+		for _, token := range tokens {
+			freqs[token]++
+		}
+		for token := range freqs {
+			index.docFreqs[token]++
+		}
+		index.termFreqs[i] = freqs
+		index.docLengths[i] = len(tokens)
+		totalLength += len(tokens)
+	}
+
+	if len(docs) > 0 {
+		index.avgDocLength = float64(totalLength) / float64(len(docs))
+	}
+
+	return index
+}
+
+// scores returns the BM25 score of every document for the given query terms
+func (b *bm25Index) scores(queryTerms []string) []float64 {
+	scores := make([]float64, len(b.termFreqs))
+	if len(queryTerms) == 0 || b.avgDocLength == 0 {
+		return scores
+	}
+
+	docCount := float64(len(b.termFreqs))
+	seen := make(map[string]bool)
+	for _, term := range queryTerms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		df := float64(b.docFreqs[term])
+		if df == 0 {
+			continue
+		}
+		idf := math.Log((docCount-df+0.5)/(df+0.5) + 1)
+
+		for i, freqs := range b.termFreqs {
+			tf := float64(freqs[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(b.docLengths[i])/b.avgDocLength
+			scores[i] += idf * (tf * (bm25K1 + 1)) / (tf + bm25K1*norm)
+		}
+	}
+
+	return scores
+}
+
+// EmbeddingProvider produces vector embeddings for text
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// OllamaEmbeddingClient generates embeddings using Ollama's /api/embeddings endpoint
+type OllamaEmbeddingClient struct {
+	client    *http.Client
+	baseURL   string
+	modelName string
+}
+
+// OllamaEmbeddingRequest represents a request to Ollama's /api/embeddings endpoint
+type OllamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// OllamaEmbeddingResponse represents a response from Ollama's /api/embeddings endpoint
+type OllamaEmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// NewOllamaEmbeddingClient creates an embedding client for the given Ollama server and embedding model
+func NewOllamaEmbeddingClient(client *http.Client, baseURL, modelName string) *OllamaEmbeddingClient {
+	return &OllamaEmbeddingClient{
+		client:    client,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		modelName: modelName,
+	}
+}
+
+// Embed returns the embedding vector for text
+func (c *OllamaEmbeddingClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	reqBody, err := json.Marshal(OllamaEmbeddingRequest{Model: c.modelName, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embeddings", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var embeddingResp OllamaEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	if len(embeddingResp.Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding returned for model %s", c.modelName)
+	}
+
+	return embeddingResp.Embedding, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if they are incompatible
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// KnowledgeRetriever selects the knowledge base sections most relevant to a query
+type KnowledgeRetriever struct {
+	topK        int
+	embedder    EmbeddingProvider
+	logger      *slog.Logger
+	mu          sync.RWMutex
+	sections    []KnowledgeSection
+	index       *bm25Index
+	embeddings  [][]float64
+	contentHash [32]byte
+}
+
+// NewKnowledgeRetriever creates a retriever returning up to topK sections.
+// A nil embedder restricts ranking to BM25.
+func NewKnowledgeRetriever(topK int, embedder EmbeddingProvider, logger *slog.Logger) *KnowledgeRetriever {
+	if topK <= 0 {
+		topK = DefaultRetrievalTopK
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &KnowledgeRetriever{
+		topK:     topK,
+		embedder: embedder,
+		logger:   logger,
+	}
+}
+
+// Index chunks and indexes the knowledge base content, replacing any previous index.
+// Unchanged content is not re-indexed. Embedding failures fall back to BM25-only ranking.
+func (r *KnowledgeRetriever) Index(ctx context.Context, content string) error {
+	hash := sha256.Sum256([]byte(content))
+
+	r.mu.RLock()
+	unchanged := r.index != nil && hash == r.contentHash
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	sections := ChunkKnowledgeBase(content)
+	if len(sections) == 0 {
+		return fmt.Errorf("knowledge base contains no indexable sections")
+	}
+
+	docs := make([][]string, len(sections))
+	for i, section := range sections {
+		// Heading terms are counted twice so they outweigh incidental mentions in body text
+		headingTokens := tokenizeForRetrieval(strings.Join(section.Path, " "))
+		docs[i] = append(append(headingTokens, headingTokens...), tokenizeForRetrieval(section.Content)...)
+	}
+	index := newBM25Index(docs)
+
+	var embeddings [][]float64
+	if r.embedder != nil {
+		embeddings = make([][]float64, len(sections))
+		for i, section := range sections {
+			embedding, err := r.embedder.Embed(ctx, section.Content)
+			if err != nil {
+				r.logger.Warn("Failed to embed knowledge base section, using BM25 ranking only",
+					"section", section.Title(),
+					"error", err)
+				embeddings = nil
+				break
+			}
+			embeddings[i] = embedding
+		}
+	}
+
+	r.mu.Lock()
+	r.sections = sections
+	r.index = index
+	r.embeddings = embeddings
+	r.contentHash = hash
+	r.mu.Unlock()
+
+	r.logger.Info("Knowledge base indexed for retrieval",
+		"sections", len(sections),
+		"embeddings_enabled", embeddings != nil,
+		"size", len(content))
+
+	return nil
+}
+
+// SectionCount returns the number of indexed sections
+func (r *KnowledgeRetriever) SectionCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sections)
+}
+
+// MaxContextSize returns the largest size in bytes the formatted sections returned by Retrieve can have
+func (r *KnowledgeRetriever) MaxContextSize() int {
+	r.mu.RLock()
+	sections := r.sections
+	r.mu.RUnlock()
+
+	sizes := make([]int, len(sections))
+	for i, section := range sections {
+		sizes[i] = len(FormatKnowledgeSections([]KnowledgeSection{section}))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	total := 0
+	for i := 0; i < min(r.topK, len(sizes)); i++ {
+		total += sizes[i] + len("\n\n---\n\n")
+	}
+	return total
+}
+
+// Retrieve returns up to topK sections relevant to the query and recent conversation history,
+// in knowledge base order. When nothing matches, the opening sections are returned as an overview.
+func (r *KnowledgeRetriever) Retrieve(ctx context.Context, query, history string) []KnowledgeSection {
+	r.mu.RLock()
+	sections := r.sections
+	index := r.index
+	embeddings := r.embeddings
+	r.mu.RUnlock()
+
+	if index == nil || len(sections) == 0 {
+		return nil
+	}
+
+	if len(history) > maxHistoryLength {
+		history = history[len(history)-maxHistoryLength:]
+	}
+
+	scores := index.scores(tokenizeForRetrieval(query))
+	if strings.TrimSpace(history) != "" {
+		for i, score := range index.scores(tokenizeForRetrieval(history)) {
+			scores[i] += historyScoreWeight * score
+		}
+	}
+
+	// Blend normalized BM25 scores with embedding similarity when available
+	if embeddings != nil {
+		if queryEmbedding, err := r.embedder.Embed(ctx, strings.TrimSpace(history+"\n"+query)); err != nil {
+			r.logger.Warn("Failed to embed query, using BM25 ranking only", "error", err)
+		} else {
+			maxScore := 0.0
+			for _, score := range scores {
+				maxScore = math.Max(maxScore, score)
+			}
+			for i := range scores {
+				lexical := 0.0
+				if maxScore > 0 {
+					lexical = scores[i] / maxScore
+				}
+				semantic := math.Max(0, cosineSimilarity(queryEmbedding, embeddings[i]))
+				scores[i] = (1-embeddingScoreWeight)*lexical + embeddingScoreWeight*semantic
+			}
+		}
+	}
+
+	ranked := make([]int, 0, len(sections))
+	for i, score := range scores {
+		if score > 0 {
+			ranked = append(ranked, i)
+		}
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	k := min(r.topK, len(sections))
+	if len(ranked) == 0 {
+		return append([]KnowledgeSection(nil), sections[:k]...)
+	}
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	// Present the selected sections in document order so related subsections read naturally
+	sort.Ints(ranked)
+	result := make([]KnowledgeSection, len(ranked))
+	for i, idx := range ranked {
+		result[i] = sections[idx]
+	}
+
+	return result
+}
+
+// FormatKnowledgeSections renders retrieved sections for inclusion in a prompt
+func FormatKnowledgeSections(sections []KnowledgeSection) string {
+	parts := make([]string, 0, len(sections))
+	for _, section := range sections {
+		if len(section.Path) > 1 {
+			parts = append(parts, fmt.Sprintf("[Section: %s]\n%s", section.Title(), section.Content))
+		} else {
+			parts = append(parts, section.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n---\n\n")
+}
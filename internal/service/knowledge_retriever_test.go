@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testRetrievalKB = `# BMAD Knowledge Base
+
+## Overview
+
+BMAD-METHOD combines AI agents with Agile development methodologies.
+
+## Agents
+
+### Scrum Master
+
+The Scrum Master agent drafts stories from sharded epics.
+
+### Architect
+
+The Architect agent designs the system architecture document.
+
+` + "```" + `
+# not a heading inside a code fence
+` + "```" + `
+
+## Workflows
+
+### Greenfield
+
+Greenfield workflows start new projects from a PRD.
+
+### Brownfield
+
+Brownfield workflows enhance existing projects and legacy codebases.
+`
+
+func newTestRetrievalLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func TestChunkKnowledgeBase(t *testing.T) {
+	sections := ChunkKnowledgeBase(testRetrievalKB)
+
+	// Heading-only sections ("# BMAD Knowledge Base", "## Agents", "## Workflows") are dropped
+	expectedTitles := []string{
+		"BMAD Knowledge Base > Overview",
+		"BMAD Knowledge Base > Agents > Scrum Master",
+		"BMAD Knowledge Base > Agents > Architect",
+		"BMAD Knowledge Base > Workflows > Greenfield",
+		"BMAD Knowledge Base > Workflows > Brownfield",
+	}
+
+	if len(sections) != len(expectedTitles) {
+		t.Fatalf("Expected %d sections, got %d", len(expectedTitles), len(sections))
+	}
+
+	for i, expected := range expectedTitles {
+		if sections[i].Title() != expected {
+			t.Errorf("Section %d: expected title %q, got %q", i, expected, sections[i].Title())
+		}
+	}
+
+	architect := sections[2]
+	if architect.Level != 3 || architect.Heading != "Architect" {
+		t.Errorf("Unexpected architect section metadata: level=%d heading=%q", architect.Level, architect.Heading)
+	}
+	if !strings.Contains(architect.Content, "# not a heading inside a code fence") {
+		t.Error("Expected fenced code to remain part of the architect section")
+	}
+	if !strings.HasPrefix(architect.Content, "### Architect") {
+		t.Errorf("Expected section content to start with its heading, got %q", architect.Content)
+	}
+}
+
+func TestChunkKnowledgeBase_SplitsLargeSections(t *testing.T) {
+	paragraph := strings.Repeat("agile workflow text ", 100)
+	content := "## Large\n\n" + strings.Join([]string{paragraph, paragraph, paragraph, paragraph}, "\n\n")
+
+	sections := ChunkKnowledgeBase(content)
+	if len(sections) < 2 {
+		t.Fatalf("Expected large section to be split, got %d sections", len(sections))
+	}
+
+	for _, section := range sections {
+		if len(section.Content) > maxSectionLength {
+			t.Errorf("Section exceeds max length: %d", len(section.Content))
+		}
+		if section.Heading != "Large" {
+			t.Errorf("Expected split parts to keep heading 'Large', got %q", section.Heading)
+		}
+	}
+}
+
+func TestParseMarkdownHeading(t *testing.T) {
+	tests := []struct {
+		line            string
+		expectedLevel   int
+		expectedHeading string
+	}{
+		{"# Title", 1, "Title"},
+		{"### Sub Title ###", 3, "Sub Title"},
+		{"#hashtag", 0, ""},
+		{"####### too deep", 0, ""},
+		{"plain text", 0, ""},
+		{"#", 0, ""},
+	}
+
+	for _, tt := range tests {
+		level, heading := parseMarkdownHeading(tt.line)
+		if level != tt.expectedLevel || heading != tt.expectedHeading {
+			t.Errorf("parseMarkdownHeading(%q) = (%d, %q), expected (%d, %q)",
+				tt.line, level, heading, tt.expectedLevel, tt.expectedHeading)
+		}
+	}
+}
+
+func TestTokenizeForRetrieval(t *testing.T) {
+	tokens := tokenizeForRetrieval("What are the Agents and Stories in BMAD?")
+	expected := []string{"agent", "story", "bmad"}
+
+	if strings.Join(tokens, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected tokens %v, got %v", expected, tokens)
+	}
+}
+
+func TestKnowledgeRetriever_RetrieveBM25(t *testing.T) {
+	retriever := NewKnowledgeRetriever(1, nil, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	if retriever.SectionCount() != 5 {
+		t.Errorf("Expected 5 indexed sections, got %d", retriever.SectionCount())
+	}
+
+	sections := retriever.Retrieve(context.Background(), "How does the Scrum Master draft stories?", "")
+	if len(sections) != 1 || sections[0].Heading != "Scrum Master" {
+		t.Fatalf("Expected Scrum Master section, got %+v", sections)
+	}
+
+	sections = retriever.Retrieve(context.Background(), "legacy codebases", "")
+	if len(sections) != 1 || sections[0].Heading != "Brownfield" {
+		t.Fatalf("Expected Brownfield section, got %+v", sections)
+	}
+}
+
+func TestKnowledgeRetriever_RetrieveUsesHistory(t *testing.T) {
+	retriever := NewKnowledgeRetriever(1, nil, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	// The follow-up question alone carries no searchable terms
+	history := "User: Tell me about the Architect agent\nBot: The Architect designs the system architecture."
+	sections := retriever.Retrieve(context.Background(), "what else does it do?", history)
+	if len(sections) != 1 || sections[0].Heading != "Architect" {
+		t.Fatalf("Expected Architect section from history, got %+v", sections)
+	}
+}
+
+func TestKnowledgeRetriever_RetrieveFallsBackToOverview(t *testing.T) {
+	retriever := NewKnowledgeRetriever(2, nil, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	sections := retriever.Retrieve(context.Background(), "hello there", "")
+	if len(sections) != 2 || sections[0].Heading != "Overview" {
+		t.Fatalf("Expected opening sections as fallback, got %+v", sections)
+	}
+}
+
+func TestKnowledgeRetriever_RetrieveReturnsDocumentOrder(t *testing.T) {
+	retriever := NewKnowledgeRetriever(2, nil, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	sections := retriever.Retrieve(context.Background(), "brownfield greenfield workflows", "")
+	if len(sections) != 2 || sections[0].Heading != "Greenfield" || sections[1].Heading != "Brownfield" {
+		t.Fatalf("Expected Greenfield then Brownfield, got %+v", sections)
+	}
+}
+
+func TestKnowledgeRetriever_MaxContextSize(t *testing.T) {
+	retriever := NewKnowledgeRetriever(2, nil, newTestRetrievalLogger())
+	if size := retriever.MaxContextSize(); size != 0 {
+		t.Errorf("Expected 0 before indexing, got %d", size)
+	}
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	// Whatever is retrieved fits within the reported size
+	for _, query := range []string{"scrum master", "brownfield greenfield workflows", "architecture agents", "unrelated"} {
+		knowledge := FormatKnowledgeSections(retriever.Retrieve(context.Background(), query, ""))
+		if len(knowledge) > retriever.MaxContextSize() {
+			t.Errorf("Retrieved %d bytes for %q, more than MaxContextSize %d", len(knowledge), query, retriever.MaxContextSize())
+		}
+	}
+	if size := retriever.MaxContextSize(); size >= len(testRetrievalKB) {
+		t.Errorf("Expected two sections to be smaller than the knowledge base, got %d of %d bytes", size, len(testRetrievalKB))
+	}
+}
+
+func TestKnowledgeRetriever_NotIndexed(t *testing.T) {
+	retriever := NewKnowledgeRetriever(3, nil, newTestRetrievalLogger())
+
+	if sections := retriever.Retrieve(context.Background(), "agents", ""); sections != nil {
+		t.Errorf("Expected no sections before indexing, got %d", len(sections))
+	}
+
+	if err := retriever.Index(context.Background(), ""); err == nil {
+		t.Error("Expected error indexing empty content")
+	}
+}
+
+func TestKnowledgeRetriever_HybridEmbeddings(t *testing.T) {
+	embedCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+
+		var req OllamaEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode embedding request: %v", err)
+		}
+		if req.Model != "nomic-embed-text" {
+			t.Errorf("Expected embedding model nomic-embed-text, got %s", req.Model)
+		}
+		embedCalls++
+
+		// Map texts mentioning "design" (and the Architect section) to the same direction
+		embedding := []float64{1, 0}
+		if strings.Contains(req.Prompt, "design") {
+			embedding = []float64{0, 1}
+		}
+		json.NewEncoder(w).Encode(OllamaEmbeddingResponse{Embedding: embedding})
+	}))
+	defer server.Close()
+
+	embedder := NewOllamaEmbeddingClient(server.Client(), server.URL, "nomic-embed-text")
+	retriever := NewKnowledgeRetriever(1, embedder, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	if embedCalls != 5 {
+		t.Errorf("Expected one embedding call per section, got %d", embedCalls)
+	}
+
+	// No lexical overlap with the Architect section, so the match comes from embeddings
+	sections := retriever.Retrieve(context.Background(), "who handles design?", "")
+	if len(sections) != 1 || sections[0].Heading != "Architect" {
+		t.Fatalf("Expected Architect section via embeddings, got %+v", sections)
+	}
+
+	// Unchanged content is not re-embedded
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Re-index failed: %v", err)
+	}
+	if embedCalls != 6 {
+		t.Errorf("Expected unchanged content to skip re-indexing, got %d embedding calls", embedCalls)
+	}
+}
+
+func TestKnowledgeRetriever_EmbeddingFailureFallsBackToBM25(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model not found"}`)
+	}))
+	defer server.Close()
+
+	embedder := NewOllamaEmbeddingClient(server.Client(), server.URL, "missing-model")
+	retriever := NewKnowledgeRetriever(1, embedder, newTestRetrievalLogger())
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	sections := retriever.Retrieve(context.Background(), "scrum master", "")
+	if len(sections) != 1 || sections[0].Heading != "Scrum Master" {
+		t.Fatalf("Expected BM25 result when embeddings are unavailable, got %+v", sections)
+	}
+}
+
+func TestFormatKnowledgeSections(t *testing.T) {
+	formatted := FormatKnowledgeSections([]KnowledgeSection{
+		{Heading: "Overview", Path: []string{"Overview"}, Level: 2, Content: "## Overview\nIntro"},
+		{Heading: "Architect", Path: []string{"Agents", "Architect"}, Level: 3, Content: "### Architect\nDesigns"},
+	})
+
+	expected := "## Overview\nIntro\n\n---\n\n[Section: Agents > Architect]\n### Architect\nDesigns"
+	if formatted != expected {
+		t.Errorf("Unexpected formatted sections:\n%s", formatted)
+	}
+}
+
+func TestOllamaAIService_KnowledgeContext(t *testing.T) {
+	service := &OllamaAIService{
+		logger:            newTestRetrievalLogger(),
+		bmadKnowledgeBase: testRetrievalKB,
+	}
+
+	// Without a retriever the full knowledge base is used
+	if service.knowledgeContext(context.Background(), "scrum master", "") != testRetrievalKB {
+		t.Error("Expected full knowledge base without retriever")
+	}
+
+	service.retriever = NewKnowledgeRetriever(1, nil, service.logger)
+	service.indexKnowledgeBase(testRetrievalKB)
+
+	knowledge := service.knowledgeContext(context.Background(), "scrum master", "")
+	if !strings.Contains(knowledge, "drafts stories") || strings.Contains(knowledge, "legacy codebases") {
+		t.Errorf("Expected only the Scrum Master section, got %q", knowledge)
+	}
+
+	prompt := service.buildSystemPrompt(context.Background(), "scrum master", "")
+	if strings.Contains(prompt, "legacy codebases") {
+		t.Error("Expected prompt to omit unrelated knowledge base sections")
+	}
+}
+
+func TestOllamaAIService_PromptTokens(t *testing.T) {
+	service := &OllamaAIService{
+		logger:            newTestRetrievalLogger(),
+		bmadKnowledgeBase: testRetrievalKB,
+	}
+
+	full := service.PromptTokens()
+	if full < EstimateTokens(testRetrievalKB) {
+		t.Errorf("Expected the prompt estimate to cover the full knowledge base, got %d tokens", full)
+	}
+
+	service.retriever = NewKnowledgeRetriever(1, nil, service.logger)
+	service.indexKnowledgeBase(testRetrievalKB)
+	if retrieved := service.PromptTokens(); retrieved >= full {
+		t.Errorf("Expected retrieval to shrink the prompt estimate, got %d of %d tokens", retrieved, full)
+	}
+}
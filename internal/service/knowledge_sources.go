@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxCitedSources caps how many knowledge base sections are cited for one answer
+	maxCitedSources = 3
+
+	// citationScoreRatio is the share of the best section's score another section needs to be cited as well
+	citationScoreRatio = 0.5
+
+	// minCitationTerms is how many distinct knowledge base terms an answer needs before it cites sources,
+	// so short replies such as refusals are not attributed to arbitrary sections
+	minCitationTerms = 5
+)
+
+// HeadingAnchor returns the GitHub anchor of a markdown heading: lower case, with punctuation removed and
+// spaces turned into hyphens. Repeated headings get a numeric suffix, which ChunkKnowledgeBase adds.
+func HeadingAnchor(heading string) string {
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), unicode.IsMark(r), r == '_', r == '-':
+			anchor.WriteRune(r)
+		case r == ' ':
+			anchor.WriteRune('-')
+		}
+	}
+	return anchor.String()
+}
+
+// KnowledgeDocumentURL returns the rendered page of the knowledge base document downloaded from remoteURL,
+// so heading anchors resolve. GitHub raw file links are turned into their blob view; other URLs are used as is.
+func KnowledgeDocumentURL(remoteURL string) string {
+	parsed, err := url.Parse(remoteURL)
+	if err != nil || parsed.Host == "" {
+		return remoteURL
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	var owner, repo string
+	var ref []string
+	switch {
+	case parsed.Host == "github.com" && len(segments) > 3 && segments[2] == "raw":
+		owner, repo, ref = segments[0], segments[1], segments[3:]
+	case parsed.Host == "raw.githubusercontent.com" && len(segments) > 2:
+		owner, repo, ref = segments[0], segments[1], segments[2:]
+	default:
+		return parsed.String()
+	}
+
+	// Branch and tag refs may be spelled out in raw links but not in blob links
+	if len(ref) > 2 && ref[0] == "refs" && (ref[1] == "heads" || ref[1] == "tags") {
+		ref = ref[2:]
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/blob/%s", owner, repo, strings.Join(ref, "/"))
+}
+
+// Sources returns the knowledge base sections that best support response, most relevant first.
+// Sections are ranked by BM25 against the response text; only headed sections scoring close to the
+// best one are returned, with each heading at most once.
+func (r *KnowledgeRetriever) Sources(response string) []KnowledgeSection {
+	r.mu.RLock()
+	sections := r.sections
+	index := r.index
+	r.mu.RUnlock()
+
+	if index == nil || len(sections) == 0 {
+		return nil
+	}
+
+	terms := tokenizeForRetrieval(response)
+	known := make(map[string]bool)
+	for _, term := range terms {
+		if index.docFreqs[term] > 0 {
+			known[term] = true
+		}
+	}
+	if len(known) < minCitationTerms {
+		return nil
+	}
+
+	scores := index.scores(terms)
+	ranked := make([]int, 0, len(sections))
+	for i, score := range scores {
+		if score > 0 && sections[i].Anchor != "" {
+			ranked = append(ranked, i)
+		}
+	}
+	if len(ranked) == 0 {
+		return nil
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	best := scores[ranked[0]]
+	seen := make(map[string]bool)
+	var cited []KnowledgeSection
+	for _, idx := range ranked {
+		if scores[idx] < best*citationScoreRatio || len(cited) == maxCitedSources {
+			break
+		}
+		if seen[sections[idx].Anchor] {
+			continue
+		}
+		seen[sections[idx].Anchor] = true
+		cited = append(cited, sections[idx])
+	}
+
+	return cited
+}
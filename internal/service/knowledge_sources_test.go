@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	testCases := map[string]string{
+		"Scrum Master":                     "scrum-master",
+		"What is BMAD-METHOD?":             "what-is-bmad-method",
+		"Core Philosophy: Vibe CEO'ing":    "core-philosophy-vibe-ceoing",
+		"IDE vs Web UI (Bundles)":          "ide-vs-web-ui-bundles",
+		"🚀 Getting Started":                "-getting-started",
+		"Step 1 - Planning & Architecture": "step-1---planning--architecture",
+	}
+	for heading, want := range testCases {
+		if got := HeadingAnchor(heading); got != want {
+			t.Errorf("HeadingAnchor(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestChunkKnowledgeBase_Anchors(t *testing.T) {
+	sections := ChunkKnowledgeBase(`# Guide
+
+## Greenfield
+
+### Setup
+
+Install the greenfield tooling.
+
+## Brownfield
+
+### Setup
+
+Document the existing project first.
+`)
+
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+	// Repeated headings are numbered like GitHub does
+	if sections[0].Anchor != "setup" || sections[1].Anchor != "setup-1" {
+		t.Errorf("Expected anchors setup and setup-1, got %q and %q", sections[0].Anchor, sections[1].Anchor)
+	}
+}
+
+func TestKnowledgeDocumentURL(t *testing.T) {
+	testCases := map[string]string{
+		"https://github.com/bmadcode/BMAD-METHOD/raw/refs/heads/main/bmad-core/data/bmad-kb.md": "https://github.com/bmadcode/BMAD-METHOD/blob/main/bmad-core/data/bmad-kb.md",
+		"https://github.com/bmadcode/BMAD-METHOD/raw/v4.0.0/bmad-core/data/bmad-kb.md":          "https://github.com/bmadcode/BMAD-METHOD/blob/v4.0.0/bmad-core/data/bmad-kb.md",
+		"https://raw.githubusercontent.com/bmadcode/BMAD-METHOD/refs/tags/v4/docs/kb.md":        "https://github.com/bmadcode/BMAD-METHOD/blob/v4/docs/kb.md",
+		"https://raw.githubusercontent.com/bmadcode/BMAD-METHOD/main/docs/kb.md?token=abc":      "https://github.com/bmadcode/BMAD-METHOD/blob/main/docs/kb.md",
+		"https://docs.example.com/bmad-kb.md":                                                   "https://docs.example.com/bmad-kb.md",
+		"not a url":                                                                             "not a url",
+	}
+	for remoteURL, want := range testCases {
+		if got := KnowledgeDocumentURL(remoteURL); got != want {
+			t.Errorf("KnowledgeDocumentURL(%q) = %q, want %q", remoteURL, got, want)
+		}
+	}
+}
+
+func TestKnowledgeRetriever_Sources(t *testing.T) {
+	retriever := NewKnowledgeRetriever(2, nil, newTestRetrievalLogger())
+	if sources := retriever.Sources("The Scrum Master agent drafts stories from sharded epics."); sources != nil {
+		t.Errorf("Expected no sources before indexing, got %+v", sources)
+	}
+	if err := retriever.Index(context.Background(), testRetrievalKB); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+
+	sources := retriever.Sources("In BMAD the Scrum Master agent drafts each story from the sharded epics so development can start.")
+	if len(sources) == 0 || sources[0].Anchor != "scrum-master" {
+		t.Fatalf("Expected the Scrum Master section first, got %+v", sources)
+	}
+	for _, source := range sources {
+		if source.Heading == "Brownfield" {
+			t.Errorf("Expected unrelated sections not to be cited, got %+v", sources)
+		}
+	}
+
+	// Short replies that barely touch the knowledge base cite nothing
+	if sources := retriever.Sources("Sorry, I don't know."); sources != nil {
+		t.Errorf("Expected no sources for a short reply, got %+v", sources)
+	}
+}
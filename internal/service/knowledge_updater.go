@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -42,6 +43,7 @@ type HTTPKnowledgeUpdater struct {
 	mu                 sync.RWMutex
 	status             RefreshStatus
 	logger             *slog.Logger
+	onContentChanged   func(content string)
 }
 
 type Config struct {
@@ -74,6 +76,13 @@ func NewHTTPKnowledgeUpdater(config Config, logger *slog.Logger) *HTTPKnowledgeU
 	}
 }
 
+// SetOnContentChanged registers a callback invoked with the new content after the cache is updated
+func (h *HTTPKnowledgeUpdater) SetOnContentChanged(callback func(content string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onContentChanged = callback
+}
+
 func (h *HTTPKnowledgeUpdater) Start(ctx context.Context) error {
 	if !h.enabled {
 		h.logger.Info("Knowledge base refresh service is disabled")
@@ -160,10 +169,16 @@ func (h *HTTPKnowledgeUpdater) RefreshNow() error {
 
 	h.mu.Lock()
 	h.status.UpdatesFound++
+	onContentChanged := h.onContentChanged
 	h.mu.Unlock()
 
 	h.logger.Info("Knowledge base successfully updated")
 	h.updateStatus(nil)
+
+	// contentChanged ignores the first cached line, so compare the raw cache before notifying
+	if onContentChanged != nil && cachedContent != remoteContent {
+		onContentChanged(remoteContent)
+	}
 	return nil
 }
 
@@ -240,10 +255,13 @@ func (h *HTTPKnowledgeUpdater) contentChanged(localContent, remoteContent string
 	localKB := h.extractKnowledgeBase(localContent)
 
 	// Compare content hashes
-	localHash := sha256.Sum256([]byte(localKB))
-	remoteHash := sha256.Sum256([]byte(remoteContent))
+	return KnowledgeBaseHash(localKB) != KnowledgeBaseHash(remoteContent)
+}
 
-	return localHash != remoteHash
+// KnowledgeBaseHash returns the hex SHA-256 of knowledge base content, identifying its version
+func KnowledgeBaseHash(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
 }
 
 func (h *HTTPKnowledgeUpdater) extractKnowledgeBase(content string) string {
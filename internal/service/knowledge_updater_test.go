@@ -439,3 +439,46 @@ func TestHTTPKnowledgeUpdater_GetLastRefresh(t *testing.T) {
 		t.Errorf("Expected zero time for failed refresh, got %v", lastRefresh)
 	}
 }
+
+func TestHTTPKnowledgeUpdater_OnContentChanged(t *testing.T) {
+	remoteContent := "System prompt\n# Knowledge Base\n\nUpdated content."
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(remoteContent))
+	}))
+	defer server.Close()
+
+	config := Config{
+		RemoteURL:          server.URL,
+		EphemeralCachePath: filepath.Join(t.TempDir(), "test_kb.md"),
+		RefreshInterval:    time.Hour,
+		Enabled:            true,
+		HTTPTimeout:        10 * time.Second,
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	updater := NewHTTPKnowledgeUpdater(config, logger)
+
+	var notified []string
+	updater.SetOnContentChanged(func(content string) {
+		notified = append(notified, content)
+	})
+
+	if err := updater.RefreshNow(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(notified) != 1 || notified[0] != remoteContent {
+		t.Fatalf("Expected one notification with the new content, got %v", notified)
+	}
+
+	// Cache now matches the remote content, so no further notification is sent
+	if err := updater.RefreshNow(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(notified) != 1 {
+		t.Errorf("Expected no notification for unchanged content, got %d", len(notified))
+	}
+}
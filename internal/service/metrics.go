@@ -0,0 +1,89 @@
+package service
+
+import (
+	"sort"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+// RegisterQualityMetrics exposes the running quality averages and response counts of every
+// quality-scoring provider behind aiService as gauges
+func RegisterQualityMetrics(metrics *monitor.Metrics, aiService AIService) {
+	reporters := qualityReporters(aiService)
+	if len(reporters) == 0 {
+		return
+	}
+
+	providerIDs := make([]string, 0, len(reporters))
+	for providerID := range reporters {
+		providerIDs = append(providerIDs, providerID)
+	}
+	sort.Strings(providerIDs)
+
+	metrics.RegisterGaugeFunc("bmad_bot_response_quality_score",
+		"Running average response quality score (0-1), by provider and dimension.",
+		[]string{"provider", "dimension"},
+		func() []monitor.GaugeSample {
+			var samples []monitor.GaugeSample
+			for _, providerID := range providerIDs {
+				quality := reporters[providerID].GetQualityMetrics()
+				samples = append(samples,
+					monitor.GaugeSample{LabelValues: []string{providerID, "overall"}, Value: quality.AverageOverallScore},
+					monitor.GaugeSample{LabelValues: []string{providerID, "bmad_coverage"}, Value: quality.AverageBMADScore},
+					monitor.GaugeSample{LabelValues: []string{providerID, "knowledge_boundary"}, Value: quality.AverageBoundaryScore},
+					monitor.GaugeSample{LabelValues: []string{providerID, "content"}, Value: quality.AverageContentScore})
+			}
+			return samples
+		})
+
+	metrics.RegisterGaugeFunc("bmad_bot_response_quality_responses",
+		"Responses scored for quality, by provider and category.",
+		[]string{"provider", "category"},
+		func() []monitor.GaugeSample {
+			var samples []monitor.GaugeSample
+			for _, providerID := range providerIDs {
+				quality := reporters[providerID].GetQualityMetrics()
+				samples = append(samples,
+					monitor.GaugeSample{LabelValues: []string{providerID, "total"}, Value: float64(quality.TotalResponses)},
+					monitor.GaugeSample{LabelValues: []string{providerID, "low_quality"}, Value: float64(quality.LowQualityResponses)},
+					monitor.GaugeSample{LabelValues: []string{providerID, "empty"}, Value: float64(quality.EmptyResponses)},
+					monitor.GaugeSample{LabelValues: []string{providerID, "off_topic"}, Value: float64(quality.OffTopicResponses)})
+			}
+			return samples
+		})
+}
+
+// RegisterAIQueueMetrics exposes the number of running and waiting AI jobs as a gauge
+func RegisterAIQueueMetrics(metrics *monitor.Metrics, queue *AIQueue) {
+	metrics.RegisterGaugeFunc("bmad_bot_ai_queue_jobs",
+		"AI jobs in the work queue, by state (running or waiting).",
+		[]string{"state"},
+		func() []monitor.GaugeSample {
+			stats := queue.Stats()
+			return []monitor.GaugeSample{
+				{LabelValues: []string{"running"}, Value: float64(stats.Running)},
+				{LabelValues: []string{"waiting"}, Value: float64(stats.Waiting)},
+			}
+		})
+}
+
+// qualityReporters returns the quality-scoring providers behind aiService keyed by provider ID,
+// looking through failover chains
+func qualityReporters(aiService AIService) map[string]QualityReporter {
+	reporters := make(map[string]QualityReporter)
+
+	providers := []AIService{aiService}
+	if failover, ok := aiService.(*FailoverAIService); ok {
+		providers = providers[:0]
+		for _, provider := range failover.Providers() {
+			providers = append(providers, provider)
+		}
+	}
+
+	for _, provider := range providers {
+		if reporter, ok := provider.(QualityReporter); ok {
+			reporters[provider.GetProviderID()] = reporter
+		}
+	}
+	return reporters
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+func scrapeMetrics(metrics *monitor.Metrics) string {
+	var output strings.Builder
+	metrics.WritePrometheus(&output)
+	return output.String()
+}
+
+func TestOllamaAIService_RecordsRequestMetrics(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "The Analyst researches the market."},
+			Done:    true,
+		})
+	}))
+	defer mockServer.Close()
+
+	service := &OllamaAIService{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		baseURL:           mockServer.URL,
+		modelName:         "devstral",
+		timeout:           10 * time.Second,
+		logger:            newTestRetrievalLogger(),
+		bmadKnowledgeBase: "Test BMAD knowledge base content",
+	}
+	metrics := monitor.NewMetrics()
+	service.SetMetrics(metrics)
+
+	if _, err := service.QueryAI(context.Background(), "What does the Analyst do?"); err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+
+	body := scrapeMetrics(metrics)
+	for _, expected := range []string{
+		`bmad_bot_ai_requests_total{provider="ollama",mode="blocking",outcome="success"} 1`,
+		`bmad_bot_ai_request_duration_seconds_count{provider="ollama",mode="blocking"} 1`,
+		`bmad_bot_ai_response_bytes_sum{provider="ollama"} 34`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRegisterQualityMetrics(t *testing.T) {
+	ollama := &OllamaAIService{
+		logger:         newTestRetrievalLogger(),
+		qualityEnabled: true,
+		qualityMetrics: &QualityMetrics{},
+	}
+	ollama.updateQualityMetrics(&QualityScore{OverallScore: 0.5, BMADCoverageScore: 0.25, KnowledgeBoundaryScore: 1, ContentQualityScore: 0.75})
+
+	// Quality scoring providers are found behind a failover chain; others are skipped
+	failover, err := NewFailoverAIService([]ProviderAIService{ollama, &fakeProvider{id: "openai"}}, newTestRetrievalLogger())
+	if err != nil {
+		t.Fatalf("NewFailoverAIService failed: %v", err)
+	}
+	metrics := monitor.NewMetrics()
+	RegisterQualityMetrics(metrics, failover)
+
+	body := scrapeMetrics(metrics)
+	for _, expected := range []string{
+		`bmad_bot_response_quality_score{provider="ollama",dimension="overall"} 0.5`,
+		`bmad_bot_response_quality_score{provider="ollama",dimension="bmad_coverage"} 0.25`,
+		`bmad_bot_response_quality_responses{provider="ollama",category="total"} 1`,
+		`bmad_bot_response_quality_responses{provider="ollama",category="low_quality"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, `provider="openai"`) {
+		t.Error("Expected providers without quality scoring to be skipped")
+	}
+}
+
+func TestFailoverAIService_SetMetrics(t *testing.T) {
+	primary := &fakeProvider{id: "ollama"}
+	secondary := &fakeProvider{id: "openai"}
+	failover, _ := NewFailoverAIService([]ProviderAIService{primary, secondary}, newTestRetrievalLogger())
+
+	metrics := monitor.NewMetrics()
+	failover.SetMetrics(metrics)
+	if primary.metrics != metrics || secondary.metrics != metrics {
+		t.Error("Expected metrics to be set on every provider in the chain")
+	}
+}
@@ -1,15 +1,17 @@
 package service
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -25,21 +27,27 @@ func min(a, b int) int {
 	return b
 }
 
-// OllamaRequest represents the request payload for Ollama API
-type OllamaRequest struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream"`
-	Options map[string]interface{} `json:"options,omitempty"`
+// OllamaChatMessage represents a single message in an Ollama /api/chat conversation
+type OllamaChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
-// OllamaResponse represents the response from Ollama API
-type OllamaResponse struct {
-	Model    string `json:"model"`
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
-	Context  []int  `json:"context,omitempty"`
-	Error    string `json:"error,omitempty"`
+// OllamaChatRequest represents the request payload for the Ollama /api/chat endpoint
+type OllamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []OllamaChatMessage    `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   interface{}            `json:"format,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// OllamaChatResponse represents a response (or stream chunk) from the Ollama /api/chat endpoint
+type OllamaChatResponse struct {
+	Model   string            `json:"model"`
+	Message OllamaChatMessage `json:"message"`
+	Done    bool              `json:"done"`
+	Error   string            `json:"error,omitempty"`
 }
 
 // QualityScore represents the quality assessment of a response
@@ -75,9 +83,12 @@ type OllamaAIService struct {
 	timeout            time.Duration
 	logger             *slog.Logger
 	rateLimiter        monitor.AIProviderRateLimiter
+	metrics            *monitor.Metrics
 	bmadKnowledgeBase  string
 	ephemeralCachePath string
 	knowledgeBaseMu    sync.RWMutex
+	settingsMu         sync.RWMutex // guards client, modelName and timeout, which change on config reload
+	retriever          *KnowledgeRetriever
 	qualityMetrics     *QualityMetrics
 	bmadTerms          []string
 	qualityEnabled     bool
@@ -114,6 +125,14 @@ func NewOllamaAIService(logger *slog.Logger) (*OllamaAIService, error) {
 	}
 	qualityEnabledBool := qualityEnabled == "true"
 
+	// Number of knowledge base sections retrieved per prompt (0 disables retrieval and sends the full KB)
+	retrievalTopK := DefaultRetrievalTopK
+	if topKStr := os.Getenv("BMAD_KB_RETRIEVAL_TOP_K"); topKStr != "" {
+		if parsedTopK, err := strconv.Atoi(topKStr); err == nil && parsedTopK >= 0 {
+			retrievalTopK = parsedTopK
+		}
+	}
+
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: timeout,
@@ -142,11 +161,23 @@ func NewOllamaAIService(logger *slog.Logger) (*OllamaAIService, error) {
 		},
 	}
 
+	// Optional embedding model enables hybrid BM25 + embedding retrieval
+	embeddingModel := os.Getenv("OLLAMA_EMBEDDING_MODEL")
+	if retrievalTopK > 0 {
+		var embedder EmbeddingProvider
+		if embeddingModel != "" {
+			embedder = NewOllamaEmbeddingClient(client, baseURL, embeddingModel)
+		}
+		service.retriever = NewKnowledgeRetriever(retrievalTopK, embedder, logger)
+	}
+
 	// Log configured settings
 	logger.Info("Ollama service configured",
 		"base_url", baseURL,
 		"model", modelName,
-		"timeout", timeout)
+		"timeout", timeout,
+		"retrieval_top_k", retrievalTopK,
+		"embedding_model", embeddingModel)
 
 	// Validate that the model is available
 	if err := service.validateModel(); err != nil {
@@ -164,6 +195,8 @@ func NewOllamaAIService(logger *slog.Logger) (*OllamaAIService, error) {
 		"cache_path", service.ephemeralCachePath,
 		"size", len(service.bmadKnowledgeBase))
 
+	service.indexKnowledgeBase(service.bmadKnowledgeBase)
+
 	return service, nil
 }
 
@@ -172,11 +205,11 @@ func (o *OllamaAIService) validateModel() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Test with a simple prompt to validate model availability
-	testRequest := OllamaRequest{
-		Model:  o.modelName,
-		Prompt: "test",
-		Stream: false,
+	// Test with a simple message to validate model availability
+	testRequest := OllamaChatRequest{
+		Model:    o.model(),
+		Messages: []OllamaChatMessage{{Role: ChatRoleUser, Content: "test"}},
+		Stream:   false,
 	}
 
 	jsonData, err := json.Marshal(testRequest)
@@ -184,14 +217,14 @@ func (o *OllamaAIService) validateModel() error {
 		return fmt.Errorf("failed to marshal test request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create test request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := o.client.Do(req)
+	resp, err := o.httpClient().Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Ollama server: %w", err)
 	}
@@ -200,13 +233,13 @@ func (o *OllamaAIService) validateModel() error {
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		if strings.Contains(string(body), "model") && strings.Contains(string(body), "not found") {
-			return fmt.Errorf("model '%s' not found on Ollama server", o.modelName)
+			return fmt.Errorf("model '%s' not found on Ollama server", o.model())
 		}
 		return fmt.Errorf("ollama server returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Parse response to check for errors
-	var ollamaResp OllamaResponse
+	var ollamaResp OllamaChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return fmt.Errorf("failed to decode test response: %w", err)
 	}
@@ -216,99 +249,129 @@ func (o *OllamaAIService) validateModel() error {
 	}
 
 	o.logger.Info("Model validation completed successfully",
-		"model", o.modelName)
+		"model", o.model())
 
 	return nil
 }
 
-// loadBMADKnowledgeBaseFromURL fetches BMAD knowledge base from remote URL and caches ephemerally
-func (o *OllamaAIService) loadBMADKnowledgeBaseFromURL() error {
-	o.knowledgeBaseMu.Lock()
-	defer o.knowledgeBaseMu.Unlock()
-
-	// Get remote URL from environment variable
-	remoteURL := os.Getenv("BMAD_KB_REMOTE_URL")
-	if remoteURL == "" {
-		remoteURL = "https://github.com/bmadcode/BMAD-METHOD/raw/refs/heads/main/bmad-core/data/bmad-kb.md"
-	}
-
-	// Set ephemeral cache path in /tmp
-	o.ephemeralCachePath = "/tmp/bmad-kb-cache.md"
-
-	// Try to read from ephemeral cache first
-	if content, err := os.ReadFile(o.ephemeralCachePath); err == nil {
-		o.bmadKnowledgeBase = string(content)
-		o.logger.Info("BMAD knowledge base loaded from ephemeral cache",
-			"cache_path", o.ephemeralCachePath,
-			"size", len(content))
-		return nil
-	}
-
-	// If cache doesn't exist or is invalid, fetch from remote URL
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", remoteURL, nil)
+// HealthCheck verifies that the Ollama server is reachable without generating a response
+func (o *OllamaAIService) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/tags", nil)
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
-	resp, err := o.client.Do(req)
+	resp, err := o.httpClient().Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to fetch BMAD knowledge base from %s: %w", remoteURL, err)
+		return fmt.Errorf("failed to connect to Ollama server: %w", err)
 	}
 	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d when fetching BMAD knowledge base from %s", resp.StatusCode, remoteURL)
+		return fmt.Errorf("ollama server returned status %d", resp.StatusCode)
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	return nil
+}
+
+// loadBMADKnowledgeBaseFromURL fetches BMAD knowledge base from remote URL and caches ephemerally
+func (o *OllamaAIService) loadBMADKnowledgeBaseFromURL() error {
+	o.knowledgeBaseMu.Lock()
+	defer o.knowledgeBaseMu.Unlock()
+
+	o.ephemeralCachePath = knowledgeBaseCachePath
+
+	content, err := loadKnowledgeBaseContent(o.httpClient(), o.ephemeralCachePath, o.logger)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return err
 	}
 
-	// Cache content ephemerally
-	if err := os.WriteFile(o.ephemeralCachePath, content, 0644); err != nil {
-		o.logger.Warn("Failed to write ephemeral cache",
+	o.bmadKnowledgeBase = content
+	return nil
+}
+
+// RefreshKnowledgeBase refreshes the knowledge base from ephemeral cache and re-indexes it for retrieval
+func (o *OllamaAIService) RefreshKnowledgeBase() error {
+	o.knowledgeBaseMu.Lock()
+
+	// Try to read from ephemeral cache
+	content, err := os.ReadFile(o.ephemeralCachePath)
+	if err != nil {
+		o.knowledgeBaseMu.Unlock()
+		o.logger.Warn("Failed to refresh knowledge base from ephemeral cache",
 			"cache_path", o.ephemeralCachePath,
 			"error", err)
+		return err
 	}
 
 	o.bmadKnowledgeBase = string(content)
-	o.logger.Info("BMAD knowledge base fetched from remote URL and cached",
-		"remote_url", remoteURL,
+	o.knowledgeBaseMu.Unlock()
+
+	o.logger.Info("BMAD knowledge base refreshed from ephemeral cache",
 		"cache_path", o.ephemeralCachePath,
 		"size", len(content))
 
+	// Index outside the knowledge base lock since embedding sections may take a while
+	o.indexKnowledgeBase(string(content))
 	return nil
 }
 
-// RefreshKnowledgeBase refreshes the knowledge base from ephemeral cache
-func (o *OllamaAIService) RefreshKnowledgeBase() error {
-	o.knowledgeBaseMu.Lock()
-	defer o.knowledgeBaseMu.Unlock()
+// indexKnowledgeBase rebuilds the retrieval index; prompts fall back to the full knowledge base on failure
+func (o *OllamaAIService) indexKnowledgeBase(content string) {
+	if o.retriever == nil {
+		return
+	}
 
-	// Try to read from ephemeral cache
-	if content, err := os.ReadFile(o.ephemeralCachePath); err == nil {
-		o.bmadKnowledgeBase = string(content)
-		o.logger.Info("BMAD knowledge base refreshed from ephemeral cache",
-			"cache_path", o.ephemeralCachePath,
-			"size", len(content))
-		return nil
-	} else {
-		o.logger.Warn("Failed to refresh knowledge base from ephemeral cache",
-			"cache_path", o.ephemeralCachePath,
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := o.retriever.Index(ctx, content); err != nil {
+		o.logger.Warn("Failed to index knowledge base for retrieval, using full knowledge base in prompts",
 			"error", err)
-		return err
 	}
 }
 
+// knowledgeContext returns the knowledge base text to include in a prompt for the query.
+// Only the most relevant sections are included when retrieval is available.
+func (o *OllamaAIService) knowledgeContext(ctx context.Context, query, conversationHistory string) string {
+	if knowledge := retrieveKnowledgeContext(ctx, o.retriever, query, conversationHistory, o.requestTimeout(), o.logger); knowledge != "" {
+		return knowledge
+	}
+
+	o.knowledgeBaseMu.RLock()
+	defer o.knowledgeBaseMu.RUnlock()
+	return o.bmadKnowledgeBase
+}
+
 // SetRateLimiter sets the rate limiter for this service
 func (o *OllamaAIService) SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter) {
 	o.rateLimiter = rateLimiter
 }
 
+// SetMetrics sets the metrics registry that records request latency and payload sizes
+func (o *OllamaAIService) SetMetrics(metrics *monitor.Metrics) {
+	o.metrics = metrics
+}
+
+// DescribeResponse returns the model and, when quality monitoring is enabled, the quality score for a response
+func (o *OllamaAIService) DescribeResponse(query, response string) ResponseMetadata {
+	metadata := ResponseMetadata{Provider: o.GetProviderID(), Model: o.model()}
+	if o.qualityEnabled {
+		metadata.Quality = o.analyzeResponseQuality(query, response)
+	}
+	return metadata
+}
+
+// chatMessagesSize returns the total size in bytes of the message contents sent to the model
+func chatMessagesSize(messages []OllamaChatMessage) int {
+	size := 0
+	for _, message := range messages {
+		size += len(message.Content)
+	}
+	return size
+}
+
 // analyzeResponseQuality performs comprehensive quality analysis on a response
 func (o *OllamaAIService) analyzeResponseQuality(query, response string) *QualityScore {
 	if !o.qualityEnabled {
@@ -626,159 +689,64 @@ func (o *OllamaAIService) GetQualityMetrics() QualityMetrics {
 	}
 }
 
-// buildBMADPrompt creates a prompt that includes the BMAD knowledge base and constraints
-func (o *OllamaAIService) buildBMADPrompt(userQuery string) string {
-	o.knowledgeBaseMu.RLock()
-	defer o.knowledgeBaseMu.RUnlock()
-
-	// Get prompt template preference from environment
-	promptStyle := os.Getenv("OLLAMA_PROMPT_STYLE")
-	if promptStyle == "" {
-		promptStyle = "structured" // Default to structured
-	}
-
-	switch promptStyle {
-	case "simple":
-		return o.buildSimplePrompt(userQuery)
-	case "detailed":
-		return o.buildDetailedPrompt(userQuery)
-	case "chain_of_thought":
-		return o.buildChainOfThoughtPrompt(userQuery)
-	default:
-		return o.buildStructuredPrompt(userQuery)
-	}
+// buildSystemPrompt creates the system message with the BMAD instructions and the knowledge base
+// sections relevant to the query and conversation history
+func (o *OllamaAIService) buildSystemPrompt(ctx context.Context, query, conversationHistory string) string {
+	return buildChatSystemPrompt(os.Getenv("OLLAMA_PROMPT_STYLE"), o.knowledgeContext(ctx, query, conversationHistory))
 }
 
-// buildStructuredPrompt creates a highly structured prompt for better model guidance
-func (o *OllamaAIService) buildStructuredPrompt(userQuery string) string {
-	return fmt.Sprintf(`# BMAD-METHOD KNOWLEDGE BASE
-%s
-
----
-
-# YOUR IDENTITY
-You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
-
-# TASK
-Answer the user's question using ONLY the BMAD knowledge base above.
-
-# USER QUESTION
-%s
-
-# INSTRUCTIONS
-1. READ the knowledge base carefully
-2. FIND relevant information for the question
-3. PROVIDE a clear, specific answer using BMAD terminology
-4. USE proper BMAD concepts (agents, workflows, stories, epics, etc.)
-5. If information is NOT in the knowledge base, say "This information is not available in the BMAD knowledge base"
-6. If asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules."
-
-# RESPONSE FORMAT
-Write your answer with proper paragraph breaks for Discord readability. Use double line breaks (blank lines) between paragraphs. Structure your response clearly with:
-- Introduction paragraph (double line break after)
-- Main content paragraphs (double line break between each)
-- Conclusion or summary paragraph (if needed)
-
-[Your answer here using BMAD terminology - remember to use double line breaks between paragraphs]
-
-[SUMMARY]: [6-8 word summary for Discord thread title]
-
-# REMEMBER
-- Stay within BMAD knowledge base boundaries
-- Use BMAD-specific terms when possible
-- Be concise but comprehensive
-- Focus on BMAD methodology and concepts`, o.bmadKnowledgeBase, userQuery)
+// PromptTokens estimates the tokens taken by the system prompt sent with every query
+func (o *OllamaAIService) PromptTokens() int {
+	o.knowledgeBaseMu.RLock()
+	defer o.knowledgeBaseMu.RUnlock()
+	return estimatePromptTokens(buildChatSystemPrompt(os.Getenv("OLLAMA_PROMPT_STYLE"), ""), o.retriever, o.bmadKnowledgeBase)
 }
 
-// buildSimplePrompt creates a simpler, more direct prompt
-func (o *OllamaAIService) buildSimplePrompt(userQuery string) string {
-	return fmt.Sprintf(`You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
-
-BMAD Knowledge Base:
-%s
-
-Question: %s
-
-Answer using only BMAD knowledge base information. Use BMAD terms like agents, workflows, stories, and epics. If asked about release dates, updates, ETAs, or future features, remind the user that you only have access to current BMAD documentation. Format with proper paragraph breaks for Discord readability - use double line breaks (blank lines) between paragraphs. End with [SUMMARY]: brief title.`, o.bmadKnowledgeBase, userQuery)
+// CiteSources returns the knowledge base sections that best support response, or nil without retrieval
+func (o *OllamaAIService) CiteSources(response string) []KnowledgeSection {
+	if o.retriever == nil {
+		return nil
+	}
+	return o.retriever.Sources(response)
 }
 
-// buildDetailedPrompt creates a more detailed prompt with examples
-func (o *OllamaAIService) buildDetailedPrompt(userQuery string) string {
-	return fmt.Sprintf(`# BMAD-METHOD EXPERT SYSTEM
-
-## KNOWLEDGE BASE
-%s
-
-## YOUR ROLE
-You are bmadhelper, the BMAD-METHOD assistant agent on Discord. Your job is to answer questions using ONLY the knowledge base above. You are a helpful AI assistant specializing in BMAD methodology.
-
-## QUESTION
-%s
-
-## RESPONSE GUIDELINES
-✓ USE BMAD terminology: agents, workflows, stories, epics, PRD, architecture
-✓ REFERENCE specific BMAD concepts and processes
-✓ EXPLAIN how things work within the BMAD framework
-✓ BE specific about BMAD roles (PM, Dev, Architect, QA, UX, SM, PO)
-✗ DON'T make up information not in the knowledge base
-✗ DON'T use general software development advice
-✗ DON'T reference external frameworks or methods
-⚠️ IF asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules."
-
-## EXAMPLE GOOD RESPONSE
-"In BMAD-METHOD, agents work in structured workflows. The SM agent creates stories from sharded PRD documents, while the Dev agent implements approved stories following the coding standards."
-
-## YOUR RESPONSE
-Format your answer with proper paragraph breaks for Discord readability - use double line breaks (blank lines) between paragraphs.
-
-[Answer here with clear paragraph spacing - remember double line breaks between paragraphs]
+// buildChatMessages creates the /api/chat messages for a query: the system message, the conversation
+// history as alternating user/assistant messages, then the query itself
+func (o *OllamaAIService) buildChatMessages(systemPrompt string, history []ChatMessage, query string) []OllamaChatMessage {
+	var conversation []ChatMessage
+	for _, message := range history {
+		conversation = appendChatMessage(conversation, message)
+	}
+	conversation = appendChatMessage(conversation, ChatMessage{Role: ChatRoleUser, Content: query})
 
-[SUMMARY]: [Brief BMAD-focused title]`, o.bmadKnowledgeBase, userQuery)
+	messages := make([]OllamaChatMessage, 0, len(conversation)+1)
+	messages = append(messages, OllamaChatMessage{Role: "system", Content: systemPrompt})
+	for _, message := range conversation {
+		messages = append(messages, OllamaChatMessage{Role: message.Role, Content: message.Content})
+	}
+	return messages
 }
 
-// buildChainOfThoughtPrompt uses chain-of-thought reasoning for better responses
-func (o *OllamaAIService) buildChainOfThoughtPrompt(userQuery string) string {
-	return fmt.Sprintf(`# YOUR IDENTITY
-You are bmadhelper, the BMAD-METHOD assistant agent on Discord.
-
-# BMAD KNOWLEDGE BASE
-%s
-
----
-
-# QUESTION: %s
-
-# REASONING PROCESS
-Let me think step by step:
-
-1. IDENTIFY: What BMAD concepts does this question relate to?
-2. SEARCH: What information is available in the knowledge base?
-3. CONNECT: How do these concepts work together in BMAD?
-4. CHECK: Is this about future updates/releases? (If so, remind user I only have current documentation)
-5. RESPOND: Provide a clear answer using BMAD terminology
-
-# ANALYSIS
-[Think through the question step by step]
-- What BMAD concepts are relevant?
-- What specific information is in the knowledge base?
-- How should I structure my response?
-
-# ANSWER
-[Your detailed BMAD-focused response - use double line breaks (blank lines) between paragraphs for Discord readability]
-
-[SUMMARY]: [Concise BMAD topic summary]`, o.bmadKnowledgeBase, userQuery)
+// executeChat sends messages to the Ollama /api/chat endpoint and returns the assistant's reply.
+// When format is set (e.g. a JSON schema) the raw content is returned for the caller to decode.
+func (o *OllamaAIService) executeChat(ctx context.Context, messages []OllamaChatMessage, format interface{}) (string, error) {
+	start := time.Now()
+	response, err := o.sendChat(ctx, messages, format)
+	o.metrics.ObserveAIRequest(o.GetProviderID(), monitor.AIRequestModeBlocking, time.Since(start), chatMessagesSize(messages), len(response), err)
+	return response, err
 }
 
-// executeQuery sends a request to the Ollama API and returns the response
-func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+// sendChat performs a single non-streaming /api/chat request, abandoning it when ctx ends
+func (o *OllamaAIService) sendChat(ctx context.Context, messages []OllamaChatMessage, format interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.requestTimeout())
 	defer cancel()
 
 	// Create request payload
-	request := OllamaRequest{
-		Model:  o.modelName,
-		Prompt: prompt,
-		Stream: false,
+	request := OllamaChatRequest{
+		Model:    o.model(),
+		Messages: messages,
+		Stream:   false,
+		Format:   format,
 	}
 
 	jsonData, err := json.Marshal(request)
@@ -787,7 +755,7 @@ func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -795,16 +763,16 @@ func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Execute request
-	resp, err := o.client.Do(req)
+	resp, err := o.httpClient().Do(req)
 	if err != nil {
 		o.logger.Error("Ollama API request failed",
 			"provider", o.GetProviderID(),
-			"model", o.modelName,
+			"model", o.model(),
 			"error", err)
 
 		// Check for specific error types
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("ollama API request timed out after %v", o.timeout)
+		if ctx.Err() != nil {
+			return "", o.contextError(ctx)
 		}
 		return "", fmt.Errorf("ollama API request failed: %w", err)
 	}
@@ -821,7 +789,7 @@ func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
 	}
 
 	// Parse response
-	var ollamaResp OllamaResponse
+	var ollamaResp OllamaChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
@@ -835,20 +803,28 @@ func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
 	}
 
 	// Validate response
-	response := strings.TrimSpace(ollamaResp.Response)
+	response := strings.TrimSpace(ollamaResp.Message.Content)
 	if response == "" {
 		o.logger.Warn("Ollama API returned empty response",
 			"provider", o.GetProviderID(),
-			"model", o.modelName)
+			"model", o.model())
 		return "I received an empty response from the AI service.", nil
 	}
 
+	if format != nil {
+		o.logger.Info("Ollama API structured response received",
+			"provider", o.GetProviderID(),
+			"model", o.model(),
+			"response_length", len(response))
+		return response, nil
+	}
+
 	// Unescape common escape sequences for proper Discord formatting
-	unescapedResponse := o.unescapeText(response)
+	unescapedResponse := unescapeText(response)
 
 	o.logger.Info("Ollama API response received",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"response_length", len(unescapedResponse),
 		"has_newlines", strings.Contains(unescapedResponse, "\n"),
 		"newline_count", strings.Count(unescapedResponse, "\n"))
@@ -856,8 +832,138 @@ func (o *OllamaAIService) executeQuery(prompt string) (string, error) {
 	return unescapedResponse, nil
 }
 
+// executeStreamingChat streams a response from the Ollama /api/chat endpoint, calling onChunk with the
+// accumulated raw response after every NDJSON chunk, and returns the complete unescaped response
+func (o *OllamaAIService) executeStreamingChat(ctx context.Context, messages []OllamaChatMessage, onChunk func(accumulated string)) (string, error) {
+	start := time.Now()
+	response, err := o.sendStreamingChat(ctx, messages, onChunk)
+	o.metrics.ObserveAIRequest(o.GetProviderID(), monitor.AIRequestModeStreaming, time.Since(start), chatMessagesSize(messages), len(response), err)
+	return response, err
+}
+
+// sendStreamingChat performs a single streaming /api/chat request, abandoning it when ctx ends
+func (o *OllamaAIService) sendStreamingChat(ctx context.Context, messages []OllamaChatMessage, onChunk func(accumulated string)) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.requestTimeout())
+	defer cancel()
+
+	// Create request payload
+	request := OllamaChatRequest{
+		Model:    o.model(),
+		Messages: messages,
+		Stream:   true,
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.httpClient().Do(req)
+	if err != nil {
+		o.logger.Error("Ollama streaming API request failed",
+			"provider", o.GetProviderID(),
+			"model", o.model(),
+			"error", err)
+
+		if ctx.Err() != nil {
+			return "", o.contextError(ctx)
+		}
+		return "", fmt.Errorf("ollama API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		o.logger.Error("Ollama API returned error status",
+			"provider", o.GetProviderID(),
+			"status", resp.StatusCode,
+			"response", string(body))
+		return "", fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Ollama streams one JSON object per line until a chunk with done=true
+	var builder strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	done := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk OllamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Error != "" {
+			o.logger.Error("Ollama API returned error",
+				"provider", o.GetProviderID(),
+				"error", chunk.Error)
+			return "", fmt.Errorf("ollama API error: %s", chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			builder.WriteString(chunk.Message.Content)
+			if onChunk != nil {
+				onChunk(builder.String())
+			}
+		}
+
+		if chunk.Done {
+			done = true
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return "", o.contextError(ctx)
+		}
+		return "", fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	if !done {
+		return "", fmt.Errorf("ollama response stream ended before completion")
+	}
+
+	response := strings.TrimSpace(builder.String())
+	if response == "" {
+		o.logger.Warn("Ollama API returned empty response",
+			"provider", o.GetProviderID(),
+			"model", o.model())
+		return "I received an empty response from the AI service.", nil
+	}
+
+	unescapedResponse := unescapeText(response)
+
+	o.logger.Info("Ollama streaming API response received",
+		"provider", o.GetProviderID(),
+		"model", o.model(),
+		"response_length", len(unescapedResponse))
+
+	return unescapedResponse, nil
+}
+
+// contextError describes why an ended request context stopped an Ollama request, wrapping the context's error
+func (o *OllamaAIService) contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("ollama API request cancelled: %w", ctx.Err())
+	}
+	return fmt.Errorf("ollama API request timed out after %v: %w", o.requestTimeout(), ctx.Err())
+}
+
 // QueryAI sends a query to the Ollama API and returns the response
-func (o *OllamaAIService) QueryAI(query string) (string, error) {
+func (o *OllamaAIService) QueryAI(ctx context.Context, query string) (string, error) {
 	if strings.TrimSpace(query) == "" {
 		return "", fmt.Errorf("query cannot be empty")
 	}
@@ -869,29 +975,25 @@ func (o *OllamaAIService) QueryAI(query string) (string, error) {
 
 	o.logger.Info("Sending query to Ollama API",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"query_length", len(query))
 
 	// Register the API call for rate limiting
-	if o.rateLimiter != nil {
-		if err := o.rateLimiter.RegisterCall(o.GetProviderID()); err != nil {
-			o.logger.Warn("Failed to register API call for rate limiting", "error", err)
-		}
-	}
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
-	// Build BMAD-constrained prompt
-	bmadPrompt := o.buildBMADPrompt(query)
+	// Build BMAD-constrained chat messages
+	messages := o.buildChatMessages(o.buildSystemPrompt(ctx, query, ""), nil, query)
 
-	response, err := o.executeQuery(bmadPrompt)
+	response, err := o.executeChat(ctx, messages, nil)
 	if err != nil {
 		return "", err
 	}
 
 	// Clean citations from the response
-	cleanedResponse := o.cleanCitations(response)
+	cleanedResponse := cleanCitations(response)
 
 	// Remove summary markers if present (since QueryAI doesn't return summary separately)
-	cleanedResponse = o.removeSummaryMarkers(cleanedResponse)
+	cleanedResponse = removeSummaryMarkers(cleanedResponse)
 
 	// Perform quality analysis if enabled
 	if o.qualityEnabled {
@@ -916,7 +1018,7 @@ func (o *OllamaAIService) QueryAI(query string) (string, error) {
 }
 
 // QueryAIWithSummary sends a query to the Ollama API and returns both the response and extracted summary
-func (o *OllamaAIService) QueryAIWithSummary(query string) (string, string, error) {
+func (o *OllamaAIService) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
 	if strings.TrimSpace(query) == "" {
 		return "", "", fmt.Errorf("query cannot be empty")
 	}
@@ -928,27 +1030,31 @@ func (o *OllamaAIService) QueryAIWithSummary(query string) (string, string, erro
 
 	o.logger.Info("Sending query to Ollama API with integrated summarization",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"query_length", len(query))
 
 	// Register the API call for rate limiting
-	if o.rateLimiter != nil {
-		if err := o.rateLimiter.RegisterCall(o.GetProviderID()); err != nil {
-			o.logger.Warn("Failed to register API call for rate limiting", "error", err)
-		}
-	}
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
-	// Build BMAD-constrained prompt with summary instructions
-	bmadPrompt := o.buildBMADPrompt(query)
+	// Build BMAD-constrained chat messages asking for a structured answer and thread title
+	messages := o.buildChatMessages(o.buildSystemPrompt(ctx, query, "")+chatSummaryInstructions, nil, query)
 
-	// Execute the query
-	fullResponse, err := o.executeQuery(bmadPrompt)
+	// Execute the query using Ollama's JSON format option
+	fullResponse, err := o.executeChat(ctx, messages, answerWithSummaryFormat)
 	if err != nil {
 		return "", "", err
 	}
 
-	// Parse the response to extract main answer and summary
-	mainAnswer, summary, parseErr := o.parseResponseWithSummary(fullResponse)
+	if mainAnswer, summary, ok := parseStructuredAnswer(fullResponse); ok {
+		return mainAnswer, summary, nil
+	}
+
+	// Models that ignore the format option may still answer in plain text with a [SUMMARY] marker
+	o.logger.Warn("Ollama response was not valid structured JSON, parsing as text",
+		"provider", o.GetProviderID(),
+		"model", o.model())
+	fullResponse = unescapeText(fullResponse)
+	mainAnswer, summary, parseErr := parseResponseWithSummary(fullResponse, o.logger)
 	if parseErr != nil {
 		o.logger.Warn("Failed to parse response with summary, returning full response",
 			"error", parseErr)
@@ -958,222 +1064,94 @@ func (o *OllamaAIService) QueryAIWithSummary(query string) (string, string, erro
 	return mainAnswer, summary, nil
 }
 
-// parseResponseWithSummary extracts the main answer and summary from an integrated response
-func (o *OllamaAIService) parseResponseWithSummary(response string) (string, string, error) {
-	if response == "" {
-		return "", "", fmt.Errorf("empty response")
-	}
-
-	// Look for various summary markers the AI might use
-	summaryMarkers := []string{"[SUMMARY]:", "### Summary", "##Summary", "Summary:", "SUMMARY:"}
-	var summaryIndex int = -1
-	var foundMarker string
-
-	for _, marker := range summaryMarkers {
-		if idx := strings.LastIndex(response, marker); idx != -1 {
-			summaryIndex = idx
-			foundMarker = marker
-			break
-		}
-	}
-
-	if summaryIndex == -1 {
-		// No summary found, return the full response as main answer with empty summary
-		o.logger.Warn("No summary marker found in response, summary extraction failed",
-			"response_preview", response[len(response)-min(200, len(response)):]) // Show last 200 chars for debugging
-		cleanedResponse := o.removeUnnecessaryHeaders(strings.TrimSpace(response))
-		return cleanedResponse, "", nil
-	}
-
-	// Extract main answer (everything before the summary marker)
-	mainAnswer := strings.TrimSpace(response[:summaryIndex])
-
-	// Remove unnecessary headers from the main answer
-	mainAnswer = o.removeUnnecessaryHeaders(mainAnswer)
-
-	// Extract summary (everything after the found marker)
-	summaryStart := summaryIndex + len(foundMarker)
-	summary := strings.TrimSpace(response[summaryStart:])
-
-	// Validate summary length (Discord thread title limit is 100 characters)
-	if len(summary) > 100 {
-		o.logger.Warn("Summary too long, truncating",
-			"original_length", len(summary),
-			"summary", summary)
-		summary = summary[:97] + "..."
-	}
-
-	// Validate summary is not empty
-	if summary == "" {
-		o.logger.Warn("Empty summary extracted")
-		return mainAnswer, "", nil
-	}
-
-	o.logger.Info("Response parsed successfully",
-		"main_answer_length", len(mainAnswer),
-		"summary_length", len(summary),
-		"summary", summary)
-
-	// Clean citations from both main answer and summary
-	mainAnswer = o.cleanCitations(mainAnswer)
-	summary = o.cleanCitations(summary)
-
-	return mainAnswer, summary, nil
-}
-
-// unescapeText converts common escape sequences to their actual characters for Discord formatting
-func (o *OllamaAIService) unescapeText(text string) string {
-	// Replace common escape sequences
-	text = strings.ReplaceAll(text, "\\n", "\n")  // Newlines
-	text = strings.ReplaceAll(text, "\\t", "\t")  // Tabs
-	text = strings.ReplaceAll(text, "\\r", "\r")  // Carriage returns
-	text = strings.ReplaceAll(text, "\\\"", "\"") // Quotes
-	text = strings.ReplaceAll(text, "\\'", "'")   // Single quotes
-	text = strings.ReplaceAll(text, "\\\\", "\\") // Backslashes (do this last)
-	return text
-}
-
-// removeSummaryMarkers removes summary markers and content from text
-func (o *OllamaAIService) removeSummaryMarkers(text string) string {
-	summaryMarkers := []string{"[SUMMARY]:", "### Summary", "##Summary", "Summary:", "SUMMARY:"}
-
-	for _, marker := range summaryMarkers {
-		if summaryIndex := strings.LastIndex(text, marker); summaryIndex != -1 {
-			// Return everything before the summary marker, trimmed
-			return strings.TrimSpace(text[:summaryIndex])
-		}
-	}
-
-	return text // No summary marker found
-}
-
-// removeUnnecessaryHeaders removes unnecessary headers like "### Answer" from response
-func (o *OllamaAIService) removeUnnecessaryHeaders(text string) string {
-	unnecessaryHeaders := []string{"### Answer", "## Answer", "# Answer", "**Answer**", "Answer:", "ANSWER:"}
-
-	lines := strings.Split(text, "\n")
-	var filteredLines []string
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		isUnnecessaryHeader := false
-
-		for _, header := range unnecessaryHeaders {
-			if trimmedLine == header {
-				isUnnecessaryHeader = true
-				break
-			}
-		}
-
-		if !isUnnecessaryHeader {
-			filteredLines = append(filteredLines, line)
-		}
-	}
-
-	return strings.Join(filteredLines, "\n")
-}
-
-// cleanCitations removes citation markers like [cite: 1, 2] from response text
-func (o *OllamaAIService) cleanCitations(text string) string {
-	// Remove citation patterns like [cite: 1], [cite: 1, 2], [cite: 1,2,3], etc.
-	citationPattern := `\[cite:[^\]]*\]`
-	re := regexp.MustCompile(citationPattern)
-	cleaned := re.ReplaceAllString(text, "")
-
-	// Clean up any multiple consecutive spaces and tabs, but preserve newlines
-	// Only collapse horizontal whitespace (spaces and tabs), not vertical (newlines)
-	cleaned = regexp.MustCompile(`[ \t]+`).ReplaceAllString(cleaned, " ")
-
-	// Remove spaces at the end of lines (before newlines)
-	cleaned = regexp.MustCompile(` +\n`).ReplaceAllString(cleaned, "\n")
-
-	return strings.TrimSpace(cleaned)
-}
-
 // SummarizeQuery creates a summarized version of a user query suitable for Discord thread titles
-func (o *OllamaAIService) SummarizeQuery(query string) (string, error) {
+func (o *OllamaAIService) SummarizeQuery(ctx context.Context, query string) (string, error) {
 	if strings.TrimSpace(query) == "" {
 		return "", fmt.Errorf("query cannot be empty")
 	}
 
 	// Check rate limit before proceeding
 	if err := o.checkRateLimit(); err != nil {
-		return o.fallbackSummarize(query), nil
+		return fallbackSummarize(query), nil
 	}
 
 	o.logger.Info("Creating query summary",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"query_length", len(query))
 
 	// Register the API call for rate limiting
-	if o.rateLimiter != nil {
-		if err := o.rateLimiter.RegisterCall(o.GetProviderID()); err != nil {
-			o.logger.Warn("Failed to register API call for rate limiting", "error", err)
-		}
-	}
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
 	// Create a specialized prompt for BMAD-focused summarization
-	prompt := fmt.Sprintf("Create a concise summary of this BMAD-METHOD related question in 8 words or less, suitable for a Discord thread title. Focus on the BMAD topic or concept being asked about. Do not include quotes or formatting. Question: %s", query)
+	prompt := fmt.Sprintf("Create a concise summary of this BMAD-METHOD related question in 8 words or less, suitable for a Discord thread title. Focus on the BMAD topic or concept being asked about. Respond with a JSON object with a \"title\" field. Question: %s", query)
 
-	summary, err := o.executeQuery(prompt)
+	response, err := o.executeChat(ctx, []OllamaChatMessage{{Role: ChatRoleUser, Content: prompt}}, threadTitleFormat)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
 		// Fallback to simple truncation if AI summarization fails
 		o.logger.Warn("AI summarization failed, using fallback", "provider", o.GetProviderID(), "error", err)
-		return o.fallbackSummarize(query), nil
+		return fallbackSummarize(query), nil
 	}
 
+	// parseThreadTitle also enforces Discord's 100 character limit for thread titles
+	summary := parseThreadTitle(response)
 	if summary == "" {
 		o.logger.Warn("Ollama API returned empty summary, using fallback", "provider", o.GetProviderID())
-		return o.fallbackSummarize(query), nil
-	}
-
-	// Ensure summary fits Discord's 100 character limit for thread titles
-	if len(summary) > 100 {
-		summary = summary[:97] + "..."
+		return fallbackSummarize(query), nil
 	}
 
 	o.logger.Info("Query summary created",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"summary_length", len(summary),
 		"summary", summary)
 
 	return summary, nil
 }
 
-// fallbackSummarize provides a simple fallback summarization when AI fails
-func (o *OllamaAIService) fallbackSummarize(query string) string {
-	// Simple fallback: take first few words and truncate to fit Discord limit
-	words := strings.Fields(strings.TrimSpace(query))
-	if len(words) == 0 {
-		return "Question"
+// QueryWithContext sends a query with conversation history context to the AI service
+func (o *OllamaAIService) QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
 	}
 
-	summary := ""
-	for _, word := range words {
-		testSummary := summary + " " + word
-		if len(strings.TrimSpace(testSummary)) > 95 { // Leave room for "..."
-			break
-		}
-		summary = testSummary
+	// Check rate limit before proceeding
+	if err := o.checkRateLimit(); err != nil {
+		return "", err
 	}
 
-	summary = strings.TrimSpace(summary)
-	if summary == "" {
-		return "Question"
+	o.logger.Info("Sending contextual query to Ollama API",
+		"provider", o.GetProviderID(),
+		"model", o.model(),
+		"query_length", len(query),
+		"history_length", len(conversationHistory))
+
+	// Register the API call for rate limiting
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
+
+	// Retrieve sections relevant to both the follow-up question and the earlier conversation
+	systemPrompt := o.buildSystemPrompt(ctx, query, conversationHistory)
+	if strings.TrimSpace(conversationHistory) != "" {
+		// Flattened history carries no roles, so it is included in the system message instead
+		systemPrompt += "\n\n# CONVERSATION HISTORY\n" + conversationHistory
 	}
 
-	// Add ellipsis if we truncated
-	if len(words) > len(strings.Fields(summary)) {
-		summary += "..."
+	response, err := o.executeChat(ctx, o.buildChatMessages(systemPrompt, nil, query), nil)
+	if err != nil {
+		return "", err
 	}
 
-	return summary
+	// Clean citations and remove summary markers from the response
+	cleanedResponse := cleanCitations(response)
+	cleanedResponse = removeSummaryMarkers(cleanedResponse)
+	return cleanedResponse, nil
 }
 
-// QueryWithContext sends a query with conversation history context to the AI service
-func (o *OllamaAIService) QueryWithContext(query string, conversationHistory string) (string, error) {
+// QueryWithMessages sends a query following role-tagged conversation history to the Ollama chat API
+func (o *OllamaAIService) QueryWithMessages(ctx context.Context, query string, history []ChatMessage) (string, error) {
 	if strings.TrimSpace(query) == "" {
 		return "", fmt.Errorf("query cannot be empty")
 	}
@@ -1183,79 +1161,91 @@ func (o *OllamaAIService) QueryWithContext(query string, conversationHistory str
 		return "", err
 	}
 
-	o.logger.Info("Sending contextual query to Ollama API",
+	o.logger.Info("Sending chat query to Ollama API",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"query_length", len(query),
-		"history_length", len(conversationHistory))
+		"history_messages", len(history))
 
 	// Register the API call for rate limiting
-	if o.rateLimiter != nil {
-		if err := o.rateLimiter.RegisterCall(o.GetProviderID()); err != nil {
-			o.logger.Warn("Failed to register API call for rate limiting", "error", err)
-		}
-	}
-
-	// Build BMAD-constrained contextual prompt
-	o.knowledgeBaseMu.RLock()
-	bmadKnowledge := o.bmadKnowledgeBase
-	o.knowledgeBaseMu.RUnlock()
-
-	// Create a contextual prompt that includes BMAD knowledge base and conversation history
-	var prompt string
-	if strings.TrimSpace(conversationHistory) != "" {
-		prompt = fmt.Sprintf(`%s
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
------
+	response, err := o.executeChat(ctx, o.buildChatMessages(o.buildSystemPrompt(ctx, query, FormatChatHistory(history)), history, query), nil)
+	if err != nil {
+		return "", err
+	}
 
-CONVERSATION HISTORY:
-%s
+	// Clean citations and remove summary markers from the response
+	cleanedResponse := cleanCitations(response)
+	cleanedResponse = removeSummaryMarkers(cleanedResponse)
+	return cleanedResponse, nil
+}
 
-USER QUESTION: %s
+// QueryWithMessagesStream sends a chat query using Ollama's streaming API and reports progress as tokens arrive
+func (o *OllamaAIService) QueryWithMessagesStream(ctx context.Context, query string, history []ChatMessage, onProgress func(partial string)) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
 
-IMPORTANT: You are bmadhelper, the BMAD-METHOD assistant agent, continuing a conversation on Discord. Answer ONLY based on the information provided in the BMAD knowledge base above. If the follow-up question refers to something mentioned earlier in the conversation, use the conversation history to understand the context. However, your answer must still be grounded in the BMAD knowledge base. If the question cannot be answered from the knowledge base, politely indicate that the information is not available in your BMAD knowledge base. If asked about release dates, updates, ETAs, or future features, remind the user: "I only have access to current BMAD documentation and cannot provide information about future updates or release schedules." Maintain any citation markers (e.g., [cite: 123]) from the source text in your response.
+	// Check rate limit before proceeding
+	if err := o.checkRateLimit(); err != nil {
+		return "", err
+	}
 
-FORMAT YOUR RESPONSE: Use double line breaks (blank lines) between paragraphs for proper Discord readability. Structure your answer clearly with proper paragraph spacing.
+	o.logger.Info("Sending streaming chat query to Ollama API",
+		"provider", o.GetProviderID(),
+		"model", o.model(),
+		"query_length", len(query),
+		"history_messages", len(history))
 
-After your main answer, provide a concise, 8-word or less topic summary of this conversation for Discord thread titles, prefixed with "[SUMMARY]:". This summary should focus on the BMAD topic or concept discussed. Example: "[SUMMARY]: BMAD Roles and Responsibilities".`, bmadKnowledge, conversationHistory, query)
-	} else {
-		// Fallback to regular BMAD query if no history
-		prompt = o.buildBMADPrompt(query)
-	}
+	// Register the API call for rate limiting
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
-	response, err := o.executeQuery(prompt)
+	response, err := o.executeStreamingChat(ctx, o.buildChatMessages(o.buildSystemPrompt(ctx, query, FormatChatHistory(history)), history, query), func(accumulated string) {
+		if onProgress == nil {
+			return
+		}
+		if partial := o.streamingPreview(accumulated); partial != "" {
+			onProgress(partial)
+		}
+	})
 	if err != nil {
 		return "", err
 	}
 
-	// Clean citations and remove summary markers from the response
-	cleanedResponse := o.cleanCitations(response)
-	cleanedResponse = o.removeSummaryMarkers(cleanedResponse)
+	// Clean citations and remove summary markers from the final response
+	cleanedResponse := cleanCitations(response)
+	cleanedResponse = removeSummaryMarkers(cleanedResponse)
 	return cleanedResponse, nil
 }
 
+// streamingPreview cleans a partially generated response for display, hiding any unfinished [cite:]/[SUMMARY] marker
+func (o *OllamaAIService) streamingPreview(accumulated string) string {
+	text := unescapeText(accumulated)
+	if open := strings.LastIndex(text, "["); open > strings.LastIndex(text, "]") {
+		text = text[:open]
+	}
+	return removeSummaryMarkers(cleanCitations(text))
+}
+
 // SummarizeConversation creates a summary of conversation history for context preservation
-func (o *OllamaAIService) SummarizeConversation(messages []string) (string, error) {
+func (o *OllamaAIService) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
 	if len(messages) == 0 {
 		return "", nil
 	}
 
 	// Check rate limit before proceeding
 	if err := o.checkRateLimit(); err != nil {
-		return o.fallbackConversationSummary(messages), nil
+		return fallbackConversationSummary(messages), nil
 	}
 
 	o.logger.Info("Summarizing conversation",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"message_count", len(messages))
 
 	// Register the API call for rate limiting
-	if o.rateLimiter != nil {
-		if err := o.rateLimiter.RegisterCall(o.GetProviderID()); err != nil {
-			o.logger.Warn("Failed to register API call for rate limiting", "error", err)
-		}
-	}
+	registerProviderCall(o.rateLimiter, o.GetProviderID(), o.logger)
 
 	// Join messages into a single conversation text
 	conversationText := strings.Join(messages, "\n")
@@ -1263,51 +1253,29 @@ func (o *OllamaAIService) SummarizeConversation(messages []string) (string, erro
 	// Create a specialized prompt for BMAD conversation summarization
 	prompt := fmt.Sprintf("Summarize this BMAD-METHOD conversation in a concise way that preserves the key BMAD concepts and topics discussed. Focus on the BMAD-related questions asked and important BMAD information shared. Keep it under 500 words:\n\n%s", conversationText)
 
-	summary, err := o.executeQuery(prompt)
+	summary, err := o.executeChat(ctx, []OllamaChatMessage{{Role: ChatRoleUser, Content: prompt}}, nil)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
 		// Fallback to truncated conversation if AI summarization fails
 		o.logger.Warn("AI conversation summarization failed, using fallback", "provider", o.GetProviderID(), "error", err)
-		return o.fallbackConversationSummary(messages), nil
+		return fallbackConversationSummary(messages), nil
 	}
 
 	if summary == "" {
 		o.logger.Warn("Ollama API returned empty conversation summary, using fallback", "provider", o.GetProviderID())
-		return o.fallbackConversationSummary(messages), nil
+		return fallbackConversationSummary(messages), nil
 	}
 
 	o.logger.Info("Conversation summary created",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"summary_length", len(summary))
 
 	return summary, nil
 }
 
-// fallbackConversationSummary provides a simple fallback when AI summarization fails
-func (o *OllamaAIService) fallbackConversationSummary(messages []string) string {
-	if len(messages) == 0 {
-		return ""
-	}
-
-	// Simple fallback: take the last few messages and truncate if needed
-	const maxMessages = 5
-	const maxLength = 1000
-
-	startIdx := 0
-	if len(messages) > maxMessages {
-		startIdx = len(messages) - maxMessages
-	}
-
-	recentMessages := messages[startIdx:]
-	summary := strings.Join(recentMessages, "\n")
-
-	if len(summary) > maxLength {
-		summary = summary[:maxLength-3] + "..."
-	}
-
-	return summary
-}
-
 // GetProviderID returns the unique identifier for this AI provider
 func (o *OllamaAIService) GetProviderID() string {
 	return "ollama"
@@ -1315,42 +1283,68 @@ func (o *OllamaAIService) GetProviderID() string {
 
 // checkRateLimit validates that the provider is not rate limited before making a call
 func (o *OllamaAIService) checkRateLimit() error {
-	if o.rateLimiter == nil {
-		// Rate limiting not configured - allow the call
-		return nil
-	}
+	return checkProviderRateLimit(o.rateLimiter, o.GetProviderID(), o.logger)
+}
+
+// SetTimeout allows customizing the HTTP client timeout
+func (o *OllamaAIService) SetTimeout(timeout time.Duration) {
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	// Swap in a new client so in-flight requests keep the one they started with
+	o.timeout = timeout
+	o.client = &http.Client{Transport: o.client.Transport, Timeout: timeout}
+}
 
-	providerID := o.GetProviderID()
-	status := o.rateLimiter.GetProviderStatus(providerID)
+// SetModel switches the chat model used for subsequent requests
+func (o *OllamaAIService) SetModel(modelName string) {
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+	o.modelName = modelName
+}
 
-	if status == "Throttled" {
-		usage, limit := o.rateLimiter.GetProviderUsage(providerID)
-		o.logger.Warn("Rate limit exceeded for provider",
-			"provider", providerID,
-			"status", status,
-			"usage", usage,
-			"limit", limit)
-		return fmt.Errorf("rate limit exceeded for provider %s: %d/%d requests",
-			providerID, usage, limit)
+// ApplyConfiguration applies OLLAMA_MODEL and OLLAMA_TIMEOUT (seconds) from a configuration snapshot.
+// Its signature matches config.ServiceConfigListener.OnReload so it can be registered directly.
+func (o *OllamaAIService) ApplyConfiguration(configs map[string]string) error {
+	// Missing keys keep their current values
+	if modelName := strings.TrimSpace(configs["OLLAMA_MODEL"]); modelName != "" && modelName != o.model() {
+		o.SetModel(modelName)
+		o.logger.Info("Ollama model updated", "model", modelName)
 	}
 
-	// Log warning status but don't block the call
-	if status == "Warning" {
-		usage, limit := o.rateLimiter.GetProviderUsage(providerID)
-		o.logger.Warn("Rate limit warning for provider",
-			"provider", providerID,
-			"status", status,
-			"usage", usage,
-			"limit", limit)
+	if value := strings.TrimSpace(configs["OLLAMA_TIMEOUT"]); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("invalid OLLAMA_TIMEOUT value %q: must be a positive number of seconds", value)
+		}
+		if timeout := time.Duration(seconds) * time.Second; timeout != o.requestTimeout() {
+			o.SetTimeout(timeout)
+			o.logger.Info("Ollama timeout updated", "timeout", timeout)
+		}
 	}
 
 	return nil
 }
 
-// SetTimeout allows customizing the HTTP client timeout
-func (o *OllamaAIService) SetTimeout(timeout time.Duration) {
-	o.timeout = timeout
-	o.client.Timeout = timeout
+// model returns the chat model currently in use
+func (o *OllamaAIService) model() string {
+	o.settingsMu.RLock()
+	defer o.settingsMu.RUnlock()
+	return o.modelName
+}
+
+// requestTimeout returns the timeout currently applied to each Ollama request
+func (o *OllamaAIService) requestTimeout() time.Duration {
+	o.settingsMu.RLock()
+	defer o.settingsMu.RUnlock()
+	return o.timeout
+}
+
+// httpClient returns the HTTP client for the current timeout
+func (o *OllamaAIService) httpClient() *http.Client {
+	o.settingsMu.RLock()
+	defer o.settingsMu.RUnlock()
+	return o.client
 }
 
 // LogQualityReport logs a detailed quality report to the logger
@@ -1374,7 +1368,7 @@ func (o *OllamaAIService) LogQualityReport() {
 
 	o.logger.Info("=== OLLAMA QUALITY REPORT ===",
 		"provider", o.GetProviderID(),
-		"model", o.modelName,
+		"model", o.model(),
 		"total_responses", metrics.TotalResponses,
 		"last_updated", metrics.LastUpdated.Format("2006-01-02 15:04:05"))
 
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -21,7 +22,7 @@ func TestOllamaAIServiceIntegration(t *testing.T) {
 
 	// Create a comprehensive mock Ollama server that simulates real API behavior
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/api/generate" {
+		if r.URL.Path != "/api/chat" {
 			http.NotFound(w, r)
 			return
 		}
@@ -31,32 +32,40 @@ func TestOllamaAIServiceIntegration(t *testing.T) {
 			return
 		}
 
-		var req OllamaRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var req OllamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
 
-		// Simulate different responses based on the prompt content
+		var systemPrompt string
+		if req.Messages[0].Role == "system" {
+			systemPrompt = req.Messages[0].Content
+		}
+		query := req.Messages[len(req.Messages)-1].Content
+
+		// Simulate different responses based on the messages and requested format
 		var responseText string
-		if strings.Contains(req.Prompt, "test") {
+		if req.Format != nil && strings.Contains(query, "Create a concise summary") && strings.Contains(query, "development process") {
+			responseText = `{"title": "BMAD Development Process"}`
+		} else if req.Format != nil {
+			responseText = `{"answer": "BMAD-METHOD is a framework for AI-driven development.", "summary": "BMAD Framework Overview"}`
+		} else if query == "test" {
 			responseText = "test response"
-		} else if strings.Contains(req.Prompt, "Create a concise summary") && strings.Contains(req.Prompt, "development process") {
-			responseText = "BMAD Development Process"
-		} else if strings.Contains(req.Prompt, "Summarize this BMAD-METHOD conversation") {
+		} else if strings.Contains(query, "Summarize this BMAD-METHOD conversation") {
 			responseText = "The conversation covered BMAD agents, their roles, and development workflows."
-		} else if strings.Contains(req.Prompt, "CONVERSATION HISTORY") {
+		} else if strings.Contains(systemPrompt, "CONVERSATION HISTORY") || len(req.Messages) > 2 {
 			responseText = "Based on the previous discussion about agents, here's more information about BMAD roles."
-		} else if strings.Contains(req.Prompt, "BMAD") {
+		} else if strings.Contains(query, "BMAD") {
 			responseText = "BMAD-METHOD is a framework for AI-driven development. [SUMMARY]: BMAD Framework Overview"
 		} else {
 			responseText = "Based on the BMAD knowledge base, here is the information requested."
 		}
 
-		response := OllamaResponse{
-			Model:    req.Model,
-			Response: responseText,
-			Done:     true,
+		response := OllamaChatResponse{
+			Model:   req.Model,
+			Message: OllamaChatMessage{Role: "assistant", Content: responseText},
+			Done:    true,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -136,7 +145,7 @@ The system follows structured workflows for development projects.`
 
 	// Test 1: Basic Query
 	t.Run("BasicQuery", func(t *testing.T) {
-		response, err := service.QueryAI("What is BMAD?")
+		response, err := service.QueryAI(context.Background(), "What is BMAD?")
 		if err != nil {
 			t.Fatalf("QueryAI failed: %v", err)
 		}
@@ -153,7 +162,7 @@ The system follows structured workflows for development projects.`
 
 	// Test 2: Query with Summary
 	t.Run("QueryWithSummary", func(t *testing.T) {
-		mainAnswer, summary, err := service.QueryAIWithSummary("Tell me about BMAD agents")
+		mainAnswer, summary, err := service.QueryAIWithSummary(context.Background(), "Tell me about BMAD agents")
 		if err != nil {
 			t.Fatalf("QueryAIWithSummary failed: %v", err)
 		}
@@ -174,7 +183,7 @@ The system follows structured workflows for development projects.`
 
 	// Test 3: Query Summarization
 	t.Run("SummarizeQuery", func(t *testing.T) {
-		summary, err := service.SummarizeQuery("How do BMAD agents work in the development process?")
+		summary, err := service.SummarizeQuery(context.Background(), "How do BMAD agents work in the development process?")
 		if err != nil {
 			t.Fatalf("SummarizeQuery failed: %v", err)
 		}
@@ -197,7 +206,7 @@ The system follows structured workflows for development projects.`
 	t.Run("QueryWithContext", func(t *testing.T) {
 		conversationHistory := "User: What are BMAD agents?\nBot: BMAD agents are specialized AI roles."
 
-		response, err := service.QueryWithContext("Tell me more about their workflows", conversationHistory)
+		response, err := service.QueryWithContext(context.Background(), "Tell me more about their workflows", conversationHistory)
 		if err != nil {
 			t.Fatalf("QueryWithContext failed: %v", err)
 		}
@@ -212,7 +221,24 @@ The system follows structured workflows for development projects.`
 		}
 	})
 
-	// Test 5: Conversation Summarization
+	// Test 5: Query with role-tagged history
+	t.Run("QueryWithMessages", func(t *testing.T) {
+		history := []ChatMessage{
+			{Role: ChatRoleUser, Content: "alice: What are BMAD agents?"},
+			{Role: ChatRoleAssistant, Content: "BMAD agents are specialized AI roles."},
+		}
+
+		response, err := service.QueryWithMessages(context.Background(), "Tell me more about their workflows", history)
+		if err != nil {
+			t.Fatalf("QueryWithMessages failed: %v", err)
+		}
+
+		if !strings.Contains(response, "Based on the previous discussion") {
+			t.Errorf("Response should reference previous discussion, got: %s", response)
+		}
+	})
+
+	// Test 6: Conversation Summarization
 	t.Run("SummarizeConversation", func(t *testing.T) {
 		messages := []string{
 			"User: What is BMAD?",
@@ -221,7 +247,7 @@ The system follows structured workflows for development projects.`
 			"Bot: Agents have specialized roles like PM, Dev, and Architect.",
 		}
 
-		summary, err := service.SummarizeConversation(messages)
+		summary, err := service.SummarizeConversation(context.Background(), messages)
 		if err != nil {
 			t.Fatalf("SummarizeConversation failed: %v", err)
 		}
@@ -236,14 +262,14 @@ The system follows structured workflows for development projects.`
 		}
 	})
 
-	// Test 6: Rate Limiting Integration
+	// Test 7: Rate Limiting Integration
 	t.Run("RateLimitingIntegration", func(t *testing.T) {
 		// Get initial usage count
 		initialUsage, _ := rateLimitManager.GetProviderUsage("ollama")
 
 		// Make multiple rapid calls to test rate limiting
 		for i := 0; i < 5; i++ {
-			_, err := service.QueryAI("Test query " + string(rune(i+'1')))
+			_, err := service.QueryAI(context.Background(), "Test query "+string(rune(i+'1')))
 			if err != nil {
 				t.Fatalf("Query %d failed: %v", i+1, err)
 			}
@@ -266,16 +292,16 @@ The system follows structured workflows for development projects.`
 		}
 	})
 
-	// Test 7: Error Handling and Recovery
+	// Test 8: Error Handling and Recovery
 	t.Run("ErrorHandlingAndRecovery", func(t *testing.T) {
 		// Test empty query
-		_, err := service.QueryAI("")
+		_, err := service.QueryAI(context.Background(), "")
 		if err == nil {
 			t.Errorf("Expected error for empty query")
 		}
 
 		// Test service recovery after error
-		response, err := service.QueryAI("Recovery test")
+		response, err := service.QueryAI(context.Background(), "Recovery test")
 		if err != nil {
 			t.Fatalf("Service should recover after error: %v", err)
 		}
@@ -285,7 +311,7 @@ The system follows structured workflows for development projects.`
 		}
 	})
 
-	// Test 8: BMAD Knowledge Base Integration
+	// Test 9: BMAD Knowledge Base Integration
 	t.Run("BMADKnowledgeBaseIntegration", func(t *testing.T) {
 		// The service should have loaded the BMAD knowledge base
 		if service.bmadKnowledgeBase == "" {
@@ -297,7 +323,7 @@ The system follows structured workflows for development projects.`
 		}
 
 		// Test that queries include the knowledge base
-		response, err := service.QueryAI("What frameworks are available?")
+		response, err := service.QueryAI(context.Background(), "What frameworks are available?")
 		if err != nil {
 			t.Fatalf("Query failed: %v", err)
 		}
@@ -308,7 +334,7 @@ The system follows structured workflows for development projects.`
 		}
 	})
 
-	// Test 9: Provider ID and Configuration
+	// Test 10: Provider ID and Configuration
 	t.Run("ProviderConfiguration", func(t *testing.T) {
 		if service.GetProviderID() != "ollama" {
 			t.Errorf("Expected provider ID 'ollama', got '%s'", service.GetProviderID())
@@ -325,11 +351,11 @@ The system follows structured workflows for development projects.`
 		service.SetTimeout(originalTimeout)
 	})
 
-	// Test 10: Fallback Mechanisms
+	// Test 11: Fallback Mechanisms
 	t.Run("FallbackMechanisms", func(t *testing.T) {
 		// Test fallback summarization
 		longQuery := strings.Repeat("This is a very long query that exceeds normal length limits ", 10)
-		summary := service.fallbackSummarize(longQuery)
+		summary := fallbackSummarize(longQuery)
 
 		if len(summary) > 100 {
 			t.Errorf("Fallback summary should be <= 100 characters, got %d", len(summary))
@@ -345,7 +371,7 @@ The system follows structured workflows for development projects.`
 			longMessages[i] = "Message " + string(rune(i+'1')) + ": This is a test message."
 		}
 
-		convSummary := service.fallbackConversationSummary(longMessages)
+		convSummary := fallbackConversationSummary(longMessages)
 		if convSummary == "" {
 			t.Errorf("Fallback conversation summary should not be empty")
 		}
@@ -400,7 +426,7 @@ func TestOllamaAIServiceRealEndpoint(t *testing.T) {
 	}
 
 	// Test a simple query
-	response, err := service.QueryAI("What is BMAD in one sentence?")
+	response, err := service.QueryAI(context.Background(), "What is BMAD in one sentence?")
 	if err != nil {
 		t.Fatalf("Real endpoint query failed: %v", err)
 	}
@@ -412,7 +438,7 @@ func TestOllamaAIServiceRealEndpoint(t *testing.T) {
 	t.Logf("Real endpoint response: %s", response)
 
 	// Test query with summary
-	mainAnswer, summary, err := service.QueryAIWithSummary("Explain BMAD agents briefly")
+	mainAnswer, summary, err := service.QueryAIWithSummary(context.Background(), "Explain BMAD agents briefly")
 	if err != nil {
 		t.Fatalf("Real endpoint query with summary failed: %v", err)
 	}
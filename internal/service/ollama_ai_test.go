@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -30,11 +33,11 @@ func TestNewOllamaAIService(t *testing.T) {
 
 	// Create a mock Ollama server
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/generate" {
-			response := OllamaResponse{
-				Model:    "devstral",
-				Response: "test response",
-				Done:     true,
+		if r.URL.Path == "/api/chat" {
+			response := OllamaChatResponse{
+				Model:   "devstral",
+				Message: OllamaChatMessage{Role: "assistant", Content: "test response"},
+				Done:    true,
 			}
 			json.NewEncoder(w).Encode(response)
 		}
@@ -96,11 +99,11 @@ func TestNewOllamaAIServiceDefaults(t *testing.T) {
 
 	// Create a mock Ollama server
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/generate" {
-			response := OllamaResponse{
-				Model:    "devstral",
-				Response: "test response",
-				Done:     true,
+		if r.URL.Path == "/api/chat" {
+			response := OllamaChatResponse{
+				Model:   "devstral",
+				Message: OllamaChatMessage{Role: "assistant", Content: "test response"},
+				Done:    true,
 			}
 			json.NewEncoder(w).Encode(response)
 		}
@@ -150,7 +153,7 @@ func TestValidateModel(t *testing.T) {
 		{
 			name:           "valid model",
 			responseStatus: http.StatusOK,
-			responseBody:   OllamaResponse{Model: "devstral", Response: "test", Done: true},
+			responseBody:   OllamaChatResponse{Model: "devstral", Message: OllamaChatMessage{Role: "assistant", Content: "test"}, Done: true},
 			expectError:    false,
 		},
 		{
@@ -170,7 +173,7 @@ func TestValidateModel(t *testing.T) {
 		{
 			name:           "API error in response",
 			responseStatus: http.StatusOK,
-			responseBody:   OllamaResponse{Error: "model not loaded"},
+			responseBody:   OllamaChatResponse{Error: "model not loaded"},
 			expectError:    true,
 			errorContains:  "ollama API error: model not loaded",
 		},
@@ -180,7 +183,7 @@ func TestValidateModel(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(tt.responseStatus)
-				if resp, ok := tt.responseBody.(OllamaResponse); ok {
+				if resp, ok := tt.responseBody.(OllamaChatResponse); ok {
 					json.NewEncoder(w).Encode(resp)
 				} else {
 					w.Write([]byte(tt.responseBody.(string)))
@@ -228,19 +231,25 @@ func TestQueryAI(t *testing.T) {
 	tempFile.Close()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/generate" {
-			var req OllamaRequest
+		if r.URL.Path == "/api/chat" {
+			var req OllamaChatRequest
 			json.NewDecoder(r.Body).Decode(&req)
 
-			// Verify the request contains BMAD knowledge base
-			if !strings.Contains(req.Prompt, testKnowledge) {
-				t.Errorf("Request prompt should contain BMAD knowledge base")
+			// Verify the system message carries the BMAD knowledge base and the query is sent as the user message
+			if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Role != "user" {
+				t.Fatalf("Expected system and user messages, got %+v", req.Messages)
+			}
+			if !strings.Contains(req.Messages[0].Content, testKnowledge) {
+				t.Errorf("System message should contain BMAD knowledge base")
+			}
+			if req.Messages[1].Content != "What is BMAD?" {
+				t.Errorf("Expected user message to be the query, got %q", req.Messages[1].Content)
 			}
 
-			response := OllamaResponse{
-				Model:    "devstral",
-				Response: "This is a test response based on BMAD knowledge [cite: 1]",
-				Done:     true,
+			response := OllamaChatResponse{
+				Model:   "devstral",
+				Message: OllamaChatMessage{Role: "assistant", Content: "This is a test response based on BMAD knowledge [cite: 1]"},
+				Done:    true,
 			}
 			json.NewEncoder(w).Encode(response)
 		}
@@ -259,7 +268,7 @@ func TestQueryAI(t *testing.T) {
 		ephemeralCachePath: tempFile.Name(),
 	}
 
-	response, err := service.QueryAI("What is BMAD?")
+	response, err := service.QueryAI(context.Background(), "What is BMAD?")
 	if err != nil {
 		t.Fatalf("QueryAI failed: %v", err)
 	}
@@ -289,10 +298,18 @@ func TestQueryAIWithSummary(t *testing.T) {
 	tempFile.Close()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := OllamaResponse{
-			Model:    "devstral",
-			Response: "This is the main answer about BMAD.\n\n[SUMMARY]: BMAD Overview",
-			Done:     true,
+		var req OllamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// The thread title is requested through the JSON format option
+		if req.Format == nil {
+			t.Errorf("Expected request to set a JSON format")
+		}
+
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: `{"answer": "This is the main answer about BMAD. [cite: 1]", "summary": "BMAD Overview"}`},
+			Done:    true,
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -310,7 +327,7 @@ func TestQueryAIWithSummary(t *testing.T) {
 		ephemeralCachePath: tempFile.Name(),
 	}
 
-	mainAnswer, summary, err := service.QueryAIWithSummary("What is BMAD?")
+	mainAnswer, summary, err := service.QueryAIWithSummary(context.Background(), "What is BMAD?")
 	if err != nil {
 		t.Fatalf("QueryAIWithSummary failed: %v", err)
 	}
@@ -326,13 +343,56 @@ func TestQueryAIWithSummary(t *testing.T) {
 	}
 }
 
+// TestQueryAIWithSummaryTextFallback tests that plain text responses with summary markers are still parsed
+func TestQueryAIWithSummaryTextFallback(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "This is the main answer about BMAD.\n\n[SUMMARY]: BMAD Overview"},
+			Done:    true,
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	service := &OllamaAIService{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		baseURL:           mockServer.URL,
+		modelName:         "devstral",
+		timeout:           10 * time.Second,
+		logger:            logger,
+		bmadKnowledgeBase: "Test BMAD knowledge base content",
+	}
+
+	mainAnswer, summary, err := service.QueryAIWithSummary(context.Background(), "What is BMAD?")
+	if err != nil {
+		t.Fatalf("QueryAIWithSummary failed: %v", err)
+	}
+
+	if strings.TrimSpace(mainAnswer) != "This is the main answer about BMAD." {
+		t.Errorf("Unexpected main answer '%s'", mainAnswer)
+	}
+	if summary != "BMAD Overview" {
+		t.Errorf("Expected summary 'BMAD Overview', got '%s'", summary)
+	}
+}
+
 // TestSummarizeQuery tests query summarization
 func TestSummarizeQuery(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := OllamaResponse{
-			Model:    "devstral",
-			Response: "BMAD Method Overview",
-			Done:     true,
+		var req OllamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if req.Format == nil {
+			t.Errorf("Expected thread title request to set a JSON format")
+		}
+
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: `{"title": "BMAD Method Overview"}`},
+			Done:    true,
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -347,7 +407,7 @@ func TestSummarizeQuery(t *testing.T) {
 		logger:    logger,
 	}
 
-	summary, err := service.SummarizeQuery("What is the BMAD method and how does it work?")
+	summary, err := service.SummarizeQuery(context.Background(), "What is the BMAD method and how does it work?")
 	if err != nil {
 		t.Fatalf("SummarizeQuery failed: %v", err)
 	}
@@ -361,12 +421,6 @@ func TestSummarizeQuery(t *testing.T) {
 
 // TestFallbackSummarize tests the fallback summarization
 func TestFallbackSummarize(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-
-	service := &OllamaAIService{
-		logger: logger,
-	}
-
 	tests := []struct {
 		name     string
 		query    string
@@ -391,7 +445,7 @@ func TestFallbackSummarize(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.fallbackSummarize(tt.query)
+			result := fallbackSummarize(tt.query)
 			if result != tt.expected {
 				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
 			}
@@ -414,21 +468,24 @@ func TestQueryWithContext(t *testing.T) {
 	tempFile.Close()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req OllamaRequest
+		var req OllamaChatRequest
 		json.NewDecoder(r.Body).Decode(&req)
 
-		// Verify the request contains both BMAD knowledge and conversation history
-		if !strings.Contains(req.Prompt, testKnowledge) {
+		// Verify the system message contains both BMAD knowledge and conversation history
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Fatalf("Expected system and user messages, got %+v", req.Messages)
+		}
+		if !strings.Contains(req.Messages[0].Content, testKnowledge) {
 			t.Errorf("Request should contain BMAD knowledge base")
 		}
-		if !strings.Contains(req.Prompt, "Previous conversation about agents") {
+		if !strings.Contains(req.Messages[0].Content, "Previous conversation about agents") {
 			t.Errorf("Request should contain conversation history")
 		}
 
-		response := OllamaResponse{
-			Model:    "devstral",
-			Response: "Based on the previous discussion about agents, here's more information about BMAD roles.",
-			Done:     true,
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "Based on the previous discussion about agents, here's more information about BMAD roles."},
+			Done:    true,
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -447,7 +504,7 @@ func TestQueryWithContext(t *testing.T) {
 	}
 
 	history := "Previous conversation about agents"
-	response, err := service.QueryWithContext("Tell me more about the roles", history)
+	response, err := service.QueryWithContext(context.Background(), "Tell me more about the roles", history)
 	if err != nil {
 		t.Fatalf("QueryWithContext failed: %v", err)
 	}
@@ -457,6 +514,67 @@ func TestQueryWithContext(t *testing.T) {
 	}
 }
 
+// TestQueryWithMessages tests that role-tagged history is sent as alternating chat messages
+func TestQueryWithMessages(t *testing.T) {
+	var received OllamaChatRequest
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "The Architect reviews it. [cite: 2]"},
+			Done:    true,
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	service := &OllamaAIService{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		baseURL:           mockServer.URL,
+		modelName:         "devstral",
+		timeout:           10 * time.Second,
+		logger:            logger,
+		bmadKnowledgeBase: "Test BMAD knowledge base content",
+	}
+
+	history := []ChatMessage{
+		{Role: ChatRoleUser, Content: "alice: Who designs the system?"},
+		{Role: ChatRoleAssistant, Content: "The Architect designs it."},
+		{Role: ChatRoleUser, Content: "bob: Thanks!"},
+	}
+
+	response, err := service.QueryWithMessages(context.Background(), "Who reviews the design?", history)
+	if err != nil {
+		t.Fatalf("QueryWithMessages failed: %v", err)
+	}
+	if response != "The Architect reviews it." {
+		t.Errorf("Unexpected response %q", response)
+	}
+
+	expected := []OllamaChatMessage{
+		{Role: "user", Content: "alice: Who designs the system?"},
+		{Role: "assistant", Content: "The Architect designs it."},
+		{Role: "user", Content: "bob: Thanks!\n\nWho reviews the design?"},
+	}
+	if len(received.Messages) != len(expected)+1 || received.Messages[0].Role != "system" {
+		t.Fatalf("Expected a system message followed by %d messages, got %+v", len(expected), received.Messages)
+	}
+	if !strings.Contains(received.Messages[0].Content, "Test BMAD knowledge base content") {
+		t.Errorf("System message should contain BMAD knowledge base")
+	}
+	for i, message := range expected {
+		if received.Messages[i+1] != message {
+			t.Errorf("Message %d: expected %+v, got %+v", i+1, message, received.Messages[i+1])
+		}
+	}
+	if received.Format != nil {
+		t.Errorf("Expected no format for plain chat queries, got %v", received.Format)
+	}
+}
+
 // TestQueryWithContextSummaryRemoval tests that QueryWithContext removes summary markers
 func TestQueryWithContextSummaryRemoval(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "test_bmad_*.md")
@@ -473,10 +591,10 @@ func TestQueryWithContextSummaryRemoval(t *testing.T) {
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Return a response with summary markers that should be removed
-		response := OllamaResponse{
-			Model:    "devstral",
-			Response: "This is the main response content.\n\n[SUMMARY]: Brief Summary",
-			Done:     true,
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "This is the main response content.\n\n[SUMMARY]: Brief Summary"},
+			Done:    true,
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -495,7 +613,7 @@ func TestQueryWithContextSummaryRemoval(t *testing.T) {
 	}
 
 	history := "Previous conversation"
-	response, err := service.QueryWithContext("Test query", history)
+	response, err := service.QueryWithContext(context.Background(), "Test query", history)
 	if err != nil {
 		t.Fatalf("QueryWithContext failed: %v", err)
 	}
@@ -533,16 +651,16 @@ func TestQueryWithContextRealWorldExample(t *testing.T) {
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Return a response similar to what the user reported
-		response := OllamaResponse{
+		response := OllamaChatResponse{
 			Model: "devstral",
-			Response: `only when their skills are needed.
+			Message: OllamaChatMessage{Role: "assistant", Content: `only when their skills are needed.
 - **Modular Approach**: Easily integrate or exclude agents based on project requirements.
 
 ### Conclusion
 
 The BMad-Method framework leverages the strengths of various specialized agents to create a cohesive and efficient development process. Each agent contributes to a specific phase, ensuring that all aspects of software development are covered comprehensively.
 
-[SUMMARY]: BMAD Roles and Responsibilities`,
+[SUMMARY]: BMAD Roles and Responsibilities`},
 			Done: true,
 		}
 		json.NewEncoder(w).Encode(response)
@@ -562,7 +680,7 @@ The BMad-Method framework leverages the strengths of various specialized agents
 	}
 
 	history := "Previous conversation about BMAD"
-	response, err := service.QueryWithContext("Tell me about the framework", history)
+	response, err := service.QueryWithContext(context.Background(), "Tell me about the framework", history)
 	if err != nil {
 		t.Fatalf("QueryWithContext failed: %v", err)
 	}
@@ -587,10 +705,10 @@ The BMad-Method framework leverages the strengths of various specialized agents
 // TestSummarizeConversation tests conversation summarization
 func TestSummarizeConversation(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := OllamaResponse{
-			Model:    "devstral",
-			Response: "Summary: Discussion about BMAD agents and their roles in development workflow.",
-			Done:     true,
+		response := OllamaChatResponse{
+			Model:   "devstral",
+			Message: OllamaChatMessage{Role: "assistant", Content: "Summary: Discussion about BMAD agents and their roles in development workflow."},
+			Done:    true,
 		}
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -612,7 +730,7 @@ func TestSummarizeConversation(t *testing.T) {
 		"Bot: They follow structured workflows for project development.",
 	}
 
-	summary, err := service.SummarizeConversation(messages)
+	summary, err := service.SummarizeConversation(context.Background(), messages)
 	if err != nil {
 		t.Fatalf("SummarizeConversation failed: %v", err)
 	}
@@ -638,7 +756,7 @@ func TestRateLimitIntegration(t *testing.T) {
 		rateLimiter: mockRateLimiter,
 	}
 
-	_, err := service.QueryAI("test query")
+	_, err := service.QueryAI(context.Background(), "test query")
 	if err == nil {
 		t.Errorf("Expected rate limit error but got none")
 	}
@@ -650,12 +768,6 @@ func TestRateLimitIntegration(t *testing.T) {
 
 // TestRemoveSummaryMarkers tests the summary marker removal functionality
 func TestRemoveSummaryMarkers(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-
-	service := &OllamaAIService{
-		logger: logger,
-	}
-
 	tests := []struct {
 		name     string
 		input    string
@@ -690,7 +802,7 @@ func TestRemoveSummaryMarkers(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.removeSummaryMarkers(tt.input)
+			result := removeSummaryMarkers(tt.input)
 			if result != tt.expected {
 				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
 			}
@@ -700,12 +812,6 @@ func TestRemoveSummaryMarkers(t *testing.T) {
 
 // TestUnescapeText tests the text unescaping functionality
 func TestUnescapeText(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-
-	service := &OllamaAIService{
-		logger: logger,
-	}
-
 	tests := []struct {
 		name     string
 		input    string
@@ -745,7 +851,7 @@ func TestUnescapeText(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.unescapeText(tt.input)
+			result := unescapeText(tt.input)
 			if result != tt.expected {
 				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
 			}
@@ -785,10 +891,10 @@ func TestErrorHandling(t *testing.T) {
 			name: "empty response",
 			setupServer: func() *httptest.Server {
 				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					response := OllamaResponse{
-						Model:    "devstral",
-						Response: "",
-						Done:     true,
+					response := OllamaChatResponse{
+						Model:   "devstral",
+						Message: OllamaChatMessage{Role: "assistant", Content: ""},
+						Done:    true,
 					}
 					json.NewEncoder(w).Encode(response)
 				}))
@@ -812,7 +918,7 @@ func TestErrorHandling(t *testing.T) {
 				logger:    logger,
 			}
 
-			_, err := service.executeQuery("test query")
+			_, err := service.executeChat(context.Background(), []OllamaChatMessage{{Role: ChatRoleUser, Content: "test query"}}, nil)
 
 			if tt.expectError {
 				if err == nil {
@@ -861,9 +967,6 @@ func (m *MockRateLimiter) ClearQuotaExhaustion(providerID string) {
 
 // TestCleanCitations tests the citation cleaning functionality while preserving newlines
 func TestCleanCitations(t *testing.T) {
-	// Create test service
-	service := &OllamaAIService{}
-
 	tests := []struct {
 		name     string
 		input    string
@@ -923,7 +1026,7 @@ func TestCleanCitations(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.cleanCitations(tt.input)
+			result := cleanCitations(tt.input)
 			if result != tt.expected {
 				t.Errorf("cleanCitations() = %q, expected %q", result, tt.expected)
 			}
@@ -933,8 +1036,6 @@ func TestCleanCitations(t *testing.T) {
 
 // TestCleanCitationsNewlinePreservation specifically tests newline preservation
 func TestCleanCitationsNewlinePreservation(t *testing.T) {
-	service := &OllamaAIService{}
-
 	// Test various newline scenarios
 	testCases := []struct {
 		name     string
@@ -965,7 +1066,7 @@ func TestCleanCitationsNewlinePreservation(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := service.cleanCitations(tc.input)
+			result := cleanCitations(tc.input)
 			if result != tc.expected {
 				t.Errorf("Expected %q, got %q", tc.expected, result)
 				// Show character-by-character comparison for debugging
@@ -975,3 +1076,224 @@ func TestCleanCitationsNewlinePreservation(t *testing.T) {
 		})
 	}
 }
+
+// TestQueryWithMessagesStream tests streaming responses from Ollama's NDJSON chat API
+func TestQueryWithMessagesStream(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			t.Errorf("Expected streaming request")
+		}
+		if len(req.Messages) != 4 || req.Messages[1].Content != "Previous question about agents" || req.Messages[2].Role != "assistant" {
+			t.Errorf("Request should contain conversation history as chat messages, got %+v", req.Messages)
+		}
+
+		encoder := json.NewEncoder(w)
+		for _, token := range []string{"BMAD uses ", "specialized agents.", " [cite: 12]", "\n\n[SUMMARY]: ", "BMAD Agents"} {
+			encoder.Encode(OllamaChatResponse{Model: "devstral", Message: OllamaChatMessage{Role: "assistant", Content: token}})
+			w.(http.Flusher).Flush()
+		}
+		encoder.Encode(OllamaChatResponse{Model: "devstral", Done: true})
+	}))
+	defer mockServer.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	service := &OllamaAIService{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		baseURL:           mockServer.URL,
+		modelName:         "devstral",
+		timeout:           10 * time.Second,
+		logger:            logger,
+		bmadKnowledgeBase: "Test BMAD knowledge base content",
+	}
+
+	history := []ChatMessage{
+		{Role: ChatRoleUser, Content: "Previous question about agents"},
+		{Role: ChatRoleAssistant, Content: "Previous answer about agents"},
+	}
+
+	var progress []string
+	response, err := service.QueryWithMessagesStream(context.Background(), "Tell me about agents", history, func(partial string) {
+		progress = append(progress, partial)
+	})
+	if err != nil {
+		t.Fatalf("QueryWithMessagesStream failed: %v", err)
+	}
+
+	if response != "BMAD uses specialized agents." {
+		t.Errorf("Expected cleaned final response, got: %q", response)
+	}
+
+	if len(progress) == 0 || progress[0] != "BMAD uses" {
+		t.Fatalf("Expected progressive updates starting with the first token, got: %q", progress)
+	}
+
+	for _, partial := range progress {
+		if strings.Contains(partial, "[cite") || strings.Contains(partial, "SUMMARY") {
+			t.Errorf("Progress update should not expose markers, got: %q", partial)
+		}
+	}
+}
+
+// TestQueryWithMessagesStreamErrors tests error handling for streamed responses
+func TestQueryWithMessagesStreamErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		errText string
+	}{
+		{
+			name: "error chunk",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(OllamaChatResponse{Message: OllamaChatMessage{Role: "assistant", Content: "partial"}})
+				json.NewEncoder(w).Encode(OllamaChatResponse{Error: "model crashed"})
+			},
+			errText: "ollama API error: model crashed",
+		},
+		{
+			name: "stream ends early",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(OllamaChatResponse{Message: OllamaChatMessage{Role: "assistant", Content: "partial"}})
+			},
+			errText: "ended before completion",
+		},
+		{
+			name: "http error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("internal error"))
+			},
+			errText: "status 500",
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := httptest.NewServer(tt.handler)
+			defer mockServer.Close()
+
+			service := &OllamaAIService{
+				client:            &http.Client{Timeout: 10 * time.Second},
+				baseURL:           mockServer.URL,
+				modelName:         "devstral",
+				timeout:           10 * time.Second,
+				logger:            logger,
+				bmadKnowledgeBase: "Test BMAD knowledge base content",
+			}
+
+			_, err := service.QueryWithMessagesStream(context.Background(), "query", nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("Expected error containing %q, got %v", tt.errText, err)
+			}
+		})
+	}
+}
+
+// TestOllamaCancelledRequest tests that cancelling the caller's context abandons an in-flight generation
+func TestOllamaCancelledRequest(t *testing.T) {
+	requestStarted := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		close(requestStarted)
+		<-r.Context().Done()
+	}))
+	defer mockServer.Close()
+
+	service := &OllamaAIService{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		baseURL:           mockServer.URL,
+		modelName:         "devstral",
+		timeout:           10 * time.Second,
+		logger:            slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+		bmadKnowledgeBase: "Test BMAD knowledge base content",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requestStarted
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := service.QueryAI(ctx, "What is BMAD?")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to stop when cancelled, took %v", elapsed)
+	}
+}
+
+// TestOllamaHealthCheck tests the lightweight reachability check used for readiness probes
+func TestOllamaHealthCheck(t *testing.T) {
+	status := http.StatusOK
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" || r.Method != http.MethodGet {
+			t.Errorf("Unexpected health check request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"models":[]}`))
+	}))
+
+	service := &OllamaAIService{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: mockServer.URL,
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+	}
+
+	if err := service.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected reachable server to pass, got %v", err)
+	}
+
+	status = http.StatusInternalServerError
+	if err := service.HealthCheck(context.Background()); err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Expected error status to fail, got %v", err)
+	}
+
+	mockServer.Close()
+	if err := service.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected unreachable server to fail")
+	}
+}
+
+// TestApplyConfiguration tests live model and timeout changes
+func TestApplyConfiguration(t *testing.T) {
+	service := &OllamaAIService{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		modelName: "devstral",
+		timeout:   30 * time.Second,
+		logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := service.ApplyConfiguration(map[string]string{"OLLAMA_MODEL": "llama3", "OLLAMA_TIMEOUT": "90"}); err != nil {
+		t.Fatalf("ApplyConfiguration failed: %v", err)
+	}
+	if service.model() != "llama3" {
+		t.Errorf("Expected model llama3, got %s", service.model())
+	}
+	if service.requestTimeout() != 90*time.Second || service.httpClient().Timeout != 90*time.Second {
+		t.Errorf("Expected 90s timeout, got %v (client %v)", service.requestTimeout(), service.httpClient().Timeout)
+	}
+
+	// Missing keys keep their current values
+	if err := service.ApplyConfiguration(map[string]string{}); err != nil {
+		t.Fatalf("ApplyConfiguration failed: %v", err)
+	}
+	if service.model() != "llama3" || service.requestTimeout() != 90*time.Second {
+		t.Errorf("Expected settings to be unchanged, got %s and %v", service.model(), service.requestTimeout())
+	}
+
+	for _, value := range []string{"0", "-5", "1m"} {
+		if err := service.ApplyConfiguration(map[string]string{"OLLAMA_TIMEOUT": value}); err == nil {
+			t.Errorf("Expected OLLAMA_TIMEOUT %q to be rejected", value)
+		}
+	}
+	if service.requestTimeout() != 90*time.Second {
+		t.Errorf("Expected rejected timeout to leave 90s in place, got %v", service.requestTimeout())
+	}
+}
@@ -253,3 +253,32 @@ func TestQualityDisabled(t *testing.T) {
 		t.Errorf("Expected no metrics updates when disabled, got %d responses", metrics.TotalResponses)
 	}
 }
+
+// TestDescribeResponse verifies the metadata recorded alongside each response
+func TestDescribeResponse(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	service := &OllamaAIService{
+		logger:         logger,
+		modelName:      "devstral",
+		qualityEnabled: true,
+		qualityMetrics: &QualityMetrics{},
+		bmadTerms:      []string{"BMAD", "agents"},
+	}
+
+	metadata := service.DescribeResponse("What are BMAD agents?", "BMAD agents are specialized roles.")
+	if metadata.Provider != "ollama" || metadata.Model != "devstral" {
+		t.Errorf("Unexpected metadata: %+v", metadata)
+	}
+	if metadata.Quality == nil || metadata.Quality.OverallScore <= 0 {
+		t.Errorf("Expected a quality score, got %+v", metadata.Quality)
+	}
+	if service.qualityMetrics.TotalResponses != 0 {
+		t.Error("Describing a response should not update the running quality metrics")
+	}
+
+	service.qualityEnabled = false
+	if metadata := service.DescribeResponse("query", "response"); metadata.Quality != nil {
+		t.Errorf("Expected no quality score when monitoring is disabled, got %+v", metadata.Quality)
+	}
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+// OpenAIConfig holds configuration for an OpenAI-compatible chat completions provider
+type OpenAIConfig struct {
+	BaseURL            string        // API base URL including the version prefix (e.g. https://api.openai.com/v1)
+	Model              string        // Model name sent with each request
+	APIKey             string        // Bearer token; optional for local servers such as vLLM or llama.cpp
+	Temperature        float64       // Sampling temperature
+	MaxTokens          int           // Maximum completion tokens (0 = server default)
+	Timeout            time.Duration // HTTP request timeout
+	PromptStyle        string        // BMAD prompt style (structured, simple, detailed, chain_of_thought)
+	RetrievalTopK      int           // Knowledge base sections per prompt (0 = send the full knowledge base)
+	KnowledgeCachePath string        // Ephemeral knowledge base cache path
+}
+
+// OpenAIChatMessage represents a single message in a chat completions request or response
+type OpenAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIChatRequest represents the request payload for the /chat/completions endpoint
+type OpenAIChatRequest struct {
+	Model       string              `json:"model"`
+	Messages    []OpenAIChatMessage `json:"messages"`
+	Temperature float64             `json:"temperature"`
+	MaxTokens   int                 `json:"max_tokens,omitempty"`
+	Stream      bool                `json:"stream"`
+}
+
+// OpenAIChatResponse represents the response from the /chat/completions endpoint
+type OpenAIChatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int               `json:"index"`
+		Message      OpenAIChatMessage `json:"message"`
+		FinishReason string            `json:"finish_reason"`
+	} `json:"choices"`
+	Error *OpenAIError `json:"error,omitempty"`
+}
+
+// OpenAIError represents an error object returned by OpenAI-compatible APIs
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// OpenAIAIService implements AIService using the OpenAI chat completions protocol,
+// covering hosted APIs as well as vLLM, llama.cpp server and LM Studio
+type OpenAIAIService struct {
+	client            *http.Client
+	config            OpenAIConfig
+	logger            *slog.Logger
+	rateLimiter       monitor.AIProviderRateLimiter
+	metrics           *monitor.Metrics
+	bmadKnowledgeBase string
+	knowledgeBaseMu   sync.RWMutex
+	retriever         *KnowledgeRetriever
+}
+
+// LoadOpenAIConfigFromEnv reads OpenAI-compatible provider configuration from environment variables
+func LoadOpenAIConfigFromEnv() (OpenAIConfig, error) {
+	config := OpenAIConfig{
+		BaseURL:            os.Getenv("OPENAI_BASE_URL"),
+		Model:              os.Getenv("OPENAI_MODEL"),
+		APIKey:             os.Getenv("OPENAI_API_KEY"),
+		Temperature:        0.2,
+		Timeout:            60 * time.Second,
+		PromptStyle:        os.Getenv("OPENAI_PROMPT_STYLE"),
+		RetrievalTopK:      DefaultRetrievalTopK,
+		KnowledgeCachePath: knowledgeBaseCachePath,
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.openai.com/v1"
+	}
+
+	if config.Model == "" {
+		config.Model = "gpt-4o-mini"
+	}
+
+	if temperatureStr := os.Getenv("OPENAI_TEMPERATURE"); temperatureStr != "" {
+		temperature, err := strconv.ParseFloat(temperatureStr, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return config, fmt.Errorf("invalid OPENAI_TEMPERATURE (must be between 0 and 2): %s", temperatureStr)
+		}
+		config.Temperature = temperature
+	}
+
+	if maxTokensStr := os.Getenv("OPENAI_MAX_TOKENS"); maxTokensStr != "" {
+		maxTokens, err := strconv.Atoi(maxTokensStr)
+		if err != nil || maxTokens < 0 {
+			return config, fmt.Errorf("invalid OPENAI_MAX_TOKENS (must be a non-negative integer): %s", maxTokensStr)
+		}
+		config.MaxTokens = maxTokens
+	}
+
+	if timeoutStr := os.Getenv("OPENAI_TIMEOUT"); timeoutStr != "" {
+		timeout, err := strconv.Atoi(timeoutStr)
+		if err != nil || timeout <= 0 {
+			return config, fmt.Errorf("invalid OPENAI_TIMEOUT (must be a positive number of seconds): %s", timeoutStr)
+		}
+		config.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	if topKStr := os.Getenv("BMAD_KB_RETRIEVAL_TOP_K"); topKStr != "" {
+		if topK, err := strconv.Atoi(topKStr); err == nil && topK >= 0 {
+			config.RetrievalTopK = topK
+		}
+	}
+
+	return config, nil
+}
+
+// NewOpenAIAIService creates an OpenAI-compatible AI service configured from environment variables
+func NewOpenAIAIService(logger *slog.Logger) (*OpenAIAIService, error) {
+	config, err := LoadOpenAIConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewOpenAIAIServiceWithConfig(config, logger)
+}
+
+// NewOpenAIAIServiceWithConfig creates an OpenAI-compatible AI service and loads the BMAD knowledge base
+func NewOpenAIAIServiceWithConfig(config OpenAIConfig, logger *slog.Logger) (*OpenAIAIService, error) {
+	if strings.TrimSpace(config.BaseURL) == "" {
+		return nil, fmt.Errorf("OpenAI base URL is required")
+	}
+	if strings.TrimSpace(config.Model) == "" {
+		return nil, fmt.Errorf("OpenAI model is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 60 * time.Second
+	}
+	if config.KnowledgeCachePath == "" {
+		config.KnowledgeCachePath = knowledgeBaseCachePath
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	service := &OpenAIAIService{
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		config: config,
+		logger: logger,
+	}
+
+	// Retrieval uses BM25 ranking; embeddings are only available through Ollama
+	if config.RetrievalTopK > 0 {
+		service.retriever = NewKnowledgeRetriever(config.RetrievalTopK, nil, logger)
+	}
+
+	logger.Info("OpenAI-compatible service configured",
+		"base_url", config.BaseURL,
+		"model", config.Model,
+		"timeout", config.Timeout,
+		"temperature", config.Temperature,
+		"max_tokens", config.MaxTokens,
+		"api_key_set", config.APIKey != "",
+		"retrieval_top_k", config.RetrievalTopK)
+
+	content, err := loadKnowledgeBaseContent(service.client, config.KnowledgeCachePath, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load BMAD knowledge base: %w", err)
+	}
+
+	service.bmadKnowledgeBase = content
+	service.indexKnowledgeBase(content)
+
+	return service, nil
+}
+
+// SetRateLimiter sets the rate limiter for this service
+func (s *OpenAIAIService) SetRateLimiter(rateLimiter monitor.AIProviderRateLimiter) {
+	s.rateLimiter = rateLimiter
+}
+
+// SetMetrics sets the metrics registry that records request latency and payload sizes
+func (s *OpenAIAIService) SetMetrics(metrics *monitor.Metrics) {
+	s.metrics = metrics
+}
+
+// GetProviderID returns the unique identifier for this AI provider
+func (s *OpenAIAIService) GetProviderID() string {
+	return "openai"
+}
+
+// DescribeResponse returns the configured model; responses from this provider are not quality scored
+func (s *OpenAIAIService) DescribeResponse(query, response string) ResponseMetadata {
+	return ResponseMetadata{Provider: s.GetProviderID(), Model: s.config.Model}
+}
+
+// RefreshKnowledgeBase refreshes the knowledge base from ephemeral cache and re-indexes it for retrieval
+func (s *OpenAIAIService) RefreshKnowledgeBase() error {
+	content, err := os.ReadFile(s.config.KnowledgeCachePath)
+	if err != nil {
+		s.logger.Warn("Failed to refresh knowledge base from ephemeral cache",
+			"cache_path", s.config.KnowledgeCachePath,
+			"error", err)
+		return err
+	}
+
+	s.knowledgeBaseMu.Lock()
+	s.bmadKnowledgeBase = string(content)
+	s.knowledgeBaseMu.Unlock()
+
+	s.logger.Info("BMAD knowledge base refreshed from ephemeral cache",
+		"cache_path", s.config.KnowledgeCachePath,
+		"size", len(content))
+
+	s.indexKnowledgeBase(string(content))
+	return nil
+}
+
+// indexKnowledgeBase rebuilds the retrieval index; prompts fall back to the full knowledge base on failure
+func (s *OpenAIAIService) indexKnowledgeBase(content string) {
+	if s.retriever == nil {
+		return
+	}
+
+	if err := s.retriever.Index(context.Background(), content); err != nil {
+		s.logger.Warn("Failed to index knowledge base for retrieval, using full knowledge base in prompts",
+			"error", err)
+	}
+}
+
+// knowledgeContext returns the knowledge base text to include in a prompt for the query
+func (s *OpenAIAIService) knowledgeContext(ctx context.Context, query, conversationHistory string) string {
+	if knowledge := retrieveKnowledgeContext(ctx, s.retriever, query, conversationHistory, s.config.Timeout, s.logger); knowledge != "" {
+		return knowledge
+	}
+
+	s.knowledgeBaseMu.RLock()
+	defer s.knowledgeBaseMu.RUnlock()
+	return s.bmadKnowledgeBase
+}
+
+// buildBMADPrompt creates a prompt that includes the relevant BMAD knowledge base sections and constraints
+func (s *OpenAIAIService) buildBMADPrompt(ctx context.Context, userQuery string) string {
+	return buildPromptForStyle(s.config.PromptStyle, s.knowledgeContext(ctx, userQuery, ""), userQuery)
+}
+
+// PromptTokens estimates the tokens taken by the instructions and knowledge base sent with every query
+func (s *OpenAIAIService) PromptTokens() int {
+	s.knowledgeBaseMu.RLock()
+	defer s.knowledgeBaseMu.RUnlock()
+	return estimatePromptTokens(buildPromptForStyle(s.config.PromptStyle, "", ""), s.retriever, s.bmadKnowledgeBase)
+}
+
+// CiteSources returns the knowledge base sections that best support response, or nil without retrieval
+func (s *OpenAIAIService) CiteSources(response string) []KnowledgeSection {
+	if s.retriever == nil {
+		return nil
+	}
+	return s.retriever.Sources(response)
+}
+
+// beginCall checks the provider rate limit and registers the call
+func (s *OpenAIAIService) beginCall() error {
+	if err := checkProviderRateLimit(s.rateLimiter, s.GetProviderID(), s.logger); err != nil {
+		return err
+	}
+	registerProviderCall(s.rateLimiter, s.GetProviderID(), s.logger)
+	return nil
+}
+
+// QueryAI sends a query to the chat completions API and returns the response
+func (s *OpenAIAIService) QueryAI(ctx context.Context, query string) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
+
+	if err := s.beginCall(); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("Sending query to OpenAI-compatible API",
+		"provider", s.GetProviderID(),
+		"model", s.config.Model,
+		"query_length", len(query))
+
+	response, err := s.executeChatCompletion(ctx, s.buildBMADPrompt(ctx, query))
+	if err != nil {
+		return "", err
+	}
+
+	return removeSummaryMarkers(cleanCitations(response)), nil
+}
+
+// QueryAIWithSummary sends a query and returns both the response and extracted summary
+func (s *OpenAIAIService) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", "", fmt.Errorf("query cannot be empty")
+	}
+
+	if err := s.beginCall(); err != nil {
+		return "", "", err
+	}
+
+	s.logger.Info("Sending query to OpenAI-compatible API with integrated summarization",
+		"provider", s.GetProviderID(),
+		"model", s.config.Model,
+		"query_length", len(query))
+
+	fullResponse, err := s.executeChatCompletion(ctx, s.buildBMADPrompt(ctx, query))
+	if err != nil {
+		return "", "", err
+	}
+
+	mainAnswer, summary, parseErr := parseResponseWithSummary(fullResponse, s.logger)
+	if parseErr != nil {
+		s.logger.Warn("Failed to parse response with summary, returning full response",
+			"error", parseErr)
+		return fullResponse, "", nil
+	}
+
+	return mainAnswer, summary, nil
+}
+
+// SummarizeQuery creates a summarized version of a user query suitable for Discord thread titles
+func (s *OpenAIAIService) SummarizeQuery(ctx context.Context, query string) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
+
+	if err := s.beginCall(); err != nil {
+		return fallbackSummarize(query), nil
+	}
+
+	prompt := fmt.Sprintf("Create a concise summary of this BMAD-METHOD related question in 8 words or less, suitable for a Discord thread title. Focus on the BMAD topic or concept being asked about. Do not include quotes or formatting. Question: %s", query)
+
+	summary, err := s.executeChatCompletion(ctx, prompt)
+	if ctx.Err() != nil {
+		return "", err
+	}
+	if err != nil || summary == "" {
+		s.logger.Warn("AI summarization failed, using fallback", "provider", s.GetProviderID(), "error", err)
+		return fallbackSummarize(query), nil
+	}
+
+	// Ensure summary fits Discord's 100 character limit for thread titles
+	if len(summary) > 100 {
+		summary = summary[:97] + "..."
+	}
+
+	return summary, nil
+}
+
+// QueryWithContext sends a query with conversation history context to the AI service
+func (s *OpenAIAIService) QueryWithContext(ctx context.Context, query string, conversationHistory string) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
+
+	if err := s.beginCall(); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("Sending contextual query to OpenAI-compatible API",
+		"provider", s.GetProviderID(),
+		"model", s.config.Model,
+		"query_length", len(query),
+		"history_length", len(conversationHistory))
+
+	var prompt string
+	if strings.TrimSpace(conversationHistory) != "" {
+		prompt = buildConversationPrompt(s.knowledgeContext(ctx, query, conversationHistory), conversationHistory, query)
+	} else {
+		prompt = s.buildBMADPrompt(ctx, query)
+	}
+
+	response, err := s.executeChatCompletion(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return removeSummaryMarkers(cleanCitations(response)), nil
+}
+
+// SummarizeConversation creates a summary of conversation history for context preservation
+func (s *OpenAIAIService) SummarizeConversation(ctx context.Context, messages []string) (string, error) {
+	if len(messages) == 0 {
+		return "", nil
+	}
+
+	if err := s.beginCall(); err != nil {
+		return fallbackConversationSummary(messages), nil
+	}
+
+	prompt := fmt.Sprintf("Summarize this BMAD-METHOD conversation in a concise way that preserves the key BMAD concepts and topics discussed. Focus on the BMAD-related questions asked and important BMAD information shared. Keep it under 500 words:\n\n%s", strings.Join(messages, "\n"))
+
+	summary, err := s.executeChatCompletion(ctx, prompt)
+	if ctx.Err() != nil {
+		return "", err
+	}
+	if err != nil || summary == "" {
+		s.logger.Warn("AI conversation summarization failed, using fallback", "provider", s.GetProviderID(), "error", err)
+		return fallbackConversationSummary(messages), nil
+	}
+
+	return summary, nil
+}
+
+// executeChatCompletion sends the prompt as a single user message and returns the assistant's reply
+func (s *OpenAIAIService) executeChatCompletion(ctx context.Context, prompt string) (string, error) {
+	start := time.Now()
+	response, err := s.sendChatCompletion(ctx, prompt)
+	s.metrics.ObserveAIRequest(s.GetProviderID(), monitor.AIRequestModeBlocking, time.Since(start), len(prompt), len(response), err)
+	return response, err
+}
+
+// sendChatCompletion performs a single /chat/completions request, abandoning it when ctx ends
+func (s *OpenAIAIService) sendChatCompletion(ctx context.Context, prompt string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	request := OpenAIChatRequest{
+		Model: s.config.Model,
+		Messages: []OpenAIChatMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: s.config.Temperature,
+		MaxTokens:   s.config.MaxTokens,
+		Stream:      false,
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("OpenAI-compatible API request failed",
+			"provider", s.GetProviderID(),
+			"model", s.config.Model,
+			"error", err)
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", fmt.Errorf("openai API request cancelled: %w", ctx.Err())
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("openai API request timed out after %v: %w", s.config.Timeout, ctx.Err())
+		}
+		return "", fmt.Errorf("openai API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var chatResp OpenAIChatResponse
+	decodeErr := json.Unmarshal(body, &chatResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := string(body)
+		if decodeErr == nil && chatResp.Error != nil && chatResp.Error.Message != "" {
+			message = chatResp.Error.Message
+		}
+		s.logger.Error("OpenAI-compatible API returned error status",
+			"provider", s.GetProviderID(),
+			"status", resp.StatusCode,
+			"response", message)
+
+		// Hosted APIs report an exhausted account quota as 429 with an insufficient_quota code
+		if resp.StatusCode == http.StatusTooManyRequests && decodeErr == nil && chatResp.Error != nil &&
+			(chatResp.Error.Code == "insufficient_quota" || chatResp.Error.Type == "insufficient_quota") {
+			return "", fmt.Errorf("openai API returned status %d: %s: %w", resp.StatusCode, message, ErrQuotaExhausted)
+		}
+		return "", fmt.Errorf("openai API returned status %d: %s", resp.StatusCode, message)
+	}
+
+	if decodeErr != nil {
+		return "", fmt.Errorf("failed to decode response: %w", decodeErr)
+	}
+
+	if chatResp.Error != nil {
+		return "", fmt.Errorf("openai API error: %s", chatResp.Error.Message)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai API returned no choices")
+	}
+
+	response := strings.TrimSpace(chatResp.Choices[0].Message.Content)
+	if response == "" {
+		s.logger.Warn("OpenAI-compatible API returned empty response",
+			"provider", s.GetProviderID(),
+			"model", s.config.Model)
+		return "I received an empty response from the AI service.", nil
+	}
+
+	s.logger.Info("OpenAI-compatible API response received",
+		"provider", s.GetProviderID(),
+		"model", s.config.Model,
+		"response_length", len(response),
+		"finish_reason", chatResp.Choices[0].FinishReason)
+
+	return response, nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+// newTestOpenAIService creates an OpenAI service backed by the given handler and a cached test knowledge base
+func newTestOpenAIService(t *testing.T, handler http.HandlerFunc) (*OpenAIAIService, *httptest.Server) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cachePath := filepath.Join(t.TempDir(), "kb.md")
+	if err := os.WriteFile(cachePath, []byte(testRetrievalKB), 0644); err != nil {
+		t.Fatalf("Failed to write knowledge base cache: %v", err)
+	}
+
+	service, err := NewOpenAIAIServiceWithConfig(OpenAIConfig{
+		BaseURL:            server.URL + "/v1/",
+		Model:              "test-model",
+		APIKey:             "sk-test",
+		Temperature:        0.7,
+		MaxTokens:          256,
+		Timeout:            5 * time.Second,
+		KnowledgeCachePath: cachePath,
+	}, newTestRetrievalLogger())
+	if err != nil {
+		t.Fatalf("Failed to create OpenAI service: %v", err)
+	}
+
+	return service, server
+}
+
+// writeChatCompletion writes a minimal chat completions response with the given content
+func writeChatCompletion(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"id":"chatcmpl-1","model":"test-model","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, content)
+}
+
+func TestOpenAIAIService_QueryAI(t *testing.T) {
+	var received OpenAIChatRequest
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer sk-test" {
+			t.Errorf("Expected bearer token, got %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		writeChatCompletion(w, "The Scrum Master drafts stories. [cite: 3]")
+	})
+
+	response, err := service.QueryAI(context.Background(), "What does the Scrum Master do?")
+	if err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+
+	if response != "The Scrum Master drafts stories." {
+		t.Errorf("Unexpected response: %q", response)
+	}
+
+	if received.Model != "test-model" || received.Temperature != 0.7 || received.MaxTokens != 256 || received.Stream {
+		t.Errorf("Unexpected request parameters: %+v", received)
+	}
+	if len(received.Messages) != 1 || received.Messages[0].Role != "user" {
+		t.Fatalf("Expected a single user message, got %+v", received.Messages)
+	}
+	if !strings.Contains(received.Messages[0].Content, "What does the Scrum Master do?") ||
+		!strings.Contains(received.Messages[0].Content, "drafts stories from sharded epics") {
+		t.Error("Expected prompt to include the query and knowledge base")
+	}
+}
+
+func TestOpenAIAIService_QueryAIWithSummary(t *testing.T) {
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeChatCompletion(w, "Greenfield workflows start new projects.\n\n[SUMMARY]: Greenfield workflows")
+	})
+
+	answer, summary, err := service.QueryAIWithSummary(context.Background(), "What is greenfield?")
+	if err != nil {
+		t.Fatalf("QueryAIWithSummary failed: %v", err)
+	}
+
+	if answer != "Greenfield workflows start new projects." {
+		t.Errorf("Unexpected answer: %q", answer)
+	}
+	if summary != "Greenfield workflows" {
+		t.Errorf("Unexpected summary: %q", summary)
+	}
+}
+
+func TestOpenAIAIService_QueryWithContext(t *testing.T) {
+	var prompt string
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[0].Content
+		writeChatCompletion(w, "It also reviews the architecture.")
+	})
+
+	history := "User: Tell me about the Architect\nBot: The Architect designs systems."
+	response, err := service.QueryWithContext(context.Background(), "What else?", history)
+	if err != nil {
+		t.Fatalf("QueryWithContext failed: %v", err)
+	}
+
+	if response != "It also reviews the architecture." {
+		t.Errorf("Unexpected response: %q", response)
+	}
+	if !strings.Contains(prompt, history) || !strings.Contains(prompt, "What else?") {
+		t.Error("Expected prompt to include conversation history and query")
+	}
+}
+
+func TestOpenAIAIService_OmitsOptionalRequestFields(t *testing.T) {
+	var rawBody map[string]interface{}
+	var authHeader string
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&rawBody)
+		writeChatCompletion(w, "ok")
+	})
+	service.config.APIKey = ""
+	service.config.MaxTokens = 0
+
+	if _, err := service.QueryAI(context.Background(), "agents"); err != nil {
+		t.Fatalf("QueryAI failed: %v", err)
+	}
+
+	if authHeader != "" {
+		t.Errorf("Expected no Authorization header without an API key, got %q", authHeader)
+	}
+	if _, ok := rawBody["max_tokens"]; ok {
+		t.Error("Expected max_tokens to be omitted when unset")
+	}
+}
+
+func TestOpenAIAIService_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "error status with error object",
+			status:        http.StatusUnauthorized,
+			body:          `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`,
+			expectedError: "openai API returned status 401: Incorrect API key provided",
+		},
+		{
+			name:          "error status with plain body",
+			status:        http.StatusBadGateway,
+			body:          "upstream unavailable",
+			expectedError: "openai API returned status 502: upstream unavailable",
+		},
+		{
+			name:          "no choices",
+			status:        http.StatusOK,
+			body:          `{"id":"chatcmpl-1","choices":[]}`,
+			expectedError: "openai API returned no choices",
+		},
+		{
+			name:          "invalid JSON",
+			status:        http.StatusOK,
+			body:          "not json",
+			expectedError: "failed to decode response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := service.QueryAI(context.Background(), "agents")
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestOpenAIAIService_QuotaExhausted(t *testing.T) {
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`)
+	})
+
+	_, err := service.QueryAI(context.Background(), "agents")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Expected ErrQuotaExhausted, got %v", err)
+	}
+}
+
+func TestOpenAIAIService_SummarizeFallbacks(t *testing.T) {
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	summary, err := service.SummarizeQuery(context.Background(), "What is the BMAD method for agile development?")
+	if err != nil {
+		t.Fatalf("SummarizeQuery should fall back instead of failing: %v", err)
+	}
+	if summary == "" {
+		t.Error("Expected fallback summary")
+	}
+
+	conversation, err := service.SummarizeConversation(context.Background(), []string{"User: hi", "Bot: hello"})
+	if err != nil {
+		t.Fatalf("SummarizeConversation should fall back instead of failing: %v", err)
+	}
+	if conversation == "" {
+		t.Error("Expected fallback conversation summary")
+	}
+}
+
+func TestOpenAIAIService_RateLimiting(t *testing.T) {
+	requests := 0
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeChatCompletion(w, "ok")
+	})
+
+	if service.GetProviderID() != "openai" {
+		t.Errorf("Expected provider ID openai, got %s", service.GetProviderID())
+	}
+
+	rateLimiter := monitor.NewRateLimitManager(newTestRetrievalLogger(), []monitor.ProviderConfig{
+		{
+			ProviderID: "openai",
+			Limits:     map[string]int{"minute": 1},
+			Thresholds: map[string]float64{"warning": 0.5, "throttled": 1.0},
+		},
+	})
+	service.SetRateLimiter(rateLimiter)
+
+	if _, err := service.QueryAI(context.Background(), "agents"); err != nil {
+		t.Fatalf("First query should succeed: %v", err)
+	}
+
+	usage, _ := rateLimiter.GetProviderUsage("openai")
+	if usage != 1 {
+		t.Errorf("Expected call registered under the openai provider, got usage %d", usage)
+	}
+
+	_, err := service.QueryAI(context.Background(), "agents")
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded for provider openai") {
+		t.Errorf("Expected rate limit error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected throttled query not to reach the API, got %d requests", requests)
+	}
+}
+
+func TestOpenAIAIService_RefreshKnowledgeBase(t *testing.T) {
+	service, _ := newTestOpenAIService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeChatCompletion(w, "ok")
+	})
+
+	updated := "## Updated\n\nRefreshed knowledge about the orchestrator agent."
+	if err := os.WriteFile(service.config.KnowledgeCachePath, []byte(updated), 0644); err != nil {
+		t.Fatalf("Failed to update cache: %v", err)
+	}
+
+	if err := service.RefreshKnowledgeBase(); err != nil {
+		t.Fatalf("RefreshKnowledgeBase failed: %v", err)
+	}
+
+	if !strings.Contains(service.buildBMADPrompt(context.Background(), "orchestrator"), "Refreshed knowledge") {
+		t.Error("Expected prompt to use the refreshed knowledge base")
+	}
+}
+
+func TestLoadOpenAIConfigFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "http://localhost:8000/v1")
+	t.Setenv("OPENAI_MODEL", "llama-3")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_TEMPERATURE", "0.5")
+	t.Setenv("OPENAI_MAX_TOKENS", "1024")
+	t.Setenv("OPENAI_TIMEOUT", "30")
+
+	config, err := LoadOpenAIConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadOpenAIConfigFromEnv failed: %v", err)
+	}
+
+	if config.BaseURL != "http://localhost:8000/v1" || config.Model != "llama-3" ||
+		config.Temperature != 0.5 || config.MaxTokens != 1024 || config.Timeout != 30*time.Second {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	t.Setenv("OPENAI_TEMPERATURE", "3")
+	if _, err := LoadOpenAIConfigFromEnv(); err == nil {
+		t.Error("Expected error for out-of-range temperature")
+	}
+
+	t.Setenv("OPENAI_TEMPERATURE", "")
+	t.Setenv("OPENAI_MAX_TOKENS", "many")
+	if _, err := LoadOpenAIConfigFromEnv(); err == nil {
+		t.Error("Expected error for invalid max tokens")
+	}
+}
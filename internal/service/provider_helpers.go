@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+const (
+	// knowledgeBaseCachePath is the ephemeral cache shared by AI providers and the knowledge base updater
+	knowledgeBaseCachePath = "/tmp/bmad-kb-cache.md"
+
+	// defaultKnowledgeBaseURL is used when BMAD_KB_REMOTE_URL is not set
+	defaultKnowledgeBaseURL = "https://github.com/bmadcode/BMAD-METHOD/raw/refs/heads/main/bmad-core/data/bmad-kb.md"
+)
+
+// loadKnowledgeBaseContent reads the BMAD knowledge base from the ephemeral cache,
+// fetching it from BMAD_KB_REMOTE_URL and populating the cache when no cached copy exists
+func loadKnowledgeBaseContent(client *http.Client, cachePath string, logger *slog.Logger) (string, error) {
+	// Get remote URL from environment variable
+	remoteURL := os.Getenv("BMAD_KB_REMOTE_URL")
+	if remoteURL == "" {
+		remoteURL = defaultKnowledgeBaseURL
+	}
+
+	// Try to read from ephemeral cache first
+	if content, err := os.ReadFile(cachePath); err == nil {
+		logger.Info("BMAD knowledge base loaded from ephemeral cache",
+			"cache_path", cachePath,
+			"size", len(content))
+		return string(content), nil
+	}
+
+	// If cache doesn't exist or is invalid, fetch from remote URL
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", remoteURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch BMAD knowledge base from %s: %w", remoteURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d when fetching BMAD knowledge base from %s", resp.StatusCode, remoteURL)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Cache content ephemerally
+	if err := os.WriteFile(cachePath, content, 0644); err != nil {
+		logger.Warn("Failed to write ephemeral cache",
+			"cache_path", cachePath,
+			"error", err)
+	}
+
+	logger.Info("BMAD knowledge base fetched from remote URL and cached",
+		"remote_url", remoteURL,
+		"cache_path", cachePath,
+		"size", len(content))
+
+	return string(content), nil
+}
+
+// retrieveKnowledgeContext returns the formatted knowledge base sections relevant to the query,
+// or an empty string when retrieval is unavailable and the full knowledge base should be used
+func retrieveKnowledgeContext(ctx context.Context, retriever *KnowledgeRetriever, query, conversationHistory string, timeout time.Duration, logger *slog.Logger) string {
+	if retriever == nil || retriever.SectionCount() == 0 {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sections := retriever.Retrieve(ctx, query, conversationHistory)
+	if len(sections) == 0 {
+		return ""
+	}
+
+	logger.Debug("Retrieved knowledge base sections for prompt",
+		"sections", len(sections),
+		"query_length", len(query))
+	return FormatKnowledgeSections(sections)
+}
+
+// checkProviderRateLimit validates that the provider is not rate limited before making a call
+func checkProviderRateLimit(rateLimiter monitor.AIProviderRateLimiter, providerID string, logger *slog.Logger) error {
+	if rateLimiter == nil {
+		// Rate limiting not configured - allow the call
+		return nil
+	}
+
+	status := rateLimiter.GetProviderStatus(providerID)
+
+	if status == "Throttled" {
+		usage, limit := rateLimiter.GetProviderUsage(providerID)
+		logger.Warn("Rate limit exceeded for provider",
+			"provider", providerID,
+			"status", status,
+			"usage", usage,
+			"limit", limit)
+		return fmt.Errorf("rate limit exceeded for provider %s: %d/%d requests",
+			providerID, usage, limit)
+	}
+
+	// Log warning status but don't block the call
+	if status == "Warning" {
+		usage, limit := rateLimiter.GetProviderUsage(providerID)
+		logger.Warn("Rate limit warning for provider",
+			"provider", providerID,
+			"status", status,
+			"usage", usage,
+			"limit", limit)
+	}
+
+	return nil
+}
+
+// registerProviderCall records an API call with the rate limiter, if one is configured
+func registerProviderCall(rateLimiter monitor.AIProviderRateLimiter, providerID string, logger *slog.Logger) {
+	if rateLimiter == nil {
+		return
+	}
+	if err := rateLimiter.RegisterCall(providerID); err != nil {
+		logger.Warn("Failed to register API call for rate limiting", "error", err)
+	}
+}
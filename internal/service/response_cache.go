@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"bmad-knowledge-bot/internal/monitor"
+)
+
+const (
+	// DefaultResponseCacheTTL is how long a cached answer is served before it is regenerated
+	DefaultResponseCacheTTL = 6 * time.Hour
+
+	// DefaultResponseCacheMaxEntries bounds the number of cached answers; the oldest is evicted first
+	DefaultResponseCacheMaxEntries = 500
+)
+
+// similarityStopWords are ignored when comparing questions for near-duplicate matches
+var similarityStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "between": true, "can": true, "do": true, "does": true,
+	"explain": true, "for": true, "how": true, "i": true, "in": true, "is": true, "me": true, "of": true,
+	"please": true, "s": true, "tell": true, "the": true, "to": true, "what": true, "with": true, "you": true,
+	"your": true,
+}
+
+// cachedResponse is an answer stored in the ResponseCache
+type cachedResponse struct {
+	query    string // Normalized query the answer was generated for
+	response string
+	summary  string
+	storedAt time.Time
+	terms    map[string]bool // Significant words of the query, for near-duplicate matching
+}
+
+// ResponseCacheStats is a snapshot of the cache's size and effectiveness
+type ResponseCacheStats struct {
+	Enabled             bool
+	Entries             int
+	Hits                int64
+	Misses              int64
+	TTL                 time.Duration
+	SimilarityThreshold int
+	KnowledgeBaseHash   string
+}
+
+// ResponseCache stores answers to standalone questions keyed by normalized query text and knowledge base
+// version, so frequently asked questions skip a full AI generation. Answers expire after the TTL and are
+// dropped whenever the knowledge base changes. It is safe for concurrent use.
+type ResponseCache struct {
+	logger  *slog.Logger
+	metrics *monitor.Metrics
+
+	mu                  sync.Mutex
+	enabled             bool
+	ttl                 time.Duration
+	maxEntries          int
+	similarityThreshold int // Minimum word overlap percentage for near-duplicate hits (0 = exact matches only)
+	kbHash              string
+	entries             map[string]*cachedResponse
+	hits                int64
+	misses              int64
+	now                 func() time.Time
+}
+
+// NewResponseCache creates an enabled cache with the default TTL and size and exact matching only
+func NewResponseCache(logger *slog.Logger) *ResponseCache {
+	return &ResponseCache{
+		logger:     logger,
+		enabled:    true,
+		ttl:        DefaultResponseCacheTTL,
+		maxEntries: DefaultResponseCacheMaxEntries,
+		entries:    make(map[string]*cachedResponse),
+		now:        time.Now,
+	}
+}
+
+// SetMetrics sets the metrics registry that counts cache hits and misses
+func (c *ResponseCache) SetMetrics(metrics *monitor.Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics = metrics
+}
+
+// SetEnabled turns the cache on or off; disabling it drops every cached answer
+func (c *ResponseCache) SetEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.enabled = enabled
+	if !enabled {
+		c.entries = make(map[string]*cachedResponse)
+	}
+}
+
+// SetTTL sets how long cached answers are served
+func (c *ResponseCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// SetMaxEntries sets how many answers are kept, evicting the oldest when the cache shrinks
+func (c *ResponseCache) SetMaxEntries(maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxEntries = maxEntries
+	for len(c.entries) > c.maxEntries {
+		c.evictOldestLocked()
+	}
+}
+
+// SetSimilarityThreshold sets the minimum percentage of shared significant words for a different question
+// to be answered from the cache; 0 limits hits to questions that normalize identically
+func (c *ResponseCache) SetSimilarityThreshold(percent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.similarityThreshold = percent
+}
+
+// SetKnowledgeBaseHash records the version of the knowledge base answers are generated against.
+// Answers cached for any other version are dropped.
+func (c *ResponseCache) SetKnowledgeBaseHash(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if hash == c.kbHash {
+		return
+	}
+
+	dropped := len(c.entries)
+	c.kbHash = hash
+	c.entries = make(map[string]*cachedResponse)
+	c.logger.Info("Response cache invalidated by knowledge base change", "dropped_entries", dropped)
+}
+
+// QueryAIWithSummary answers query from the cache when possible and from aiService otherwise,
+// caching successful answers. hit reports whether the answer came from the cache.
+func (c *ResponseCache) QueryAIWithSummary(ctx context.Context, aiService AIService, query string) (response string, summary string, hit bool, err error) {
+	response, summary, hit, store := c.Lookup(query)
+	if hit {
+		return response, summary, true, nil
+	}
+
+	response, summary, err = aiService.QueryAIWithSummary(ctx, query)
+	if err != nil {
+		return response, summary, false, err
+	}
+
+	store(response, summary)
+	return response, summary, false, nil
+}
+
+// Lookup returns the cached answer to query, counting the lookup as a hit or miss. On a miss, store caches
+// the answer once it has been generated elsewhere, e.g. streamed; it ignores blank answers and answers
+// generated against a knowledge base that changed since the lookup.
+func (c *ResponseCache) Lookup(query string) (response string, summary string, hit bool, store func(response, summary string)) {
+	normalized := NormalizeQuery(query)
+
+	c.mu.Lock()
+	enabled := c.enabled && normalized != ""
+	kbHash := c.kbHash
+	var cached *cachedResponse
+	if enabled {
+		cached = c.lookupLocked(normalized)
+		c.recordLookupLocked(cached != nil)
+	}
+	c.mu.Unlock()
+
+	if cached != nil {
+		c.logger.Debug("Response cache hit", "query", normalized, "cached_query", cached.query)
+		return cached.response, cached.summary, true, func(string, string) {}
+	}
+
+	store = func(response, summary string) {
+		if !enabled || strings.TrimSpace(response) == "" {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// An answer generated against a knowledge base that changed mid-query must not be cached
+		if c.enabled && c.kbHash == kbHash {
+			c.storeLocked(normalized, response, summary)
+		}
+	}
+	return "", "", false, store
+}
+
+// Purge drops every cached answer and returns how many were removed
+func (c *ResponseCache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := len(c.entries)
+	c.entries = make(map[string]*cachedResponse)
+	return purged
+}
+
+// Stats returns the cache's current size, settings and hit/miss counters
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ResponseCacheStats{
+		Enabled:             c.enabled,
+		Entries:             len(c.entries),
+		Hits:                c.hits,
+		Misses:              c.misses,
+		TTL:                 c.ttl,
+		SimilarityThreshold: c.similarityThreshold,
+		KnowledgeBaseHash:   c.kbHash,
+	}
+}
+
+// lookupLocked returns the exact or closest near-duplicate answer, dropping expired ones; callers must hold the lock
+func (c *ResponseCache) lookupLocked(normalized string) *cachedResponse {
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.Sub(entry.storedAt) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	if entry, exists := c.entries[c.key(normalized)]; exists {
+		return entry
+	}
+
+	if c.similarityThreshold <= 0 {
+		return nil
+	}
+
+	terms := significantTerms(normalized)
+	var best *cachedResponse
+	bestScore := 0
+	for _, entry := range c.entries {
+		if score := termOverlapPercent(terms, entry.terms); score >= c.similarityThreshold && score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	return best
+}
+
+// storeLocked caches an answer, evicting the oldest one when full; callers must hold the lock
+func (c *ResponseCache) storeLocked(normalized, response, summary string) {
+	key := c.key(normalized)
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictOldestLocked()
+	}
+
+	c.entries[key] = &cachedResponse{
+		query:    normalized,
+		response: response,
+		summary:  summary,
+		storedAt: c.now(),
+		terms:    significantTerms(normalized),
+	}
+}
+
+// evictOldestLocked removes the least recently stored answer; callers must hold the lock
+func (c *ResponseCache) evictOldestLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// recordLookupLocked counts a hit or miss; callers must hold the lock
+func (c *ResponseCache) recordLookupLocked(hit bool) {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.metrics.RecordResponseCacheLookup(hit)
+}
+
+// key combines the knowledge base version with the normalized query
+func (c *ResponseCache) key(normalized string) string {
+	return c.kbHash + "\x00" + normalized
+}
+
+// NormalizeQuery lowercases a question, drops punctuation and collapses whitespace so trivially
+// different phrasings of the same question share a cache key
+func NormalizeQuery(query string) string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// significantTerms returns the words of a normalized query that carry meaning, ignoring stop words
+func significantTerms(normalized string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range strings.Fields(normalized) {
+		if !similarityStopWords[word] {
+			terms[word] = true
+		}
+	}
+	return terms
+}
+
+// termOverlapPercent returns the Jaccard similarity of two term sets as a percentage
+func termOverlapPercent(a, b map[string]bool) int {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	return shared * 100 / union
+}
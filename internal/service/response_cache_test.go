@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+// hookedProvider runs onQuery while answering, to change cache state mid-query
+type hookedProvider struct {
+	*fakeProvider
+	onQuery func()
+}
+
+func (p *hookedProvider) QueryAIWithSummary(ctx context.Context, query string) (string, string, error) {
+	p.onQuery()
+	return p.fakeProvider.QueryAIWithSummary(ctx, query)
+}
+
+func newTestResponseCache() (*ResponseCache, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewResponseCache(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	testCases := map[string]string{
+		"What is BMAD?":                   "what is bmad",
+		"  what   IS bmad!!  ":            "what is bmad",
+		"How do I use the PM agent's PRD": "how do i use the pm agent s prd",
+		"???":                             "",
+	}
+	for query, want := range testCases {
+		if got := NormalizeQuery(query); got != want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestResponseCache_HitsAndMisses(t *testing.T) {
+	cache, _ := newTestResponseCache()
+	provider := &fakeProvider{id: "ollama"}
+
+	response, summary, hit, err := cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?")
+	if err != nil || hit {
+		t.Fatalf("expected a miss answered by the provider, got hit=%v err=%v", hit, err)
+	}
+	if response != "What is BMAD? from ollama" || summary != "summary" {
+		t.Errorf("unexpected answer %q / %q", response, summary)
+	}
+
+	// Questions that normalize identically share the cached answer
+	cachedResponse, cachedSummary, hit, err := cache.QueryAIWithSummary(context.Background(), provider, "what is bmad")
+	if err != nil || !hit {
+		t.Fatalf("expected a cache hit, got hit=%v err=%v", hit, err)
+	}
+	if cachedResponse != response || cachedSummary != summary {
+		t.Errorf("expected the cached answer, got %q / %q", cachedResponse, cachedSummary)
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", provider.calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestResponseCache_LookupAndStore(t *testing.T) {
+	cache, _ := newTestResponseCache()
+
+	_, _, hit, store := cache.Lookup("What is BMAD?")
+	if hit {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	// Blank answers are not stored
+	store("  ", "summary")
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Fatalf("expected blank answers not to be cached, got %d entries", entries)
+	}
+
+	store("A streamed answer", "Streamed summary")
+	response, summary, hit, _ := cache.Lookup("what is bmad")
+	if !hit || response != "A streamed answer" || summary != "Streamed summary" {
+		t.Errorf("expected the stored answer, got hit=%v %q / %q", hit, response, summary)
+	}
+
+	// An answer stored after the knowledge base changed is dropped
+	_, _, _, store = cache.Lookup("What is the PM agent?")
+	cache.SetKnowledgeBaseHash(KnowledgeBaseHash("v2"))
+	store("Outdated answer", "summary")
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Errorf("expected no entries after a knowledge base change, got %d", entries)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestResponseCache_DoesNotCacheFailures(t *testing.T) {
+	cache, _ := newTestResponseCache()
+	provider := &fakeProvider{id: "ollama", err: errors.New("provider down")}
+
+	if _, _, _, err := cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?"); err == nil {
+		t.Fatal("expected the provider error")
+	}
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Errorf("expected failed answers not to be cached, got %d entries", entries)
+	}
+}
+
+func TestResponseCache_ExpiresAfterTTL(t *testing.T) {
+	cache, now := newTestResponseCache()
+	cache.SetTTL(time.Hour)
+	provider := &fakeProvider{id: "ollama"}
+
+	cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?")
+	*now = now.Add(59 * time.Minute)
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?"); !hit {
+		t.Error("expected a hit within the TTL")
+	}
+
+	*now = now.Add(time.Minute)
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?"); hit {
+		t.Error("expected the answer to expire after the TTL")
+	}
+}
+
+func TestResponseCache_SimilarQuestions(t *testing.T) {
+	cache, _ := newTestResponseCache()
+	provider := &fakeProvider{id: "ollama"}
+	cache.QueryAIWithSummary(context.Background(), provider, "What is the role of the PM agent?")
+
+	// Exact matching only by default
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "Explain the PM agent role"); hit {
+		t.Error("expected no near-duplicate hit without a similarity threshold")
+	}
+
+	cache.SetSimilarityThreshold(80)
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "Can you explain the PM agent's role?"); !hit {
+		t.Error("expected a near-duplicate hit above the threshold")
+	}
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "What is the role of the QA agent?"); hit {
+		t.Error("expected a different question not to match")
+	}
+}
+
+func TestResponseCache_KnowledgeBaseChange(t *testing.T) {
+	cache, _ := newTestResponseCache()
+	provider := &fakeProvider{id: "ollama"}
+	cache.SetKnowledgeBaseHash(KnowledgeBaseHash("v1"))
+	cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?")
+
+	// Setting the same version keeps the cache
+	cache.SetKnowledgeBaseHash(KnowledgeBaseHash("v1"))
+	if entries := cache.Stats().Entries; entries != 1 {
+		t.Fatalf("expected 1 entry, got %d", entries)
+	}
+
+	cache.SetKnowledgeBaseHash(KnowledgeBaseHash("v2"))
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "What is BMAD?"); hit {
+		t.Error("expected answers for an older knowledge base to be dropped")
+	}
+
+	// An answer generated while the knowledge base changes is not cached
+	cache.Purge()
+	hooked := &hookedProvider{fakeProvider: provider, onQuery: func() { cache.SetKnowledgeBaseHash(KnowledgeBaseHash("v3")) }}
+	cache.QueryAIWithSummary(context.Background(), hooked, "What is BMAD?")
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Errorf("expected no entries after a mid-query knowledge base change, got %d", entries)
+	}
+}
+
+func TestResponseCache_EvictionPurgeAndDisable(t *testing.T) {
+	cache, now := newTestResponseCache()
+	cache.SetMaxEntries(2)
+	provider := &fakeProvider{id: "ollama"}
+
+	for _, query := range []string{"first question", "second question", "third question"} {
+		cache.QueryAIWithSummary(context.Background(), provider, query)
+		*now = now.Add(time.Second)
+	}
+	if entries := cache.Stats().Entries; entries != 2 {
+		t.Fatalf("expected 2 entries, got %d", entries)
+	}
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "first question"); hit {
+		t.Error("expected the oldest answer to be evicted")
+	}
+
+	if purged := cache.Purge(); purged != 2 {
+		t.Errorf("expected 2 purged entries, got %d", purged)
+	}
+
+	cache.SetEnabled(false)
+	cache.QueryAIWithSummary(context.Background(), provider, "first question")
+	if _, _, hit, _ := cache.QueryAIWithSummary(context.Background(), provider, "first question"); hit {
+		t.Error("expected no hits while disabled")
+	}
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Enabled {
+		t.Errorf("unexpected stats while disabled %+v", stats)
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+// charsPerToken approximates how many bytes of English text make up one model token
+const charsPerToken = 4
+
+// EstimateTokens approximates the number of model tokens in text without a tokenizer.
+// It errs on the high side for English prose so budgets built on it leave some headroom.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// estimatePromptTokens estimates the tokens of a prompt made of instructions and the knowledge included
+// for a query: the largest retrieved context when retrieval is available, otherwise the full knowledge base
+func estimatePromptTokens(instructions string, retriever *KnowledgeRetriever, knowledgeBase string) int {
+	knowledgeSize := len(knowledgeBase)
+	if retriever != nil && retriever.SectionCount() > 0 {
+		knowledgeSize = retriever.MaxContextSize()
+	}
+	return EstimateTokens(instructions) + (knowledgeSize+charsPerToken-1)/charsPerToken
+}
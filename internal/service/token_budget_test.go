@@ -0,0 +1,21 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	testCases := map[string]int{
+		"":                        0,
+		"abc":                     1,
+		"abcd":                    1,
+		"What is BMAD?":           4,
+		strings.Repeat("x", 4000): 1000,
+	}
+	for text, want := range testCases {
+		if got := EstimateTokens(text); got != want {
+			t.Errorf("EstimateTokens(%d bytes) = %d, want %d", len(text), got, want)
+		}
+	}
+}
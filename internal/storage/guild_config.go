@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// ResolveConfiguration retrieves guildID's override for key, falling back to the global value.
+// It returns nil if neither exists; an empty guildID (e.g. a DM) only reads the global value.
+func ResolveConfiguration(ctx context.Context, s StorageService, guildID, key string) (*Configuration, error) {
+	if guildID != "" {
+		config, err := s.GetGuildConfiguration(ctx, guildID, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get guild configuration: %w", err)
+		}
+		if config != nil {
+			return config, nil
+		}
+	}
+
+	config, err := s.GetConfiguration(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration: %w", err)
+	}
+	return config, nil
+}
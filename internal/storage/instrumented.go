@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// OperationObserver is called after every storage operation with the operation name and its error, if any
+type OperationObserver func(operation string, err error)
+
+// InstrumentedStorageService wraps a StorageService and reports the outcome of each operation to an observer
+type InstrumentedStorageService struct {
+	StorageService
+	observe OperationObserver
+}
+
+// NewInstrumentedStorageService wraps inner so every operation is reported to observe
+func NewInstrumentedStorageService(inner StorageService, observe OperationObserver) *InstrumentedStorageService {
+	return &InstrumentedStorageService{StorageService: inner, observe: observe}
+}
+
+// record reports an operation outcome and returns its error unchanged
+func (s *InstrumentedStorageService) record(operation string, err error) error {
+	s.observe(operation, err)
+	return err
+}
+
+// Initialize delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) Initialize(ctx context.Context) error {
+	return s.record("initialize", s.StorageService.Initialize(ctx))
+}
+
+// Close delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) Close() error {
+	return s.record("close", s.StorageService.Close())
+}
+
+// GetMessageState delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetMessageState(ctx context.Context, channelID string, threadID *string) (*MessageState, error) {
+	result, err := s.StorageService.GetMessageState(ctx, channelID, threadID)
+	return result, s.record("get_message_state", err)
+}
+
+// UpsertMessageState delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertMessageState(ctx context.Context, state *MessageState) error {
+	return s.record("upsert_message_state", s.StorageService.UpsertMessageState(ctx, state))
+}
+
+// GetAllMessageStates delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetAllMessageStates(ctx context.Context) ([]*MessageState, error) {
+	result, err := s.StorageService.GetAllMessageStates(ctx)
+	return result, s.record("get_all_message_states", err)
+}
+
+// GetMessageStatesWithinWindow delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*MessageState, error) {
+	result, err := s.StorageService.GetMessageStatesWithinWindow(ctx, windowDuration)
+	return result, s.record("get_message_states_within_window", err)
+}
+
+// HealthCheck delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) HealthCheck(ctx context.Context) error {
+	return s.record("health_check", s.StorageService.HealthCheck(ctx))
+}
+
+// GetThreadOwnership delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetThreadOwnership(ctx context.Context, threadID string) (*ThreadOwnership, error) {
+	result, err := s.StorageService.GetThreadOwnership(ctx, threadID)
+	return result, s.record("get_thread_ownership", err)
+}
+
+// UpsertThreadOwnership delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertThreadOwnership(ctx context.Context, ownership *ThreadOwnership) error {
+	return s.record("upsert_thread_ownership", s.StorageService.UpsertThreadOwnership(ctx, ownership))
+}
+
+// GetAllThreadOwnerships delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetAllThreadOwnerships(ctx context.Context) ([]*ThreadOwnership, error) {
+	result, err := s.StorageService.GetAllThreadOwnerships(ctx)
+	return result, s.record("get_all_thread_ownerships", err)
+}
+
+// CleanupOldThreadOwnerships delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
+	return s.record("cleanup_old_thread_ownerships", s.StorageService.CleanupOldThreadOwnerships(ctx, maxAge))
+}
+
+// GetConfiguration delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetConfiguration(ctx context.Context, key string) (*Configuration, error) {
+	result, err := s.StorageService.GetConfiguration(ctx, key)
+	return result, s.record("get_configuration", err)
+}
+
+// UpsertConfiguration delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertConfiguration(ctx context.Context, config *Configuration) error {
+	return s.record("upsert_configuration", s.StorageService.UpsertConfiguration(ctx, config))
+}
+
+// GetConfigurationsByCategory delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetConfigurationsByCategory(ctx context.Context, category string) ([]*Configuration, error) {
+	result, err := s.StorageService.GetConfigurationsByCategory(ctx, category)
+	return result, s.record("get_configurations_by_category", err)
+}
+
+// GetAllConfigurations delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetAllConfigurations(ctx context.Context) ([]*Configuration, error) {
+	result, err := s.StorageService.GetAllConfigurations(ctx)
+	return result, s.record("get_all_configurations", err)
+}
+
+// DeleteConfiguration delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteConfiguration(ctx context.Context, key string) error {
+	return s.record("delete_configuration", s.StorageService.DeleteConfiguration(ctx, key))
+}
+
+// GetGuildConfiguration delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetGuildConfiguration(ctx context.Context, guildID, key string) (*Configuration, error) {
+	result, err := s.StorageService.GetGuildConfiguration(ctx, guildID, key)
+	return result, s.record("get_guild_configuration", err)
+}
+
+// GetGuildConfigurations delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetGuildConfigurations(ctx context.Context, guildID string) ([]*Configuration, error) {
+	result, err := s.StorageService.GetGuildConfigurations(ctx, guildID)
+	return result, s.record("get_guild_configurations", err)
+}
+
+// DeleteGuildConfiguration delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteGuildConfiguration(ctx context.Context, guildID, key string) error {
+	return s.record("delete_guild_configuration", s.StorageService.DeleteGuildConfiguration(ctx, guildID, key))
+}
+
+// UpsertConfigurationWithHistory delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertConfigurationWithHistory(ctx context.Context, config *Configuration, change *ConfigurationHistory) error {
+	return s.record("upsert_configuration_with_history", s.StorageService.UpsertConfigurationWithHistory(ctx, config, change))
+}
+
+// DeleteConfigurationWithHistory delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteConfigurationWithHistory(ctx context.Context, guildID, key string, change *ConfigurationHistory) error {
+	return s.record("delete_configuration_with_history", s.StorageService.DeleteConfigurationWithHistory(ctx, guildID, key, change))
+}
+
+// RecordConfigurationChange delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) RecordConfigurationChange(ctx context.Context, change *ConfigurationHistory) error {
+	return s.record("record_configuration_change", s.StorageService.RecordConfigurationChange(ctx, change))
+}
+
+// GetConfigurationHistory delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetConfigurationHistory(ctx context.Context, guildID, key string, limit int) ([]*ConfigurationHistory, error) {
+	result, err := s.StorageService.GetConfigurationHistory(ctx, guildID, key, limit)
+	return result, s.record("get_configuration_history", err)
+}
+
+// GetConfigurationChange delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetConfigurationChange(ctx context.Context, id int64) (*ConfigurationHistory, error) {
+	result, err := s.StorageService.GetConfigurationChange(ctx, id)
+	return result, s.record("get_configuration_change", err)
+}
+
+// GetStatusMessagesBatch delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*StatusMessage, error) {
+	result, err := s.StorageService.GetStatusMessagesBatch(ctx, limit)
+	return result, s.record("get_status_messages_batch", err)
+}
+
+// AddStatusMessage delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
+	return s.record("add_status_message", s.StorageService.AddStatusMessage(ctx, activityType, statusText, enabled))
+}
+
+// UpdateStatusMessage delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
+	return s.record("update_status_message", s.StorageService.UpdateStatusMessage(ctx, id, enabled))
+}
+
+// GetAllStatusMessages delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetAllStatusMessages(ctx context.Context) ([]*StatusMessage, error) {
+	result, err := s.StorageService.GetAllStatusMessages(ctx)
+	return result, s.record("get_all_status_messages", err)
+}
+
+// GetEnabledStatusMessagesCount delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
+	result, err := s.StorageService.GetEnabledStatusMessagesCount(ctx)
+	return result, s.record("get_enabled_status_messages_count", err)
+}
+
+// GetUserRateLimit delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*UserRateLimit, error) {
+	result, err := s.StorageService.GetUserRateLimit(ctx, userID, timeWindow)
+	return result, s.record("get_user_rate_limit", err)
+}
+
+// UpsertUserRateLimit delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertUserRateLimit(ctx context.Context, rateLimit *UserRateLimit) error {
+	return s.record("upsert_user_rate_limit", s.StorageService.UpsertUserRateLimit(ctx, rateLimit))
+}
+
+// CleanupExpiredUserRateLimits delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
+	return s.record("cleanup_expired_user_rate_limits", s.StorageService.CleanupExpiredUserRateLimits(ctx, expiredBefore))
+}
+
+// GetUserRateLimitsByUser delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*UserRateLimit, error) {
+	result, err := s.StorageService.GetUserRateLimitsByUser(ctx, userID)
+	return result, s.record("get_user_rate_limits_by_user", err)
+}
+
+// ResetUserRateLimit delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
+	return s.record("reset_user_rate_limit", s.StorageService.ResetUserRateLimit(ctx, userID, timeWindow))
+}
+
+// RecordInteraction delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) RecordInteraction(ctx context.Context, interaction *Interaction) error {
+	return s.record("record_interaction", s.StorageService.RecordInteraction(ctx, interaction))
+}
+
+// GetInteraction delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetInteraction(ctx context.Context, id int64) (*Interaction, error) {
+	result, err := s.StorageService.GetInteraction(ctx, id)
+	return result, s.record("get_interaction", err)
+}
+
+// GetRecentInteractions delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetRecentInteractions(ctx context.Context, limit int) ([]*Interaction, error) {
+	result, err := s.StorageService.GetRecentInteractions(ctx, limit)
+	return result, s.record("get_recent_interactions", err)
+}
+
+// PurgeInteractionsBefore delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) PurgeInteractionsBefore(ctx context.Context, before int64) (int64, error) {
+	result, err := s.StorageService.PurgeInteractionsBefore(ctx, before)
+	return result, s.record("purge_interactions_before", err)
+}
+
+// GetInteractionByResponseMessageID delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetInteractionByResponseMessageID(ctx context.Context, messageID string) (*Interaction, error) {
+	result, err := s.StorageService.GetInteractionByResponseMessageID(ctx, messageID)
+	return result, s.record("get_interaction_by_response_message_id", err)
+}
+
+// UpsertInteractionFeedback delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertInteractionFeedback(ctx context.Context, feedback *InteractionFeedback) error {
+	return s.record("upsert_interaction_feedback", s.StorageService.UpsertInteractionFeedback(ctx, feedback))
+}
+
+// DeleteInteractionFeedback delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteInteractionFeedback(ctx context.Context, interactionID int64, userID string, vote int) error {
+	return s.record("delete_interaction_feedback", s.StorageService.DeleteInteractionFeedback(ctx, interactionID, userID, vote))
+}
+
+// GetMostDownvotedInteractions delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetMostDownvotedInteractions(ctx context.Context, limit int) ([]*InteractionFeedbackSummary, error) {
+	result, err := s.StorageService.GetMostDownvotedInteractions(ctx, limit)
+	return result, s.record("get_most_downvoted_interactions", err)
+}
+
+// GetInteractionsByUser delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetInteractionsByUser(ctx context.Context, userID string) ([]*Interaction, error) {
+	result, err := s.StorageService.GetInteractionsByUser(ctx, userID)
+	return result, s.record("get_interactions_by_user", err)
+}
+
+// DeleteInteractionsByUser delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteInteractionsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := s.StorageService.DeleteInteractionsByUser(ctx, userID)
+	return result, s.record("delete_interactions_by_user", err)
+}
+
+// AppendDMMessage delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) AppendDMMessage(ctx context.Context, message *DMMessage) error {
+	return s.record("append_dm_message", s.StorageService.AppendDMMessage(ctx, message))
+}
+
+// GetDMMessages delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetDMMessages(ctx context.Context, userID string) ([]*DMMessage, error) {
+	result, err := s.StorageService.GetDMMessages(ctx, userID)
+	return result, s.record("get_dm_messages", err)
+}
+
+// GetDMSummary delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetDMSummary(ctx context.Context, userID string) (*DMSummary, error) {
+	result, err := s.StorageService.GetDMSummary(ctx, userID)
+	return result, s.record("get_dm_summary", err)
+}
+
+// CompactDMConversation delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) CompactDMConversation(ctx context.Context, summary *DMSummary, throughID int64) error {
+	return s.record("compact_dm_conversation", s.StorageService.CompactDMConversation(ctx, summary, throughID))
+}
+
+// DeleteDMConversation delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) DeleteDMConversation(ctx context.Context, userID string) error {
+	return s.record("delete_dm_conversation", s.StorageService.DeleteDMConversation(ctx, userID))
+}
+
+// GetThreadSummary delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) GetThreadSummary(ctx context.Context, threadID string) (*ThreadSummary, error) {
+	result, err := s.StorageService.GetThreadSummary(ctx, threadID)
+	return result, s.record("get_thread_summary", err)
+}
+
+// UpsertThreadSummary delegates to the wrapped service and records the outcome
+func (s *InstrumentedStorageService) UpsertThreadSummary(ctx context.Context, summary *ThreadSummary) error {
+	return s.record("upsert_thread_summary", s.StorageService.UpsertThreadSummary(ctx, summary))
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingConfigStorage fails configuration reads; other methods are unused by the test
+type failingConfigStorage struct {
+	StorageService
+}
+
+func (s *failingConfigStorage) GetConfiguration(ctx context.Context, key string) (*Configuration, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (s *failingConfigStorage) DeleteConfiguration(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestInstrumentedStorageService(t *testing.T) {
+	observed := make(map[string]error)
+	service := NewInstrumentedStorageService(&failingConfigStorage{}, func(operation string, err error) {
+		observed[operation] = err
+	})
+
+	_, err := service.GetConfiguration(context.Background(), "MAX_TOKENS")
+	require.Error(t, err)
+	assert.Equal(t, err, observed["get_configuration"])
+
+	require.NoError(t, service.DeleteConfiguration(context.Background(), "MAX_TOKENS"))
+	assert.Contains(t, observed, "delete_configuration")
+	assert.NoError(t, observed["delete_configuration"])
+}
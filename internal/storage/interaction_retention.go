@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultInteractionPurgeInterval is how often expired interactions are purged
+const DefaultInteractionPurgeInterval = 6 * time.Hour
+
+// InteractionPurger deletes interactions older than the configured retention period
+type InteractionPurger struct {
+	storage   StorageService
+	logger    *slog.Logger
+	retention func() time.Duration // Read on every purge so configuration reloads apply; <= 0 keeps everything
+	interval  time.Duration
+}
+
+// NewInteractionPurger creates a purger that enforces the retention period returned by retention
+func NewInteractionPurger(storage StorageService, retention func() time.Duration, logger *slog.Logger) *InteractionPurger {
+	return &InteractionPurger{
+		storage:   storage,
+		logger:    logger,
+		retention: retention,
+		interval:  DefaultInteractionPurgeInterval,
+	}
+}
+
+// Purge deletes interactions older than the retention period and returns how many were removed
+func (p *InteractionPurger) Purge(ctx context.Context) (int64, error) {
+	retention := p.retention()
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-retention).Unix()
+	purged, err := p.storage.PurgeInteractionsBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired interactions: %w", err)
+	}
+
+	if purged > 0 {
+		p.logger.Info("Purged expired interactions", "count", purged, "retention", retention)
+	}
+	return purged, nil
+}
+
+// Start purges immediately and then on every interval until ctx is cancelled
+func (p *InteractionPurger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := p.Purge(ctx); err != nil {
+				p.logger.Error("Interaction retention purge failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
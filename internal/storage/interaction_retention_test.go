@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// purgeRecordingStorage records the cutoff passed to PurgeInteractionsBefore
+type purgeRecordingStorage struct {
+	StorageService
+	cutoffs []int64
+}
+
+func (s *purgeRecordingStorage) PurgeInteractionsBefore(ctx context.Context, before int64) (int64, error) {
+	s.cutoffs = append(s.cutoffs, before)
+	return 3, nil
+}
+
+func TestInteractionPurger_Purge(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := &purgeRecordingStorage{}
+	retention := 30 * 24 * time.Hour
+	purger := NewInteractionPurger(store, func() time.Duration { return retention }, logger)
+
+	purged, err := purger.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	require.Len(t, store.cutoffs, 1)
+	assert.InDelta(t, time.Now().Add(-retention).Unix(), store.cutoffs[0], 2)
+
+	// A retention of zero keeps every interaction
+	retention = 0
+	purged, err = purger.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Len(t, store.cutoffs, 1)
+}
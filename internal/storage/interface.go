@@ -30,7 +30,8 @@ type ThreadOwnership struct {
 // Configuration represents a configuration key-value pair stored in the database
 type Configuration struct {
 	ID          int64  `db:"id"`           // Primary key, auto-increment
-	Key         string `db:"config_key"`   // Configuration key (unique)
+	GuildID     string `db:"guild_id"`     // Discord guild the value applies to (empty for the global value)
+	Key         string `db:"config_key"`   // Configuration key (unique per guild)
 	Value       string `db:"config_value"` // Configuration value
 	Type        string `db:"value_type"`   // Value type: string, int, bool, duration
 	Category    string `db:"category"`     // Configuration category for organization
@@ -39,6 +40,19 @@ type Configuration struct {
 	UpdatedAt   int64  `db:"updated_at"`   // Record last update timestamp
 }
 
+// ConfigurationHistory records one change to a configuration entry
+type ConfigurationHistory struct {
+	ID        int64   `db:"id"`         // Primary key, auto-increment
+	GuildID   string  `db:"guild_id"`   // Guild whose override changed (empty for the global value)
+	Key       string  `db:"config_key"` // Configuration key that changed
+	OldValue  *string `db:"old_value"`  // Value before the change (nil when the key was created)
+	NewValue  *string `db:"new_value"`  // Value after the change (nil when the key was deleted)
+	Type      string  `db:"value_type"` // Value type of the entry at the time of the change
+	Category  string  `db:"category"`   // Category of the entry at the time of the change
+	Actor     string  `db:"actor"`      // Discord user ID, or who made the change on the bot's behalf (e.g. "seed")
+	ChangedAt int64   `db:"changed_at"` // Unix timestamp of the change
+}
+
 // StatusMessage represents a Discord bot status message stored in the database
 type StatusMessage struct {
 	ID           int64  `db:"id"`            // Primary key, auto-increment
@@ -61,6 +75,84 @@ type UserRateLimit struct {
 	UpdatedAt       int64  `db:"updated_at"`        // Record last update timestamp
 }
 
+// Interaction represents a single Q&A exchange between a user and the bot, kept for auditing answers
+type Interaction struct {
+	ID                       int64    `db:"id"`                         // Primary key, auto-increment
+	GuildID                  string   `db:"guild_id"`                   // Discord guild ID (empty for DMs)
+	ChannelID                string   `db:"channel_id"`                 // Discord channel ID (parent Forum channel for Forum posts)
+	ThreadID                 *string  `db:"thread_id"`                  // Discord thread ID the answer was posted in (nullable)
+	UserID                   string   `db:"user_id"`                    // Discord user ID of the user who asked
+	MessageID                string   `db:"message_id"`                 // ID of the message holding the question
+	TriggerType              string   `db:"trigger_type"`               // How the query reached the bot: mention, reply_mention, reaction, dm, forum, auto_thread
+	Query                    string   `db:"query"`                      // Question sent to the AI provider
+	Response                 string   `db:"response"`                   // Answer returned by the AI provider (empty on error)
+	Provider                 string   `db:"provider"`                   // AI provider that served the answer
+	Model                    string   `db:"model"`                      // Model that generated the answer
+	LatencyMs                int64    `db:"latency_ms"`                 // Time spent waiting for the AI provider
+	QualityOverall           *float64 `db:"quality_overall"`            // Overall quality score (nullable when not scored)
+	QualityBMADCoverage      *float64 `db:"quality_bmad_coverage"`      // BMAD coverage score (nullable)
+	QualityKnowledgeBoundary *float64 `db:"quality_knowledge_boundary"` // Knowledge boundary score (nullable)
+	QualityContent           *float64 `db:"quality_content"`            // Content quality score (nullable)
+	Error                    string   `db:"error_message"`              // AI error message (empty on success)
+	ResponseMessageID        *string  `db:"response_message_id"`        // Final Discord message of the answer, which carries the feedback reactions (nullable)
+	CreatedAt                int64    `db:"created_at"`                 // Record creation timestamp
+}
+
+// Feedback votes recorded against an interaction
+const (
+	FeedbackUpvote   = 1
+	FeedbackDownvote = -1
+)
+
+// InteractionFeedback represents one user's vote on an answered interaction
+type InteractionFeedback struct {
+	ID            int64  `db:"id"`             // Primary key, auto-increment
+	InteractionID int64  `db:"interaction_id"` // Interaction the vote applies to
+	UserID        string `db:"user_id"`        // Discord user ID of the voter
+	Vote          int    `db:"vote"`           // FeedbackUpvote or FeedbackDownvote
+	CreatedAt     int64  `db:"created_at"`     // Record creation timestamp
+	UpdatedAt     int64  `db:"updated_at"`     // Record last update timestamp
+}
+
+// InteractionFeedbackSummary is an interaction with its vote totals
+type InteractionFeedbackSummary struct {
+	Interaction *Interaction
+	Upvotes     int
+	Downvotes   int
+}
+
+// Roles recorded for DM conversation messages
+const (
+	DMRoleUser      = "user"
+	DMRoleAssistant = "assistant"
+)
+
+// DMMessage represents one turn of a user's stored DM conversation with the bot
+type DMMessage struct {
+	ID        int64  `db:"id"`         // Primary key, auto-increment
+	UserID    string `db:"user_id"`    // Discord user ID the conversation belongs to
+	Role      string `db:"role"`       // DMRoleUser or DMRoleAssistant
+	Content   string `db:"content"`    // Message text
+	CreatedAt int64  `db:"created_at"` // Record creation timestamp
+}
+
+// DMSummary is the rolling summary of DM messages that have been compacted out of a user's conversation
+type DMSummary struct {
+	UserID             string `db:"user_id"`             // Discord user ID the summary belongs to
+	Summary            string `db:"summary"`             // Summary text covering all compacted messages
+	SummarizedMessages int    `db:"summarized_messages"` // Total number of messages folded into the summary
+	UpdatedAt          int64  `db:"updated_at"`          // Record last update timestamp
+}
+
+// ThreadSummary is the rolling summary of the older messages of a thread that no longer fit the prompt
+type ThreadSummary struct {
+	ThreadID            string `db:"thread_id"`             // Discord thread ID the summary belongs to
+	Summary             string `db:"summary"`               // Summary text covering all summarized messages
+	SummarizedThroughID string `db:"summarized_through_id"` // ID of the newest Discord message folded into the summary
+	SummarizedMessages  int    `db:"summarized_messages"`   // Total number of messages folded into the summary
+	UpdatedAt           int64  `db:"updated_at"`            // Record last update timestamp
+}
+
 // StorageService defines the interface for message state persistence operations
 type StorageService interface {
 	// Initialize sets up the database connection and creates necessary tables
@@ -96,21 +188,48 @@ type StorageService interface {
 	// CleanupOldThreadOwnerships removes old thread ownership records
 	CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error
 
-	// GetConfiguration retrieves a configuration value by key
+	// GetConfiguration retrieves a global configuration value by key
 	GetConfiguration(ctx context.Context, key string) (*Configuration, error)
 
-	// UpsertConfiguration creates or updates a configuration entry
+	// UpsertConfiguration creates or updates a configuration entry, scoped to its GuildID when set
 	UpsertConfiguration(ctx context.Context, config *Configuration) error
 
-	// GetConfigurationsByCategory retrieves all configurations in a category
+	// GetConfigurationsByCategory retrieves all global configurations in a category
 	GetConfigurationsByCategory(ctx context.Context, category string) ([]*Configuration, error)
 
-	// GetAllConfigurations retrieves all configurations
+	// GetAllConfigurations retrieves all global configurations
 	GetAllConfigurations(ctx context.Context) ([]*Configuration, error)
 
-	// DeleteConfiguration removes a configuration entry by key
+	// DeleteConfiguration removes a global configuration entry by key
 	DeleteConfiguration(ctx context.Context, key string) error
 
+	// GetGuildConfiguration retrieves a guild's override for a configuration key, returning nil if the guild has none
+	GetGuildConfiguration(ctx context.Context, guildID, key string) (*Configuration, error)
+
+	// GetGuildConfigurations retrieves all configuration overrides for a guild
+	GetGuildConfigurations(ctx context.Context, guildID string) ([]*Configuration, error)
+
+	// DeleteGuildConfiguration removes a guild's override for a configuration key
+	DeleteGuildConfiguration(ctx context.Context, guildID, key string) error
+
+	// RecordConfigurationChange appends an entry to the configuration history, setting its ID and timestamp
+	RecordConfigurationChange(ctx context.Context, change *ConfigurationHistory) error
+
+	// UpsertConfigurationWithHistory creates or updates a configuration entry and records the change atomically,
+	// taking the change's OldValue from the stored entry
+	UpsertConfigurationWithHistory(ctx context.Context, config *Configuration, change *ConfigurationHistory) error
+
+	// DeleteConfigurationWithHistory removes a configuration entry and records the change atomically,
+	// taking the change's OldValue, Type and Category from the removed entry
+	DeleteConfigurationWithHistory(ctx context.Context, guildID, key string, change *ConfigurationHistory) error
+
+	// GetConfigurationHistory retrieves the most recent changes to a configuration key's global value and
+	// guildID's override, newest first; an empty guildID returns global changes only
+	GetConfigurationHistory(ctx context.Context, guildID, key string, limit int) ([]*ConfigurationHistory, error)
+
+	// GetConfigurationChange retrieves a configuration history entry by ID, returning nil if it does not exist
+	GetConfigurationChange(ctx context.Context, id int64) (*ConfigurationHistory, error)
+
 	// GetStatusMessagesBatch retrieves a random batch of enabled status messages
 	GetStatusMessagesBatch(ctx context.Context, limit int) ([]*StatusMessage, error)
 
@@ -140,4 +259,56 @@ type StorageService interface {
 
 	// ResetUserRateLimit resets rate limiting for a specific user and time window
 	ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error
+
+	// RecordInteraction stores a Q&A exchange, setting its ID and creation timestamp
+	RecordInteraction(ctx context.Context, interaction *Interaction) error
+
+	// GetInteraction retrieves an interaction by ID, returning nil if it does not exist
+	GetInteraction(ctx context.Context, id int64) (*Interaction, error)
+
+	// GetRecentInteractions retrieves the most recent interactions, newest first
+	GetRecentInteractions(ctx context.Context, limit int) ([]*Interaction, error)
+
+	// PurgeInteractionsBefore deletes interactions created before the given Unix timestamp and returns how many were removed
+	PurgeInteractionsBefore(ctx context.Context, before int64) (int64, error)
+
+	// GetInteractionByResponseMessageID retrieves the interaction answered by the given Discord message, returning nil if none
+	GetInteractionByResponseMessageID(ctx context.Context, messageID string) (*Interaction, error)
+
+	// UpsertInteractionFeedback records a user's vote on an interaction, replacing any earlier vote by the same user
+	UpsertInteractionFeedback(ctx context.Context, feedback *InteractionFeedback) error
+
+	// DeleteInteractionFeedback removes a user's vote on an interaction if it still matches the given vote
+	DeleteInteractionFeedback(ctx context.Context, interactionID int64, userID string, vote int) error
+
+	// GetMostDownvotedInteractions retrieves interactions with at least one downvote, most downvoted first
+	GetMostDownvotedInteractions(ctx context.Context, limit int) ([]*InteractionFeedbackSummary, error)
+
+	// GetInteractionsByUser retrieves every interaction asked by a user, newest first
+	GetInteractionsByUser(ctx context.Context, userID string) ([]*Interaction, error)
+
+	// DeleteInteractionsByUser deletes a user's interactions, the votes on them and the votes the user cast,
+	// and returns how many interactions were removed
+	DeleteInteractionsByUser(ctx context.Context, userID string) (int64, error)
+
+	// AppendDMMessage stores a turn of a user's DM conversation, setting its ID and creation timestamp
+	AppendDMMessage(ctx context.Context, message *DMMessage) error
+
+	// GetDMMessages retrieves the stored, not yet summarized turns of a user's DM conversation, oldest first
+	GetDMMessages(ctx context.Context, userID string) ([]*DMMessage, error)
+
+	// GetDMSummary retrieves the rolling summary of a user's DM conversation, returning nil if there is none
+	GetDMSummary(ctx context.Context, userID string) (*DMSummary, error)
+
+	// CompactDMConversation stores a new rolling summary and removes the user's messages up to and including throughID
+	CompactDMConversation(ctx context.Context, summary *DMSummary, throughID int64) error
+
+	// DeleteDMConversation removes all stored messages and the summary of a user's DM conversation
+	DeleteDMConversation(ctx context.Context, userID string) error
+
+	// GetThreadSummary retrieves the rolling summary of a thread's older messages, returning nil if there is none
+	GetThreadSummary(ctx context.Context, threadID string) (*ThreadSummary, error)
+
+	// UpsertThreadSummary creates or replaces the rolling summary of a thread's older messages
+	UpsertThreadSummary(ctx context.Context, summary *ThreadSummary) error
 }
@@ -0,0 +1,1170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// messageStateKey identifies a message state the way unique (channel_id, thread_id) does in the SQL schema
+type messageStateKey struct {
+	channelID string
+	threadID  string
+	inThread  bool
+}
+
+// rateLimitKey identifies a rate limit record the way unique (user_id, time_window) does in the SQL schema
+type rateLimitKey struct {
+	userID     string
+	timeWindow string
+}
+
+// configurationKey identifies a configuration entry the way unique (guild_id, config_key) does in the SQL schema
+type configurationKey struct {
+	guildID string
+	key     string
+}
+
+// feedbackKey identifies a vote the way unique (interaction_id, user_id) does in the SQL schema
+type feedbackKey struct {
+	interactionID int64
+	userID        string
+}
+
+// MemoryStorageService implements StorageService in process memory, for tests and ephemeral runs.
+// Nothing survives a restart. It is safe for concurrent use and never returns pointers into its own state.
+type MemoryStorageService struct {
+	mu               sync.RWMutex
+	closed           bool
+	lastIDs          map[string]int64 // Auto-increment sequence per table
+	messageStates    map[messageStateKey]*MessageState
+	threadOwnerships map[string]*ThreadOwnership
+	configurations   map[configurationKey]*Configuration
+	statusMessages   map[int64]*StatusMessage
+	rateLimits       map[rateLimitKey]*UserRateLimit
+	interactions     map[int64]*Interaction
+	feedback         map[feedbackKey]*InteractionFeedback
+	configHistory    map[int64]*ConfigurationHistory
+	dmMessages       map[int64]*DMMessage
+	dmSummaries      map[string]*DMSummary
+	threadSummaries  map[string]*ThreadSummary
+}
+
+// NewMemoryStorageService creates a new, empty in-memory storage service
+func NewMemoryStorageService() *MemoryStorageService {
+	return &MemoryStorageService{
+		lastIDs:          make(map[string]int64),
+		messageStates:    make(map[messageStateKey]*MessageState),
+		threadOwnerships: make(map[string]*ThreadOwnership),
+		configurations:   make(map[configurationKey]*Configuration),
+		statusMessages:   make(map[int64]*StatusMessage),
+		rateLimits:       make(map[rateLimitKey]*UserRateLimit),
+		interactions:     make(map[int64]*Interaction),
+		feedback:         make(map[feedbackKey]*InteractionFeedback),
+		configHistory:    make(map[int64]*ConfigurationHistory),
+		dmMessages:       make(map[int64]*DMMessage),
+		dmSummaries:      make(map[string]*DMSummary),
+		threadSummaries:  make(map[string]*ThreadSummary),
+	}
+}
+
+// nextID returns the next auto-increment ID for table; callers must hold the write lock
+func (s *MemoryStorageService) nextID(table string) int64 {
+	s.lastIDs[table]++
+	return s.lastIDs[table]
+}
+
+// checkAvailable reports why an operation cannot run, mirroring a closed connection or cancelled query
+func (s *MemoryStorageService) checkAvailable(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.closed {
+		return fmt.Errorf("storage is closed")
+	}
+	return nil
+}
+
+// newMessageStateKey builds the lookup key for a channel/thread
+func newMessageStateKey(channelID string, threadID *string) messageStateKey {
+	key := messageStateKey{channelID: channelID}
+	if threadID != nil {
+		key.threadID = *threadID
+		key.inThread = true
+	}
+	return key
+}
+
+// Initialize marks the storage as open; existing data is kept
+func (s *MemoryStorageService) Initialize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.closed = false
+	return nil
+}
+
+// Close marks the storage as closed; later operations fail until Initialize is called again
+func (s *MemoryStorageService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+// HealthCheck verifies that the storage is open
+func (s *MemoryStorageService) HealthCheck(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checkAvailable(ctx)
+}
+
+// GetMessageState retrieves the last seen message state for a channel/thread
+func (s *MemoryStorageService) GetMessageState(ctx context.Context, channelID string, threadID *string) (*MessageState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get message state: %w", err)
+	}
+
+	state, exists := s.messageStates[newMessageStateKey(channelID, threadID)]
+	if !exists {
+		return nil, nil // No state found, not an error
+	}
+	stored := *state
+	return &stored, nil
+}
+
+// UpsertMessageState creates or updates the message state for a channel/thread
+func (s *MemoryStorageService) UpsertMessageState(ctx context.Context, state *MessageState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert message state: %w", err)
+	}
+
+	now := time.Now().Unix()
+	state.UpdatedAt = now
+
+	key := newMessageStateKey(state.ChannelID, state.ThreadID)
+	stored := *state
+	if existing, exists := s.messageStates[key]; exists {
+		state.CreatedAt = existing.CreatedAt // Preserve original creation time
+		stored.ID = existing.ID
+		stored.CreatedAt = existing.CreatedAt
+	} else {
+		if state.CreatedAt == 0 {
+			state.CreatedAt = now
+		}
+		stored.ID = s.nextID("message_states")
+		stored.CreatedAt = state.CreatedAt
+	}
+	s.messageStates[key] = &stored
+
+	return nil
+}
+
+// GetAllMessageStates retrieves all message states for recovery purposes
+func (s *MemoryStorageService) GetAllMessageStates(ctx context.Context) ([]*MessageState, error) {
+	return s.messageStatesSince(ctx, 0, false)
+}
+
+// GetMessageStatesWithinWindow retrieves message states within a specific time window
+func (s *MemoryStorageService) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*MessageState, error) {
+	return s.messageStatesSince(ctx, time.Now().Add(-windowDuration).Unix(), true)
+}
+
+// messageStatesSince returns copies of the message states, optionally only those seen at or after windowStart,
+// most recently seen first
+func (s *MemoryStorageService) messageStatesSince(ctx context.Context, windowStart int64, filter bool) ([]*MessageState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query message states: %w", err)
+	}
+
+	var states []*MessageState
+	for _, state := range s.messageStates {
+		if filter && state.LastSeenTimestamp < windowStart {
+			continue
+		}
+		stored := *state
+		states = append(states, &stored)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].LastSeenTimestamp > states[j].LastSeenTimestamp
+	})
+
+	return states, nil
+}
+
+// GetThreadOwnership retrieves thread ownership information for a thread
+func (s *MemoryStorageService) GetThreadOwnership(ctx context.Context, threadID string) (*ThreadOwnership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get thread ownership: %w", err)
+	}
+
+	ownership, exists := s.threadOwnerships[threadID]
+	if !exists {
+		return nil, nil // Thread ownership not found
+	}
+	stored := *ownership
+	return &stored, nil
+}
+
+// UpsertThreadOwnership creates or updates thread ownership information
+func (s *MemoryStorageService) UpsertThreadOwnership(ctx context.Context, ownership *ThreadOwnership) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert thread ownership: %w", err)
+	}
+
+	now := time.Now().Unix()
+	ownership.UpdatedAt = now
+
+	stored := *ownership
+	if existing, exists := s.threadOwnerships[ownership.ThreadID]; exists {
+		stored.ID = existing.ID
+		stored.CreatedAt = existing.CreatedAt
+	} else {
+		ownership.CreatedAt = now
+		stored.ID = s.nextID("thread_ownerships")
+		stored.CreatedAt = now
+	}
+	s.threadOwnerships[ownership.ThreadID] = &stored
+
+	return nil
+}
+
+// GetAllThreadOwnerships retrieves all thread ownership records
+func (s *MemoryStorageService) GetAllThreadOwnerships(ctx context.Context) ([]*ThreadOwnership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query thread ownerships: %w", err)
+	}
+
+	var ownerships []*ThreadOwnership
+	for _, ownership := range s.threadOwnerships {
+		stored := *ownership
+		ownerships = append(ownerships, &stored)
+	}
+	sort.Slice(ownerships, func(i, j int) bool {
+		return ownerships[i].CreationTime > ownerships[j].CreationTime
+	})
+
+	return ownerships, nil
+}
+
+// CleanupOldThreadOwnerships removes old thread ownership records
+func (s *MemoryStorageService) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to cleanup old thread ownerships: %w", err)
+	}
+
+	cutoffTime := time.Now().Unix() - maxAge
+	for threadID, ownership := range s.threadOwnerships {
+		if ownership.CreationTime < cutoffTime {
+			delete(s.threadOwnerships, threadID)
+		}
+	}
+
+	return nil
+}
+
+// GetConfiguration retrieves a global configuration value by key
+func (s *MemoryStorageService) GetConfiguration(ctx context.Context, key string) (*Configuration, error) {
+	return s.GetGuildConfiguration(ctx, "", key)
+}
+
+// GetGuildConfiguration retrieves a guild's override for a configuration key, returning nil if the guild has none
+func (s *MemoryStorageService) GetGuildConfiguration(ctx context.Context, guildID, key string) (*Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get configuration: %w", err)
+	}
+
+	config, exists := s.configurations[configurationKey{guildID: guildID, key: key}]
+	if !exists {
+		return nil, nil // No configuration found, not an error
+	}
+	stored := *config
+	return &stored, nil
+}
+
+// UpsertConfiguration creates or updates a configuration entry, scoped to its GuildID when set
+func (s *MemoryStorageService) UpsertConfiguration(ctx context.Context, config *Configuration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert configuration: %w", err)
+	}
+
+	s.upsertConfigurationLocked(config)
+	return nil
+}
+
+// upsertConfigurationLocked stores a configuration entry; the caller must hold s.mu
+func (s *MemoryStorageService) upsertConfigurationLocked(config *Configuration) {
+	now := time.Now().Unix()
+	config.UpdatedAt = now
+
+	stored := *config
+	scope := configurationKey{guildID: config.GuildID, key: config.Key}
+	if existing, exists := s.configurations[scope]; exists {
+		config.CreatedAt = existing.CreatedAt // Preserve original creation time
+		stored.ID = existing.ID
+		stored.CreatedAt = existing.CreatedAt
+	} else {
+		if config.CreatedAt == 0 {
+			config.CreatedAt = now
+		}
+		stored.ID = s.nextID("configurations")
+		stored.CreatedAt = config.CreatedAt
+	}
+	if stored.Type == "" {
+		stored.Type = "string" // Column default
+	}
+	s.configurations[scope] = &stored
+}
+
+// GetConfigurationsByCategory retrieves all global configurations in a category
+func (s *MemoryStorageService) GetConfigurationsByCategory(ctx context.Context, category string) ([]*Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query configurations by category: %w", err)
+	}
+
+	var configs []*Configuration
+	for _, config := range s.configurations {
+		if config.GuildID != "" || config.Category != category {
+			continue
+		}
+		stored := *config
+		configs = append(configs, &stored)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key < configs[j].Key })
+
+	return configs, nil
+}
+
+// GetAllConfigurations retrieves all global configurations
+func (s *MemoryStorageService) GetAllConfigurations(ctx context.Context) ([]*Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query all configurations: %w", err)
+	}
+
+	var configs []*Configuration
+	for _, config := range s.configurations {
+		if config.GuildID != "" {
+			continue
+		}
+		stored := *config
+		configs = append(configs, &stored)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		if configs[i].Category != configs[j].Category {
+			return configs[i].Category < configs[j].Category
+		}
+		return configs[i].Key < configs[j].Key
+	})
+
+	return configs, nil
+}
+
+// DeleteConfiguration removes a global configuration entry by key
+func (s *MemoryStorageService) DeleteConfiguration(ctx context.Context, key string) error {
+	return s.DeleteGuildConfiguration(ctx, "", key)
+}
+
+// GetGuildConfigurations retrieves all configuration overrides for a guild
+func (s *MemoryStorageService) GetGuildConfigurations(ctx context.Context, guildID string) ([]*Configuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query guild configurations: %w", err)
+	}
+
+	var configs []*Configuration
+	for _, config := range s.configurations {
+		if config.GuildID != guildID {
+			continue
+		}
+		stored := *config
+		configs = append(configs, &stored)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key < configs[j].Key })
+
+	return configs, nil
+}
+
+// DeleteGuildConfiguration removes a guild's override for a configuration key
+func (s *MemoryStorageService) DeleteGuildConfiguration(ctx context.Context, guildID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+
+	scope := configurationKey{guildID: guildID, key: key}
+	if _, exists := s.configurations[scope]; !exists {
+		return fmt.Errorf("configuration with key '%s' not found", key)
+	}
+	delete(s.configurations, scope)
+
+	return nil
+}
+
+// RecordConfigurationChange appends an entry to the configuration history, setting its ID and timestamp
+func (s *MemoryStorageService) RecordConfigurationChange(ctx context.Context, change *ConfigurationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to record configuration change: %w", err)
+	}
+
+	s.recordConfigurationChangeLocked(change)
+	return nil
+}
+
+// recordConfigurationChangeLocked appends a configuration history entry; the caller must hold s.mu
+func (s *MemoryStorageService) recordConfigurationChangeLocked(change *ConfigurationHistory) {
+	if change.ChangedAt == 0 {
+		change.ChangedAt = time.Now().Unix()
+	}
+	if change.Type == "" {
+		change.Type = "string"
+	}
+	change.ID = s.nextID("configuration_history")
+
+	stored := *change
+	s.configHistory[stored.ID] = &stored
+}
+
+// UpsertConfigurationWithHistory creates or updates a configuration entry and records the change atomically,
+// taking the change's OldValue from the stored entry
+func (s *MemoryStorageService) UpsertConfigurationWithHistory(ctx context.Context, config *Configuration, change *ConfigurationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert configuration: %w", err)
+	}
+
+	change.OldValue = nil
+	if existing, exists := s.configurations[configurationKey{guildID: config.GuildID, key: config.Key}]; exists {
+		oldValue := existing.Value
+		change.OldValue = &oldValue
+	}
+
+	s.upsertConfigurationLocked(config)
+	s.recordConfigurationChangeLocked(change)
+	return nil
+}
+
+// DeleteConfigurationWithHistory removes a configuration entry and records the change atomically,
+// taking the change's OldValue, Type and Category from the removed entry
+func (s *MemoryStorageService) DeleteConfigurationWithHistory(ctx context.Context, guildID, key string, change *ConfigurationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+
+	scope := configurationKey{guildID: guildID, key: key}
+	existing, exists := s.configurations[scope]
+	if !exists {
+		return fmt.Errorf("configuration with key '%s' not found", key)
+	}
+	oldValue := existing.Value
+	change.OldValue = &oldValue
+	change.Type = existing.Type
+	change.Category = existing.Category
+
+	delete(s.configurations, scope)
+	s.recordConfigurationChangeLocked(change)
+	return nil
+}
+
+// GetConfigurationHistory retrieves the most recent changes to a configuration key's global value and guildID's override, newest first
+func (s *MemoryStorageService) GetConfigurationHistory(ctx context.Context, guildID, key string, limit int) ([]*ConfigurationHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query configuration history: %w", err)
+	}
+
+	var history []*ConfigurationHistory
+	for _, change := range s.configHistory {
+		if change.Key != key || (change.GuildID != "" && change.GuildID != guildID) {
+			continue
+		}
+		stored := *change
+		history = append(history, &stored)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].ChangedAt != history[j].ChangedAt {
+			return history[i].ChangedAt > history[j].ChangedAt
+		}
+		return history[i].ID > history[j].ID
+	})
+	if limit >= 0 && len(history) > limit {
+		history = history[:limit]
+	}
+
+	return history, nil
+}
+
+// GetConfigurationChange retrieves a configuration history entry by ID, returning nil if it does not exist
+func (s *MemoryStorageService) GetConfigurationChange(ctx context.Context, id int64) (*ConfigurationHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get configuration change: %w", err)
+	}
+
+	change, exists := s.configHistory[id]
+	if !exists {
+		return nil, nil // No history entry found, not an error
+	}
+	stored := *change
+	return &stored, nil
+}
+
+// GetStatusMessagesBatch retrieves a random batch of enabled status messages
+func (s *MemoryStorageService) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*StatusMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query status messages batch: %w", err)
+	}
+
+	var messages []*StatusMessage
+	for _, msg := range s.statusMessages {
+		if !msg.Enabled {
+			continue
+		}
+		stored := *msg
+		messages = append(messages, &stored)
+	}
+	rand.Shuffle(len(messages), func(i, j int) { messages[i], messages[j] = messages[j], messages[i] })
+	if limit >= 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// AddStatusMessage creates a new status message
+func (s *MemoryStorageService) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to add status message: %w", err)
+	}
+
+	now := time.Now().Unix()
+	id := s.nextID("bot_status_messages")
+	s.statusMessages[id] = &StatusMessage{
+		ID:           id,
+		ActivityType: activityType,
+		StatusText:   statusText,
+		Enabled:      enabled,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	return nil
+}
+
+// UpdateStatusMessage updates the enabled status of a status message
+func (s *MemoryStorageService) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to update status message: %w", err)
+	}
+
+	msg, exists := s.statusMessages[id]
+	if !exists {
+		return fmt.Errorf("status message with ID %d not found", id)
+	}
+	msg.Enabled = enabled
+	msg.UpdatedAt = time.Now().Unix()
+
+	return nil
+}
+
+// GetAllStatusMessages retrieves all status messages
+func (s *MemoryStorageService) GetAllStatusMessages(ctx context.Context) ([]*StatusMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query all status messages: %w", err)
+	}
+
+	var messages []*StatusMessage
+	for _, msg := range s.statusMessages {
+		stored := *msg
+		messages = append(messages, &stored)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].ActivityType != messages[j].ActivityType {
+			return messages[i].ActivityType < messages[j].ActivityType
+		}
+		return messages[i].StatusText < messages[j].StatusText
+	})
+
+	return messages, nil
+}
+
+// GetEnabledStatusMessagesCount returns the count of enabled status messages
+func (s *MemoryStorageService) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to get enabled status messages count: %w", err)
+	}
+
+	count := 0
+	for _, msg := range s.statusMessages {
+		if msg.Enabled {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetUserRateLimit retrieves rate limit state for a user and time window
+func (s *MemoryStorageService) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*UserRateLimit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get user rate limit: %w", err)
+	}
+
+	rateLimit, exists := s.rateLimits[rateLimitKey{userID: userID, timeWindow: timeWindow}]
+	if !exists {
+		return nil, nil // No rate limit record found
+	}
+	stored := *rateLimit
+	return &stored, nil
+}
+
+// UpsertUserRateLimit creates or updates user rate limit state
+func (s *MemoryStorageService) UpsertUserRateLimit(ctx context.Context, rateLimit *UserRateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert user rate limit: %w", err)
+	}
+
+	now := time.Now().Unix()
+	key := rateLimitKey{userID: rateLimit.UserID, timeWindow: rateLimit.TimeWindow}
+
+	stored := *rateLimit
+	stored.UpdatedAt = now
+	if existing, exists := s.rateLimits[key]; exists {
+		stored.ID = existing.ID
+		stored.CreatedAt = existing.CreatedAt
+	} else {
+		stored.ID = s.nextID("user_rate_limits")
+		stored.CreatedAt = now
+	}
+	s.rateLimits[key] = &stored
+
+	return nil
+}
+
+// CleanupExpiredUserRateLimits removes expired rate limit records
+func (s *MemoryStorageService) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to cleanup expired user rate limits: %w", err)
+	}
+
+	for key, rateLimit := range s.rateLimits {
+		if rateLimit.WindowStartTime < expiredBefore {
+			delete(s.rateLimits, key)
+		}
+	}
+
+	return nil
+}
+
+// GetUserRateLimitsByUser retrieves all rate limit records for a user
+func (s *MemoryStorageService) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*UserRateLimit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query user rate limits: %w", err)
+	}
+
+	var rateLimits []*UserRateLimit
+	for _, rateLimit := range s.rateLimits {
+		if rateLimit.UserID != userID {
+			continue
+		}
+		stored := *rateLimit
+		rateLimits = append(rateLimits, &stored)
+	}
+	sort.Slice(rateLimits, func(i, j int) bool { return rateLimits[i].TimeWindow < rateLimits[j].TimeWindow })
+
+	return rateLimits, nil
+}
+
+// ResetUserRateLimit resets rate limiting for a specific user and time window
+func (s *MemoryStorageService) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to reset user rate limit: %w", err)
+	}
+
+	delete(s.rateLimits, rateLimitKey{userID: userID, timeWindow: timeWindow})
+
+	return nil
+}
+
+// RecordInteraction stores a Q&A exchange, setting its ID and creation timestamp
+func (s *MemoryStorageService) RecordInteraction(ctx context.Context, interaction *Interaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+
+	if interaction.CreatedAt == 0 {
+		interaction.CreatedAt = time.Now().Unix()
+	}
+	interaction.ID = s.nextID("interactions")
+
+	stored := *interaction
+	s.interactions[stored.ID] = &stored
+
+	return nil
+}
+
+// GetInteraction retrieves an interaction by ID, returning nil if it does not exist
+func (s *MemoryStorageService) GetInteraction(ctx context.Context, id int64) (*Interaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get interaction: %w", err)
+	}
+
+	interaction, exists := s.interactions[id]
+	if !exists {
+		return nil, nil // No interaction found, not an error
+	}
+	stored := *interaction
+	return &stored, nil
+}
+
+// GetRecentInteractions retrieves the most recent interactions, newest first
+func (s *MemoryStorageService) GetRecentInteractions(ctx context.Context, limit int) ([]*Interaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query interactions: %w", err)
+	}
+
+	interactions := s.sortedInteractions()
+	if limit >= 0 && len(interactions) > limit {
+		interactions = interactions[:limit]
+	}
+
+	return interactions, nil
+}
+
+// sortedInteractions returns copies of all interactions, newest first; callers must hold the lock
+func (s *MemoryStorageService) sortedInteractions() []*Interaction {
+	var interactions []*Interaction
+	for _, interaction := range s.interactions {
+		stored := *interaction
+		interactions = append(interactions, &stored)
+	}
+	sort.Slice(interactions, func(i, j int) bool {
+		if interactions[i].CreatedAt != interactions[j].CreatedAt {
+			return interactions[i].CreatedAt > interactions[j].CreatedAt
+		}
+		return interactions[i].ID > interactions[j].ID
+	})
+	return interactions
+}
+
+// PurgeInteractionsBefore deletes interactions created before the given Unix timestamp, along with their feedback,
+// and returns how many interactions were removed
+func (s *MemoryStorageService) PurgeInteractionsBefore(ctx context.Context, before int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to purge interactions: %w", err)
+	}
+
+	var purged int64
+	for id, interaction := range s.interactions {
+		if interaction.CreatedAt < before {
+			delete(s.interactions, id)
+			purged++
+		}
+	}
+	for key := range s.feedback {
+		if _, exists := s.interactions[key.interactionID]; !exists {
+			delete(s.feedback, key)
+		}
+	}
+
+	return purged, nil
+}
+
+// GetInteractionByResponseMessageID retrieves the interaction answered by the given Discord message, returning nil if none
+func (s *MemoryStorageService) GetInteractionByResponseMessageID(ctx context.Context, messageID string) (*Interaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get interaction by response message: %w", err)
+	}
+
+	var found *Interaction
+	for _, interaction := range s.interactions {
+		if interaction.ResponseMessageID == nil || *interaction.ResponseMessageID != messageID {
+			continue
+		}
+		if found == nil || interaction.ID > found.ID {
+			found = interaction
+		}
+	}
+	if found == nil {
+		return nil, nil // No interaction found, not an error
+	}
+	stored := *found
+	return &stored, nil
+}
+
+// UpsertInteractionFeedback records a user's vote on an interaction, replacing any earlier vote by the same user
+func (s *MemoryStorageService) UpsertInteractionFeedback(ctx context.Context, feedback *InteractionFeedback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert interaction feedback: %w", err)
+	}
+
+	now := time.Now().Unix()
+	if feedback.CreatedAt == 0 {
+		feedback.CreatedAt = now
+	}
+	feedback.UpdatedAt = now
+
+	key := feedbackKey{interactionID: feedback.InteractionID, userID: feedback.UserID}
+	if existing, exists := s.feedback[key]; exists {
+		existing.Vote = feedback.Vote
+		existing.UpdatedAt = now
+		return nil
+	}
+
+	stored := *feedback
+	stored.ID = s.nextID("interaction_feedback")
+	s.feedback[key] = &stored
+
+	return nil
+}
+
+// DeleteInteractionFeedback removes a user's vote on an interaction if it still matches the given vote
+func (s *MemoryStorageService) DeleteInteractionFeedback(ctx context.Context, interactionID int64, userID string, vote int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to delete interaction feedback: %w", err)
+	}
+
+	key := feedbackKey{interactionID: interactionID, userID: userID}
+	if existing, exists := s.feedback[key]; exists && existing.Vote == vote {
+		delete(s.feedback, key)
+	}
+
+	return nil
+}
+
+// GetMostDownvotedInteractions retrieves interactions with at least one downvote, most downvoted first
+func (s *MemoryStorageService) GetMostDownvotedInteractions(ctx context.Context, limit int) ([]*InteractionFeedbackSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query downvoted interactions: %w", err)
+	}
+
+	votes := make(map[int64]*InteractionFeedbackSummary)
+	for key, feedback := range s.feedback {
+		interaction, exists := s.interactions[key.interactionID]
+		if !exists {
+			continue
+		}
+		summary, seen := votes[key.interactionID]
+		if !seen {
+			stored := *interaction
+			summary = &InteractionFeedbackSummary{Interaction: &stored}
+			votes[key.interactionID] = summary
+		}
+		if feedback.Vote > 0 {
+			summary.Upvotes++
+		} else if feedback.Vote < 0 {
+			summary.Downvotes++
+		}
+	}
+
+	var summaries []*InteractionFeedbackSummary
+	for _, summary := range votes {
+		if summary.Downvotes > 0 {
+			summaries = append(summaries, summary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Downvotes != b.Downvotes {
+			return a.Downvotes > b.Downvotes
+		}
+		if a.Upvotes != b.Upvotes {
+			return a.Upvotes < b.Upvotes
+		}
+		return a.Interaction.CreatedAt > b.Interaction.CreatedAt
+	})
+	if limit >= 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+
+	return summaries, nil
+}
+
+// GetInteractionsByUser retrieves every interaction asked by a user, newest first
+func (s *MemoryStorageService) GetInteractionsByUser(ctx context.Context, userID string) ([]*Interaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query user interactions: %w", err)
+	}
+
+	var interactions []*Interaction
+	for _, interaction := range s.sortedInteractions() {
+		if interaction.UserID == userID {
+			interactions = append(interactions, interaction)
+		}
+	}
+
+	return interactions, nil
+}
+
+// DeleteInteractionsByUser deletes a user's interactions, the votes on them and the votes the user cast,
+// and returns how many interactions were removed
+func (s *MemoryStorageService) DeleteInteractionsByUser(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete user interactions: %w", err)
+	}
+
+	var deleted int64
+	for id, interaction := range s.interactions {
+		if interaction.UserID == userID {
+			delete(s.interactions, id)
+			deleted++
+		}
+	}
+	for key := range s.feedback {
+		if _, exists := s.interactions[key.interactionID]; !exists || key.userID == userID {
+			delete(s.feedback, key)
+		}
+	}
+
+	return deleted, nil
+}
+
+// AppendDMMessage stores a turn of a user's DM conversation, setting its ID and creation timestamp
+func (s *MemoryStorageService) AppendDMMessage(ctx context.Context, message *DMMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to append DM message: %w", err)
+	}
+
+	if message.CreatedAt == 0 {
+		message.CreatedAt = time.Now().Unix()
+	}
+	message.ID = s.nextID("dm_messages")
+
+	stored := *message
+	s.dmMessages[stored.ID] = &stored
+
+	return nil
+}
+
+// GetDMMessages retrieves the stored, not yet summarized turns of a user's DM conversation, oldest first
+func (s *MemoryStorageService) GetDMMessages(ctx context.Context, userID string) ([]*DMMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to query DM messages: %w", err)
+	}
+
+	var messages []*DMMessage
+	for _, message := range s.dmMessages {
+		if message.UserID == userID {
+			stored := *message
+			messages = append(messages, &stored)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages, nil
+}
+
+// GetDMSummary retrieves the rolling summary of a user's DM conversation, returning nil if there is none
+func (s *MemoryStorageService) GetDMSummary(ctx context.Context, userID string) (*DMSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get DM summary: %w", err)
+	}
+
+	summary, exists := s.dmSummaries[userID]
+	if !exists {
+		return nil, nil // No summary yet, not an error
+	}
+	stored := *summary
+	return &stored, nil
+}
+
+// CompactDMConversation stores a new rolling summary and removes the user's messages up to and including throughID
+func (s *MemoryStorageService) CompactDMConversation(ctx context.Context, summary *DMSummary, throughID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert DM summary: %w", err)
+	}
+
+	summary.UpdatedAt = time.Now().Unix()
+	stored := *summary
+	s.dmSummaries[stored.UserID] = &stored
+
+	for id, message := range s.dmMessages {
+		if message.UserID == summary.UserID && id <= throughID {
+			delete(s.dmMessages, id)
+		}
+	}
+
+	return nil
+}
+
+// DeleteDMConversation removes all stored messages and the summary of a user's DM conversation
+func (s *MemoryStorageService) DeleteDMConversation(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to delete DM messages: %w", err)
+	}
+
+	for id, message := range s.dmMessages {
+		if message.UserID == userID {
+			delete(s.dmMessages, id)
+		}
+	}
+	delete(s.dmSummaries, userID)
+
+	return nil
+}
+
+// GetThreadSummary retrieves the rolling summary of a thread's older messages, returning nil if there is none
+func (s *MemoryStorageService) GetThreadSummary(ctx context.Context, threadID string) (*ThreadSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get thread summary: %w", err)
+	}
+
+	summary, exists := s.threadSummaries[threadID]
+	if !exists {
+		return nil, nil // No summary yet, not an error
+	}
+	stored := *summary
+	return &stored, nil
+}
+
+// UpsertThreadSummary creates or replaces the rolling summary of a thread's older messages
+func (s *MemoryStorageService) UpsertThreadSummary(ctx context.Context, summary *ThreadSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAvailable(ctx); err != nil {
+		return fmt.Errorf("failed to upsert thread summary: %w", err)
+	}
+
+	summary.UpdatedAt = time.Now().Unix()
+	stored := *summary
+	s.threadSummaries[stored.ThreadID] = &stored
+
+	return nil
+}
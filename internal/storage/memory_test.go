@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorageService_Conformance(t *testing.T) {
+	runStorageConformanceTests(t, func(t *testing.T) StorageService {
+		service := NewMemoryStorageService()
+		require.NoError(t, service.Initialize(context.Background()))
+		return service
+	})
+}
+
+func TestMemoryStorageService_ReturnsCopies(t *testing.T) {
+	service := NewMemoryStorageService()
+	ctx := context.Background()
+
+	require.NoError(t, service.UpsertConfiguration(ctx, &Configuration{Key: "KEY", Value: "original", Category: "test"}))
+
+	// Mutating a returned record must not change the stored one
+	config, err := service.GetConfiguration(ctx, "KEY")
+	require.NoError(t, err)
+	config.Value = "mutated"
+
+	config, err = service.GetConfiguration(ctx, "KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "original", config.Value)
+}
+
+func TestMemoryStorageService_ConcurrentUpserts(t *testing.T) {
+	service := NewMemoryStorageService()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(count int) {
+			defer wg.Done()
+			assert.NoError(t, service.UpsertUserRateLimit(ctx, &UserRateLimit{
+				UserID:          "user1",
+				TimeWindow:      "minute",
+				RequestCount:    count,
+				WindowStartTime: time.Now().Unix(),
+				LastRequestTime: time.Now().Unix(),
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	// Concurrent upserts of the same user and window leave a single record
+	rateLimits, err := service.GetUserRateLimitsByUser(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, rateLimits, 1)
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration is a single versioned schema change with the statements to apply and revert it
+type Migration struct {
+	Version     int
+	Description string
+	Up          []string
+	Down        []string
+}
+
+// MigrationStatus reports whether a known migration has been applied to the database
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   int64
+}
+
+// SchemaMigrator is implemented by storage backends whose schema is managed by versioned migrations
+type SchemaMigrator interface {
+	Migrator(ctx context.Context) (*Migrator, error)
+}
+
+// migrationLocker serializes migration runs between processes sharing the same database
+type migrationLocker interface {
+	// Lock blocks until the connection holds the migration lock
+	Lock(ctx context.Context, conn *sql.Conn) error
+	// Unlock releases the lock; success reports whether the locked work completed
+	Unlock(ctx context.Context, conn *sql.Conn, success bool) error
+}
+
+// schemaMigrationsTable records which migrations have been applied; the DDL is valid for MySQL and SQLite
+const schemaMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	description VARCHAR(255) NOT NULL,
+	applied_at BIGINT NOT NULL
+)`
+
+// Migrator applies and reverts ordered schema migrations under a database-wide lock
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	locker     migrationLocker
+}
+
+// newMigrator creates a migrator for the given migrations, which must be in ascending version order
+func newMigrator(db *sql.DB, migrations []Migration, locker migrationLocker) (*Migrator, error) {
+	if err := validateMigrations(migrations); err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		locker:     locker,
+	}, nil
+}
+
+// validateMigrations ensures versions are positive, unique and strictly increasing
+func validateMigrations(migrations []Migration) error {
+	previous := 0
+	for _, migration := range migrations {
+		if migration.Version <= previous {
+			return fmt.Errorf("migration %d is out of order (follows %d)", migration.Version, previous)
+		}
+		if len(migration.Up) == 0 {
+			return fmt.Errorf("migration %d has no up statements", migration.Version)
+		}
+		previous = migration.Version
+	}
+	return nil
+}
+
+// Status lists every known migration along with whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]MigrationStatus, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			appliedAt, ok := applied[migration.Version]
+			statuses = append(statuses, MigrationStatus{
+				Version:     migration.Version,
+				Description: migration.Description,
+				Applied:     ok,
+				AppliedAt:   appliedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns the versions it applied
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	var appliedNow []int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := execMigrationStatements(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Description, time.Now().Unix()); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+
+			appliedNow = append(appliedNow, migration.Version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedNow, nil
+}
+
+// Down reverts up to steps of the most recently applied migrations and returns the versions it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, version := range versions {
+			if len(reverted) == steps {
+				break
+			}
+
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but unknown to this build", version)
+			}
+
+			if err := execMigrationStatements(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", version); err != nil {
+				return fmt.Errorf("failed to unrecord migration %d: %w", version, err)
+			}
+
+			reverted = append(reverted, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// withLock runs fn on a dedicated connection that holds the migration lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.locker.Lock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := m.locker.Unlock(ctx, conn, err == nil); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions returns the applied migration versions mapped to when they were applied
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]int64, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// execMigrationStatements runs each statement of a migration in order
+func execMigrationStatements(ctx context.Context, conn *sql.Conn, statements []string) error {
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_DialectsShareVersions(t *testing.T) {
+	mysql := mysqlMigrations()
+	sqlite := sqliteMigrations()
+
+	require.NoError(t, validateMigrations(mysql))
+	require.NoError(t, validateMigrations(sqlite))
+	require.Len(t, sqlite, len(mysql))
+
+	for i := range mysql {
+		assert.Equal(t, mysql[i].Version, sqlite[i].Version)
+		assert.Equal(t, mysql[i].Description, sqlite[i].Description)
+		assert.NotEmpty(t, mysql[i].Down, "migration %d must be reversible", mysql[i].Version)
+		assert.NotEmpty(t, sqlite[i].Down, "migration %d must be reversible", sqlite[i].Version)
+	}
+}
+
+func TestValidateMigrations(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+		wantErr    bool
+	}{
+		{"ascending", []Migration{{Version: 1, Up: []string{"x"}}, {Version: 2, Up: []string{"y"}}}, false},
+		{"duplicate version", []Migration{{Version: 1, Up: []string{"x"}}, {Version: 1, Up: []string{"y"}}}, true},
+		{"descending", []Migration{{Version: 2, Up: []string{"x"}}, {Version: 1, Up: []string{"y"}}}, true},
+		{"zero version", []Migration{{Version: 0, Up: []string{"x"}}}, true},
+		{"no up statements", []Migration{{Version: 1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMigrations(tt.migrations)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMigrator_UpStatusDown(t *testing.T) {
+	ctx := context.Background()
+	service := NewSQLiteStorageService(filepath.Join(t.TempDir(), "migrate.db"))
+	defer service.Close()
+
+	migrator, err := service.Migrator(ctx)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(sqliteMigrations()))
+	for _, status := range statuses {
+		assert.False(t, status.Applied)
+	}
+
+	var versions []int
+	for _, migration := range sqliteMigrations() {
+		versions = append(versions, migration.Version)
+	}
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, versions, applied)
+	assert.True(t, tableExists(t, service.db, "message_states"))
+
+	// A second run has nothing left to apply
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied)
+		assert.NotZero(t, status.AppliedAt)
+	}
+
+	// Rolling back one step reverts only the latest migration
+	latest := versions[len(versions)-1]
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{latest}, reverted)
+	assert.True(t, tableExists(t, service.db, "message_states"))
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+
+	// Rolling back everything reverts the rest newest first
+	reverted, err = migrator.Down(ctx, len(versions))
+	require.NoError(t, err)
+	require.NotEmpty(t, reverted)
+	assert.Equal(t, 1, reverted[len(reverted)-1])
+	assert.False(t, tableExists(t, service.db, "message_states"))
+
+	// Nothing is left to revert
+	reverted, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	_, err = migrator.Down(ctx, 0)
+	assert.Error(t, err)
+}
+
+func TestSQLiteMigrations_GuildScopedConfigurationKeepsValues(t *testing.T) {
+	ctx := context.Background()
+	service := NewSQLiteStorageService(filepath.Join(t.TempDir(), "guild.db"))
+	defer service.Close()
+	require.NoError(t, service.open(ctx))
+
+	// Apply the schema as it was before configuration became guild-scoped
+	migrator, err := newMigrator(service.db, sqliteMigrations()[:2], sqliteMigrationLocker{})
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	_, err = service.db.ExecContext(ctx,
+		"INSERT INTO configurations (config_key, config_value, value_type, category, description, created_at, updated_at) VALUES ('RATE_LIMITING_ENABLED', 'false', 'bool', 'rate_limiting', '', 1, 1)")
+	require.NoError(t, err)
+
+	migrator, err = service.Migrator(ctx)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, service.prepareStatements(sqliteStatements()))
+
+	config, err := service.GetConfiguration(ctx, "RATE_LIMITING_ENABLED")
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, "false", config.Value)
+	assert.Empty(t, config.GuildID)
+
+	// The same key can now be overridden per guild
+	require.NoError(t, service.UpsertConfiguration(ctx, &Configuration{GuildID: "guild-a", Key: "RATE_LIMITING_ENABLED", Value: "true", Type: "bool", Category: "rate_limiting"}))
+	config, err = service.GetConfiguration(ctx, "RATE_LIMITING_ENABLED")
+	require.NoError(t, err)
+	assert.Equal(t, "false", config.Value)
+}
+
+func TestMigrator_FailedRunRollsBack(t *testing.T) {
+	ctx := context.Background()
+	service := NewSQLiteStorageService(filepath.Join(t.TempDir(), "rollback.db"))
+	defer service.Close()
+	require.NoError(t, service.open(ctx))
+
+	migrator, err := newMigrator(service.db, []Migration{
+		{Version: 1, Description: "create widgets", Up: []string{"CREATE TABLE widgets (id INTEGER)"}, Down: []string{"DROP TABLE widgets"}},
+		{Version: 2, Description: "broken", Up: []string{"CREATE TABLE"}, Down: []string{"SELECT 1"}},
+	}, sqliteMigrationLocker{})
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2")
+
+	// The whole run shares one transaction, so the first migration is undone too
+	assert.False(t, tableExists(t, service.db, "widgets"))
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.False(t, status.Applied)
+	}
+}
+
+func TestMigrator_AdoptsExistingSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Simulate a database created before schema_migrations existed
+	legacy := NewSQLiteStorageService(path)
+	require.NoError(t, legacy.open(ctx))
+	_, err := legacy.db.ExecContext(ctx, sqliteMigrations()[0].Up[0])
+	require.NoError(t, err)
+	_, err = legacy.db.ExecContext(ctx,
+		"INSERT INTO message_states (channel_id, last_message_id, last_seen_timestamp, created_at, updated_at) VALUES ('channel123', 'msg123', 1, 1, 1)")
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	service := NewSQLiteStorageService(path)
+	require.NoError(t, service.Initialize(ctx))
+	defer service.Close()
+
+	state, err := service.GetMessageState(ctx, "channel123", nil)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "msg123", state.LastMessageID)
+}
+
+func TestMigrator_ConcurrentReplicasApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "replicas.db")
+
+	const replicas = 4
+	var wg sync.WaitGroup
+	results := make([][]int, replicas)
+	errs := make([]error, replicas)
+
+	for i := 0; i < replicas; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			service := NewSQLiteStorageService(path)
+			defer service.Close()
+
+			migrator, err := service.Migrator(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = migrator.Up(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := 0; i < replicas; i++ {
+		require.NoError(t, errs[i])
+		total += len(results[i])
+	}
+	assert.Equal(t, len(sqliteMigrations()), total, "each migration should be applied by exactly one replica")
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	require.NoError(t, err)
+	return count > 0
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	// mysqlMigrationLockName is the advisory lock shared by every replica running migrations
+	mysqlMigrationLockName = "bmad_bot_schema_migrations"
+
+	// mysqlMigrationLockTimeoutSeconds bounds how long a replica waits for another to finish migrating
+	mysqlMigrationLockTimeoutSeconds = 120
+)
+
+// mysqlMigrationLocker serializes migrations with a MySQL named lock held by the migration connection
+type mysqlMigrationLocker struct{}
+
+// Lock waits for the named lock, failing if another replica holds it past the timeout
+func (mysqlMigrationLocker) Lock(ctx context.Context, conn *sql.Conn) error {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", mysqlMigrationLockName, mysqlMigrationLockTimeoutSeconds).Scan(&acquired); err != nil {
+		return err
+	}
+
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("timed out after %ds waiting for lock %q", mysqlMigrationLockTimeoutSeconds, mysqlMigrationLockName)
+	}
+
+	return nil
+}
+
+// Unlock releases the named lock; MySQL DDL commits implicitly so there is nothing to roll back
+func (mysqlMigrationLocker) Unlock(ctx context.Context, conn *sql.Conn, _ bool) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", mysqlMigrationLockName)
+	return err
+}
+
+// Migrator returns the schema migrator for this database, connecting first if needed
+func (s *MySQLStorageService) Migrator(ctx context.Context) (*Migrator, error) {
+	if s.db == nil {
+		db, err := s.connectWithRetry(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		s.db = db
+	}
+
+	return newMigrator(s.db, mysqlMigrations(), mysqlMigrationLocker{})
+}
+
+// mysqlMigrations returns the MySQL schema history; versions must match sqliteMigrations
+func mysqlMigrations() []Migration {
+	return []Migration{
+		{
+			// Reproduces the schema previously built by createTables so existing databases adopt it in place
+			Version:     1,
+			Description: "initial schema",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS message_states (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					channel_id VARCHAR(255) NOT NULL,
+					thread_id VARCHAR(255) NULL,
+					last_message_id VARCHAR(255) NOT NULL,
+					last_seen_timestamp BIGINT NOT NULL,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					UNIQUE KEY unique_channel_thread (channel_id, thread_id),
+					INDEX idx_message_states_channel_thread (channel_id, thread_id),
+					INDEX idx_message_states_timestamp (last_seen_timestamp)
+				)`,
+				`CREATE TABLE IF NOT EXISTS thread_ownerships (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					thread_id VARCHAR(255) NOT NULL UNIQUE,
+					original_user_id VARCHAR(255) NOT NULL,
+					created_by VARCHAR(255) NOT NULL,
+					creation_time BIGINT NOT NULL,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					INDEX idx_thread_ownerships_thread_id (thread_id),
+					INDEX idx_thread_ownerships_creation_time (creation_time)
+				)`,
+				`CREATE TABLE IF NOT EXISTS configurations (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					config_key VARCHAR(255) NOT NULL UNIQUE,
+					config_value TEXT NOT NULL,
+					value_type ENUM('string', 'int', 'bool', 'duration') DEFAULT 'string',
+					category VARCHAR(100) NOT NULL,
+					description TEXT,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					INDEX idx_configurations_category (category),
+					INDEX idx_configurations_key_category (config_key, category)
+				)`,
+				`CREATE TABLE IF NOT EXISTS bot_status_messages (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					activity_type VARCHAR(50) NOT NULL,
+					status_text VARCHAR(255) NOT NULL,
+					enabled BOOLEAN NOT NULL DEFAULT TRUE,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					INDEX idx_enabled (enabled),
+					INDEX idx_activity_type (activity_type)
+				)`,
+				`CREATE TABLE IF NOT EXISTS user_rate_limits (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					user_id VARCHAR(255) NOT NULL,
+					time_window VARCHAR(20) NOT NULL,
+					request_count INT NOT NULL DEFAULT 0,
+					window_start_time BIGINT NOT NULL,
+					last_request_time BIGINT NOT NULL,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					UNIQUE KEY unique_user_window (user_id, time_window),
+					INDEX idx_user_id (user_id),
+					INDEX idx_window_start_time (window_start_time)
+				)`,
+				`CREATE TABLE IF NOT EXISTS interactions (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					guild_id VARCHAR(255) NOT NULL DEFAULT '',
+					channel_id VARCHAR(255) NOT NULL,
+					thread_id VARCHAR(255) NULL,
+					user_id VARCHAR(255) NOT NULL,
+					message_id VARCHAR(255) NOT NULL,
+					trigger_type VARCHAR(50) NOT NULL,
+					query TEXT NOT NULL,
+					response MEDIUMTEXT NOT NULL,
+					provider VARCHAR(100) NOT NULL,
+					model VARCHAR(255) NOT NULL,
+					latency_ms BIGINT NOT NULL,
+					quality_overall DOUBLE NULL,
+					quality_bmad_coverage DOUBLE NULL,
+					quality_knowledge_boundary DOUBLE NULL,
+					quality_content DOUBLE NULL,
+					error_message TEXT NOT NULL,
+					created_at BIGINT NOT NULL,
+					response_message_id VARCHAR(255) NULL,
+					INDEX idx_interactions_created_at (created_at),
+					INDEX idx_interactions_user_id (user_id),
+					INDEX idx_interactions_message_id (message_id),
+					INDEX idx_interactions_response_message_id (response_message_id)
+				)`,
+				`CREATE TABLE IF NOT EXISTS interaction_feedback (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					interaction_id BIGINT NOT NULL,
+					user_id VARCHAR(255) NOT NULL,
+					vote TINYINT NOT NULL,
+					created_at BIGINT NOT NULL,
+					updated_at BIGINT NOT NULL,
+					UNIQUE KEY unique_interaction_user (interaction_id, user_id)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE IF EXISTS interaction_feedback`,
+				`DROP TABLE IF EXISTS interactions`,
+				`DROP TABLE IF EXISTS user_rate_limits`,
+				`DROP TABLE IF EXISTS bot_status_messages`,
+				`DROP TABLE IF EXISTS configurations`,
+				`DROP TABLE IF EXISTS thread_ownerships`,
+				`DROP TABLE IF EXISTS message_states`,
+			},
+		},
+		{
+			Version:     2,
+			Description: "configuration history",
+			Up: []string{
+				`CREATE TABLE configuration_history (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					config_key VARCHAR(255) NOT NULL,
+					old_value TEXT NULL,
+					new_value TEXT NULL,
+					value_type VARCHAR(20) NOT NULL DEFAULT 'string',
+					category VARCHAR(100) NOT NULL DEFAULT '',
+					actor VARCHAR(255) NOT NULL,
+					changed_at BIGINT NOT NULL,
+					INDEX idx_configuration_history_key (config_key, changed_at)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE configuration_history`,
+			},
+		},
+		{
+			Version:     3,
+			Description: "guild-scoped configuration",
+			Up: []string{
+				`ALTER TABLE configurations
+					ADD COLUMN guild_id VARCHAR(255) NOT NULL DEFAULT '' AFTER id,
+					DROP INDEX config_key,
+					ADD UNIQUE KEY unique_guild_config (guild_id, config_key)`,
+				`ALTER TABLE configuration_history
+					ADD COLUMN guild_id VARCHAR(255) NOT NULL DEFAULT '' AFTER id`,
+			},
+			Down: []string{
+				`ALTER TABLE configuration_history DROP COLUMN guild_id`,
+				`DELETE FROM configurations WHERE guild_id <> ''`,
+				`ALTER TABLE configurations
+					DROP INDEX unique_guild_config,
+					DROP COLUMN guild_id,
+					ADD UNIQUE KEY config_key (config_key)`,
+			},
+		},
+		{
+			Version:     4,
+			Description: "dm conversation memory",
+			Up: []string{
+				`CREATE TABLE dm_messages (
+					id BIGINT PRIMARY KEY AUTO_INCREMENT,
+					user_id VARCHAR(255) NOT NULL,
+					role VARCHAR(20) NOT NULL,
+					content MEDIUMTEXT NOT NULL,
+					created_at BIGINT NOT NULL,
+					INDEX idx_dm_messages_user (user_id, id)
+				)`,
+				`CREATE TABLE dm_summaries (
+					user_id VARCHAR(255) PRIMARY KEY,
+					summary MEDIUMTEXT NOT NULL,
+					summarized_messages INT NOT NULL DEFAULT 0,
+					updated_at BIGINT NOT NULL
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE dm_summaries`,
+				`DROP TABLE dm_messages`,
+			},
+		},
+		{
+			Version:     5,
+			Description: "thread conversation summaries",
+			Up: []string{
+				`CREATE TABLE thread_summaries (
+					thread_id VARCHAR(255) PRIMARY KEY,
+					summary MEDIUMTEXT NOT NULL,
+					summarized_through_id VARCHAR(255) NOT NULL,
+					summarized_messages INT NOT NULL DEFAULT 0,
+					updated_at BIGINT NOT NULL
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE thread_summaries`,
+			},
+		},
+	}
+}
@@ -0,0 +1,1695 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlStorage implements the StorageService queries shared by the database/sql backends.
+// Backends open db, create their schema and prepare sharedStatements along with their dialect-specific statements.
+type sqlStorage struct {
+	db       *sql.DB
+	prepared map[string]*sql.Stmt
+}
+
+// sharedStatements returns the SQL statements that run unchanged on every supported database
+func sharedStatements() map[string]string {
+	return map[string]string{
+		"get_state": `
+			SELECT id, channel_id, thread_id, last_message_id, last_seen_timestamp, created_at, updated_at
+			FROM message_states 
+			WHERE channel_id = ? AND (thread_id = ? OR (thread_id IS NULL AND ? IS NULL))
+		`,
+		"check_exists": `
+			SELECT id, created_at FROM message_states
+			WHERE channel_id = ? AND (thread_id = ? OR (thread_id IS NULL AND ? IS NULL))
+		`,
+		"insert_state": `
+			INSERT INTO message_states (channel_id, thread_id, last_message_id, last_seen_timestamp, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`,
+		"update_state": `
+			UPDATE message_states
+			SET last_message_id = ?, last_seen_timestamp = ?, updated_at = ?
+			WHERE channel_id = ? AND (thread_id = ? OR (thread_id IS NULL AND ? IS NULL))
+		`,
+		"get_all_states": `
+			SELECT id, channel_id, thread_id, last_message_id, last_seen_timestamp, created_at, updated_at
+			FROM message_states
+			ORDER BY last_seen_timestamp DESC
+		`,
+		"get_states_within_window": `
+			SELECT id, channel_id, thread_id, last_message_id, last_seen_timestamp, created_at, updated_at
+			FROM message_states
+			WHERE last_seen_timestamp >= ?
+			ORDER BY last_seen_timestamp DESC
+		`,
+		"get_thread_ownership": `
+			SELECT id, thread_id, original_user_id, created_by, creation_time, created_at, updated_at
+			FROM thread_ownerships
+			WHERE thread_id = ?
+		`,
+		"insert_thread_ownership": `
+			INSERT INTO thread_ownerships (thread_id, original_user_id, created_by, creation_time, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`,
+		"update_thread_ownership": `
+			UPDATE thread_ownerships
+			SET original_user_id = ?, created_by = ?, creation_time = ?, updated_at = ?
+			WHERE thread_id = ?
+		`,
+		"get_all_thread_ownerships": `
+			SELECT id, thread_id, original_user_id, created_by, creation_time, created_at, updated_at
+			FROM thread_ownerships
+			ORDER BY creation_time DESC
+		`,
+		"cleanup_old_thread_ownerships": `
+			DELETE FROM thread_ownerships
+			WHERE creation_time < ?
+		`,
+		"get_configuration": `
+			SELECT id, guild_id, config_key, config_value, value_type, category, description, created_at, updated_at
+			FROM configurations
+			WHERE guild_id = ? AND config_key = ?
+		`,
+		"check_config_exists": `
+			SELECT id, created_at FROM configurations
+			WHERE guild_id = ? AND config_key = ?
+		`,
+		"insert_configuration": `
+			INSERT INTO configurations (guild_id, config_key, config_value, value_type, category, description, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		"update_configuration": `
+			UPDATE configurations
+			SET config_value = ?, value_type = ?, category = ?, description = ?, updated_at = ?
+			WHERE guild_id = ? AND config_key = ?
+		`,
+		"get_configurations_by_category": `
+			SELECT id, guild_id, config_key, config_value, value_type, category, description, created_at, updated_at
+			FROM configurations
+			WHERE guild_id = '' AND category = ?
+			ORDER BY config_key
+		`,
+		"get_all_configurations": `
+			SELECT id, guild_id, config_key, config_value, value_type, category, description, created_at, updated_at
+			FROM configurations
+			WHERE guild_id = ''
+			ORDER BY category, config_key
+		`,
+		"get_guild_configurations": `
+			SELECT id, guild_id, config_key, config_value, value_type, category, description, created_at, updated_at
+			FROM configurations
+			WHERE guild_id = ?
+			ORDER BY config_key
+		`,
+		"delete_configuration": `
+			DELETE FROM configurations
+			WHERE guild_id = ? AND config_key = ?
+		`,
+		"insert_configuration_history": `
+			INSERT INTO configuration_history (guild_id, config_key, old_value, new_value, value_type, category, actor, changed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		"get_configuration_history": `
+			SELECT id, guild_id, config_key, old_value, new_value, value_type, category, actor, changed_at
+			FROM configuration_history
+			WHERE config_key = ? AND (guild_id = '' OR guild_id = ?)
+			ORDER BY changed_at DESC, id DESC
+			LIMIT ?
+		`,
+		"get_configuration_change": `
+			SELECT id, guild_id, config_key, old_value, new_value, value_type, category, actor, changed_at
+			FROM configuration_history
+			WHERE id = ?
+		`,
+		"add_status_message": `
+			INSERT INTO bot_status_messages (activity_type, status_text, enabled, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`,
+		"update_status_message": `
+			UPDATE bot_status_messages
+			SET enabled = ?, updated_at = ?
+			WHERE id = ?
+		`,
+		"get_all_status_messages": `
+			SELECT id, activity_type, status_text, enabled, created_at, updated_at
+			FROM bot_status_messages
+			ORDER BY activity_type, status_text
+		`,
+		"get_enabled_status_messages_count": `
+			SELECT COUNT(*)
+			FROM bot_status_messages
+			WHERE enabled = TRUE
+		`,
+		"get_user_rate_limit": `
+			SELECT id, user_id, time_window, request_count, window_start_time, last_request_time, created_at, updated_at
+			FROM user_rate_limits
+			WHERE user_id = ? AND time_window = ?
+		`,
+		"cleanup_expired_user_rate_limits": `
+			DELETE FROM user_rate_limits
+			WHERE window_start_time < ?
+		`,
+		"get_user_rate_limits_by_user": `
+			SELECT id, user_id, time_window, request_count, window_start_time, last_request_time, created_at, updated_at
+			FROM user_rate_limits
+			WHERE user_id = ?
+			ORDER BY time_window
+		`,
+		"reset_user_rate_limit": `
+			DELETE FROM user_rate_limits
+			WHERE user_id = ? AND time_window = ?
+		`,
+		"insert_interaction": `
+			INSERT INTO interactions (guild_id, channel_id, thread_id, user_id, message_id, trigger_type, query, response,
+				provider, model, latency_ms, quality_overall, quality_bmad_coverage, quality_knowledge_boundary,
+				quality_content, error_message, response_message_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		"get_interaction": `
+			SELECT ` + interactionColumns + `
+			FROM interactions i
+			WHERE i.id = ?
+		`,
+		"get_recent_interactions": `
+			SELECT ` + interactionColumns + `
+			FROM interactions i
+			ORDER BY i.created_at DESC, i.id DESC
+			LIMIT ?
+		`,
+		"purge_interactions_before": `
+			DELETE FROM interactions
+			WHERE created_at < ?
+		`,
+		"get_interaction_by_response_message_id": `
+			SELECT ` + interactionColumns + `
+			FROM interactions i
+			WHERE i.response_message_id = ?
+			ORDER BY i.id DESC
+			LIMIT 1
+		`,
+		"get_most_downvoted_interactions": `
+			SELECT ` + interactionColumns + `,
+				SUM(CASE WHEN f.vote > 0 THEN 1 ELSE 0 END) AS upvotes,
+				SUM(CASE WHEN f.vote < 0 THEN 1 ELSE 0 END) AS downvotes
+			FROM interactions i
+			JOIN interaction_feedback f ON f.interaction_id = i.id
+			GROUP BY i.id
+			HAVING downvotes > 0
+			ORDER BY downvotes DESC, upvotes ASC, i.created_at DESC
+			LIMIT ?
+		`,
+		"get_interactions_by_user": `
+			SELECT ` + interactionColumns + `
+			FROM interactions i
+			WHERE i.user_id = ?
+			ORDER BY i.created_at DESC, i.id DESC
+		`,
+		"delete_interaction_feedback": `
+			DELETE FROM interaction_feedback
+			WHERE interaction_id = ? AND user_id = ? AND vote = ?
+		`,
+		"delete_interaction_feedback_by_user": `
+			DELETE FROM interaction_feedback
+			WHERE user_id = ? OR interaction_id IN (SELECT id FROM interactions WHERE user_id = ?)
+		`,
+		"delete_interactions_by_user": `
+			DELETE FROM interactions
+			WHERE user_id = ?
+		`,
+		"insert_dm_message": `
+			INSERT INTO dm_messages (user_id, role, content, created_at)
+			VALUES (?, ?, ?, ?)
+		`,
+		"get_dm_messages": `
+			SELECT id, user_id, role, content, created_at
+			FROM dm_messages
+			WHERE user_id = ?
+			ORDER BY id
+		`,
+		"get_dm_summary": `
+			SELECT user_id, summary, summarized_messages, updated_at
+			FROM dm_summaries
+			WHERE user_id = ?
+		`,
+		"delete_dm_messages_through": `
+			DELETE FROM dm_messages
+			WHERE user_id = ? AND id <= ?
+		`,
+		"delete_dm_messages": `
+			DELETE FROM dm_messages
+			WHERE user_id = ?
+		`,
+		"delete_dm_summary": `
+			DELETE FROM dm_summaries
+			WHERE user_id = ?
+		`,
+		"get_thread_summary": `
+			SELECT thread_id, summary, summarized_through_id, summarized_messages, updated_at
+			FROM thread_summaries
+			WHERE thread_id = ?
+		`,
+	}
+}
+
+// prepareStatements prepares the shared statements and the backend's dialect-specific statements
+func (s *sqlStorage) prepareStatements(dialectStatements map[string]string) error {
+	statements := sharedStatements()
+	for name, query := range dialectStatements {
+		statements[name] = query
+	}
+
+	for name, query := range statements {
+		stmt, err := s.db.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement %s: %w", name, err)
+		}
+		s.prepared[name] = stmt
+	}
+
+	return nil
+}
+
+// Close closes the database connection
+func (s *sqlStorage) Close() error {
+	// Close prepared statements
+	for _, stmt := range s.prepared {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+
+	// Close database
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// GetMessageState retrieves the last seen message state for a channel/thread
+func (s *sqlStorage) GetMessageState(ctx context.Context, channelID string, threadID *string) (*MessageState, error) {
+	stmt := s.prepared["get_state"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_state statement not prepared")
+	}
+
+	var state MessageState
+	err := stmt.QueryRowContext(ctx, channelID, threadID, threadID).Scan(
+		&state.ID,
+		&state.ChannelID,
+		&state.ThreadID,
+		&state.LastMessageID,
+		&state.LastSeenTimestamp,
+		&state.CreatedAt,
+		&state.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // No state found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// UpsertMessageState creates or updates the message state for a channel/thread
+func (s *sqlStorage) UpsertMessageState(ctx context.Context, state *MessageState) error {
+	checkStmt := s.prepared["check_exists"]
+	insertStmt := s.prepared["insert_state"]
+	updateStmt := s.prepared["update_state"]
+
+	if checkStmt == nil || insertStmt == nil || updateStmt == nil {
+		return fmt.Errorf("required statements not prepared")
+	}
+
+	now := time.Now().Unix()
+	state.UpdatedAt = now
+
+	// Check if record exists
+	var existingID int64
+	var existingCreatedAt int64
+	err := checkStmt.QueryRowContext(ctx, state.ChannelID, state.ThreadID, state.ThreadID).Scan(&existingID, &existingCreatedAt)
+
+	if err == sql.ErrNoRows {
+		// Record doesn't exist, insert new one
+		if state.CreatedAt == 0 {
+			state.CreatedAt = now
+		}
+		_, err = insertStmt.ExecContext(ctx,
+			state.ChannelID,
+			state.ThreadID,
+			state.LastMessageID,
+			state.LastSeenTimestamp,
+			state.CreatedAt,
+			state.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert message state: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to check existing state: %w", err)
+	} else {
+		// Record exists, update it
+		state.CreatedAt = existingCreatedAt // Preserve original creation time
+		_, err = updateStmt.ExecContext(ctx,
+			state.LastMessageID,
+			state.LastSeenTimestamp,
+			state.UpdatedAt,
+			state.ChannelID,
+			state.ThreadID,
+			state.ThreadID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update message state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAllMessageStates retrieves all message states for recovery purposes
+func (s *sqlStorage) GetAllMessageStates(ctx context.Context) ([]*MessageState, error) {
+	stmt := s.prepared["get_all_states"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_all_states statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*MessageState
+	for rows.Next() {
+		var state MessageState
+		err := rows.Scan(
+			&state.ID,
+			&state.ChannelID,
+			&state.ThreadID,
+			&state.LastMessageID,
+			&state.LastSeenTimestamp,
+			&state.CreatedAt,
+			&state.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message state: %w", err)
+		}
+		states = append(states, &state)
+	}
+
+	return states, rows.Err()
+}
+
+// GetMessageStatesWithinWindow retrieves message states within a specific time window
+func (s *sqlStorage) GetMessageStatesWithinWindow(ctx context.Context, windowDuration time.Duration) ([]*MessageState, error) {
+	stmt := s.prepared["get_states_within_window"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_states_within_window statement not prepared")
+	}
+
+	windowStart := time.Now().Add(-windowDuration).Unix()
+	rows, err := stmt.QueryContext(ctx, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query states within window: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*MessageState
+	for rows.Next() {
+		var state MessageState
+		err := rows.Scan(
+			&state.ID,
+			&state.ChannelID,
+			&state.ThreadID,
+			&state.LastMessageID,
+			&state.LastSeenTimestamp,
+			&state.CreatedAt,
+			&state.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message state: %w", err)
+		}
+		states = append(states, &state)
+	}
+
+	return states, rows.Err()
+}
+
+// GetThreadOwnership retrieves thread ownership information for a thread
+func (s *sqlStorage) GetThreadOwnership(ctx context.Context, threadID string) (*ThreadOwnership, error) {
+	stmt := s.prepared["get_thread_ownership"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_thread_ownership statement not prepared")
+	}
+
+	row := stmt.QueryRowContext(ctx, threadID)
+
+	var ownership ThreadOwnership
+	err := row.Scan(
+		&ownership.ID,
+		&ownership.ThreadID,
+		&ownership.OriginalUserID,
+		&ownership.CreatedBy,
+		&ownership.CreationTime,
+		&ownership.CreatedAt,
+		&ownership.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // Thread ownership not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread ownership: %w", err)
+	}
+
+	return &ownership, nil
+}
+
+// UpsertThreadOwnership creates or updates thread ownership information
+func (s *sqlStorage) UpsertThreadOwnership(ctx context.Context, ownership *ThreadOwnership) error {
+	// Check if ownership exists
+	existing, err := s.GetThreadOwnership(ctx, ownership.ThreadID)
+	if err != nil {
+		return fmt.Errorf("failed to check existing thread ownership: %w", err)
+	}
+
+	now := time.Now().Unix()
+
+	if existing == nil {
+		// Insert new ownership
+		stmt := s.prepared["insert_thread_ownership"]
+		if stmt == nil {
+			return fmt.Errorf("insert_thread_ownership statement not prepared")
+		}
+
+		ownership.CreatedAt = now
+		ownership.UpdatedAt = now
+
+		_, err = stmt.ExecContext(ctx,
+			ownership.ThreadID,
+			ownership.OriginalUserID,
+			ownership.CreatedBy,
+			ownership.CreationTime,
+			ownership.CreatedAt,
+			ownership.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert thread ownership: %w", err)
+		}
+	} else {
+		// Update existing ownership
+		stmt := s.prepared["update_thread_ownership"]
+		if stmt == nil {
+			return fmt.Errorf("update_thread_ownership statement not prepared")
+		}
+
+		ownership.UpdatedAt = now
+
+		_, err = stmt.ExecContext(ctx,
+			ownership.OriginalUserID,
+			ownership.CreatedBy,
+			ownership.CreationTime,
+			ownership.UpdatedAt,
+			ownership.ThreadID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update thread ownership: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAllThreadOwnerships retrieves all thread ownership records
+func (s *sqlStorage) GetAllThreadOwnerships(ctx context.Context) ([]*ThreadOwnership, error) {
+	stmt := s.prepared["get_all_thread_ownerships"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_all_thread_ownerships statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread ownerships: %w", err)
+	}
+	defer rows.Close()
+
+	var ownerships []*ThreadOwnership
+	for rows.Next() {
+		var ownership ThreadOwnership
+		err := rows.Scan(
+			&ownership.ID,
+			&ownership.ThreadID,
+			&ownership.OriginalUserID,
+			&ownership.CreatedBy,
+			&ownership.CreationTime,
+			&ownership.CreatedAt,
+			&ownership.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thread ownership: %w", err)
+		}
+		ownerships = append(ownerships, &ownership)
+	}
+
+	return ownerships, rows.Err()
+}
+
+// CleanupOldThreadOwnerships removes old thread ownership records
+func (s *sqlStorage) CleanupOldThreadOwnerships(ctx context.Context, maxAge int64) error {
+	stmt := s.prepared["cleanup_old_thread_ownerships"]
+	if stmt == nil {
+		return fmt.Errorf("cleanup_old_thread_ownerships statement not prepared")
+	}
+
+	cutoffTime := time.Now().Unix() - maxAge
+
+	result, err := stmt.ExecContext(ctx, cutoffTime)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup old thread ownerships: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err == nil && rowsAffected > 0 {
+		// Log cleanup success, but don't fail if logging fails
+		_ = fmt.Sprintf("Cleaned up %d old thread ownership records", rowsAffected)
+	}
+
+	return nil
+}
+
+// GetConfiguration retrieves a global configuration value by key
+func (s *sqlStorage) GetConfiguration(ctx context.Context, key string) (*Configuration, error) {
+	return s.GetGuildConfiguration(ctx, "", key)
+}
+
+// GetGuildConfiguration retrieves a guild's override for a configuration key, returning nil if the guild has none
+func (s *sqlStorage) GetGuildConfiguration(ctx context.Context, guildID, key string) (*Configuration, error) {
+	stmt := s.prepared["get_configuration"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_configuration statement not prepared")
+	}
+
+	config, err := scanConfiguration(stmt.QueryRowContext(ctx, guildID, key))
+	if err == sql.ErrNoRows {
+		return nil, nil // No configuration found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// UpsertConfiguration creates or updates a configuration entry, scoped to its GuildID when set
+func (s *sqlStorage) UpsertConfiguration(ctx context.Context, config *Configuration) error {
+	checkStmt := s.prepared["check_config_exists"]
+	insertStmt := s.prepared["insert_configuration"]
+	updateStmt := s.prepared["update_configuration"]
+
+	if checkStmt == nil || insertStmt == nil || updateStmt == nil {
+		return fmt.Errorf("required configuration statements not prepared")
+	}
+
+	return upsertConfiguration(ctx, checkStmt, insertStmt, updateStmt, config)
+}
+
+// upsertConfiguration inserts or updates a configuration entry using the given statements
+func upsertConfiguration(ctx context.Context, checkStmt, insertStmt, updateStmt *sql.Stmt, config *Configuration) error {
+	now := time.Now().Unix()
+	config.UpdatedAt = now
+
+	// Check if record exists
+	var existingID int64
+	var existingCreatedAt int64
+	err := checkStmt.QueryRowContext(ctx, config.GuildID, config.Key).Scan(&existingID, &existingCreatedAt)
+
+	if err == sql.ErrNoRows {
+		// Record doesn't exist, insert new one
+		if config.CreatedAt == 0 {
+			config.CreatedAt = now
+		}
+		_, err = insertStmt.ExecContext(ctx,
+			config.GuildID,
+			config.Key,
+			config.Value,
+			config.Type,
+			config.Category,
+			config.Description,
+			config.CreatedAt,
+			config.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert configuration: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to check existing configuration: %w", err)
+	} else {
+		// Record exists, update it
+		config.CreatedAt = existingCreatedAt // Preserve original creation time
+		_, err = updateStmt.ExecContext(ctx,
+			config.Value,
+			config.Type,
+			config.Category,
+			config.Description,
+			config.UpdatedAt,
+			config.GuildID,
+			config.Key,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetConfigurationsByCategory retrieves all global configurations in a category
+func (s *sqlStorage) GetConfigurationsByCategory(ctx context.Context, category string) ([]*Configuration, error) {
+	configs, err := s.queryConfigurations(ctx, "get_configurations_by_category", category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query configurations by category: %w", err)
+	}
+	return configs, nil
+}
+
+// GetAllConfigurations retrieves all global configurations
+func (s *sqlStorage) GetAllConfigurations(ctx context.Context) ([]*Configuration, error) {
+	configs, err := s.queryConfigurations(ctx, "get_all_configurations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all configurations: %w", err)
+	}
+	return configs, nil
+}
+
+// GetGuildConfigurations retrieves all configuration overrides for a guild
+func (s *sqlStorage) GetGuildConfigurations(ctx context.Context, guildID string) ([]*Configuration, error) {
+	configs, err := s.queryConfigurations(ctx, "get_guild_configurations", guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guild configurations: %w", err)
+	}
+	return configs, nil
+}
+
+// queryConfigurations runs a prepared configurations query and scans every row
+func (s *sqlStorage) queryConfigurations(ctx context.Context, name string, args ...interface{}) ([]*Configuration, error) {
+	stmt := s.prepared[name]
+	if stmt == nil {
+		return nil, fmt.Errorf("%s statement not prepared", name)
+	}
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []*Configuration
+	for rows.Next() {
+		config, err := scanConfiguration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan configuration: %w", err)
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, rows.Err()
+}
+
+// scanConfiguration reads a configurations row
+func scanConfiguration(row rowScanner) (*Configuration, error) {
+	var config Configuration
+	if err := row.Scan(
+		&config.ID,
+		&config.GuildID,
+		&config.Key,
+		&config.Value,
+		&config.Type,
+		&config.Category,
+		&config.Description,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// DeleteConfiguration removes a global configuration entry by key
+func (s *sqlStorage) DeleteConfiguration(ctx context.Context, key string) error {
+	return s.DeleteGuildConfiguration(ctx, "", key)
+}
+
+// DeleteGuildConfiguration removes a guild's override for a configuration key
+func (s *sqlStorage) DeleteGuildConfiguration(ctx context.Context, guildID, key string) error {
+	stmt := s.prepared["delete_configuration"]
+	if stmt == nil {
+		return fmt.Errorf("delete_configuration statement not prepared")
+	}
+
+	return deleteConfiguration(ctx, stmt, guildID, key)
+}
+
+// deleteConfiguration removes a configuration entry using the given statement
+func deleteConfiguration(ctx context.Context, stmt *sql.Stmt, guildID, key string) error {
+	result, err := stmt.ExecContext(ctx, guildID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("configuration with key '%s' not found", key)
+	}
+
+	return nil
+}
+
+// RecordConfigurationChange appends an entry to the configuration history, setting its ID and timestamp
+func (s *sqlStorage) RecordConfigurationChange(ctx context.Context, change *ConfigurationHistory) error {
+	stmt := s.prepared["insert_configuration_history"]
+	if stmt == nil {
+		return fmt.Errorf("insert_configuration_history statement not prepared")
+	}
+
+	return recordConfigurationChange(ctx, stmt, change)
+}
+
+// recordConfigurationChange inserts a configuration history entry using the given statement
+func recordConfigurationChange(ctx context.Context, stmt *sql.Stmt, change *ConfigurationHistory) error {
+	if change.ChangedAt == 0 {
+		change.ChangedAt = time.Now().Unix()
+	}
+	if change.Type == "" {
+		change.Type = "string"
+	}
+
+	result, err := stmt.ExecContext(ctx,
+		change.GuildID,
+		change.Key,
+		change.OldValue,
+		change.NewValue,
+		change.Type,
+		change.Category,
+		change.Actor,
+		change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record configuration change: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration history ID: %w", err)
+	}
+	change.ID = id
+
+	return nil
+}
+
+// UpsertConfigurationWithHistory creates or updates a configuration entry and records the change in one transaction.
+// The change's OldValue is taken from the stored entry, or left nil when there was none.
+func (s *sqlStorage) UpsertConfigurationWithHistory(ctx context.Context, config *Configuration, change *ConfigurationHistory) error {
+	return s.withTx(ctx, func(stmt func(name string) (*sql.Stmt, error)) error {
+		get, err := stmt("get_configuration")
+		if err != nil {
+			return err
+		}
+		check, err := stmt("check_config_exists")
+		if err != nil {
+			return err
+		}
+		insert, err := stmt("insert_configuration")
+		if err != nil {
+			return err
+		}
+		update, err := stmt("update_configuration")
+		if err != nil {
+			return err
+		}
+		history, err := stmt("insert_configuration_history")
+		if err != nil {
+			return err
+		}
+
+		existing, err := scanConfiguration(get.QueryRowContext(ctx, config.GuildID, config.Key))
+		switch {
+		case err == sql.ErrNoRows:
+			change.OldValue = nil
+		case err != nil:
+			return fmt.Errorf("failed to get configuration: %w", err)
+		default:
+			change.OldValue = &existing.Value
+		}
+
+		if err := upsertConfiguration(ctx, check, insert, update, config); err != nil {
+			return err
+		}
+		return recordConfigurationChange(ctx, history, change)
+	})
+}
+
+// DeleteConfigurationWithHistory removes a configuration entry and records the change in one transaction.
+// The change's OldValue, Type and Category are taken from the removed entry.
+func (s *sqlStorage) DeleteConfigurationWithHistory(ctx context.Context, guildID, key string, change *ConfigurationHistory) error {
+	return s.withTx(ctx, func(stmt func(name string) (*sql.Stmt, error)) error {
+		get, err := stmt("get_configuration")
+		if err != nil {
+			return err
+		}
+		remove, err := stmt("delete_configuration")
+		if err != nil {
+			return err
+		}
+		history, err := stmt("insert_configuration_history")
+		if err != nil {
+			return err
+		}
+
+		existing, err := scanConfiguration(get.QueryRowContext(ctx, guildID, key))
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("configuration with key '%s' not found", key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get configuration: %w", err)
+		}
+		change.OldValue = &existing.Value
+		change.Type = existing.Type
+		change.Category = existing.Category
+
+		if err := deleteConfiguration(ctx, remove, guildID, key); err != nil {
+			return err
+		}
+		return recordConfigurationChange(ctx, history, change)
+	})
+}
+
+// withTx runs fn in a transaction, handing it the prepared statements bound to that transaction.
+// The transaction is committed when fn succeeds and rolled back otherwise.
+func (s *sqlStorage) withTx(ctx context.Context, fn func(stmt func(name string) (*sql.Stmt, error)) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	stmt := func(name string) (*sql.Stmt, error) {
+		prepared := s.prepared[name]
+		if prepared == nil {
+			return nil, fmt.Errorf("%s statement not prepared", name)
+		}
+		return tx.StmtContext(ctx, prepared), nil
+	}
+
+	if err := fn(stmt); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetConfigurationHistory retrieves the most recent changes to a configuration key's global value and guildID's override, newest first
+func (s *sqlStorage) GetConfigurationHistory(ctx context.Context, guildID, key string, limit int) ([]*ConfigurationHistory, error) {
+	stmt := s.prepared["get_configuration_history"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_configuration_history statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, key, guildID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query configuration history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*ConfigurationHistory
+	for rows.Next() {
+		change, err := scanConfigurationChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan configuration history: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating configuration history: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetConfigurationChange retrieves a configuration history entry by ID, returning nil if it does not exist
+func (s *sqlStorage) GetConfigurationChange(ctx context.Context, id int64) (*ConfigurationHistory, error) {
+	stmt := s.prepared["get_configuration_change"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_configuration_change statement not prepared")
+	}
+
+	change, err := scanConfigurationChange(stmt.QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil // No history entry found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration change: %w", err)
+	}
+
+	return change, nil
+}
+
+// scanConfigurationChange reads a configuration_history row
+func scanConfigurationChange(row rowScanner) (*ConfigurationHistory, error) {
+	var change ConfigurationHistory
+	if err := row.Scan(
+		&change.ID,
+		&change.GuildID,
+		&change.Key,
+		&change.OldValue,
+		&change.NewValue,
+		&change.Type,
+		&change.Category,
+		&change.Actor,
+		&change.ChangedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetStatusMessagesBatch retrieves a random batch of enabled status messages
+func (s *sqlStorage) GetStatusMessagesBatch(ctx context.Context, limit int) ([]*StatusMessage, error) {
+	stmt := s.prepared["get_status_messages_batch"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_status_messages_batch statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status messages batch: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*StatusMessage
+	for rows.Next() {
+		var msg StatusMessage
+		if err := rows.Scan(&msg.ID, &msg.ActivityType, &msg.StatusText, &msg.Enabled, &msg.CreatedAt, &msg.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// AddStatusMessage creates a new status message
+func (s *sqlStorage) AddStatusMessage(ctx context.Context, activityType, statusText string, enabled bool) error {
+	stmt := s.prepared["add_status_message"]
+	if stmt == nil {
+		return fmt.Errorf("add_status_message statement not prepared")
+	}
+
+	now := time.Now().Unix()
+	_, err := stmt.ExecContext(ctx, activityType, statusText, enabled, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to add status message: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatusMessage updates the enabled status of a status message
+func (s *sqlStorage) UpdateStatusMessage(ctx context.Context, id int64, enabled bool) error {
+	stmt := s.prepared["update_status_message"]
+	if stmt == nil {
+		return fmt.Errorf("update_status_message statement not prepared")
+	}
+
+	now := time.Now().Unix()
+	result, err := stmt.ExecContext(ctx, enabled, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to update status message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("status message with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// GetAllStatusMessages retrieves all status messages
+func (s *sqlStorage) GetAllStatusMessages(ctx context.Context) ([]*StatusMessage, error) {
+	stmt := s.prepared["get_all_status_messages"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_all_status_messages statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all status messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*StatusMessage
+	for rows.Next() {
+		var msg StatusMessage
+		if err := rows.Scan(&msg.ID, &msg.ActivityType, &msg.StatusText, &msg.Enabled, &msg.CreatedAt, &msg.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetEnabledStatusMessagesCount returns the count of enabled status messages
+func (s *sqlStorage) GetEnabledStatusMessagesCount(ctx context.Context) (int, error) {
+	stmt := s.prepared["get_enabled_status_messages_count"]
+	if stmt == nil {
+		return 0, fmt.Errorf("get_enabled_status_messages_count statement not prepared")
+	}
+
+	var count int
+	err := stmt.QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get enabled status messages count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetUserRateLimit retrieves rate limit state for a user and time window
+func (s *sqlStorage) GetUserRateLimit(ctx context.Context, userID string, timeWindow string) (*UserRateLimit, error) {
+	stmt := s.prepared["get_user_rate_limit"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_user_rate_limit statement not prepared")
+	}
+
+	var rateLimit UserRateLimit
+	err := stmt.QueryRowContext(ctx, userID, timeWindow).Scan(
+		&rateLimit.ID,
+		&rateLimit.UserID,
+		&rateLimit.TimeWindow,
+		&rateLimit.RequestCount,
+		&rateLimit.WindowStartTime,
+		&rateLimit.LastRequestTime,
+		&rateLimit.CreatedAt,
+		&rateLimit.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil // No rate limit record found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user rate limit: %w", err)
+	}
+
+	return &rateLimit, nil
+}
+
+// UpsertUserRateLimit creates or updates user rate limit state
+func (s *sqlStorage) UpsertUserRateLimit(ctx context.Context, rateLimit *UserRateLimit) error {
+	stmt := s.prepared["upsert_user_rate_limit"]
+	if stmt == nil {
+		return fmt.Errorf("upsert_user_rate_limit statement not prepared")
+	}
+
+	now := time.Now().Unix()
+	_, err := stmt.ExecContext(ctx,
+		rateLimit.UserID,
+		rateLimit.TimeWindow,
+		rateLimit.RequestCount,
+		rateLimit.WindowStartTime,
+		rateLimit.LastRequestTime,
+		now, // created_at
+		now, // updated_at
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert user rate limit: %w", err)
+	}
+
+	return nil
+}
+
+// CleanupExpiredUserRateLimits removes expired rate limit records
+func (s *sqlStorage) CleanupExpiredUserRateLimits(ctx context.Context, expiredBefore int64) error {
+	stmt := s.prepared["cleanup_expired_user_rate_limits"]
+	if stmt == nil {
+		return fmt.Errorf("cleanup_expired_user_rate_limits statement not prepared")
+	}
+
+	_, err := stmt.ExecContext(ctx, expiredBefore)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired user rate limits: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserRateLimitsByUser retrieves all rate limit records for a user
+func (s *sqlStorage) GetUserRateLimitsByUser(ctx context.Context, userID string) ([]*UserRateLimit, error) {
+	stmt := s.prepared["get_user_rate_limits_by_user"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_user_rate_limits_by_user statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user rate limits: %w", err)
+	}
+	defer rows.Close()
+
+	var rateLimits []*UserRateLimit
+	for rows.Next() {
+		var rateLimit UserRateLimit
+		err := rows.Scan(
+			&rateLimit.ID,
+			&rateLimit.UserID,
+			&rateLimit.TimeWindow,
+			&rateLimit.RequestCount,
+			&rateLimit.WindowStartTime,
+			&rateLimit.LastRequestTime,
+			&rateLimit.CreatedAt,
+			&rateLimit.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user rate limit: %w", err)
+		}
+		rateLimits = append(rateLimits, &rateLimit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rate limits: %w", err)
+	}
+
+	return rateLimits, nil
+}
+
+// ResetUserRateLimit resets rate limiting for a specific user and time window
+func (s *sqlStorage) ResetUserRateLimit(ctx context.Context, userID string, timeWindow string) error {
+	stmt := s.prepared["reset_user_rate_limit"]
+	if stmt == nil {
+		return fmt.Errorf("reset_user_rate_limit statement not prepared")
+	}
+
+	_, err := stmt.ExecContext(ctx, userID, timeWindow)
+	if err != nil {
+		return fmt.Errorf("failed to reset user rate limit: %w", err)
+	}
+
+	return nil
+}
+
+// interactionColumns lists the interactions columns in the order scanned by scanInteraction
+const interactionColumns = `i.id, i.guild_id, i.channel_id, i.thread_id, i.user_id, i.message_id, i.trigger_type,
+	i.query, i.response, i.provider, i.model, i.latency_ms, i.quality_overall, i.quality_bmad_coverage,
+	i.quality_knowledge_boundary, i.quality_content, i.error_message, i.response_message_id, i.created_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanInteraction reads an interaction selected with interactionColumns, followed by any extra columns
+func scanInteraction(row rowScanner, extra ...interface{}) (*Interaction, error) {
+	var interaction Interaction
+	dest := []interface{}{
+		&interaction.ID,
+		&interaction.GuildID,
+		&interaction.ChannelID,
+		&interaction.ThreadID,
+		&interaction.UserID,
+		&interaction.MessageID,
+		&interaction.TriggerType,
+		&interaction.Query,
+		&interaction.Response,
+		&interaction.Provider,
+		&interaction.Model,
+		&interaction.LatencyMs,
+		&interaction.QualityOverall,
+		&interaction.QualityBMADCoverage,
+		&interaction.QualityKnowledgeBoundary,
+		&interaction.QualityContent,
+		&interaction.Error,
+		&interaction.ResponseMessageID,
+		&interaction.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &interaction, nil
+}
+
+// RecordInteraction stores a Q&A exchange, setting its ID and creation timestamp
+func (s *sqlStorage) RecordInteraction(ctx context.Context, interaction *Interaction) error {
+	stmt := s.prepared["insert_interaction"]
+	if stmt == nil {
+		return fmt.Errorf("insert_interaction statement not prepared")
+	}
+
+	if interaction.CreatedAt == 0 {
+		interaction.CreatedAt = time.Now().Unix()
+	}
+
+	result, err := stmt.ExecContext(ctx,
+		interaction.GuildID,
+		interaction.ChannelID,
+		interaction.ThreadID,
+		interaction.UserID,
+		interaction.MessageID,
+		interaction.TriggerType,
+		interaction.Query,
+		interaction.Response,
+		interaction.Provider,
+		interaction.Model,
+		interaction.LatencyMs,
+		interaction.QualityOverall,
+		interaction.QualityBMADCoverage,
+		interaction.QualityKnowledgeBoundary,
+		interaction.QualityContent,
+		interaction.Error,
+		interaction.ResponseMessageID,
+		interaction.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get interaction ID: %w", err)
+	}
+	interaction.ID = id
+
+	return nil
+}
+
+// GetInteraction retrieves an interaction by ID, returning nil if it does not exist
+func (s *sqlStorage) GetInteraction(ctx context.Context, id int64) (*Interaction, error) {
+	stmt := s.prepared["get_interaction"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_interaction statement not prepared")
+	}
+
+	interaction, err := scanInteraction(stmt.QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, nil // No interaction found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interaction: %w", err)
+	}
+
+	return interaction, nil
+}
+
+// GetRecentInteractions retrieves the most recent interactions, newest first
+func (s *sqlStorage) GetRecentInteractions(ctx context.Context, limit int) ([]*Interaction, error) {
+	stmt := s.prepared["get_recent_interactions"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_recent_interactions statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var interactions []*Interaction
+	for rows.Next() {
+		interaction, err := scanInteraction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interaction: %w", err)
+		}
+		interactions = append(interactions, interaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating interactions: %w", err)
+	}
+
+	return interactions, nil
+}
+
+// PurgeInteractionsBefore deletes interactions created before the given Unix timestamp, along with their feedback,
+// and returns how many interactions were removed
+func (s *sqlStorage) PurgeInteractionsBefore(ctx context.Context, before int64) (int64, error) {
+	stmt := s.prepared["purge_interactions_before"]
+	if stmt == nil {
+		return 0, fmt.Errorf("purge_interactions_before statement not prepared")
+	}
+
+	feedbackStmt := s.prepared["purge_interaction_feedback_before"]
+	if feedbackStmt == nil {
+		return 0, fmt.Errorf("purge_interaction_feedback_before statement not prepared")
+	}
+
+	if _, err := feedbackStmt.ExecContext(ctx, before); err != nil {
+		return 0, fmt.Errorf("failed to purge interaction feedback: %w", err)
+	}
+
+	result, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge interactions: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purged interactions: %w", err)
+	}
+
+	return purged, nil
+}
+
+// GetInteractionByResponseMessageID retrieves the interaction answered by the given Discord message, returning nil if none
+func (s *sqlStorage) GetInteractionByResponseMessageID(ctx context.Context, messageID string) (*Interaction, error) {
+	stmt := s.prepared["get_interaction_by_response_message_id"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_interaction_by_response_message_id statement not prepared")
+	}
+
+	interaction, err := scanInteraction(stmt.QueryRowContext(ctx, messageID))
+	if err == sql.ErrNoRows {
+		return nil, nil // No interaction found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interaction by response message: %w", err)
+	}
+
+	return interaction, nil
+}
+
+// UpsertInteractionFeedback records a user's vote on an interaction, replacing any earlier vote by the same user
+func (s *sqlStorage) UpsertInteractionFeedback(ctx context.Context, feedback *InteractionFeedback) error {
+	stmt := s.prepared["upsert_interaction_feedback"]
+	if stmt == nil {
+		return fmt.Errorf("upsert_interaction_feedback statement not prepared")
+	}
+
+	now := time.Now().Unix()
+	if feedback.CreatedAt == 0 {
+		feedback.CreatedAt = now
+	}
+	feedback.UpdatedAt = now
+
+	_, err := stmt.ExecContext(ctx,
+		feedback.InteractionID,
+		feedback.UserID,
+		feedback.Vote,
+		feedback.CreatedAt,
+		feedback.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert interaction feedback: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteInteractionFeedback removes a user's vote on an interaction if it still matches the given vote
+func (s *sqlStorage) DeleteInteractionFeedback(ctx context.Context, interactionID int64, userID string, vote int) error {
+	stmt := s.prepared["delete_interaction_feedback"]
+	if stmt == nil {
+		return fmt.Errorf("delete_interaction_feedback statement not prepared")
+	}
+
+	if _, err := stmt.ExecContext(ctx, interactionID, userID, vote); err != nil {
+		return fmt.Errorf("failed to delete interaction feedback: %w", err)
+	}
+
+	return nil
+}
+
+// GetMostDownvotedInteractions retrieves interactions with at least one downvote, most downvoted first
+func (s *sqlStorage) GetMostDownvotedInteractions(ctx context.Context, limit int) ([]*InteractionFeedbackSummary, error) {
+	stmt := s.prepared["get_most_downvoted_interactions"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_most_downvoted_interactions statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query downvoted interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*InteractionFeedbackSummary
+	for rows.Next() {
+		summary := &InteractionFeedbackSummary{}
+		summary.Interaction, err = scanInteraction(rows, &summary.Upvotes, &summary.Downvotes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan downvoted interaction: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating downvoted interactions: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetInteractionsByUser retrieves every interaction asked by a user, newest first
+func (s *sqlStorage) GetInteractionsByUser(ctx context.Context, userID string) ([]*Interaction, error) {
+	stmt := s.prepared["get_interactions_by_user"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_interactions_by_user statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var interactions []*Interaction
+	for rows.Next() {
+		interaction, err := scanInteraction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interaction: %w", err)
+		}
+		interactions = append(interactions, interaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user interactions: %w", err)
+	}
+
+	return interactions, nil
+}
+
+// DeleteInteractionsByUser deletes a user's interactions, the votes on them and the votes the user cast,
+// and returns how many interactions were removed
+func (s *sqlStorage) DeleteInteractionsByUser(ctx context.Context, userID string) (int64, error) {
+	stmt := s.prepared["delete_interactions_by_user"]
+	if stmt == nil {
+		return 0, fmt.Errorf("delete_interactions_by_user statement not prepared")
+	}
+
+	feedbackStmt := s.prepared["delete_interaction_feedback_by_user"]
+	if feedbackStmt == nil {
+		return 0, fmt.Errorf("delete_interaction_feedback_by_user statement not prepared")
+	}
+
+	if _, err := feedbackStmt.ExecContext(ctx, userID, userID); err != nil {
+		return 0, fmt.Errorf("failed to delete user interaction feedback: %w", err)
+	}
+
+	result, err := stmt.ExecContext(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user interactions: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted interactions: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// AppendDMMessage stores a turn of a user's DM conversation, setting its ID and creation timestamp
+func (s *sqlStorage) AppendDMMessage(ctx context.Context, message *DMMessage) error {
+	stmt := s.prepared["insert_dm_message"]
+	if stmt == nil {
+		return fmt.Errorf("insert_dm_message statement not prepared")
+	}
+
+	if message.CreatedAt == 0 {
+		message.CreatedAt = time.Now().Unix()
+	}
+
+	result, err := stmt.ExecContext(ctx, message.UserID, message.Role, message.Content, message.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to append DM message: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get DM message ID: %w", err)
+	}
+	message.ID = id
+
+	return nil
+}
+
+// GetDMMessages retrieves the stored, not yet summarized turns of a user's DM conversation, oldest first
+func (s *sqlStorage) GetDMMessages(ctx context.Context, userID string) ([]*DMMessage, error) {
+	stmt := s.prepared["get_dm_messages"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_dm_messages statement not prepared")
+	}
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DM messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*DMMessage
+	for rows.Next() {
+		var message DMMessage
+		err := rows.Scan(&message.ID, &message.UserID, &message.Role, &message.Content, &message.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan DM message: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating DM messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetDMSummary retrieves the rolling summary of a user's DM conversation, returning nil if there is none
+func (s *sqlStorage) GetDMSummary(ctx context.Context, userID string) (*DMSummary, error) {
+	stmt := s.prepared["get_dm_summary"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_dm_summary statement not prepared")
+	}
+
+	var summary DMSummary
+	err := stmt.QueryRowContext(ctx, userID).Scan(
+		&summary.UserID,
+		&summary.Summary,
+		&summary.SummarizedMessages,
+		&summary.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil // No summary yet, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DM summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// CompactDMConversation stores a new rolling summary and removes the user's messages up to and including throughID.
+// The summary is written first so a failed delete leaves messages duplicated in the summary rather than lost.
+func (s *sqlStorage) CompactDMConversation(ctx context.Context, summary *DMSummary, throughID int64) error {
+	upsertStmt := s.prepared["upsert_dm_summary"]
+	if upsertStmt == nil {
+		return fmt.Errorf("upsert_dm_summary statement not prepared")
+	}
+
+	deleteStmt := s.prepared["delete_dm_messages_through"]
+	if deleteStmt == nil {
+		return fmt.Errorf("delete_dm_messages_through statement not prepared")
+	}
+
+	summary.UpdatedAt = time.Now().Unix()
+	_, err := upsertStmt.ExecContext(ctx, summary.UserID, summary.Summary, summary.SummarizedMessages, summary.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert DM summary: %w", err)
+	}
+
+	if _, err := deleteStmt.ExecContext(ctx, summary.UserID, throughID); err != nil {
+		return fmt.Errorf("failed to delete summarized DM messages: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDMConversation removes all stored messages and the summary of a user's DM conversation
+func (s *sqlStorage) DeleteDMConversation(ctx context.Context, userID string) error {
+	messagesStmt := s.prepared["delete_dm_messages"]
+	if messagesStmt == nil {
+		return fmt.Errorf("delete_dm_messages statement not prepared")
+	}
+
+	summaryStmt := s.prepared["delete_dm_summary"]
+	if summaryStmt == nil {
+		return fmt.Errorf("delete_dm_summary statement not prepared")
+	}
+
+	if _, err := messagesStmt.ExecContext(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete DM messages: %w", err)
+	}
+
+	if _, err := summaryStmt.ExecContext(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete DM summary: %w", err)
+	}
+
+	return nil
+}
+
+// GetThreadSummary retrieves the rolling summary of a thread's older messages, returning nil if there is none
+func (s *sqlStorage) GetThreadSummary(ctx context.Context, threadID string) (*ThreadSummary, error) {
+	stmt := s.prepared["get_thread_summary"]
+	if stmt == nil {
+		return nil, fmt.Errorf("get_thread_summary statement not prepared")
+	}
+
+	var summary ThreadSummary
+	err := stmt.QueryRowContext(ctx, threadID).Scan(
+		&summary.ThreadID,
+		&summary.Summary,
+		&summary.SummarizedThroughID,
+		&summary.SummarizedMessages,
+		&summary.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil // No summary yet, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// UpsertThreadSummary creates or replaces the rolling summary of a thread's older messages
+func (s *sqlStorage) UpsertThreadSummary(ctx context.Context, summary *ThreadSummary) error {
+	stmt := s.prepared["upsert_thread_summary"]
+	if stmt == nil {
+		return fmt.Errorf("upsert_thread_summary statement not prepared")
+	}
+
+	summary.UpdatedAt = time.Now().Unix()
+	_, err := stmt.ExecContext(ctx, summary.ThreadID, summary.Summary, summary.SummarizedThroughID, summary.SummarizedMessages, summary.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert thread summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// SQLiteStorageService implements StorageService using an embedded SQLite database file
+type SQLiteStorageService struct {
+	sqlStorage
+	path string
+}
+
+// NewSQLiteStorageService creates a new SQLite storage service backed by the database file at path
+func NewSQLiteStorageService(path string) *SQLiteStorageService {
+	return &SQLiteStorageService{
+		sqlStorage: sqlStorage{prepared: make(map[string]*sql.Stmt)},
+		path:       path,
+	}
+}
+
+// Initialize opens the database file, creating it and its directory if needed, and applies pending schema migrations
+func (s *SQLiteStorageService) Initialize(ctx context.Context) error {
+	if err := s.open(ctx); err != nil {
+		return err
+	}
+
+	migrator, err := s.Migrator(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	if err := s.prepareStatements(sqliteStatements()); err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return nil
+}
+
+// open connects to the database file, creating it and its directory if needed
+func (s *SQLiteStorageService) open(ctx context.Context) error {
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// WAL lets readers proceed while a write is in progress; the busy timeout waits out the remaining lock contention
+	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on", s.path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	s.db = db
+
+	// SQLite allows a single writer, so serialize access through one connection
+	s.db.SetMaxOpenConns(1)
+
+	return nil
+}
+
+// HealthCheck verifies that the database connection is working
+func (s *SQLiteStorageService) HealthCheck(ctx context.Context) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+
+	// Test query to ensure tables exist
+	if _, err := s.db.ExecContext(ctx, "SELECT COUNT(*) FROM message_states LIMIT 1"); err != nil {
+		return fmt.Errorf("database health check query failed: %w", err)
+	}
+
+	// Test configurations table
+	if _, err := s.db.ExecContext(ctx, "SELECT COUNT(*) FROM configurations LIMIT 1"); err != nil {
+		return fmt.Errorf("configurations table health check failed: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteStatements returns the SQLite equivalents of the MySQL-specific statements
+func sqliteStatements() map[string]string {
+	return map[string]string{
+		"get_status_messages_batch": `
+			SELECT id, activity_type, status_text, enabled, created_at, updated_at
+			FROM bot_status_messages
+			WHERE enabled = TRUE
+			ORDER BY RANDOM()
+			LIMIT ?
+		`,
+		"upsert_user_rate_limit": `
+			INSERT INTO user_rate_limits (user_id, time_window, request_count, window_start_time, last_request_time, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (user_id, time_window) DO UPDATE SET
+			request_count = excluded.request_count,
+			window_start_time = excluded.window_start_time,
+			last_request_time = excluded.last_request_time,
+			updated_at = excluded.updated_at
+		`,
+		"purge_interaction_feedback_before": `
+			DELETE FROM interaction_feedback
+			WHERE interaction_id IN (SELECT id FROM interactions WHERE created_at < ?)
+		`,
+		"upsert_interaction_feedback": `
+			INSERT INTO interaction_feedback (interaction_id, user_id, vote, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (interaction_id, user_id) DO UPDATE SET
+			vote = excluded.vote,
+			updated_at = excluded.updated_at
+		`,
+		"upsert_dm_summary": `
+			INSERT INTO dm_summaries (user_id, summary, summarized_messages, updated_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET
+			summary = excluded.summary,
+			summarized_messages = excluded.summarized_messages,
+			updated_at = excluded.updated_at
+		`,
+		"upsert_thread_summary": `
+			INSERT INTO thread_summaries (thread_id, summary, summarized_through_id, summarized_messages, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (thread_id) DO UPDATE SET
+			summary = excluded.summary,
+			summarized_through_id = excluded.summarized_through_id,
+			summarized_messages = excluded.summarized_messages,
+			updated_at = excluded.updated_at
+		`,
+	}
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// sqliteMigrationLocker serializes migrations by running them inside one write transaction
+type sqliteMigrationLocker struct{}
+
+// Lock starts an immediate transaction, taking the database write lock up front
+func (sqliteMigrationLocker) Lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+	return err
+}
+
+// Unlock commits the migration run, or rolls every statement back if it failed
+func (sqliteMigrationLocker) Unlock(ctx context.Context, conn *sql.Conn, success bool) error {
+	if !success {
+		_, err := conn.ExecContext(ctx, "ROLLBACK")
+		return err
+	}
+	_, err := conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+// Migrator returns the schema migrator for this database, opening the file first if needed
+func (s *SQLiteStorageService) Migrator(ctx context.Context) (*Migrator, error) {
+	if s.db == nil {
+		if err := s.open(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return newMigrator(s.db, sqliteMigrations(), sqliteMigrationLocker{})
+}
+
+// sqliteMigrations returns the SQLite schema history; versions must match mysqlMigrations
+func sqliteMigrations() []Migration {
+	return []Migration{
+		{
+			// Reproduces the schema previously built by createTables so existing databases adopt it in place
+			Version:     1,
+			Description: "initial schema",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS message_states (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					channel_id TEXT NOT NULL,
+					thread_id TEXT NULL,
+					last_message_id TEXT NOT NULL,
+					last_seen_timestamp INTEGER NOT NULL,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL,
+					UNIQUE (channel_id, thread_id)
+				)`,
+				`CREATE TABLE IF NOT EXISTS thread_ownerships (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					thread_id TEXT NOT NULL UNIQUE,
+					original_user_id TEXT NOT NULL,
+					created_by TEXT NOT NULL,
+					creation_time INTEGER NOT NULL,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS configurations (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					config_key TEXT NOT NULL UNIQUE,
+					config_value TEXT NOT NULL,
+					value_type TEXT DEFAULT 'string' CHECK (value_type IN ('string', 'int', 'bool', 'duration')),
+					category TEXT NOT NULL,
+					description TEXT,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS bot_status_messages (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					activity_type TEXT NOT NULL,
+					status_text TEXT NOT NULL,
+					enabled BOOLEAN NOT NULL DEFAULT TRUE,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS user_rate_limits (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id TEXT NOT NULL,
+					time_window TEXT NOT NULL,
+					request_count INTEGER NOT NULL DEFAULT 0,
+					window_start_time INTEGER NOT NULL,
+					last_request_time INTEGER NOT NULL,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL,
+					UNIQUE (user_id, time_window)
+				)`,
+				`CREATE TABLE IF NOT EXISTS interactions (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					guild_id TEXT NOT NULL DEFAULT '',
+					channel_id TEXT NOT NULL,
+					thread_id TEXT NULL,
+					user_id TEXT NOT NULL,
+					message_id TEXT NOT NULL,
+					trigger_type TEXT NOT NULL,
+					query TEXT NOT NULL,
+					response TEXT NOT NULL,
+					provider TEXT NOT NULL,
+					model TEXT NOT NULL,
+					latency_ms INTEGER NOT NULL,
+					quality_overall REAL NULL,
+					quality_bmad_coverage REAL NULL,
+					quality_knowledge_boundary REAL NULL,
+					quality_content REAL NULL,
+					error_message TEXT NOT NULL,
+					created_at INTEGER NOT NULL,
+					response_message_id TEXT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS interaction_feedback (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					interaction_id INTEGER NOT NULL,
+					user_id TEXT NOT NULL,
+					vote INTEGER NOT NULL,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL,
+					UNIQUE (interaction_id, user_id)
+				)`,
+				`CREATE INDEX IF NOT EXISTS idx_message_states_channel_thread ON message_states(channel_id, thread_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_message_states_timestamp ON message_states(last_seen_timestamp)`,
+				`CREATE INDEX IF NOT EXISTS idx_thread_ownerships_thread_id ON thread_ownerships(thread_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_thread_ownerships_creation_time ON thread_ownerships(creation_time)`,
+				`CREATE INDEX IF NOT EXISTS idx_configurations_category ON configurations(category)`,
+				`CREATE INDEX IF NOT EXISTS idx_configurations_key_category ON configurations(config_key, category)`,
+				`CREATE INDEX IF NOT EXISTS idx_enabled ON bot_status_messages(enabled)`,
+				`CREATE INDEX IF NOT EXISTS idx_activity_type ON bot_status_messages(activity_type)`,
+				`CREATE INDEX IF NOT EXISTS idx_user_id ON user_rate_limits(user_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_window_start_time ON user_rate_limits(window_start_time)`,
+				`CREATE INDEX IF NOT EXISTS idx_interactions_created_at ON interactions(created_at)`,
+				`CREATE INDEX IF NOT EXISTS idx_interactions_user_id ON interactions(user_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_interactions_message_id ON interactions(message_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_interactions_response_message_id ON interactions(response_message_id)`,
+			},
+			Down: []string{
+				`DROP TABLE IF EXISTS interaction_feedback`,
+				`DROP TABLE IF EXISTS interactions`,
+				`DROP TABLE IF EXISTS user_rate_limits`,
+				`DROP TABLE IF EXISTS bot_status_messages`,
+				`DROP TABLE IF EXISTS configurations`,
+				`DROP TABLE IF EXISTS thread_ownerships`,
+				`DROP TABLE IF EXISTS message_states`,
+			},
+		},
+		{
+			Version:     2,
+			Description: "configuration history",
+			Up: []string{
+				`CREATE TABLE configuration_history (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					config_key TEXT NOT NULL,
+					old_value TEXT NULL,
+					new_value TEXT NULL,
+					value_type TEXT NOT NULL DEFAULT 'string',
+					category TEXT NOT NULL DEFAULT '',
+					actor TEXT NOT NULL,
+					changed_at INTEGER NOT NULL
+				)`,
+				`CREATE INDEX idx_configuration_history_key ON configuration_history(config_key, changed_at)`,
+			},
+			Down: []string{
+				`DROP TABLE configuration_history`,
+			},
+		},
+		{
+			Version:     3,
+			Description: "guild-scoped configuration",
+			// SQLite cannot drop the config_key UNIQUE constraint in place, so the table is rebuilt
+			Up: []string{
+				`CREATE TABLE configurations_scoped (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					guild_id TEXT NOT NULL DEFAULT '',
+					config_key TEXT NOT NULL,
+					config_value TEXT NOT NULL,
+					value_type TEXT DEFAULT 'string' CHECK (value_type IN ('string', 'int', 'bool', 'duration')),
+					category TEXT NOT NULL,
+					description TEXT,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL,
+					UNIQUE (guild_id, config_key)
+				)`,
+				`INSERT INTO configurations_scoped (id, config_key, config_value, value_type, category, description, created_at, updated_at)
+					SELECT id, config_key, config_value, value_type, category, description, created_at, updated_at FROM configurations`,
+				`DROP TABLE configurations`,
+				`ALTER TABLE configurations_scoped RENAME TO configurations`,
+				`CREATE INDEX idx_configurations_category ON configurations(category)`,
+				`CREATE INDEX idx_configurations_key_category ON configurations(config_key, category)`,
+				`ALTER TABLE configuration_history ADD COLUMN guild_id TEXT NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE configuration_history DROP COLUMN guild_id`,
+				`CREATE TABLE configurations_global (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					config_key TEXT NOT NULL UNIQUE,
+					config_value TEXT NOT NULL,
+					value_type TEXT DEFAULT 'string' CHECK (value_type IN ('string', 'int', 'bool', 'duration')),
+					category TEXT NOT NULL,
+					description TEXT,
+					created_at INTEGER NOT NULL,
+					updated_at INTEGER NOT NULL
+				)`,
+				`INSERT INTO configurations_global (id, config_key, config_value, value_type, category, description, created_at, updated_at)
+					SELECT id, config_key, config_value, value_type, category, description, created_at, updated_at FROM configurations
+					WHERE guild_id = ''`,
+				`DROP TABLE configurations`,
+				`ALTER TABLE configurations_global RENAME TO configurations`,
+				`CREATE INDEX idx_configurations_category ON configurations(category)`,
+				`CREATE INDEX idx_configurations_key_category ON configurations(config_key, category)`,
+			},
+		},
+		{
+			Version:     4,
+			Description: "dm conversation memory",
+			Up: []string{
+				`CREATE TABLE dm_messages (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id TEXT NOT NULL,
+					role TEXT NOT NULL,
+					content TEXT NOT NULL,
+					created_at INTEGER NOT NULL
+				)`,
+				`CREATE INDEX idx_dm_messages_user ON dm_messages(user_id, id)`,
+				`CREATE TABLE dm_summaries (
+					user_id TEXT PRIMARY KEY,
+					summary TEXT NOT NULL,
+					summarized_messages INTEGER NOT NULL DEFAULT 0,
+					updated_at INTEGER NOT NULL
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE dm_summaries`,
+				`DROP TABLE dm_messages`,
+			},
+		},
+		{
+			Version:     5,
+			Description: "thread conversation summaries",
+			Up: []string{
+				`CREATE TABLE thread_summaries (
+					thread_id TEXT PRIMARY KEY,
+					summary TEXT NOT NULL,
+					summarized_through_id TEXT NOT NULL,
+					summarized_messages INTEGER NOT NULL DEFAULT 0,
+					updated_at INTEGER NOT NULL
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE thread_summaries`,
+			},
+		},
+	}
+}
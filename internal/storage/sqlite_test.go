@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorageService_Conformance(t *testing.T) {
+	runStorageConformanceTests(t, func(t *testing.T) StorageService {
+		return setupTestSQLiteStorage(t)
+	})
+}
+
+func TestSQLiteStorageService_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data", "bot.db")
+
+	service := NewSQLiteStorageService(path)
+	require.NoError(t, service.Initialize(ctx))
+
+	state := &MessageState{
+		ChannelID:         "channel123",
+		LastMessageID:     "msg123",
+		LastSeenTimestamp: time.Now().Unix(),
+	}
+	require.NoError(t, service.UpsertMessageState(ctx, state))
+	require.NoError(t, service.Close())
+
+	// Reopening an existing database must reuse its schema and data
+	reopened := NewSQLiteStorageService(path)
+	require.NoError(t, reopened.Initialize(ctx))
+	defer reopened.Close()
+
+	retrieved, err := reopened.GetMessageState(ctx, "channel123", nil)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, "msg123", retrieved.LastMessageID)
+}
+
+func setupTestSQLiteStorage(t *testing.T) *SQLiteStorageService {
+	service := NewSQLiteStorageService(filepath.Join(t.TempDir(), "test.db"))
+	if err := service.Initialize(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize SQLite storage: %v", err)
+	}
+	return service
+}
@@ -0,0 +1,1503 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runStorageConformanceTests runs the behaviour every StorageService backend must share.
+// newStorage returns a freshly initialized service backed by an empty database.
+func runStorageConformanceTests(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, newStorage func(t *testing.T) StorageService)
+	}{
+		{"Initialize", testStorageInitialize},
+		{"UpsertMessageState", testStorageUpsertMessageState},
+		{"UpdateExistingState", testStorageUpdateExistingState},
+		{"GetMessageState_NotFound", testStorageGetMessageStateNotFound},
+		{"GetAllMessageStates", testStorageGetAllMessageStates},
+		{"GetMessageStatesWithinWindow", testStorageGetMessageStatesWithinWindow},
+		{"UniqueConstraint", testStorageUniqueConstraint},
+		{"ThreadOwnership", testStorageThreadOwnership},
+		{"ThreadOwnership_Update", testStorageThreadOwnershipUpdate},
+		{"GetAllThreadOwnerships", testStorageGetAllThreadOwnerships},
+		{"CleanupOldThreadOwnerships", testStorageCleanupOldThreadOwnerships},
+		{"HealthCheck", testStorageHealthCheck},
+		{"ContextTimeout", testStorageContextTimeout},
+		{"Configuration", testStorageConfiguration},
+		{"ConfigurationHistory", testStorageConfigurationHistory},
+		{"GuildConfiguration", testStorageGuildConfiguration},
+		{"StatusMessages", testStorageStatusMessages},
+		{"UserRateLimit", testStorageUserRateLimit},
+		{"UserRateLimit_EdgeCases", testStorageUserRateLimitEdgeCases},
+		{"Interactions", testStorageInteractions},
+		{"InteractionFeedback", testStorageInteractionFeedback},
+		{"InteractionsByUser", testStorageInteractionsByUser},
+		{"DMConversation", testStorageDMConversation},
+		{"ThreadSummary", testStorageThreadSummary},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, newStorage)
+		})
+	}
+}
+
+func testStorageInitialize(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Test health check works after initialization
+	err := service.HealthCheck(ctx)
+	assert.NoError(t, err)
+}
+
+func testStorageUpsertMessageState(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		state    *MessageState
+		expected *MessageState
+	}{
+		{
+			name: "insert new channel state",
+			state: &MessageState{
+				ChannelID:         "channel123",
+				ThreadID:          nil,
+				LastMessageID:     "msg123",
+				LastSeenTimestamp: time.Now().Unix(),
+			},
+			expected: &MessageState{
+				ChannelID:         "channel123",
+				ThreadID:          nil,
+				LastMessageID:     "msg123",
+				LastSeenTimestamp: time.Now().Unix(),
+			},
+		},
+		{
+			name: "insert new thread state",
+			state: &MessageState{
+				ChannelID:         "channel456",
+				ThreadID:          stringPtr("thread789"),
+				LastMessageID:     "msg456",
+				LastSeenTimestamp: time.Now().Unix(),
+			},
+			expected: &MessageState{
+				ChannelID:         "channel456",
+				ThreadID:          stringPtr("thread789"),
+				LastMessageID:     "msg456",
+				LastSeenTimestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Insert the state
+			err := service.UpsertMessageState(ctx, tc.state)
+			require.NoError(t, err)
+
+			// Verify the state was inserted correctly
+			retrieved, err := service.GetMessageState(ctx, tc.state.ChannelID, tc.state.ThreadID)
+			require.NoError(t, err)
+			assert.NotNil(t, retrieved)
+			assert.Equal(t, tc.expected.ChannelID, retrieved.ChannelID)
+			assert.Equal(t, tc.expected.ThreadID, retrieved.ThreadID)
+			assert.Equal(t, tc.expected.LastMessageID, retrieved.LastMessageID)
+			assert.Equal(t, tc.expected.LastSeenTimestamp, retrieved.LastSeenTimestamp)
+			assert.Greater(t, retrieved.ID, int64(0))
+			assert.Greater(t, retrieved.CreatedAt, int64(0))
+			assert.Greater(t, retrieved.UpdatedAt, int64(0))
+		})
+	}
+}
+
+func testStorageUpdateExistingState(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	channelID := "channel123"
+	var threadID *string = nil
+
+	// Insert initial state
+	initialState := &MessageState{
+		ChannelID:         channelID,
+		ThreadID:          threadID,
+		LastMessageID:     "msg123",
+		LastSeenTimestamp: time.Now().Unix() - 100,
+	}
+	err := service.UpsertMessageState(ctx, initialState)
+	require.NoError(t, err)
+
+	// Sleep briefly to ensure different timestamp
+	time.Sleep(10 * time.Millisecond)
+
+	// Update the state
+	updatedState := &MessageState{
+		ChannelID:         channelID,
+		ThreadID:          threadID,
+		LastMessageID:     "msg456",
+		LastSeenTimestamp: time.Now().Unix(),
+	}
+	err = service.UpsertMessageState(ctx, updatedState)
+	require.NoError(t, err)
+
+	// Verify the state was updated
+	retrieved, err := service.GetMessageState(ctx, channelID, threadID)
+	require.NoError(t, err)
+	assert.NotNil(t, retrieved)
+	assert.Equal(t, "msg456", retrieved.LastMessageID)
+	assert.Equal(t, updatedState.LastSeenTimestamp, retrieved.LastSeenTimestamp)
+	assert.GreaterOrEqual(t, retrieved.UpdatedAt, retrieved.CreatedAt)
+}
+
+func testStorageGetMessageStateNotFound(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Try to get a non-existent state
+	state, err := service.GetMessageState(ctx, "nonexistent", nil)
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func testStorageGetAllMessageStates(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Insert multiple states
+	states := []*MessageState{
+		{
+			ChannelID:         "channel1",
+			ThreadID:          nil,
+			LastMessageID:     "msg1",
+			LastSeenTimestamp: time.Now().Unix() - 300,
+		},
+		{
+			ChannelID:         "channel2",
+			ThreadID:          stringPtr("thread1"),
+			LastMessageID:     "msg2",
+			LastSeenTimestamp: time.Now().Unix() - 200,
+		},
+		{
+			ChannelID:         "channel3",
+			ThreadID:          nil,
+			LastMessageID:     "msg3",
+			LastSeenTimestamp: time.Now().Unix() - 100,
+		},
+	}
+
+	for _, state := range states {
+		err := service.UpsertMessageState(ctx, state)
+		require.NoError(t, err)
+	}
+
+	// Retrieve all states
+	allStates, err := service.GetAllMessageStates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, allStates, 3)
+
+	// Verify states are ordered by timestamp (newest first)
+	assert.True(t, allStates[0].LastSeenTimestamp >= allStates[1].LastSeenTimestamp)
+	assert.True(t, allStates[1].LastSeenTimestamp >= allStates[2].LastSeenTimestamp)
+}
+
+func testStorageGetMessageStatesWithinWindow(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	now := time.Now().Unix()
+
+	// Insert states with different timestamps
+	states := []*MessageState{
+		{
+			ChannelID:         "recent",
+			LastMessageID:     "msg1",
+			LastSeenTimestamp: now - 60, // 1 minute ago (within 5-minute window)
+		},
+		{
+			ChannelID:         "old",
+			LastMessageID:     "msg2",
+			LastSeenTimestamp: now - 600, // 10 minutes ago (outside 5-minute window)
+		},
+		{
+			ChannelID:         "very_recent",
+			LastMessageID:     "msg3",
+			LastSeenTimestamp: now - 30, // 30 seconds ago (within window)
+		},
+	}
+
+	for _, state := range states {
+		err := service.UpsertMessageState(ctx, state)
+		require.NoError(t, err)
+	}
+
+	// Get states within 5-minute window
+	windowDuration := 5 * time.Minute
+	recentStates, err := service.GetMessageStatesWithinWindow(ctx, windowDuration)
+	require.NoError(t, err)
+
+	// Should only get the recent ones
+	assert.Len(t, recentStates, 2)
+
+	channelIDs := make([]string, len(recentStates))
+	for i, state := range recentStates {
+		channelIDs[i] = state.ChannelID
+	}
+	assert.Contains(t, channelIDs, "recent")
+	assert.Contains(t, channelIDs, "very_recent")
+	assert.NotContains(t, channelIDs, "old")
+}
+
+func testStorageUniqueConstraint(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Insert initial state
+	state1 := &MessageState{
+		ChannelID:         "channel123",
+		ThreadID:          nil,
+		LastMessageID:     "msg1",
+		LastSeenTimestamp: time.Now().Unix(),
+	}
+	err := service.UpsertMessageState(ctx, state1)
+	require.NoError(t, err)
+
+	// Insert state with same channel/thread combination should update, not create duplicate
+	state2 := &MessageState{
+		ChannelID:         "channel123",
+		ThreadID:          nil,
+		LastMessageID:     "msg2",
+		LastSeenTimestamp: time.Now().Unix(),
+	}
+	err = service.UpsertMessageState(ctx, state2)
+	require.NoError(t, err)
+
+	// Verify only one record exists
+	allStates, err := service.GetAllMessageStates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, allStates, 1)
+	assert.Equal(t, "msg2", allStates[0].LastMessageID)
+}
+
+func testStorageThreadOwnership(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Test inserting new thread ownership
+	ownership := &ThreadOwnership{
+		ThreadID:       "thread123",
+		OriginalUserID: "user456",
+		CreatedBy:      "bot789",
+		CreationTime:   time.Now().Unix(),
+	}
+
+	err := service.UpsertThreadOwnership(ctx, ownership)
+	require.NoError(t, err)
+
+	// Retrieve the ownership
+	retrieved, err := service.GetThreadOwnership(ctx, "thread123")
+	require.NoError(t, err)
+	assert.NotNil(t, retrieved)
+	assert.Equal(t, "thread123", retrieved.ThreadID)
+	assert.Equal(t, "user456", retrieved.OriginalUserID)
+	assert.Equal(t, "bot789", retrieved.CreatedBy)
+	assert.Equal(t, ownership.CreationTime, retrieved.CreationTime)
+	assert.Greater(t, retrieved.ID, int64(0))
+	assert.Greater(t, retrieved.CreatedAt, int64(0))
+	assert.Greater(t, retrieved.UpdatedAt, int64(0))
+}
+
+func testStorageThreadOwnershipUpdate(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	threadID := "thread123"
+
+	// Insert initial ownership
+	initialOwnership := &ThreadOwnership{
+		ThreadID:       threadID,
+		OriginalUserID: "user456",
+		CreatedBy:      "bot789",
+		CreationTime:   time.Now().Unix() - 100,
+	}
+	err := service.UpsertThreadOwnership(ctx, initialOwnership)
+	require.NoError(t, err)
+
+	// Update the ownership
+	updatedOwnership := &ThreadOwnership{
+		ThreadID:       threadID,
+		OriginalUserID: "user999",
+		CreatedBy:      "bot888",
+		CreationTime:   time.Now().Unix(),
+	}
+	err = service.UpsertThreadOwnership(ctx, updatedOwnership)
+	require.NoError(t, err)
+
+	// Verify the ownership was updated
+	retrieved, err := service.GetThreadOwnership(ctx, threadID)
+	require.NoError(t, err)
+	assert.NotNil(t, retrieved)
+	assert.Equal(t, "user999", retrieved.OriginalUserID)
+	assert.Equal(t, "bot888", retrieved.CreatedBy)
+	assert.Equal(t, updatedOwnership.CreationTime, retrieved.CreationTime)
+}
+
+func testStorageGetAllThreadOwnerships(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Insert multiple ownerships
+	ownerships := []*ThreadOwnership{
+		{
+			ThreadID:       "thread1",
+			OriginalUserID: "user1",
+			CreatedBy:      "bot1",
+			CreationTime:   time.Now().Unix() - 300,
+		},
+		{
+			ThreadID:       "thread2",
+			OriginalUserID: "user2",
+			CreatedBy:      "bot2",
+			CreationTime:   time.Now().Unix() - 200,
+		},
+		{
+			ThreadID:       "thread3",
+			OriginalUserID: "user3",
+			CreatedBy:      "bot3",
+			CreationTime:   time.Now().Unix() - 100,
+		},
+	}
+
+	for _, ownership := range ownerships {
+		err := service.UpsertThreadOwnership(ctx, ownership)
+		require.NoError(t, err)
+	}
+
+	// Retrieve all ownerships
+	allOwnerships, err := service.GetAllThreadOwnerships(ctx)
+	require.NoError(t, err)
+	assert.Len(t, allOwnerships, 3)
+
+	// Verify ownerships are ordered by creation time (newest first)
+	assert.True(t, allOwnerships[0].CreationTime >= allOwnerships[1].CreationTime)
+	assert.True(t, allOwnerships[1].CreationTime >= allOwnerships[2].CreationTime)
+}
+
+func testStorageCleanupOldThreadOwnerships(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	now := time.Now().Unix()
+
+	// Insert ownerships with different ages
+	ownerships := []*ThreadOwnership{
+		{
+			ThreadID:       "recent_thread",
+			OriginalUserID: "user1",
+			CreatedBy:      "bot1",
+			CreationTime:   now - 300, // 5 minutes ago (should be kept)
+		},
+		{
+			ThreadID:       "old_thread",
+			OriginalUserID: "user2",
+			CreatedBy:      "bot2",
+			CreationTime:   now - 7200, // 2 hours ago (should be cleaned up)
+		},
+	}
+
+	for _, ownership := range ownerships {
+		err := service.UpsertThreadOwnership(ctx, ownership)
+		require.NoError(t, err)
+	}
+
+	// Cleanup ownerships older than 1 hour (3600 seconds)
+	maxAge := int64(3600)
+	err := service.CleanupOldThreadOwnerships(ctx, maxAge)
+	require.NoError(t, err)
+
+	// Verify only recent ownership remains
+	allOwnerships, err := service.GetAllThreadOwnerships(ctx)
+	require.NoError(t, err)
+	assert.Len(t, allOwnerships, 1)
+	assert.Equal(t, "recent_thread", allOwnerships[0].ThreadID)
+}
+
+func testStorageHealthCheck(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	// Health check should pass when database is working
+	err := service.HealthCheck(ctx)
+	assert.NoError(t, err)
+
+	// Health check should fail when database is closed
+	service.Close()
+	err = service.HealthCheck(ctx)
+	assert.Error(t, err)
+}
+
+func testStorageContextTimeout(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+
+	// Create context with very short timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
+	defer cancel()
+
+	// Wait for context to timeout
+	time.Sleep(1 * time.Millisecond)
+
+	// Operations should respect context timeout
+	state := &MessageState{
+		ChannelID:         "test",
+		LastMessageID:     "msg",
+		LastSeenTimestamp: time.Now().Unix(),
+	}
+
+	err := service.UpsertMessageState(ctx, state)
+	assert.Error(t, err)
+}
+
+func testStorageConfiguration(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("GetConfiguration_NotFound", func(t *testing.T) {
+		config, err := service.GetConfiguration(ctx, "nonexistent_key")
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("UpsertConfiguration_Insert", func(t *testing.T) {
+		config := &Configuration{
+			Key:         "test_key",
+			Value:       "test_value",
+			Type:        "string",
+			Category:    "test",
+			Description: "Test configuration",
+		}
+
+		err := service.UpsertConfiguration(ctx, config)
+		assert.NoError(t, err)
+
+		// Verify it was inserted
+		retrieved, err := service.GetConfiguration(ctx, "test_key")
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, "test_key", retrieved.Key)
+		assert.Equal(t, "test_value", retrieved.Value)
+		assert.Equal(t, "string", retrieved.Type)
+		assert.Equal(t, "test", retrieved.Category)
+		assert.Equal(t, "Test configuration", retrieved.Description)
+	})
+
+	t.Run("UpsertConfiguration_Update", func(t *testing.T) {
+		// Insert initial config
+		config := &Configuration{
+			Key:         "update_key",
+			Value:       "initial_value",
+			Type:        "string",
+			Category:    "test",
+			Description: "Initial description",
+		}
+		err := service.UpsertConfiguration(ctx, config)
+		assert.NoError(t, err)
+
+		// Update the config
+		config.Value = "updated_value"
+		config.Description = "Updated description"
+		err = service.UpsertConfiguration(ctx, config)
+		assert.NoError(t, err)
+
+		// Verify it was updated
+		retrieved, err := service.GetConfiguration(ctx, "update_key")
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, "updated_value", retrieved.Value)
+		assert.Equal(t, "Updated description", retrieved.Description)
+	})
+
+	t.Run("GetConfigurationsByCategory", func(t *testing.T) {
+		// Insert multiple configs in same category
+		configs := []*Configuration{
+			{Key: "cat1_key1", Value: "value1", Type: "string", Category: "category1", Description: "Config 1"},
+			{Key: "cat1_key2", Value: "value2", Type: "string", Category: "category1", Description: "Config 2"},
+			{Key: "cat2_key1", Value: "value3", Type: "string", Category: "category2", Description: "Config 3"},
+		}
+
+		for _, config := range configs {
+			err := service.UpsertConfiguration(ctx, config)
+			assert.NoError(t, err)
+		}
+
+		// Get configs by category
+		category1Configs, err := service.GetConfigurationsByCategory(ctx, "category1")
+		assert.NoError(t, err)
+		assert.Len(t, category1Configs, 2)
+
+		category2Configs, err := service.GetConfigurationsByCategory(ctx, "category2")
+		assert.NoError(t, err)
+		assert.Len(t, category2Configs, 1)
+	})
+
+	t.Run("GetAllConfigurations", func(t *testing.T) {
+		// Insert a few configs
+		configs := []*Configuration{
+			{Key: "all1", Value: "value1", Type: "string", Category: "all", Description: "All Config 1"},
+			{Key: "all2", Value: "value2", Type: "int", Category: "all", Description: "All Config 2"},
+		}
+
+		for _, config := range configs {
+			err := service.UpsertConfiguration(ctx, config)
+			assert.NoError(t, err)
+		}
+
+		// Get all configs
+		allConfigs, err := service.GetAllConfigurations(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, len(allConfigs), 2)
+	})
+
+	t.Run("DeleteConfiguration", func(t *testing.T) {
+		// Insert config to delete
+		config := &Configuration{
+			Key:         "delete_key",
+			Value:       "delete_value",
+			Type:        "string",
+			Category:    "delete",
+			Description: "To be deleted",
+		}
+		err := service.UpsertConfiguration(ctx, config)
+		assert.NoError(t, err)
+
+		// Delete the config
+		err = service.DeleteConfiguration(ctx, "delete_key")
+		assert.NoError(t, err)
+
+		// Verify it was deleted
+		retrieved, err := service.GetConfiguration(ctx, "delete_key")
+		assert.NoError(t, err)
+		assert.Nil(t, retrieved)
+
+		// Try to delete non-existent config
+		err = service.DeleteConfiguration(ctx, "nonexistent")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
+
+func testStorageGuildConfiguration(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	require.NoError(t, service.UpsertConfiguration(ctx, &Configuration{Key: "REACTION_TRIGGER_EMOJI", Value: "❓", Type: "string", Category: "features"}))
+	require.NoError(t, service.UpsertConfiguration(ctx, &Configuration{GuildID: "guild-a", Key: "REACTION_TRIGGER_EMOJI", Value: "🤖", Type: "string", Category: "features"}))
+	require.NoError(t, service.UpsertConfiguration(ctx, &Configuration{GuildID: "guild-a", Key: "ALLOWED_CHANNEL_IDS", Value: "123", Type: "string", Category: "channel_restrictions"}))
+
+	t.Run("OverridesAreScoped", func(t *testing.T) {
+		global, err := service.GetConfiguration(ctx, "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		require.NotNil(t, global)
+		assert.Equal(t, "❓", global.Value)
+		assert.Empty(t, global.GuildID)
+
+		override, err := service.GetGuildConfiguration(ctx, "guild-a", "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		require.NotNil(t, override)
+		assert.Equal(t, "🤖", override.Value)
+		assert.Equal(t, "guild-a", override.GuildID)
+
+		missing, err := service.GetGuildConfiguration(ctx, "guild-b", "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("GlobalListingsExcludeOverrides", func(t *testing.T) {
+		all, err := service.GetAllConfigurations(ctx)
+		require.NoError(t, err)
+		for _, config := range all {
+			assert.Empty(t, config.GuildID)
+			assert.NotEqual(t, "ALLOWED_CHANNEL_IDS", config.Key)
+		}
+
+		byCategory, err := service.GetConfigurationsByCategory(ctx, "features")
+		require.NoError(t, err)
+		require.Len(t, byCategory, 1)
+		assert.Equal(t, "❓", byCategory[0].Value)
+
+		overrides, err := service.GetGuildConfigurations(ctx, "guild-a")
+		require.NoError(t, err)
+		require.Len(t, overrides, 2)
+		assert.Equal(t, "ALLOWED_CHANNEL_IDS", overrides[0].Key)
+		assert.Equal(t, "REACTION_TRIGGER_EMOJI", overrides[1].Key)
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		resolved, err := ResolveConfiguration(ctx, service, "guild-a", "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		assert.Equal(t, "🤖", resolved.Value)
+
+		resolved, err = ResolveConfiguration(ctx, service, "guild-b", "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		assert.Equal(t, "❓", resolved.Value, "guilds without an override use the global value")
+
+		resolved, err = ResolveConfiguration(ctx, service, "", "ALLOWED_CHANNEL_IDS")
+		require.NoError(t, err)
+		assert.Nil(t, resolved)
+	})
+
+	t.Run("DeleteGuildConfiguration", func(t *testing.T) {
+		require.NoError(t, service.DeleteGuildConfiguration(ctx, "guild-a", "REACTION_TRIGGER_EMOJI"))
+
+		override, err := service.GetGuildConfiguration(ctx, "guild-a", "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		assert.Nil(t, override)
+
+		global, err := service.GetConfiguration(ctx, "REACTION_TRIGGER_EMOJI")
+		require.NoError(t, err)
+		require.NotNil(t, global, "deleting an override keeps the global value")
+
+		err = service.DeleteGuildConfiguration(ctx, "guild-a", "REACTION_TRIGGER_EMOJI")
+		assert.Error(t, err)
+	})
+
+	t.Run("HistoryRecordsGuild", func(t *testing.T) {
+		change := &ConfigurationHistory{GuildID: "guild-a", Key: "ALLOWED_CHANNEL_IDS", NewValue: stringPtr("123"), Actor: "123456789"}
+		require.NoError(t, service.RecordConfigurationChange(ctx, change))
+
+		stored, err := service.GetConfigurationChange(ctx, change.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "guild-a", stored.GuildID)
+	})
+}
+
+func testStorageConfigurationHistory(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("GetConfigurationChange_NotFound", func(t *testing.T) {
+		change, err := service.GetConfigurationChange(ctx, 999999)
+		assert.NoError(t, err)
+		assert.Nil(t, change)
+	})
+
+	t.Run("RecordAndList", func(t *testing.T) {
+		now := time.Now().Unix()
+		changes := []*ConfigurationHistory{
+			{Key: "history_key", NewValue: stringPtr("1"), Type: "int", Category: "test", Actor: "seed", ChangedAt: now - 20},
+			{Key: "history_key", OldValue: stringPtr("1"), NewValue: stringPtr("2"), Type: "int", Category: "test", Actor: "123456789", ChangedAt: now - 10},
+			{Key: "history_key", OldValue: stringPtr("2"), Type: "int", Category: "test", Actor: "123456789", ChangedAt: now},
+			{Key: "other_key", NewValue: stringPtr("x"), Category: "test", Actor: "env-migration"},
+		}
+		for _, change := range changes {
+			require.NoError(t, service.RecordConfigurationChange(ctx, change))
+			assert.NotZero(t, change.ID)
+		}
+		assert.NotZero(t, changes[3].ChangedAt, "ChangedAt defaults to now")
+
+		history, err := service.GetConfigurationHistory(ctx, "", "history_key", 10)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+
+		// Newest first; the latest change is a deletion
+		assert.Equal(t, changes[2].ID, history[0].ID)
+		assert.Equal(t, "2", *history[0].OldValue)
+		assert.Nil(t, history[0].NewValue)
+		assert.Equal(t, changes[0].ID, history[2].ID)
+		assert.Nil(t, history[2].OldValue)
+		assert.Equal(t, "1", *history[2].NewValue)
+		assert.Equal(t, "seed", history[2].Actor)
+		assert.Equal(t, "int", history[2].Type)
+
+		limited, err := service.GetConfigurationHistory(ctx, "", "history_key", 2)
+		require.NoError(t, err)
+		assert.Len(t, limited, 2)
+
+		change, err := service.GetConfigurationChange(ctx, changes[1].ID)
+		require.NoError(t, err)
+		require.NotNil(t, change)
+		assert.Equal(t, "history_key", change.Key)
+		assert.Equal(t, "1", *change.OldValue)
+		assert.Equal(t, "2", *change.NewValue)
+		assert.Equal(t, "123456789", change.Actor)
+		assert.Equal(t, "test", change.Category)
+		assert.Equal(t, now-10, change.ChangedAt)
+
+		other, err := service.GetConfigurationChange(ctx, changes[3].ID)
+		require.NoError(t, err)
+		require.NotNil(t, other)
+		assert.Equal(t, "string", other.Type, "value type defaults to string")
+	})
+
+	t.Run("GetConfigurationHistory_Empty", func(t *testing.T) {
+		history, err := service.GetConfigurationHistory(ctx, "", "never_changed", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("UpsertAndDeleteWithHistory", func(t *testing.T) {
+		config := &Configuration{GuildID: "guild-a", Key: "atomic_key", Value: "1", Type: "int", Category: "test"}
+		created := &ConfigurationHistory{GuildID: "guild-a", Key: "atomic_key", NewValue: stringPtr("1"), Type: "int", Category: "test", Actor: "seed"}
+		require.NoError(t, service.UpsertConfigurationWithHistory(ctx, config, created))
+		assert.NotZero(t, created.ID)
+		assert.Nil(t, created.OldValue)
+
+		config = &Configuration{GuildID: "guild-a", Key: "atomic_key", Value: "2", Type: "int", Category: "test"}
+		updated := &ConfigurationHistory{GuildID: "guild-a", Key: "atomic_key", NewValue: stringPtr("2"), Type: "int", Category: "test", Actor: "123456789"}
+		require.NoError(t, service.UpsertConfigurationWithHistory(ctx, config, updated))
+		require.NotNil(t, updated.OldValue)
+		assert.Equal(t, "1", *updated.OldValue, "old value comes from the stored entry")
+
+		stored, err := service.GetGuildConfiguration(ctx, "guild-a", "atomic_key")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "2", stored.Value)
+
+		deleted := &ConfigurationHistory{GuildID: "guild-a", Key: "atomic_key", Actor: "123456789"}
+		require.NoError(t, service.DeleteConfigurationWithHistory(ctx, "guild-a", "atomic_key", deleted))
+		require.NotNil(t, deleted.OldValue)
+		assert.Equal(t, "2", *deleted.OldValue)
+		assert.Equal(t, "int", deleted.Type)
+		assert.Equal(t, "test", deleted.Category)
+
+		stored, err = service.GetGuildConfiguration(ctx, "guild-a", "atomic_key")
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+
+		history, err := service.GetConfigurationHistory(ctx, "guild-a", "atomic_key", 10)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, deleted.ID, history[0].ID)
+		assert.Equal(t, "2", *history[0].OldValue)
+		assert.Nil(t, history[0].NewValue)
+
+		// Deleting a missing entry fails without recording history
+		err = service.DeleteConfigurationWithHistory(ctx, "guild-a", "atomic_key", &ConfigurationHistory{GuildID: "guild-a", Key: "atomic_key"})
+		assert.Error(t, err)
+		history, err = service.GetConfigurationHistory(ctx, "guild-a", "atomic_key", 10)
+		require.NoError(t, err)
+		assert.Len(t, history, 3)
+	})
+
+	t.Run("GetConfigurationHistory_GuildScope", func(t *testing.T) {
+		changes := []*ConfigurationHistory{
+			{Key: "scoped_key", NewValue: stringPtr("global"), Actor: "seed"},
+			{GuildID: "guild-a", Key: "scoped_key", NewValue: stringPtr("a"), Actor: "123456789"},
+			{GuildID: "guild-b", Key: "scoped_key", NewValue: stringPtr("b"), Actor: "987654321"},
+		}
+		for _, change := range changes {
+			require.NoError(t, service.RecordConfigurationChange(ctx, change))
+		}
+
+		// A guild sees global changes and its own overrides, never another guild's
+		history, err := service.GetConfigurationHistory(ctx, "guild-a", "scoped_key", 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		for _, change := range history {
+			assert.Contains(t, []string{"", "guild-a"}, change.GuildID)
+		}
+
+		history, err = service.GetConfigurationHistory(ctx, "", "scoped_key", 10)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "global", *history[0].NewValue)
+	})
+}
+
+func testStorageStatusMessages(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("AddStatusMessage", func(t *testing.T) {
+		err := service.AddStatusMessage(ctx, "playing", "Test Game", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetAllStatusMessages", func(t *testing.T) {
+		// Add a few messages
+		err := service.AddStatusMessage(ctx, "listening", "Test Music", true)
+		assert.NoError(t, err)
+		err = service.AddStatusMessage(ctx, "watching", "Test Video", false)
+		assert.NoError(t, err)
+
+		messages, err := service.GetAllStatusMessages(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, len(messages), 2)
+	})
+
+	t.Run("GetStatusMessagesBatch", func(t *testing.T) {
+		// Add enabled message
+		err := service.AddStatusMessage(ctx, "competing", "Test Competition", true)
+		assert.NoError(t, err)
+
+		messages, err := service.GetStatusMessagesBatch(ctx, 5)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, len(messages), 1)
+
+		// All returned messages should be enabled
+		for _, msg := range messages {
+			assert.True(t, msg.Enabled)
+		}
+	})
+
+	t.Run("GetEnabledStatusMessagesCount", func(t *testing.T) {
+		count, err := service.GetEnabledStatusMessagesCount(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, count, 0)
+	})
+
+	t.Run("UpdateStatusMessage", func(t *testing.T) {
+		// Add a message first
+		err := service.AddStatusMessage(ctx, "playing", "Update Test", true)
+		assert.NoError(t, err)
+
+		// Get all messages to find the ID
+		messages, err := service.GetAllStatusMessages(ctx)
+		assert.NoError(t, err)
+		require.Greater(t, len(messages), 0)
+
+		// Find our test message
+		var testMessage *StatusMessage
+		for _, msg := range messages {
+			if msg.StatusText == "Update Test" {
+				testMessage = msg
+				break
+			}
+		}
+		require.NotNil(t, testMessage)
+
+		// Update it to disabled
+		err = service.UpdateStatusMessage(ctx, testMessage.ID, false)
+		assert.NoError(t, err)
+
+		// Verify the update
+		messages, err = service.GetAllStatusMessages(ctx)
+		assert.NoError(t, err)
+
+		var updatedMessage *StatusMessage
+		for _, msg := range messages {
+			if msg.ID == testMessage.ID {
+				updatedMessage = msg
+				break
+			}
+		}
+		require.NotNil(t, updatedMessage)
+		assert.False(t, updatedMessage.Enabled)
+	})
+}
+
+func testStorageUserRateLimit(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	userID := "user123"
+	timeWindow := "minute"
+
+	t.Run("GetUserRateLimit_NotFound", func(t *testing.T) {
+		rateLimit, err := service.GetUserRateLimit(ctx, userID, timeWindow)
+		assert.NoError(t, err)
+		assert.Nil(t, rateLimit)
+	})
+
+	t.Run("UpsertUserRateLimit_Insert", func(t *testing.T) {
+		now := time.Now()
+		rateLimit := &UserRateLimit{
+			UserID:          userID,
+			TimeWindow:      timeWindow,
+			RequestCount:    1,
+			WindowStartTime: now.Unix(),
+			LastRequestTime: now.Unix(),
+		}
+
+		err := service.UpsertUserRateLimit(ctx, rateLimit)
+		assert.NoError(t, err)
+
+		// Verify it was inserted
+		retrieved, err := service.GetUserRateLimit(ctx, userID, timeWindow)
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, userID, retrieved.UserID)
+		assert.Equal(t, timeWindow, retrieved.TimeWindow)
+		assert.Equal(t, 1, retrieved.RequestCount)
+	})
+
+	t.Run("UpsertUserRateLimit_Update", func(t *testing.T) {
+		now := time.Now()
+		rateLimit := &UserRateLimit{
+			UserID:          userID,
+			TimeWindow:      timeWindow,
+			RequestCount:    5,
+			WindowStartTime: now.Unix(),
+			LastRequestTime: now.Unix(),
+		}
+
+		err := service.UpsertUserRateLimit(ctx, rateLimit)
+		assert.NoError(t, err)
+
+		// Verify it was updated
+		retrieved, err := service.GetUserRateLimit(ctx, userID, timeWindow)
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, 5, retrieved.RequestCount)
+	})
+
+	t.Run("GetUserRateLimitsByUser", func(t *testing.T) {
+		// Insert additional time windows
+		hourLimit := &UserRateLimit{
+			UserID:          userID,
+			TimeWindow:      "hour",
+			RequestCount:    10,
+			WindowStartTime: time.Now().Unix(),
+			LastRequestTime: time.Now().Unix(),
+		}
+		err := service.UpsertUserRateLimit(ctx, hourLimit)
+		assert.NoError(t, err)
+
+		dayLimit := &UserRateLimit{
+			UserID:          userID,
+			TimeWindow:      "day",
+			RequestCount:    25,
+			WindowStartTime: time.Now().Unix(),
+			LastRequestTime: time.Now().Unix(),
+		}
+		err = service.UpsertUserRateLimit(ctx, dayLimit)
+		assert.NoError(t, err)
+
+		// Get all rate limits for user
+		rateLimits, err := service.GetUserRateLimitsByUser(ctx, userID)
+		assert.NoError(t, err)
+		assert.Len(t, rateLimits, 3) // minute, hour, and day
+
+		// Verify all are present
+		timeWindows := make(map[string]int)
+		for _, rl := range rateLimits {
+			timeWindows[rl.TimeWindow] = rl.RequestCount
+		}
+		assert.Equal(t, 5, timeWindows["minute"])
+		assert.Equal(t, 10, timeWindows["hour"])
+		assert.Equal(t, 25, timeWindows["day"])
+	})
+
+	t.Run("ResetUserRateLimit_Specific", func(t *testing.T) {
+		err := service.ResetUserRateLimit(ctx, userID, "minute")
+		assert.NoError(t, err)
+
+		// Verify minute was deleted but others remain
+		minuteLimit, err := service.GetUserRateLimit(ctx, userID, "minute")
+		assert.NoError(t, err)
+		assert.Nil(t, minuteLimit)
+
+		hourLimit, err := service.GetUserRateLimit(ctx, userID, "hour")
+		assert.NoError(t, err)
+		assert.NotNil(t, hourLimit)
+		assert.Equal(t, 10, hourLimit.RequestCount)
+	})
+
+	t.Run("CleanupExpiredUserRateLimits", func(t *testing.T) {
+		// Insert old rate limit
+		oldTime := time.Now().Add(-24 * time.Hour)
+		oldRateLimit := &UserRateLimit{
+			UserID:          "old_user",
+			TimeWindow:      "minute",
+			RequestCount:    1,
+			WindowStartTime: oldTime.Unix(),
+			LastRequestTime: oldTime.Unix(),
+		}
+		err := service.UpsertUserRateLimit(ctx, oldRateLimit)
+		assert.NoError(t, err)
+
+		// Insert recent rate limit
+		recentRateLimit := &UserRateLimit{
+			UserID:          "recent_user",
+			TimeWindow:      "minute",
+			RequestCount:    1,
+			WindowStartTime: time.Now().Unix(),
+			LastRequestTime: time.Now().Unix(),
+		}
+		err = service.UpsertUserRateLimit(ctx, recentRateLimit)
+		assert.NoError(t, err)
+
+		// Cleanup old records (older than 1 hour ago)
+		expiredBefore := time.Now().Add(-1 * time.Hour).Unix()
+		err = service.CleanupExpiredUserRateLimits(ctx, expiredBefore)
+		assert.NoError(t, err)
+
+		// Verify old record was deleted
+		oldRetrieved, err := service.GetUserRateLimit(ctx, "old_user", "minute")
+		assert.NoError(t, err)
+		assert.Nil(t, oldRetrieved)
+
+		// Verify recent record still exists
+		recentRetrieved, err := service.GetUserRateLimit(ctx, "recent_user", "minute")
+		assert.NoError(t, err)
+		assert.NotNil(t, recentRetrieved)
+	})
+
+	t.Run("GetUserRateLimitsByUser_EmptyResult", func(t *testing.T) {
+		rateLimits, err := service.GetUserRateLimitsByUser(ctx, "nonexistent_user")
+		assert.NoError(t, err)
+		assert.Empty(t, rateLimits)
+	})
+}
+
+func testStorageUserRateLimitEdgeCases(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("UpsertUserRateLimit_ZeroValues", func(t *testing.T) {
+		rateLimit := &UserRateLimit{
+			UserID:          "zero_user",
+			TimeWindow:      "minute",
+			RequestCount:    0,
+			WindowStartTime: 0,
+			LastRequestTime: 0,
+		}
+
+		err := service.UpsertUserRateLimit(ctx, rateLimit)
+		assert.NoError(t, err)
+
+		retrieved, err := service.GetUserRateLimit(ctx, "zero_user", "minute")
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, 0, retrieved.RequestCount)
+		assert.Equal(t, int64(0), retrieved.WindowStartTime)
+	})
+
+	t.Run("ResetUserRateLimit_Nonexistent", func(t *testing.T) {
+		err := service.ResetUserRateLimit(ctx, "nonexistent_user", "minute")
+		assert.NoError(t, err) // Should not error even if nothing to delete
+	})
+
+	t.Run("UpsertUserRateLimit_LongUserID", func(t *testing.T) {
+		longUserID := strings.Repeat("x", 100) // Test with very long user ID
+		rateLimit := &UserRateLimit{
+			UserID:          longUserID,
+			TimeWindow:      "hour",
+			RequestCount:    50,
+			WindowStartTime: time.Now().Unix(),
+			LastRequestTime: time.Now().Unix(),
+		}
+
+		err := service.UpsertUserRateLimit(ctx, rateLimit)
+		assert.NoError(t, err)
+
+		retrieved, err := service.GetUserRateLimit(ctx, longUserID, "hour")
+		assert.NoError(t, err)
+		assert.NotNil(t, retrieved)
+		assert.Equal(t, longUserID, retrieved.UserID)
+		assert.Equal(t, 50, retrieved.RequestCount)
+	})
+
+	t.Run("MultipleTimeWindows_SameUser", func(t *testing.T) {
+		userID := "multi_window_user"
+		windows := []string{"minute", "hour", "day"}
+
+		// Insert rate limits for all time windows
+		for i, window := range windows {
+			rateLimit := &UserRateLimit{
+				UserID:          userID,
+				TimeWindow:      window,
+				RequestCount:    (i + 1) * 10,
+				WindowStartTime: time.Now().Unix(),
+				LastRequestTime: time.Now().Unix(),
+			}
+			err := service.UpsertUserRateLimit(ctx, rateLimit)
+			assert.NoError(t, err)
+		}
+
+		// Verify all were inserted
+		for i, window := range windows {
+			retrieved, err := service.GetUserRateLimit(ctx, userID, window)
+			assert.NoError(t, err)
+			assert.NotNil(t, retrieved)
+			assert.Equal(t, (i+1)*10, retrieved.RequestCount)
+		}
+
+		// Get all for user
+		allLimits, err := service.GetUserRateLimitsByUser(ctx, userID)
+		assert.NoError(t, err)
+		assert.Len(t, allLimits, 3)
+	})
+}
+
+func testStorageInteractions(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("GetInteraction_NotFound", func(t *testing.T) {
+		interaction, err := service.GetInteraction(ctx, 12345)
+		assert.NoError(t, err)
+		assert.Nil(t, interaction)
+	})
+
+	t.Run("RecordInteraction", func(t *testing.T) {
+		overall := 0.85
+		interaction := &Interaction{
+			GuildID:        "guild123",
+			ChannelID:      "channel123",
+			ThreadID:       stringPtr("thread123"),
+			UserID:         "user123",
+			MessageID:      "message123",
+			TriggerType:    "mention",
+			Query:          "What is BMAD?",
+			Response:       "BMAD is an agile AI-driven development method.",
+			Provider:       "ollama",
+			Model:          "devstral",
+			LatencyMs:      1250,
+			QualityOverall: &overall,
+		}
+
+		err := service.RecordInteraction(ctx, interaction)
+		require.NoError(t, err)
+		assert.NotZero(t, interaction.ID)
+		assert.NotZero(t, interaction.CreatedAt)
+
+		retrieved, err := service.GetInteraction(ctx, interaction.ID)
+		require.NoError(t, err)
+		require.NotNil(t, retrieved)
+		assert.Equal(t, "thread123", *retrieved.ThreadID)
+		assert.Equal(t, interaction.Response, retrieved.Response)
+		assert.Equal(t, int64(1250), retrieved.LatencyMs)
+		assert.InDelta(t, overall, *retrieved.QualityOverall, 0.0001)
+		assert.Nil(t, retrieved.QualityContent)
+	})
+
+	t.Run("RecordInteraction_Error", func(t *testing.T) {
+		interaction := &Interaction{
+			ChannelID:   "dm123",
+			UserID:      "user456",
+			MessageID:   "message456",
+			TriggerType: "dm",
+			Query:       "Hello?",
+			Provider:    "openai",
+			Error:       "provider unavailable",
+		}
+
+		require.NoError(t, service.RecordInteraction(ctx, interaction))
+
+		recent, err := service.GetRecentInteractions(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, recent, 2)
+		assert.Equal(t, "message456", recent[0].MessageID)
+		assert.Nil(t, recent[0].ThreadID)
+		assert.Equal(t, "provider unavailable", recent[0].Error)
+	})
+
+	t.Run("PurgeInteractionsBefore", func(t *testing.T) {
+		purged, err := service.PurgeInteractionsBefore(ctx, time.Now().Add(-time.Hour).Unix())
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = service.PurgeInteractionsBefore(ctx, time.Now().Add(time.Hour).Unix())
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+
+		recent, err := service.GetRecentInteractions(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, recent)
+	})
+}
+
+func testStorageInteractionFeedback(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	record := func(messageID string, responseMessageID string) *Interaction {
+		interaction := &Interaction{
+			ChannelID:         "channel123",
+			UserID:            "user123",
+			MessageID:         messageID,
+			TriggerType:       "mention",
+			Query:             "What is BMAD?",
+			Response:          "BMAD is a method.",
+			Provider:          "ollama",
+			ResponseMessageID: stringPtr(responseMessageID),
+		}
+		require.NoError(t, service.RecordInteraction(ctx, interaction))
+		return interaction
+	}
+
+	first := record("question1", "answer1")
+	second := record("question2", "answer2")
+	record("question3", "answer3")
+
+	t.Run("GetInteractionByResponseMessageID", func(t *testing.T) {
+		interaction, err := service.GetInteractionByResponseMessageID(ctx, "answer2")
+		require.NoError(t, err)
+		require.NotNil(t, interaction)
+		assert.Equal(t, second.ID, interaction.ID)
+
+		interaction, err = service.GetInteractionByResponseMessageID(ctx, "unknown")
+		assert.NoError(t, err)
+		assert.Nil(t, interaction)
+	})
+
+	t.Run("UpsertInteractionFeedback", func(t *testing.T) {
+		votes := []*InteractionFeedback{
+			{InteractionID: first.ID, UserID: "voter1", Vote: FeedbackDownvote},
+			{InteractionID: first.ID, UserID: "voter2", Vote: FeedbackUpvote},
+			{InteractionID: second.ID, UserID: "voter1", Vote: FeedbackDownvote},
+			{InteractionID: second.ID, UserID: "voter2", Vote: FeedbackDownvote},
+		}
+		for _, vote := range votes {
+			require.NoError(t, service.UpsertInteractionFeedback(ctx, vote))
+		}
+
+		// Changing a vote replaces it
+		require.NoError(t, service.UpsertInteractionFeedback(ctx, &InteractionFeedback{InteractionID: first.ID, UserID: "voter2", Vote: FeedbackDownvote}))
+	})
+
+	t.Run("DeleteInteractionFeedback", func(t *testing.T) {
+		require.NoError(t, service.UpsertInteractionFeedback(ctx, &InteractionFeedback{InteractionID: first.ID, UserID: "voter3", Vote: FeedbackDownvote}))
+
+		// Withdrawing a vote the user no longer holds leaves the current vote alone
+		require.NoError(t, service.DeleteInteractionFeedback(ctx, first.ID, "voter3", FeedbackUpvote))
+		summaries, err := service.GetMostDownvotedInteractions(ctx, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, summaries)
+		assert.Equal(t, first.ID, summaries[0].Interaction.ID)
+		assert.Equal(t, 3, summaries[0].Downvotes)
+
+		require.NoError(t, service.DeleteInteractionFeedback(ctx, first.ID, "voter3", FeedbackDownvote))
+	})
+
+	t.Run("GetMostDownvotedInteractions", func(t *testing.T) {
+		summaries, err := service.GetMostDownvotedInteractions(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, summaries, 2)
+		for _, summary := range summaries {
+			assert.Equal(t, 2, summary.Downvotes)
+			assert.Equal(t, 0, summary.Upvotes)
+		}
+
+		summaries, err = service.GetMostDownvotedInteractions(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, summaries, 1)
+	})
+
+	t.Run("PurgeRemovesFeedback", func(t *testing.T) {
+		purged, err := service.PurgeInteractionsBefore(ctx, time.Now().Add(time.Hour).Unix())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+
+		summaries, err := service.GetMostDownvotedInteractions(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, summaries)
+	})
+}
+
+func testStorageInteractionsByUser(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	record := func(userID string, messageID string) *Interaction {
+		interaction := &Interaction{ChannelID: "channel123", UserID: userID, MessageID: messageID, TriggerType: "dm", Query: "What is BMAD?"}
+		require.NoError(t, service.RecordInteraction(ctx, interaction))
+		return interaction
+	}
+
+	first := record("user-a", "question1")
+	second := record("user-a", "question2")
+	other := record("user-b", "question3")
+
+	require.NoError(t, service.UpsertInteractionFeedback(ctx, &InteractionFeedback{InteractionID: first.ID, UserID: "user-b", Vote: FeedbackUpvote}))
+	require.NoError(t, service.UpsertInteractionFeedback(ctx, &InteractionFeedback{InteractionID: other.ID, UserID: "user-a", Vote: FeedbackDownvote}))
+	require.NoError(t, service.UpsertInteractionFeedback(ctx, &InteractionFeedback{InteractionID: other.ID, UserID: "user-c", Vote: FeedbackDownvote}))
+
+	t.Run("GetInteractionsByUser", func(t *testing.T) {
+		interactions, err := service.GetInteractionsByUser(ctx, "user-a")
+		require.NoError(t, err)
+		require.Len(t, interactions, 2)
+		assert.Equal(t, second.ID, interactions[0].ID)
+		assert.Equal(t, first.ID, interactions[1].ID)
+
+		interactions, err = service.GetInteractionsByUser(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, interactions)
+	})
+
+	t.Run("DeleteInteractionsByUser", func(t *testing.T) {
+		deleted, err := service.DeleteInteractionsByUser(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		interactions, err := service.GetInteractionsByUser(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Empty(t, interactions)
+
+		// Only user-c's vote on user-b's question remains
+		summaries, err := service.GetMostDownvotedInteractions(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, other.ID, summaries[0].Interaction.ID)
+		assert.Equal(t, 1, summaries[0].Downvotes)
+	})
+}
+
+// Helper function to create string pointer
+func stringPtr(s string) *string {
+	return &s
+}
+
+func testStorageDMConversation(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	var ids []int64
+	for i, content := range []string{"What is BMAD?", "A method.", "Who are the agents?", "Analyst, PM, Architect."} {
+		role := DMRoleUser
+		if i%2 == 1 {
+			role = DMRoleAssistant
+		}
+		message := &DMMessage{UserID: "user-a", Role: role, Content: content}
+		require.NoError(t, service.AppendDMMessage(ctx, message))
+		assert.NotZero(t, message.ID)
+		assert.NotZero(t, message.CreatedAt)
+		ids = append(ids, message.ID)
+	}
+	require.NoError(t, service.AppendDMMessage(ctx, &DMMessage{UserID: "user-b", Role: DMRoleUser, Content: "Hello"}))
+
+	t.Run("GetDMMessages", func(t *testing.T) {
+		messages, err := service.GetDMMessages(ctx, "user-a")
+		require.NoError(t, err)
+		require.Len(t, messages, 4)
+		assert.Equal(t, "What is BMAD?", messages[0].Content)
+		assert.Equal(t, DMRoleUser, messages[0].Role)
+		assert.Equal(t, "Analyst, PM, Architect.", messages[3].Content)
+		assert.Equal(t, DMRoleAssistant, messages[3].Role)
+
+		messages, err = service.GetDMMessages(ctx, "user-c")
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("GetDMSummary_NotFound", func(t *testing.T) {
+		summary, err := service.GetDMSummary(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Nil(t, summary)
+	})
+
+	t.Run("CompactDMConversation", func(t *testing.T) {
+		summary := &DMSummary{UserID: "user-a", Summary: "User asked what BMAD is.", SummarizedMessages: 2}
+		require.NoError(t, service.CompactDMConversation(ctx, summary, ids[1]))
+
+		messages, err := service.GetDMMessages(ctx, "user-a")
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "Who are the agents?", messages[0].Content)
+
+		stored, err := service.GetDMSummary(ctx, "user-a")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "User asked what BMAD is.", stored.Summary)
+		assert.Equal(t, 2, stored.SummarizedMessages)
+		assert.NotZero(t, stored.UpdatedAt)
+
+		// Compacting again replaces the summary
+		summary = &DMSummary{UserID: "user-a", Summary: "User asked about BMAD and its agents.", SummarizedMessages: 4}
+		require.NoError(t, service.CompactDMConversation(ctx, summary, ids[3]))
+
+		stored, err = service.GetDMSummary(ctx, "user-a")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "User asked about BMAD and its agents.", stored.Summary)
+		assert.Equal(t, 4, stored.SummarizedMessages)
+
+		messages, err = service.GetDMMessages(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("DeleteDMConversation", func(t *testing.T) {
+		require.NoError(t, service.AppendDMMessage(ctx, &DMMessage{UserID: "user-a", Role: DMRoleUser, Content: "Thanks"}))
+		require.NoError(t, service.DeleteDMConversation(ctx, "user-a"))
+
+		messages, err := service.GetDMMessages(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+
+		summary, err := service.GetDMSummary(ctx, "user-a")
+		require.NoError(t, err)
+		assert.Nil(t, summary)
+
+		other, err := service.GetDMMessages(ctx, "user-b")
+		require.NoError(t, err)
+		assert.Len(t, other, 1, "deleting one user's conversation keeps other users' data")
+
+		// Deleting an empty conversation is not an error
+		assert.NoError(t, service.DeleteDMConversation(ctx, "user-a"))
+	})
+}
+
+func testStorageThreadSummary(t *testing.T, newStorage func(t *testing.T) StorageService) {
+	service := newStorage(t)
+	defer service.Close()
+	ctx := context.Background()
+
+	t.Run("GetThreadSummary_NotFound", func(t *testing.T) {
+		summary, err := service.GetThreadSummary(ctx, "thread-a")
+		require.NoError(t, err)
+		assert.Nil(t, summary)
+	})
+
+	t.Run("UpsertThreadSummary", func(t *testing.T) {
+		summary := &ThreadSummary{ThreadID: "thread-a", Summary: "User asked what BMAD is.", SummarizedThroughID: "1001", SummarizedMessages: 2}
+		require.NoError(t, service.UpsertThreadSummary(ctx, summary))
+		assert.NotZero(t, summary.UpdatedAt)
+
+		stored, err := service.GetThreadSummary(ctx, "thread-a")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "User asked what BMAD is.", stored.Summary)
+		assert.Equal(t, "1001", stored.SummarizedThroughID)
+		assert.Equal(t, 2, stored.SummarizedMessages)
+
+		// Summarizing more of the thread replaces the summary
+		summary = &ThreadSummary{ThreadID: "thread-a", Summary: "User asked about BMAD and its agents.", SummarizedThroughID: "1003", SummarizedMessages: 4}
+		require.NoError(t, service.UpsertThreadSummary(ctx, summary))
+
+		stored, err = service.GetThreadSummary(ctx, "thread-a")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "User asked about BMAD and its agents.", stored.Summary)
+		assert.Equal(t, "1003", stored.SummarizedThroughID)
+		assert.Equal(t, 4, stored.SummarizedMessages)
+
+		other, err := service.GetThreadSummary(ctx, "thread-b")
+		require.NoError(t, err)
+		assert.Nil(t, other)
+	})
+}